@@ -52,6 +52,9 @@ const (
 	ArrowPayloadType_RESOURCE_ATTRS ArrowPayloadType = 1
 	// A payload representing a collection of scope attributes.
 	ArrowPayloadType_SCOPE_ATTRS ArrowPayloadType = 2
+	// An optional payload representing the distinct instrumentation scopes of
+	// a batch, referenced by ID from the main payload.
+	ArrowPayloadType_SCOPES ArrowPayloadType = 3
 	// A set of payloads representing a collection of metrics.
 	ArrowPayloadType_METRICS                         ArrowPayloadType = 10 // Main metric payload
 	ArrowPayloadType_NUMBER_DATA_POINTS              ArrowPayloadType = 11
@@ -68,6 +71,9 @@ const (
 	ArrowPayloadType_NUMBER_DP_EXEMPLAR_ATTRS        ArrowPayloadType = 22
 	ArrowPayloadType_HISTOGRAM_DP_EXEMPLAR_ATTRS     ArrowPayloadType = 23
 	ArrowPayloadType_EXP_HISTOGRAM_DP_EXEMPLAR_ATTRS ArrowPayloadType = 24
+	// An optional payload representing the metadata of the metrics, referenced
+	// by the metric ID.
+	ArrowPayloadType_METRIC_METADATA ArrowPayloadType = 25
 	// A set of payloads representing a collection of logs.
 	ArrowPayloadType_LOGS      ArrowPayloadType = 30
 	ArrowPayloadType_LOG_ATTRS ArrowPayloadType = 31
@@ -78,6 +84,9 @@ const (
 	ArrowPayloadType_SPAN_LINKS       ArrowPayloadType = 43
 	ArrowPayloadType_SPAN_EVENT_ATTRS ArrowPayloadType = 44
 	ArrowPayloadType_SPAN_LINK_ATTRS  ArrowPayloadType = 45
+	// An optional payload indexing the rows of the main spans or logs
+	// payload of the batch by trace ID.
+	ArrowPayloadType_TRACE_ID_INDEX ArrowPayloadType = 50
 )
 
 // Enum value maps for ArrowPayloadType.
@@ -86,6 +95,7 @@ var (
 		0:  "UNKNOWN",
 		1:  "RESOURCE_ATTRS",
 		2:  "SCOPE_ATTRS",
+		3:  "SCOPES",
 		10: "METRICS",
 		11: "NUMBER_DATA_POINTS",
 		12: "SUMMARY_DATA_POINTS",
@@ -101,6 +111,7 @@ var (
 		22: "NUMBER_DP_EXEMPLAR_ATTRS",
 		23: "HISTOGRAM_DP_EXEMPLAR_ATTRS",
 		24: "EXP_HISTOGRAM_DP_EXEMPLAR_ATTRS",
+		25: "METRIC_METADATA",
 		30: "LOGS",
 		31: "LOG_ATTRS",
 		40: "SPANS",
@@ -109,11 +120,13 @@ var (
 		43: "SPAN_LINKS",
 		44: "SPAN_EVENT_ATTRS",
 		45: "SPAN_LINK_ATTRS",
+		50: "TRACE_ID_INDEX",
 	}
 	ArrowPayloadType_value = map[string]int32{
 		"UNKNOWN":                         0,
 		"RESOURCE_ATTRS":                  1,
 		"SCOPE_ATTRS":                     2,
+		"SCOPES":                          3,
 		"METRICS":                         10,
 		"NUMBER_DATA_POINTS":              11,
 		"SUMMARY_DATA_POINTS":             12,
@@ -129,6 +142,7 @@ var (
 		"NUMBER_DP_EXEMPLAR_ATTRS":        22,
 		"HISTOGRAM_DP_EXEMPLAR_ATTRS":     23,
 		"EXP_HISTOGRAM_DP_EXEMPLAR_ATTRS": 24,
+		"METRIC_METADATA":                 25,
 		"LOGS":                            30,
 		"LOG_ATTRS":                       31,
 		"SPANS":                           40,
@@ -137,6 +151,7 @@ var (
 		"SPAN_LINKS":                      43,
 		"SPAN_EVENT_ATTRS":                44,
 		"SPAN_LINK_ATTRS":                 45,
+		"TRACE_ID_INDEX":                  50,
 	}
 )
 
@@ -368,6 +383,23 @@ type BatchStatus struct {
 	BatchId       int64      `protobuf:"varint,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
 	StatusCode    StatusCode `protobuf:"varint,2,opt,name=status_code,json=statusCode,proto3,enum=opentelemetry.proto.experimental.arrow.v1.StatusCode" json:"status_code,omitempty"`
 	StatusMessage string     `protobuf:"bytes,3,opt,name=status_message,json=statusMessage,proto3" json:"status_message,omitempty"`
+	// The number of items (spans, data points, or log records) of the batch
+	// that were rejected by the receiver while the others were accepted, in
+	// which case status_code is OK and status_message explains why the
+	// items were rejected (see OTLP partial success).
+	RejectedItems int64 `protobuf:"varint,4,opt,name=rejected_items,json=rejectedItems,proto3" json:"rejected_items,omitempty"`
+	// Hints sent by the receiver to the producer of the stream.
+	// reset_dictionaries asks the producer to reset the dictionaries of the
+	// stream, e.g., because they are close to the receiver's memory limit.
+	ResetDictionaries bool `protobuf:"varint,5,opt,name=reset_dictionaries,json=resetDictionaries,proto3" json:"reset_dictionaries,omitempty"`
+	// plain_encoding_fields lists the fields the producer should not
+	// dictionary encode, using the keys of the producer's encoding
+	// overrides (e.g. "spans.name").
+	PlainEncodingFields []string `protobuf:"bytes,6,rep,name=plain_encoding_fields,json=plainEncodingFields,proto3" json:"plain_encoding_fields,omitempty"`
+	// decoded_bytes is the size in bytes of the OTLP representation of the
+	// batch once decoded by the receiver, so the exporter can compute the
+	// compression ratio achieved end-to-end. Zero if unknown.
+	DecodedBytes int64 `protobuf:"varint,7,opt,name=decoded_bytes,json=decodedBytes,proto3" json:"decoded_bytes,omitempty"`
 }
 
 func (x *BatchStatus) Reset() {
@@ -423,6 +455,34 @@ func (x *BatchStatus) GetStatusMessage() string {
 	return ""
 }
 
+func (x *BatchStatus) GetRejectedItems() int64 {
+	if x != nil {
+		return x.RejectedItems
+	}
+	return 0
+}
+
+func (x *BatchStatus) GetResetDictionaries() bool {
+	if x != nil {
+		return x.ResetDictionaries
+	}
+	return false
+}
+
+func (x *BatchStatus) GetPlainEncodingFields() []string {
+	if x != nil {
+		return x.PlainEncodingFields
+	}
+	return nil
+}
+
+func (x *BatchStatus) GetDecodedBytes() int64 {
+	if x != nil {
+		return x.DecodedBytes
+	}
+	return 0
+}
+
 var File_opentelemetry_proto_experimental_arrow_v1_arrow_service_proto protoreflect.FileDescriptor
 
 var file_opentelemetry_proto_experimental_arrow_v1_arrow_service_proto_rawDesc = []byte{
@@ -452,7 +512,7 @@ var file_opentelemetry_proto_experimental_arrow_v1_arrow_service_proto_rawDesc =
 	0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x72,
 	0x72, 0x6f, 0x77, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04,
 	0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0xa7, 0x01, 0x0a,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x22, 0xd6, 0x02, 0x0a,
 	0x0b, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x19, 0x0a, 0x08,
 	0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
 	0x62, 0x61, 0x74, 0x63, 0x68, 0x49, 0x64, 0x12, 0x56, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x74, 0x75,
@@ -463,81 +523,75 @@ var file_opentelemetry_proto_experimental_arrow_v1_arrow_service_proto_rawDesc =
 	0x6f, 0x64, 0x65, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x12,
 	0x25, 0x0a, 0x0e, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
 	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2a, 0xd4, 0x04, 0x0a, 0x10, 0x41, 0x72, 0x72, 0x6f, 0x77,
-	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55,
-	0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x52, 0x45, 0x53, 0x4f,
-	0x55, 0x52, 0x43, 0x45, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b,
-	0x53, 0x43, 0x4f, 0x50, 0x45, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x02, 0x12, 0x0b, 0x0a,
-	0x07, 0x4d, 0x45, 0x54, 0x52, 0x49, 0x43, 0x53, 0x10, 0x0a, 0x12, 0x16, 0x0a, 0x12, 0x4e, 0x55,
-	0x4d, 0x42, 0x45, 0x52, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x53,
-	0x10, 0x0b, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x55, 0x4d, 0x4d, 0x41, 0x52, 0x59, 0x5f, 0x44, 0x41,
-	0x54, 0x41, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x53, 0x10, 0x0c, 0x12, 0x19, 0x0a, 0x15, 0x48,
-	0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x5f, 0x50, 0x4f,
-	0x49, 0x4e, 0x54, 0x53, 0x10, 0x0d, 0x12, 0x1d, 0x0a, 0x19, 0x45, 0x58, 0x50, 0x5f, 0x48, 0x49,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74,
+	0x65, 0x64, 0x5f, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d,
+	0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x65, 0x64, 0x49, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x2d, 0x0a,
+	0x12, 0x72, 0x65, 0x73, 0x65, 0x74, 0x5f, 0x64, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x72,
+	0x69, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x72, 0x65, 0x73, 0x65, 0x74,
+	0x44, 0x69, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x72, 0x69, 0x65, 0x73, 0x12, 0x32, 0x0a, 0x15,
+	0x70, 0x6c, 0x61, 0x69, 0x6e, 0x5f, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x66,
+	0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x70, 0x6c, 0x61,
+	0x69, 0x6e, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73,
+	0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x64, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x64,
+	0x42, 0x79, 0x74, 0x65, 0x73, 0x2a, 0x89, 0x05, 0x0a, 0x10, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e,
+	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x52, 0x45, 0x53, 0x4f, 0x55,
+	0x52, 0x43, 0x45, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x53,
+	0x43, 0x4f, 0x50, 0x45, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07,
+	0x4d, 0x45, 0x54, 0x52, 0x49, 0x43, 0x53, 0x10, 0x0a, 0x12, 0x16, 0x0a, 0x12, 0x4e, 0x55, 0x4d,
+	0x42, 0x45, 0x52, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x53, 0x10,
+	0x0b, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x55, 0x4d, 0x4d, 0x41, 0x52, 0x59, 0x5f, 0x44, 0x41, 0x54,
+	0x41, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x53, 0x10, 0x0c, 0x12, 0x19, 0x0a, 0x15, 0x48, 0x49,
 	0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x5f, 0x50, 0x4f, 0x49,
-	0x4e, 0x54, 0x53, 0x10, 0x0e, 0x12, 0x13, 0x0a, 0x0f, 0x4e, 0x55, 0x4d, 0x42, 0x45, 0x52, 0x5f,
-	0x44, 0x50, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x0f, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x55,
-	0x4d, 0x4d, 0x41, 0x52, 0x59, 0x5f, 0x44, 0x50, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x10,
-	0x12, 0x16, 0x0a, 0x12, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50,
-	0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x11, 0x12, 0x1a, 0x0a, 0x16, 0x45, 0x58, 0x50, 0x5f,
-	0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x41, 0x54, 0x54,
-	0x52, 0x53, 0x10, 0x12, 0x12, 0x17, 0x0a, 0x13, 0x4e, 0x55, 0x4d, 0x42, 0x45, 0x52, 0x5f, 0x44,
-	0x50, 0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x53, 0x10, 0x13, 0x12, 0x1a, 0x0a,
-	0x16, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58,
-	0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x53, 0x10, 0x14, 0x12, 0x1e, 0x0a, 0x1a, 0x45, 0x58, 0x50,
-	0x5f, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58,
-	0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x53, 0x10, 0x15, 0x12, 0x1c, 0x0a, 0x18, 0x4e, 0x55, 0x4d,
-	0x42, 0x45, 0x52, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x5f,
-	0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x16, 0x12, 0x1f, 0x0a, 0x1b, 0x48, 0x49, 0x53, 0x54, 0x4f,
-	0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52,
-	0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x17, 0x12, 0x23, 0x0a, 0x1f, 0x45, 0x58, 0x50, 0x5f,
+	0x4e, 0x54, 0x53, 0x10, 0x0d, 0x12, 0x1d, 0x0a, 0x19, 0x45, 0x58, 0x50, 0x5f, 0x48, 0x49, 0x53,
+	0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x5f, 0x50, 0x4f, 0x49, 0x4e,
+	0x54, 0x53, 0x10, 0x0e, 0x12, 0x13, 0x0a, 0x0f, 0x4e, 0x55, 0x4d, 0x42, 0x45, 0x52, 0x5f, 0x44,
+	0x50, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x0f, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x55, 0x4d,
+	0x4d, 0x41, 0x52, 0x59, 0x5f, 0x44, 0x50, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x10, 0x12,
+	0x16, 0x0a, 0x12, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f,
+	0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x11, 0x12, 0x1a, 0x0a, 0x16, 0x45, 0x58, 0x50, 0x5f, 0x48,
+	0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x41, 0x54, 0x54, 0x52,
+	0x53, 0x10, 0x12, 0x12, 0x17, 0x0a, 0x13, 0x4e, 0x55, 0x4d, 0x42, 0x45, 0x52, 0x5f, 0x44, 0x50,
+	0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x53, 0x10, 0x13, 0x12, 0x1a, 0x0a, 0x16,
 	0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45,
-	0x4d, 0x50, 0x4c, 0x41, 0x52, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x18, 0x12, 0x08, 0x0a,
-	0x04, 0x4c, 0x4f, 0x47, 0x53, 0x10, 0x1e, 0x12, 0x0d, 0x0a, 0x09, 0x4c, 0x4f, 0x47, 0x5f, 0x41,
-	0x54, 0x54, 0x52, 0x53, 0x10, 0x1f, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x50, 0x41, 0x4e, 0x53, 0x10,
-	0x28, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10,
-	0x29, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x53,
-	0x10, 0x2a, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x4c, 0x49, 0x4e, 0x4b, 0x53,
-	0x10, 0x2b, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54,
-	0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x2c, 0x12, 0x13, 0x0a, 0x0f, 0x53, 0x50, 0x41, 0x4e,
-	0x5f, 0x4c, 0x49, 0x4e, 0x4b, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x2d, 0x2a, 0x3b, 0x0a,
-	0x0a, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x06, 0x0a, 0x02, 0x4f,
-	0x4b, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42,
-	0x4c, 0x45, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f,
-	0x41, 0x52, 0x47, 0x55, 0x4d, 0x45, 0x4e, 0x54, 0x10, 0x02, 0x32, 0xa0, 0x01, 0x0a, 0x12, 0x41,
-	0x72, 0x72, 0x6f, 0x77, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
-	0x65, 0x12, 0x89, 0x01, 0x0a, 0x0b, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x53, 0x74, 0x72, 0x65, 0x61,
-	0x6d, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72,
-	0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65,
-	0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61,
-	0x74, 0x63, 0x68, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x1a,
-	0x36, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74,
-	0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63,
-	0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32, 0xa0, 0x01,
-	0x0a, 0x12, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x73, 0x53, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x12, 0x89, 0x01, 0x0a, 0x0b, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x54, 0x72,
-	0x61, 0x63, 0x65, 0x73, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d,
-	0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72,
-	0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31,
-	0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x52, 0x65, 0x63, 0x6f, 0x72,
-	0x64, 0x73, 0x1a, 0x36, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74,
-	0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d,
-	0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42,
-	0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01,
-	0x32, 0x9c, 0x01, 0x0a, 0x10, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x4c, 0x6f, 0x67, 0x73, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x87, 0x01, 0x0a, 0x09, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x4c,
-	0x6f, 0x67, 0x73, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65,
+	0x4d, 0x50, 0x4c, 0x41, 0x52, 0x53, 0x10, 0x14, 0x12, 0x1e, 0x0a, 0x1a, 0x45, 0x58, 0x50, 0x5f,
+	0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45,
+	0x4d, 0x50, 0x4c, 0x41, 0x52, 0x53, 0x10, 0x15, 0x12, 0x1c, 0x0a, 0x18, 0x4e, 0x55, 0x4d, 0x42,
+	0x45, 0x52, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x5f, 0x41,
+	0x54, 0x54, 0x52, 0x53, 0x10, 0x16, 0x12, 0x1f, 0x0a, 0x1b, 0x48, 0x49, 0x53, 0x54, 0x4f, 0x47,
+	0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45, 0x4d, 0x50, 0x4c, 0x41, 0x52, 0x5f,
+	0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x17, 0x12, 0x23, 0x0a, 0x1f, 0x45, 0x58, 0x50, 0x5f, 0x48,
+	0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x5f, 0x44, 0x50, 0x5f, 0x45, 0x58, 0x45, 0x4d,
+	0x50, 0x4c, 0x41, 0x52, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x18, 0x12, 0x13, 0x0a, 0x0f,
+	0x4d, 0x45, 0x54, 0x52, 0x49, 0x43, 0x5f, 0x4d, 0x45, 0x54, 0x41, 0x44, 0x41, 0x54, 0x41, 0x10,
+	0x19, 0x12, 0x08, 0x0a, 0x04, 0x4c, 0x4f, 0x47, 0x53, 0x10, 0x1e, 0x12, 0x0d, 0x0a, 0x09, 0x4c,
+	0x4f, 0x47, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x1f, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x50,
+	0x41, 0x4e, 0x53, 0x10, 0x28, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x41, 0x54,
+	0x54, 0x52, 0x53, 0x10, 0x29, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x45, 0x56,
+	0x45, 0x4e, 0x54, 0x53, 0x10, 0x2a, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x4c,
+	0x49, 0x4e, 0x4b, 0x53, 0x10, 0x2b, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x50, 0x41, 0x4e, 0x5f, 0x45,
+	0x56, 0x45, 0x4e, 0x54, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10, 0x2c, 0x12, 0x13, 0x0a, 0x0f,
+	0x53, 0x50, 0x41, 0x4e, 0x5f, 0x4c, 0x49, 0x4e, 0x4b, 0x5f, 0x41, 0x54, 0x54, 0x52, 0x53, 0x10,
+	0x2d, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x52, 0x41, 0x43, 0x45, 0x5f, 0x49, 0x44, 0x5f, 0x49, 0x4e,
+	0x44, 0x45, 0x58, 0x10, 0x32, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x43, 0x4f, 0x50, 0x45, 0x53, 0x10,
+	0x03, 0x2a, 0x3b, 0x0a, 0x0a, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65, 0x12,
+	0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41,
+	0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x49, 0x4e, 0x56, 0x41,
+	0x4c, 0x49, 0x44, 0x5f, 0x41, 0x52, 0x47, 0x55, 0x4d, 0x45, 0x4e, 0x54, 0x10, 0x02, 0x32, 0xa0,
+	0x01, 0x0a, 0x12, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x89, 0x01, 0x0a, 0x0b, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65,
+	0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65,
+	0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76,
+	0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x52, 0x65, 0x63, 0x6f,
+	0x72, 0x64, 0x73, 0x1a, 0x36, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65,
 	0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69,
 	0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e,
-	0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
-	0x73, 0x1a, 0x36, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72,
-	0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65,
-	0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61,
-	0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x32,
-	0xa2, 0x01, 0x0a, 0x13, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
-	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x8a, 0x01, 0x0a, 0x0c, 0x41, 0x72, 0x72, 0x6f,
-	0x77, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74,
+	0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x28, 0x01, 0x30,
+	0x01, 0x32, 0xa0, 0x01, 0x0a, 0x12, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65,
+	0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x89, 0x01, 0x0a, 0x0b, 0x41, 0x72, 0x72,
+	0x6f, 0x77, 0x54, 0x72, 0x61, 0x63, 0x65, 0x73, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74,
 	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65,
 	0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f,
 	0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x52,
@@ -545,15 +599,36 @@ var file_opentelemetry_proto_experimental_arrow_v1_arrow_service_proto_rawDesc =
 	0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70,
 	0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e,
 	0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00,
-	0x28, 0x01, 0x30, 0x01, 0x42, 0x7f, 0x0a, 0x2c, 0x69, 0x6f, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74,
-	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65,
-	0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f,
-	0x77, 0x2e, 0x76, 0x31, 0x42, 0x11, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x53, 0x65, 0x72, 0x76, 0x69,
-	0x63, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75,
-	0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x66, 0x35, 0x2f, 0x6f, 0x74, 0x65, 0x6c, 0x2d, 0x61, 0x72,
-	0x72, 0x6f, 0x77, 0x2d, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x2f,
-	0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2f, 0x61, 0x72, 0x72,
-	0x6f, 0x77, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x28, 0x01, 0x30, 0x01, 0x32, 0x9c, 0x01, 0x0a, 0x10, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x4c, 0x6f,
+	0x67, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x87, 0x01, 0x0a, 0x09, 0x41, 0x72,
+	0x72, 0x6f, 0x77, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x3c, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65,
+	0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78,
+	0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77,
+	0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x73, 0x1a, 0x36, 0x2e, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65,
+	0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65,
+	0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76,
+	0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x28,
+	0x01, 0x30, 0x01, 0x32, 0xa2, 0x01, 0x0a, 0x13, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x4d, 0x65, 0x74,
+	0x72, 0x69, 0x63, 0x73, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x8a, 0x01, 0x0a, 0x0c,
+	0x41, 0x72, 0x72, 0x6f, 0x77, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x3c, 0x2e, 0x6f,
+	0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e,
+	0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41, 0x72,
+	0x72, 0x6f, 0x77, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x73, 0x1a, 0x36, 0x2e, 0x6f, 0x70, 0x65,
+	0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e, 0x61, 0x72,
+	0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x42, 0x7f, 0x0a, 0x2c, 0x69, 0x6f, 0x2e, 0x6f,
+	0x70, 0x65, 0x6e, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c, 0x2e,
+	0x61, 0x72, 0x72, 0x6f, 0x77, 0x2e, 0x76, 0x31, 0x42, 0x11, 0x41, 0x72, 0x72, 0x6f, 0x77, 0x53,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x3a, 0x67,
+	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x66, 0x35, 0x2f, 0x6f, 0x74, 0x65,
+	0x6c, 0x2d, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2d, 0x61, 0x64, 0x61, 0x70, 0x74, 0x65, 0x72, 0x2f,
+	0x61, 0x70, 0x69, 0x2f, 0x65, 0x78, 0x70, 0x65, 0x72, 0x69, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x6c,
+	0x2f, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x2f, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
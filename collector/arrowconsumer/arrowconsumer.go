@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package arrowconsumer defines the consumers encoding the data they receive
+// with an Arrow producer. The sources grouping their data by resource, scope
+// and metric (e.g. scrapers and aggregators) append it directly to the Arrow
+// builders of these consumers instead of building the pdata representation
+// first.
+package arrowconsumer // import "github.com/f5/otel-arrow-adapter/collector/arrowconsumer"
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+)
+
+// Metrics is implemented by the metrics consumers encoding the metrics with
+// an Arrow producer. A source checks whether its next consumer implements
+// this interface and falls back to ConsumeMetrics otherwise.
+type Metrics interface {
+	consumer.Metrics
+
+	// ConsumeMetricsAppender encodes the metrics appended by fn (see
+	// arrowRecord.Producer.BatchArrowRecordsFromMetricsAppender). fn may be
+	// called more than once, it must append the same metrics every time.
+	ConsumeMetricsAppender(ctx context.Context, fn func(*metricsarrow.MetricsAppender) error) error
+}
+
+// MetricsProducer is a Metrics consumer encoding the metrics with its own
+// producer, i.e. the batches form a single Arrow stream, and passing the
+// batches to a function (e.g. sending them on this stream).
+type MetricsProducer struct {
+	mu       sync.Mutex
+	producer *arrowRecord.Producer
+	next     func(context.Context, *arrowpb.BatchArrowRecords) error
+}
+
+var _ Metrics = (*MetricsProducer)(nil)
+
+// NewMetricsProducer returns a MetricsProducer encoding the metrics with the
+// given producer and passing the batches to next.
+func NewMetricsProducer(producer *arrowRecord.Producer, next func(context.Context, *arrowpb.BatchArrowRecords) error) *MetricsProducer {
+	return &MetricsProducer{
+		producer: producer,
+		next:     next,
+	}
+}
+
+// Capabilities implements consumer.Metrics.
+func (p *MetricsProducer) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeMetrics implements consumer.Metrics.
+func (p *MetricsProducer) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	p.mu.Lock()
+	batch, err := p.producer.BatchArrowRecordsFromMetrics(md)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.next(ctx, batch)
+}
+
+// ConsumeMetricsAppender implements Metrics.
+func (p *MetricsProducer) ConsumeMetricsAppender(ctx context.Context, fn func(*metricsarrow.MetricsAppender) error) error {
+	p.mu.Lock()
+	batch, err := p.producer.BatchArrowRecordsFromMetricsAppender(fn)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.next(ctx, batch)
+}
@@ -1,16 +1,22 @@
 package main
 
 import (
+	"github.com/f5/otel-arrow-adapter/collector/connector/spanmetricsconnector"
+	"github.com/f5/otel-arrow-adapter/collector/extension/tracecompletenessextension"
 	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter"
 	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/fileexporter"
 	"github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver"
 	"github.com/f5/otel-arrow-adapter/collector/receiver/filereceiver"
+	"github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+	"github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
+	"github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
 	"github.com/f5/otel-arrow-adapter/collector/processor/obfuscationprocessor"
 	"github.com/f5/otel-arrow-adapter/collector/processor/experimentprocessor"
 
 	"github.com/lightstep/telemetry-generator/generatorreceiver"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/basicauthextension"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/headerssetterextension"
+	"go.opentelemetry.io/collector/connector"
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/loggingexporter"
 	"go.opentelemetry.io/collector/exporter/otlphttpexporter"
@@ -33,6 +39,7 @@ func components() (otelcol.Factories, error) {
 		zpagesextension.NewFactory(),
 		headerssetterextension.NewFactory(),
 		basicauthextension.NewFactory(),
+		tracecompletenessextension.NewFactory(),
 	)
 	if err != nil {
 		return otelcol.Factories{}, err
@@ -41,6 +48,9 @@ func components() (otelcol.Factories, error) {
 	factories.Receivers, err = receiver.MakeFactoryMap(
 		otlpreceiver.NewFactory(),
 		filereceiver.NewFactory(),
+		logtailreceiver.NewFactory(),
+		promscrapereceiver.NewFactory(),
+		statsdreceiver.NewFactory(),
 		generatorreceiver.NewFactory(),
 	)
 	if err != nil {
@@ -67,5 +77,12 @@ func components() (otelcol.Factories, error) {
 		return otelcol.Factories{}, err
 	}
 
+	factories.Connectors, err = connector.MakeFactoryMap(
+		spanmetricsconnector.NewFactory(),
+	)
+	if err != nil {
+		return otelcol.Factories{}, err
+	}
+
 	return factories, nil
 }
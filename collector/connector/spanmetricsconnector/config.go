@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package spanmetricsconnector // import "github.com/f5/otel-arrow-adapter/collector/connector/spanmetricsconnector"
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the spanmetrics connector.
+type Config struct {
+	// Buckets are the bucket boundaries of the duration histogram.
+	// Default: spanmetrics.DefaultBounds.
+	Buckets []time.Duration `mapstructure:"buckets"`
+	// SeriesExpiry is the duration after which a series without new spans
+	// is forgotten, its metrics restarting from 0 on the next span
+	// (0 = never). Default: 5m.
+	SeriesExpiry time.Duration `mapstructure:"series_expiry"`
+	// FlushInterval is the interval at which the metrics are emitted.
+	// Default: 15s.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		SeriesExpiry:  5 * time.Minute,
+		FlushInterval: 15 * time.Second,
+	}
+}
+
+// Validate checks the connector configuration is valid.
+func (c Config) Validate() error {
+	if c.FlushInterval <= 0 {
+		return errors.New("flush_interval must be positive")
+	}
+	if c.SeriesExpiry < 0 {
+		return errors.New("series_expiry cannot be negative")
+	}
+	if !sort.SliceIsSorted(c.Buckets, func(i, j int) bool { return c.Buckets[i] < c.Buckets[j] }) {
+		return errors.New("buckets must be sorted")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package spanmetricsconnector // import "github.com/f5/otel-arrow-adapter/collector/connector/spanmetricsconnector"
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/spanmetrics"
+)
+
+type spanMetricsConnector struct {
+	next       consumer.Metrics
+	logger     *zap.Logger
+	config     *Config
+	aggregator *spanmetrics.Aggregator
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newConnector(logger *zap.Logger, config *Config, next consumer.Metrics) *spanMetricsConnector {
+	return &spanMetricsConnector{
+		next:       next,
+		logger:     logger,
+		config:     config,
+		aggregator: spanmetrics.NewAggregator(config.Buckets, config.SeriesExpiry),
+	}
+}
+
+func (c *spanMetricsConnector) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+func (c *spanMetricsConnector) Start(_ context.Context, _ component.Host) error {
+	// The context passed to Start is not meant to outlive it, the flush loop
+	// runs until Shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.flushLoop(ctx)
+	}()
+	return nil
+}
+
+func (c *spanMetricsConnector) Shutdown(_ context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+	return nil
+}
+
+func (c *spanMetricsConnector) ConsumeTraces(_ context.Context, traces ptrace.Traces) error {
+	c.aggregator.AggregateTraces(traces)
+	return nil
+}
+
+// flushLoop sends the metrics to the next consumer every flush interval and
+// once more on shutdown.
+func (c *spanMetricsConnector) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(c.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush(ctx)
+		case <-ctx.Done():
+			c.flush(context.Background())
+			return
+		}
+	}
+}
+
+// flush passes the current value of the metrics to the next consumer.
+func (c *spanMetricsConnector) flush(ctx context.Context) {
+	metrics := c.aggregator.Metrics(time.Now())
+	if metrics.ResourceMetrics().Len() == 0 {
+		return
+	}
+	if err := c.next.ConsumeMetrics(ctx, metrics); err != nil {
+		c.logger.Error("failed to consume span metrics", zap.Error(err))
+	}
+}
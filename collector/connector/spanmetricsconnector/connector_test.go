@@ -0,0 +1,77 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package spanmetricsconnector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/connector/connectortest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/spanmetrics"
+)
+
+func newTraces(service string, spans int) ptrace.Traces {
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", service)
+	ss := rs.ScopeSpans().AppendEmpty()
+	start := time.Unix(1000, 0)
+	for i := 0; i < spans; i++ {
+		span := ss.Spans().AppendEmpty()
+		span.SetName("GET /users")
+		span.SetKind(ptrace.SpanKindServer)
+		span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+		span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(5 * time.Millisecond)))
+		if i == 0 {
+			span.Status().SetCode(ptrace.StatusCodeError)
+		}
+	}
+	return traces
+}
+
+func TestConnector(t *testing.T) {
+	sink := &consumertest.MetricsSink{}
+	cfg := createDefaultConfig().(*Config)
+	cfg.FlushInterval = time.Hour
+
+	conn, err := NewFactory().CreateTracesToMetrics(context.Background(), connectortest.NewNopCreateSettings(), cfg, sink)
+	require.NoError(t, err)
+
+	// The flush loop must outlive the context passed to Start.
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, conn.Start(ctx, componenttest.NewNopHost()))
+	cancel()
+
+	require.NoError(t, conn.ConsumeTraces(context.Background(), newTraces("frontend", 3)))
+	require.NoError(t, conn.ConsumeTraces(context.Background(), newTraces("backend", 2)))
+
+	// The metrics are flushed on shutdown.
+	require.NoError(t, conn.Shutdown(context.Background()))
+	require.Len(t, sink.AllMetrics(), 1)
+
+	md := sink.AllMetrics()[0]
+	require.Equal(t, 2, md.ResourceMetrics().Len())
+	for i, want := range []struct {
+		service string
+		calls   int64
+	}{{"backend", 2}, {"frontend", 3}} {
+		rm := md.ResourceMetrics().At(i)
+		name, ok := rm.Resource().Attributes().Get("service.name")
+		require.True(t, ok)
+		require.Equal(t, want.service, name.Str())
+
+		metrics := rm.ScopeMetrics().At(0).Metrics()
+		require.Equal(t, spanmetrics.CallsMetric, metrics.At(0).Name())
+		require.Equal(t, want.calls, metrics.At(0).Sum().DataPoints().At(0).IntValue())
+		require.Equal(t, spanmetrics.ErrorsMetric, metrics.At(1).Name())
+		require.Equal(t, int64(1), metrics.At(1).Sum().DataPoints().At(0).IntValue())
+	}
+}
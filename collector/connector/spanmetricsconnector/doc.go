@@ -0,0 +1,12 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate mdatagen metadata.yaml
+
+// Package spanmetricsconnector implements a connector computing the RED
+// metrics (calls, errors, and duration histogram) of the spans of a traces
+// pipeline, per service name, span name, and span kind, with the aggregator
+// of the spanmetrics package. The cumulative metrics are emitted to the
+// metrics pipeline every flush interval, a series without new spans for
+// series_expiry being no longer reported.
+package spanmetricsconnector // import "github.com/f5/otel-arrow-adapter/collector/connector/spanmetricsconnector"
@@ -0,0 +1,32 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package spanmetricsconnector // import "github.com/f5/otel-arrow-adapter/collector/connector/spanmetricsconnector"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/connector"
+	"go.opentelemetry.io/collector/consumer"
+
+	"github.com/f5/otel-arrow-adapter/collector/connector/spanmetricsconnector/internal/metadata"
+)
+
+// NewFactory creates a factory for the spanmetrics connector.
+func NewFactory() connector.Factory {
+	return connector.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		connector.WithTracesToMetrics(createTracesToMetrics, metadata.TracesToMetricsStability),
+	)
+}
+
+func createTracesToMetrics(
+	_ context.Context,
+	settings connector.CreateSettings,
+	cc component.Config,
+	next consumer.Metrics,
+) (connector.Traces, error) {
+	return newConnector(settings.Logger, cc.(*Config), next), nil
+}
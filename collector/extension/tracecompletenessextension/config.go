@@ -0,0 +1,39 @@
+package tracecompletenessextension
+
+import (
+	"fmt"
+	"time"
+)
+
+type Config struct {
+	// CompletionDelay is the time without new spans after which a
+	// trace whose root span has been received is complete.
+	CompletionDelay time.Duration `mapstructure:"completion_delay"`
+	// Timeout is the time without new spans after which a trace
+	// whose root span has not been received is incomplete.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxTraces is the maximum number of traces tracked at once,
+	// the spans of the other traces are ignored and counted.
+	MaxTraces int `mapstructure:"max_traces"`
+	// SweepInterval is the period at which the settled traces are
+	// counted and notified.
+	SweepInterval time.Duration `mapstructure:"sweep_interval"`
+}
+
+// Validate checks that the durations and the number of traces are
+// positive.
+func (cfg *Config) Validate() error {
+	if cfg.CompletionDelay <= 0 {
+		return fmt.Errorf("completion delay must be > 0: %v", cfg.CompletionDelay)
+	}
+	if cfg.Timeout < cfg.CompletionDelay {
+		return fmt.Errorf("timeout must be >= completion delay: %v", cfg.Timeout)
+	}
+	if cfg.MaxTraces < 1 {
+		return fmt.Errorf("max traces must be > 0: %d", cfg.MaxTraces)
+	}
+	if cfg.SweepInterval <= 0 {
+		return fmt.Errorf("sweep interval must be > 0: %v", cfg.SweepInterval)
+	}
+	return nil
+}
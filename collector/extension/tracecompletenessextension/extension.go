@@ -0,0 +1,129 @@
+package tracecompletenessextension
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
+)
+
+const meterScopeName = "github.com/f5/otel-arrow-adapter/collector/extension/tracecompletenessextension"
+
+// Notifier is implemented by the extension.  Downstream components
+// find the extension with the host and register their listener,
+// notified of each settled trace, when they start.
+type Notifier interface {
+	AddListener(completeness.Listener)
+}
+
+var _ Notifier = (*traceCompleteness)(nil)
+
+// traceCompleteness owns the tracker fed by the OTLP receivers
+// configured with `protocols::arrow::trace_tracker`, and sweeps it periodically.
+type traceCompleteness struct {
+	cfg       *Config
+	telemetry component.TelemetrySettings
+	tracker   *completeness.Tracker
+
+	registration metric.Registration
+	stop         chan struct{}
+	wg           sync.WaitGroup
+}
+
+func newTraceCompleteness(cfg *Config, telemetry component.TelemetrySettings) *traceCompleteness {
+	return &traceCompleteness{
+		cfg:       cfg,
+		telemetry: telemetry,
+		tracker: completeness.NewTracker(completeness.Config{
+			CompletionDelay: cfg.CompletionDelay,
+			Timeout:         cfg.Timeout,
+			MaxTraces:       cfg.MaxTraces,
+		}),
+	}
+}
+
+// Tracker returns the tracker fed by the receivers, implementing the
+// TraceTracker interface of the OTLP receiver.
+func (tc *traceCompleteness) Tracker() *completeness.Tracker {
+	return tc.tracker
+}
+
+// AddListener registers a listener notified of each settled trace.
+func (tc *traceCompleteness) AddListener(l completeness.Listener) {
+	tc.tracker.AddListener(l)
+}
+
+func (tc *traceCompleteness) Start(_ context.Context, _ component.Host) error {
+	if err := tc.registerMetrics(); err != nil {
+		tc.telemetry.Logger.Error("trace completeness metrics", zap.Error(err))
+	}
+
+	tc.stop = make(chan struct{})
+	tc.wg.Add(1)
+	go func() {
+		defer tc.wg.Done()
+
+		ticker := time.NewTicker(tc.cfg.SweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				tc.tracker.Sweep(now)
+			case <-tc.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (tc *traceCompleteness) Shutdown(_ context.Context) error {
+	if tc.stop != nil {
+		close(tc.stop)
+		tc.wg.Wait()
+		tc.stop = nil
+	}
+	if tc.registration != nil {
+		err := tc.registration.Unregister()
+		tc.registration = nil
+		return err
+	}
+	return nil
+}
+
+// registerMetrics reports the counts of the tracker.
+func (tc *traceCompleteness) registerMetrics() error {
+	meter := tc.telemetry.MeterProvider.Meter(meterScopeName)
+
+	complete, err1 := meter.Int64ObservableCounter(completeness.CompleteMetric,
+		metric.WithDescription("Number of traces settled with a root span."))
+	incomplete, err2 := meter.Int64ObservableCounter(completeness.IncompleteMetric,
+		metric.WithDescription("Number of traces settled without a root span."))
+	dropped, err3 := meter.Int64ObservableCounter(completeness.DroppedSpansMetric,
+		metric.WithDescription("Number of spans not tracked because the maximum number of traces was reached."))
+	active, err4 := meter.Int64ObservableGauge(completeness.ActiveMetric,
+		metric.WithDescription("Number of traces currently tracked."))
+	if err := multierr.Combine(err1, err2, err3, err4); err != nil {
+		return err
+	}
+
+	reg, err := meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		counts := tc.tracker.Counts()
+		obs.ObserveInt64(complete, int64(counts.Complete))
+		obs.ObserveInt64(incomplete, int64(counts.Incomplete))
+		obs.ObserveInt64(dropped, int64(counts.DroppedSpans))
+		obs.ObserveInt64(active, int64(counts.Active))
+		return nil
+	}, complete, incomplete, dropped, active)
+	if err != nil {
+		return err
+	}
+	tc.registration = reg
+	return nil
+}
@@ -0,0 +1,47 @@
+package tracecompletenessextension
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/extension/extensiontest"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
+)
+
+func TestConfigValidate(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	require.NoError(t, cfg.Validate())
+
+	cfg.Timeout = cfg.CompletionDelay / 2
+	require.Error(t, cfg.Validate())
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.MaxTraces = 0
+	require.Error(t, cfg.Validate())
+}
+
+func TestExtensionLifecycle(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.SweepInterval = time.Millisecond
+
+	ext, err := NewFactory().CreateExtension(context.Background(), extensiontest.NewNopCreateSettings(), cfg)
+	require.NoError(t, err)
+
+	// The OTLP receiver expects a Tracker method, the downstream
+	// components the Notifier interface.
+	tracker, ok := ext.(interface{ Tracker() *completeness.Tracker })
+	require.True(t, ok)
+	require.NotNil(t, tracker.Tracker())
+	notifier, ok := ext.(Notifier)
+	require.True(t, ok)
+	notifier.AddListener(func(completeness.TraceStatus) {})
+
+	require.NoError(t, ext.Start(context.Background(), componenttest.NewNopHost()))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, ext.Shutdown(context.Background()))
+	require.Equal(t, completeness.Counts{}, tracker.Tracker().Counts())
+}
@@ -0,0 +1,47 @@
+package tracecompletenessextension
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
+)
+
+const (
+	// The value of "type" key in configuration.
+	typeStr = "tracecompleteness"
+	// The stability level of the extension.
+	stability = component.StabilityLevelAlpha
+
+	defaultSweepInterval = time.Second
+)
+
+// NewFactory creates a factory for the trace completeness extension.
+func NewFactory() extension.Factory {
+	return extension.NewFactory(
+		typeStr,
+		createDefaultConfig,
+		createExtension,
+		stability,
+	)
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		CompletionDelay: completeness.DefaultCompletionDelay,
+		Timeout:         completeness.DefaultTimeout,
+		MaxTraces:       completeness.DefaultMaxTraces,
+		SweepInterval:   defaultSweepInterval,
+	}
+}
+
+func createExtension(
+	_ context.Context,
+	set extension.CreateSettings,
+	cfg component.Config,
+) (extension.Extension, error) {
+	return newTraceCompleteness(cfg.(*Config), set.TelemetrySettings), nil
+}
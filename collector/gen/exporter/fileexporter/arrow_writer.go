@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package fileexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/fileexporter"
+
+import (
+	"bufio"
+	"os"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"go.uber.org/multierr"
+)
+
+// arrowFormat writes the Arrow records to Arrow IPC stream files. An IPC
+// stream carries a single schema, so every payload type of a signal gets
+// its own stream. The streams are aligned on the batches of the signal,
+// which allows the file receiver to reassemble and replay the batches.
+// The record bodies are compressed with zstd when the compression option
+// is set.
+func arrowFormat(cfg *Config) recordFormat {
+	return recordFormat{
+		extension: "arrows",
+		aligned:   true,
+		open: func(file *os.File, schema *arrow.Schema) (recordFile, error) {
+			buffered := bufio.NewWriter(file)
+			options := []ipc.Option{
+				ipc.WithSchema(schema),
+				ipc.WithDictionaryDeltas(true),
+			}
+			if cfg.Compression == compressionZSTD {
+				options = append(options, ipc.WithZstd())
+			}
+			return &arrowFile{
+				file:     file,
+				buffered: buffered,
+				writer:   ipc.NewWriter(buffered, options...),
+			}, nil
+		},
+	}
+}
+
+// arrowFile is an Arrow IPC stream file.
+type arrowFile struct {
+	file     *os.File
+	buffered *bufio.Writer
+	writer   *ipc.Writer
+}
+
+func (af *arrowFile) Write(record arrow.Record) error {
+	return af.writer.Write(record)
+}
+
+func (af *arrowFile) Flush() error {
+	return af.buffered.Flush()
+}
+
+// Close writes the end-of-stream marker and closes the file.
+func (af *arrowFile) Close() error {
+	return multierr.Combine(
+		af.writer.Close(),
+		af.buffered.Flush(),
+		af.file.Close(),
+	)
+}
@@ -29,12 +29,19 @@ type Config struct {
 	// Options:
 	// - json[default]:  OTLP json bytes.
 	// - proto:  OTLP binary protobuf bytes.
+	// - parquet:  Arrow records written as Parquet files under Path.
+	// - arrow:  Arrow records written as Arrow IPC stream files under Path.
 	FormatType string `mapstructure:"format"`
 
 	// Compression Codec used to export telemetry data
 	// Supported compression algorithms:`zstd`
+	// With the arrow format, the IPC record bodies are compressed.
 	Compression string `mapstructure:"compression"`
 
+	// Parquet configures the Parquet writer, only used when FormatType is
+	// parquet.
+	Parquet *ParquetSettings `mapstructure:"parquet"`
+
 	// FlushInterval is the duration between flushes.
 	// See time.ParseDuration for valid values.
 	FlushInterval time.Duration `mapstructure:"flush_interval"`
@@ -63,6 +70,18 @@ type Rotation struct {
 	LocalTime bool `mapstructure:"localtime"`
 }
 
+// ParquetSettings configures the Parquet files written by the exporter.
+type ParquetSettings struct {
+	// RowGroupSize is the maximum number of rows per row group. It
+	// defaults to 1048576 rows.
+	RowGroupSize int64 `mapstructure:"row_group_size"`
+
+	// Compression is the codec used to compress the Parquet column
+	// chunks.  Supported codecs: `none`, `snappy`[default], `gzip`,
+	// `brotli` and `zstd`.
+	Compression string `mapstructure:"compression"`
+}
+
 var _ component.Config = (*Config)(nil)
 
 // Validate checks if the exporter configuration is valid
@@ -70,12 +89,34 @@ func (cfg *Config) Validate() error {
 	if cfg.Path == "" {
 		return errors.New("path must be non-empty")
 	}
-	if cfg.FormatType != formatTypeJSON && cfg.FormatType != formatTypeProto {
+	switch cfg.FormatType {
+	case formatTypeJSON, formatTypeProto, formatTypeParquet, formatTypeArrow:
+	default:
 		return errors.New("format type is not supported")
 	}
 	if cfg.Compression != "" && cfg.Compression != compressionZSTD {
 		return errors.New("compression is not supported")
 	}
+	if cfg.FormatType == formatTypeArrow && cfg.Rotation != nil {
+		return errors.New("rotation is not supported with the arrow format")
+	}
+	if cfg.FormatType == formatTypeParquet {
+		if cfg.Rotation != nil {
+			return errors.New("rotation is not supported with the parquet format")
+		}
+		if cfg.Compression != "" {
+			return errors.New("compression is not supported with the parquet format, use parquet::compression")
+		}
+		if cfg.Parquet == nil {
+			return errors.New("parquet settings must be set with the parquet format")
+		}
+		if cfg.Parquet.RowGroupSize <= 0 {
+			return errors.New("parquet::row_group_size must be larger than zero")
+		}
+		if _, ok := parquetCodecs[cfg.Parquet.Compression]; !ok {
+			return errors.New("parquet compression is not supported")
+		}
+	}
 	if cfg.FlushInterval < 0 {
 		return errors.New("flush_interval must be larger than zero")
 	}
@@ -103,5 +144,18 @@ func (cfg *Config) Unmarshal(componentParser *confmap.Conf) error {
 	if cfg.FlushInterval == 0 {
 		cfg.FlushInterval = time.Second
 	}
+
+	// fill in the parquet defaults when the parquet format is selected.
+	if cfg.FormatType == formatTypeParquet {
+		if cfg.Parquet == nil {
+			cfg.Parquet = &ParquetSettings{}
+		}
+		if cfg.Parquet.RowGroupSize == 0 {
+			cfg.Parquet.RowGroupSize = defaultParquetRowGroupSize
+		}
+		if cfg.Parquet.Compression == "" {
+			cfg.Parquet.Compression = defaultParquetCompression
+		}
+	}
 	return nil
 }
\ No newline at end of file
@@ -114,6 +114,51 @@ func TestLoadConfig(t *testing.T) {
 			id:           component.NewIDWithName(metadata.Type, "flush_interval_negative_value"),
 			errorMessage: "flush_interval must be larger than zero",
 		},
+		{
+			id: component.NewIDWithName(metadata.Type, "parquet"),
+			expected: &Config{
+				Path:       "./parquet",
+				FormatType: formatTypeParquet,
+				Parquet: &ParquetSettings{
+					RowGroupSize: 10000,
+					Compression:  "zstd",
+				},
+				FlushInterval: time.Second,
+			},
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "parquet_default_settings"),
+			expected: &Config{
+				Path:       "./parquet",
+				FormatType: formatTypeParquet,
+				Parquet: &ParquetSettings{
+					RowGroupSize: defaultParquetRowGroupSize,
+					Compression:  defaultParquetCompression,
+				},
+				FlushInterval: time.Second,
+			},
+		},
+		{
+			id:           component.NewIDWithName(metadata.Type, "parquet_compression_error"),
+			errorMessage: "parquet compression is not supported",
+		},
+		{
+			id:           component.NewIDWithName(metadata.Type, "parquet_rotation_error"),
+			errorMessage: "rotation is not supported with the parquet format",
+		},
+		{
+			id: component.NewIDWithName(metadata.Type, "arrow"),
+			expected: &Config{
+				Path:          "./arrow",
+				FormatType:    formatTypeArrow,
+				Compression:   compressionZSTD,
+				FlushInterval: time.Second,
+			},
+		},
+		{
+			id:           component.NewIDWithName(metadata.Type, "arrow_rotation_error"),
+			errorMessage: "rotation is not supported with the arrow format",
+		},
 		{
 			id:           component.NewIDWithName(metadata.Type, ""),
 			errorMessage: "path must be non-empty",
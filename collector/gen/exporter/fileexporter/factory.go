@@ -23,8 +23,14 @@ const (
 	defaultMaxBackups = 100
 
 	// the format of encoded telemetry data
-	formatTypeJSON  = "json"
-	formatTypeProto = "proto"
+	formatTypeJSON    = "json"
+	formatTypeProto   = "proto"
+	formatTypeParquet = "parquet"
+	formatTypeArrow   = "arrow"
+
+	// the parquet writer defaults
+	defaultParquetRowGroupSize = 1 << 20
+	defaultParquetCompression  = "snappy"
 
 	// the type of compression codec
 	compressionZSTD = "zstd"
@@ -140,10 +146,25 @@ func newFileExporter(conf *Config, writer WriteCloseFlusher) *fileExporter {
 		compressor:       buildCompressor(conf.Compression),
 		flushInterval:    conf.FlushInterval,
 	}
+	if rw, ok := writer.(*recordWriter); ok {
+		fe.records = rw
+	}
 	return fe
 }
 
 func buildFileWriter(cfg *Config, logger *zap.Logger) (WriteCloseFlusher, error) {
+	if cfg.FormatType == formatTypeParquet || cfg.FormatType == formatTypeArrow {
+		format := arrowFormat(cfg)
+		if cfg.FormatType == formatTypeParquet {
+			format = parquetFormat(cfg)
+		}
+		rw, err := newRecordWriter(cfg, logger, format)
+		if err != nil {
+			return nil, err
+		}
+		return rw, nil
+	}
+
 	var writer io.WriteCloser
 	var err error
 	if cfg.Rotation == nil {
@@ -50,6 +50,11 @@ type fileExporter struct {
 	compression string
 	compressor  compressFunc
 
+	// records is set when the format type is parquet or arrow, in which
+	// case telemetry is written as Arrow records instead of being
+	// marshaled.
+	records *recordWriter
+
 	formatType string
 
 	flushInterval time.Duration
@@ -58,6 +63,9 @@ type fileExporter struct {
 }
 
 func (e *fileExporter) consumeTraces(_ context.Context, td ptrace.Traces) error {
+	if e.records != nil {
+		return e.records.writeTraces(td)
+	}
 	buf, err := e.tracesMarshaler.MarshalTraces(td)
 	if err != nil {
 		return err
@@ -67,6 +75,9 @@ func (e *fileExporter) consumeTraces(_ context.Context, td ptrace.Traces) error
 }
 
 func (e *fileExporter) consumeMetrics(_ context.Context, md pmetric.Metrics) error {
+	if e.records != nil {
+		return e.records.writeMetrics(md)
+	}
 	buf, err := e.metricsMarshaler.MarshalMetrics(md)
 	if err != nil {
 		return err
@@ -76,6 +87,9 @@ func (e *fileExporter) consumeMetrics(_ context.Context, md pmetric.Metrics) err
 }
 
 func (e *fileExporter) consumeLogs(_ context.Context, ld plog.Logs) error {
+	if e.records != nil {
+		return e.records.writeLogs(ld)
+	}
 	buf, err := e.logsMarshaler.MarshalLogs(ld)
 	if err != nil {
 		return err
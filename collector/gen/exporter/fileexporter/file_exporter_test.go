@@ -11,10 +11,13 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/parquet/file"
 	"github.com/klauspost/compress/zstd"
 	"github.com/f5/otel-arrow-adapter/collector/gen/internal/testdata"
 	"github.com/stretchr/testify/assert"
@@ -684,4 +687,80 @@ func TestFlushing(t *testing.T) {
 	// Compare the content.
 	assert.EqualValues(t, b, bbuf.Bytes())
 	assert.NoError(t, fe.Shutdown(ctx))
-}
\ No newline at end of file
+}
+
+func TestFileParquetExporter(t *testing.T) {
+	conf := &Config{
+		Path:       t.TempDir(),
+		FormatType: formatTypeParquet,
+		Parquet: &ParquetSettings{
+			RowGroupSize: defaultParquetRowGroupSize,
+			Compression:  "zstd",
+		},
+	}
+	writer, err := buildFileWriter(conf, zap.NewNop())
+	require.NoError(t, err)
+	fe := newFileExporter(conf, writer)
+	require.NotNil(t, fe.records)
+
+	ctx := context.Background()
+	assert.NoError(t, fe.Start(ctx, componenttest.NewNopHost()))
+	assert.NoError(t, fe.consumeTraces(ctx, testdata.GenerateTraces(2)))
+	assert.NoError(t, fe.consumeMetrics(ctx, testdata.GenerateMetrics(2)))
+	assert.NoError(t, fe.consumeLogs(ctx, testdata.GenerateLogs(2)))
+	assert.NoError(t, fe.Shutdown(ctx))
+
+	// Resource attributes are written per signal.
+	for name, rows := range map[string]int64{
+		"traces/spans-0.parquet":          2,
+		"metrics/metrics-0.parquet":       2,
+		"logs/logs-0.parquet":             2,
+		"traces/resource_attrs-0.parquet": 1,
+		"logs/resource_attrs-0.parquet":   1,
+	} {
+		rdr, err := file.OpenParquetFile(filepath.Join(conf.Path, name), false)
+		require.NoError(t, err, name)
+		assert.Equal(t, rows, rdr.NumRows(), name)
+		assert.NoError(t, rdr.Close())
+	}
+}
+
+func TestFileArrowExporter(t *testing.T) {
+	conf := &Config{
+		Path:        t.TempDir(),
+		FormatType:  formatTypeArrow,
+		Compression: compressionZSTD,
+	}
+	writer, err := buildFileWriter(conf, zap.NewNop())
+	require.NoError(t, err)
+	fe := newFileExporter(conf, writer)
+	require.NotNil(t, fe.records)
+
+	ctx := context.Background()
+	assert.NoError(t, fe.Start(ctx, componenttest.NewNopHost()))
+	assert.NoError(t, fe.consumeTraces(ctx, testdata.GenerateTraces(2)))
+	assert.NoError(t, fe.consumeTraces(ctx, testdata.GenerateTraces(2)))
+	assert.NoError(t, fe.consumeMetrics(ctx, testdata.GenerateMetrics(2)))
+	assert.NoError(t, fe.consumeLogs(ctx, testdata.GenerateLogs(2)))
+	assert.NoError(t, fe.Shutdown(ctx))
+
+	for name, rows := range map[string]int64{
+		"traces/spans-0.arrows":        4,
+		"metrics/metrics-0.arrows":     2,
+		"logs/logs-0.arrows":           2,
+		"logs/resource_attrs-0.arrows": 1,
+	} {
+		f, err := os.Open(filepath.Join(conf.Path, name))
+		require.NoError(t, err, name)
+		rdr, err := ipc.NewReader(f)
+		require.NoError(t, err, name)
+		var count int64
+		for rdr.Next() {
+			count += rdr.Record().NumRows()
+		}
+		assert.NoError(t, rdr.Err(), name)
+		assert.Equal(t, rows, count, name)
+		rdr.Release()
+		assert.NoError(t, f.Close())
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package fileexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/fileexporter"
+
+import (
+	"os"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+)
+
+// parquetCodecs maps the supported parquet::compression values to their
+// Parquet codec.
+var parquetCodecs = map[string]compress.Compression{
+	"none":   compress.Codecs.Uncompressed,
+	"snappy": compress.Codecs.Snappy,
+	"gzip":   compress.Codecs.Gzip,
+	"brotli": compress.Codecs.Brotli,
+	"zstd":   compress.Codecs.Zstd,
+}
+
+// parquetFormat writes the Arrow records to Parquet files. The records are
+// buffered until a row group reaches the configured row group size or
+// until the file is closed.
+func parquetFormat(cfg *Config) recordFormat {
+	props := parquet.NewWriterProperties(
+		parquet.WithMaxRowGroupLength(cfg.Parquet.RowGroupSize),
+		parquet.WithCompression(parquetCodecs[cfg.Parquet.Compression]),
+	)
+	return recordFormat{
+		extension: "parquet",
+		open: func(file *os.File, schema *arrow.Schema) (recordFile, error) {
+			writer, err := pqarrow.NewFileWriter(schema, file, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+			if err != nil {
+				return nil, err
+			}
+			return &parquetFile{writer: writer}, nil
+		},
+	}
+}
+
+// parquetFile is a Parquet file, closing the writer closes the file.
+type parquetFile struct {
+	writer *pqarrow.FileWriter
+}
+
+func (pf *parquetFile) Write(record arrow.Record) error {
+	return pf.writer.WriteBuffered(record)
+}
+
+// Flush is a no-op, a row group is written out once it reaches the
+// configured row group size or when the file is closed.
+func (pf *parquetFile) Flush() error {
+	return nil
+}
+
+func (pf *parquetFile) Close() error {
+	return pf.writer.Close()
+}
@@ -0,0 +1,242 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package fileexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/fileexporter"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+var errRecordRawWrite = errors.New("the arrow record writer does not accept marshaled telemetry")
+
+// recordFile is an open file of Arrow records sharing the same schema.
+type recordFile interface {
+	Write(arrow.Record) error
+	Flush() error
+	Close() error
+}
+
+// recordFormat describes how the Arrow records are stored on disk.
+type recordFormat struct {
+	// extension of the files, without the dot.
+	extension string
+	// open starts a new file of records with the given schema. The
+	// returned recordFile owns the file and closes it.
+	open func(file *os.File, schema *arrow.Schema) (recordFile, error)
+	// aligned writes an empty record to the open files of the signal
+	// that received no record in a batch, so that the record index of
+	// every file is the batch index, offset by the batch that started
+	// the file.
+	aligned bool
+}
+
+// recordWriter encodes telemetry with the OTel Arrow producer and writes
+// every Arrow record it produces to a file, one file per signal and
+// payload type. The Arrow schemas are adaptive, so a schema change closes
+// the current file of the payload type and starts a new one. A file is
+// named after its payload type and the index of the first batch of the
+// signal it contains.
+type recordWriter struct {
+	mutex  sync.Mutex
+	dir    string
+	logger *zap.Logger
+	format recordFormat
+
+	producer *arrowRecord.Producer
+	files    map[recordFileKey]*openRecordFile
+	batches  map[string]int
+
+	// signal is the signal of the batch being written, resource and
+	// scope attributes records are produced for every signal.
+	signal string
+	// err is the first error encountered while writing the records of
+	// the current batch.
+	err error
+}
+
+// recordFileKey identifies the files of a payload type.
+type recordFileKey struct {
+	signal      string
+	payloadType record_message.PayloadType
+}
+
+// openRecordFile is an open file and the schema of its records.
+type openRecordFile struct {
+	path   string
+	schema *arrow.Schema
+	file   recordFile
+	// batch is the index of the last batch written to the file.
+	batch int
+}
+
+var _ WriteCloseFlusher = (*recordWriter)(nil)
+var _ arrowRecord.ProducerObserver = (*recordWriter)(nil)
+
+func newRecordWriter(cfg *Config, logger *zap.Logger, format recordFormat) (*recordWriter, error) {
+	if err := os.MkdirAll(cfg.Path, 0700); err != nil {
+		return nil, err
+	}
+	rw := &recordWriter{
+		dir:      cfg.Path,
+		logger:   logger,
+		format:   format,
+		producer: arrowRecord.NewProducer(),
+		files:    make(map[recordFileKey]*openRecordFile),
+		batches:  make(map[string]int),
+	}
+	rw.producer.SetObserver(rw)
+	return rw, nil
+}
+
+func (rw *recordWriter) writeTraces(td ptrace.Traces) error {
+	return rw.writeBatch("traces", func() error {
+		_, err := rw.producer.BatchArrowRecordsFromTraces(td)
+		return err
+	})
+}
+
+func (rw *recordWriter) writeMetrics(md pmetric.Metrics) error {
+	return rw.writeBatch("metrics", func() error {
+		_, err := rw.producer.BatchArrowRecordsFromMetrics(md)
+		return err
+	})
+}
+
+func (rw *recordWriter) writeLogs(ld plog.Logs) error {
+	return rw.writeBatch("logs", func() error {
+		_, err := rw.producer.BatchArrowRecordsFromLogs(ld)
+		return err
+	})
+}
+
+// writeBatch produces a batch of the signal, its records are written by
+// OnRecord.
+func (rw *recordWriter) writeBatch(signal string, produce func() error) error {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	rw.signal, rw.err = signal, nil
+	defer func() { rw.batches[signal]++ }()
+
+	if err := produce(); err != nil {
+		return err
+	}
+	if rw.err == nil && rw.format.aligned {
+		rw.err = rw.alignFiles()
+	}
+	return rw.err
+}
+
+// alignFiles writes an empty record to the open files of the current
+// signal that received no record in the current batch.
+func (rw *recordWriter) alignFiles() error {
+	batch := rw.batches[rw.signal]
+	for key, rf := range rw.files {
+		if key.signal != rw.signal || rf.batch == batch {
+			continue
+		}
+		builder := array.NewRecordBuilder(memory.DefaultAllocator, rf.schema)
+		record := builder.NewRecord()
+		err := rf.file.Write(record)
+		record.Release()
+		builder.Release()
+		if err != nil {
+			return err
+		}
+		rf.batch = batch
+	}
+	return nil
+}
+
+// OnRecord is called by the producer for every record of a batch, before
+// the record is released.
+func (rw *recordWriter) OnRecord(record arrow.Record, payloadType record_message.PayloadType) {
+	if rw.err != nil {
+		return
+	}
+	rw.err = rw.writeRecord(record, payloadType)
+}
+
+func (rw *recordWriter) writeRecord(record arrow.Record, payloadType record_message.PayloadType) error {
+	key := recordFileKey{signal: rw.signal, payloadType: payloadType}
+	rf := rw.files[key]
+	if rf != nil && !rf.schema.Equal(record.Schema()) {
+		rw.logger.Debug("Arrow schema changed, starting a new file",
+			zap.String("payload_type", payloadType.String()),
+			zap.String("previous_file", rf.path))
+		delete(rw.files, key)
+		if err := rf.file.Close(); err != nil {
+			return err
+		}
+		rf = nil
+	}
+	if rf == nil {
+		var err error
+		if rf, err = rw.openFile(record.Schema(), key); err != nil {
+			return err
+		}
+		rw.files[key] = rf
+	}
+	rf.batch = rw.batches[rw.signal]
+	return rf.file.Write(record)
+}
+
+func (rw *recordWriter) openFile(schema *arrow.Schema, key recordFileKey) (*openRecordFile, error) {
+	dir := filepath.Join(rw.dir, key.signal)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%d.%s", strings.ToLower(key.payloadType.String()), rw.batches[key.signal], rw.format.extension))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	rf, err := rw.format.open(file, schema)
+	if err != nil {
+		return nil, multierr.Append(fmt.Errorf("%s: %w", key.payloadType.String(), err), file.Close())
+	}
+	return &openRecordFile{path: path, schema: schema, file: rf}, nil
+}
+
+// Write is not supported, telemetry is written through the write* methods.
+func (rw *recordWriter) Write([]byte) (int, error) {
+	return 0, errRecordRawWrite
+}
+
+// Flush flushes every open file.
+func (rw *recordWriter) Flush() error {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	var err error
+	for _, rf := range rw.files {
+		err = multierr.Append(err, rf.file.Flush())
+	}
+	return err
+}
+
+// Close closes every open file and the producer.
+func (rw *recordWriter) Close() error {
+	rw.mutex.Lock()
+	defer rw.mutex.Unlock()
+	var err error
+	for key, rf := range rw.files {
+		err = multierr.Append(err, rf.file.Close())
+		delete(rw.files, key)
+	}
+	return multierr.Append(err, rw.producer.Close())
+}
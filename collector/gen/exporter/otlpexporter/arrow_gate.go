@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter"
+
+import (
+	"context"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"go.opentelemetry.io/collector/featuregate"
+)
+
+// arrowFeatureGate allows OTLP+Arrow to be turned off and on without
+// a restart, for staged rollouts and fast rollback.  When the gate is
+// disabled, exporters that are configured for Arrow drain and close
+// their streams and use standard OTLP; when it is enabled again, they
+// reopen their streams.  The gate is checked before each export.
+var arrowFeatureGate = featuregate.GlobalRegistry().MustRegister(
+	"exporter.otlp.arrow",
+	featuregate.StageBeta,
+	featuregate.WithRegisterDescription("When disabled, the OTLP exporter uses standard OTLP instead of OTLP+Arrow streams."),
+)
+
+// syncArrowGate starts or stops the Arrow exporter when the feature
+// gate has changed since the last call.  Stopping waits for in-flight
+// Arrow sends to finish.
+func (e *baseExporter) syncArrowGate(ctx context.Context) error {
+	if e.startArrow == nil {
+		return nil
+	}
+	enabled := arrowFeatureGate.IsEnabled()
+
+	e.arrowLock.RLock()
+	running := e.arrowRunning
+	e.arrowLock.RUnlock()
+
+	if enabled == running {
+		return nil
+	}
+
+	e.arrowLock.Lock()
+	defer e.arrowLock.Unlock()
+
+	switch {
+	case enabled == e.arrowRunning:
+		// Another caller made the change.
+		return nil
+	case enabled && e.arrowIdle:
+		e.settings.Logger.Info("starting arrow streams on first export", zap.String("signal", string(e.signal)))
+		return e.startArrowLocked()
+	case enabled:
+		e.settings.Logger.Info("arrow feature gate enabled, starting arrow streams")
+		return e.startArrowLocked()
+	default:
+		e.settings.Logger.Info("arrow feature gate disabled, stopping arrow streams")
+		return e.stopArrowLocked(ctx)
+	}
+}
+
+// startArrowLocked starts the Arrow exporter, the caller holds the
+// write lock.
+func (e *baseExporter) startArrowLocked() error {
+	if err := e.startArrow(); err != nil {
+		return err
+	}
+	e.arrowRunning = true
+	e.arrowIdle = false
+	return nil
+}
+
+// stopArrowLocked shuts down the Arrow exporter, the caller holds the
+// write lock.
+func (e *baseExporter) stopArrowLocked(ctx context.Context) error {
+	var err error
+	if e.arrow != nil {
+		err = multierr.Append(err, e.arrow.Shutdown(ctx))
+		e.arrow = nil
+	}
+	if e.arrowPartitions != nil {
+		err = multierr.Append(err, e.arrowPartitions.shutdown(ctx))
+		e.arrowPartitions = nil
+	}
+	e.arrowRunning = false
+	return err
+}
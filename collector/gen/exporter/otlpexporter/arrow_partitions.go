@@ -0,0 +1,246 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter"
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+var errTooManyPartitions = errors.New("too many arrow stream metadata-value combinations")
+
+// arrowPartitions maintains one arrow.Exporter per distinct
+// combination of client metadata values for the configured keys, so
+// that each partition has its own streams and dictionaries.  The
+// partitions without data for longer than the idle timeout are
+// evicted and their exporter shut down.
+type arrowPartitions struct {
+	keys        []string
+	limit       int
+	idleTimeout time.Duration
+	bgctx       context.Context
+	newExporter func() *arrow.Exporter
+	logger      *zap.Logger
+	now         func() time.Time
+
+	lock       sync.Mutex
+	partitions map[string]*arrowPartition
+
+	// stop ends the eviction of the idle partitions, wg waits
+	// for it.
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// arrowPartition is the exporter of a partition, started by the
+// first request of the partition.
+type arrowPartition struct {
+	exp *arrow.Exporter
+
+	// started is closed once the exporter is started, err being
+	// the error of its start.
+	started chan struct{}
+	err     error
+
+	// inflight is the number of requests using the exporter and
+	// lastUsed the end of the last one, protected by the lock of
+	// the partitions.
+	inflight int
+	lastUsed time.Time
+}
+
+func newArrowPartitions(bgctx context.Context, keys []string, limit uint32, idleTimeout time.Duration, logger *zap.Logger, newExporter func() *arrow.Exporter) *arrowPartitions {
+	lower := make([]string, len(keys))
+	for i, key := range keys {
+		lower[i] = strings.ToLower(key)
+	}
+	p := &arrowPartitions{
+		keys:        lower,
+		limit:       int(limit),
+		idleTimeout: idleTimeout,
+		bgctx:       bgctx,
+		newExporter: newExporter,
+		logger:      logger,
+		now:         time.Now,
+		partitions:  map[string]*arrowPartition{},
+		stop:        make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		p.wg.Add(1)
+		go p.runEviction()
+	}
+	return p
+}
+
+// sendAndWait selects the partition for the client metadata in ctx,
+// starting its exporter on first use, and sends the data on it.
+func (p *arrowPartitions) sendAndWait(ctx context.Context, data interface{}) (bool, error) {
+	part, err := p.acquire(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer p.release(part)
+
+	return part.exp.SendAndWait(ctx, data)
+}
+
+// acquire returns the partition for the client metadata in ctx, which
+// is not evicted until released.  The exporter of a new partition is
+// started outside of the lock, the requests of the other partitions
+// are not blocked while its streams are opened.
+func (p *arrowPartitions) acquire(ctx context.Context) (*arrowPartition, error) {
+	key, md := p.partition(ctx)
+
+	p.lock.Lock()
+	part, ok := p.partitions[key]
+	if !ok {
+		if len(p.partitions) >= p.limit {
+			p.lock.Unlock()
+			return nil, consumererror.NewPermanent(errTooManyPartitions)
+		}
+		part = &arrowPartition{
+			exp:     p.newExporter(),
+			started: make(chan struct{}),
+		}
+		p.partitions[key] = part
+	}
+	part.inflight++
+	p.lock.Unlock()
+
+	if !ok {
+		// The partition's metadata values are sent as outgoing
+		// metadata when the streams are opened, so the receiver
+		// sees them on every request of the stream.
+		outgoing, _ := metadata.FromOutgoingContext(p.bgctx)
+		part.err = part.exp.Start(metadata.NewOutgoingContext(p.bgctx, metadata.Join(outgoing, md)))
+		close(part.started)
+	}
+
+	select {
+	case <-part.started:
+	case <-ctx.Done():
+		p.release(part)
+		return nil, ctx.Err()
+	}
+	if part.err != nil {
+		// The next request of the partition starts a new
+		// exporter.
+		p.lock.Lock()
+		if p.partitions[key] == part {
+			delete(p.partitions, key)
+		}
+		p.lock.Unlock()
+		p.release(part)
+		return nil, part.err
+	}
+	return part, nil
+}
+
+// release ends a request of an acquired partition.
+func (p *arrowPartitions) release(part *arrowPartition) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	part.inflight--
+	part.lastUsed = p.now()
+}
+
+// partition returns the key of the partition for the client metadata
+// in ctx, and its metadata values.
+func (p *arrowPartitions) partition(ctx context.Context) (string, metadata.MD) {
+	info := client.FromContext(ctx)
+
+	var sb strings.Builder
+	md := metadata.MD{}
+	for _, key := range p.keys {
+		values := info.Metadata.Get(key)
+		if len(values) != 0 {
+			md.Set(key, values...)
+		}
+		sb.WriteString(key)
+		for _, value := range values {
+			sb.WriteByte(0)
+			sb.WriteString(value)
+		}
+		sb.WriteByte(0)
+	}
+	return sb.String(), md
+}
+
+// runEviction evicts the idle partitions until shutdown.
+func (p *arrowPartitions) runEviction() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if err := p.evictIdle(p.bgctx); err != nil {
+				p.logger.Warn("shutdown of an idle arrow stream partition failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// evictIdle removes the partitions without request for longer than
+// the idle timeout and shuts down their exporter.
+func (p *arrowPartitions) evictIdle(ctx context.Context) error {
+	idleSince := p.now().Add(-p.idleTimeout)
+
+	p.lock.Lock()
+	var evicted []*arrowPartition
+	for key, part := range p.partitions {
+		if part.inflight == 0 && part.lastUsed.Before(idleSince) {
+			delete(p.partitions, key)
+			evicted = append(evicted, part)
+		}
+	}
+	p.lock.Unlock()
+
+	return shutdownPartitions(ctx, evicted)
+}
+
+// shutdown stops the eviction and the exporters of all partitions.
+func (p *arrowPartitions) shutdown(ctx context.Context) error {
+	close(p.stop)
+	p.wg.Wait()
+
+	p.lock.Lock()
+	parts := make([]*arrowPartition, 0, len(p.partitions))
+	for _, part := range p.partitions {
+		parts = append(parts, part)
+	}
+	p.partitions = map[string]*arrowPartition{}
+	p.lock.Unlock()
+
+	return shutdownPartitions(ctx, parts)
+}
+
+// shutdownPartitions shuts down the exporters of removed partitions,
+// once started.
+func shutdownPartitions(ctx context.Context, parts []*arrowPartition) error {
+	var err error
+	for _, part := range parts {
+		<-part.started
+		if part.err == nil {
+			err = multierr.Append(err, part.exp.Shutdown(ctx))
+		}
+	}
+	return err
+}
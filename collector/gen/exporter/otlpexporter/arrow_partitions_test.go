@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpexporter
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+	"go.opentelemetry.io/collector/client"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumererror"
+)
+
+func tenantContext(tenant string) context.Context {
+	return client.NewContext(context.Background(), client.Info{
+		Metadata: client.NewMetadata(map[string][]string{"X-Tenant-Id": {tenant}}),
+	})
+}
+
+func TestArrowPartitions(t *testing.T) {
+	opened := make(chan metadata.MD, 10)
+
+	newExporter := func() *arrow.Exporter {
+		// The stream client records the outgoing metadata
+		// and fails, which downgrades the partition.
+		streamClient := func(ctx context.Context, _ ...grpc.CallOption) (arrow.AnyStreamClient, error) {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			opened <- md
+			return nil, fmt.Errorf("unavailable")
+		}
+		return arrow.NewExporter(1, false, componenttest.NewNopTelemetrySettings(), nil, func() arrowRecord.ProducerAPI {
+			return arrowRecord.NewProducer()
+		}, streamClient, nil, nil, nil, arrow.EncodeFailureDrop, nil)
+	}
+
+	bgctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("static", "header"))
+	parts := newArrowPartitions(bgctx, []string{"X-Tenant-Id"}, 2, time.Hour, zap.NewNop(), newExporter)
+	clock := time.Now()
+	parts.now = func() time.Time { return clock }
+
+	partA, err := parts.acquire(tenantContext("a"))
+	require.NoError(t, err)
+	md := <-opened
+	require.Equal(t, []string{"a"}, md.Get("x-tenant-id"))
+	require.Equal(t, []string{"header"}, md.Get("static"))
+	parts.release(partA)
+
+	again, err := parts.acquire(tenantContext("a"))
+	require.NoError(t, err)
+	require.Same(t, partA, again)
+	parts.release(again)
+
+	partB, err := parts.acquire(tenantContext("b"))
+	require.NoError(t, err)
+	require.NotSame(t, partA.exp, partB.exp)
+	md = <-opened
+	require.Equal(t, []string{"b"}, md.Get("x-tenant-id"))
+
+	// The cardinality limit is reached.
+	_, err = parts.acquire(tenantContext("c"))
+	require.Error(t, err)
+	require.True(t, consumererror.IsPermanent(err))
+
+	// The idle partition is evicted, not the one in use.
+	clock = clock.Add(2 * time.Hour)
+	require.NoError(t, parts.evictIdle(context.Background()))
+	parts.release(partB)
+
+	partC, err := parts.acquire(tenantContext("c"))
+	require.NoError(t, err)
+	md = <-opened
+	require.Equal(t, []string{"c"}, md.Get("x-tenant-id"))
+	parts.release(partC)
+
+	_, err = parts.acquire(tenantContext("a"))
+	require.Error(t, err)
+	require.True(t, consumererror.IsPermanent(err))
+
+	require.NoError(t, parts.shutdown(context.Background()))
+}
@@ -5,12 +5,20 @@ package otlpexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/e
 
 import (
 	"fmt"
+	"time"
 
 	"google.golang.org/grpc"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+	"github.com/f5/otel-arrow-adapter/collector/internal/arrowflight"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	logsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/logs/arrow"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+	tracesarrow "github.com/f5/otel-arrow-adapter/pkg/otel/traces/arrow"
 )
 
 // Config defines configuration for OTLP exporter.
@@ -37,6 +45,196 @@ type ArrowSettings struct {
 	NumStreams         int  `mapstructure:"num_streams"`
 	DisableDowngrade   bool `mapstructure:"disable_downgrade"`
 	EnableMixedSignals bool `mapstructure:"enable_mixed_signals"`
+
+	// MetadataKeys is a list of client.Metadata keys used to
+	// partition Arrow streams.  Each distinct combination of
+	// values opens its own set of NumStreams streams, with the
+	// values passed as outgoing gRPC metadata on the stream.
+	MetadataKeys []string `mapstructure:"metadata_keys"`
+
+	// MetadataCardinalityLimit limits the number of distinct
+	// combinations of MetadataKeys values; data for additional
+	// combinations is rejected with a permanent error.
+	MetadataCardinalityLimit uint32 `mapstructure:"metadata_cardinality_limit"`
+
+	// MetadataIdleTimeout is the time after which the streams of
+	// a combination of MetadataKeys values without data are
+	// closed, the combination no longer counting against
+	// MetadataCardinalityLimit.  Zero keeps the streams open
+	// until shutdown.
+	MetadataIdleTimeout time.Duration `mapstructure:"metadata_idle_timeout"`
+
+	// MinArrowBatchBytes enables a heuristic that sends batches
+	// smaller than this uncompressed OTLP size via standard
+	// OTLP, since the Arrow schema and dictionary overhead
+	// exceeds the savings for tiny batches.  Zero disables the
+	// heuristic.
+	MinArrowBatchBytes int `mapstructure:"min_arrow_batch_bytes"`
+
+	// MaxRecordBytes is the maximum serialized size of the Arrow
+	// batches, e.g., the maximum message size of the receiver.  A
+	// batch exceeding it is split into several batches, each
+	// acknowledged separately.  Zero disables the limit.
+	MaxRecordBytes int `mapstructure:"max_record_bytes"`
+
+	// WholeResources keeps every resource within a single batch
+	// when the data is split, by the adaptive batch size or by
+	// MaxRecordBytes, so that receivers can route whole resources
+	// without reassembling them across messages.  A resource
+	// larger than the adaptive batch size is sent as one
+	// oversized batch, a resource exceeding MaxRecordBytes is
+	// rejected.
+	WholeResources bool `mapstructure:"whole_resources"`
+
+	// Hashing configures keyed hashing of attribute values at
+	// encode time.
+	Hashing HashingSettings `mapstructure:"hashing"`
+
+	// EncryptionKeyProvider is the ID of an extension implementing
+	// encryption.KeyProvider, whose current key encrypts the
+	// serialized Arrow payloads with AES-GCM, independently of
+	// TLS.  The receivers must be configured with the same keys.
+	// The data sent with standard OTLP (e.g., small batches or
+	// after a downgrade) is not encrypted.
+	EncryptionKeyProvider *component.ID `mapstructure:"encryption_key_provider"`
+
+	// Adaptive configures adjustment of the send concurrency and
+	// batch size based on the receiver's batch status responses.
+	Adaptive AdaptiveSettings `mapstructure:"adaptive"`
+
+	// CPUBudget is the maximum fraction of the time each stream
+	// may spend encoding and compressing batches, e.g., 0.1 for
+	// 10% of a core.  When the budget is exceeded the optional
+	// transforms and then the Arrow compression are turned off,
+	// and restored once the encoding cost falls well below the
+	// budget.  Zero disables the budget.
+	CPUBudget float64 `mapstructure:"cpu_budget"`
+
+	// Warmup configures the coalescing of batches sent shortly
+	// after the exporter starts.
+	Warmup WarmupSettings `mapstructure:"warmup"`
+
+	// EncodeFailure is the policy for batches that cannot be
+	// encoded as Arrow: "drop" (the default) rejects the batch
+	// with a permanent error, "block" retries it on a new stream
+	// until the send times out, and "fallback" sends it using
+	// standard OTLP.
+	EncodeFailure arrow.EncodeFailurePolicy `mapstructure:"encode_failure"`
+
+	// EncodingOverrides forces the encoding of fields that are
+	// dictionary encoded by default.  The keys are the payload
+	// type and the path of the field, e.g., "spans.name" or
+	// "span_events.name", and the values are "plain",
+	// "dictionary8", or "dictionary16", or "run_end" for the
+	// int32 and uint8 fields.  The overrides
+	// of unknown fields or not applicable to their field are
+	// rejected.  The delta encodings of the ID columns are fixed
+	// by the protocol and cannot be overridden.
+	EncodingOverrides map[string]string `mapstructure:"encoding_overrides"`
+
+	// SortKeys selects the order in which the items of each signal
+	// are sorted before their encoding, since the ordering with
+	// the best compression ratio depends on the workload.
+	SortKeys SortKeysSettings `mapstructure:"sort_keys"`
+
+	// AcceptHints applies the producer hints sent by the receiver
+	// in its batch statuses.  A stream restarts with a new
+	// producer when the receiver asks for a dictionary reset or
+	// for the plain encoding of a field.
+	AcceptHints bool `mapstructure:"accept_hints"`
+
+	// LazyConnect defers opening the Arrow streams until the
+	// first export, instead of opening them at Start().  Each
+	// signal has its own streams, so a pipeline that only
+	// exports metrics does not hold idle trace and log streams
+	// open against the receiver's stream limit.
+	LazyConnect bool `mapstructure:"lazy_connect"`
+
+	// Flight configures carrying the Arrow streams over the Arrow
+	// Flight service instead of the OTel Arrow services.
+	Flight FlightSettings `mapstructure:"flight"`
+}
+
+// FlightSettings configures the Arrow Flight transport, for receivers
+// behind Flight-based data infrastructure.  The receiver must serve
+// the Flight service (see the receiver's enable_flight setting).
+type FlightSettings struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Method is the Flight method carrying the streams:
+	// "do_exchange" (the default) or "do_put".
+	Method arrowflight.Method `mapstructure:"method"`
+}
+
+// SortKeysSettings lists the sort keys of each signal, the first key
+// being the most significant, e.g., [resource, scope, trace_id] to
+// keep the spans of a trace together.  An empty list keeps the
+// default ordering of the signal.
+type SortKeysSettings struct {
+	// Traces lists keys among resource, scope, trace_id,
+	// span_id, name, kind, and start_time.
+	Traces []string `mapstructure:"traces"`
+
+	// Logs lists keys among resource, scope, trace_id,
+	// span_id, severity, and timestamp.
+	Logs []string `mapstructure:"logs"`
+
+	// Metrics lists keys among resource, scope, type, name,
+	// and unit.
+	Metrics []string `mapstructure:"metrics"`
+}
+
+// HashingSettings configures the replacement of the string values of
+// selected attribute keys by their HMAC-SHA256, whether the data is
+// encoded as Arrow records or sent with standard OTLP (e.g. small
+// batches or after a downgrade).  Hashed values remain joinable but
+// the raw values are not transmitted; the receiver decodes them as
+// ordinary strings.
+type HashingSettings struct {
+	// Attributes lists the attribute keys whose values are hashed.
+	Attributes []string `mapstructure:"attributes"`
+
+	// KeyProvider is the ID of the extension providing the HMAC
+	// key, it must implement HashKeyProvider.
+	KeyProvider *component.ID `mapstructure:"key_provider"`
+}
+
+// HashKeyProvider is implemented by extensions that provide the HMAC
+// key used to hash attribute values.
+type HashKeyProvider interface {
+	HashKey() ([]byte, error)
+}
+
+// AdaptiveSettings configures AIMD-style adaptive batching.  While
+// batches are acknowledged within LatencyTarget, the number of
+// batches in flight (up to NumStreams) and the target batch size
+// increase additively; both are halved when the latency target is
+// exceeded or the receiver responds with an UNAVAILABLE status.
+type AdaptiveSettings struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// LatencyTarget is the acknowledgement latency above which
+	// the exporter backs off.
+	LatencyTarget time.Duration `mapstructure:"latency_target"`
+
+	// MinBatchSize and MaxBatchSize bound the target number of
+	// items (spans, log records, or metrics) per Arrow batch.
+	MinBatchSize int `mapstructure:"min_batch_size"`
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+}
+
+// WarmupSettings configures a warm-up period during which batches
+// sent concurrently are coalesced into fewer, larger batches, so that
+// the dictionaries and optional columns of new streams stabilize
+// quickly instead of being reset by a series of tiny first batches.
+type WarmupSettings struct {
+	// Duration is the length of the warm-up period.  Zero
+	// disables warm-up.
+	Duration time.Duration `mapstructure:"duration"`
+
+	// Linger is how long a batch waits for others to join it
+	// during the warm-up period.
+	Linger time.Duration `mapstructure:"linger"`
 }
 
 var _ component.Config = (*Config)(nil)
@@ -53,11 +251,80 @@ func (cfg *Config) Validate() error {
 	return nil
 }
 
-// Validate returns an error when the number of streams is less than 1.
+// Validate returns an error when the number of streams is less than 1
+// or when metadata keys are configured without a cardinality limit.
 func (cfg *ArrowSettings) Validate() error {
 	if cfg.NumStreams < 1 {
 		return fmt.Errorf("stream count must be > 0: %d", cfg.NumStreams)
 	}
+	if len(cfg.MetadataKeys) != 0 && cfg.MetadataCardinalityLimit == 0 {
+		return fmt.Errorf("metadata cardinality limit must be > 0 when metadata keys are set")
+	}
+	if cfg.MetadataIdleTimeout < 0 {
+		return fmt.Errorf("metadata idle timeout must be >= 0: %v", cfg.MetadataIdleTimeout)
+	}
+	if cfg.MinArrowBatchBytes < 0 {
+		return fmt.Errorf("min arrow batch bytes must be >= 0: %d", cfg.MinArrowBatchBytes)
+	}
+	if cfg.MaxRecordBytes < 0 {
+		return fmt.Errorf("max record bytes must be >= 0: %d", cfg.MaxRecordBytes)
+	}
+	if len(cfg.Hashing.Attributes) != 0 && cfg.Hashing.KeyProvider == nil {
+		return fmt.Errorf("hashing key provider must be set when hashed attributes are set")
+	}
+	if err := cfg.Adaptive.Validate(); err != nil {
+		return fmt.Errorf("adaptive settings has invalid configuration: %w", err)
+	}
+	if cfg.CPUBudget < 0 || cfg.CPUBudget > 1 {
+		return fmt.Errorf("cpu budget must be between 0 and 1: %v", cfg.CPUBudget)
+	}
+	if cfg.Warmup.Duration < 0 || cfg.Warmup.Linger < 0 {
+		return fmt.Errorf("warmup duration and linger must be >= 0: %v, %v", cfg.Warmup.Duration, cfg.Warmup.Linger)
+	}
+	if cfg.Warmup.Duration > 0 && cfg.Warmup.Linger == 0 {
+		return fmt.Errorf("warmup linger must be > 0 when warmup duration is set")
+	}
+	if err := cfg.EncodeFailure.Validate(); err != nil {
+		return err
+	}
+	if err := arrowRecord.ValidateEncodingOverrides(cfg.EncodingOverrides); err != nil {
+		return fmt.Errorf("invalid encoding overrides: %w", err)
+	}
+	if err := cfg.SortKeys.Validate(); err != nil {
+		return fmt.Errorf("invalid sort keys: %w", err)
+	}
+	if err := cfg.Flight.Method.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
 
+// Validate checks the latency target and batch size bounds when
+// adaptive batching is enabled.
+func (cfg *AdaptiveSettings) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.LatencyTarget <= 0 {
+		return fmt.Errorf("latency target must be > 0: %v", cfg.LatencyTarget)
+	}
+	if cfg.MinBatchSize < 1 || cfg.MaxBatchSize < cfg.MinBatchSize {
+		return fmt.Errorf("batch sizes must satisfy 0 < min <= max: %d, %d", cfg.MinBatchSize, cfg.MaxBatchSize)
+	}
+	return nil
+}
+
+// Validate checks that the sort keys are supported by their signal.
+func (cfg *SortKeysSettings) Validate() error {
+	if _, err := tracesarrow.SortSpansByKeys(cfg.Traces...); err != nil {
+		return fmt.Errorf("traces: %w", err)
+	}
+	if _, err := logsarrow.SortLogsByKeys(cfg.Logs...); err != nil {
+		return fmt.Errorf("logs: %w", err)
+	}
+	if _, err := metricsarrow.SortMetricsByKeys(cfg.Metrics...); err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
 	return nil
 }
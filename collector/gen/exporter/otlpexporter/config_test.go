@@ -20,6 +20,9 @@ import (
 	"go.opentelemetry.io/collector/confmap"
 	"go.opentelemetry.io/collector/confmap/confmaptest"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+	"github.com/f5/otel-arrow-adapter/collector/internal/arrowflight"
 )
 
 func TestUnmarshalDefaultConfig(t *testing.T) {
@@ -77,8 +80,31 @@ func TestUnmarshalConfig(t *testing.T) {
 				Auth:            &configauth.Authentication{AuthenticatorID: component.NewID("nop")},
 			},
 			Arrow: ArrowSettings{
-				NumStreams:         2,
-				EnableMixedSignals: true,
+				NumStreams:               2,
+				EnableMixedSignals:       true,
+				MetadataKeys:             []string{"x-tenant-id"},
+				MetadataCardinalityLimit: 10,
+				MetadataIdleTimeout:      time.Minute,
+				Adaptive: AdaptiveSettings{
+					Enabled:        true,
+					LatencyTarget:  500 * time.Millisecond,
+					MinBatchSize:   defaultAdaptiveMinBatchSize,
+					MaxBatchSize:   defaultAdaptiveMaxBatchSize,
+				},
+				WholeResources: true,
+				EncodeFailure: arrow.EncodeFailureFallback,
+				EncodingOverrides: map[string]string{
+					"spans.name": "plain",
+				},
+				SortKeys: SortKeysSettings{
+					Traces: []string{"resource", "scope", "trace_id"},
+				},
+				AcceptHints: true,
+				LazyConnect: true,
+				Flight: FlightSettings{
+					Enabled: true,
+					Method:  arrowflight.MethodDoPut,
+				},
 			},
 		}, cfg)
 }
@@ -96,6 +122,92 @@ func TestArrowSettingsValidate(t *testing.T) {
 	require.Contains(t, settings(true, 0).Validate().Error(), "stream count must be")
 	require.Error(t, settings(false, -1).Validate())
 	require.Error(t, settings(true, math.MinInt).Validate())
+
+	partitioned := settings(true, 1)
+	partitioned.MetadataKeys = []string{"x-tenant-id"}
+	require.Error(t, partitioned.Validate())
+	require.Contains(t, partitioned.Validate().Error(), "metadata cardinality limit")
+	partitioned.MetadataCardinalityLimit = 1
+	require.NoError(t, partitioned.Validate())
+	partitioned.MetadataIdleTimeout = -time.Second
+	require.Error(t, partitioned.Validate())
+	partitioned.MetadataIdleTimeout = 0
+	require.NoError(t, partitioned.Validate())
+
+	adaptive := settings(true, 1)
+	adaptive.Adaptive = AdaptiveSettings{Enabled: true, LatencyTarget: time.Second, MinBatchSize: 10, MaxBatchSize: 100}
+	require.NoError(t, adaptive.Validate())
+	adaptive.Adaptive.MaxBatchSize = 5
+	require.Error(t, adaptive.Validate())
+	adaptive.Adaptive.MaxBatchSize = 100
+	adaptive.Adaptive.LatencyTarget = 0
+	require.Error(t, adaptive.Validate())
+	adaptive.Adaptive.Enabled = false
+	require.NoError(t, adaptive.Validate())
+
+	small := settings(true, 1)
+	small.MinArrowBatchBytes = -1
+	require.Error(t, small.Validate())
+
+	limited := settings(true, 1)
+	limited.MaxRecordBytes = 4 << 20
+	require.NoError(t, limited.Validate())
+	limited.MaxRecordBytes = -1
+	require.Error(t, limited.Validate())
+
+	hashing := settings(true, 1)
+	hashing.Hashing.Attributes = []string{"user.id"}
+	require.Error(t, hashing.Validate())
+	keyProvider := component.NewID("hashkey")
+	hashing.Hashing.KeyProvider = &keyProvider
+	require.NoError(t, hashing.Validate())
+
+	budget := settings(true, 1)
+	budget.CPUBudget = 0.1
+	require.NoError(t, budget.Validate())
+	budget.CPUBudget = 1.5
+	require.Error(t, budget.Validate())
+	budget.CPUBudget = -0.1
+	require.Error(t, budget.Validate())
+
+	warmup := settings(true, 1)
+	warmup.Warmup.Duration = 10 * time.Second
+	require.Error(t, warmup.Validate())
+	warmup.Warmup.Linger = 100 * time.Millisecond
+	require.NoError(t, warmup.Validate())
+	warmup.Warmup.Duration = -time.Second
+	require.Error(t, warmup.Validate())
+
+	encodeFailure := settings(true, 1)
+	encodeFailure.EncodeFailure = arrow.EncodeFailureBlock
+	require.NoError(t, encodeFailure.Validate())
+	encodeFailure.EncodeFailure = "retry"
+	require.Error(t, encodeFailure.Validate())
+	require.Contains(t, encodeFailure.Validate().Error(), "unrecognized encode failure policy")
+
+	overrides := settings(true, 1)
+	overrides.EncodingOverrides = map[string]string{"spans.name": "dictionary16"}
+	require.NoError(t, overrides.Validate())
+	overrides.EncodingOverrides["span_events.id"] = "delta"
+	require.Error(t, overrides.Validate())
+	delete(overrides.EncodingOverrides, "span_events.id")
+	overrides.EncodingOverrides["spans.unknown"] = "plain"
+	require.Error(t, overrides.Validate())
+
+	sortKeys := settings(true, 1)
+	sortKeys.SortKeys.Traces = []string{"resource", "scope", "trace_id"}
+	sortKeys.SortKeys.Metrics = []string{"name"}
+	require.NoError(t, sortKeys.Validate())
+	sortKeys.SortKeys.Logs = []string{"severity_text"}
+	require.Error(t, sortKeys.Validate())
+	require.Contains(t, sortKeys.Validate().Error(), "unknown sort key")
+
+	flightMethod := settings(true, 1)
+	flightMethod.Flight = FlightSettings{Enabled: true, Method: arrowflight.MethodDoPut}
+	require.NoError(t, flightMethod.Validate())
+	flightMethod.Flight.Method = "do_get"
+	require.Error(t, flightMethod.Validate())
+	require.Contains(t, flightMethod.Validate().Error(), "unrecognized flight method")
 }
 
 func TestDefaultSettingsValid(t *testing.T) {
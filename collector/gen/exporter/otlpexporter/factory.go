@@ -6,7 +6,9 @@ package otlpexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/e
 import (
 	"context"
 	"runtime"
+	"time"
 
+	"github.com/apache/arrow/go/v12/arrow/flight"
 	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	"google.golang.org/grpc"
 
@@ -18,11 +20,25 @@ import (
 	"go.opentelemetry.io/collector/exporter"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+	"github.com/f5/otel-arrow-adapter/collector/internal/arrowflight"
 )
 
 const (
 	// The value of "type" key in configuration.
 	typeStr = "otlp"
+
+	// defaultMetadataCardinalityLimit bounds the number of
+	// Arrow stream partitions when MetadataKeys is configured.
+	defaultMetadataCardinalityLimit = 1000
+
+	// defaultMetadataIdleTimeout closes the streams of the
+	// Arrow stream partitions without data.
+	defaultMetadataIdleTimeout = 5 * time.Minute
+
+	// Adaptive batching defaults, used when it is enabled.
+	defaultAdaptiveLatencyTarget = time.Second
+	defaultAdaptiveMinBatchSize  = 100
+	defaultAdaptiveMaxBatchSize  = 8192
 )
 
 // NewFactory creates a factory for OTLP exporter.
@@ -49,7 +65,15 @@ func createDefaultConfig() component.Config {
 			WriteBufferSize: 512 * 1024,
 		},
 		Arrow: ArrowSettings{
-			NumStreams: runtime.NumCPU(),
+			NumStreams:               runtime.NumCPU(),
+			MetadataCardinalityLimit: defaultMetadataCardinalityLimit,
+			MetadataIdleTimeout:      defaultMetadataIdleTimeout,
+			Adaptive: AdaptiveSettings{
+				LatencyTarget: defaultAdaptiveLatencyTarget,
+				MinBatchSize:  defaultAdaptiveMinBatchSize,
+				MaxBatchSize:  defaultAdaptiveMaxBatchSize,
+			},
+			EncodeFailure: arrow.EncodeFailureDrop,
 		},
 	}
 }
@@ -65,7 +89,23 @@ func (oce *baseExporter) helperOptions() []exporterhelper.Option {
 	}
 }
 
+// createFlightStream returns the constructor of the Arrow streams of
+// the signal carried by the Arrow Flight service, the signal being
+// empty for mixed signals.
+func createFlightStream(cfg *Config, conn *grpc.ClientConn, signal string) func(ctx context.Context, opts ...grpc.CallOption) (arrow.AnyStreamClient, error) {
+	if cfg.Arrow.EnableMixedSignals {
+		signal = ""
+	}
+	client := flight.NewClientFromConn(conn, nil)
+	return func(ctx context.Context, opts ...grpc.CallOption) (arrow.AnyStreamClient, error) {
+		return arrowflight.NewClientStream(ctx, client, cfg.Arrow.Flight.Method, signal, opts...)
+	}
+}
+
 func createArrowTracesStream(cfg *Config, conn *grpc.ClientConn) func(ctx context.Context, opts ...grpc.CallOption) (arrow.AnyStreamClient, error) {
+	if cfg.Arrow.Flight.Enabled {
+		return createFlightStream(cfg, conn, "traces")
+	}
 	if cfg.Arrow.EnableMixedSignals {
 		return arrow.MakeAnyStreamClient(arrowpb.NewArrowStreamServiceClient(conn).ArrowStream)
 	}
@@ -77,7 +117,7 @@ func createTracesExporter(
 	set exporter.CreateSettings,
 	cfg component.Config,
 ) (exporter.Traces, error) {
-	oce, err := newExporter(cfg, set, createArrowTracesStream)
+	oce, err := newExporter(cfg, set, component.DataTypeTraces, createArrowTracesStream)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +128,9 @@ func createTracesExporter(
 }
 
 func createArrowMetricsStream(cfg *Config, conn *grpc.ClientConn) func(ctx context.Context, opts ...grpc.CallOption) (arrow.AnyStreamClient, error) {
+	if cfg.Arrow.Flight.Enabled {
+		return createFlightStream(cfg, conn, "metrics")
+	}
 	if cfg.Arrow.EnableMixedSignals {
 		return arrow.MakeAnyStreamClient(arrowpb.NewArrowStreamServiceClient(conn).ArrowStream)
 	}
@@ -99,7 +142,7 @@ func createMetricsExporter(
 	set exporter.CreateSettings,
 	cfg component.Config,
 ) (exporter.Metrics, error) {
-	oce, err := newExporter(cfg, set, createArrowMetricsStream)
+	oce, err := newExporter(cfg, set, component.DataTypeMetrics, createArrowMetricsStream)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +153,9 @@ func createMetricsExporter(
 }
 
 func createArrowLogsStream(cfg *Config, conn *grpc.ClientConn) func(ctx context.Context, opts ...grpc.CallOption) (arrow.AnyStreamClient, error) {
+	if cfg.Arrow.Flight.Enabled {
+		return createFlightStream(cfg, conn, "logs")
+	}
 	if cfg.Arrow.EnableMixedSignals {
 		return arrow.MakeAnyStreamClient(arrowpb.NewArrowStreamServiceClient(conn).ArrowStream)
 	}
@@ -121,7 +167,7 @@ func createLogsExporter(
 	set exporter.CreateSettings,
 	cfg component.Config,
 ) (exporter.Logs, error) {
-	oce, err := newExporter(cfg, set, createArrowLogsStream)
+	oce, err := newExporter(cfg, set, component.DataTypeLogs, createArrowLogsStream)
 	if err != nil {
 		return nil, err
 	}
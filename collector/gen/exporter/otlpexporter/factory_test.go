@@ -34,7 +34,16 @@ func TestCreateDefaultConfig(t *testing.T) {
 	assert.Equal(t, ocfg.QueueSettings, exporterhelper.NewDefaultQueueSettings())
 	assert.Equal(t, ocfg.TimeoutSettings, exporterhelper.NewDefaultTimeoutSettings())
 	assert.Equal(t, ocfg.Compression, configcompression.Gzip)
-	assert.Equal(t, ocfg.Arrow, ArrowSettings{Disabled: false, NumStreams: runtime.NumCPU()})
+	assert.Equal(t, ocfg.Arrow, ArrowSettings{
+		Disabled:                 false,
+		NumStreams:               runtime.NumCPU(),
+		MetadataCardinalityLimit: defaultMetadataCardinalityLimit,
+		Adaptive: AdaptiveSettings{
+			LatencyTarget: defaultAdaptiveLatencyTarget,
+			MinBatchSize:  defaultAdaptiveMinBatchSize,
+			MaxBatchSize:  defaultAdaptiveMaxBatchSize,
+		},
+	})
 }
 
 func TestCreateMetricsExporter(t *testing.T) {
@@ -0,0 +1,125 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpexporter // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter"
+
+import (
+	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// The data sent with standard OTLP is not encoded by an Arrow
+// producer, its attributes are hashed here like the producers hash
+// the attributes they encode.  The data is copied first, exporters
+// must not modify their input.
+
+func (e *baseExporter) hashTraces(td ptrace.Traces) ptrace.Traces {
+	if e.hashing == nil {
+		return td
+	}
+	hasher := carrow.NewAttributeHasher(e.hashing)
+	hashed := ptrace.NewTraces()
+	td.CopyTo(hashed)
+
+	for i := 0; i < hashed.ResourceSpans().Len(); i++ {
+		rs := hashed.ResourceSpans().At(i)
+		hasher.HashMap(rs.Resource().Attributes())
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			hasher.HashMap(ss.Scope().Attributes())
+			for k := 0; k < ss.Spans().Len(); k++ {
+				span := ss.Spans().At(k)
+				hasher.HashMap(span.Attributes())
+				for l := 0; l < span.Events().Len(); l++ {
+					hasher.HashMap(span.Events().At(l).Attributes())
+				}
+				for l := 0; l < span.Links().Len(); l++ {
+					hasher.HashMap(span.Links().At(l).Attributes())
+				}
+			}
+		}
+	}
+	return hashed
+}
+
+func (e *baseExporter) hashLogs(ld plog.Logs) plog.Logs {
+	if e.hashing == nil {
+		return ld
+	}
+	hasher := carrow.NewAttributeHasher(e.hashing)
+	hashed := plog.NewLogs()
+	ld.CopyTo(hashed)
+
+	for i := 0; i < hashed.ResourceLogs().Len(); i++ {
+		rl := hashed.ResourceLogs().At(i)
+		hasher.HashMap(rl.Resource().Attributes())
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			hasher.HashMap(sl.Scope().Attributes())
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				hasher.HashMap(sl.LogRecords().At(k).Attributes())
+			}
+		}
+	}
+	return hashed
+}
+
+func (e *baseExporter) hashMetrics(md pmetric.Metrics) pmetric.Metrics {
+	if e.hashing == nil {
+		return md
+	}
+	hasher := carrow.NewAttributeHasher(e.hashing)
+	hashed := pmetric.NewMetrics()
+	md.CopyTo(hashed)
+
+	hashExemplars := func(exemplars pmetric.ExemplarSlice) {
+		for i := 0; i < exemplars.Len(); i++ {
+			hasher.HashMap(exemplars.At(i).FilteredAttributes())
+		}
+	}
+	hashNumberDataPoints := func(dps pmetric.NumberDataPointSlice) {
+		for i := 0; i < dps.Len(); i++ {
+			hasher.HashMap(dps.At(i).Attributes())
+			hashExemplars(dps.At(i).Exemplars())
+		}
+	}
+
+	for i := 0; i < hashed.ResourceMetrics().Len(); i++ {
+		rm := hashed.ResourceMetrics().At(i)
+		hasher.HashMap(rm.Resource().Attributes())
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			hasher.HashMap(sm.Scope().Attributes())
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				metric := sm.Metrics().At(k)
+				hasher.HashMap(metric.Metadata())
+				switch metric.Type() {
+				case pmetric.MetricTypeGauge:
+					hashNumberDataPoints(metric.Gauge().DataPoints())
+				case pmetric.MetricTypeSum:
+					hashNumberDataPoints(metric.Sum().DataPoints())
+				case pmetric.MetricTypeHistogram:
+					dps := metric.Histogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						hasher.HashMap(dps.At(l).Attributes())
+						hashExemplars(dps.At(l).Exemplars())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					dps := metric.ExponentialHistogram().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						hasher.HashMap(dps.At(l).Attributes())
+						hashExemplars(dps.At(l).Exemplars())
+					}
+				case pmetric.MetricTypeSummary:
+					dps := metric.Summary().DataPoints()
+					for l := 0; l < dps.Len(); l++ {
+						hasher.HashMap(dps.At(l).Attributes())
+					}
+				}
+			}
+		}
+	}
+	return hashed
+}
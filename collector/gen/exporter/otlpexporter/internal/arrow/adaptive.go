@@ -0,0 +1,189 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/multierr"
+)
+
+const meterScopeName = "github.com/f5/otel-arrow-adapter/collector/exporter/otlpexporter"
+
+// AdaptiveConfig configures the adaptive (AIMD) adjustment of the
+// send concurrency and target batch size.
+type AdaptiveConfig struct {
+	// LatencyTarget is the batch acknowledgement latency above
+	// which the exporter backs off.
+	LatencyTarget time.Duration
+
+	// MinBatchSize and MaxBatchSize bound the target number of
+	// items (spans, log records, or metrics) per Arrow batch.
+	MinBatchSize int
+	MaxBatchSize int
+
+	// WholeResources splits the data only between resources.
+	WholeResources bool
+}
+
+// adaptiveController limits the number of batches in flight and the
+// size of each batch.  Both limits grow additively while batches are
+// acknowledged within the latency target and are halved when the
+// latency target is exceeded or the receiver responds UNAVAILABLE,
+// which is its signal to slow down.
+type adaptiveController struct {
+	cfg            AdaptiveConfig
+	maxConcurrency int
+
+	// lock protects the fields below.
+	lock sync.Mutex
+
+	// concurrency is the current limit on batches in flight,
+	// between 1 and maxConcurrency.
+	concurrency float64
+	// batchSize is the current target batch size, between
+	// cfg.MinBatchSize and cfg.MaxBatchSize.
+	batchSize int
+	// inflight is the number of batches that were sent and are
+	// waiting for a response.
+	inflight int
+	// waiters are closed when a batch completes.
+	waiters []chan struct{}
+	// lastDecrease prevents more than one decrease per latency
+	// target interval, since all batches in flight at the time of
+	// congestion will tend to report it.
+	lastDecrease time.Time
+
+	registration metric.Registration
+}
+
+// newAdaptiveController returns a controller that starts with one
+// batch in flight and the minimum batch size.  The controller is
+// usable even when its metric instruments fail to register.
+func newAdaptiveController(cfg AdaptiveConfig, maxConcurrency int, meter metric.Meter) (*adaptiveController, error) {
+	ac := &adaptiveController{
+		cfg:            cfg,
+		maxConcurrency: maxConcurrency,
+		concurrency:    1,
+		batchSize:      cfg.MinBatchSize,
+	}
+
+	concurrency, err1 := meter.Int64ObservableGauge("exporter_arrow_concurrency",
+		metric.WithDescription("Current limit on the number of Arrow batches in flight."))
+	batchSize, err2 := meter.Int64ObservableGauge("exporter_arrow_batch_size",
+		metric.WithDescription("Current target number of items per Arrow batch."))
+	if err := multierr.Append(err1, err2); err != nil {
+		return ac, err
+	}
+
+	reg, err := meter.RegisterCallback(func(_ context.Context, obs metric.Observer) error {
+		limit, size := ac.operatingPoint()
+		obs.ObserveInt64(concurrency, int64(limit))
+		obs.ObserveInt64(batchSize, int64(size))
+		return nil
+	}, concurrency, batchSize)
+	if err != nil {
+		return ac, err
+	}
+	ac.registration = reg
+	return ac, nil
+}
+
+// operatingPoint returns the current concurrency limit and target
+// batch size.
+func (ac *adaptiveController) operatingPoint() (concurrency, batchSize int) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	return int(ac.concurrency), ac.batchSize
+}
+
+// acquire blocks until a batch may be sent or the context is done.
+func (ac *adaptiveController) acquire(ctx context.Context) error {
+	for {
+		ac.lock.Lock()
+		if ac.inflight < int(ac.concurrency) {
+			ac.inflight++
+			ac.lock.Unlock()
+			return nil
+		}
+		wait := make(chan struct{})
+		ac.waiters = append(ac.waiters, wait)
+		ac.lock.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release completes a batch acquired by acquire(), adjusting the
+// operating point from its acknowledgement latency and whether the
+// receiver asked to slow down.
+func (ac *adaptiveController) release(latency time.Duration, slowDown bool) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	ac.inflight--
+
+	now := time.Now()
+	if slowDown || latency > ac.cfg.LatencyTarget {
+		if now.Sub(ac.lastDecrease) >= ac.cfg.LatencyTarget {
+			ac.lastDecrease = now
+			ac.concurrency = maxFloat(1, ac.concurrency/2)
+			ac.batchSize = maxInt(ac.cfg.MinBatchSize, ac.batchSize/2)
+		}
+	} else {
+		// Increase by about one batch and one minimum-size
+		// step per window of acknowledgements.
+		ac.concurrency = minFloat(float64(ac.maxConcurrency), ac.concurrency+1/ac.concurrency)
+		ac.batchSize = minInt(ac.cfg.MaxBatchSize, ac.batchSize+maxInt(1, ac.cfg.MinBatchSize/int(ac.concurrency)))
+	}
+
+	for _, wait := range ac.waiters {
+		close(wait)
+	}
+	ac.waiters = nil
+}
+
+// shutdown unregisters the operating point metrics.
+func (ac *adaptiveController) shutdown() error {
+	if ac.registration == nil {
+		return nil
+	}
+	return ac.registration.Unregister()
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
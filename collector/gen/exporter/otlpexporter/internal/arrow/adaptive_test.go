@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func newTestAdaptiveController(t *testing.T, maxConcurrency int) *adaptiveController {
+	ac, err := newAdaptiveController(AdaptiveConfig{
+		LatencyTarget: time.Minute,
+		MinBatchSize:  10,
+		MaxBatchSize:  100,
+	}, maxConcurrency, noop.NewMeterProvider().Meter("test"))
+	require.NoError(t, err)
+	return ac
+}
+
+func TestAdaptiveIncreaseDecrease(t *testing.T) {
+	ac := newTestAdaptiveController(t, 4)
+	ctx := context.Background()
+
+	concurrency, size := ac.operatingPoint()
+	require.Equal(t, 1, concurrency)
+	require.Equal(t, 10, size)
+
+	// Fast acknowledgements increase both limits up to their maximum.
+	for i := 0; i < 100; i++ {
+		require.NoError(t, ac.acquire(ctx))
+		ac.release(time.Millisecond, false)
+	}
+	concurrency, size = ac.operatingPoint()
+	require.Equal(t, 4, concurrency)
+	require.Equal(t, 100, size)
+
+	// A slow-down response halves both limits.
+	require.NoError(t, ac.acquire(ctx))
+	ac.release(time.Millisecond, true)
+	concurrency, size = ac.operatingPoint()
+	require.Equal(t, 2, concurrency)
+	require.Equal(t, 50, size)
+
+	// A second signal within the latency target is ignored.
+	require.NoError(t, ac.acquire(ctx))
+	ac.release(2*time.Minute, false)
+	concurrency, size = ac.operatingPoint()
+	require.Equal(t, 2, concurrency)
+	require.Equal(t, 50, size)
+
+	require.NoError(t, ac.shutdown())
+}
+
+func TestAdaptiveAcquireBlocks(t *testing.T) {
+	ac := newTestAdaptiveController(t, 4)
+
+	require.NoError(t, ac.acquire(context.Background()))
+
+	// The initial concurrency is one, so this times out.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, ac.acquire(ctx), context.DeadlineExceeded)
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- ac.acquire(context.Background())
+	}()
+	ac.release(time.Millisecond, false)
+	require.NoError(t, <-acquired)
+}
+
+func TestSplitData(t *testing.T) {
+	traces := splitData(testdata.GenerateTraces(25), 10, false)
+	require.Len(t, traces, 3)
+	require.Equal(t, 10, traces[0].(ptrace.Traces).SpanCount())
+	require.Equal(t, 5, traces[2].(ptrace.Traces).SpanCount())
+
+	logs := splitData(testdata.GenerateLogs(20), 10, false)
+	require.Len(t, logs, 2)
+	require.Equal(t, 10, logs[1].(plog.Logs).LogRecordCount())
+
+	metrics := splitData(testdata.GenerateMetrics(7), 3, false)
+	require.Len(t, metrics, 3)
+	require.Equal(t, 1, metrics[2].(pmetric.Metrics).MetricCount())
+
+	// Small data is not copied.
+	small := testdata.GenerateTraces(2)
+	require.Equal(t, []interface{}{small}, splitData(small, 10, false))
+}
+
+func TestSplitDataWholeResources(t *testing.T) {
+	td := ptrace.NewTraces()
+	for _, spans := range []int{4, 4, 12, 3} {
+		testdata.GenerateTraces(spans).ResourceSpans().MoveAndAppendTo(td.ResourceSpans())
+	}
+
+	// Resources are never divided, the oversized one is sent alone.
+	traces := splitData(td, 10, true)
+	require.Len(t, traces, 3)
+	for i, spans := range []int{8, 12, 3} {
+		require.Equal(t, spans, traces[i].(ptrace.Traces).SpanCount())
+	}
+	require.Equal(t, 2, traces[0].(ptrace.Traces).ResourceSpans().Len())
+
+	ld := plog.NewLogs()
+	for _, records := range []int{6, 6} {
+		testdata.GenerateLogs(records).ResourceLogs().MoveAndAppendTo(ld.ResourceLogs())
+	}
+	logs := splitData(ld, 10, true)
+	require.Len(t, logs, 2)
+	require.Equal(t, 6, logs[1].(plog.Logs).LogRecordCount())
+
+	md := pmetric.NewMetrics()
+	for _, metrics := range []int{2, 2, 2} {
+		testdata.GenerateMetrics(metrics).ResourceMetrics().MoveAndAppendTo(md.ResourceMetrics())
+	}
+	metrics := splitData(md, 5, true)
+	require.Len(t, metrics, 2)
+	require.Equal(t, 4, metrics[0].(pmetric.Metrics).MetricCount())
+}
@@ -0,0 +1,52 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"fmt"
+)
+
+// EncodeFailurePolicy determines what the exporter does with a batch
+// the stream could not encode, whether the Arrow producer failed (or
+// panicked) or its headers could not be encoded.  Transport errors
+// are not subject to this policy.
+type EncodeFailurePolicy string
+
+const (
+	// EncodeFailureDrop counts the batch as an encode failure and
+	// returns a permanent error, so the batch is not retried.
+	EncodeFailureDrop EncodeFailurePolicy = "drop"
+
+	// EncodeFailureBlock retries the batch on the next available
+	// stream, which uses a new producer, until it is sent or the
+	// caller's context is done.
+	EncodeFailureBlock EncodeFailurePolicy = "block"
+
+	// EncodeFailureFallback sends the batch using standard OTLP.
+	EncodeFailureFallback EncodeFailurePolicy = "fallback"
+)
+
+// Validate returns an error for an unrecognized policy.  The empty
+// policy is equivalent to EncodeFailureDrop.
+func (p EncodeFailurePolicy) Validate() error {
+	switch p {
+	case "", EncodeFailureDrop, EncodeFailureBlock, EncodeFailureFallback:
+		return nil
+	}
+	return fmt.Errorf("unrecognized encode failure policy: %q", p)
+}
+
+// encodeError is returned by a stream to the sender of a batch it
+// could not encode.  The stream restarts after an encode error.
+type encodeError struct {
+	err error
+}
+
+func (e *encodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *encodeError) Unwrap() error {
+	return e.err
+}
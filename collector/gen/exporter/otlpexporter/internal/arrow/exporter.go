@@ -7,13 +7,17 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamid"
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamparams"
 	"go.opentelemetry.io/collector/component"
 )
 
@@ -44,6 +48,34 @@ type Exporter struct {
 	// perRPCCredentials derived from the exporter's gRPC auth settings.
 	perRPCCredentials credentials.PerRPCCredentials
 
+	// adaptive adjusts the send concurrency and batch size, or is
+	// nil when adaptive batching is not configured.
+	adaptive *adaptiveController
+
+	// warmupConfig configures the coalescing of batches after
+	// the exporter starts, or is nil when warm-up is not
+	// configured.
+	warmupConfig *WarmupConfig
+
+	// warmup is the coalescer started by Start(), or nil.
+	warmup *warmupCoalescer
+
+	// encodeFailure is the policy for batches that a stream
+	// could not encode.
+	encodeFailure EncodeFailurePolicy
+
+	// hints accumulates the producer hints sent by the receiver,
+	// or is nil when they are ignored.
+	hints *HintState
+
+	// encodeFailures counts the batches that a stream could not
+	// encode, or is nil when the instrument failed to register.
+	encodeFailures metric.Int64Counter
+
+	// sizes records the serialized size of the batches and their
+	// payloads, or is nil when the instruments failed to register.
+	sizes *sizeMetrics
+
 	// returning is used to pass broken, gracefully-terminated,
 	// and otherwise to the stream controller.
 	returning chan *Stream
@@ -97,8 +129,12 @@ func NewExporter(
 	newProducer func() arrowRecord.ProducerAPI,
 	streamClient StreamClientFunc,
 	perRPCCredentials credentials.PerRPCCredentials,
+	adaptive *AdaptiveConfig,
+	warmup *WarmupConfig,
+	encodeFailure EncodeFailurePolicy,
+	hints *HintState,
 ) *Exporter {
-	return &Exporter{
+	e := &Exporter{
 		numStreams:        numStreams,
 		disableDowngrade:  disableDowngrade,
 		telemetry:         telemetry,
@@ -106,8 +142,33 @@ func NewExporter(
 		newProducer:       newProducer,
 		streamClient:      streamClient,
 		perRPCCredentials: perRPCCredentials,
+		warmupConfig:      warmup,
+		encodeFailure:     encodeFailure,
+		hints:             hints,
 		returning:         make(chan *Stream, numStreams),
 	}
+	encodeFailures, err := telemetry.MeterProvider.Meter(meterScopeName).Int64Counter("exporter_arrow_encode_failures",
+		metric.WithDescription("Number of batches that could not be encoded by an Arrow stream."))
+	if err != nil {
+		telemetry.Logger.Error("arrow encode failure metrics", zap.Error(err))
+	} else {
+		e.encodeFailures = encodeFailures
+	}
+	sizes, err := newSizeMetrics(telemetry.MeterProvider.Meter(meterScopeName))
+	if err != nil {
+		telemetry.Logger.Error("arrow size metrics", zap.Error(err))
+	} else {
+		e.sizes = sizes
+	}
+	if adaptive != nil {
+		ac, err := newAdaptiveController(*adaptive, numStreams, telemetry.MeterProvider.Meter(meterScopeName))
+		if err != nil {
+			// Adaptive batching is still used, without its metrics.
+			telemetry.Logger.Error("arrow adaptive batching metrics", zap.Error(err))
+		}
+		e.adaptive = ac
+	}
+	return e
 }
 
 // Start creates the background context used by all streams and starts
@@ -119,6 +180,10 @@ func (e *Exporter) Start(ctx context.Context) error {
 	e.wg.Add(1)
 	e.ready = newStreamPrioritizer(ctx, e.numStreams)
 
+	if e.warmupConfig != nil {
+		e.warmup = newWarmupCoalescer(*e.warmupConfig, time.Now())
+	}
+
 	go e.runStreamController(ctx)
 
 	return nil
@@ -134,19 +199,21 @@ func (e *Exporter) runStreamController(bgctx context.Context) {
 
 	running := e.numStreams
 
-	// Start the initial number of streams
+	// Start the initial number of streams, each with a new
+	// identity.
 	for i := 0; i < running; i++ {
 		e.wg.Add(1)
-		go e.runArrowStream(bgctx)
+		go e.runArrowStream(bgctx, streamid.New())
 	}
 
 	for {
 		select {
 		case stream := <-e.returning:
 			if stream.client != nil || e.disableDowngrade {
-				// The stream closed or broken.  Restart it.
+				// The stream closed or broken.  Restart it,
+				// keeping its identity.
 				e.wg.Add(1)
-				go e.runArrowStream(bgctx)
+				go e.runArrowStream(bgctx, stream.identity)
 				continue
 			}
 			// Otherwise, the stream never got started.  It was
@@ -171,10 +238,13 @@ func (e *Exporter) runStreamController(bgctx context.Context) {
 // If the stream connection is successful, this goroutine starts another goroutine
 // to call writeStream() and performs readStream() itself.  When the stream shuts
 // down this call synchronously waits for and unblocks the consumers.
-func (e *Exporter) runArrowStream(ctx context.Context) {
+// The identity is sent to the receiver when the stream is established.
+func (e *Exporter) runArrowStream(ctx context.Context, identity string) {
 	producer := e.newProducer()
 
-	stream := newStream(producer, e.ready, e.telemetry, e.perRPCCredentials)
+	stream := newStream(producer, e.ready, e.telemetry, e.perRPCCredentials, identity, e.hints)
+	stream.sizes = e.sizes
+	stream.params = e.streamParams()
 
 	defer func() {
 		if err := producer.Close(); err != nil {
@@ -187,6 +257,20 @@ func (e *Exporter) runArrowStream(ctx context.Context) {
 	stream.run(ctx, e.streamClient, e.grpcOptions)
 }
 
+// streamParams returns the parameters declared to the receiver when a
+// stream is established.
+func (e *Exporter) streamParams() streamparams.Params {
+	var params streamparams.Params
+	if e.hints != nil {
+		params.Features = append(params.Features, streamparams.FeatureHints)
+	}
+	if e.adaptive != nil {
+		params.Features = append(params.Features, streamparams.FeatureAdaptive)
+		params.MaxBatchSize = e.adaptive.cfg.MaxBatchSize
+	}
+	return params
+}
+
 // SendAndWait tries to send using an Arrow stream.  The results are:
 //
 // (true, nil):      Arrow send: success at consumer
@@ -195,7 +279,72 @@ func (e *Exporter) runArrowStream(ctx context.Context) {
 // (false, non-nil): Context timeout prevents retry.
 //
 // consumer should fall back to standard OTLP, (true, nil)
+//
+// A batch that cannot be encoded is handled according to the
+// EncodeFailurePolicy, the fallback policy returns (false, nil).
+//
+// When adaptive batching is configured, the data is split into
+// batches of the current target size, which are sent one at a time
+// subject to the current concurrency limit.  With whole resources,
+// the data is only split between resources.
+//
+// When part of the data was accepted, a non-nil error carries the
+// remaining data (see RemainingData), and a fallback returns
+// (false, ErrFallback) with the remaining data.
+//
+// When warm-up is configured, batches sent concurrently during the
+// warm-up period are first coalesced into larger batches.
 func (e *Exporter) SendAndWait(ctx context.Context, data interface{}) (bool, error) {
+	if e.warmup != nil && e.warmup.active(time.Now()) {
+		return e.warmup.sendAndWait(ctx, data, e.sendBatches)
+	}
+	return e.sendBatches(ctx, data)
+}
+
+// sendBatches sends the data as one batch, or in parts when adaptive
+// batching is configured.  After a failure, only the parts that were
+// not accepted are returned to the caller.
+func (e *Exporter) sendBatches(ctx context.Context, data interface{}) (bool, error) {
+	if e.adaptive == nil {
+		return e.sendAndWait(ctx, data)
+	}
+	_, size := e.adaptive.operatingPoint()
+	parts := splitData(data, size, e.adaptive.cfg.WholeResources)
+	for i, part := range parts {
+		if err := e.adaptive.acquire(ctx); err != nil {
+			if i != 0 {
+				err = partialError(err, mergeData(parts[i:]))
+			}
+			return false, err // a Context error
+		}
+		start := time.Now()
+		sent, err := e.sendAndWait(ctx, part)
+		e.adaptive.release(time.Since(start), errors.Is(err, ErrDestinationUnavailable))
+
+		if sent && err == nil {
+			continue
+		}
+		remaining, partial := remainingData(err)
+		if i == 0 && !partial {
+			return sent, err // none of the data was accepted
+		}
+		if !partial {
+			remaining = part
+		}
+		if err == nil {
+			err = ErrFallback // a fallback after accepted parts
+		}
+		unsent := append([]interface{}{remaining}, parts[i+1:]...)
+		return sent, partialError(err, mergeData(unsent))
+	}
+	return true, nil
+}
+
+// sendAndWait sends one batch using the first-available stream.
+func (e *Exporter) sendAndWait(ctx context.Context, data interface{}) (bool, error) {
+	// partial is set when a stream restarted after accepting
+	// part of the data, data being the remaining data.
+	partial := false
 	for {
 		var stream *Stream
 		var err error
@@ -206,17 +355,38 @@ func (e *Exporter) SendAndWait(ctx context.Context, data interface{}) (bool, err
 		}
 
 		if err != nil {
+			if partial {
+				err = partialError(err, data)
+			}
 			return false, err // a Context error
 		}
 		if stream == nil {
-			return false, nil // a downgraded connection
+			return false, fallbackError(partial, data) // a downgraded connection
 		}
 
 		err = stream.SendAndWait(ctx, data)
 		if err != nil && errors.Is(err, ErrStreamRestarting) {
+			if remaining, ok := remainingData(err); ok {
+				data, partial = remaining, true
+			}
 			continue // an internal retry
 
 		}
+		var encErr *encodeError
+		if errors.As(err, &encErr) {
+			if e.encodeFailures != nil {
+				e.encodeFailures.Add(ctx, 1)
+			}
+			switch e.encodeFailure {
+			case EncodeFailureBlock:
+				continue // retried on a new stream
+			case EncodeFailureFallback:
+				return false, fallbackError(partial, data) // standard OTLP
+			}
+		}
+		if _, ok := remainingData(err); err != nil && partial && !ok {
+			err = partialError(err, data)
+		}
 		// result from arrow server (may be nil, may be
 		// permanent, etc.)
 		return true, err
@@ -227,5 +397,8 @@ func (e *Exporter) SendAndWait(ctx context.Context, data interface{}) (bool, err
 func (e *Exporter) Shutdown(_ context.Context) error {
 	e.cancel()
 	e.wg.Wait()
+	if e.adaptive != nil {
+		return e.adaptive.shutdown()
+	}
 	return nil
 }
@@ -24,7 +24,9 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamid"
 	"github.com/f5/otel-arrow-adapter/collector/gen/internal/testdata"
+	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -136,7 +138,7 @@ func newExporterTestCaseCommon(t *testing.T, noisy noisyTest, numStreams int, di
 			copyBatch(prod.BatchArrowRecordsFromMetrics))
 		mock.EXPECT().Close().Times(1).Return(nil)
 		return mock
-	}, ctc.streamClient, ctc.perRPCCredentials)
+	}, ctc.streamClient, ctc.perRPCCredentials, nil, nil, EncodeFailureDrop, nil)
 
 	return &exporterTestCase{
 		commonTestCase: ctc,
@@ -144,6 +146,34 @@ func newExporterTestCaseCommon(t *testing.T, noisy noisyTest, numStreams int, di
 	}
 }
 
+// newEncodeFailureTestCase returns a single stream test case using
+// the policy, whose producers fail to encode the first `failures`
+// traces batches.
+func newEncodeFailureTestCase(t *testing.T, policy EncodeFailurePolicy, failures int) *exporterTestCase {
+	tc := newSingleStreamTestCase(t)
+	tc.exporter.encodeFailure = policy
+
+	var lock sync.Mutex
+	tc.exporter.newProducer = func() arrowRecord.ProducerAPI {
+		mock := arrowRecordMock.NewMockProducerAPI(tc.ctrl)
+		encode := copyBatch(arrowRecord.NewProducer().BatchArrowRecordsFromTraces)
+
+		mock.EXPECT().BatchArrowRecordsFromTraces(gomock.Any()).AnyTimes().DoAndReturn(
+			func(td ptrace.Traces) (*arrowpb.BatchArrowRecords, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				if failures > 0 {
+					failures--
+					return nil, fmt.Errorf("test encode error")
+				}
+				return encode(td)
+			})
+		mock.EXPECT().Close().Times(1).Return(nil)
+		return mock
+	}
+	return tc
+}
+
 func statusOKFor(id int64) *arrowpb.BatchStatus {
 	return &arrowpb.BatchStatus{
 		BatchId:    id,
@@ -404,6 +434,206 @@ func TestArrowExporterStreamFailure(t *testing.T) {
 	require.NoError(t, tc.exporter.Shutdown(bg))
 }
 
+// TestArrowExporterStreamIdentity tests that a restarted stream
+// keeps the identity it was established with.
+func TestArrowExporterStreamIdentity(t *testing.T) {
+	tc := newSingleStreamTestCase(t)
+	channel0 := newUnresponsiveTestChannel()
+	channel1 := newHealthyTestChannel()
+
+	var lock sync.Mutex
+	var identities []string
+	newStream := tc.returnNewStream(channel0, channel1)
+	tc.streamCall.AnyTimes().DoAndReturn(func(ctx context.Context, opts ...grpc.CallOption) (
+		arrowpb.ArrowStreamService_ArrowStreamClient,
+		error,
+	) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		lock.Lock()
+		identities = append(identities, md.Get(streamid.Header)...)
+		lock.Unlock()
+		return newStream(ctx, opts...)
+	})
+
+	bg := context.Background()
+	require.NoError(t, tc.exporter.Start(bg))
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		channel0.unblock()
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		outputData := <-channel1.sent
+		channel1.recv <- statusOKFor(outputData.BatchId)
+	}()
+
+	sent, err := tc.exporter.SendAndWait(bg, twoTraces)
+	require.NoError(t, err)
+	require.True(t, sent)
+
+	wg.Wait()
+
+	require.NoError(t, tc.exporter.Shutdown(bg))
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.GreaterOrEqual(t, len(identities), 2)
+	require.NotEmpty(t, identities[0])
+	for _, id := range identities {
+		require.Equal(t, identities[0], id)
+	}
+}
+
+// TestArrowExporterEncodeFailure tests the policies for batches
+// that cannot be encoded.
+func TestArrowExporterEncodeFailure(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		tc := newEncodeFailureTestCase(t, EncodeFailureDrop, 1)
+		tc.streamCall.AnyTimes().DoAndReturn(tc.returnNewStream(newHealthyTestChannel()))
+
+		bg := context.Background()
+		require.NoError(t, tc.exporter.Start(bg))
+
+		sent, err := tc.exporter.SendAndWait(bg, twoTraces)
+		require.True(t, sent)
+		require.Error(t, err)
+		require.True(t, consumererror.IsPermanent(err))
+		require.Contains(t, err.Error(), "test encode error")
+
+		require.NoError(t, tc.exporter.Shutdown(bg))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		tc := newEncodeFailureTestCase(t, EncodeFailureFallback, 1)
+		tc.streamCall.AnyTimes().DoAndReturn(tc.returnNewStream(newHealthyTestChannel()))
+
+		bg := context.Background()
+		require.NoError(t, tc.exporter.Start(bg))
+
+		sent, err := tc.exporter.SendAndWait(bg, twoTraces)
+		require.False(t, sent)
+		require.NoError(t, err)
+
+		require.NoError(t, tc.exporter.Shutdown(bg))
+	})
+
+	t.Run("block", func(t *testing.T) {
+		tc := newEncodeFailureTestCase(t, EncodeFailureBlock, 1)
+		channel0 := newHealthyTestChannel()
+		channel1 := newHealthyTestChannel()
+		tc.streamCall.AnyTimes().DoAndReturn(tc.returnNewStream(channel0, channel1))
+
+		bg := context.Background()
+		require.NoError(t, tc.exporter.Start(bg))
+
+		// The first stream fails to encode the batch and
+		// restarts, the batch is sent on the second one.
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outputData := <-channel1.sent
+			channel1.recv <- statusOKFor(outputData.BatchId)
+		}()
+
+		sent, err := tc.exporter.SendAndWait(bg, twoTraces)
+		require.True(t, sent)
+		require.NoError(t, err)
+
+		wg.Wait()
+
+		require.NoError(t, tc.exporter.Shutdown(bg))
+	})
+}
+
+// TestArrowExporterPartialFailure verifies that only the parts of the
+// data that were not accepted are retried or sent with standard OTLP.
+func TestArrowExporterPartialFailure(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		tc := newSingleStreamTestCase(t)
+		tc.exporter.adaptive = newTestAdaptiveController(t, 1)
+		channel := newHealthyTestChannel()
+		tc.streamCall.AnyTimes().DoAndReturn(tc.returnNewStream(channel))
+
+		bg := context.Background()
+		require.NoError(t, tc.exporter.Start(bg))
+
+		// The 25 spans are sent in parts of 10, 10 and 5
+		// spans, the second part is rejected.
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first := <-channel.sent
+			channel.recv <- statusOKFor(first.BatchId)
+			second := <-channel.sent
+			channel.recv <- statusInvalidFor(second.BatchId)
+		}()
+
+		sent, err := tc.exporter.SendAndWait(bg, testdata.GenerateTraces(25))
+		wg.Wait()
+		require.True(t, sent)
+		require.True(t, consumererror.IsPermanent(err))
+
+		var remaining consumererror.Traces
+		require.True(t, errors.As(err, &remaining))
+		require.Equal(t, 15, remaining.Data().SpanCount())
+
+		require.NoError(t, tc.exporter.Shutdown(bg))
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		tc := newSingleStreamTestCase(t)
+		tc.exporter.adaptive = newTestAdaptiveController(t, 1)
+		tc.exporter.encodeFailure = EncodeFailureFallback
+		tc.exporter.newProducer = func() arrowRecord.ProducerAPI {
+			mock := arrowRecordMock.NewMockProducerAPI(tc.ctrl)
+			encode := copyBatch(arrowRecord.NewProducer().BatchArrowRecordsFromTraces)
+
+			// The last part fails to encode.
+			mock.EXPECT().BatchArrowRecordsFromTraces(gomock.Any()).AnyTimes().DoAndReturn(
+				func(td ptrace.Traces) (*arrowpb.BatchArrowRecords, error) {
+					if td.SpanCount() < 10 {
+						return nil, fmt.Errorf("test encode error")
+					}
+					return encode(td)
+				})
+			mock.EXPECT().Close().AnyTimes().Return(nil)
+			return mock
+		}
+		// The stream restarts after the encoding failure.
+		channel := newHealthyTestChannel()
+		tc.streamCall.AnyTimes().DoAndReturn(tc.repeatedNewStream(func() testChannel {
+			return channel
+		}))
+
+		bg := context.Background()
+		require.NoError(t, tc.exporter.Start(bg))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2; i++ {
+				outputData := <-channel.sent
+				channel.recv <- statusOKFor(outputData.BatchId)
+			}
+		}()
+
+		sent, err := tc.exporter.SendAndWait(bg, testdata.GenerateTraces(25))
+		wg.Wait()
+		require.False(t, sent)
+		require.ErrorIs(t, err, ErrFallback)
+		require.Equal(t, 5, RemainingData(err, nil).(ptrace.Traces).SpanCount())
+
+		require.NoError(t, tc.exporter.Shutdown(bg))
+	})
+}
+
 // TestArrowExporterStreamRace reproduces the situation needed for a
 // race between stream send and stream cancel, causing it to fully
 // exercise the removeReady() code path.
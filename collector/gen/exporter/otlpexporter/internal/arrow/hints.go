@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"errors"
+	"sync"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+)
+
+// errReceiverHint is returned by a stream reader when the receiver
+// sent a hint that requires a new producer.  The stream restarts.
+var errReceiverHint = errors.New("receiver hint")
+
+// HintState accumulates the producer hints sent by the receiver in
+// its batch statuses.  It is shared by the streams of an Exporter.
+type HintState struct {
+	lock sync.Mutex
+
+	// plainFields are the fields the receiver asked to not
+	// dictionary encode.
+	plainFields map[string]struct{}
+}
+
+// NewHintState returns an empty HintState.
+func NewHintState() *HintState {
+	return &HintState{
+		plainFields: map[string]struct{}{},
+	}
+}
+
+// observe records the hints of a batch status and returns true when
+// the stream should restart with a new producer, i.e., when the
+// receiver asked for a dictionary reset or for a new plain field.
+func (h *HintState) observe(status *arrowpb.BatchStatus) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	restart := status.ResetDictionaries
+	for _, field := range status.PlainEncodingFields {
+		if _, ok := h.plainFields[field]; ok {
+			continue
+		}
+		h.plainFields[field] = struct{}{}
+		restart = true
+	}
+	return restart
+}
+
+// EncodingOverrides returns the configured overrides merged with the
+// plain encoding fields hinted so far.  The configured overrides take
+// precedence.
+func (h *HintState) EncodingOverrides(configured map[string]string) map[string]string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.plainFields) == 0 {
+		return configured
+	}
+	overrides := make(map[string]string, len(configured)+len(h.plainFields))
+	for field := range h.plainFields {
+		overrides[field] = schema.PlainEncoding
+	}
+	for field, encoding := range configured {
+		overrides[field] = encoding
+	}
+	return overrides
+}
@@ -0,0 +1,38 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+)
+
+func TestHintStateObserve(t *testing.T) {
+	hints := NewHintState()
+
+	require.False(t, hints.observe(&arrowpb.BatchStatus{}))
+	require.True(t, hints.observe(&arrowpb.BatchStatus{ResetDictionaries: true}))
+	require.True(t, hints.observe(&arrowpb.BatchStatus{PlainEncodingFields: []string{"spans.name"}}))
+
+	// A known field does not restart the stream.
+	require.False(t, hints.observe(&arrowpb.BatchStatus{PlainEncodingFields: []string{"spans.name"}}))
+}
+
+func TestHintStateEncodingOverrides(t *testing.T) {
+	hints := NewHintState()
+	configured := map[string]string{"spans.name": "dictionary16"}
+
+	require.Equal(t, configured, hints.EncodingOverrides(configured))
+
+	hints.observe(&arrowpb.BatchStatus{PlainEncodingFields: []string{"spans.name", "logs.body"}})
+
+	// The configured overrides take precedence.
+	require.Equal(t, map[string]string{
+		"spans.name": "dictionary16",
+		"logs.body":  "plain",
+	}, hints.EncodingOverrides(configured))
+}
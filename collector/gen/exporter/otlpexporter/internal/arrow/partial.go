@@ -0,0 +1,98 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Data split in several batches may be partially accepted, in which
+// case the error returned to the caller carries the remaining data,
+// the batches which were not accepted, as a consumererror.Traces,
+// consumererror.Logs or consumererror.Metrics.  The exporterhelper
+// retries the remaining data only, and the batches already accepted
+// are not sent twice.
+
+// ErrFallback is returned by Exporter.SendAndWait, with the remaining
+// data (see RemainingData), when a downgrade or an encoding failure
+// happens after part of the data was sent with Arrow.  The caller is
+// expected to send the remaining data with standard OTLP.
+var ErrFallback = errors.New("arrow: fall back with the remaining data")
+
+// partialError returns err carrying the remaining data.
+func partialError(err error, remaining interface{}) error {
+	switch data := remaining.(type) {
+	case ptrace.Traces:
+		return consumererror.NewTraces(err, data)
+	case plog.Logs:
+		return consumererror.NewLogs(err, data)
+	case pmetric.Metrics:
+		return consumererror.NewMetrics(err, data)
+	}
+	return err
+}
+
+// fallbackError returns the error of a fallback to standard OTLP: nil
+// when none of the data was sent, for the caller to send all of it,
+// otherwise ErrFallback with the remaining data.
+func fallbackError(partial bool, remaining interface{}) error {
+	if !partial {
+		return nil
+	}
+	return partialError(ErrFallback, remaining)
+}
+
+// remainingData returns the remaining data carried by err, if any.
+func remainingData(err error) (interface{}, bool) {
+	var te consumererror.Traces
+	if errors.As(err, &te) {
+		return te.Data(), true
+	}
+	var le consumererror.Logs
+	if errors.As(err, &le) {
+		return le.Data(), true
+	}
+	var me consumererror.Metrics
+	if errors.As(err, &me) {
+		return me.Data(), true
+	}
+	return nil, false
+}
+
+// withoutRemainingData returns err without the remaining data it
+// carries.
+func withoutRemainingData(err error) error {
+	for {
+		switch err.(type) {
+		case consumererror.Traces, consumererror.Logs, consumererror.Metrics:
+			err = errors.Unwrap(err)
+		default:
+			return err
+		}
+	}
+}
+
+// RemainingData returns the remaining data carried by err, or data
+// when err carries none.
+func RemainingData(err error, data interface{}) interface{} {
+	if remaining, ok := remainingData(err); ok {
+		return remaining
+	}
+	return data
+}
+
+// mergeData returns the parts of a ptrace.Traces, plog.Logs, or
+// pmetric.Metrics merged in a new one.
+func mergeData(parts []interface{}) interface{} {
+	merged := copyData(parts[0])
+	for _, part := range parts[1:] {
+		appendData(merged, part)
+	}
+	return merged
+}
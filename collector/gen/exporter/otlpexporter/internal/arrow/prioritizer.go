@@ -5,6 +5,7 @@ package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter
 
 import (
 	"context"
+	"errors"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -12,6 +13,11 @@ import (
 
 var ErrStreamRestarting = status.Error(codes.Aborted, "stream is restarting")
 
+// ErrDestinationUnavailable is wrapped by the error returned for an
+// UNAVAILABLE batch status, which the receiver uses to ask the
+// exporter to slow down.
+var ErrDestinationUnavailable = errors.New("destination unavailable")
+
 // streamPrioritizer is a placeholder for a configurable mechanism
 // that selects the next stream to write.
 type streamPrioritizer struct {
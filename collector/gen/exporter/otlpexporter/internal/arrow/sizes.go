@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+)
+
+// sizeMetrics records the distribution of the serialized size of the
+// batches and of their payloads, so the message-size limits and the
+// admission thresholds can be chosen from the observed p50/p95/p99
+// rather than from an average.
+//
+// The receiver reports the size of the decoded batches in their
+// status, which completes the picture: the decoded bytes divided by
+// the exporter_sent_wire bytes of the network statistics is the
+// compression ratio achieved end-to-end, including the gRPC-level
+// compression.
+type sizeMetrics struct {
+	payloadSize      metric.Int64Histogram
+	batchSize        metric.Int64Histogram
+	decodedBytes     metric.Int64Counter
+	compressionRatio metric.Float64Histogram
+}
+
+func newSizeMetrics(meter metric.Meter) (*sizeMetrics, error) {
+	payloadSize, err := meter.Int64Histogram("exporter_arrow_payload_size",
+		metric.WithDescription("Serialized size of the Arrow payloads, by payload type."),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	batchSize, err := meter.Int64Histogram("exporter_arrow_batch_size",
+		metric.WithDescription("Serialized size of the Arrow batches, i.e. the sum of the sizes of their payloads."),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	decodedBytes, err := meter.Int64Counter("exporter_arrow_decoded_bytes",
+		metric.WithDescription("Size of the OTLP representation of the batches once decoded, as reported by the receiver."),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+	compressionRatio, err := meter.Float64Histogram("exporter_arrow_compression_ratio",
+		metric.WithDescription("Ratio of the decoded size reported by the receiver to the serialized size of the Arrow batch."),
+		metric.WithUnit("1"))
+	if err != nil {
+		return nil, err
+	}
+	return &sizeMetrics{
+		payloadSize:      payloadSize,
+		batchSize:        batchSize,
+		decodedBytes:     decodedBytes,
+		compressionRatio: compressionRatio,
+	}, nil
+}
+
+// record adds the sizes of the batch and returns its serialized size,
+// it is a no-op returning 0 on a nil receiver.
+func (m *sizeMetrics) record(ctx context.Context, batch *arrowpb.BatchArrowRecords) int64 {
+	if m == nil {
+		return 0
+	}
+	total := 0
+	for _, payload := range batch.ArrowPayloads {
+		size := len(payload.Record)
+		total += size
+		m.payloadSize.Record(ctx, int64(size), metric.WithAttributes(attribute.String("payload_type", payload.Type.String())))
+	}
+	m.batchSize.Record(ctx, int64(total))
+	return int64(total)
+}
+
+// recordDecoded adds the decoded size reported by the receiver for a
+// batch of the given serialized size.  It is a no-op on a nil
+// receiver or when the receiver does not report the decoded size.
+func (m *sizeMetrics) recordDecoded(ctx context.Context, sent int64, status *arrowpb.BatchStatus) {
+	if m == nil || status.DecodedBytes <= 0 {
+		return
+	}
+	m.decodedBytes.Add(ctx, status.DecodedBytes)
+	if sent > 0 {
+		m.compressionRatio.Record(ctx, float64(status.DecodedBytes)/float64(sent))
+	}
+}
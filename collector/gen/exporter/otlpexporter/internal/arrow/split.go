@@ -0,0 +1,246 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// splitData splits a ptrace.Traces, plog.Logs, or pmetric.Metrics into
+// parts of at most size items (spans, log records, or metrics).
+// Data that is already small enough, or of another type, is returned
+// as-is.
+//
+// When wholeResources is set, a resource is never divided between two
+// parts: a part ends before a resource that would overflow it, and a
+// resource of more than size items forms its own part.
+func splitData(data interface{}, size int, wholeResources bool) []interface{} {
+	switch data := data.(type) {
+	case ptrace.Traces:
+		if data.SpanCount() > size {
+			if wholeResources {
+				return splitTracesByResource(data, size)
+			}
+			return splitTraces(data, size)
+		}
+	case plog.Logs:
+		if data.LogRecordCount() > size {
+			if wholeResources {
+				return splitLogsByResource(data, size)
+			}
+			return splitLogs(data, size)
+		}
+	case pmetric.Metrics:
+		if data.MetricCount() > size {
+			if wholeResources {
+				return splitMetricsByResource(data, size)
+			}
+			return splitMetrics(data, size)
+		}
+	}
+	return []interface{}{data}
+}
+
+func splitTraces(td ptrace.Traces, size int) []interface{} {
+	var parts []interface{}
+	var part ptrace.Traces
+	count := size
+
+	for i, rss := 0, td.ResourceSpans(); i < rss.Len(); i++ {
+		rs := rss.At(i)
+		var destRS ptrace.ResourceSpans
+		haveRS := false
+
+		for j, sss := 0, rs.ScopeSpans(); j < sss.Len(); j++ {
+			ss := sss.At(j)
+			var destSS ptrace.ScopeSpans
+			haveSS := false
+
+			for k, spans := 0, ss.Spans(); k < spans.Len(); k++ {
+				if count == size {
+					part = ptrace.NewTraces()
+					parts = append(parts, part)
+					count = 0
+					haveRS, haveSS = false, false
+				}
+				if !haveRS {
+					destRS = part.ResourceSpans().AppendEmpty()
+					rs.Resource().CopyTo(destRS.Resource())
+					destRS.SetSchemaUrl(rs.SchemaUrl())
+					haveRS = true
+				}
+				if !haveSS {
+					destSS = destRS.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(destSS.Scope())
+					destSS.SetSchemaUrl(ss.SchemaUrl())
+					haveSS = true
+				}
+				spans.At(k).CopyTo(destSS.Spans().AppendEmpty())
+				count++
+			}
+		}
+	}
+	return parts
+}
+
+func splitLogs(ld plog.Logs, size int) []interface{} {
+	var parts []interface{}
+	var part plog.Logs
+	count := size
+
+	for i, rls := 0, ld.ResourceLogs(); i < rls.Len(); i++ {
+		rl := rls.At(i)
+		var destRL plog.ResourceLogs
+		haveRL := false
+
+		for j, sls := 0, rl.ScopeLogs(); j < sls.Len(); j++ {
+			sl := sls.At(j)
+			var destSL plog.ScopeLogs
+			haveSL := false
+
+			for k, records := 0, sl.LogRecords(); k < records.Len(); k++ {
+				if count == size {
+					part = plog.NewLogs()
+					parts = append(parts, part)
+					count = 0
+					haveRL, haveSL = false, false
+				}
+				if !haveRL {
+					destRL = part.ResourceLogs().AppendEmpty()
+					rl.Resource().CopyTo(destRL.Resource())
+					destRL.SetSchemaUrl(rl.SchemaUrl())
+					haveRL = true
+				}
+				if !haveSL {
+					destSL = destRL.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(destSL.Scope())
+					destSL.SetSchemaUrl(sl.SchemaUrl())
+					haveSL = true
+				}
+				records.At(k).CopyTo(destSL.LogRecords().AppendEmpty())
+				count++
+			}
+		}
+	}
+	return parts
+}
+
+func splitMetrics(md pmetric.Metrics, size int) []interface{} {
+	var parts []interface{}
+	var part pmetric.Metrics
+	count := size
+
+	for i, rms := 0, md.ResourceMetrics(); i < rms.Len(); i++ {
+		rm := rms.At(i)
+		var destRM pmetric.ResourceMetrics
+		haveRM := false
+
+		for j, sms := 0, rm.ScopeMetrics(); j < sms.Len(); j++ {
+			sm := sms.At(j)
+			var destSM pmetric.ScopeMetrics
+			haveSM := false
+
+			for k, metrics := 0, sm.Metrics(); k < metrics.Len(); k++ {
+				if count == size {
+					part = pmetric.NewMetrics()
+					parts = append(parts, part)
+					count = 0
+					haveRM, haveSM = false, false
+				}
+				if !haveRM {
+					destRM = part.ResourceMetrics().AppendEmpty()
+					rm.Resource().CopyTo(destRM.Resource())
+					destRM.SetSchemaUrl(rm.SchemaUrl())
+					haveRM = true
+				}
+				if !haveSM {
+					destSM = destRM.ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(destSM.Scope())
+					destSM.SetSchemaUrl(sm.SchemaUrl())
+					haveSM = true
+				}
+				metrics.At(k).CopyTo(destSM.Metrics().AppendEmpty())
+				count++
+			}
+		}
+	}
+	return parts
+}
+
+func splitTracesByResource(td ptrace.Traces, size int) []interface{} {
+	var parts []interface{}
+	var part ptrace.Traces
+	count := size
+
+	for i, rss := 0, td.ResourceSpans(); i < rss.Len(); i++ {
+		rs := rss.At(i)
+		items := 0
+		for j, sss := 0, rs.ScopeSpans(); j < sss.Len(); j++ {
+			items += sss.At(j).Spans().Len()
+		}
+		if items == 0 {
+			continue
+		}
+		if count != 0 && count+items > size {
+			part = ptrace.NewTraces()
+			parts = append(parts, part)
+			count = 0
+		}
+		rs.CopyTo(part.ResourceSpans().AppendEmpty())
+		count += items
+	}
+	return parts
+}
+
+func splitLogsByResource(ld plog.Logs, size int) []interface{} {
+	var parts []interface{}
+	var part plog.Logs
+	count := size
+
+	for i, rls := 0, ld.ResourceLogs(); i < rls.Len(); i++ {
+		rl := rls.At(i)
+		items := 0
+		for j, sls := 0, rl.ScopeLogs(); j < sls.Len(); j++ {
+			items += sls.At(j).LogRecords().Len()
+		}
+		if items == 0 {
+			continue
+		}
+		if count != 0 && count+items > size {
+			part = plog.NewLogs()
+			parts = append(parts, part)
+			count = 0
+		}
+		rl.CopyTo(part.ResourceLogs().AppendEmpty())
+		count += items
+	}
+	return parts
+}
+
+func splitMetricsByResource(md pmetric.Metrics, size int) []interface{} {
+	var parts []interface{}
+	var part pmetric.Metrics
+	count := size
+
+	for i, rms := 0, md.ResourceMetrics(); i < rms.Len(); i++ {
+		rm := rms.At(i)
+		items := 0
+		for j, sms := 0, rm.ScopeMetrics(); j < sms.Len(); j++ {
+			items += sms.At(j).Metrics().Len()
+		}
+		if items == 0 {
+			continue
+		}
+		if count != 0 && count+items > size {
+			part = pmetric.NewMetrics()
+			parts = append(parts, part)
+			count = 0
+		}
+		rm.CopyTo(part.ResourceMetrics().AppendEmpty())
+		count += items
+	}
+	return parts
+}
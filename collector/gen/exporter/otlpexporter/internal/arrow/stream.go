@@ -20,8 +20,11 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamid"
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamparams"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -43,6 +46,21 @@ type Stream struct {
 	// telemetry are a copy of the exporter's telemetry settings
 	telemetry component.TelemetrySettings
 
+	// identity is sent in the stream metadata, it is kept by the
+	// exporter when the stream restarts.
+	identity string
+
+	// params are declared in the stream metadata.
+	params streamparams.Params
+
+	// hints accumulates the receiver's producer hints, or is nil
+	// when the exporter ignores them.
+	hints *HintState
+
+	// sizes records the serialized size of the batches sent, or is
+	// nil when the exporter does not record them.
+	sizes *sizeMetrics
+
 	// client uses the exporter's grpc.ClientConn.  this is
 	// initially nil only set when ArrowStream() calls meaning the
 	// endpoint recognizes OTLP+Arrow.
@@ -52,11 +70,15 @@ type Stream struct {
 	// includes a dedicated channel for the response.
 	toWrite chan writeItem
 
-	// lock protects waiters.
+	// lock protects waiters and sentBytes.
 	lock sync.Mutex
 
 	// waiters is the response channel for each active batch.
 	waiters map[int64]chan error
+
+	// sentBytes is the serialized size of each active batch, for
+	// comparison with the decoded size reported by the receiver.
+	sentBytes map[int64]int64
 }
 
 // writeItem is passed from the sender (a pipeline consumer) to the
@@ -76,24 +98,30 @@ func newStream(
 	prioritizer *streamPrioritizer,
 	telemetry component.TelemetrySettings,
 	perRPCCredentials credentials.PerRPCCredentials,
+	identity string,
+	hints *HintState,
 ) *Stream {
 	return &Stream{
 		producer:          producer,
 		prioritizer:       prioritizer,
 		perRPCCredentials: perRPCCredentials,
 		telemetry:         telemetry,
+		identity:          identity,
+		hints:             hints,
 		toWrite:           make(chan writeItem, 1),
 		waiters:           map[int64]chan error{},
+		sentBytes:         map[int64]int64{},
 	}
 }
 
 // setBatchChannel places a waiting consumer's batchID into the waiters map, where
-// the stream reader may find it.
-func (s *Stream) setBatchChannel(batchID int64, errCh chan error) {
+// the stream reader may find it, along with the serialized size of the batch.
+func (s *Stream) setBatchChannel(batchID int64, errCh chan error, size int64) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	s.waiters[batchID] = errCh
+	s.sentBytes[batchID] = size
 }
 
 func (s *Stream) logStreamError(err error) {
@@ -116,6 +144,11 @@ func (s *Stream) run(bgctx context.Context, streamClient StreamClientFunc, grpcO
 	ctx, cancel := context.WithCancel(bgctx)
 	defer cancel()
 
+	if s.identity != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, streamid.Header, s.identity)
+	}
+	ctx = s.params.AppendToOutgoingContext(ctx)
+
 	sc, err := streamClient(ctx, grpcOptions...)
 	if err != nil {
 		// Returning with stream.client == nil signals the
@@ -161,6 +194,13 @@ func (s *Stream) run(bgctx context.Context, streamClient StreamClientFunc, grpcO
 	cancel()
 	ww.Wait()
 
+	if errors.Is(err, errReceiverHint) {
+		// The stream restarts with a new producer, which
+		// applies the receiver's hints.
+		s.telemetry.Logger.Debug("arrow stream restarting for receiver hints")
+		err = nil
+	}
+
 	if err != nil {
 		// This branch is reached with an unimplemented status
 		// with or without the WaitForReady flag.
@@ -278,16 +318,17 @@ func (s *Stream) write(ctx context.Context) error {
 		// sender race because the stream is not available, as indicated by
 		// the successful <-stream.toWrite.
 
-		batch, err := s.encode(wri.records)
+		batches, parts, err := s.encode(wri.records)
 		if err != nil {
 			// This is some kind of internal error.  We will restart the
 			// stream and mark this record as a permanent one.
 			err = fmt.Errorf("encode: %w", err)
-			wri.errCh <- consumererror.NewPermanent(err)
+			wri.errCh <- consumererror.NewPermanent(&encodeError{err: err})
 			return err
 		}
 
 		// Optionally include outgoing metadata, if present.
+		var headers []byte
 		if len(wri.md) != 0 {
 			hdrsBuf.Reset()
 			for key, val := range wri.md {
@@ -300,30 +341,73 @@ func (s *Stream) write(ctx context.Context) error {
 					// above, we will restart the stream but consider
 					// this a permenent error.
 					err = fmt.Errorf("hpack: %w", err)
-					wri.errCh <- consumererror.NewPermanent(err)
+					wri.errCh <- consumererror.NewPermanent(&encodeError{err: err})
 					return err
 				}
 			}
-			batch.Headers = hdrsBuf.Bytes()
+			headers = hdrsBuf.Bytes()
 		}
 
-		// Let the receiver knows what to look for.
-		s.setBatchChannel(batch.BatchId, wri.errCh)
+		// Let the receiver knows what to look for.  The waiters
+		// of all the batches are set before sending the first
+		// one, so that they are all answered if the stream
+		// breaks in between.
+		errChs := []chan error{wri.errCh}
+		if len(batches) > 1 {
+			errChs = joinBatchChannels(parts, wri.errCh)
+		}
+		for i, batch := range batches {
+			batch.Headers = headers
+			size := s.sizes.record(ctx, batch)
+			s.setBatchChannel(batch.BatchId, errChs[i], size)
+		}
 
-		if err := s.client.Send(batch); err != nil {
-			// The error will be sent to errCh during cleanup for this stream.
-			// Note: do not wrap this error, it may contain a Status.
-			return err
+		for _, batch := range batches {
+			if err := s.client.Send(batch); err != nil {
+				// The error will be sent to errCh during cleanup for this stream.
+				// Note: do not wrap this error, it may contain a Status.
+				return err
+			}
 		}
 	}
 }
 
+// joinBatchChannels returns the response channels of the batches a
+// sender's data was split into, parts being the data of each batch
+// (see arrowRecord.Producer's SplitArrowRecordsWithDataFrom*
+// methods).  Once every response is received, the first error, or
+// nil, is passed to errCh.  When some of the batches were accepted,
+// the error carries the data of the others as the remaining data.
+func joinBatchChannels(parts []interface{}, errCh chan error) []chan error {
+	chs := make([]chan error, len(parts))
+	for i := range chs {
+		chs[i] = make(chan error, 1)
+	}
+	go func() {
+		var err error
+		var failed []interface{}
+		for i, ch := range chs {
+			if e := <-ch; e != nil {
+				if err == nil {
+					err = e
+				}
+				failed = append(failed, parts[i])
+			}
+		}
+		if err != nil && len(failed) < len(parts) {
+			err = partialError(err, mergeData(failed))
+		}
+		errCh <- err
+	}()
+	return chs
+}
+
 // read repeatedly reads a batch status and releases the consumers waiting for
 // a response.
-func (s *Stream) read(_ context.Context) error {
-	// Note we do not use the context, the stream context might
-	// cancel a call to Recv() but the call to processBatchStatus
-	// is non-blocking.
+func (s *Stream) read(ctx context.Context) error {
+	// Note we do not use the context to receive, the stream
+	// context might cancel a call to Recv() but the call to
+	// processBatchStatus is non-blocking.
 	for {
 		resp, err := s.client.Recv()
 		if err != nil {
@@ -331,34 +415,40 @@ func (s *Stream) read(_ context.Context) error {
 			return err
 		}
 
-		if err = s.processBatchStatus(resp); err != nil {
+		if err = s.processBatchStatus(ctx, resp); err != nil {
 			return fmt.Errorf("process: %w", err)
 		}
+
+		if s.hints != nil && s.hints.observe(resp) {
+			return errReceiverHint
+		}
 	}
 }
 
 // getSenderChannels takes the stream lock and removes the
 // corresonding sender channel for each BatchId.  They are returned
-// with the same index as the original status, for correlation.  Nil
-// channels will be returned when there are errors locating the
-// sender channel.
-func (s *Stream) getSenderChannels(status *arrowpb.BatchStatus) (chan error, error) {
+// with the same index as the original status, for correlation, along
+// with the serialized size of the batch.  Nil channels will be
+// returned when there are errors locating the sender channel.
+func (s *Stream) getSenderChannels(status *arrowpb.BatchStatus) (chan error, int64, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
 	ch, ok := s.waiters[status.BatchId]
 	if !ok {
 		// Will break the stream.
-		return nil, fmt.Errorf("unrecognized batch ID: %d", status.BatchId)
+		return nil, 0, fmt.Errorf("unrecognized batch ID: %d", status.BatchId)
 	}
+	size := s.sentBytes[status.BatchId]
 	delete(s.waiters, status.BatchId)
-	return ch, nil
+	delete(s.sentBytes, status.BatchId)
+	return ch, size, nil
 }
 
 // processBatchStatus processes a single response from the server and unblocks the
 // associated sender.
-func (s *Stream) processBatchStatus(status *arrowpb.BatchStatus) error {
-	ch, ret := s.getSenderChannels(status)
+func (s *Stream) processBatchStatus(ctx context.Context, status *arrowpb.BatchStatus) error {
+	ch, size, ret := s.getSenderChannels(status)
 
 	if ch == nil {
 		// In case getSenderChannels encounters a problem, the
@@ -366,14 +456,23 @@ func (s *Stream) processBatchStatus(status *arrowpb.BatchStatus) error {
 		return ret
 	}
 
+	s.sizes.recordDecoded(ctx, size, status)
+
 	if status.StatusCode == arrowpb.StatusCode_OK {
+		// As in the OTLP exporter, a partial success is
+		// reported to the caller as a permanent error since
+		// retrying would send the accepted items again.
+		if status.StatusMessage != "" || status.RejectedItems != 0 {
+			ch <- consumererror.NewPermanent(fmt.Errorf("OTLP partial success: \"%s\" (%d rejected)", status.StatusMessage, status.RejectedItems))
+			return nil
+		}
 		ch <- nil
 		return nil
 	}
 	var err error
 	switch status.StatusCode {
 	case arrowpb.StatusCode_UNAVAILABLE:
-		err = fmt.Errorf("destination unavailable: %d: %s", status.BatchId, status.StatusMessage)
+		err = fmt.Errorf("%w: %d: %s", ErrDestinationUnavailable, status.BatchId, status.StatusMessage)
 	case arrowpb.StatusCode_INVALID_ARGUMENT:
 		err = consumererror.NewPermanent(
 			fmt.Errorf("invalid argument: %d: %s", status.BatchId, status.StatusMessage))
@@ -431,8 +530,31 @@ func (s *Stream) SendAndWait(ctx context.Context, records interface{}) error {
 	}
 }
 
-// encode produces the next batch of Arrow records.
-func (s *Stream) encode(records interface{}) (_ *arrowpb.BatchArrowRecords, retErr error) {
+// splitProducer is implemented by the producers splitting the batches
+// exceeding their maximum record size (see config.WithMaxRecordBytes).
+type splitProducer interface {
+	SplitArrowRecordsWithDataFromTraces(ptrace.Traces) ([]*arrowpb.BatchArrowRecords, []ptrace.Traces, error)
+	SplitArrowRecordsWithDataFromLogs(plog.Logs) ([]*arrowpb.BatchArrowRecords, []plog.Logs, error)
+	SplitArrowRecordsWithDataFromMetrics(pmetric.Metrics) ([]*arrowpb.BatchArrowRecords, []pmetric.Metrics, error)
+}
+
+// splitParts returns the result of a splitProducer method with the
+// data of each batch as an interface{}.
+func splitParts[T any](batches []*arrowpb.BatchArrowRecords, data []T, err error) ([]*arrowpb.BatchArrowRecords, []interface{}, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	parts := make([]interface{}, len(data))
+	for i, part := range data {
+		parts[i] = part
+	}
+	return batches, parts, nil
+}
+
+// encode produces the next batches of Arrow records, a single batch
+// unless the producer splits the batches exceeding its maximum record
+// size, and the data encoded in each of them.
+func (s *Stream) encode(records interface{}) (_ []*arrowpb.BatchArrowRecords, _ []interface{}, retErr error) {
 	// Defensively, protect against panics in the Arrow producer function.
 	defer func() {
 		if err := recover(); err != nil {
@@ -446,6 +568,17 @@ func (s *Stream) encode(records interface{}) (_ *arrowpb.BatchArrowRecords, retE
 			retErr = fmt.Errorf("panic in otel-arrow-adapter: %v", err)
 		}
 	}()
+	if sp, ok := s.producer.(splitProducer); ok {
+		switch data := records.(type) {
+		case ptrace.Traces:
+			return splitParts(sp.SplitArrowRecordsWithDataFromTraces(data))
+		case plog.Logs:
+			return splitParts(sp.SplitArrowRecordsWithDataFromLogs(data))
+		case pmetric.Metrics:
+			return splitParts(sp.SplitArrowRecordsWithDataFromMetrics(data))
+		}
+	}
+
 	var batch *arrowpb.BatchArrowRecords
 	var err error
 	switch data := records.(type) {
@@ -456,7 +589,10 @@ func (s *Stream) encode(records interface{}) (_ *arrowpb.BatchArrowRecords, retE
 	case pmetric.Metrics:
 		batch, err = s.producer.BatchArrowRecordsFromMetrics(data)
 	default:
-		return nil, fmt.Errorf("unsupported OTLP type: %T", records)
+		return nil, nil, fmt.Errorf("unsupported OTLP type: %T", records)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
-	return batch, err
+	return []*arrowpb.BatchArrowRecords{batch}, []interface{}{records}, nil
 }
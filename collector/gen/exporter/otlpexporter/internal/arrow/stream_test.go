@@ -15,9 +15,14 @@ import (
 	arrowRecordMock "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record/mock"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"google.golang.org/grpc"
 
 	"go.opentelemetry.io/collector/consumer/consumererror"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 var oneBatch = &arrowpb.BatchArrowRecords{
@@ -52,7 +57,7 @@ func newStreamTestCase(t *testing.T) *streamTestCase {
 	// metadata functionality is tested in exporter_test.go
 	ctc.requestMetadataCall.AnyTimes().Return(nil, nil)
 
-	stream := newStream(producer, prio, ctc.telset, ctc.perRPCCredentials)
+	stream := newStream(producer, prio, ctc.telset, ctc.perRPCCredentials, "test-stream", nil)
 
 	fromTracesCall := producer.EXPECT().BatchArrowRecordsFromTraces(gomock.Any()).Times(0)
 	fromMetricsCall := producer.EXPECT().BatchArrowRecordsFromMetrics(gomock.Any()).Times(0)
@@ -197,6 +202,42 @@ func TestStreamStatusUnavailableInvalid(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestStreamStatusPartialSuccess verifies that the stream reader
+// reports a partial success as a permanent error w/o breaking the
+// stream.
+func TestStreamStatusPartialSuccess(t *testing.T) {
+	tc := newStreamTestCase(t)
+
+	tc.fromTracesCall.Times(2).Return(oneBatch, nil)
+
+	channel := newHealthyTestChannel()
+	tc.start(channel)
+	defer tc.cancelAndWaitForShutdown()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Wait()
+	go func() {
+		defer wg.Done()
+		batch := <-channel.sent
+		channel.recv <- &arrowpb.BatchStatus{
+			BatchId:       batch.BatchId,
+			StatusCode:    arrowpb.StatusCode_OK,
+			StatusMessage: "test partial success",
+			RejectedItems: 1,
+		}
+		batch = <-channel.sent
+		channel.recv <- statusOKFor(batch.BatchId)
+	}()
+	err := tc.get().SendAndWait(tc.bgctx, twoTraces)
+	require.Error(t, err)
+	require.True(t, consumererror.IsPermanent(err))
+	require.Contains(t, err.Error(), `OTLP partial success: "test partial success" (1 rejected)`)
+
+	err = tc.get().SendAndWait(tc.bgctx, twoTraces)
+	require.NoError(t, err)
+}
+
 // TestStreamStatusUnrecognized verifies that the stream reader handles
 // an unrecognized status by breaking the stream.
 func TestStreamStatusUnrecognized(t *testing.T) {
@@ -265,3 +306,152 @@ func TestStreamSendError(t *testing.T) {
 	require.Error(t, err)
 	require.True(t, errors.Is(err, ErrStreamRestarting))
 }
+
+// TestStreamReceiverHints verifies that the stream restarts, without
+// logging an error, when the receiver hints at a new plain encoding
+// field.
+func TestStreamReceiverHints(t *testing.T) {
+	tc := newStreamTestCase(t)
+	tc.stream.hints = NewHintState()
+
+	tc.fromTracesCall.Times(1).Return(oneBatch, nil)
+
+	channel := newHealthyTestChannel()
+	tc.start(channel)
+	defer tc.cancelAndWaitForShutdown()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Wait()
+	go func() {
+		defer wg.Done()
+		batch := <-channel.sent
+		status := statusOKFor(batch.BatchId)
+		status.PlainEncodingFields = []string{"spans.name"}
+		channel.recv <- status
+	}()
+	err := tc.get().SendAndWait(tc.bgctx, twoTraces)
+	require.NoError(t, err)
+
+	// Note: do not cancel the context, the stream should be
+	// restarting due to the hint.
+	tc.waitForShutdown()
+
+	require.Equal(t, 0, len(tc.observedLogs.All()), "should have no logs: %v", tc.observedLogs.All())
+	require.Equal(t, map[string]string{"spans.name": "plain"}, tc.stream.hints.EncodingOverrides(nil))
+}
+
+// TestStreamDecodedSize verifies that the stream records the decoded
+// size reported by the receiver and the corresponding compression
+// ratio.
+func TestStreamDecodedSize(t *testing.T) {
+	tc := newStreamTestCase(t)
+
+	rdr := sdkmetric.NewManualReader()
+	sizes, err := newSizeMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(rdr)).Meter("test"))
+	require.NoError(t, err)
+	tc.stream.sizes = sizes
+
+	tc.fromTracesCall.Times(1).Return(&arrowpb.BatchArrowRecords{
+		BatchId: 1,
+		ArrowPayloads: []*arrowpb.ArrowPayload{{
+			Type:   arrowpb.ArrowPayloadType_SPANS,
+			Record: make([]byte, 10),
+		}},
+	}, nil)
+
+	channel := newHealthyTestChannel()
+	tc.start(channel)
+	defer tc.cancelAndWaitForShutdown()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Wait()
+	go func() {
+		defer wg.Done()
+		batch := <-channel.sent
+		status := statusOKFor(batch.BatchId)
+		status.DecodedBytes = 40
+		channel.recv <- status
+	}()
+	err = tc.get().SendAndWait(tc.bgctx, twoTraces)
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, rdr.Collect(context.Background(), &rm))
+
+	found := 0
+	for _, sm := range rm.ScopeMetrics {
+		for _, mm := range sm.Metrics {
+			switch mm.Name {
+			case "exporter_arrow_decoded_bytes":
+				found++
+				require.Equal(t, int64(40), mm.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+			case "exporter_arrow_compression_ratio":
+				found++
+				dp := mm.Data.(metricdata.Histogram[float64]).DataPoints[0]
+				require.Equal(t, uint64(1), dp.Count)
+				require.Equal(t, 4.0, dp.Sum)
+			}
+		}
+	}
+	require.Equal(t, 2, found)
+}
+
+// splittingProducer splits every traces batch into two batches.
+type splittingProducer struct {
+	*arrowRecordMock.MockProducerAPI
+}
+
+func (splittingProducer) SplitArrowRecordsWithDataFromTraces(td ptrace.Traces) ([]*arrowpb.BatchArrowRecords, []ptrace.Traces, error) {
+	parts := splitData(td, (td.SpanCount()+1)/2, false)
+	return []*arrowpb.BatchArrowRecords{{BatchId: 1}, {BatchId: 2}}, []ptrace.Traces{parts[0].(ptrace.Traces), parts[1].(ptrace.Traces)}, nil
+}
+
+func (splittingProducer) SplitArrowRecordsWithDataFromLogs(plog.Logs) ([]*arrowpb.BatchArrowRecords, []plog.Logs, error) {
+	return nil, nil, fmt.Errorf("unexpected logs")
+}
+
+func (splittingProducer) SplitArrowRecordsWithDataFromMetrics(pmetric.Metrics) ([]*arrowpb.BatchArrowRecords, []pmetric.Metrics, error) {
+	return nil, nil, fmt.Errorf("unexpected metrics")
+}
+
+// TestStreamSplitBatches verifies that the sender of data split into
+// several batches waits for all of their statuses, and that the error
+// carries the data of the failed batches only.
+func TestStreamSplitBatches(t *testing.T) {
+	tc := newStreamTestCase(t)
+	tc.stream.producer = splittingProducer{tc.producer}
+
+	channel := newHealthyTestChannel()
+	tc.start(channel)
+	defer tc.cancelAndWaitForShutdown()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	defer wg.Wait()
+	go func() {
+		defer wg.Done()
+		for _, code := range []arrowpb.StatusCode{arrowpb.StatusCode_OK, arrowpb.StatusCode_INVALID_ARGUMENT} {
+			first, second := <-channel.sent, <-channel.sent
+			require.Equal(t, int64(1), first.BatchId)
+			require.Equal(t, int64(2), second.BatchId)
+
+			channel.recv <- statusOKFor(first.BatchId)
+			status := statusOKFor(second.BatchId)
+			status.StatusCode = code
+			channel.recv <- status
+		}
+	}()
+	require.NoError(t, tc.get().SendAndWait(tc.bgctx, twoTraces))
+
+	err := tc.get().SendAndWait(tc.bgctx, twoTraces)
+	require.Error(t, err)
+	require.True(t, consumererror.IsPermanent(err))
+	require.Contains(t, err.Error(), "invalid argument: 2")
+
+	// Only the data of the second batch remains.
+	var remaining consumererror.Traces
+	require.True(t, errors.As(err, &remaining))
+	require.Equal(t, 1, remaining.Data().SpanCount())
+}
@@ -0,0 +1,180 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow"
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// WarmupConfig configures the coalescing of batches sent shortly
+// after the exporter starts.
+type WarmupConfig struct {
+	// Duration is the length of the warm-up period, starting
+	// when the exporter starts.
+	Duration time.Duration
+
+	// Linger is how long the first batch of a coalesced group
+	// waits for other batches of the same type to join it.
+	Linger time.Duration
+}
+
+// warmupCoalescer merges the batches of concurrent senders during
+// the warm-up period.  The first few batches of a new stream
+// determine which dictionaries and optional columns are activated;
+// many tiny batches each force a schema reset and a retransmission
+// of the dictionaries, whereas a few larger ones let the schema
+// stabilize quickly.
+type warmupCoalescer struct {
+	cfg WarmupConfig
+
+	// until is the end of the warm-up period.
+	until time.Time
+
+	// lock protects pending.
+	lock sync.Mutex
+
+	// pending is the group currently accepting batches, or nil.
+	pending *warmupGroup
+}
+
+// warmupGroup is a set of batches coalesced into one.  The sender
+// that created the group sends it on behalf of the others, which
+// wait for done.
+type warmupGroup struct {
+	data interface{}
+	done chan struct{}
+	sent bool
+	err  error
+}
+
+// newWarmupCoalescer returns a coalescer whose warm-up period begins
+// at start.
+func newWarmupCoalescer(cfg WarmupConfig, start time.Time) *warmupCoalescer {
+	return &warmupCoalescer{
+		cfg:   cfg,
+		until: start.Add(cfg.Duration),
+	}
+}
+
+// active returns true during the warm-up period.
+func (w *warmupCoalescer) active(now time.Time) bool {
+	return now.Before(w.until)
+}
+
+// sendAndWait adds the data to the pending group of the same type, if
+// any, otherwise it starts a new group, waits for the linger period
+// and sends the group with the send function.  The result of the
+// combined send is returned to every member of the group, without
+// the remaining data of a partial failure.  Note that the group is
+// sent using the context of the sender that started it.
+func (w *warmupCoalescer) sendAndWait(ctx context.Context, data interface{}, send func(context.Context, interface{}) (bool, error)) (bool, error) {
+	w.lock.Lock()
+	if group := w.pending; group != nil && appendData(group.data, data) {
+		w.lock.Unlock()
+
+		select {
+		case <-group.done:
+			return group.sent, group.err
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+	group := &warmupGroup{
+		data: copyData(data),
+		done: make(chan struct{}),
+	}
+	if w.pending == nil {
+		w.pending = group
+	}
+	w.lock.Unlock()
+
+	timer := time.NewTimer(w.cfg.Linger)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+
+	w.lock.Lock()
+	if w.pending == group {
+		w.pending = nil
+	}
+	w.lock.Unlock()
+
+	group.sent, group.err = send(ctx, group.data)
+	if _, ok := remainingData(group.err); ok {
+		// The remaining data of a partially accepted group
+		// cannot be attributed to its members, each of them
+		// retries or falls back with its own data.
+		group.err = withoutRemainingData(group.err)
+		if group.err == ErrFallback {
+			group.err = nil
+		}
+	}
+	close(group.done)
+	return group.sent, group.err
+}
+
+// copyData returns a copy of a ptrace.Traces, plog.Logs, or
+// pmetric.Metrics which the coalescer may append to, since the
+// exporter does not mutate its input.  Data of another type is
+// returned as-is.
+func copyData(data interface{}) interface{} {
+	switch data := data.(type) {
+	case ptrace.Traces:
+		cpy := ptrace.NewTraces()
+		data.CopyTo(cpy)
+		return cpy
+	case plog.Logs:
+		cpy := plog.NewLogs()
+		data.CopyTo(cpy)
+		return cpy
+	case pmetric.Metrics:
+		cpy := pmetric.NewMetrics()
+		data.CopyTo(cpy)
+		return cpy
+	}
+	return data
+}
+
+// appendData copies the resources of src into dest and returns true
+// when both have the same type, otherwise it returns false.
+func appendData(dest, src interface{}) bool {
+	switch dest := dest.(type) {
+	case ptrace.Traces:
+		src, ok := src.(ptrace.Traces)
+		if !ok {
+			return false
+		}
+		for i, rss := 0, src.ResourceSpans(); i < rss.Len(); i++ {
+			rss.At(i).CopyTo(dest.ResourceSpans().AppendEmpty())
+		}
+		return true
+	case plog.Logs:
+		src, ok := src.(plog.Logs)
+		if !ok {
+			return false
+		}
+		for i, rls := 0, src.ResourceLogs(); i < rls.Len(); i++ {
+			rls.At(i).CopyTo(dest.ResourceLogs().AppendEmpty())
+		}
+		return true
+	case pmetric.Metrics:
+		src, ok := src.(pmetric.Metrics)
+		if !ok {
+			return false
+		}
+		for i, rms := 0, src.ResourceMetrics(); i < rms.Len(); i++ {
+			rms.At(i).CopyTo(dest.ResourceMetrics().AppendEmpty())
+		}
+		return true
+	}
+	return false
+}
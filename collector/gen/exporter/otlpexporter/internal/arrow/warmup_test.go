@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/internal/testdata"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestWarmupActive(t *testing.T) {
+	start := time.Now()
+	w := newWarmupCoalescer(WarmupConfig{Duration: time.Minute, Linger: time.Millisecond}, start)
+
+	require.True(t, w.active(start))
+	require.True(t, w.active(start.Add(59*time.Second)))
+	require.False(t, w.active(start.Add(time.Minute)))
+}
+
+func TestWarmupCoalesce(t *testing.T) {
+	w := newWarmupCoalescer(WarmupConfig{Duration: time.Minute, Linger: 200 * time.Millisecond}, time.Now())
+
+	var lock sync.Mutex
+	var sent []interface{}
+	send := func(_ context.Context, data interface{}) (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		sent = append(sent, data)
+		return true, nil
+	}
+
+	const senders = 5
+	inputs := make([]ptrace.Traces, senders)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		inputs[i] = testdata.GenerateTraces(2)
+		wg.Add(1)
+		go func(td ptrace.Traces) {
+			defer wg.Done()
+			ok, err := w.sendAndWait(context.Background(), td, send)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}(inputs[i])
+	}
+	wg.Wait()
+
+	// The senders raced to start a group; at least one batch
+	// was coalesced and all spans were sent once.
+	require.Less(t, len(sent), senders)
+	spans := 0
+	for _, data := range sent {
+		spans += data.(ptrace.Traces).SpanCount()
+	}
+	require.Equal(t, 2*senders, spans)
+
+	// The inputs are not modified.
+	for _, td := range inputs {
+		require.Equal(t, 2, td.SpanCount())
+	}
+}
+
+func TestWarmupMixedTypes(t *testing.T) {
+	w := newWarmupCoalescer(WarmupConfig{Duration: time.Minute, Linger: 100 * time.Millisecond}, time.Now())
+
+	var lock sync.Mutex
+	var sent []interface{}
+	send := func(_ context.Context, data interface{}) (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		sent = append(sent, data)
+		return true, nil
+	}
+
+	var wg sync.WaitGroup
+	for _, data := range []interface{}{twoTraces, twoLogs} {
+		wg.Add(1)
+		go func(data interface{}) {
+			defer wg.Done()
+			_, err := w.sendAndWait(context.Background(), data, send)
+			require.NoError(t, err)
+		}(data)
+	}
+	wg.Wait()
+
+	// Data of different types is never combined.
+	require.Equal(t, 2, len(sent))
+	for _, data := range sent {
+		switch data := data.(type) {
+		case ptrace.Traces:
+			require.Equal(t, 2, data.SpanCount())
+		case plog.Logs:
+			require.Equal(t, 2, data.LogRecordCount())
+		default:
+			t.Errorf("unexpected type %T", data)
+		}
+	}
+}
+
+func TestWarmupContextCanceled(t *testing.T) {
+	w := newWarmupCoalescer(WarmupConfig{Duration: time.Minute, Linger: time.Minute}, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ok, err := w.sendAndWait(ctx, twoTraces, func(ctx context.Context, _ interface{}) (bool, error) {
+		return false, ctx.Err()
+	})
+	require.False(t, ok)
+	require.ErrorIs(t, err, context.Canceled)
+}
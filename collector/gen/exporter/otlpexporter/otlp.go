@@ -8,11 +8,17 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync"
 	"time"
 
 	arrowPkg "github.com/apache/arrow/go/v12/arrow"
+	arrowConfig "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/multierr"
+	"go.uber.org/zap"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -51,17 +57,39 @@ type baseExporter struct {
 	// Default user-agent header.
 	userAgent string
 
+	// signal is the data type exported, the Arrow exporter of
+	// each signal has its own streams and metrics.
+	signal component.DataType
+
 	// OTLP+Arrow optional state
 	arrow *arrow.Exporter
+	// arrowPartitions is used instead of arrow when MetadataKeys is set.
+	arrowPartitions *arrowPartitions
+	// startArrow creates and starts arrow or arrowPartitions, it
+	// is nil when Arrow is disabled in the configuration.
+	startArrow func() error
+	// arrowLock is held for reading while sending with Arrow and
+	// for writing while starting or stopping it.
+	arrowLock sync.RWMutex
+	// arrowRunning indicates that startArrow succeeded and the
+	// Arrow exporter has not been stopped.
+	arrowRunning bool
+	// arrowIdle indicates that the Arrow exporter has not been
+	// started yet because of LazyConnect.
+	arrowIdle bool
 	// streamClientFunc is the stream constructor, depends on EnableMixedTelemetry.
 	streamClientFactory streamClientFactory
+	// hashing is the attribute hashing configuration, shared by
+	// the Arrow producers and the standard OTLP exports, it is
+	// nil when no attribute is hashed.
+	hashing *arrowConfig.Config
 }
 
 type streamClientFactory func(cfg *Config, conn *grpc.ClientConn) func(ctx context.Context, opts ...grpc.CallOption) (arrow.AnyStreamClient, error)
 
 // Crete new exporter and start it. The exporter will begin connecting but
 // this function may return before the connection is established.
-func newExporter(cfg component.Config, set exporter.CreateSettings, streamClientFactory streamClientFactory) (*baseExporter, error) {
+func newExporter(cfg component.Config, set exporter.CreateSettings, signal component.DataType, streamClientFactory streamClientFactory) (*baseExporter, error) {
 	oCfg := cfg.(*Config)
 
 	if oCfg.Endpoint == "" {
@@ -83,6 +111,7 @@ func newExporter(cfg component.Config, set exporter.CreateSettings, streamClient
 		config:              oCfg,
 		settings:            set,
 		userAgent:           userAgent,
+		signal:              signal,
 		netStats:            netStats,
 		streamClientFactory: streamClientFactory,
 	}, nil
@@ -112,6 +141,11 @@ func (e *baseExporter) start(ctx context.Context, host component.Host) (err erro
 	e.callOptions = []grpc.CallOption{
 		grpc.WaitForReady(e.config.GRPCClientSettings.WaitForReady),
 	}
+	// The attributes are hashed whether the data is sent with
+	// Arrow or with standard OTLP.
+	if e.hashing, err = e.attributeHashing(host); err != nil {
+		return err
+	}
 
 	if !e.config.Arrow.Disabled {
 		// Note this sets static outgoing context for all future stream requests.
@@ -131,23 +165,181 @@ func (e *baseExporter) start(ctx context.Context, host component.Host) (err erro
 			}
 		}
 
-		e.arrow = arrow.NewExporter(e.config.Arrow.NumStreams, e.config.Arrow.DisableDowngrade, e.settings.TelemetrySettings, e.callOptions, func() arrowRecord.ProducerAPI {
-			return arrowRecord.NewProducer()
-		}, e.streamClientFactory(e.config, e.clientConn), perRPCCreds)
+		var adaptive *arrow.AdaptiveConfig
+		if e.config.Arrow.Adaptive.Enabled {
+			adaptive = &arrow.AdaptiveConfig{
+				LatencyTarget:  e.config.Arrow.Adaptive.LatencyTarget,
+				MinBatchSize:   e.config.Arrow.Adaptive.MinBatchSize,
+				MaxBatchSize:   e.config.Arrow.Adaptive.MaxBatchSize,
+				WholeResources: e.config.Arrow.WholeResources,
+			}
+		}
+
+		var warmup *arrow.WarmupConfig
+		if e.config.Arrow.Warmup.Duration > 0 {
+			warmup = &arrow.WarmupConfig{
+				Duration: e.config.Arrow.Warmup.Duration,
+				Linger:   e.config.Arrow.Warmup.Linger,
+			}
+		}
+
+		producerOptions := e.producerOptions()
+		if e.config.Arrow.EncryptionKeyProvider != nil {
+			kp, err := e.encryptionKeyProvider(host)
+			if err != nil {
+				return err
+			}
+			producerOptions = append(producerOptions, arrowConfig.WithEncryption(kp))
+		}
+
+		// The streams and metrics of the Arrow exporter are
+		// distinguished by signal.
+		telemetry := e.settings.TelemetrySettings
+		telemetry.Logger = telemetry.Logger.With(zap.String("signal", string(e.signal)))
+		telemetry.MeterProvider = signalMeterProvider{
+			MeterProvider: telemetry.MeterProvider,
+			signal:        e.signal,
+		}
+		producerOptions = append(producerOptions, arrowConfig.WithMeterProvider(telemetry.MeterProvider))
 
-		if err := e.arrow.Start(ctx); err != nil {
-			return err
+		streamClient := e.streamClientFactory(e.config, e.clientConn)
+		newArrowExporter := func() *arrow.Exporter {
+			var hints *arrow.HintState
+			if e.config.Arrow.AcceptHints {
+				hints = arrow.NewHintState()
+			}
+			return arrow.NewExporter(e.config.Arrow.NumStreams, e.config.Arrow.DisableDowngrade, telemetry, e.callOptions, func() arrowRecord.ProducerAPI {
+				if hints == nil {
+					return arrowRecord.NewProducerWithOptions(producerOptions...)
+				}
+				// Copy the options, producers are created concurrently.
+				options := append([]arrowConfig.Option{}, producerOptions...)
+				if overrides := hints.EncodingOverrides(e.config.Arrow.EncodingOverrides); len(overrides) != 0 {
+					options = append(options, arrowConfig.WithEncodingOverrides(overrides))
+				}
+				return arrowRecord.NewProducerWithOptions(options...)
+			}, streamClient, perRPCCreds, adaptive, warmup, e.config.Arrow.EncodeFailure, hints)
+		}
+
+		e.startArrow = func() error {
+			if len(e.config.Arrow.MetadataKeys) != 0 {
+				// Streams are opened lazily, one set per
+				// combination of metadata values.
+				e.arrowPartitions = newArrowPartitions(ctx, e.config.Arrow.MetadataKeys, e.config.Arrow.MetadataCardinalityLimit, e.config.Arrow.MetadataIdleTimeout, e.settings.Logger, newArrowExporter)
+				return nil
+			}
+
+			e.arrow = newArrowExporter()
+
+			return e.arrow.Start(ctx)
+		}
+
+		if e.config.Arrow.LazyConnect {
+			// The streams are started by syncArrowGate
+			// before the first Arrow export.
+			e.arrowIdle = true
+			return nil
+		}
+
+		if arrowFeatureGate.IsEnabled() {
+			e.arrowLock.Lock()
+			defer e.arrowLock.Unlock()
+
+			return e.startArrowLocked()
 		}
 	}
 
 	return nil
 }
 
-func (e *baseExporter) shutdown(ctx context.Context) error {
-	var err error
-	if e.arrow != nil {
-		err = multierr.Append(err, e.arrow.Shutdown(ctx))
+// signalMeterProvider adds the signal as an instrumentation scope
+// attribute of the Arrow exporter meters, since the exporters of
+// every signal register the same instruments.
+type signalMeterProvider struct {
+	metric.MeterProvider
+	signal component.DataType
+}
+
+func (p signalMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	opts = append(opts, metric.WithInstrumentationAttributes(attribute.String("signal", string(p.signal))))
+	return p.MeterProvider.Meter(name, opts...)
+}
+
+// producerOptions returns the options of the Arrow producers.
+func (e *baseExporter) producerOptions() []arrowConfig.Option {
+	var options []arrowConfig.Option
+	if e.config.Arrow.CPUBudget > 0 {
+		options = append(options, arrowConfig.WithCPUBudget(e.config.Arrow.CPUBudget))
+	}
+	if len(e.config.Arrow.EncodingOverrides) != 0 {
+		options = append(options, arrowConfig.WithEncodingOverrides(e.config.Arrow.EncodingOverrides))
+	}
+	if e.config.Arrow.MaxRecordBytes > 0 {
+		options = append(options, arrowConfig.WithMaxRecordBytes(e.config.Arrow.MaxRecordBytes))
+	}
+	if e.config.Arrow.WholeResources {
+		options = append(options, arrowConfig.WithWholeResources())
+	}
+	if keys := e.config.Arrow.SortKeys; len(keys.Traces)+len(keys.Logs)+len(keys.Metrics) != 0 {
+		options = append(options,
+			arrowConfig.WithSpanSortKeys(keys.Traces...),
+			arrowConfig.WithLogSortKeys(keys.Logs...),
+			arrowConfig.WithMetricSortKeys(keys.Metrics...),
+		)
 	}
+
+	if e.hashing != nil {
+		options = append(options, arrowConfig.WithAttributeHashing(e.hashing.AttributeHashKey, e.hashing.HashedAttributes...))
+	}
+	return options
+}
+
+// attributeHashing returns the attribute hashing configuration,
+// including the key obtained from its extension, or nil when no
+// attribute is hashed.
+func (e *baseExporter) attributeHashing(host component.Host) (*arrowConfig.Config, error) {
+	hashing := e.config.Arrow.Hashing
+	if len(hashing.Attributes) == 0 {
+		return nil, nil
+	}
+	ext, ok := host.GetExtensions()[*hashing.KeyProvider]
+	if !ok {
+		return nil, fmt.Errorf("hashing key provider %q not found", hashing.KeyProvider)
+	}
+	provider, ok := ext.(HashKeyProvider)
+	if !ok {
+		return nil, fmt.Errorf("extension %q does not provide a hashing key", hashing.KeyProvider)
+	}
+	key, err := provider.HashKey()
+	if err != nil {
+		return nil, err
+	}
+	return &arrowConfig.Config{
+		HashedAttributes: hashing.Attributes,
+		AttributeHashKey: key,
+	}, nil
+}
+
+// encryptionKeyProvider returns the extension providing the keys
+// encrypting the Arrow payloads.
+func (e *baseExporter) encryptionKeyProvider(host component.Host) (encryption.KeyProvider, error) {
+	id := *e.config.Arrow.EncryptionKeyProvider
+	ext, ok := host.GetExtensions()[id]
+	if !ok {
+		return nil, fmt.Errorf("encryption key provider %q not found", id)
+	}
+	kp, ok := ext.(encryption.KeyProvider)
+	if !ok {
+		return nil, fmt.Errorf("extension %q does not provide encryption keys", id)
+	}
+	return kp, nil
+}
+
+func (e *baseExporter) shutdown(ctx context.Context) error {
+	e.arrowLock.Lock()
+	err := e.stopArrowLocked(ctx)
+	e.arrowLock.Unlock()
+
 	if e.clientConn != nil {
 		err = multierr.Append(err, e.clientConn.Close())
 	}
@@ -156,25 +348,60 @@ func (e *baseExporter) shutdown(ctx context.Context) error {
 
 // arrowSendAndWait gets an available stream and tries to send using
 // Arrow if it is configured.  A (false, nil) result indicates for the
-// caller to fall back to ordinary OTLP.
+// caller to fall back to ordinary OTLP, which is also the case for
+// batches smaller than MinArrowBatchBytes.  After a partial send, the
+// caller falls back with the remaining data of an arrow.ErrFallback.
 //
 // Note that ctx is has not had enhanceContext() called, meaning it
 // will have outgoing gRPC metadata only when an upstream processor or
 // receiver placed it there.
 func (e *baseExporter) arrowSendAndWait(ctx context.Context, data interface{}) (sent bool, _ error) {
+	if e.config.Arrow.MinArrowBatchBytes > 0 && otlpSize(data) < e.config.Arrow.MinArrowBatchBytes {
+		return false, nil
+	}
+	if err := e.syncArrowGate(ctx); err != nil {
+		return false, err
+	}
+
+	// Holding the read lock prevents the Arrow exporter from
+	// being stopped until this send is finished.
+	e.arrowLock.RLock()
+	defer e.arrowLock.RUnlock()
+
+	if e.arrowPartitions != nil {
+		return e.arrowPartitions.sendAndWait(ctx, data)
+	}
 	if e.arrow == nil {
 		return false, nil
 	}
 	return e.arrow.SendAndWait(ctx, data)
 }
 
+// otlpSize returns the uncompressed OTLP size of the data.
+func otlpSize(data interface{}) int {
+	switch data := data.(type) {
+	case ptrace.Traces:
+		return (&ptrace.ProtoMarshaler{}).TracesSize(data)
+	case plog.Logs:
+		return (&plog.ProtoMarshaler{}).LogsSize(data)
+	case pmetric.Metrics:
+		return (&pmetric.ProtoMarshaler{}).MetricsSize(data)
+	}
+	return 0
+}
+
 func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
-	if sent, err := e.arrowSendAndWait(ctx, td); err != nil {
+	sent, err := e.arrowSendAndWait(ctx, td)
+	if errors.Is(err, arrow.ErrFallback) {
+		// Part of the data was sent with Arrow.
+		td, err = arrow.RemainingData(err, td).(ptrace.Traces), nil
+	}
+	if err != nil {
 		return err
 	} else if sent {
 		return nil
 	}
-	req := ptraceotlp.NewExportRequestFromTraces(td)
+	req := ptraceotlp.NewExportRequestFromTraces(e.hashTraces(td))
 	resp, respErr := e.traceExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
 	if err := processError(respErr); err != nil {
 		return err
@@ -187,12 +414,17 @@ func (e *baseExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
 }
 
 func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) error {
-	if sent, err := e.arrowSendAndWait(ctx, md); err != nil {
+	sent, err := e.arrowSendAndWait(ctx, md)
+	if errors.Is(err, arrow.ErrFallback) {
+		// Part of the data was sent with Arrow.
+		md, err = arrow.RemainingData(err, md).(pmetric.Metrics), nil
+	}
+	if err != nil {
 		return err
 	} else if sent {
 		return nil
 	}
-	req := pmetricotlp.NewExportRequestFromMetrics(md)
+	req := pmetricotlp.NewExportRequestFromMetrics(e.hashMetrics(md))
 	resp, respErr := e.metricExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
 	if err := processError(respErr); err != nil {
 		return err
@@ -205,12 +437,17 @@ func (e *baseExporter) pushMetrics(ctx context.Context, md pmetric.Metrics) erro
 }
 
 func (e *baseExporter) pushLogs(ctx context.Context, ld plog.Logs) error {
-	if sent, err := e.arrowSendAndWait(ctx, ld); err != nil {
+	sent, err := e.arrowSendAndWait(ctx, ld)
+	if errors.Is(err, arrow.ErrFallback) {
+		// Part of the data was sent with Arrow.
+		ld, err = arrow.RemainingData(err, ld).(plog.Logs), nil
+	}
+	if err != nil {
 		return err
 	} else if sent {
 		return nil
 	}
-	req := plogotlp.NewExportRequestFromLogs(ld)
+	req := plogotlp.NewExportRequestFromLogs(e.hashLogs(ld))
 	resp, respErr := e.logExporter.Export(e.enhanceContext(ctx), req, e.callOptions...)
 	if err := processError(respErr); err != nil {
 		return err
@@ -4,7 +4,11 @@
 package otlpexporter
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net"
 	"net/http"
@@ -17,6 +21,7 @@ import (
 
 	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	arrowpbMock "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1/mock"
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -43,6 +48,7 @@ import (
 	"github.com/f5/otel-arrow-adapter/collector/gen/exporter/otlpexporter/internal/arrow/grpcmock"
 	"go.opentelemetry.io/collector/extension"
 	"go.opentelemetry.io/collector/extension/auth"
+	"go.opentelemetry.io/collector/featuregate"
 	"github.com/f5/otel-arrow-adapter/collector/gen/internal/testdata"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
@@ -1134,6 +1140,203 @@ func TestSendArrowFailedTraces(t *testing.T) {
 	assert.EqualValues(t, td, rcv.getLastRequest())
 }
 
+// TestSendArrowSmallBatch tests that batches below the configured
+// size use the standard OTLP path.
+func TestSendArrowSmallBatch(t *testing.T) {
+	exp := &baseExporter{
+		config: &Config{
+			Arrow: ArrowSettings{
+				NumStreams:         1,
+				MinArrowBatchBytes: 1 << 20,
+			},
+		},
+	}
+	td := testdata.GenerateTraces(2)
+	require.Less(t, otlpSize(td), 1<<20)
+
+	sent, err := exp.arrowSendAndWait(context.Background(), td)
+	require.NoError(t, err)
+	require.False(t, sent)
+}
+
+type testHashKeyExtension struct {
+	extension.Extension
+
+	key []byte
+}
+
+func (e *testHashKeyExtension) HashKey() ([]byte, error) {
+	return e.key, nil
+}
+
+// TestSendHashedAttributesWithOTLP tests that the hashed attributes
+// are hashed when the data is sent with standard OTLP instead of
+// Arrow, for small batches and when Arrow is disabled.
+func TestSendHashedAttributesWithOTLP(t *testing.T) {
+	key := []byte("test-key")
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("resource-attr-val-1"))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, arrowDisabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("arrowDisabled=%v", arrowDisabled), func(t *testing.T) {
+			ln, err := net.Listen("tcp", "localhost:")
+			require.NoError(t, err, "Failed to find an available address to run the gRPC server: %v", err)
+			rcv, _ := otlpTracesReceiverOnGRPCServer(ln, false)
+			rcv.start()
+			defer rcv.srv.GracefulStop()
+
+			factory := NewFactory()
+			keyID := component.NewID("testhashkey")
+			cfg := factory.CreateDefaultConfig().(*Config)
+			cfg.GRPCClientSettings = configgrpc.GRPCClientSettings{
+				Endpoint: ln.Addr().String(),
+				TLSSetting: configtls.TLSClientSetting{
+					Insecure: true,
+				},
+			}
+			cfg.Arrow = ArrowSettings{
+				Disabled:           arrowDisabled,
+				NumStreams:         1,
+				MinArrowBatchBytes: 1 << 20,
+				Hashing: HashingSettings{
+					Attributes:  []string{"resource-attr"},
+					KeyProvider: &keyID,
+				},
+			}
+			cfg.QueueSettings.Enabled = false
+
+			set := exportertest.NewNopCreateSettings()
+			set.TelemetrySettings.Logger = zaptest.NewLogger(t)
+			exp, err := factory.CreateTracesExporter(context.Background(), set, cfg)
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, exp.Shutdown(context.Background()))
+			}()
+
+			host := newHostWithExtensions(map[component.ID]component.Component{
+				keyID: &testHashKeyExtension{key: key},
+			})
+			require.NoError(t, exp.Start(context.Background(), host))
+
+			td := testdata.GenerateTraces(2)
+			require.NoError(t, exp.ConsumeTraces(context.Background(), td))
+			require.EqualValues(t, 1, rcv.requestCount.Load())
+
+			value, ok := rcv.getLastRequest().ResourceSpans().At(0).Resource().Attributes().Get("resource-attr")
+			require.True(t, ok)
+			require.Equal(t, expected, value.Str())
+
+			// The input is not modified.
+			value, _ = td.ResourceSpans().At(0).Resource().Attributes().Get("resource-attr")
+			require.Equal(t, "resource-attr-val-1", value.Str())
+		})
+	}
+}
+
+// TestEncryptionKeyProvider tests the lookup of the extension
+// providing the encryption keys.
+func TestEncryptionKeyProvider(t *testing.T) {
+	keys, err := encryption.NewStaticKeyProvider("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	keyID := component.NewID("testkeys")
+	exp := &baseExporter{
+		config: &Config{Arrow: ArrowSettings{EncryptionKeyProvider: &keyID}},
+	}
+
+	_, err = exp.encryptionKeyProvider(newHostWithExtensions(nil))
+	require.ErrorContains(t, err, "not found")
+
+	_, err = exp.encryptionKeyProvider(newHostWithExtensions(map[component.ID]component.Component{
+		keyID: &testHashKeyExtension{},
+	}))
+	require.ErrorContains(t, err, "does not provide encryption keys")
+
+	kp, err := exp.encryptionKeyProvider(newHostWithExtensions(map[component.ID]component.Component{
+		keyID: &testEncryptionKeyExtension{StaticKeyProvider: keys},
+	}))
+	require.NoError(t, err)
+	id, _, err := kp.EncryptionKey()
+	require.NoError(t, err)
+	require.Equal(t, "k1", id)
+}
+
+type testEncryptionKeyExtension struct {
+	extension.Extension
+	*encryption.StaticKeyProvider
+}
+
+// TestArrowFeatureGate tests that toggling the feature gate stops
+// and restarts the Arrow exporter.
+func TestArrowFeatureGate(t *testing.T) {
+	starts := 0
+	exp := &baseExporter{
+		config:   &Config{Arrow: ArrowSettings{NumStreams: 1}},
+		settings: exportertest.NewNopCreateSettings(),
+	}
+	exp.startArrow = func() error {
+		starts++
+		return nil
+	}
+	ctx := context.Background()
+
+	require.True(t, arrowFeatureGate.IsEnabled())
+	require.NoError(t, exp.syncArrowGate(ctx))
+	require.True(t, exp.arrowRunning)
+	require.Equal(t, 1, starts)
+
+	require.NoError(t, featuregate.GlobalRegistry().Set(arrowFeatureGate.ID(), false))
+	defer func() {
+		require.NoError(t, featuregate.GlobalRegistry().Set(arrowFeatureGate.ID(), true))
+	}()
+
+	// Disabled: the data is sent using standard OTLP.
+	sent, err := exp.arrowSendAndWait(ctx, testdata.GenerateTraces(1))
+	require.NoError(t, err)
+	require.False(t, sent)
+	require.False(t, exp.arrowRunning)
+
+	require.NoError(t, featuregate.GlobalRegistry().Set(arrowFeatureGate.ID(), true))
+	require.NoError(t, exp.syncArrowGate(ctx))
+	require.True(t, exp.arrowRunning)
+	require.Equal(t, 2, starts)
+
+	require.NoError(t, exp.shutdown(ctx))
+	require.False(t, exp.arrowRunning)
+}
+
+// TestArrowLazyConnect tests that an idle Arrow exporter is started
+// by the first export.
+func TestArrowLazyConnect(t *testing.T) {
+	starts := 0
+	exp := &baseExporter{
+		config:    &Config{Arrow: ArrowSettings{NumStreams: 1, LazyConnect: true}},
+		settings:  exportertest.NewNopCreateSettings(),
+		signal:    component.DataTypeMetrics,
+		arrowIdle: true,
+	}
+	exp.startArrow = func() error {
+		starts++
+		return nil
+	}
+	ctx := context.Background()
+
+	require.False(t, exp.arrowRunning)
+	require.Equal(t, 0, starts)
+
+	require.NoError(t, exp.syncArrowGate(ctx))
+	require.True(t, exp.arrowRunning)
+	require.False(t, exp.arrowIdle)
+	require.Equal(t, 1, starts)
+
+	// Started once.
+	require.NoError(t, exp.syncArrowGate(ctx))
+	require.Equal(t, 1, starts)
+
+	require.NoError(t, exp.shutdown(ctx))
+	require.False(t, exp.arrowRunning)
+}
+
 func TestUserDialOptions(t *testing.T) {
 	// Start an OTLP-compatible receiver.
 	ln, err := net.Listen("tcp", "127.0.0.1:")
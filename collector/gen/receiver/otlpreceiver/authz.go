@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpreceiver // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver"
+
+import (
+	"fmt"
+
+	"github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver/internal/arrow"
+	"go.opentelemetry.io/collector/component"
+)
+
+// StreamAuthorizer is implemented by the extensions deciding whether
+// the Arrow streams are accepted (see ArrowSettings.Authorizer).  It
+// receives the authenticated identity and the parameters of each
+// stream, and may constrain the accepted streams, e.g., cap the batch
+// size of a tenant.
+type StreamAuthorizer = arrow.StreamAuthorizer
+
+// StreamParams are the parameters of an Arrow stream: its signal and
+// the features and maximum batch size declared by the exporter.
+type StreamParams = arrow.StreamParams
+
+// StreamConstraints are the limits applied to an accepted stream.
+type StreamConstraints = arrow.StreamConstraints
+
+// getStreamAuthorizer returns the StreamAuthorizer extension with the
+// given ID.
+func getStreamAuthorizer(id component.ID, extensions map[component.ID]component.Component) (StreamAuthorizer, error) {
+	ext, ok := extensions[id]
+	if !ok {
+		return nil, fmt.Errorf("stream authorizer %q not found", id)
+	}
+	authorizer, ok := ext.(StreamAuthorizer)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a stream authorizer", id)
+	}
+	return authorizer, nil
+}
@@ -5,7 +5,12 @@ package otlpreceiver // import "github.com/f5/otel-arrow-adapter/collector/gen/r
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/redact"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
 	"go.opentelemetry.io/collector/config/confighttp"
@@ -34,6 +39,116 @@ type ArrowSettings struct {
 
 	// DisableMixedSignals when true prevents mixed-signal gRPC being served.
 	DisableMixedSignals bool `mapstructure:"disable_mixed_signals"`
+
+	// EnableFlight when true serves the Arrow streams carried by the
+	// DoPut and DoExchange methods of the Arrow Flight service, for
+	// the exporters using the Flight transport.
+	EnableFlight bool `mapstructure:"enable_flight"`
+
+	// MaxItemsPerRequest limits the number of spans, data points, or
+	// log records decoded from a single Arrow batch.  The items
+	// beyond the limit are dropped and counted in the partial
+	// success status of the batch.  0 means no limit.
+	MaxItemsPerRequest int `mapstructure:"max_items_per_request"`
+
+	// MaxExpansionFactor rejects the batches whose IPC buffers or
+	// records decompress to more than this factor times their
+	// compressed size, protecting the receiver from compressed
+	// payloads crafted to expand to gigabytes.  The rejected
+	// batches receive an INVALID_ARGUMENT status and their streams
+	// are reset.  0 means no limit.
+	MaxExpansionFactor uint64 `mapstructure:"max_expansion_factor"`
+
+	// MetadataAttributes maps gRPC metadata keys (or per-batch
+	// header names) to resource attributes.  The values found in
+	// a request are set on every resource of the decoded data,
+	// e.g., to record the ingest region or the client version.
+	MetadataAttributes map[string]string `mapstructure:"metadata_attributes"`
+
+	// ProducerHints configures the hints sent to the exporters in
+	// the batch statuses.
+	ProducerHints ProducerHintsSettings `mapstructure:"producer_hints"`
+
+	// Redaction configures the redaction of the decoded batches
+	// before their conversion into the OTLP representation.
+	Redaction RedactionSettings `mapstructure:"redaction"`
+
+	// Authorizer is the ID of an extension implementing
+	// StreamAuthorizer, which accepts or rejects each Arrow stream
+	// given its authenticated identity and parameters, and may
+	// constrain the accepted streams.
+	Authorizer *component.ID `mapstructure:"authorizer"`
+
+	// TraceTracker is the ID of an extension implementing
+	// TraceTracker, whose tracker is fed the spans of every Arrow
+	// traces batch to track the completeness of the traces.
+	TraceTracker *component.ID `mapstructure:"trace_tracker"`
+
+	// EncryptionKeyProvider is the ID of an extension implementing
+	// encryption.KeyProvider, which provides the keys decrypting
+	// the Arrow payloads of the exporters configured with the
+	// same encryption keys.  Every payload must then be
+	// encrypted, the payloads of each stream by a single producer
+	// and the replayed batches are rejected.
+	EncryptionKeyProvider *component.ID `mapstructure:"encryption_key_provider"`
+}
+
+// ProducerHintsSettings configures the hints the receiver sends to the
+// producers of its streams, which may act on them.
+type ProducerHintsSettings struct {
+	// MemoryFraction is the fraction of the per-stream memory
+	// limit above which the producer is asked to reset its
+	// dictionaries.  0 disables the hint.
+	MemoryFraction float64 `mapstructure:"memory_fraction"`
+
+	// PlainEncodingFields lists the fields the producers should
+	// not dictionary encode, using the keys of the exporter's
+	// encoding_overrides, e.g., "spans.name".
+	PlainEncodingFields []string `mapstructure:"plain_encoding_fields"`
+}
+
+// RedactionSettings configures the redaction of the attribute values,
+// log bodies, and span status messages of the Arrow batches.  The
+// string dictionaries of the decoded records are redacted, so each
+// distinct value is scanned once per stream instead of once per
+// occurrence.  Nothing is redacted unless allow_all_keys is false or
+// blocked_values are set.
+type RedactionSettings struct {
+	// AllowAllKeys disables the AllowedKeys check.
+	AllowAllKeys bool `mapstructure:"allow_all_keys"`
+
+	// AllowedKeys lists the attribute keys whose values are kept
+	// when AllowAllKeys is false, the values of the other
+	// attributes are replaced by Mask.
+	AllowedKeys []string `mapstructure:"allowed_keys"`
+
+	// BlockedValues lists regular expressions, the matching parts
+	// of the string values are replaced by Mask.
+	BlockedValues []string `mapstructure:"blocked_values"`
+
+	// Mask is the replacement of the redacted values.
+	Mask string `mapstructure:"mask"`
+
+	// DictionarySize is the number of distinct values whose
+	// redaction is kept by each stream, the dictionary being
+	// reset when full.
+	DictionarySize int `mapstructure:"dictionary_size"`
+}
+
+// enabled returns true if the settings redact anything.
+func (s *RedactionSettings) enabled() bool {
+	return !s.AllowAllKeys || len(s.BlockedValues) != 0
+}
+
+// redactorConfig returns the configuration of the redactor.
+func (s *RedactionSettings) redactorConfig() *redact.Config {
+	return &redact.Config{
+		AllowAllKeys:   s.AllowAllKeys,
+		AllowedKeys:    s.AllowedKeys,
+		BlockedValues:  s.BlockedValues,
+		Mask:           s.Mask,
+		DictionarySize: s.DictionarySize,
+	}
 }
 
 // Config defines configuration for OTLP receiver.
@@ -53,6 +168,36 @@ func (cfg *Config) Validate() error {
 	if cfg.Arrow != nil && !cfg.Arrow.Disabled && cfg.GRPC == nil {
 		return errors.New("must specify at gRPC protocol when using the OTLP+Arrow receiver")
 	}
+	if cfg.Arrow != nil && cfg.Arrow.MaxItemsPerRequest < 0 {
+		return errors.New("max_items_per_request must be non-negative")
+	}
+	if cfg.Arrow != nil && (cfg.Arrow.ProducerHints.MemoryFraction < 0 || cfg.Arrow.ProducerHints.MemoryFraction > 1) {
+		return errors.New("producer_hints: memory_fraction must be between 0 and 1")
+	}
+	if cfg.Arrow != nil && len(cfg.Arrow.ProducerHints.PlainEncodingFields) != 0 {
+		overrides := make(map[string]string, len(cfg.Arrow.ProducerHints.PlainEncodingFields))
+		for _, path := range cfg.Arrow.ProducerHints.PlainEncodingFields {
+			overrides[path] = schema.PlainEncoding
+		}
+		if err := arrowRecord.ValidateEncodingOverrides(overrides); err != nil {
+			return fmt.Errorf("producer_hints: plain_encoding_fields: %w", err)
+		}
+	}
+	if cfg.Arrow != nil {
+		for key, attr := range cfg.Arrow.MetadataAttributes {
+			if attr == "" {
+				return fmt.Errorf("metadata_attributes: empty attribute name for key %q", key)
+			}
+		}
+		if cfg.Arrow.Redaction.DictionarySize <= 0 {
+			return errors.New("redaction: dictionary_size must be greater than 0")
+		}
+		for _, expr := range cfg.Arrow.Redaction.BlockedValues {
+			if _, err := regexp.Compile(expr); err != nil {
+				return fmt.Errorf("redaction: invalid blocked value %q: %w", expr, err)
+			}
+		}
+	}
 	return nil
 }
 
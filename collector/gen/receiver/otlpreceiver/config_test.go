@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/f5/otel-arrow-adapter/pkg/otel/redact"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -129,7 +130,22 @@ func TestUnmarshalConfig(t *testing.T) {
 					},
 				},
 				Arrow: &ArrowSettings{
-					Disabled: false,
+					Disabled:           false,
+					MaxItemsPerRequest: 10000,
+					MaxExpansionFactor: 100,
+					MetadataAttributes: map[string]string{
+						"x-ingest-region": "ingest.region",
+					},
+					ProducerHints: ProducerHintsSettings{
+						MemoryFraction: 0.8,
+					},
+					EnableFlight: true,
+					Redaction: RedactionSettings{
+						AllowedKeys:    []string{"http.method", "http.route", "service.name"},
+						BlockedValues:  []string{"[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{4}"},
+						Mask:           redact.DefaultMask,
+						DictionarySize: redact.DefaultDictionarySize,
+					},
 				},
 			},
 		}, cfg)
@@ -156,7 +172,13 @@ func TestUnmarshalConfigUnix(t *testing.T) {
 					Endpoint: "/tmp/http_otlp.sock",
 					// Transport: "unix",
 				},
-				Arrow: &ArrowSettings{},
+				Arrow: &ArrowSettings{
+					Redaction: RedactionSettings{
+						AllowAllKeys:   true,
+						Mask:           redact.DefaultMask,
+						DictionarySize: redact.DefaultDictionarySize,
+					},
+				},
 			},
 		}, cfg)
 }
@@ -195,6 +217,47 @@ func TestUnmarshalConfigArrowWithoutGRPC(t *testing.T) {
 	assert.EqualError(t, component.ValidateConfig(cfg), "must specify at gRPC protocol when using the OTLP+Arrow receiver")
 }
 
+func TestUnmarshalConfigNegativeMaxItems(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Arrow.MaxItemsPerRequest = -1
+	assert.EqualError(t, component.ValidateConfig(cfg), "max_items_per_request must be non-negative")
+}
+
+func TestUnmarshalConfigInvalidMemoryFraction(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Arrow.ProducerHints.MemoryFraction = 1.5
+	assert.EqualError(t, component.ValidateConfig(cfg), "producer_hints: memory_fraction must be between 0 and 1")
+}
+
+func TestUnmarshalConfigInvalidPlainEncodingField(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Arrow.ProducerHints.PlainEncodingFields = []string{"spans.name"}
+	assert.NoError(t, component.ValidateConfig(cfg))
+	cfg.Arrow.ProducerHints.PlainEncodingFields = []string{"spans.nmae"}
+	assert.ErrorContains(t, component.ValidateConfig(cfg), "producer_hints: plain_encoding_fields:")
+}
+
+func TestUnmarshalConfigEmptyMetadataAttribute(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Arrow.MetadataAttributes = map[string]string{"x-ingest-region": ""}
+	assert.EqualError(t, component.ValidateConfig(cfg), "metadata_attributes: empty attribute name for key \"x-ingest-region\"")
+}
+
+func TestUnmarshalConfigInvalidRedaction(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig().(*Config)
+	cfg.Arrow.Redaction.DictionarySize = 0
+	assert.EqualError(t, component.ValidateConfig(cfg), "redaction: dictionary_size must be greater than 0")
+
+	cfg = factory.CreateDefaultConfig().(*Config)
+	cfg.Arrow.Redaction.BlockedValues = []string{"[0-9"}
+	assert.ErrorContains(t, component.ValidateConfig(cfg), "redaction: invalid blocked value \"[0-9\"")
+}
+
 func TestUnmarshalConfigEmpty(t *testing.T) {
 	factory := NewFactory()
 	cfg := factory.CreateDefaultConfig()
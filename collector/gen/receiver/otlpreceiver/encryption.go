@@ -0,0 +1,27 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpreceiver // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver"
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
+)
+
+// getEncryptionKeyProvider returns the extension with the given ID
+// providing the keys of the encrypted Arrow payloads (see
+// ArrowSettings.EncryptionKeyProvider).
+func getEncryptionKeyProvider(id component.ID, extensions map[component.ID]component.Component) (encryption.KeyProvider, error) {
+	ext, ok := extensions[id]
+	if !ok {
+		return nil, fmt.Errorf("encryption key provider %q not found", id)
+	}
+	kp, ok := ext.(encryption.KeyProvider)
+	if !ok {
+		return nil, fmt.Errorf("extension %q does not provide encryption keys", id)
+	}
+	return kp, nil
+}
@@ -12,6 +12,7 @@ import (
 	"go.opentelemetry.io/collector/config/confignet"
 	"go.opentelemetry.io/collector/consumer"
 	"github.com/f5/otel-arrow-adapter/collector/gen/internal/sharedcomponent"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/redact"
 	"go.opentelemetry.io/collector/receiver"
 )
 
@@ -49,6 +50,11 @@ func createDefaultConfig() component.Config {
 			},
 			Arrow: &ArrowSettings{
 				Disabled: false,
+				Redaction: RedactionSettings{
+					AllowAllKeys:   true,
+					Mask:           redact.DefaultMask,
+					DictionarySize: redact.DefaultDictionarySize,
+				},
 			},
 		},
 	}
@@ -9,9 +9,13 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/multierr"
 	"go.uber.org/zap"
 	"golang.org/x/net/http2/hpack"
@@ -20,6 +24,8 @@ import (
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
+	"github.com/f5/otel-arrow-adapter/collector/gen/internal/netstats"
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamid"
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/configgrpc"
@@ -27,12 +33,19 @@ import (
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/extension/auth"
 	"go.opentelemetry.io/collector/obsreport"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/receiver"
 )
 
 const (
-	streamFormat        = "arrow"
+	// streamFormat is the data format reported by obsreport for the
+	// Arrow streams, distinct from the formats of the OTLP paths.
+	streamFormat        = "otelarrow"
 	hpackMaxDynamicSize = 4096
+
+	meterScopeName = "github.com/f5/otel-arrow-adapter/collector/receiver/otlpreceiver"
 )
 
 var (
@@ -48,6 +61,18 @@ type Consumers interface {
 	Logs() consumer.Logs
 }
 
+// producerHinter is implemented by the consumers suggesting
+// encoding changes to the producer of their stream.
+type producerHinter interface {
+	ProducerHints() *arrowRecord.ProducerHints
+}
+
+// schemaResetter is implemented by the consumers counting the schema
+// resets of their stream.
+type schemaResetter interface {
+	SchemaResets() uint64
+}
+
 type Receiver struct {
 	Consumers
 
@@ -61,6 +86,27 @@ type Receiver struct {
 	gsettings   *configgrpc.GRPCServerSettings
 	authServer  auth.Server
 	newConsumer func() arrowRecord.ConsumerAPI
+
+	// maxItemsPerRequest limits the number of items (spans, data
+	// points, or log records) accepted per request, 0 means no
+	// limit.  The items beyond the limit are rejected and reported
+	// in the batch status.
+	maxItemsPerRequest int
+
+	// metadataAttributes maps request metadata keys to the
+	// resource attributes set from their values, or is empty.
+	metadataAttributes map[string]string
+
+	// authorizer accepts or constrains each stream, or is nil.
+	authorizer StreamAuthorizer
+
+	// decodeDuration records the time spent decoding each Arrow
+	// batch and schemaResets counts the schema resets of the
+	// streams.  Both are nil when their instrument could not be
+	// created.
+	decodeDuration metric.Float64Histogram
+	schemaResets   metric.Int64Counter
+	staticAttr     attribute.KeyValue
 }
 
 // New creates a new Receiver reference.
@@ -70,16 +116,40 @@ func New(
 	obsrecv *obsreport.Receiver,
 	gsettings *configgrpc.GRPCServerSettings,
 	authServer auth.Server,
+	maxItemsPerRequest int,
+	metadataAttributes map[string]string,
+	authorizer StreamAuthorizer,
 	newConsumer func() arrowRecord.ConsumerAPI,
 ) *Receiver {
-	return &Receiver{
-		Consumers:   cs,
-		obsrecv:     obsrecv,
-		telemetry:   set.TelemetrySettings,
-		authServer:  authServer,
-		newConsumer: newConsumer,
-		gsettings:   gsettings,
+	r := &Receiver{
+		Consumers:          cs,
+		obsrecv:            obsrecv,
+		telemetry:          set.TelemetrySettings,
+		authServer:         authServer,
+		newConsumer:        newConsumer,
+		gsettings:          gsettings,
+		maxItemsPerRequest: maxItemsPerRequest,
+		metadataAttributes: metadataAttributes,
+		authorizer:         authorizer,
+		staticAttr:         attribute.String(netstats.ReceiverKey, set.ID.String()),
+	}
+	meter := set.MeterProvider.Meter(meterScopeName)
+	decodeDuration, err := meter.Float64Histogram("receiver_arrow_decode_duration",
+		metric.WithDescription("Duration of the decoding of an Arrow batch into OTLP data."),
+		metric.WithUnit("s"))
+	if err != nil {
+		r.telemetry.Logger.Error("arrow decode duration metrics", zap.Error(err))
+	} else {
+		r.decodeDuration = decodeDuration
 	}
+	schemaResets, err := meter.Int64Counter("receiver_arrow_schema_resets",
+		metric.WithDescription("Number of schema resets of the Arrow streams, each requiring a new schema and new dictionaries."))
+	if err != nil {
+		r.telemetry.Logger.Error("arrow schema reset metrics", zap.Error(err))
+	} else {
+		r.schemaResets = schemaResets
+	}
+	return r
 }
 
 // headerReceiver contains the state necessary to decode per-request metadata
@@ -95,6 +165,11 @@ type headerReceiver struct {
 	// independent of includeMetadata.
 	hasAuthServer bool
 
+	// captureMetadata indicates that headers must be produced
+	// for capturing resource attributes, independent of
+	// includeMetadata.
+	captureMetadata bool
+
 	// client connection info from the stream context, (optionally
 	// if includeMetadata) to be extended with per-request metadata.
 	connInfo client.Info
@@ -108,22 +183,32 @@ type headerReceiver struct {
 
 	// tmpHdrs is used by the decoder's emit function during Write.
 	tmpHdrs map[string][]string
+
+	// streamID is the identity of the exporter's stream, if it
+	// sent one.  It is included in the client metadata independent
+	// of includeMetadata.
+	streamID string
 }
 
-func newHeaderReceiver(streamCtx context.Context, as auth.Server, includeMetadata bool) *headerReceiver {
+func newHeaderReceiver(streamCtx context.Context, as auth.Server, includeMetadata, captureMetadata bool) *headerReceiver {
 	hr := &headerReceiver{
 		includeMetadata: includeMetadata,
 		hasAuthServer:   as != nil,
+		captureMetadata: captureMetadata,
 		connInfo:        client.FromContext(streamCtx),
 	}
 
 	// Note that we capture the incoming context if there is an
-	// Auth plugin configured or includeMetadata is set.
-	if hr.includeMetadata || hr.hasAuthServer {
+	// Auth plugin configured, metadata is captured, or
+	// includeMetadata is set.
+	if hr.includeMetadata || hr.hasAuthServer || hr.captureMetadata {
 		if smd, ok := metadata.FromIncomingContext(streamCtx); ok {
 			hr.streamHdrs = smd
 		}
 	}
+	if ids := metadata.ValueFromIncomingContext(streamCtx, streamid.Header); len(ids) != 0 {
+		hr.streamID = ids[0]
+	}
 
 	// Note the hpack decoder supports additional protections,
 	// such as SetMaxStringLength(), but as we already have limits
@@ -136,7 +221,7 @@ func newHeaderReceiver(streamCtx context.Context, as auth.Server, includeMetadat
 // combineHeaders calculates per-request Metadata by combining the stream's
 // client.Info with additional key:values associated with the arrow batch.
 func (h *headerReceiver) combineHeaders(ctx context.Context, hdrsBytes []byte) (context.Context, map[string][]string, error) {
-	if len(hdrsBytes) == 0 && len(h.streamHdrs) == 0 {
+	if len(hdrsBytes) == 0 && len(h.streamHdrs) == 0 && h.streamID == "" {
 		return ctx, nil, nil
 	}
 
@@ -149,7 +234,7 @@ func (h *headerReceiver) combineHeaders(ctx context.Context, hdrsBytes []byte) (
 	// modifying tmpHdrs if it is nil.
 	h.tmpHdrs = nil
 
-	needMergedHeaders := h.includeMetadata || h.hasAuthServer
+	needMergedHeaders := h.includeMetadata || h.hasAuthServer || h.captureMetadata
 
 	// If headers are being merged, allocate a new map.
 	if needMergedHeaders {
@@ -204,8 +289,13 @@ func (h *headerReceiver) newContext(ctx context.Context, hdrs map[string][]strin
 	// Retain the Addr/Auth of the stream connection, update the
 	// per-request metadata from the Arrow batch.
 	var md client.Metadata
-	if h.includeMetadata && hdrs != nil {
+	switch {
+	case h.includeMetadata && hdrs != nil:
 		md = client.NewMetadata(hdrs)
+	case h.streamID != "":
+		md = client.NewMetadata(map[string][]string{
+			streamid.Header: {h.streamID},
+		})
 	}
 	return client.NewContext(ctx, client.Info{
 		Addr:     h.connInfo.Addr,
@@ -249,19 +339,19 @@ func (r *Receiver) logStreamError(err error) {
 }
 
 func (r *Receiver) ArrowStream(serverStream arrowpb.ArrowStreamService_ArrowStreamServer) error {
-	return r.anyStream(serverStream)
+	return r.anyStream(serverStream, "")
 }
 
 func (r *Receiver) ArrowTraces(serverStream arrowpb.ArrowTracesService_ArrowTracesServer) error {
-	return r.anyStream(serverStream)
+	return r.anyStream(serverStream, "traces")
 }
 
 func (r *Receiver) ArrowLogs(serverStream arrowpb.ArrowLogsService_ArrowLogsServer) error {
-	return r.anyStream(serverStream)
+	return r.anyStream(serverStream, "logs")
 }
 
 func (r *Receiver) ArrowMetrics(serverStream arrowpb.ArrowMetricsService_ArrowMetricsServer) error {
-	return r.anyStream(serverStream)
+	return r.anyStream(serverStream, "metrics")
 }
 
 type anyStreamServer interface {
@@ -270,10 +360,18 @@ type anyStreamServer interface {
 	grpc.ServerStream
 }
 
-func (r *Receiver) anyStream(serverStream anyStreamServer) (retErr error) {
+// anyStream serves a stream of the given signal, empty for the
+// mixed-signal service.
+func (r *Receiver) anyStream(serverStream anyStreamServer, signal string) (retErr error) {
 	streamCtx := serverStream.Context()
 	ac := r.newConsumer()
-	hrcv := newHeaderReceiver(serverStream.Context(), r.authServer, r.gsettings.IncludeMetadata)
+	// resets is the number of schema resets of ac already counted.
+	var resets uint64
+	// authorized is true once the authorizer accepted the stream,
+	// with the per-request item limit maxItems.
+	authorized := r.authorizer == nil
+	maxItems := r.maxItemsPerRequest
+	hrcv := newHeaderReceiver(serverStream.Context(), r.authServer, r.gsettings.IncludeMetadata, len(r.metadataAttributes) != 0)
 
 	defer func() {
 		if err := recover(); err != nil {
@@ -302,7 +400,7 @@ func (r *Receiver) anyStream(serverStream anyStreamServer) (retErr error) {
 		}
 
 		// Check for optional headers and set the incoming context.
-		thisCtx, authHdrs, err := hrcv.combineHeaders(streamCtx, req.GetHeaders())
+		thisCtx, hdrs, err := hrcv.combineHeaders(streamCtx, req.GetHeaders())
 		if err != nil {
 			// Failing to parse the incoming headers breaks the stream.
 			r.telemetry.Logger.Error("arrow metadata error", zap.Error(err))
@@ -312,19 +410,32 @@ func (r *Receiver) anyStream(serverStream anyStreamServer) (retErr error) {
 		var authErr error
 		if r.authServer != nil {
 			var newCtx context.Context
-			if newCtx, err = r.authServer.Authenticate(thisCtx, authHdrs); err != nil {
+			if newCtx, err = r.authServer.Authenticate(thisCtx, hdrs); err != nil {
 				authErr = err
 			} else {
 				thisCtx = newCtx
 			}
 		}
 
+		// The stream is authorized with the context of its first
+		// authenticated batch, before any batch is consumed.
+		if authErr == nil && !authorized {
+			constraints, err := r.authorizer.AuthorizeStream(thisCtx, newStreamParams(streamCtx, signal))
+			if err != nil {
+				r.telemetry.Logger.Debug("arrow stream not authorized", zap.Error(err))
+				return status.Error(codes.PermissionDenied, err.Error())
+			}
+			authorized = true
+			maxItems = limitItems(maxItems, constraints)
+		}
+
 		// Process records: an error in this code path does
 		// not necessarily break the stream.
+		var rejected, decoded int
 		if authErr != nil {
 			err = authErr
 		} else {
-			err = r.processRecords(thisCtx, ac, req)
+			rejected, decoded, err = r.processRecords(thisCtx, ac, req, maxItems, r.captureMetadata(hdrs))
 		}
 
 		// Note: Statuses can be batched, but we do not take
@@ -334,6 +445,16 @@ func (r *Receiver) anyStream(serverStream anyStreamServer) (retErr error) {
 		}
 		if err == nil {
 			status.StatusCode = arrowpb.StatusCode_OK
+			status.DecodedBytes = int64(decoded)
+			if rejected > 0 {
+				// Partial success: the other items were accepted.
+				status.RejectedItems = int64(rejected)
+				status.StatusMessage = fmt.Sprintf("%d items rejected, the limit is %d items per request", rejected, maxItems)
+				r.telemetry.Logger.Debug("arrow items rejected",
+					zap.Int("rejected", rejected),
+					zap.Int("max_items_per_request", maxItems),
+				)
+			}
 		} else {
 			status.StatusMessage = err.Error()
 
@@ -346,6 +467,20 @@ func (r *Receiver) anyStream(serverStream anyStreamServer) (retErr error) {
 			}
 		}
 
+		if sr, ok := ac.(schemaResetter); ok && r.schemaResets != nil {
+			if n := sr.SchemaResets(); n > resets {
+				r.schemaResets.Add(thisCtx, int64(n-resets), metric.WithAttributes(r.staticAttr))
+				resets = n
+			}
+		}
+
+		if ph, ok := ac.(producerHinter); ok {
+			if hints := ph.ProducerHints(); hints != nil {
+				status.ResetDictionaries = hints.ResetDictionaries
+				status.PlainEncodingFields = hints.PlainEncodingFields
+			}
+		}
+
 		err = serverStream.Send(status)
 		if err != nil {
 			r.logStreamError(err)
@@ -354,28 +489,42 @@ func (r *Receiver) anyStream(serverStream anyStreamServer) (retErr error) {
 	}
 }
 
-// processRecords returns an error and a boolean indicating whether
-// the error (true) was from processing the data (i.e., invalid
-// argument) or (false) from the consuming pipeline.  The boolean is
-// not used when success (nil error) is returned.
-func (r *Receiver) processRecords(ctx context.Context, arrowConsumer arrowRecord.ConsumerAPI, records *arrowpb.BatchArrowRecords) error {
+// processRecords returns the number of items rejected because of the
+// per-request limit of maxItems (0 = no limit), the size of the OTLP representation of the
+// decoded data before truncation (reported to the exporter in the
+// batch status) and an error, which is permanent when it was from
+// processing the data (i.e., invalid argument) and not from the
+// consuming pipeline.  The captured attributes, if any, are set on
+// every resource of the decoded data.
+func (r *Receiver) processRecords(ctx context.Context, arrowConsumer arrowRecord.ConsumerAPI, records *arrowpb.BatchArrowRecords, maxItems int, captured capturedAttributes) (int, int, error) {
 	payloads := records.GetArrowPayloads()
 	if len(payloads) == 0 {
-		return nil
+		return 0, 0, nil
+	}
+	var budget *itemBudget
+	if maxItems > 0 {
+		budget = &itemBudget{remaining: maxItems}
 	}
 	switch payloads[0].Type {
 	case arrowpb.ArrowPayloadType_METRICS:
 		if r.Metrics() == nil {
-			return status.Error(codes.Unimplemented, "metrics service not available")
+			return 0, 0, status.Error(codes.Unimplemented, "metrics service not available")
 		}
-		var numPts int
+		var numPts, decoded int
 		ctx = r.obsrecv.StartMetricsOp(ctx)
 
+		start := time.Now()
 		otlp, err := arrowConsumer.MetricsFrom(records)
+		r.recordDecodeDuration(ctx, start, "metrics")
 		if err != nil {
-			err = consumererror.NewPermanent(err)
+			err = decodeError(err)
 		} else {
 			for _, metrics := range otlp {
+				decoded += (&pmetric.ProtoMarshaler{}).MetricsSize(metrics)
+				if budget != nil {
+					budget.truncateMetrics(metrics)
+				}
+				captured.applyMetrics(metrics)
 				numPts += metrics.DataPointCount()
 				err = multierr.Append(err,
 					r.Metrics().ConsumeMetrics(ctx, metrics),
@@ -383,20 +532,27 @@ func (r *Receiver) processRecords(ctx context.Context, arrowConsumer arrowRecord
 			}
 		}
 		r.obsrecv.EndMetricsOp(ctx, streamFormat, numPts, err)
-		return err
+		return budget.rejectedItems(), decoded, err
 
 	case arrowpb.ArrowPayloadType_LOGS:
 		if r.Logs() == nil {
-			return status.Error(codes.Unimplemented, "logs service not available")
+			return 0, 0, status.Error(codes.Unimplemented, "logs service not available")
 		}
-		var numLogs int
+		var numLogs, decoded int
 		ctx = r.obsrecv.StartLogsOp(ctx)
 
+		start := time.Now()
 		otlp, err := arrowConsumer.LogsFrom(records)
+		r.recordDecodeDuration(ctx, start, "logs")
 		if err != nil {
-			err = consumererror.NewPermanent(err)
+			err = decodeError(err)
 		} else {
 			for _, logs := range otlp {
+				decoded += (&plog.ProtoMarshaler{}).LogsSize(logs)
+				if budget != nil {
+					budget.truncateLogs(logs)
+				}
+				captured.applyLogs(logs)
 				numLogs += logs.LogRecordCount()
 				err = multierr.Append(err,
 					r.Logs().ConsumeLogs(ctx, logs),
@@ -404,20 +560,27 @@ func (r *Receiver) processRecords(ctx context.Context, arrowConsumer arrowRecord
 			}
 		}
 		r.obsrecv.EndLogsOp(ctx, streamFormat, numLogs, err)
-		return err
+		return budget.rejectedItems(), decoded, err
 
 	case arrowpb.ArrowPayloadType_SPANS:
 		if r.Traces() == nil {
-			return status.Error(codes.Unimplemented, "traces service not available")
+			return 0, 0, status.Error(codes.Unimplemented, "traces service not available")
 		}
-		var numSpans int
+		var numSpans, decoded int
 		ctx = r.obsrecv.StartTracesOp(ctx)
 
+		start := time.Now()
 		otlp, err := arrowConsumer.TracesFrom(records)
+		r.recordDecodeDuration(ctx, start, "traces")
 		if err != nil {
-			err = consumererror.NewPermanent(err)
+			err = decodeError(err)
 		} else {
 			for _, traces := range otlp {
+				decoded += (&ptrace.ProtoMarshaler{}).TracesSize(traces)
+				if budget != nil {
+					budget.truncateTraces(traces)
+				}
+				captured.applyTraces(traces)
 				numSpans += traces.SpanCount()
 				err = multierr.Append(err,
 					r.Traces().ConsumeTraces(ctx, traces),
@@ -425,9 +588,29 @@ func (r *Receiver) processRecords(ctx context.Context, arrowConsumer arrowRecord
 			}
 		}
 		r.obsrecv.EndTracesOp(ctx, streamFormat, numSpans, err)
-		return err
+		return budget.rejectedItems(), decoded, err
 
 	default:
-		return ErrUnrecognizedPayload
+		return 0, 0, ErrUnrecognizedPayload
+	}
+}
+
+// recordDecodeDuration records the time spent decoding a batch of the
+// given signal since start.
+func (r *Receiver) recordDecodeDuration(ctx context.Context, start time.Time, signal string) {
+	if r.decodeDuration == nil {
+		return
+	}
+	r.decodeDuration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(r.staticAttr, attribute.String("signal", signal)))
+}
+
+// decodeError returns the error of a batch that could not be decoded. The
+// error is permanent (i.e. INVALID_ARGUMENT) unless the code of the decoder
+// error is retryable, e.g. when the memory limit of the stream is exceeded.
+func decodeError(err error) error {
+	if werror.CodeOf(err).Retryable() {
+		return err
 	}
+	return consumererror.NewPermanent(err)
 }
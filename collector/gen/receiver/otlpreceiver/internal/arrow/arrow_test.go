@@ -22,9 +22,13 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	"go.uber.org/zap/zaptest"
 	"golang.org/x/net/http2/hpack"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/component"
@@ -33,6 +37,7 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/extension/auth"
 	"github.com/f5/otel-arrow-adapter/collector/gen/internal/testdata"
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamid"
 	"go.opentelemetry.io/collector/obsreport"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -82,6 +87,16 @@ type commonTestCase struct {
 
 	ctxCall  *gomock.Call
 	recvCall *gomock.Call
+
+	// maxItems is the receiver's per-request item limit.
+	maxItems int
+
+	// metadataAttributes maps metadata keys to captured resource
+	// attributes.
+	metadataAttributes map[string]string
+
+	// authorizer accepts or constrains the stream, or is nil.
+	authorizer StreamAuthorizer
 }
 
 type testChannel interface {
@@ -250,10 +265,11 @@ func (ctc *commonTestCase) wait() error {
 	return <-ctc.streamErr
 }
 
-func statusOKFor(batchID int64) *arrowpb.BatchStatus {
+func statusOKFor(batchID int64, decodedBytes int) *arrowpb.BatchStatus {
 	return &arrowpb.BatchStatus{
-		BatchId:    batchID,
-		StatusCode: arrowpb.StatusCode_OK,
+		BatchId:      batchID,
+		StatusCode:   arrowpb.StatusCode_OK,
+		DecodedBytes: int64(decodedBytes),
 	}
 }
 
@@ -319,6 +335,9 @@ func (ctc *commonTestCase) start(newConsumer func() arrowRecord.ConsumerAPI, opt
 		obsrecv,
 		gsettings,
 		authServer,
+		ctc.maxItems,
+		ctc.metadataAttributes,
+		ctc.authorizer,
 		newConsumer,
 	)
 	go func() {
@@ -334,7 +353,7 @@ func TestReceiverTraces(t *testing.T) {
 	batch, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
 	require.NoError(t, err)
 
-	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId)).Times(1).Return(nil)
+	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId, (&ptrace.ProtoMarshaler{}).TracesSize(td))).Times(1).Return(nil)
 
 	ctc.start(ctc.newRealConsumer)
 	ctc.putBatch(batch, nil)
@@ -346,6 +365,47 @@ func TestReceiverTraces(t *testing.T) {
 	require.True(t, errors.Is(err, context.Canceled))
 }
 
+func TestReceiverDecodeDuration(t *testing.T) {
+	tc := healthyTestChannel{}
+	ctc := newCommonTestCase(t, tc)
+
+	rdr := sdkmetric.NewManualReader()
+	ctc.telset.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(rdr))
+
+	td := testdata.GenerateTraces(2)
+	batch, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+
+	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId, (&ptrace.ProtoMarshaler{}).TracesSize(td))).Times(1).Return(nil)
+
+	ctc.start(ctc.newRealConsumer)
+	ctc.putBatch(batch, nil)
+
+	assert.EqualValues(t, td, (<-ctc.consume).Data)
+
+	err = ctc.cancelAndWait()
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, rdr.Collect(context.Background(), &rm))
+
+	var count uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, mm := range sm.Metrics {
+			if mm.Name != "receiver_arrow_decode_duration" {
+				continue
+			}
+			for _, dp := range mm.Data.(metricdata.Histogram[float64]).DataPoints {
+				signal, _ := dp.Attributes.Value("signal")
+				require.Equal(t, "traces", signal.AsString())
+				count += dp.Count
+			}
+		}
+	}
+	require.Equal(t, uint64(1), count)
+}
+
 func TestReceiverLogs(t *testing.T) {
 	tc := healthyTestChannel{}
 	ctc := newCommonTestCase(t, tc)
@@ -354,7 +414,7 @@ func TestReceiverLogs(t *testing.T) {
 	batch, err := ctc.testProducer.BatchArrowRecordsFromLogs(ld)
 	require.NoError(t, err)
 
-	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId)).Times(1).Return(nil)
+	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId, (&plog.ProtoMarshaler{}).LogsSize(ld))).Times(1).Return(nil)
 
 	ctc.start(ctc.newRealConsumer)
 	ctc.putBatch(batch, nil)
@@ -374,22 +434,185 @@ func TestReceiverMetrics(t *testing.T) {
 	batch, err := ctc.testProducer.BatchArrowRecordsFromMetrics(md)
 	require.NoError(t, err)
 
-	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId)).Times(1).Return(nil)
+	statusCh := make(chan *arrowpb.BatchStatus, 1)
+	ctc.stream.EXPECT().Send(gomock.Any()).Times(1).DoAndReturn(func(status *arrowpb.BatchStatus) error {
+		statusCh <- status
+		return nil
+	})
 
 	ctc.start(ctc.newRealConsumer)
 	ctc.putBatch(batch, nil)
 
+	consumed := (<-ctc.consume).Data.(pmetric.Metrics)
 	otelAssert.Equiv(t, []json.Marshaler{
 		compareJSONMetrics{md},
 	}, []json.Marshaler{
-		compareJSONMetrics{(<-ctc.consume).Data.(pmetric.Metrics)},
+		compareJSONMetrics{consumed},
 	})
 
+	// The decoded data points may not be in the original order,
+	// compare with the size of what was consumed.
+	require.Equal(t, statusOKFor(batch.BatchId, (&pmetric.ProtoMarshaler{}).MetricsSize(consumed)), <-statusCh)
+
 	err = ctc.cancelAndWait()
 	require.Error(t, err)
 	require.True(t, errors.Is(err, context.Canceled), "for %v", err)
 }
 
+func TestReceiverMaxItemsPerRequest(t *testing.T) {
+	tc := healthyTestChannel{}
+	ctc := newCommonTestCase(t, tc)
+	ctc.maxItems = 3
+
+	td := testdata.GenerateTraces(5)
+	batch, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+
+	ctc.stream.EXPECT().Send(&arrowpb.BatchStatus{
+		BatchId:       batch.BatchId,
+		StatusCode:    arrowpb.StatusCode_OK,
+		StatusMessage: "2 items rejected, the limit is 3 items per request",
+		RejectedItems: 2,
+		// The decoded size includes the rejected items.
+		DecodedBytes: int64((&ptrace.ProtoMarshaler{}).TracesSize(td)),
+	}).Times(1).Return(nil)
+
+	ctc.start(ctc.newRealConsumer)
+	ctc.putBatch(batch, nil)
+
+	otelAssert.Equiv(t, []json.Marshaler{
+		compareJSONTraces{testdata.GenerateTraces(3)},
+	}, []json.Marshaler{
+		compareJSONTraces{(<-ctc.consume).Data.(ptrace.Traces)},
+	})
+
+	err = ctc.cancelAndWait()
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
+type streamAuthorizerFunc func(context.Context, StreamParams) (StreamConstraints, error)
+
+func (f streamAuthorizerFunc) AuthorizeStream(ctx context.Context, params StreamParams) (StreamConstraints, error) {
+	return f(ctx, params)
+}
+
+func TestReceiverStreamAuthorizerConstraints(t *testing.T) {
+	tc := healthyTestChannel{}
+	ctc := newCommonTestCase(t, tc)
+	ctc.maxItems = 4
+
+	var calls int
+	ctc.authorizer = streamAuthorizerFunc(func(_ context.Context, params StreamParams) (StreamConstraints, error) {
+		calls++
+		require.Equal(t, StreamParams{}, params)
+		return StreamConstraints{MaxItemsPerRequest: 3}, nil
+	})
+
+	td := testdata.GenerateTraces(5)
+	batch1, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+	batch1 = copyBatch(batch1)
+	batch2, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+
+	for _, batch := range []*arrowpb.BatchArrowRecords{batch1, batch2} {
+		ctc.stream.EXPECT().Send(&arrowpb.BatchStatus{
+			BatchId:       batch.BatchId,
+			StatusCode:    arrowpb.StatusCode_OK,
+			StatusMessage: "2 items rejected, the limit is 3 items per request",
+			RejectedItems: 2,
+			DecodedBytes:  int64((&ptrace.ProtoMarshaler{}).TracesSize(td)),
+		}).Times(1).Return(nil)
+	}
+
+	ctc.start(ctc.newRealConsumer)
+	for _, batch := range []*arrowpb.BatchArrowRecords{batch1, batch2} {
+		ctc.putBatch(batch, nil)
+		require.Equal(t, 3, (<-ctc.consume).Data.(ptrace.Traces).SpanCount())
+	}
+
+	err = ctc.cancelAndWait()
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+
+	// The stream is authorized once.
+	require.Equal(t, 1, calls)
+}
+
+func TestReceiverStreamAuthorizerReject(t *testing.T) {
+	tc := healthyTestChannel{}
+	ctc := newCommonTestCase(t, tc)
+	ctc.authorizer = streamAuthorizerFunc(func(context.Context, StreamParams) (StreamConstraints, error) {
+		return StreamConstraints{}, fmt.Errorf("tenant over quota")
+	})
+
+	td := testdata.GenerateTraces(2)
+	batch, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+
+	// The stream ends without a status nor consuming the batch.
+	ctc.start(ctc.newRealConsumer)
+	ctc.putBatch(batch, nil)
+
+	err = ctc.wait()
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Contains(t, err.Error(), "tenant over quota")
+}
+
+func TestLimitItems(t *testing.T) {
+	require.Equal(t, 0, limitItems(0, StreamConstraints{}))
+	require.Equal(t, 3, limitItems(0, StreamConstraints{MaxItemsPerRequest: 3}))
+	require.Equal(t, 3, limitItems(3, StreamConstraints{MaxItemsPerRequest: 5}))
+	require.Equal(t, 2, limitItems(3, StreamConstraints{MaxItemsPerRequest: 2}))
+}
+
+func TestReceiverCaptureMetadata(t *testing.T) {
+	tc := healthyTestChannel{}
+	ctc := newCommonTestCase(t, tc)
+	ctc.metadataAttributes = map[string]string{
+		"X-Ingest-Region": "ingest.region",
+		"stream_ctx":      "ingest.stream",
+		"missing":         "ingest.missing",
+	}
+
+	td := testdata.GenerateTraces(2)
+	batch, err := ctc.testProducer.BatchArrowRecordsFromTraces(td)
+	require.NoError(t, err)
+	batch = copyBatch(batch)
+
+	var hpb bytes.Buffer
+	hpe := hpack.NewEncoder(&hpb)
+	require.NoError(t, hpe.WriteField(hpack.HeaderField{
+		Name:  "x-ingest-region",
+		Value: "us-east",
+	}))
+	batch.Headers = hpb.Bytes()
+
+	// The decoded size does not include the captured attributes.
+	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId, (&ptrace.ProtoMarshaler{}).TracesSize(td))).Times(1).Return(nil)
+
+	ctc.start(ctc.newRealConsumer)
+	ctc.putBatch(batch, nil)
+
+	expect := testdata.GenerateTraces(2)
+	for i := 0; i < expect.ResourceSpans().Len(); i++ {
+		attrs := expect.ResourceSpans().At(i).Resource().Attributes()
+		attrs.PutStr("ingest.region", "us-east")
+		attrs.PutStr("ingest.stream", "per-request")
+	}
+
+	otelAssert.Equiv(t, []json.Marshaler{
+		compareJSONTraces{expect},
+	}, []json.Marshaler{
+		compareJSONTraces{(<-ctc.consume).Data.(ptrace.Traces)},
+	})
+
+	err = ctc.cancelAndWait()
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
 func TestReceiverRecvError(t *testing.T) {
 	tc := healthyTestChannel{}
 	ctc := newCommonTestCase(t, tc)
@@ -411,7 +634,7 @@ func TestReceiverSendError(t *testing.T) {
 	batch, err := ctc.testProducer.BatchArrowRecordsFromLogs(ld)
 	require.NoError(t, err)
 
-	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId)).Times(1).Return(fmt.Errorf("test send error"))
+	ctc.stream.EXPECT().Send(statusOKFor(batch.BatchId, (&plog.ProtoMarshaler{}).LogsSize(ld))).Times(1).Return(fmt.Errorf("test send error"))
 
 	ctc.start(ctc.newRealConsumer)
 	ctc.putBatch(batch, nil)
@@ -715,7 +938,7 @@ func TestHeaderReceiverStreamContextOnly(t *testing.T) {
 
 	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD(expect))
 
-	h := newHeaderReceiver(ctx, nil, true)
+	h := newHeaderReceiver(ctx, nil, true, false)
 
 	for i := 0; i < 3; i++ {
 		cc, _, err := h.combineHeaders(ctx, nil)
@@ -733,7 +956,7 @@ func TestHeaderReceiverNoIncludeMetadata(t *testing.T) {
 
 	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD(noExpect))
 
-	h := newHeaderReceiver(ctx, nil, false)
+	h := newHeaderReceiver(ctx, nil, false, false)
 
 	for i := 0; i < 3; i++ {
 		cc, _, err := h.combineHeaders(ctx, nil)
@@ -743,6 +966,30 @@ func TestHeaderReceiverNoIncludeMetadata(t *testing.T) {
 	}
 }
 
+func TestHeaderReceiverStreamIdentity(t *testing.T) {
+	md := map[string][]string{
+		"K":             {"k1"},
+		streamid.Header: {"stream-1234"},
+	}
+	expect := map[string][]string{
+		streamid.Header: {"stream-1234"},
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD(md))
+
+	// The stream identity is included without includeMetadata,
+	// the other keys are not.
+	h := newHeaderReceiver(ctx, nil, false, false)
+
+	for i := 0; i < 3; i++ {
+		cc, _, err := h.combineHeaders(ctx, nil)
+
+		require.NoError(t, err)
+		requireContainsAll(t, client.FromContext(cc).Metadata, expect)
+		requireContainsNone(t, client.FromContext(cc).Metadata, map[string][]string{"K": {"k1"}})
+	}
+}
+
 func TestHeaderReceiverAuthServerNoIncludeMetadata(t *testing.T) {
 	expectForAuth := map[string][]string{
 		"L": {"k1", "k2"},
@@ -757,7 +1004,7 @@ func TestHeaderReceiverAuthServerNoIncludeMetadata(t *testing.T) {
 	// The auth server is not called, it just needs to be non-nil.
 	as.EXPECT().Authenticate(gomock.Any(), gomock.Any()).Times(0)
 
-	h := newHeaderReceiver(ctx, as, false)
+	h := newHeaderReceiver(ctx, as, false, false)
 
 	for i := 0; i < 3; i++ {
 		cc, hdrs, err := h.combineHeaders(ctx, nil)
@@ -787,7 +1034,7 @@ func TestHeaderReceiverRequestNoStreamMetadata(t *testing.T) {
 
 	ctx := context.Background()
 
-	h := newHeaderReceiver(ctx, nil, true)
+	h := newHeaderReceiver(ctx, nil, true, false)
 
 	for i := 0; i < 3; i++ {
 		hpb.Reset()
@@ -827,7 +1074,7 @@ func TestHeaderReceiverAuthServerIsSetNoIncludeMetadata(t *testing.T) {
 	// The auth server is not called, it just needs to be non-nil.
 	as.EXPECT().Authenticate(gomock.Any(), gomock.Any()).Times(0)
 
-	h := newHeaderReceiver(ctx, as, true)
+	h := newHeaderReceiver(ctx, as, true, false)
 
 	for i := 0; i < 3; i++ {
 		hpb.Reset()
@@ -886,7 +1133,7 @@ func TestHeaderReceiverBothMetadata(t *testing.T) {
 
 	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD(expectK))
 
-	h := newHeaderReceiver(ctx, nil, true)
+	h := newHeaderReceiver(ctx, nil, true, false)
 
 	for i := 0; i < 3; i++ {
 		hpb.Reset()
@@ -932,7 +1179,7 @@ func TestHeaderReceiverDuplicateMetadata(t *testing.T) {
 
 	ctx := metadata.NewIncomingContext(context.Background(), metadata.MD(expectStream))
 
-	h := newHeaderReceiver(ctx, nil, true)
+	h := newHeaderReceiver(ctx, nil, true, false)
 
 	for i := 0; i < 3; i++ {
 		hpb.Reset()
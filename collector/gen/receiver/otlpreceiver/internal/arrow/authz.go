@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver/internal/arrow"
+
+import (
+	"context"
+
+	"github.com/f5/otel-arrow-adapter/collector/internal/streamparams"
+)
+
+// StreamParams are the parameters of an Arrow stream presented to the
+// StreamAuthorizer.
+type StreamParams struct {
+	// Signal is "traces", "metrics", or "logs" for the
+	// per-signal services, and empty for the mixed-signal
+	// ArrowStream service.
+	Signal string
+
+	// Features lists the features declared by the exporter,
+	// e.g., "hints" or "adaptive".
+	Features []string
+
+	// MaxBatchSize is the maximum number of items per batch
+	// declared by the exporter, 0 when not declared.
+	MaxBatchSize int
+}
+
+// StreamConstraints are the limits applied to an accepted stream, in
+// addition to the limits of the receiver.
+type StreamConstraints struct {
+	// MaxItemsPerRequest limits the number of items decoded from
+	// each batch of the stream.  The receiver's limit applies
+	// when it is lower.  0 means no additional limit.
+	MaxItemsPerRequest int
+}
+
+// StreamAuthorizer decides whether an Arrow stream is accepted.  The
+// context carries the authenticated client.Info of the first batch of
+// the stream.  An error rejects the stream with a PermissionDenied
+// status before any of its batches is consumed.
+type StreamAuthorizer interface {
+	AuthorizeStream(ctx context.Context, params StreamParams) (StreamConstraints, error)
+}
+
+// newStreamParams returns the parameters of a stream of the given
+// signal, declared in its metadata.
+func newStreamParams(streamCtx context.Context, signal string) StreamParams {
+	declared := streamparams.FromIncomingContext(streamCtx)
+	return StreamParams{
+		Signal:       signal,
+		Features:     declared.Features,
+		MaxBatchSize: declared.MaxBatchSize,
+	}
+}
+
+// limitItems returns the per-request item limit of a stream, the
+// lower of the receiver's limit and the constraint, where 0 means no
+// limit.
+func limitItems(limit int, constraints StreamConstraints) int {
+	if constraints.MaxItemsPerRequest > 0 && (limit == 0 || constraints.MaxItemsPerRequest < limit) {
+		return constraints.MaxItemsPerRequest
+	}
+	return limit
+}
@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver/internal/arrow"
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// capturedAttributes are the resource attributes derived from the
+// metadata of a request, keyed by attribute name.
+type capturedAttributes map[string]string
+
+// captureMetadata returns the values of the configured metadata keys
+// found in the request headers.  Multiple values of a key are joined
+// with commas.  Returns nil when nothing was captured.
+func (r *Receiver) captureMetadata(hdrs map[string][]string) capturedAttributes {
+	var captured capturedAttributes
+	for key, attr := range r.metadataAttributes {
+		vals := hdrs[strings.ToLower(key)]
+		if len(vals) == 0 {
+			continue
+		}
+		if captured == nil {
+			captured = capturedAttributes{}
+		}
+		captured[attr] = strings.Join(vals, ",")
+	}
+	return captured
+}
+
+// apply sets the captured attributes on a resource, replacing the
+// existing values of the same attributes.
+func (c capturedAttributes) apply(res pcommon.Resource) {
+	for attr, val := range c {
+		res.Attributes().PutStr(attr, val)
+	}
+}
+
+// applyTraces sets the captured attributes on every resource.
+func (c capturedAttributes) applyTraces(td ptrace.Traces) {
+	for i, rss := 0, td.ResourceSpans(); i < rss.Len(); i++ {
+		c.apply(rss.At(i).Resource())
+	}
+}
+
+// applyLogs sets the captured attributes on every resource.
+func (c capturedAttributes) applyLogs(ld plog.Logs) {
+	for i, rls := 0, ld.ResourceLogs(); i < rls.Len(); i++ {
+		c.apply(rls.At(i).Resource())
+	}
+}
+
+// applyMetrics sets the captured attributes on every resource.
+func (c capturedAttributes) applyMetrics(md pmetric.Metrics) {
+	for i, rms := 0, md.ResourceMetrics(); i < rms.Len(); i++ {
+		c.apply(rms.At(i).Resource())
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver/internal/arrow"
+
+import (
+	"errors"
+
+	"github.com/apache/arrow/go/v12/arrow/flight"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/f5/otel-arrow-adapter/collector/internal/arrowflight"
+)
+
+// FlightServer serves the OTel Arrow streams carried by the DoPut and
+// DoExchange methods of the Arrow Flight service (see arrowflight),
+// each stream being served like those of the OTel Arrow services.
+// The other Flight methods are unimplemented.
+type FlightServer struct {
+	flight.BaseFlightServer
+
+	receiver *Receiver
+}
+
+// NewFlightServer returns a FlightServer serving the streams with the
+// receiver.
+func NewFlightServer(r *Receiver) *FlightServer {
+	return &FlightServer{receiver: r}
+}
+
+func (s *FlightServer) DoPut(stream flight.FlightService_DoPutServer) error {
+	serverStream, signal, err := arrowflight.NewPutServerStream(stream)
+	if err != nil {
+		return s.flightStreamError(err)
+	}
+	return s.receiver.anyStream(serverStream, signal)
+}
+
+func (s *FlightServer) DoExchange(stream flight.FlightService_DoExchangeServer) error {
+	serverStream, signal, err := arrowflight.NewExchangeServerStream(stream)
+	if err != nil {
+		return s.flightStreamError(err)
+	}
+	return s.receiver.anyStream(serverStream, signal)
+}
+
+// flightStreamError returns the error of a stream failing before its
+// first batch, an invalid descriptor being an invalid argument.
+func (s *FlightServer) flightStreamError(err error) error {
+	if errors.Is(err, arrowflight.ErrInvalidDescriptor) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.receiver.logStreamError(err)
+	return err
+}
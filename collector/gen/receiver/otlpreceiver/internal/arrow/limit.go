@@ -0,0 +1,119 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrow // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver/internal/arrow"
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// itemBudget is the number of items (spans, data points, or log
+// records) that may still be accepted for a request.  Items beyond
+// the budget are removed from the data and counted as rejected.
+type itemBudget struct {
+	remaining int
+	rejected  int
+}
+
+// take returns true when one more item can be accepted.
+func (b *itemBudget) take() bool {
+	if b.remaining > 0 {
+		b.remaining--
+		return true
+	}
+	b.rejected++
+	return false
+}
+
+// rejectedItems returns the number of items rejected, a nil budget
+// (no limit) rejects nothing.
+func (b *itemBudget) rejectedItems() int {
+	if b == nil {
+		return 0
+	}
+	return b.rejected
+}
+
+// truncateTraces removes the spans beyond the budget, along with the
+// scopes and resources left empty.
+func (b *itemBudget) truncateTraces(td ptrace.Traces) {
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		before := rs.ScopeSpans().Len()
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			before := ss.Spans().Len()
+			ss.Spans().RemoveIf(func(ptrace.Span) bool {
+				return !b.take()
+			})
+			return before > 0 && ss.Spans().Len() == 0
+		})
+		return before > 0 && rs.ScopeSpans().Len() == 0
+	})
+}
+
+// truncateLogs removes the log records beyond the budget, along with
+// the scopes and resources left empty.
+func (b *itemBudget) truncateLogs(ld plog.Logs) {
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		before := rl.ScopeLogs().Len()
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			before := sl.LogRecords().Len()
+			sl.LogRecords().RemoveIf(func(plog.LogRecord) bool {
+				return !b.take()
+			})
+			return before > 0 && sl.LogRecords().Len() == 0
+		})
+		return before > 0 && rl.ScopeLogs().Len() == 0
+	})
+}
+
+// truncateMetrics removes the data points beyond the budget, along
+// with the metrics, scopes and resources left empty.
+func (b *itemBudget) truncateMetrics(md pmetric.Metrics) {
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		before := rm.ScopeMetrics().Len()
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			before := sm.Metrics().Len()
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				return b.truncateDataPoints(m)
+			})
+			return before > 0 && sm.Metrics().Len() == 0
+		})
+		return before > 0 && rm.ScopeMetrics().Len() == 0
+	})
+}
+
+// truncateDataPoints removes the data points of m beyond the budget
+// and returns true when m was left without data points.
+func (b *itemBudget) truncateDataPoints(m pmetric.Metric) bool {
+	var before, after int
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		before = dps.Len()
+		dps.RemoveIf(func(pmetric.NumberDataPoint) bool { return !b.take() })
+		after = dps.Len()
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		before = dps.Len()
+		dps.RemoveIf(func(pmetric.NumberDataPoint) bool { return !b.take() })
+		after = dps.Len()
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		before = dps.Len()
+		dps.RemoveIf(func(pmetric.HistogramDataPoint) bool { return !b.take() })
+		after = dps.Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		before = dps.Len()
+		dps.RemoveIf(func(pmetric.ExponentialHistogramDataPoint) bool { return !b.take() })
+		after = dps.Len()
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		before = dps.Len()
+		dps.RemoveIf(func(pmetric.SummaryDataPoint) bool { return !b.take() })
+		after = dps.Len()
+	}
+	return before > 0 && after == 0
+}
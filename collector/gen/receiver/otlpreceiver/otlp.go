@@ -11,8 +11,10 @@ import (
 	"net/http"
 	"sync"
 
+	"github.com/apache/arrow/go/v12/arrow/flight"
 	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/redact"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
@@ -148,13 +150,57 @@ func (r *otlpReceiver) startProtocolServers(host component.Host) error {
 				}
 			}
 
-			r.arrowReceiver = arrow.New(arrow.Consumers(r), r.settings, r.obsrepGRPC, r.cfg.GRPC, authServer, func() arrowRecord.ConsumerAPI {
-				return arrowRecord.NewConsumer()
+			var authorizer arrow.StreamAuthorizer
+			if r.cfg.Arrow.Authorizer != nil {
+				authorizer, err = getStreamAuthorizer(*r.cfg.Arrow.Authorizer, host.GetExtensions())
+				if err != nil {
+					return err
+				}
+			}
+
+			consumerOptions := []arrowRecord.ConsumerOption{
+				arrowRecord.WithUnknownColumnsHandler(func(err error) {
+					r.settings.Logger.Warn("skipping unknown columns of the arrow records", zap.Error(err))
+				}),
+			}
+			if r.cfg.Arrow.TraceTracker != nil {
+				tracker, err := getTraceTracker(*r.cfg.Arrow.TraceTracker, host.GetExtensions())
+				if err != nil {
+					return err
+				}
+				consumerOptions = append(consumerOptions, arrowRecord.WithTraceCompleteness(tracker.Tracker()))
+			}
+			if r.cfg.Arrow.EncryptionKeyProvider != nil {
+				kp, err := getEncryptionKeyProvider(*r.cfg.Arrow.EncryptionKeyProvider, host.GetExtensions())
+				if err != nil {
+					return err
+				}
+				consumerOptions = append(consumerOptions, arrowRecord.WithDecryption(kp))
+			}
+			if r.cfg.Arrow.MaxExpansionFactor > 0 {
+				consumerOptions = append(consumerOptions, arrowRecord.WithMaxExpansion(r.cfg.Arrow.MaxExpansionFactor))
+			}
+			if hints := r.cfg.Arrow.ProducerHints; hints.MemoryFraction > 0 || len(hints.PlainEncodingFields) != 0 {
+				consumerOptions = append(consumerOptions, arrowRecord.WithProducerHints(hints.MemoryFraction, hints.PlainEncodingFields...))
+			}
+			if r.cfg.Arrow.Redaction.enabled() {
+				redactor, err := redact.NewRedactor(r.cfg.Arrow.Redaction.redactorConfig())
+				if err != nil {
+					return err
+				}
+				consumerOptions = append(consumerOptions, arrowRecord.WithRedactor(redactor))
+			}
+
+			r.arrowReceiver = arrow.New(arrow.Consumers(r), r.settings, r.obsrepGRPC, r.cfg.GRPC, authServer, r.cfg.Arrow.MaxItemsPerRequest, r.cfg.Arrow.MetadataAttributes, authorizer, func() arrowRecord.ConsumerAPI {
+				return arrowRecord.NewConsumerWithOptions(consumerOptions...)
 			})
 
 			if !r.cfg.Arrow.DisableMixedSignals {
 				arrowpb.RegisterArrowStreamServiceServer(r.serverGRPC, r.arrowReceiver)
 			}
+			if r.cfg.Arrow.EnableFlight {
+				flight.RegisterFlightServiceServer(r.serverGRPC, arrow.NewFlightServer(r.arrowReceiver))
+			}
 		}
 
 		if r.tracesReceiver != nil {
@@ -0,0 +1,34 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package otlpreceiver // import "github.com/f5/otel-arrow-adapter/collector/gen/receiver/otlpreceiver"
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
+)
+
+// TraceTracker is implemented by the extensions tracking the
+// completeness of the traces received over Arrow (see
+// ArrowSettings.TraceTracker).  The tracker is shared by all the
+// streams of the receiver.
+type TraceTracker interface {
+	Tracker() *completeness.Tracker
+}
+
+// getTraceTracker returns the TraceTracker extension with the given
+// ID.
+func getTraceTracker(id component.ID, extensions map[component.ID]component.Component) (TraceTracker, error) {
+	ext, ok := extensions[id]
+	if !ok {
+		return nil, fmt.Errorf("trace tracker %q not found", id)
+	}
+	tracker, ok := ext.(TraceTracker)
+	if !ok {
+		return nil, fmt.Errorf("extension %q is not a trace tracker", id)
+	}
+	return tracker, nil
+}
@@ -0,0 +1,327 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package arrowflight carries the OTel Arrow streams over the DoPut and
+// DoExchange methods of the Arrow Flight service, for interoperability
+// with Flight-based data infrastructure (proxies, gateways, or storage
+// services already speaking Flight).
+//
+// The Arrow payloads of each BatchArrowRecords are split into their IPC
+// messages, each message being sent as the data header and data body
+// of a FlightData message.  The first message of a payload carries the
+// payload, without its record, as application metadata, identifying
+// the schema and the type of the following messages.  A FlightData
+// message without data header then carries the batch, without its
+// payloads, as application metadata, and ends the batch.  The first
+// message of a stream carries a descriptor identifying the signal of
+// the stream.  The batch statuses are returned as the application
+// metadata of the PutResult (DoPut) or FlightData (DoExchange)
+// messages.
+package arrowflight // import "github.com/f5/otel-arrow-adapter/collector/internal/arrowflight"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/arrow/go/v12/arrow/flight"
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Method is the Flight method carrying the streams.
+type Method string
+
+const (
+	// MethodDoExchange carries the streams over DoExchange, the
+	// batch statuses being returned as FlightData messages.
+	MethodDoExchange Method = "do_exchange"
+
+	// MethodDoPut carries the streams over DoPut, the batch
+	// statuses being returned as PutResult messages.
+	MethodDoPut Method = "do_put"
+)
+
+// PathPrefix is the first element of the descriptor path of the OTel
+// Arrow streams, the second being the signal of a single-signal
+// stream ("traces", "logs", or "metrics").
+const PathPrefix = "otel-arrow"
+
+var (
+	// ErrInvalidDescriptor is returned for a stream whose first message
+	// has no descriptor or a descriptor of another kind of stream.
+	ErrInvalidDescriptor = errors.New("invalid OTel Arrow flight descriptor")
+
+	// ErrInvalidMessage is returned for an IPC message which is not
+	// part of a payload, and for a payload without IPC message.
+	ErrInvalidMessage = errors.New("invalid OTel Arrow flight message")
+)
+
+// Validate returns an error for an unrecognized method.  The empty
+// method is equivalent to MethodDoExchange.
+func (m Method) Validate() error {
+	switch m {
+	case "", MethodDoExchange, MethodDoPut:
+		return nil
+	}
+	return fmt.Errorf("unrecognized flight method: %q", m)
+}
+
+// Descriptor returns the descriptor of a stream of the given signal,
+// empty for a mixed-signal stream.
+func Descriptor(signal string) *flight.FlightDescriptor {
+	path := []string{PathPrefix}
+	if signal != "" {
+		path = append(path, signal)
+	}
+	return &flight.FlightDescriptor{
+		Type: flight.DescriptorPATH,
+		Path: path,
+	}
+}
+
+// SignalFromDescriptor returns the signal of a stream given its
+// descriptor, empty for a mixed-signal stream.
+func SignalFromDescriptor(desc *flight.FlightDescriptor) (string, error) {
+	if desc == nil || desc.Type != flight.DescriptorPATH || len(desc.Path) == 0 || len(desc.Path) > 2 || desc.Path[0] != PathPrefix {
+		return "", ErrInvalidDescriptor
+	}
+	if len(desc.Path) == 1 {
+		return "", nil
+	}
+	switch signal := desc.Path[1]; signal {
+	case "traces", "logs", "metrics":
+		return signal, nil
+	}
+	return "", ErrInvalidDescriptor
+}
+
+// flightDataClient is implemented by the DoPut and DoExchange client
+// streams.
+type flightDataClient interface {
+	Send(*flight.FlightData) error
+	grpc.ClientStream
+}
+
+// ClientStream is an OTel Arrow client stream carried by a Flight
+// stream.
+type ClientStream struct {
+	flightDataClient
+
+	// recv returns the application metadata of the next message.
+	recv func() ([]byte, error)
+
+	// desc is sent with the first batch, it is nil afterwards.
+	desc *flight.FlightDescriptor
+}
+
+// NewClientStream opens a Flight stream carrying an OTel Arrow stream of
+// the given signal, empty for mixed signals.
+func NewClientStream(ctx context.Context, client flight.Client, method Method, signal string, opts ...grpc.CallOption) (*ClientStream, error) {
+	cs := &ClientStream{
+		desc: Descriptor(signal),
+	}
+	switch method {
+	case MethodDoPut:
+		stream, err := client.DoPut(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		cs.flightDataClient = stream
+		cs.recv = func() ([]byte, error) {
+			res, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return res.AppMetadata, nil
+		}
+	default:
+		stream, err := client.DoExchange(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		cs.flightDataClient = stream
+		cs.recv = func() ([]byte, error) {
+			data, err := stream.Recv()
+			if err != nil {
+				return nil, err
+			}
+			return data.AppMetadata, nil
+		}
+	}
+	return cs, nil
+}
+
+// Send sends a batch, the first message of the stream carrying its
+// descriptor.
+func (cs *ClientStream) Send(batch *arrowpb.BatchArrowRecords) error {
+	for _, payload := range batch.ArrowPayloads {
+		messages, err := splitMessages(payload.Record)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return fmt.Errorf("%w: empty payload of type %s", ErrInvalidMessage, payload.Type)
+		}
+		meta, err := proto.Marshal(&arrowpb.ArrowPayload{
+			SchemaId: payload.SchemaId,
+			Type:     payload.Type,
+		})
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if err := cs.send(&flight.FlightData{
+				DataHeader:  msg.header,
+				DataBody:    msg.body,
+				AppMetadata: meta,
+			}); err != nil {
+				return err
+			}
+			meta = nil
+		}
+	}
+	meta, err := proto.Marshal(&arrowpb.BatchArrowRecords{
+		BatchId: batch.BatchId,
+		Headers: batch.Headers,
+	})
+	if err != nil {
+		return err
+	}
+	return cs.send(&flight.FlightData{AppMetadata: meta})
+}
+
+func (cs *ClientStream) send(data *flight.FlightData) error {
+	data.FlightDescriptor = cs.desc
+	if err := cs.flightDataClient.Send(data); err != nil {
+		return err
+	}
+	cs.desc = nil
+	return nil
+}
+
+// Recv receives the next batch status.
+func (cs *ClientStream) Recv() (*arrowpb.BatchStatus, error) {
+	meta, err := cs.recv()
+	if err != nil {
+		return nil, err
+	}
+	status := &arrowpb.BatchStatus{}
+	if err := proto.Unmarshal(meta, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ServerStream is an OTel Arrow server stream carried by a Flight
+// stream.
+type ServerStream struct {
+	grpc.ServerStream
+
+	send func([]byte) error
+	recv func() (*flight.FlightData, error)
+
+	// first is the first message, read to obtain the descriptor of
+	// the stream, it is nil once returned by next.
+	first *flight.FlightData
+}
+
+// NewPutServerStream returns the OTel Arrow stream carried by a DoPut
+// stream and its signal, empty for mixed signals.  The first message
+// of the stream is read to obtain its descriptor.
+func NewPutServerStream(stream flight.FlightService_DoPutServer) (*ServerStream, string, error) {
+	ss := &ServerStream{
+		ServerStream: stream,
+		send: func(meta []byte) error {
+			return stream.Send(&flight.PutResult{AppMetadata: meta})
+		},
+		recv: stream.Recv,
+	}
+	return ss.init()
+}
+
+// NewExchangeServerStream returns the OTel Arrow stream carried by a
+// DoExchange stream and its signal, empty for mixed signals.  The first
+// message of the stream is read to obtain its descriptor.
+func NewExchangeServerStream(stream flight.FlightService_DoExchangeServer) (*ServerStream, string, error) {
+	ss := &ServerStream{
+		ServerStream: stream,
+		send: func(meta []byte) error {
+			return stream.Send(&flight.FlightData{AppMetadata: meta})
+		},
+		recv: stream.Recv,
+	}
+	return ss.init()
+}
+
+func (ss *ServerStream) init() (*ServerStream, string, error) {
+	data, err := ss.recv()
+	if err != nil {
+		return nil, "", err
+	}
+	signal, err := SignalFromDescriptor(data.FlightDescriptor)
+	if err != nil {
+		return nil, "", err
+	}
+	ss.first = data
+	return ss, signal, nil
+}
+
+// Send sends a batch status.
+func (ss *ServerStream) Send(status *arrowpb.BatchStatus) error {
+	meta, err := proto.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return ss.send(meta)
+}
+
+// Recv receives the next batch, reassembling the IPC streams of its
+// payloads.
+func (ss *ServerStream) Recv() (*arrowpb.BatchArrowRecords, error) {
+	var (
+		payloads []*arrowpb.ArrowPayload
+		record   bytes.Buffer
+	)
+	for {
+		data, err := ss.next()
+		if err != nil {
+			return nil, err
+		}
+		if len(data.DataHeader) == 0 {
+			batch := &arrowpb.BatchArrowRecords{}
+			if err := proto.Unmarshal(data.AppMetadata, batch); err != nil {
+				return nil, err
+			}
+			if len(payloads) != 0 {
+				payloads[len(payloads)-1].Record = record.Bytes()
+			}
+			batch.ArrowPayloads = payloads
+			return batch, nil
+		}
+		if len(data.AppMetadata) != 0 {
+			payload := &arrowpb.ArrowPayload{}
+			if err := proto.Unmarshal(data.AppMetadata, payload); err != nil {
+				return nil, err
+			}
+			if len(payloads) != 0 {
+				payloads[len(payloads)-1].Record = record.Bytes()
+				record = bytes.Buffer{}
+			}
+			payloads = append(payloads, payload)
+		} else if len(payloads) == 0 {
+			return nil, fmt.Errorf("%w: IPC message without payload", ErrInvalidMessage)
+		}
+		joinMessage(&record, data.DataHeader, data.DataBody)
+	}
+}
+
+func (ss *ServerStream) next() (*flight.FlightData, error) {
+	if data := ss.first; data != nil {
+		ss.first = nil
+		return data, nil
+	}
+	return ss.recv()
+}
@@ -0,0 +1,196 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrowflight
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/flight"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// echoServer acknowledges every batch of the streams, reporting their
+// signal and returning the size of their payloads as decoded bytes.
+type echoServer struct {
+	flight.BaseFlightServer
+
+	signals chan string
+}
+
+func (s *echoServer) DoPut(stream flight.FlightService_DoPutServer) error {
+	serverStream, signal, err := NewPutServerStream(stream)
+	if err != nil {
+		return err
+	}
+	return s.echo(serverStream, signal)
+}
+
+func (s *echoServer) DoExchange(stream flight.FlightService_DoExchangeServer) error {
+	serverStream, signal, err := NewExchangeServerStream(stream)
+	if err != nil {
+		return err
+	}
+	return s.echo(serverStream, signal)
+}
+
+func (s *echoServer) echo(stream *ServerStream, signal string) error {
+	s.signals <- signal
+	for {
+		batch, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		var decoded int
+		for _, payload := range batch.ArrowPayloads {
+			decoded += len(payload.Record)
+		}
+		err = stream.Send(&arrowpb.BatchStatus{
+			BatchId:      batch.BatchId,
+			StatusCode:   arrowpb.StatusCode_OK,
+			DecodedBytes: int64(decoded),
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func startEchoServer(t *testing.T) (flight.Client, chan string) {
+	ln, err := net.Listen("tcp", "localhost:")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	server := &echoServer{signals: make(chan string, 1)}
+	flight.RegisterFlightServiceServer(srv, server)
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.Dial(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return flight.NewClientFromConn(cc, nil), server.signals
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	client, signals := startEchoServer(t)
+
+	for _, method := range []Method{MethodDoExchange, MethodDoPut} {
+		for _, signal := range []string{"traces", ""} {
+			ctx, cancel := context.WithCancel(context.Background())
+			stream, err := NewClientStream(ctx, client, method, signal)
+			require.NoError(t, err)
+
+			for batchID := int64(1); batchID <= 3; batchID++ {
+				spans := ipcStream(t, int(batchID))
+				attrs := ipcStream(t, 1)
+				require.NoError(t, stream.Send(&arrowpb.BatchArrowRecords{
+					BatchId: batchID,
+					ArrowPayloads: []*arrowpb.ArrowPayload{{
+						SchemaId: "spans",
+						Type:     arrowpb.ArrowPayloadType_SPANS,
+						Record:   spans,
+					}, {
+						SchemaId: "attrs",
+						Type:     arrowpb.ArrowPayloadType_SPAN_ATTRS,
+						Record:   attrs,
+					}},
+				}))
+				status, err := stream.Recv()
+				require.NoError(t, err)
+				require.Equal(t, batchID, status.BatchId)
+				require.Equal(t, arrowpb.StatusCode_OK, status.StatusCode)
+				require.Equal(t, int64(len(spans)+len(attrs)), status.DecodedBytes)
+			}
+			require.Equal(t, signal, <-signals, "method %s", method)
+
+			require.NoError(t, stream.CloseSend())
+			cancel()
+		}
+	}
+}
+
+// ipcStream returns an IPC stream of the given number of records.
+func ipcStream(t *testing.T, records int) []byte {
+	schema := arrow.NewSchema([]arrow.Field{{Name: "value", Type: arrow.PrimitiveTypes.Int64}}, nil)
+
+	var buf bytes.Buffer
+	writer := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	for i := 0; i < records; i++ {
+		builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+		builder.Field(0).(*array.Int64Builder).AppendValues([]int64{int64(i), int64(i) + 1, int64(i) + 2}, nil)
+		record := builder.NewRecord()
+		require.NoError(t, writer.Write(record))
+		record.Release()
+		builder.Release()
+	}
+	return buf.Bytes()
+}
+
+func TestSplitJoinMessages(t *testing.T) {
+	stream := ipcStream(t, 2)
+
+	messages, err := splitMessages(stream)
+	require.NoError(t, err)
+	// The schema and two record batches.
+	require.Equal(t, 3, len(messages))
+
+	var joined bytes.Buffer
+	for _, msg := range messages {
+		joinMessage(&joined, msg.header, msg.body)
+	}
+	require.Equal(t, stream, joined.Bytes())
+
+	reader, err := ipc.NewReader(bytes.NewReader(joined.Bytes()))
+	require.NoError(t, err)
+	defer reader.Release()
+	records := 0
+	for reader.Next() {
+		require.Equal(t, int64(records), reader.Record().Column(0).(*array.Int64).Value(0))
+		records++
+	}
+	require.Equal(t, 2, records)
+
+	_, err = splitMessages(stream[:len(stream)-1])
+	require.ErrorIs(t, err, ErrInvalidMessage)
+}
+
+func TestMethodValidate(t *testing.T) {
+	require.NoError(t, Method("").Validate())
+	require.NoError(t, MethodDoExchange.Validate())
+	require.NoError(t, MethodDoPut.Validate())
+	require.Error(t, Method("do_get").Validate())
+}
+
+func TestSignalFromDescriptor(t *testing.T) {
+	for _, signal := range []string{"traces", "logs", "metrics", ""} {
+		got, err := SignalFromDescriptor(Descriptor(signal))
+		require.NoError(t, err)
+		require.Equal(t, signal, got)
+	}
+
+	for _, desc := range []*flight.FlightDescriptor{
+		nil,
+		{Type: flight.DescriptorCMD, Cmd: []byte(PathPrefix)},
+		{Type: flight.DescriptorPATH},
+		{Type: flight.DescriptorPATH, Path: []string{"other", "traces"}},
+		{Type: flight.DescriptorPATH, Path: []string{PathPrefix, "profiles"}},
+		{Type: flight.DescriptorPATH, Path: []string{PathPrefix, "traces", "extra"}},
+	} {
+		_, err := SignalFromDescriptor(desc)
+		require.ErrorIs(t, err, ErrInvalidDescriptor)
+	}
+}
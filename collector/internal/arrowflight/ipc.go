@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package arrowflight // import "github.com/f5/otel-arrow-adapter/collector/internal/arrowflight"
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+)
+
+// continuationToken precedes the metadata length of the IPC messages
+// (see the Arrow encapsulated message format).
+const continuationToken uint32 = 0xFFFFFFFF
+
+// message is an IPC message, its header being the flatbuffer metadata
+// of the message, padding included.
+type message struct {
+	header []byte
+	body   []byte
+}
+
+// splitMessages splits an IPC stream into its messages, stopping at the
+// end-of-stream marker if present.  The messages reference the stream.
+func splitMessages(stream []byte) ([]message, error) {
+	var messages []message
+	for len(stream) != 0 {
+		if len(stream) < 4 {
+			return nil, fmt.Errorf("%w: truncated IPC message", ErrInvalidMessage)
+		}
+		metaLen := binary.LittleEndian.Uint32(stream)
+		stream = stream[4:]
+		if metaLen == continuationToken {
+			if len(stream) < 4 {
+				return nil, fmt.Errorf("%w: truncated IPC message", ErrInvalidMessage)
+			}
+			metaLen = binary.LittleEndian.Uint32(stream)
+			stream = stream[4:]
+		}
+		if metaLen == 0 {
+			break
+		}
+		if uint64(metaLen) > uint64(len(stream)) {
+			return nil, fmt.Errorf("%w: truncated IPC message metadata", ErrInvalidMessage)
+		}
+		header := stream[:metaLen]
+		stream = stream[metaLen:]
+
+		msg := ipc.NewMessage(memory.NewBufferBytes(header), memory.NewBufferBytes(nil))
+		bodyLen := msg.BodyLen()
+		msg.Release()
+		if bodyLen < 0 || bodyLen > int64(len(stream)) {
+			return nil, fmt.Errorf("%w: truncated IPC message body", ErrInvalidMessage)
+		}
+		messages = append(messages, message{
+			header: header,
+			body:   stream[:bodyLen],
+		})
+		stream = stream[bodyLen:]
+	}
+	return messages, nil
+}
+
+// joinMessage appends an IPC message to an IPC stream, padding its
+// header to keep the body 8-byte aligned.
+func joinMessage(stream *bytes.Buffer, header, body []byte) {
+	padding := (8 - len(header)%8) % 8
+
+	var prefix [8]byte
+	binary.LittleEndian.PutUint32(prefix[:4], continuationToken)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(header)+padding))
+	stream.Write(prefix[:])
+	stream.Write(header)
+	stream.Write(make([]byte, padding))
+	stream.Write(body)
+}
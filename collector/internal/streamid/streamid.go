@@ -0,0 +1,22 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package streamid defines the identity an Arrow exporter attaches to
+// its streams.  The identity of a stream survives reconnects, so
+// receivers and load balancers in front of them can route every
+// stream of a producer to the same place (e.g., consistent hashing)
+// and keep per-producer state there.
+package streamid // import "github.com/f5/otel-arrow-adapter/collector/internal/streamid"
+
+import (
+	"github.com/google/uuid"
+)
+
+// Header is the gRPC metadata key carrying the stream identity,
+// set by the exporter when it establishes an Arrow stream.
+const Header = "otel-arrow-stream-id"
+
+// New returns a new stream identity.
+func New() string {
+	return uuid.NewString()
+}
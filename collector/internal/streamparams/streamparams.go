@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package streamparams defines the parameters an Arrow exporter
+// declares when it establishes a stream, so receivers can authorize
+// or constrain the stream before accepting its batches.
+package streamparams // import "github.com/f5/otel-arrow-adapter/collector/internal/streamparams"
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// FeaturesHeader is the gRPC metadata key carrying the comma
+	// separated features of the exporter.
+	FeaturesHeader = "otel-arrow-features"
+
+	// MaxBatchSizeHeader is the gRPC metadata key carrying the
+	// maximum number of items per batch of the exporter.
+	MaxBatchSizeHeader = "otel-arrow-max-batch-size"
+)
+
+// Features declared by the exporters.
+const (
+	// FeatureHints means the exporter applies the producer hints
+	// of the batch statuses.
+	FeatureHints = "hints"
+
+	// FeatureAdaptive means the exporter adjusts its batch size
+	// and concurrency to the batch statuses.
+	FeatureAdaptive = "adaptive"
+)
+
+// Params are the parameters declared by an exporter for its streams.
+type Params struct {
+	// Features lists the features of the exporter.
+	Features []string
+
+	// MaxBatchSize is the maximum number of items (spans, data
+	// points, or log records) per batch, 0 when not declared.
+	MaxBatchSize int
+}
+
+// AppendToOutgoingContext returns the context with the declared
+// parameters added to its outgoing metadata.
+func (p Params) AppendToOutgoingContext(ctx context.Context) context.Context {
+	var kv []string
+	if len(p.Features) != 0 {
+		kv = append(kv, FeaturesHeader, strings.Join(p.Features, ","))
+	}
+	if p.MaxBatchSize > 0 {
+		kv = append(kv, MaxBatchSizeHeader, strconv.Itoa(p.MaxBatchSize))
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// FromIncomingContext returns the parameters declared in the incoming
+// metadata of a stream.  Malformed values are ignored.
+func FromIncomingContext(ctx context.Context) Params {
+	var p Params
+	for _, v := range metadata.ValueFromIncomingContext(ctx, FeaturesHeader) {
+		for _, f := range strings.Split(v, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				p.Features = append(p.Features, f)
+			}
+		}
+	}
+	if vs := metadata.ValueFromIncomingContext(ctx, MaxBatchSizeHeader); len(vs) != 0 {
+		if n, err := strconv.Atoi(vs[0]); err == nil && n > 0 {
+			p.MaxBatchSize = n
+		}
+	}
+	return p
+}
@@ -0,0 +1,29 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package streamparams
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestParamsRoundTrip(t *testing.T) {
+	params := Params{
+		Features:     []string{FeatureHints, FeatureAdaptive},
+		MaxBatchSize: 1000,
+	}
+	md, _ := metadata.FromOutgoingContext(params.AppendToOutgoingContext(context.Background()))
+	require.Equal(t, params, FromIncomingContext(metadata.NewIncomingContext(context.Background(), md)))
+
+	// Nothing is declared by default.
+	md, _ = metadata.FromOutgoingContext(Params{}.AppendToOutgoingContext(context.Background()))
+	require.Empty(t, md)
+
+	// Malformed sizes are ignored.
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MaxBatchSizeHeader, "many"))
+	require.Equal(t, Params{}, FromIncomingContext(ctx))
+}
@@ -0,0 +1,293 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filereceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/filereceiver"
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+)
+
+// arrowStreamExtension is the extension of the Arrow IPC stream files
+// written by the file exporter.
+const arrowStreamExtension = ".arrows"
+
+// batchReader decodes batches of Arrow records and passes them to the
+// consumer.
+type batchReader struct {
+	consumer      consumerType
+	timer         *replayTimer
+	arrowConsumer *arrowRecord.Consumer
+}
+
+func newBatchReader(consumer consumerType, timer *replayTimer) batchReader {
+	return batchReader{
+		consumer:      consumer,
+		timer:         timer,
+		arrowConsumer: arrowRecord.NewConsumer(),
+	}
+}
+
+// consume decodes the records of a batch, the records are released.
+func (br batchReader) consume(ctx context.Context, records []*record_message.RecordMessage) error {
+	switch {
+	case br.consumer.tracesConsumer != nil:
+		batches, err := br.arrowConsumer.TracesFromRecords(records)
+		if err != nil {
+			return fmt.Errorf("failed to decode traces: %w", err)
+		}
+		for _, traces := range batches {
+			if err := br.timer.wait(ctx, getFirstTimestampFromTraces(traces)); err != nil {
+				return fmt.Errorf("readBatch interrupted while waiting for timer: %w", err)
+			}
+			if err := br.consumer.tracesConsumer.ConsumeTraces(ctx, traces); err != nil {
+				return err
+			}
+		}
+	case br.consumer.metricsConsumer != nil:
+		batches, err := br.arrowConsumer.MetricsFromRecords(records)
+		if err != nil {
+			return fmt.Errorf("failed to decode metrics: %w", err)
+		}
+		for _, metrics := range batches {
+			if err := br.timer.wait(ctx, getFirstTimestampFromMetrics(metrics)); err != nil {
+				return fmt.Errorf("readBatch interrupted while waiting for timer: %w", err)
+			}
+			if err := br.consumer.metricsConsumer.ConsumeMetrics(ctx, metrics); err != nil {
+				return err
+			}
+		}
+	case br.consumer.logsConsumer != nil:
+		batches, err := br.arrowConsumer.LogsFromRecords(records)
+		if err != nil {
+			return fmt.Errorf("failed to decode logs: %w", err)
+		}
+		for _, logs := range batches {
+			if err := br.timer.wait(ctx, getFirstTimestampFromLogs(logs)); err != nil {
+				return fmt.Errorf("readBatch interrupted while waiting for timer: %w", err)
+			}
+			if err := br.consumer.logsConsumer.ConsumeLogs(ctx, logs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (br batchReader) close() error {
+	return br.arrowConsumer.Close()
+}
+
+// readAllOTAPBatches reads a file of BatchArrowRecords messages, each
+// prefixed by its size encoded as an uvarint, until the end of the file or
+// the context is cancelled.  The batches are fragments of Arrow IPC streams
+// and are decoded in order.
+func (br batchReader) readAllOTAPBatches(ctx context.Context, file *os.File, compression string) error {
+	var reader *bufio.Reader
+	if compression == compressionTypeZSTD {
+		cr, err := zstd.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer cr.Close()
+		reader = bufio.NewReader(cr)
+	} else { // no compression
+		reader = bufio.NewReader(file)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		size, err := binary.ReadUvarint(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return fmt.Errorf("failed to read batch from input file: %w", err)
+		}
+		bar := &arrowpb.BatchArrowRecords{}
+		if err := proto.Unmarshal(data, bar); err != nil {
+			return fmt.Errorf("failed to unmarshal batch: %w", err)
+		}
+		records, err := br.arrowConsumer.Consume(bar)
+		if err != nil {
+			return fmt.Errorf("failed to decode batch: %w", err)
+		}
+		if err := br.consume(ctx, records); err != nil {
+			return err
+		}
+	}
+}
+
+// arrowStream is an Arrow IPC stream file of one payload type, starting at
+// the given batch of its signal.
+type arrowStream struct {
+	path        string
+	payloadType record_message.PayloadType
+	start       int
+
+	file   *os.File
+	reader *ipc.Reader
+}
+
+func (s *arrowStream) open() error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	reader, err := ipc.NewReader(file)
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to read Arrow stream %q: %w", s.path, err)
+	}
+	s.file, s.reader = file, reader
+	return nil
+}
+
+func (s *arrowStream) close() error {
+	if s.reader == nil {
+		return nil
+	}
+	s.reader.Release()
+	s.reader = nil
+	return s.file.Close()
+}
+
+// listArrowStreams returns the Arrow IPC streams of a directory written by
+// the file exporter, grouped by payload type and sorted by first batch.
+func listArrowStreams(dir string) (map[record_message.PayloadType][]*arrowStream, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	streams := make(map[record_message.PayloadType][]*arrowStream)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, arrowStreamExtension) {
+			continue
+		}
+		// The file name is <payload type>-<first batch>.arrows
+		base := strings.TrimSuffix(name, arrowStreamExtension)
+		sep := strings.LastIndexByte(base, '-')
+		if sep < 0 {
+			continue
+		}
+		payloadType, ok := arrowpb.ArrowPayloadType_value[strings.ToUpper(base[:sep])]
+		if !ok {
+			continue
+		}
+		start, err := strconv.Atoi(base[sep+1:])
+		if err != nil {
+			continue
+		}
+		pt := record_message.PayloadType(payloadType)
+		streams[pt] = append(streams[pt], &arrowStream{
+			path:        filepath.Join(dir, name),
+			payloadType: pt,
+			start:       start,
+		})
+	}
+	for _, list := range streams {
+		sort.Slice(list, func(i, j int) bool { return list[i].start < list[j].start })
+	}
+	return streams, nil
+}
+
+// readAllArrowBatches replays the Arrow IPC streams of a directory written
+// by the file exporter.  The streams of a signal are aligned, the n-th
+// record of a stream belongs to the batch of index start+n, so every batch
+// is reassembled from the records of the streams covering it.
+func (br batchReader) readAllArrowBatches(ctx context.Context, dir string) error {
+	streams, err := listArrowStreams(dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, list := range streams {
+			for _, s := range list {
+				_ = s.close()
+			}
+		}
+	}()
+
+	for batch := 0; len(streams) != 0; batch++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		var records []*record_message.RecordMessage
+		for pt, list := range streams {
+			// Skip the streams replaced by a new schema.
+			for len(list) > 1 && list[1].start <= batch {
+				if err := list[0].close(); err != nil {
+					return err
+				}
+				list = list[1:]
+			}
+			streams[pt] = list
+
+			s := list[0]
+			if s.start > batch {
+				continue
+			}
+			if s.reader == nil {
+				if err := s.open(); err != nil {
+					return err
+				}
+			}
+			if !s.reader.Next() {
+				if err := s.reader.Err(); err != nil && !errors.Is(err, io.EOF) {
+					return fmt.Errorf("failed to read Arrow stream %q: %w", s.path, err)
+				}
+				if err := s.close(); err != nil {
+					return err
+				}
+				if len(list) == 1 {
+					delete(streams, pt)
+				} else {
+					streams[pt] = list[1:]
+				}
+				continue
+			}
+			record := s.reader.Record()
+			if record.NumRows() == 0 {
+				continue
+			}
+			record.Retain()
+			records = append(records, record_message.NewRelatedDataMessage(pt.String(), record, pt))
+		}
+		if len(records) == 0 {
+			continue
+		}
+		if err := br.consume(ctx, records); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package filereceiver
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"google.golang.org/protobuf/proto"
+
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+)
+
+func testMetrics(t *testing.T) pmetric.Metrics {
+	data, err := os.ReadFile(filepath.Join("testdata", "metrics.json"))
+	require.NoError(t, err)
+	metrics, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics(data)
+	require.NoError(t, err)
+	return metrics
+}
+
+func TestBatchReader_ReadAllOTAPBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.otap")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	producer := arrowRecord.NewProducer()
+	for i := 0; i < 2; i++ {
+		bar, err := producer.BatchArrowRecordsFromMetrics(testMetrics(t))
+		require.NoError(t, err)
+		data, err := proto.Marshal(bar)
+		require.NoError(t, err)
+		_, err = file.Write(binary.AppendUvarint(nil, uint64(len(data))))
+		require.NoError(t, err)
+		_, err = file.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, producer.Close())
+	require.NoError(t, file.Close())
+
+	tc := testConsumer{}
+	br := newBatchReader(consumerType{metricsConsumer: &tc}, newReplayTimer(0))
+	file, err = os.Open(path)
+	require.NoError(t, err)
+	require.NoError(t, br.readAllOTAPBatches(context.Background(), file, ""))
+	require.NoError(t, file.Close())
+	require.NoError(t, br.close())
+
+	require.Equal(t, 2, len(tc.consumed))
+	for _, metrics := range tc.consumed {
+		assert.Equal(t, 26, metrics.MetricCount())
+	}
+}
+
+// streamWriter writes every record produced to an Arrow IPC stream per
+// payload type, as the file exporter does.
+type streamWriter struct {
+	t       *testing.T
+	dir     string
+	files   []*os.File
+	writers map[record_message.PayloadType]*ipc.Writer
+}
+
+func (w *streamWriter) OnRecord(record arrow.Record, payloadType record_message.PayloadType) {
+	writer, ok := w.writers[payloadType]
+	if !ok {
+		name := fmt.Sprintf("%s-0%s", strings.ToLower(payloadType.String()), arrowStreamExtension)
+		file, err := os.Create(filepath.Join(w.dir, name))
+		require.NoError(w.t, err)
+		w.files = append(w.files, file)
+		writer = ipc.NewWriter(file, ipc.WithSchema(record.Schema()), ipc.WithDictionaryDeltas(true))
+		w.writers[payloadType] = writer
+	}
+	require.NoError(w.t, writer.Write(record))
+}
+
+func TestBatchReader_ReadAllArrowBatches(t *testing.T) {
+	dir := t.TempDir()
+	sw := &streamWriter{
+		t:       t,
+		dir:     dir,
+		writers: make(map[record_message.PayloadType]*ipc.Writer),
+	}
+
+	producer := arrowRecord.NewProducer()
+	producer.SetObserver(sw)
+	for i := 0; i < 2; i++ {
+		_, err := producer.BatchArrowRecordsFromMetrics(testMetrics(t))
+		require.NoError(t, err)
+	}
+	require.NoError(t, producer.Close())
+	for _, writer := range sw.writers {
+		require.NoError(t, writer.Close())
+	}
+	for _, file := range sw.files {
+		require.NoError(t, file.Close())
+	}
+
+	tc := testConsumer{}
+	br := newBatchReader(consumerType{metricsConsumer: &tc}, newReplayTimer(0))
+	require.NoError(t, br.readAllArrowBatches(context.Background(), dir))
+	require.NoError(t, br.close())
+
+	require.Equal(t, 2, len(tc.consumed))
+	for _, metrics := range tc.consumed {
+		assert.Equal(t, 26, metrics.MetricCount())
+	}
+}
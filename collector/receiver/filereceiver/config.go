@@ -12,12 +12,17 @@ import (
 const (
 	formatTypeJSON      = "json"
 	formatTypeProto     = "proto"
+	formatTypeOTAP      = "otap"
+	formatTypeArrow     = "arrow"
 	compressionTypeZSTD = "zstd"
 )
 
 // Config defines the configuration for the file receiver.
 type Config struct {
 	// Path of the file to read from. Path is relative to current directory.
+	// With the arrow format, Path is the directory written by the file
+	// exporter, the streams of the signal are read from its traces, metrics
+	// or logs subdirectory.
 	Path string `mapstructure:"path"`
 	// Throttle determines how fast telemetry is replayed. A value of zero means
 	// that it will be replayed as fast as the system will allow. A value of 1 means
@@ -27,7 +32,9 @@ type Config struct {
 	// to replay telemetry at a higher speed. Default: 1.
 	Throttle float64 `mapstructure:"throttle"`
 	// Format will specify the format of the file to be read.
-	// Currently support json and proto options.
+	// Currently support json, proto, otap (BatchArrowRecords messages
+	// prefixed by their uvarint size) and arrow (the Arrow IPC streams of
+	// the file exporter) options.
 	FormatType string `mapstructure:"format"`
 	// type of compression algorithm used. Currently supports zstd.
 	Compression string `mapstructure:"compression"`
@@ -47,11 +54,16 @@ func (c Config) Validate() error {
 	if c.Throttle < 0 {
 		return errors.New("throttle cannot be negative")
 	}
-	if c.FormatType != "" && c.FormatType != formatTypeJSON && c.FormatType != formatTypeProto {
-		return errors.New("format must be json or proto")
+	switch c.FormatType {
+	case "", formatTypeJSON, formatTypeProto, formatTypeOTAP, formatTypeArrow:
+	default:
+		return errors.New("format must be json, proto, otap or arrow")
 	}
 	if c.Compression != "" && c.Compression != compressionTypeZSTD {
 		return errors.New("compression must be zstd or none")
 	}
+	if c.Compression != "" && c.FormatType == formatTypeArrow {
+		return errors.New("compression is not supported with the arrow format")
+	}
 	return nil
 }
\ No newline at end of file
@@ -47,6 +47,16 @@ func TestLoadConfig(t *testing.T) {
 		}, {
 			id:           component.NewIDWithName(metadata.Type, "2"),
 			errorMessage: "throttle cannot be negative",
+		}, {
+			id: component.NewIDWithName(metadata.Type, "3"),
+			expected: &Config{
+				Path:       "./arrow",
+				Throttle:   1,
+				FormatType: "arrow",
+			},
+		}, {
+			id:           component.NewIDWithName(metadata.Type, "4"),
+			errorMessage: "compression is not supported with the arrow format",
 		},
 	}
 
@@ -9,9 +9,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +22,20 @@ type consumerType struct {
 	tracesConsumer  consumer.Traces
 	logsConsumer    consumer.Logs
 }
+
+// signal returns the name of the signal of the consumer, as used by the
+// file exporter for the directories of the Arrow formats.
+func (c consumerType) signal() string {
+	switch {
+	case c.tracesConsumer != nil:
+		return "traces"
+	case c.metricsConsumer != nil:
+		return "metrics"
+	default:
+		return "logs"
+	}
+}
+
 type fileReceiver struct {
 	consumer    consumerType
 	logger      *zap.Logger
@@ -33,6 +49,10 @@ type fileReceiver struct {
 func (r *fileReceiver) Start(ctx context.Context, _ component.Host) error {
 	ctx, r.cancel = context.WithCancel(ctx)
 
+	if r.format == formatTypeArrow || r.format == formatTypeOTAP {
+		return r.startArrow(ctx)
+	}
+
 	file, err := os.Open(r.path)
 	if err != nil {
 		return fmt.Errorf("failed to open file %q: %w", r.path, err)
@@ -57,6 +77,33 @@ func (r *fileReceiver) Start(ctx context.Context, _ component.Host) error {
 	return nil
 }
 
+// startArrow replays the batches of Arrow records of an OTAP file or of
+// the Arrow IPC streams written by the file exporter.
+func (r *fileReceiver) startArrow(ctx context.Context) error {
+	var file *os.File
+	if r.format == formatTypeOTAP {
+		var err error
+		if file, err = os.Open(r.path); err != nil {
+			return fmt.Errorf("failed to open file %q: %w", r.path, err)
+		}
+	}
+
+	br := newBatchReader(r.consumer, newReplayTimer(r.throttle))
+	go func() {
+		var err error
+		if file != nil {
+			err = br.readAllOTAPBatches(ctx, file, r.compression)
+			err = multierr.Append(err, file.Close())
+		} else {
+			err = br.readAllArrowBatches(ctx, filepath.Join(r.path, r.consumer.signal()))
+		}
+		if err = multierr.Append(err, br.close()); err != nil {
+			r.logger.Error("failed to read input file", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
 func (r *fileReceiver) Shutdown(_ context.Context) error {
 	if r.cancel != nil {
 		r.cancel()
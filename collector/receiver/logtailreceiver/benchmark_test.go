@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+const benchmarkLines = 1000
+
+// writeBenchmarkFile writes a JSON lines file to tail.
+func writeBenchmarkFile(b *testing.B) string {
+	path := filepath.Join(b.TempDir(), "app.log")
+	file, err := os.Create(path)
+	require.NoError(b, err)
+	levels := []string{"info", "warn", "error", "debug"}
+	for i := 0; i < benchmarkLines; i++ {
+		_, err := fmt.Fprintf(file, `{"time":"2023-06-01T10:00:%02d.%03dZ","level":%q,"msg":"request %d served","method":"GET","status":%d}`+"\n",
+			i%60, i%1000, levels[i%len(levels)], i%50, 200+(i%3)*100)
+		require.NoError(b, err)
+	}
+	require.NoError(b, file.Close())
+	return path
+}
+
+// tailFile reads the whole file through the tailer, as the receiver does.
+func tailFile(b *testing.B, path string) []plog.Logs {
+	file, err := os.Open(path)
+	require.NoError(b, err)
+	defer func() { _ = file.Close() }()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Path = path
+	cfg.StartAt = startAtBeginning
+	cfg.PollInterval = time.Millisecond
+	t, err := newTailer(file, cfg, zap.NewNop())
+	require.NoError(b, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var batches []plog.Logs
+	count := 0
+	_ = t.run(ctx, func(logs plog.Logs) {
+		batches = append(batches, logs)
+		if count += logs.LogRecordCount(); count >= benchmarkLines {
+			cancel()
+		}
+	})
+	return batches
+}
+
+// readFileFilelogStyle reads the whole file the way a filelog receiver
+// configured with a json_parser operator does: the raw line is kept as the
+// body, the file name is an attribute, the parsed object is merged into the
+// attributes and the timestamp and severity are promoted from it. The
+// contrib filelog receiver is not a dependency of this module, so its
+// pipeline shape is reproduced here.
+func readFileFilelogStyle(b *testing.B, path string) []plog.Logs {
+	file, err := os.Open(path)
+	require.NoError(b, err)
+	defer func() { _ = file.Close() }()
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lr := records.AppendEmpty()
+		lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+		lr.Body().SetStr(line)
+		lr.Attributes().PutStr("log.file.name", filepath.Base(path))
+
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			continue
+		}
+		if v, ok := obj["time"].(string); ok {
+			if ts, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+			}
+		}
+		if v, ok := obj["level"].(string); ok {
+			lr.SetSeverityText(v)
+			lr.SetSeverityNumber(severityFromText(v))
+		}
+		for k, v := range obj {
+			require.NoError(b, lr.Attributes().PutEmpty(k).FromRaw(v))
+		}
+	}
+	require.NoError(b, scanner.Err())
+	return []plog.Logs{logs}
+}
+
+// BenchmarkTailAndEncode compares reading a file and encoding it with Arrow
+// through the tailer and through a filelog-style pipeline.
+func BenchmarkTailAndEncode(b *testing.B) {
+	path := writeBenchmarkFile(b)
+
+	for _, bench := range []struct {
+		name string
+		read func(*testing.B, string) []plog.Logs
+	}{
+		{"logtail", tailFile},
+		{"filelog", readFileFilelogStyle},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			producer := arrowRecord.NewProducer()
+			defer func() { _ = producer.Close() }()
+			var size int
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				size = 0
+				for _, logs := range bench.read(b, path) {
+					batch, err := producer.BatchArrowRecordsFromLogs(logs)
+					require.NoError(b, err)
+					for _, payload := range batch.ArrowPayloads {
+						size += len(payload.Record)
+					}
+				}
+			}
+			b.ReportMetric(float64(size), "bytes/file")
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+
+import (
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+const (
+	formatSyslog = "syslog"
+	formatJSONL  = "jsonl"
+
+	startAtBeginning = "beginning"
+	startAtEnd       = "end"
+)
+
+// Config defines the configuration for the log tailer receiver.
+type Config struct {
+	// Path of the file to tail.
+	Path string `mapstructure:"path"`
+	// Format of the file, syslog (RFC 5424 or RFC 3164) or jsonl.
+	// Default: jsonl.
+	Format string `mapstructure:"format"`
+	// StartAt defines where to start reading the file, beginning or end.
+	// Default: end.
+	StartAt string `mapstructure:"start_at"`
+	// PollInterval is the delay between two reads once the end of the file
+	// is reached. Default: 200ms.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// MaxBatchSize is the maximum number of log records per batch.
+	// Default: 1000.
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Format:       formatJSONL,
+		StartAt:      startAtEnd,
+		PollInterval: 200 * time.Millisecond,
+		MaxBatchSize: 1000,
+	}
+}
+
+// Validate checks the receiver configuration is valid.
+func (c Config) Validate() error {
+	if c.Path == "" {
+		return errors.New("path cannot be empty")
+	}
+	if c.Format != formatSyslog && c.Format != formatJSONL {
+		return errors.New("format must be syslog or jsonl")
+	}
+	if c.StartAt != startAtBeginning && c.StartAt != startAtEnd {
+		return errors.New("start_at must be beginning or end")
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("poll_interval must be positive")
+	}
+	if c.MaxBatchSize <= 0 {
+		return errors.New("max_batch_size must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate mdatagen metadata.yaml
+
+// Package logtailreceiver implements a receiver tailing a syslog or JSONL
+// file. Timestamps and severities are parsed and promoted to the dedicated log
+// record fields, which map to the `time_unix_nano`, `severity_number` and
+// `severity_text` columns of the Arrow logs payload, and every batch shares a
+// single resource and scope.
+package logtailreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
@@ -0,0 +1,36 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver/internal/metadata"
+)
+
+// NewFactory creates a factory for the log tailer receiver.
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		receiver.WithLogs(createLogsReceiver, metadata.LogsStability),
+	)
+}
+
+func createLogsReceiver(
+	_ context.Context,
+	settings receiver.CreateSettings,
+	cc component.Config,
+	consumer consumer.Logs,
+) (receiver.Logs, error) {
+	return &logTailReceiver{
+		consumer: consumer,
+		logger:   settings.Logger,
+		config:   cc.(*Config),
+	}, nil
+}
@@ -0,0 +1,219 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// lineParser parses a single line into a log record. The severity and the
+// timestamp are always promoted to the corresponding log record fields (and
+// therefore to dedicated Arrow columns) instead of being kept as attributes.
+type lineParser func(line string, lr plog.LogRecord) error
+
+func parserFor(format string) lineParser {
+	if format == formatSyslog {
+		return parseSyslog
+	}
+	return parseJSONLine
+}
+
+// syslogSeverities maps syslog severities (0-7) to OTel severity numbers.
+var syslogSeverities = [8]struct {
+	number plog.SeverityNumber
+	text   string
+}{
+	{plog.SeverityNumberFatal4, "emerg"},
+	{plog.SeverityNumberFatal3, "alert"},
+	{plog.SeverityNumberFatal2, "crit"},
+	{plog.SeverityNumberError, "err"},
+	{plog.SeverityNumberWarn, "warning"},
+	{plog.SeverityNumberInfo2, "notice"},
+	{plog.SeverityNumberInfo, "info"},
+	{plog.SeverityNumberDebug, "debug"},
+}
+
+// parseSyslog parses RFC 5424 and RFC 3164 messages.
+func parseSyslog(line string, lr plog.LogRecord) error {
+	if !strings.HasPrefix(line, "<") {
+		return fmt.Errorf("invalid syslog line: missing priority")
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 {
+		return fmt.Errorf("invalid syslog line: invalid priority")
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return fmt.Errorf("invalid syslog line: invalid priority")
+	}
+	severity := syslogSeverities[pri%8]
+	lr.SetSeverityNumber(severity.number)
+	lr.SetSeverityText(severity.text)
+	lr.Attributes().PutInt("syslog.facility", int64(pri/8))
+
+	rest := line[end+1:]
+	if strings.HasPrefix(rest, "1 ") {
+		return parseRFC5424(rest[2:], lr)
+	}
+	return parseRFC3164(rest, lr)
+}
+
+// parseRFC5424 parses `TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG`.
+func parseRFC5424(rest string, lr plog.LogRecord) error {
+	fields := strings.SplitN(rest, " ", 6)
+	if len(fields) < 6 {
+		return fmt.Errorf("invalid RFC 5424 syslog line")
+	}
+	if fields[0] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid RFC 5424 timestamp: %w", err)
+		}
+		lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	}
+	putNonNil(lr.Attributes(), "host.name", fields[1])
+	putNonNil(lr.Attributes(), "syslog.appname", fields[2])
+	putNonNil(lr.Attributes(), "syslog.procid", fields[3])
+	putNonNil(lr.Attributes(), "syslog.msgid", fields[4])
+
+	msg := fields[5]
+	if strings.HasPrefix(msg, "- ") || msg == "-" {
+		msg = strings.TrimPrefix(strings.TrimPrefix(msg, "-"), " ")
+	} else if strings.HasPrefix(msg, "[") {
+		// Structured data is kept verbatim.
+		sdEnd := strings.Index(msg, "] ")
+		if sdEnd < 0 {
+			lr.Attributes().PutStr("syslog.structured_data", msg)
+			msg = ""
+		} else {
+			lr.Attributes().PutStr("syslog.structured_data", msg[:sdEnd+1])
+			msg = msg[sdEnd+2:]
+		}
+	}
+	lr.Body().SetStr(msg)
+	return nil
+}
+
+// parseRFC3164 parses `Mmm dd hh:mm:ss HOSTNAME TAG: MSG`.
+func parseRFC3164(rest string, lr plog.LogRecord) error {
+	if len(rest) < len(time.Stamp)+1 {
+		return fmt.Errorf("invalid RFC 3164 syslog line")
+	}
+	ts, err := time.ParseInLocation(time.Stamp, rest[:len(time.Stamp)], time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid RFC 3164 timestamp: %w", err)
+	}
+	// RFC 3164 timestamps have no year.
+	ts = ts.AddDate(time.Now().Year(), 0, 0)
+	lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+
+	rest = strings.TrimPrefix(rest[len(time.Stamp):], " ")
+	host, msg, _ := strings.Cut(rest, " ")
+	lr.Attributes().PutStr("host.name", host)
+	if tag, content, found := strings.Cut(msg, ": "); found && !strings.Contains(tag, " ") {
+		lr.Attributes().PutStr("syslog.appname", tag)
+		msg = content
+	}
+	lr.Body().SetStr(msg)
+	return nil
+}
+
+func putNonNil(attrs pcommon.Map, key, value string) {
+	if value != "-" && value != "" {
+		attrs.PutStr(key, value)
+	}
+}
+
+// JSON keys recognized (in order of preference) for promoted fields.
+var (
+	jsonTimestampKeys = []string{"timestamp", "time", "ts", "@timestamp"}
+	jsonSeverityKeys  = []string{"level", "severity", "lvl"}
+	jsonBodyKeys      = []string{"message", "msg", "body"}
+)
+
+// parseJSONLine parses a JSON object. Well-known keys are promoted to the
+// timestamp, severity and body fields, the other keys become attributes.
+func parseJSONLine(line string, lr plog.LogRecord) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return fmt.Errorf("invalid JSON line: %w", err)
+	}
+
+	if v, ok := takeKey(obj, jsonTimestampKeys); ok {
+		if ts, ok := parseJSONTimestamp(v); ok {
+			lr.SetTimestamp(ts)
+		}
+	}
+	if v, ok := takeKey(obj, jsonSeverityKeys); ok {
+		text := fmt.Sprint(v)
+		lr.SetSeverityText(text)
+		lr.SetSeverityNumber(severityFromText(text))
+	}
+	if v, ok := takeKey(obj, jsonBodyKeys); ok {
+		if err := lr.Body().FromRaw(v); err != nil {
+			return err
+		}
+	}
+	return lr.Attributes().FromRaw(obj)
+}
+
+func takeKey(obj map[string]interface{}, keys []string) (interface{}, bool) {
+	for _, k := range keys {
+		if v, ok := obj[k]; ok {
+			delete(obj, k)
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// parseJSONTimestamp accepts RFC 3339 strings and numeric epoch timestamps
+// (seconds, milliseconds, microseconds or nanoseconds based on magnitude).
+func parseJSONTimestamp(v interface{}) (pcommon.Timestamp, bool) {
+	switch v := v.(type) {
+	case string:
+		ts, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return 0, false
+		}
+		return pcommon.NewTimestampFromTime(ts), true
+	case float64:
+		switch {
+		case v > 1e17:
+			return pcommon.Timestamp(v), true
+		case v > 1e14:
+			return pcommon.Timestamp(v * 1e3), true
+		case v > 1e11:
+			return pcommon.Timestamp(v * 1e6), true
+		default:
+			return pcommon.Timestamp(v * 1e9), true
+		}
+	}
+	return 0, false
+}
+
+func severityFromText(text string) plog.SeverityNumber {
+	switch strings.ToLower(text) {
+	case "trace":
+		return plog.SeverityNumberTrace
+	case "debug":
+		return plog.SeverityNumberDebug
+	case "info", "information", "notice":
+		return plog.SeverityNumberInfo
+	case "warn", "warning":
+		return plog.SeverityNumberWarn
+	case "error", "err":
+		return plog.SeverityNumberError
+	case "fatal", "critical", "crit", "panic", "emerg", "alert":
+		return plog.SeverityNumberFatal
+	}
+	return plog.SeverityNumberUnspecified
+}
@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+func TestParseSyslogRFC5424(t *testing.T) {
+	lr := plog.NewLogRecord()
+	err := parseSyslog(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`, lr)
+	require.NoError(t, err)
+
+	assert.Equal(t, plog.SeverityNumberInfo2, lr.SeverityNumber())
+	assert.Equal(t, "notice", lr.SeverityText())
+	assert.Equal(t, time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC), lr.Timestamp().AsTime())
+	assert.Equal(t, "An application event", lr.Body().Str())
+	assert.Equal(t, map[string]interface{}{
+		"syslog.facility":        int64(20),
+		"host.name":              "mymachine.example.com",
+		"syslog.appname":         "evntslog",
+		"syslog.msgid":           "ID47",
+		"syslog.structured_data": `[exampleSDID@32473 iut="3"]`,
+	}, lr.Attributes().AsRaw())
+}
+
+func TestParseSyslogRFC3164(t *testing.T) {
+	lr := plog.NewLogRecord()
+	require.NoError(t, parseSyslog(`<34>Oct 11 22:14:15 mymachine su: 'su root' failed`, lr))
+
+	assert.Equal(t, plog.SeverityNumberFatal2, lr.SeverityNumber())
+	assert.Equal(t, "'su root' failed", lr.Body().Str())
+	assert.Equal(t, time.October, lr.Timestamp().AsTime().Local().Month())
+	host, _ := lr.Attributes().Get("host.name")
+	assert.Equal(t, "mymachine", host.Str())
+
+	assert.Error(t, parseSyslog("no priority", plog.NewLogRecord()))
+}
+
+func TestParseJSONLine(t *testing.T) {
+	lr := plog.NewLogRecord()
+	require.NoError(t, parseJSONLine(`{"ts": 1700000000.5, "level": "WARN", "msg": "disk almost full", "disk": "/dev/sda1", "usage": 0.97}`, lr))
+
+	assert.Equal(t, plog.SeverityNumberWarn, lr.SeverityNumber())
+	assert.Equal(t, "WARN", lr.SeverityText())
+	assert.Equal(t, int64(1700000000500000000), int64(lr.Timestamp()))
+	assert.Equal(t, "disk almost full", lr.Body().Str())
+	assert.Equal(t, map[string]interface{}{"disk": "/dev/sda1", "usage": 0.97}, lr.Attributes().AsRaw())
+
+	assert.Error(t, parseJSONLine("not json", plog.NewLogRecord()))
+}
+
+// generateLogs builds a batch of log records through the tailer parsing path.
+func generateLogs(n int) plog.Logs {
+	tl := &tailer{parse: parseJSONLine, path: "/var/log/app.log", logger: zap.NewNop()}
+	logs, records := tl.newBatch()
+	levels := []string{"info", "warn", "error", "debug"}
+	for i := 0; i < n; i++ {
+		tl.append(records, fmt.Sprintf(`{"time":"2023-06-01T10:00:%02d.%03dZ","level":%q,"msg":"request %d served","method":"GET","status":%d}`,
+			i%60, i%1000, levels[i%len(levels)], i%50, 200+(i%3)*100))
+	}
+	return logs
+}
+
+// BenchmarkLogsEncoding compares the size of the OTLP and the Arrow encodings
+// of a batch produced by the tailer.
+func BenchmarkLogsEncoding(b *testing.B) {
+	logs := generateLogs(1000)
+
+	b.Run("otlp", func(b *testing.B) {
+		marshaler := &plog.ProtoMarshaler{}
+		var size int
+		for i := 0; i < b.N; i++ {
+			buf, err := marshaler.MarshalLogs(logs)
+			require.NoError(b, err)
+			size = len(buf)
+		}
+		b.ReportMetric(float64(size), "bytes/batch")
+	})
+
+	b.Run("arrow", func(b *testing.B) {
+		producer := arrowRecord.NewProducer()
+		defer func() { _ = producer.Close() }()
+		var size int
+		for i := 0; i < b.N; i++ {
+			batch, err := producer.BatchArrowRecordsFromLogs(logs)
+			require.NoError(b, err)
+			size = 0
+			for _, payload := range batch.ArrowPayloads {
+				size += len(payload.Record)
+			}
+		}
+		b.ReportMetric(float64(size), "bytes/batch")
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+type logTailReceiver struct {
+	consumer consumer.Logs
+	logger   *zap.Logger
+	config   *Config
+
+	file   *os.File
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (r *logTailReceiver) Start(_ context.Context, _ component.Host) error {
+	file, err := os.Open(r.config.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %q: %w", r.config.Path, err)
+	}
+	t, err := newTailer(file, r.config, r.logger)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file = file
+
+	// The context passed to Start only covers the start of the component,
+	// the tailer runs on its own context until Shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		err := t.run(ctx, func(logs plog.Logs) {
+			if err := r.consumer.ConsumeLogs(ctx, logs); err != nil {
+				r.logger.Error("failed to consume logs", zap.Error(err))
+			}
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			r.logger.Error("failed to tail file", zap.String("path", r.config.Path), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+func (r *logTailReceiver) Shutdown(_ context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
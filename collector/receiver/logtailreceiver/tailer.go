@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logtailreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+const scopeName = "github.com/f5/otel-arrow-adapter/collector/receiver/logtailreceiver"
+
+// tailer reads complete lines from a file, follows it as it grows and emits
+// batches of log records.
+type tailer struct {
+	reader       *bufio.Reader
+	parse        lineParser
+	path         string
+	pollInterval time.Duration
+	maxBatchSize int
+	logger       *zap.Logger
+
+	// partial holds an incomplete line read before the end of the file.
+	partial string
+}
+
+func newTailer(file *os.File, cfg *Config, logger *zap.Logger) (*tailer, error) {
+	if cfg.StartAt == startAtEnd {
+		if _, err := file.Seek(0, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+	return &tailer{
+		reader:       bufio.NewReader(file),
+		parse:        parserFor(cfg.Format),
+		path:         cfg.Path,
+		pollInterval: cfg.PollInterval,
+		maxBatchSize: cfg.MaxBatchSize,
+		logger:       logger,
+	}, nil
+}
+
+// newBatch creates an empty batch with a single resource and scope. All the
+// log records of a batch share them so the Arrow logs builder only encodes
+// one resource and one scope per batch.
+func (t *tailer) newBatch() (plog.Logs, plog.LogRecordSlice) {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("log.file.path", t.path)
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName(scopeName)
+	return logs, sl.LogRecords()
+}
+
+// run reads the file until the context is canceled, calling emit for each
+// batch. A batch is emitted when it is full or when the end of the file is
+// reached.
+func (t *tailer) run(ctx context.Context, emit func(plog.Logs)) error {
+	logs, records := t.newBatch()
+
+	flush := func() {
+		if records.Len() == 0 {
+			return
+		}
+		emit(logs)
+		logs, records = t.newBatch()
+	}
+
+	for {
+		line, err := t.reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			flush()
+			return err
+		}
+		if errors.Is(err, io.EOF) {
+			// Keep the incomplete line until the rest is written.
+			t.partial += line
+			flush()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(t.pollInterval):
+			}
+			continue
+		}
+
+		line = t.partial + line
+		t.partial = ""
+		t.append(records, line)
+		if records.Len() >= t.maxBatchSize {
+			flush()
+		}
+	}
+}
+
+func (t *tailer) append(records plog.LogRecordSlice, line string) {
+	line = trimEOL(line)
+	if line == "" {
+		return
+	}
+	lr := records.AppendEmpty()
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	if err := t.parse(line, lr); err != nil {
+		t.logger.Debug("unparsable log line, keeping it as is", zap.Error(err))
+		lr.Attributes().Clear()
+		lr.Body().SetStr(line)
+	}
+}
+
+func trimEOL(line string) string {
+	for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	return line
+}
@@ -0,0 +1,49 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package promscrapereceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the Prometheus scrape receiver.
+type Config struct {
+	// Targets is the list of URLs to scrape (e.g. http://localhost:9100/metrics).
+	Targets []string `mapstructure:"targets"`
+	// ScrapeInterval is the interval between two scrapes of a target.
+	// Default: 15s.
+	ScrapeInterval time.Duration `mapstructure:"scrape_interval"`
+	// ScrapeTimeout is the timeout of a single scrape. Default: 10s.
+	ScrapeTimeout time.Duration `mapstructure:"scrape_timeout"`
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		ScrapeInterval: 15 * time.Second,
+		ScrapeTimeout:  10 * time.Second,
+	}
+}
+
+// Validate checks the receiver configuration is valid.
+func (c Config) Validate() error {
+	if len(c.Targets) == 0 {
+		return errors.New("targets cannot be empty")
+	}
+	for _, target := range c.Targets {
+		if _, err := url.ParseRequestURI(target); err != nil {
+			return errors.New("invalid target URL: " + target)
+		}
+	}
+	if c.ScrapeInterval <= 0 {
+		return errors.New("scrape_interval must be positive")
+	}
+	if c.ScrapeTimeout <= 0 || c.ScrapeTimeout > c.ScrapeInterval {
+		return errors.New("scrape_timeout must be positive and not greater than scrape_interval")
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate mdatagen metadata.yaml
+
+// Package promscrapereceiver implements a lightweight receiver scraping
+// Prometheus endpoints (text exposition format). Samples are grouped by metric
+// family and series while parsing, so each scrape produces one resource, one
+// scope and one metric per family, the layout encoded most efficiently by the
+// Arrow metrics builders (no intermediate Prometheus model and no regrouping).
+//
+// When the next consumer encodes the metrics in Arrow (see
+// arrowconsumer.Metrics), the metrics are appended directly to its Arrow
+// metrics builders without building a pmetric.Metrics. Note that the consumers
+// inserted by the collector pipelines (e.g. fan-out) do not implement this
+// interface, this path is taken when the receiver is wired directly to such a
+// consumer, e.g. in an agent embedding the receiver.
+package promscrapereceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package promscrapereceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver/internal/metadata"
+)
+
+// NewFactory creates a factory for the Prometheus scrape receiver.
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		receiver.WithMetrics(createMetricsReceiver, metadata.MetricsStability),
+	)
+}
+
+func createMetricsReceiver(
+	_ context.Context,
+	settings receiver.CreateSettings,
+	cc component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	cfg := cc.(*Config)
+	return &scrapeReceiver{
+		consumer: consumer,
+		logger:   settings.Logger,
+		config:   cfg,
+		client:   &http.Client{Timeout: cfg.ScrapeTimeout},
+	}, nil
+}
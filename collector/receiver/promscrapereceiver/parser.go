@@ -0,0 +1,477 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package promscrapereceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+)
+
+const (
+	familyTypeCounter   = "counter"
+	familyTypeGauge     = "gauge"
+	familyTypeHistogram = "histogram"
+	familyTypeSummary   = "summary"
+	familyTypeUntyped   = "untyped"
+)
+
+// label is a single Prometheus label.
+type label struct {
+	name  string
+	value string
+}
+
+// sample is a single line of the text exposition format.
+type sample struct {
+	name   string
+	labels []label
+	value  float64
+	// timestamp in milliseconds, 0 if absent.
+	timestamp int64
+}
+
+// family groups the samples of a metric family in order of appearance.
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples []sample
+}
+
+// parser is a minimal parser for the Prometheus text exposition format
+// (version 0.0.4). It groups samples by metric family so that each family
+// becomes a single OTLP metric with all its series as data points, which is
+// the layout the Arrow metrics builders encode most efficiently.
+type parser struct {
+	families []*family
+	byName   map[string]*family
+}
+
+func newParser() *parser {
+	return &parser{byName: map[string]*family{}}
+}
+
+// parse reads an exposition payload and accumulates its metric families.
+func (p *parser) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			p.parseComment(line)
+			continue
+		}
+		s, err := parseSample(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		fam := p.familyOf(s.name)
+		fam.samples = append(fam.samples, s)
+	}
+	return scanner.Err()
+}
+
+func (p *parser) parseComment(line string) {
+	fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), " ", 3)
+	if len(fields) < 3 {
+		return
+	}
+	switch fields[0] {
+	case "HELP":
+		p.family(fields[1]).help = fields[2]
+	case "TYPE":
+		p.family(fields[1]).typ = strings.TrimSpace(fields[2])
+	}
+}
+
+// family returns (and creates if needed) the family with the given name.
+func (p *parser) family(name string) *family {
+	if fam, ok := p.byName[name]; ok {
+		return fam
+	}
+	fam := &family{name: name, typ: familyTypeUntyped}
+	p.byName[name] = fam
+	p.families = append(p.families, fam)
+	return fam
+}
+
+// familyOf returns the family a sample belongs to, taking into account the
+// `_bucket`, `_sum` and `_count` suffixes of histograms and summaries.
+func (p *parser) familyOf(sampleName string) *family {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if !strings.HasSuffix(sampleName, suffix) {
+			continue
+		}
+		base := strings.TrimSuffix(sampleName, suffix)
+		if fam, ok := p.byName[base]; ok && (fam.typ == familyTypeHistogram || fam.typ == familyTypeSummary) {
+			return fam
+		}
+	}
+	return p.family(sampleName)
+}
+
+func parseSample(line string) (s sample, err error) {
+	rest := line
+	end := strings.IndexAny(rest, "{ \t")
+	if end <= 0 {
+		return s, fmt.Errorf("invalid sample %q", line)
+	}
+	s.name = rest[:end]
+	rest = rest[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		s.labels, rest, err = parseLabels(rest[1:])
+		if err != nil {
+			return s, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return s, fmt.Errorf("invalid sample %q", line)
+	}
+	s.value, err = parseFloat(fields[0])
+	if err != nil {
+		return s, fmt.Errorf("invalid value in %q: %w", line, err)
+	}
+	if len(fields) == 2 {
+		s.timestamp, err = strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return s, fmt.Errorf("invalid timestamp in %q: %w", line, err)
+		}
+	}
+	return s, nil
+}
+
+// parseLabels parses `name="value",...}` and returns the labels and the
+// remainder of the line after the closing brace.
+func parseLabels(in string) ([]label, string, error) {
+	var labels []label
+	for {
+		in = strings.TrimLeft(in, " \t,")
+		if strings.HasPrefix(in, "}") {
+			return labels, in[1:], nil
+		}
+		eq := strings.IndexByte(in, '=')
+		if eq <= 0 || len(in) < eq+2 || in[eq+1] != '"' {
+			return nil, "", fmt.Errorf("invalid label set")
+		}
+		name := strings.TrimSpace(in[:eq])
+		in = in[eq+2:]
+
+		var value strings.Builder
+		closed := false
+		for i := 0; i < len(in); i++ {
+			c := in[i]
+			if c == '\\' && i+1 < len(in) {
+				i++
+				switch in[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(in[i])
+				}
+				continue
+			}
+			if c == '"' {
+				in = in[i+1:]
+				closed = true
+				break
+			}
+			value.WriteByte(c)
+		}
+		if !closed {
+			return nil, "", fmt.Errorf("unterminated label value")
+		}
+		labels = append(labels, label{name: name, value: value.String()})
+	}
+}
+
+func parseFloat(s string) (float64, error) {
+	switch s {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parsedMetric is a metric family converted to the description of a metric
+// and its data points, the slices of the other data point types being empty.
+type parsedMetric struct {
+	desc       metricsarrow.Metric
+	numbers    pmetric.NumberDataPointSlice
+	histograms pmetric.HistogramDataPointSlice
+	summaries  pmetric.SummaryDataPointSlice
+}
+
+// metrics converts the accumulated families into metrics, in order of
+// appearance. now is used for samples without an explicit timestamp.
+func (p *parser) metrics(now pcommon.Timestamp) []parsedMetric {
+	metrics := make([]parsedMetric, 0, len(p.families))
+	for _, fam := range p.families {
+		if len(fam.samples) == 0 {
+			continue
+		}
+		m := parsedMetric{
+			desc: metricsarrow.Metric{
+				Name:        fam.name,
+				Description: fam.help,
+			},
+			numbers:    pmetric.NewNumberDataPointSlice(),
+			histograms: pmetric.NewHistogramDataPointSlice(),
+			summaries:  pmetric.NewSummaryDataPointSlice(),
+		}
+
+		switch fam.typ {
+		case familyTypeCounter:
+			m.desc.Type = pmetric.MetricTypeSum
+			m.desc.AggregationTemporality = pmetric.AggregationTemporalityCumulative
+			m.desc.IsMonotonic = true
+			appendNumberDataPoints(m.numbers, fam.samples, now)
+		case familyTypeHistogram:
+			m.desc.Type = pmetric.MetricTypeHistogram
+			m.desc.AggregationTemporality = pmetric.AggregationTemporalityCumulative
+			appendHistogramDataPoints(fam.name, m.histograms, fam.samples, now)
+		case familyTypeSummary:
+			m.desc.Type = pmetric.MetricTypeSummary
+			appendSummaryDataPoints(fam.name, m.summaries, fam.samples, now)
+		default:
+			m.desc.Type = pmetric.MetricTypeGauge
+			appendNumberDataPoints(m.numbers, fam.samples, now)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// appendTo converts the accumulated families into metrics appended to the
+// given slice. now is used for samples without an explicit timestamp.
+func (p *parser) appendTo(metrics pmetric.MetricSlice, now pcommon.Timestamp) {
+	appendTo(metrics, p.metrics(now))
+}
+
+// appendTo appends the given metrics to a slice, their data points are moved.
+func appendTo(metrics pmetric.MetricSlice, parsed []parsedMetric) {
+	for _, pm := range parsed {
+		m := metrics.AppendEmpty()
+		m.SetName(pm.desc.Name)
+		m.SetDescription(pm.desc.Description)
+
+		switch pm.desc.Type {
+		case pmetric.MetricTypeSum:
+			sum := m.SetEmptySum()
+			sum.SetAggregationTemporality(pm.desc.AggregationTemporality)
+			sum.SetIsMonotonic(pm.desc.IsMonotonic)
+			pm.numbers.MoveAndAppendTo(sum.DataPoints())
+		case pmetric.MetricTypeHistogram:
+			hist := m.SetEmptyHistogram()
+			hist.SetAggregationTemporality(pm.desc.AggregationTemporality)
+			pm.histograms.MoveAndAppendTo(hist.DataPoints())
+		case pmetric.MetricTypeSummary:
+			pm.summaries.MoveAndAppendTo(m.SetEmptySummary().DataPoints())
+		default:
+			pm.numbers.MoveAndAppendTo(m.SetEmptyGauge().DataPoints())
+		}
+	}
+}
+
+// appendToArrow appends the given metrics directly to the Arrow metrics
+// builders, with the current resource and scope of the appender.
+func appendToArrow(a *metricsarrow.MetricsAppender, metrics []parsedMetric) error {
+	for _, pm := range metrics {
+		if err := a.AppendMetric(pm.desc); err != nil {
+			return err
+		}
+		switch pm.desc.Type {
+		case pmetric.MetricTypeHistogram:
+			if err := a.AppendHistogramDataPoints(pm.histograms); err != nil {
+				return err
+			}
+		case pmetric.MetricTypeSummary:
+			if err := a.AppendSummaryDataPoints(pm.summaries); err != nil {
+				return err
+			}
+		default:
+			for i := 0; i < pm.numbers.Len(); i++ {
+				if err := a.AppendNumberDataPoint(pm.numbers.At(i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func timestampOf(s *sample, now pcommon.Timestamp) pcommon.Timestamp {
+	if s.timestamp == 0 {
+		return now
+	}
+	return pcommon.Timestamp(s.timestamp * 1e6)
+}
+
+func appendNumberDataPoints(dps pmetric.NumberDataPointSlice, samples []sample, now pcommon.Timestamp) {
+	dps.EnsureCapacity(len(samples))
+	for i := range samples {
+		s := &samples[i]
+		dp := dps.AppendEmpty()
+		dp.SetTimestamp(timestampOf(s, now))
+		dp.SetDoubleValue(s.value)
+		putLabels(dp.Attributes(), s.labels, "")
+	}
+}
+
+// seriesKey returns a key identifying the series of a sample excluding the
+// given label (i.e. `le` or `quantile`).
+func seriesKey(labels []label, exclude string) string {
+	var b strings.Builder
+	for _, l := range labels {
+		if l.name == exclude {
+			continue
+		}
+		b.WriteString(l.name)
+		b.WriteByte('=')
+		b.WriteString(l.value)
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+func labelValue(labels []label, name string) (string, bool) {
+	for _, l := range labels {
+		if l.name == name {
+			return l.value, true
+		}
+	}
+	return "", false
+}
+
+func putLabels(attrs pcommon.Map, labels []label, exclude string) {
+	attrs.EnsureCapacity(len(labels))
+	for _, l := range labels {
+		if l.name == exclude {
+			continue
+		}
+		attrs.PutStr(l.name, l.value)
+	}
+}
+
+type bucket struct {
+	bound float64
+	count float64
+}
+
+func appendHistogramDataPoints(name string, dps pmetric.HistogramDataPointSlice, samples []sample, now pcommon.Timestamp) {
+	type series struct {
+		sample  *sample
+		buckets []bucket
+		sum     float64
+		count   float64
+	}
+	var order []string
+	bySeries := map[string]*series{}
+
+	for i := range samples {
+		s := &samples[i]
+		key := seriesKey(s.labels, "le")
+		ser, ok := bySeries[key]
+		if !ok {
+			ser = &series{sample: s}
+			bySeries[key] = ser
+			order = append(order, key)
+		}
+		switch s.name {
+		case name + "_bucket":
+			le, found := labelValue(s.labels, "le")
+			if !found {
+				continue
+			}
+			bound, err := parseFloat(le)
+			if err != nil {
+				continue
+			}
+			ser.buckets = append(ser.buckets, bucket{bound: bound, count: s.value})
+		case name + "_sum":
+			ser.sum = s.value
+		case name + "_count":
+			ser.count = s.value
+		}
+	}
+
+	dps.EnsureCapacity(len(order))
+	for _, key := range order {
+		ser := bySeries[key]
+		dp := dps.AppendEmpty()
+		dp.SetTimestamp(timestampOf(ser.sample, now))
+		dp.SetSum(ser.sum)
+		dp.SetCount(uint64(ser.count))
+		putLabels(dp.Attributes(), ser.sample.labels, "le")
+
+		// Prometheus buckets are cumulative, OTLP buckets are not.
+		sort.Slice(ser.buckets, func(i, j int) bool { return ser.buckets[i].bound < ser.buckets[j].bound })
+		prev := 0.0
+		for _, b := range ser.buckets {
+			if !math.IsInf(b.bound, 1) {
+				dp.ExplicitBounds().Append(b.bound)
+			}
+			dp.BucketCounts().Append(uint64(b.count - prev))
+			prev = b.count
+		}
+		if len(ser.buckets) > 0 && !math.IsInf(ser.buckets[len(ser.buckets)-1].bound, 1) {
+			// Missing +Inf bucket, derive it from the total count.
+			dp.BucketCounts().Append(uint64(ser.count - prev))
+		}
+	}
+}
+
+func appendSummaryDataPoints(name string, dps pmetric.SummaryDataPointSlice, samples []sample, now pcommon.Timestamp) {
+	bySeries := map[string]pmetric.SummaryDataPoint{}
+
+	for i := range samples {
+		s := &samples[i]
+		key := seriesKey(s.labels, "quantile")
+		dp, ok := bySeries[key]
+		if !ok {
+			dp = dps.AppendEmpty()
+			dp.SetTimestamp(timestampOf(s, now))
+			putLabels(dp.Attributes(), s.labels, "quantile")
+			bySeries[key] = dp
+		}
+		switch s.name {
+		case name + "_sum":
+			dp.SetSum(s.value)
+		case name + "_count":
+			dp.SetCount(uint64(s.value))
+		default:
+			q, found := labelValue(s.labels, "quantile")
+			if !found {
+				continue
+			}
+			quantile, err := parseFloat(q)
+			if err != nil {
+				continue
+			}
+			qv := dp.QuantileValues().AppendEmpty()
+			qv.SetQuantile(quantile)
+			qv.SetValue(s.value)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package promscrapereceiver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+const exposition = `
+# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"}    3 1395066363000
+
+# A free-form comment.
+temperature{room="a \"b\""} -12.5
+
+# TYPE latency histogram
+latency_bucket{le="0.1"} 2
+latency_bucket{le="1"} 5
+latency_bucket{le="+Inf"} 6
+latency_sum 4.2
+latency_count 6
+
+# TYPE rpc summary
+rpc{quantile="0.5"} 0.05
+rpc{quantile="0.99"} 0.2
+rpc_sum 17
+rpc_count 100
+`
+
+func TestParser(t *testing.T) {
+	p := newParser()
+	require.NoError(t, p.parse(strings.NewReader(exposition)))
+
+	metrics := pmetric.NewMetricSlice()
+	p.appendTo(metrics, pcommon.Timestamp(42))
+	require.Equal(t, 4, metrics.Len())
+
+	counter := metrics.At(0)
+	assert.Equal(t, "http_requests_total", counter.Name())
+	assert.Equal(t, "The total number of HTTP requests.", counter.Description())
+	require.Equal(t, pmetric.MetricTypeSum, counter.Type())
+	assert.True(t, counter.Sum().IsMonotonic())
+	require.Equal(t, 2, counter.Sum().DataPoints().Len())
+	dp := counter.Sum().DataPoints().At(1)
+	assert.Equal(t, 3.0, dp.DoubleValue())
+	assert.Equal(t, pcommon.Timestamp(1395066363000*1e6), dp.Timestamp())
+	code, _ := dp.Attributes().Get("code")
+	assert.Equal(t, "400", code.Str())
+
+	gauge := metrics.At(1)
+	require.Equal(t, pmetric.MetricTypeGauge, gauge.Type())
+	gdp := gauge.Gauge().DataPoints().At(0)
+	assert.Equal(t, -12.5, gdp.DoubleValue())
+	assert.Equal(t, pcommon.Timestamp(42), gdp.Timestamp())
+	room, _ := gdp.Attributes().Get("room")
+	assert.Equal(t, `a "b"`, room.Str())
+
+	hist := metrics.At(2)
+	require.Equal(t, pmetric.MetricTypeHistogram, hist.Type())
+	require.Equal(t, 1, hist.Histogram().DataPoints().Len())
+	hdp := hist.Histogram().DataPoints().At(0)
+	assert.Equal(t, uint64(6), hdp.Count())
+	assert.Equal(t, 4.2, hdp.Sum())
+	assert.Equal(t, []float64{0.1, 1}, hdp.ExplicitBounds().AsRaw())
+	assert.Equal(t, []uint64{2, 3, 1}, hdp.BucketCounts().AsRaw())
+
+	summary := metrics.At(3)
+	require.Equal(t, pmetric.MetricTypeSummary, summary.Type())
+	sdp := summary.Summary().DataPoints().At(0)
+	assert.Equal(t, uint64(100), sdp.Count())
+	assert.Equal(t, 17.0, sdp.Sum())
+	assert.Equal(t, 2, sdp.QuantileValues().Len())
+}
+
+func TestParserInvalid(t *testing.T) {
+	p := newParser()
+	assert.Error(t, p.parse(strings.NewReader(`metric{label="unterminated} 1`)))
+	assert.Error(t, p.parse(strings.NewReader(`metric not_a_number`)))
+}
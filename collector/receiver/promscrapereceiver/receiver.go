@@ -0,0 +1,152 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package promscrapereceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.uber.org/zap"
+
+	"github.com/f5/otel-arrow-adapter/collector/arrowconsumer"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+)
+
+const (
+	scopeName = "github.com/f5/otel-arrow-adapter/collector/receiver/promscrapereceiver"
+
+	acceptHeader = "text/plain;version=0.0.4;q=1,*/*;q=0.1"
+)
+
+type scrapeReceiver struct {
+	consumer consumer.Metrics
+	logger   *zap.Logger
+	config   *Config
+	client   *http.Client
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func (r *scrapeReceiver) Start(_ context.Context, _ component.Host) error {
+	// The context passed to Start is not meant to outlive it, the scrape
+	// loops run until Shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	for _, target := range r.config.Targets {
+		r.wg.Add(1)
+		go func(target string) {
+			defer r.wg.Done()
+			r.scrapeLoop(ctx, target)
+		}(target)
+	}
+	return nil
+}
+
+func (r *scrapeReceiver) Shutdown(_ context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	return nil
+}
+
+// scrapeLoop periodically scrapes a target until the context is canceled.
+func (r *scrapeReceiver) scrapeLoop(ctx context.Context, target string) {
+	ticker := time.NewTicker(r.config.ScrapeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.scrape(ctx, target); err != nil && ctx.Err() == nil {
+			r.logger.Error("scrape failed", zap.String("target", target), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape fetches the metrics exposed by a target and passes them to the next
+// consumer. All the families of a target are placed in a single
+// resource/scope so that the Arrow metrics builders receive one resource and
+// one scope per scrape. When the next consumer encodes the metrics in Arrow
+// (see arrowconsumer.Metrics), the data points are appended directly to its
+// Arrow metrics builders, otherwise a pmetric.Metrics is built.
+func (r *scrapeReceiver) scrape(ctx context.Context, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", acceptHeader)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	p := newParser()
+	if err := p.parse(resp.Body); err != nil {
+		return err
+	}
+	metrics := p.metrics(pcommon.NewTimestampFromTime(time.Now()))
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	resource := pcommon.NewResource()
+	putTargetAttributes(resource.Attributes(), target)
+	scope := pcommon.NewInstrumentationScope()
+	scope.SetName(scopeName)
+
+	if ac, ok := r.consumer.(arrowconsumer.Metrics); ok {
+		err = ac.ConsumeMetricsAppender(ctx, func(a *metricsarrow.MetricsAppender) error {
+			a.SetResource(resource, "")
+			a.SetScope(scope, "")
+			return appendToArrow(a, metrics)
+		})
+	} else {
+		md := pmetric.NewMetrics()
+		rm := md.ResourceMetrics().AppendEmpty()
+		resource.MoveTo(rm.Resource())
+		sm := rm.ScopeMetrics().AppendEmpty()
+		scope.MoveTo(sm.Scope())
+		appendTo(sm.Metrics(), metrics)
+		err = r.consumer.ConsumeMetrics(ctx, md)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consume scraped metrics: %w", err)
+	}
+	return nil
+}
+
+// putTargetAttributes sets the resource attributes identifying a target,
+// following the Prometheus receiver conventions.
+func putTargetAttributes(attrs pcommon.Map, target string) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return
+	}
+	attrs.PutStr("service.instance.id", u.Host)
+	attrs.PutStr("net.host.name", u.Hostname())
+	if port := u.Port(); port != "" {
+		attrs.PutStr("net.host.port", port)
+	}
+	attrs.PutStr("http.scheme", u.Scheme)
+}
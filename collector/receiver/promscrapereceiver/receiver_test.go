@@ -0,0 +1,148 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package promscrapereceiver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/collector/arrowconsumer"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+)
+
+// startTarget starts an HTTP server exposing the test exposition.
+func startTarget(t *testing.T) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(exposition))
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/metrics"
+}
+
+// scrapeOnce starts a receiver scraping the test target with the given
+// consumer, and shuts it down once the first scrape has been consumed.
+func scrapeOnce(t *testing.T, next consumer.Metrics, scraped func() bool) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Targets = []string{startTarget(t)}
+	cfg.ScrapeInterval = time.Hour
+
+	rcv, err := NewFactory().CreateMetricsReceiver(context.Background(), receivertest.NewNopCreateSettings(), cfg, next)
+	require.NoError(t, err)
+
+	// The scrape loop must outlive the context passed to Start.
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, rcv.Start(ctx, componenttest.NewNopHost()))
+	cancel()
+
+	require.Eventually(t, scraped, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, rcv.Shutdown(context.Background()))
+}
+
+func TestScrape(t *testing.T) {
+	sink := &consumertest.MetricsSink{}
+	scrapeOnce(t, sink, func() bool { return len(sink.AllMetrics()) > 0 })
+
+	md := sink.AllMetrics()[0]
+	require.Equal(t, 1, md.ResourceMetrics().Len())
+	rm := md.ResourceMetrics().At(0)
+	scheme, ok := rm.Resource().Attributes().Get("http.scheme")
+	require.True(t, ok)
+	require.Equal(t, "http", scheme.Str())
+	require.Equal(t, 1, rm.ScopeMetrics().Len())
+	require.Equal(t, scopeName, rm.ScopeMetrics().At(0).Scope().Name())
+	require.Equal(t, 4, rm.ScopeMetrics().At(0).Metrics().Len())
+}
+
+// arrowSink encodes the metrics it receives in Arrow and decodes them.
+type arrowSink struct {
+	*arrowconsumer.MetricsProducer
+
+	mu      sync.Mutex
+	metrics []pmetric.Metrics
+}
+
+func newArrowSink(t *testing.T) *arrowSink {
+	s := &arrowSink{}
+	arrowConsumer := arrowRecord.NewConsumer()
+	s.MetricsProducer = arrowconsumer.NewMetricsProducer(arrowRecord.NewProducer(), func(_ context.Context, batch *arrowpb.BatchArrowRecords) error {
+		metrics, err := arrowConsumer.MetricsFrom(batch)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.metrics = append(s.metrics, metrics...)
+		return nil
+	})
+	return s
+}
+
+func (s *arrowSink) received() []pmetric.Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metrics
+}
+
+func TestScrapeArrow(t *testing.T) {
+	// The metrics appended directly to the Arrow builders are the metrics
+	// built by the pdata path.
+	sink := &consumertest.MetricsSink{}
+	scrapeOnce(t, sink, func() bool { return len(sink.AllMetrics()) > 0 })
+	expected := sink.AllMetrics()[0]
+
+	arrowSink := newArrowSink(t)
+	scrapeOnce(t, arrowSink, func() bool { return len(arrowSink.received()) > 0 })
+	received := arrowSink.received()[0]
+
+	// The resource attributes differ by the port of the target.
+	expected.ResourceMetrics().At(0).Resource().Attributes().Clear()
+	received.ResourceMetrics().At(0).Resource().Attributes().Clear()
+
+	// Samples without timestamp are stamped with the scrape time.
+	clearTimestamps(expected)
+	clearTimestamps(received)
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(expected)},
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received)},
+	)
+}
+
+func clearTimestamps(md pmetric.Metrics) {
+	ms := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		m := ms.At(i)
+		switch m.Type() {
+		case pmetric.MetricTypeGauge:
+			for j := 0; j < m.Gauge().DataPoints().Len(); j++ {
+				m.Gauge().DataPoints().At(j).SetTimestamp(0)
+			}
+		case pmetric.MetricTypeHistogram:
+			for j := 0; j < m.Histogram().DataPoints().Len(); j++ {
+				m.Histogram().DataPoints().At(j).SetTimestamp(0)
+			}
+		case pmetric.MetricTypeSummary:
+			for j := 0; j < m.Summary().DataPoints().Len(); j++ {
+				m.Summary().DataPoints().At(j).SetTimestamp(0)
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package statsdreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+)
+
+const scopeName = "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
+
+type (
+	// seriesKey identifies a series within a flush interval.
+	seriesKey struct {
+		name string
+		kind string
+		tags string
+	}
+
+	// series is the state aggregated for one series during a flush interval.
+	series struct {
+		name string
+		kind string
+		tags []tag
+
+		// counter and gauge value.
+		value float64
+
+		// timer/histogram/distribution state.
+		count   uint64
+		sum     float64
+		min     float64
+		max     float64
+		buckets []uint64
+
+		// set members.
+		members map[string]struct{}
+
+		// lastReported is the time of the last flush reporting a gauge.
+		lastReported pcommon.Timestamp
+	}
+
+	// flushedMetric is the description of a metric reported by a flush and
+	// its data points, the slice of the other data point type being empty.
+	flushedMetric struct {
+		desc       metricsarrow.Metric
+		numbers    pmetric.NumberDataPointSlice
+		histograms pmetric.HistogramDataPointSlice
+	}
+
+	// aggregator aggregates statsd metrics per flush interval. Counters and
+	// timers are reported as delta sums and delta histograms, gauges keep
+	// their last value across flush intervals until they expire, sets are
+	// reported as the number of unique members seen during the interval.
+	aggregator struct {
+		lock sync.Mutex
+
+		bounds []float64
+		// gaugeExpiry is the duration after which a gauge not updated is
+		// forgotten (0 = never).
+		gaugeExpiry time.Duration
+
+		series map[seriesKey]*series
+		// gauges are kept across flush intervals as statsd gauges are
+		// stateful (relative updates apply to the previous value).
+		gauges map[seriesKey]*series
+
+		start pcommon.Timestamp
+	}
+)
+
+func newAggregator(bounds []float64, gaugeExpiry time.Duration, now pcommon.Timestamp) *aggregator {
+	return &aggregator{
+		bounds:      bounds,
+		gaugeExpiry: gaugeExpiry,
+		series:      map[seriesKey]*series{},
+		gauges:      map[seriesKey]*series{},
+		start:       now,
+	}
+}
+
+// kindOf groups the timer-like statsd types into a single histogram kind.
+func kindOf(typ string) string {
+	switch typ {
+	case typeTimer, typeHistogram, typeDistribution:
+		return typeHistogram
+	default:
+		return typ
+	}
+}
+
+func tagsKey(tags []tag) string {
+	var b strings.Builder
+	for _, t := range tags {
+		b.WriteString(t.key)
+		b.WriteByte(':')
+		b.WriteString(t.value)
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// add aggregates a parsed statsd metric.
+func (a *aggregator) add(m statsdMetric) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	kind := kindOf(m.typ)
+	key := seriesKey{name: m.name, kind: kind, tags: tagsKey(m.tags)}
+
+	table := a.series
+	if kind == typeGauge {
+		table = a.gauges
+	}
+	s, ok := table[key]
+	if !ok {
+		s = &series{name: m.name, kind: kind, tags: m.tags}
+		table[key] = s
+	}
+
+	switch kind {
+	case typeCounter:
+		s.value += m.value / m.sampleRate
+	case typeGauge:
+		if m.relative {
+			s.value += m.value
+		} else {
+			s.value = m.value
+		}
+		// Mark the gauge as updated during this interval.
+		s.count = 1
+	case typeHistogram:
+		if s.buckets == nil {
+			s.buckets = make([]uint64, len(a.bounds)+1)
+			s.min = math.Inf(1)
+			s.max = math.Inf(-1)
+		}
+		n := uint64(math.Round(1 / m.sampleRate))
+		s.count += n
+		s.sum += m.value * float64(n)
+		s.min = math.Min(s.min, m.value)
+		s.max = math.Max(s.max, m.value)
+		s.buckets[sort.SearchFloat64s(a.bounds, m.value)] += n
+	case typeSet:
+		if s.members == nil {
+			s.members = map[string]struct{}{}
+		}
+		s.members[m.rawValue] = struct{}{}
+	}
+}
+
+// flush returns the metrics aggregated since the previous flush and resets
+// the per-interval state. All series sharing the same name and kind are
+// reported as data points of a single metric. The gauges not updated since
+// gaugeExpiry are forgotten.
+func (a *aggregator) flush(now pcommon.Timestamp) []flushedMetric {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	all := make([]*series, 0, len(a.series)+len(a.gauges))
+	for _, s := range a.series {
+		all = append(all, s)
+	}
+	for key, s := range a.gauges {
+		switch {
+		case s.count > 0:
+			all = append(all, s)
+		case a.gaugeExpiry > 0 && now.AsTime().Sub(s.lastReported.AsTime()) >= a.gaugeExpiry:
+			delete(a.gauges, key)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].name != all[j].name {
+			return all[i].name < all[j].name
+		}
+		if all[i].kind != all[j].kind {
+			return all[i].kind < all[j].kind
+		}
+		return tagsKey(all[i].tags) < tagsKey(all[j].tags)
+	})
+
+	var metrics []flushedMetric
+	var metric *flushedMetric
+	var prev *series
+	for _, s := range all {
+		if prev == nil || prev.name != s.name || prev.kind != s.kind {
+			metrics = append(metrics, newFlushedMetric(s))
+			metric = &metrics[len(metrics)-1]
+		}
+		prev = s
+
+		switch s.kind {
+		case typeCounter, typeSet:
+			dp := metric.numbers.AppendEmpty()
+			dp.SetStartTimestamp(a.start)
+			dp.SetTimestamp(now)
+			if s.kind == typeSet {
+				dp.SetIntValue(int64(len(s.members)))
+			} else {
+				dp.SetDoubleValue(s.value)
+			}
+			putTags(dp.Attributes(), s.tags)
+		case typeGauge:
+			dp := metric.numbers.AppendEmpty()
+			dp.SetTimestamp(now)
+			dp.SetDoubleValue(s.value)
+			putTags(dp.Attributes(), s.tags)
+			s.count = 0
+			s.lastReported = now
+		case typeHistogram:
+			dp := metric.histograms.AppendEmpty()
+			dp.SetStartTimestamp(a.start)
+			dp.SetTimestamp(now)
+			dp.SetCount(s.count)
+			dp.SetSum(s.sum)
+			dp.SetMin(s.min)
+			dp.SetMax(s.max)
+			dp.ExplicitBounds().FromRaw(a.bounds)
+			dp.BucketCounts().FromRaw(s.buckets)
+			putTags(dp.Attributes(), s.tags)
+		}
+	}
+
+	a.series = map[seriesKey]*series{}
+	a.start = now
+
+	return metrics
+}
+
+func newFlushedMetric(s *series) flushedMetric {
+	m := flushedMetric{
+		desc:       metricsarrow.Metric{Name: s.name},
+		numbers:    pmetric.NewNumberDataPointSlice(),
+		histograms: pmetric.NewHistogramDataPointSlice(),
+	}
+	switch s.kind {
+	case typeCounter, typeSet:
+		m.desc.Type = pmetric.MetricTypeSum
+		m.desc.AggregationTemporality = pmetric.AggregationTemporalityDelta
+		m.desc.IsMonotonic = s.kind == typeCounter
+	case typeGauge:
+		m.desc.Type = pmetric.MetricTypeGauge
+	case typeHistogram:
+		m.desc.Type = pmetric.MetricTypeHistogram
+		m.desc.AggregationTemporality = pmetric.AggregationTemporalityDelta
+	}
+	return m
+}
+
+// toMetrics returns the flushed metrics as a pmetric.Metrics with a single
+// resource and scope, their data points are moved.
+func toMetrics(metrics []flushedMetric) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+
+	for _, fm := range metrics {
+		m := sm.Metrics().AppendEmpty()
+		m.SetName(fm.desc.Name)
+		switch fm.desc.Type {
+		case pmetric.MetricTypeSum:
+			sum := m.SetEmptySum()
+			sum.SetAggregationTemporality(fm.desc.AggregationTemporality)
+			sum.SetIsMonotonic(fm.desc.IsMonotonic)
+			fm.numbers.MoveAndAppendTo(sum.DataPoints())
+		case pmetric.MetricTypeGauge:
+			fm.numbers.MoveAndAppendTo(m.SetEmptyGauge().DataPoints())
+		case pmetric.MetricTypeHistogram:
+			hist := m.SetEmptyHistogram()
+			hist.SetAggregationTemporality(fm.desc.AggregationTemporality)
+			fm.histograms.MoveAndAppendTo(hist.DataPoints())
+		}
+	}
+	return md
+}
+
+// appendToArrow appends the flushed metrics directly to the Arrow metrics
+// builders, through the number data point and histogram accumulators.
+func appendToArrow(a *metricsarrow.MetricsAppender, metrics []flushedMetric) error {
+	scope := pcommon.NewInstrumentationScope()
+	scope.SetName(scopeName)
+	a.SetScope(scope, "")
+
+	for _, fm := range metrics {
+		if err := a.AppendMetric(fm.desc); err != nil {
+			return err
+		}
+		if fm.desc.Type == pmetric.MetricTypeHistogram {
+			if err := a.AppendHistogramDataPoints(fm.histograms); err != nil {
+				return err
+			}
+			continue
+		}
+		for i := 0; i < fm.numbers.Len(); i++ {
+			if err := a.AppendNumberDataPoint(fm.numbers.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func putTags(attrs pcommon.Map, tags []tag) {
+	attrs.EnsureCapacity(len(tags))
+	for _, t := range tags {
+		attrs.PutStr(t.key, t.value)
+	}
+}
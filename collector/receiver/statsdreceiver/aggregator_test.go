@@ -0,0 +1,145 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package statsdreceiver
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	otelassert "github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+)
+
+func TestParseLine(t *testing.T) {
+	m, err := parseLine("page.views:2|c|@0.5|#env:prod,region:us")
+	require.NoError(t, err)
+	assert.Equal(t, "page.views", m.name)
+	assert.Equal(t, typeCounter, m.typ)
+	assert.Equal(t, 2.0, m.value)
+	assert.Equal(t, 0.5, m.sampleRate)
+	assert.Equal(t, []tag{{key: "env", value: "prod"}, {key: "region", value: "us"}}, m.tags)
+
+	m, err = parseLine("queue:-3|g")
+	require.NoError(t, err)
+	assert.True(t, m.relative)
+
+	_, err = parseLine("invalid")
+	assert.Error(t, err)
+	_, err = parseLine("x:1|unknown")
+	assert.Error(t, err)
+	_, err = parseLine("x:1|c|@2")
+	assert.Error(t, err)
+}
+
+func mustAdd(t *testing.T, a *aggregator, line string) {
+	m, err := parseLine(line)
+	require.NoError(t, err)
+	a.add(m)
+}
+
+func TestAggregator(t *testing.T) {
+	a := newAggregator([]float64{10, 100}, 0, 1)
+
+	mustAdd(t, a, "hits:1|c|#a:1")
+	mustAdd(t, a, "hits:2|c|@0.5|#a:1")
+	mustAdd(t, a, "hits:1|c|#a:2")
+	mustAdd(t, a, "temp:20|g")
+	mustAdd(t, a, "temp:+5|g")
+	mustAdd(t, a, "latency:5|ms")
+	mustAdd(t, a, "latency:50|ms")
+	mustAdd(t, a, "latency:500|h")
+	mustAdd(t, a, "users:bob|s")
+	mustAdd(t, a, "users:alice|s")
+	mustAdd(t, a, "users:bob|s")
+
+	md := toMetrics(a.flush(2))
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 4, metrics.Len())
+
+	hits := metrics.At(0)
+	assert.Equal(t, "hits", hits.Name())
+	require.Equal(t, pmetric.MetricTypeSum, hits.Type())
+	require.Equal(t, 2, hits.Sum().DataPoints().Len())
+	assert.Equal(t, 5.0, hits.Sum().DataPoints().At(0).DoubleValue())
+	assert.Equal(t, 1.0, hits.Sum().DataPoints().At(1).DoubleValue())
+
+	latency := metrics.At(1)
+	require.Equal(t, pmetric.MetricTypeHistogram, latency.Type())
+	dp := latency.Histogram().DataPoints().At(0)
+	assert.Equal(t, uint64(3), dp.Count())
+	assert.Equal(t, 555.0, dp.Sum())
+	assert.Equal(t, 5.0, dp.Min())
+	assert.Equal(t, 500.0, dp.Max())
+	assert.Equal(t, []uint64{1, 1, 1}, dp.BucketCounts().AsRaw())
+
+	temp := metrics.At(2)
+	require.Equal(t, pmetric.MetricTypeGauge, temp.Type())
+	assert.Equal(t, 25.0, temp.Gauge().DataPoints().At(0).DoubleValue())
+
+	users := metrics.At(3)
+	assert.Equal(t, int64(2), users.Sum().DataPoints().At(0).IntValue())
+
+	// Gauges are only reported again once updated, keeping their state.
+	assert.Empty(t, a.flush(3))
+	mustAdd(t, a, "temp:-10|g")
+	md = toMetrics(a.flush(4))
+	assert.Equal(t, 15.0, md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).DoubleValue())
+}
+
+func TestAggregatorGaugeExpiry(t *testing.T) {
+	second := pcommon.Timestamp(time.Second)
+	a := newAggregator(nil, 2*time.Second, 0)
+
+	mustAdd(t, a, "temp:20|g")
+	require.Len(t, a.flush(1*second), 1)
+	assert.Empty(t, a.flush(2*second))
+	assert.Len(t, a.gauges, 1)
+
+	// The gauge is forgotten once idle for the expiry duration, a relative
+	// update then starts from 0.
+	assert.Empty(t, a.flush(3*second))
+	assert.Empty(t, a.gauges)
+	mustAdd(t, a, "temp:+5|g")
+	md := toMetrics(a.flush(4 * second))
+	assert.Equal(t, 5.0, md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints().At(0).DoubleValue())
+}
+
+func TestAggregatorAppendToArrow(t *testing.T) {
+	lines := []string{"hits:1|c|#a:1", "hits:1|c|#a:2", "temp:20|g", "latency:5|ms", "latency:50|ms", "users:bob|s"}
+	newFlushed := func() []flushedMetric {
+		a := newAggregator([]float64{10, 100}, 0, 1)
+		for _, line := range lines {
+			mustAdd(t, a, line)
+		}
+		return a.flush(2)
+	}
+	expected := toMetrics(newFlushed())
+
+	producer := arrowRecord.NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	batch, err := producer.BatchArrowRecordsFromMetricsAppender(func(a *metricsarrow.MetricsAppender) error {
+		return appendToArrow(a, newFlushed())
+	})
+	require.NoError(t, err)
+
+	consumer := arrowRecord.NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+	received, err := consumer.MetricsFrom(batch)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+
+	otelassert.Equiv(
+		t,
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(expected)},
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+	)
+}
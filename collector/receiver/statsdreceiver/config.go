@@ -0,0 +1,53 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package statsdreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+// Config defines the configuration for the statsd receiver.
+type Config struct {
+	// Endpoint is the UDP address to listen on. Default: localhost:8125.
+	Endpoint string `mapstructure:"endpoint"`
+	// FlushInterval is the aggregation interval. Default: 10s.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// HistogramBounds are the explicit bucket boundaries used for timers,
+	// histograms and distributions (in the unit of the reported values).
+	HistogramBounds []float64 `mapstructure:"histogram_bounds"`
+	// GaugeExpiry is the duration after which a gauge that has not been
+	// updated is forgotten, i.e. its series is no longer tracked and a later
+	// relative update starts from 0 (0 = never). Default: 5m.
+	GaugeExpiry time.Duration `mapstructure:"gauge_expiry"`
+}
+
+func createDefaultConfig() component.Config {
+	return &Config{
+		Endpoint:        "localhost:8125",
+		FlushInterval:   10 * time.Second,
+		HistogramBounds: []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+		GaugeExpiry:     5 * time.Minute,
+	}
+}
+
+// Validate checks the receiver configuration is valid.
+func (c Config) Validate() error {
+	if c.Endpoint == "" {
+		return errors.New("endpoint cannot be empty")
+	}
+	if c.FlushInterval <= 0 {
+		return errors.New("flush_interval must be positive")
+	}
+	if c.GaugeExpiry < 0 {
+		return errors.New("gauge_expiry cannot be negative")
+	}
+	if !sort.Float64sAreSorted(c.HistogramBounds) {
+		return errors.New("histogram_bounds must be sorted")
+	}
+	return nil
+}
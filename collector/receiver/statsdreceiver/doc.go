@@ -0,0 +1,14 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate mdatagen metadata.yaml
+
+// Package statsdreceiver implements a lightweight statsd/dogstatsd listener.
+// Counters, gauges, timers and sets are aggregated per flush interval and
+// emitted as a single batch per interval with one metric per name and one data
+// point per tag set. When the next consumer encodes the metrics in Arrow (see
+// arrowconsumer.Metrics), the data points are appended directly through the
+// Arrow number data point and histogram accumulators, without building a
+// pmetric.Metrics. Gauges keep their value across intervals until they have
+// not been updated for gauge_expiry.
+package statsdreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
@@ -0,0 +1,36 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package statsdreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver/internal/metadata"
+)
+
+// NewFactory creates a factory for the statsd receiver.
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		receiver.WithMetrics(createMetricsReceiver, metadata.MetricsStability),
+	)
+}
+
+func createMetricsReceiver(
+	_ context.Context,
+	settings receiver.CreateSettings,
+	cc component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	return &statsdReceiver{
+		consumer: consumer,
+		logger:   settings.Logger,
+		config:   cc.(*Config),
+	}, nil
+}
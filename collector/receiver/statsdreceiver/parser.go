@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package statsdreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// statsd metric types.
+const (
+	typeCounter      = "c"
+	typeGauge        = "g"
+	typeTimer        = "ms"
+	typeHistogram    = "h"
+	typeDistribution = "d"
+	typeSet          = "s"
+)
+
+// tag is a dogstatsd tag (`key:value`, or `key` alone).
+type tag struct {
+	key   string
+	value string
+}
+
+// statsdMetric is a single parsed statsd line.
+type statsdMetric struct {
+	name       string
+	typ        string
+	value      float64
+	rawValue   string
+	sampleRate float64
+	// relative is true for gauges prefixed with a sign (e.g. `g:+3|g`).
+	relative bool
+	tags     []tag
+}
+
+// parseLine parses a single statsd or dogstatsd line:
+//
+//	<name>:<value>|<type>[|@<sample rate>][|#<tag>:<value>,...]
+func parseLine(line string) (statsdMetric, error) {
+	m := statsdMetric{sampleRate: 1}
+
+	colon := strings.IndexByte(line, ':')
+	if colon <= 0 {
+		return m, fmt.Errorf("invalid statsd line %q", line)
+	}
+	m.name = line[:colon]
+
+	parts := strings.Split(line[colon+1:], "|")
+	if len(parts) < 2 {
+		return m, fmt.Errorf("invalid statsd line %q", line)
+	}
+	m.rawValue = parts[0]
+	m.typ = parts[1]
+
+	switch m.typ {
+	case typeCounter, typeGauge, typeTimer, typeHistogram, typeDistribution:
+		if m.typ == typeGauge && (strings.HasPrefix(m.rawValue, "+") || strings.HasPrefix(m.rawValue, "-")) {
+			m.relative = true
+		}
+		value, err := strconv.ParseFloat(m.rawValue, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid value in %q: %w", line, err)
+		}
+		m.value = value
+	case typeSet:
+	default:
+		return m, fmt.Errorf("unsupported metric type %q", m.typ)
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return m, fmt.Errorf("invalid sample rate in %q", line)
+			}
+			m.sampleRate = rate
+		case strings.HasPrefix(part, "#"):
+			for _, t := range strings.Split(part[1:], ",") {
+				if t == "" {
+					continue
+				}
+				k, v, _ := strings.Cut(t, ":")
+				m.tags = append(m.tags, tag{key: k, value: v})
+			}
+		}
+	}
+	sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].key < m.tags[j].key })
+
+	return m, nil
+}
@@ -0,0 +1,140 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package statsdreceiver // import "github.com/f5/otel-arrow-adapter/collector/receiver/statsdreceiver"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.uber.org/zap"
+
+	"github.com/f5/otel-arrow-adapter/collector/arrowconsumer"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+)
+
+// maxPacketSize is the maximum size of a UDP datagram.
+const maxPacketSize = 65535
+
+type statsdReceiver struct {
+	consumer consumer.Metrics
+	logger   *zap.Logger
+	config   *Config
+
+	conn       net.PacketConn
+	aggregator *aggregator
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func (r *statsdReceiver) Start(_ context.Context, _ component.Host) error {
+	conn, err := net.ListenPacket("udp", r.config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %q: %w", r.config.Endpoint, err)
+	}
+	r.conn = conn
+	r.aggregator = newAggregator(r.config.HistogramBounds, r.config.GaugeExpiry, pcommon.NewTimestampFromTime(time.Now()))
+
+	// The context passed to Start is not meant to outlive it, the flush loop
+	// runs until Shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		r.readLoop()
+	}()
+	go func() {
+		defer r.wg.Done()
+		r.flushLoop(ctx)
+	}()
+	return nil
+}
+
+func (r *statsdReceiver) Shutdown(_ context.Context) error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	var err error
+	if r.conn != nil {
+		err = r.conn.Close()
+	}
+	r.wg.Wait()
+	return err
+}
+
+// readLoop reads datagrams until the connection is closed. A datagram can
+// contain multiple newline-separated statsd lines.
+func (r *statsdReceiver) readLoop() {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				r.logger.Error("statsd read failed", zap.Error(err))
+			}
+			return
+		}
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			m, err := parseLine(line)
+			if err != nil {
+				r.logger.Debug("invalid statsd line", zap.Error(err))
+				continue
+			}
+			r.aggregator.add(m)
+		}
+	}
+}
+
+// flushLoop sends the aggregated metrics to the next consumer every flush
+// interval and once more on shutdown.
+func (r *statsdReceiver) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush(ctx)
+		case <-ctx.Done():
+			r.flush(context.Background())
+			return
+		}
+	}
+}
+
+// flush passes the aggregated metrics to the next consumer. When the next
+// consumer encodes the metrics in Arrow (see arrowconsumer.Metrics), the data
+// points are appended directly to its Arrow metrics builders, otherwise a
+// pmetric.Metrics is built.
+func (r *statsdReceiver) flush(ctx context.Context) {
+	metrics := r.aggregator.flush(pcommon.NewTimestampFromTime(time.Now()))
+	if len(metrics) == 0 {
+		return
+	}
+
+	var err error
+	if ac, ok := r.consumer.(arrowconsumer.Metrics); ok {
+		err = ac.ConsumeMetricsAppender(ctx, func(a *metricsarrow.MetricsAppender) error {
+			return appendToArrow(a, metrics)
+		})
+	} else {
+		err = r.consumer.ConsumeMetrics(ctx, toMetrics(metrics))
+	}
+	if err != nil {
+		r.logger.Error("failed to consume statsd metrics", zap.Error(err))
+	}
+}
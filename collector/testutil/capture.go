@@ -0,0 +1,308 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil // import "github.com/f5/otel-arrow-adapter/collector/testutil"
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+)
+
+// A capture file is a sequence of entries, each encoded as:
+//   - the kind of the entry (1 byte),
+//   - the ID of the stream (uvarint), unique in the capture,
+//   - the length of the body (uvarint) followed by the body: the method and
+//     the metadata of the stream in JSON for CaptureOpen, the protobuf
+//     encoding of the message for CaptureBatch and CaptureStatus, and an
+//     empty body for CaptureClose.
+
+// CaptureKind is the kind of a CaptureEntry.
+type CaptureKind byte
+
+const (
+	// CaptureOpen is the establishment of a stream.
+	CaptureOpen CaptureKind = iota + 1
+	// CaptureBatch is a BatchArrowRecords sent by the client of a stream.
+	CaptureBatch
+	// CaptureStatus is a BatchStatus sent by the server of a stream.
+	CaptureStatus
+	// CaptureClose is the end of a stream.
+	CaptureClose
+)
+
+type (
+	// CaptureEntry is an entry of a capture.
+	CaptureEntry struct {
+		Kind     CaptureKind
+		StreamID uint64
+
+		// Method and Metadata are only defined for CaptureOpen.
+		Method   string
+		Metadata metadata.MD
+		// Batch is only defined for CaptureBatch.
+		Batch *arrowpb.BatchArrowRecords
+		// Status is only defined for CaptureStatus.
+		Status *arrowpb.BatchStatus
+	}
+
+	// Capture records the Arrow streams of a gRPC server or client (see
+	// StreamServerInterceptor and StreamClientInterceptor) to a capture
+	// file, to be replayed by Replay or ReplayTo. It is safe for concurrent
+	// use.
+	Capture struct {
+		lock   sync.Mutex
+		w      *bufio.Writer
+		closer io.Closer
+		nextID uint64
+		// err is the first write error, the following entries are dropped.
+		err error
+	}
+
+	// captureOpen is the body of a CaptureOpen entry.
+	captureOpen struct {
+		Method   string              `json:"method"`
+		Metadata map[string][]string `json:"metadata,omitempty"`
+	}
+)
+
+// NewCapture creates a Capture writing to w.
+func NewCapture(w io.Writer) *Capture {
+	return &Capture{w: bufio.NewWriter(w)}
+}
+
+// CreateCapture creates a Capture writing to a new file (truncated if it
+// exists).
+func CreateCapture(path string) (*Capture, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	c := NewCapture(file)
+	c.closer = file
+	return c, nil
+}
+
+// Close flushes the capture, closes its file and returns the first error
+// encountered while writing the capture.
+func (c *Capture) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closer != nil {
+		if err := c.closer.Close(); err != nil && c.err == nil {
+			c.err = err
+		}
+		c.closer = nil
+	}
+	return c.err
+}
+
+// StreamServerInterceptor returns an interceptor recording the streams of a
+// gRPC server, e.g. the receiver under test.
+func (c *Capture) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		id := c.open(info.FullMethod, md)
+		defer c.write(CaptureEntry{Kind: CaptureClose, StreamID: id})
+
+		return handler(srv, &captureServerStream{ServerStream: ss, capture: c, id: id})
+	}
+}
+
+// StreamClientInterceptor returns an interceptor recording the streams of a
+// gRPC client, e.g. the exporter under test.
+func (c *Capture) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		md, _ := metadata.FromOutgoingContext(ctx)
+		return &captureClientStream{ClientStream: cs, capture: c, id: c.open(method, md)}, nil
+	}
+}
+
+// open records the establishment of a stream and returns its ID.
+func (c *Capture) open(method string, md metadata.MD) uint64 {
+	c.lock.Lock()
+	c.nextID++
+	id := c.nextID
+	c.lock.Unlock()
+
+	c.write(CaptureEntry{Kind: CaptureOpen, StreamID: id, Method: method, Metadata: md})
+	return id
+}
+
+// write appends an entry to the capture.
+func (c *Capture) write(entry CaptureEntry) {
+	var body []byte
+	var err error
+	switch entry.Kind {
+	case CaptureOpen:
+		body, err = json.Marshal(captureOpen{Method: entry.Method, Metadata: entry.Metadata})
+	case CaptureBatch:
+		body, err = proto.Marshal(entry.Batch)
+	case CaptureStatus:
+		body, err = proto.Marshal(entry.Status)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		return
+	}
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	var header [1 + 2*binary.MaxVarintLen64]byte
+	header[0] = byte(entry.Kind)
+	n := 1 + binary.PutUvarint(header[1:], entry.StreamID)
+	n += binary.PutUvarint(header[n:], uint64(len(body)))
+	if _, err := c.w.Write(header[:n]); err != nil {
+		c.err = err
+		return
+	}
+	if _, err := c.w.Write(body); err != nil {
+		c.err = err
+		return
+	}
+	// Every entry is flushed so that the capture of a crashing test run is
+	// usable.
+	c.err = c.w.Flush()
+}
+
+// ReadCapture reads the entries of a capture.
+func ReadCapture(r io.Reader) ([]CaptureEntry, error) {
+	br := bufio.NewReader(r)
+
+	var entries []CaptureEntry
+	for {
+		kind, err := br.ReadByte()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := CaptureEntry{Kind: CaptureKind(kind)}
+		if entry.StreamID, err = binary.ReadUvarint(br); err != nil {
+			return nil, fmt.Errorf("capture entry %d: %w", len(entries), err)
+		}
+		size, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("capture entry %d: %w", len(entries), err)
+		}
+		body := make([]byte, size)
+		if _, err := io.ReadFull(br, body); err != nil {
+			return nil, fmt.Errorf("capture entry %d: %w", len(entries), err)
+		}
+
+		switch entry.Kind {
+		case CaptureOpen:
+			var open captureOpen
+			err = json.Unmarshal(body, &open)
+			entry.Method, entry.Metadata = open.Method, open.Metadata
+		case CaptureBatch:
+			entry.Batch = &arrowpb.BatchArrowRecords{}
+			err = proto.Unmarshal(body, entry.Batch)
+		case CaptureStatus:
+			entry.Status = &arrowpb.BatchStatus{}
+			err = proto.Unmarshal(body, entry.Status)
+		case CaptureClose:
+		default:
+			err = fmt.Errorf("unknown kind %d", kind)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("capture entry %d: %w", len(entries), err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// OpenCapture reads the entries of a capture file.
+func OpenCapture(path string) ([]CaptureEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadCapture(file)
+}
+
+// captureServerStream records the batches received and the statuses sent by
+// a server stream.
+type captureServerStream struct {
+	grpc.ServerStream
+	capture *Capture
+	id      uint64
+}
+
+func (s *captureServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if batch, ok := m.(*arrowpb.BatchArrowRecords); ok && err == nil {
+		s.capture.write(CaptureEntry{Kind: CaptureBatch, StreamID: s.id, Batch: batch})
+	}
+	return err
+}
+
+func (s *captureServerStream) SendMsg(m interface{}) error {
+	if status, ok := m.(*arrowpb.BatchStatus); ok {
+		s.capture.write(CaptureEntry{Kind: CaptureStatus, StreamID: s.id, Status: status})
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// captureClientStream records the batches sent and the statuses received by a
+// client stream. The stream is closed by the first receive error.
+type captureClientStream struct {
+	grpc.ClientStream
+	capture *Capture
+	id      uint64
+	closed  sync.Once
+}
+
+func (s *captureClientStream) SendMsg(m interface{}) error {
+	if batch, ok := m.(*arrowpb.BatchArrowRecords); ok {
+		s.capture.write(CaptureEntry{Kind: CaptureBatch, StreamID: s.id, Batch: batch})
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *captureClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.closed.Do(func() {
+			s.capture.write(CaptureEntry{Kind: CaptureClose, StreamID: s.id})
+		})
+		return err
+	}
+	if status, ok := m.(*arrowpb.BatchStatus); ok {
+		s.capture.write(CaptureEntry{Kind: CaptureStatus, StreamID: s.id, Status: status})
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/datagen"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// sendTraces sends count traces batches on a new stream of conn and returns
+// the statuses received.
+func sendTraces(ctx context.Context, t *testing.T, conn *grpc.ClientConn, count int) []arrowpb.StatusCode {
+	ctx = metadata.AppendToOutgoingContext(ctx, "tenant", "test")
+	stream, err := arrowpb.NewArrowStreamServiceClient(conn).ArrowStream(ctx)
+	require.NoError(t, err)
+
+	producer := arrowRecord.NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+
+	var codes []arrowpb.StatusCode
+	for i := 0; i < count; i++ {
+		batch, err := producer.BatchArrowRecordsFromTraces(dg.Generate(10, time.Minute))
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(batch))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		codes = append(codes, resp.StatusCode)
+	}
+	require.NoError(t, stream.CloseSend())
+
+	// The end of the stream is received once the server handler returned.
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+	return codes
+}
+
+func TestCaptureServerReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "streams.capture")
+	capture, err := CreateCapture(path)
+	require.NoError(t, err)
+
+	rcv := NewReceiver(grpc.StreamInterceptor(capture.StreamServerInterceptor()))
+	defer rcv.Close()
+	rcv.SetStatus(StatusSequence(arrowpb.StatusCode_UNAVAILABLE))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := rcv.Dial(ctx)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, conn.Close()) }()
+
+	codes := sendTraces(ctx, t, conn, 3)
+	require.Equal(t, []arrowpb.StatusCode{arrowpb.StatusCode_UNAVAILABLE, arrowpb.StatusCode_OK, arrowpb.StatusCode_OK}, codes)
+	require.NoError(t, capture.Close())
+
+	entries, err := OpenCapture(path)
+	require.NoError(t, err)
+	var kinds []CaptureKind
+	for _, entry := range entries {
+		kinds = append(kinds, entry.Kind)
+	}
+	require.Equal(t, []CaptureKind{
+		CaptureOpen,
+		CaptureBatch, CaptureStatus,
+		CaptureBatch, CaptureStatus,
+		CaptureBatch, CaptureStatus,
+		CaptureClose,
+	}, kinds)
+	require.Equal(t, "/opentelemetry.proto.experimental.arrow.v1.ArrowStreamService/ArrowStream", entries[0].Method)
+	require.Equal(t, []string{"test"}, entries[0].Metadata.Get("tenant"))
+
+	// The replay decodes the same data as the receiver, deterministically.
+	received := rcv.Traces()
+	for i := 0; i < 2; i++ {
+		results := Replay(entries)
+		require.Len(t, results, 3)
+		require.Equal(t, arrowpb.StatusCode_UNAVAILABLE, results[0].Status.StatusCode)
+		for j, result := range results {
+			require.NoError(t, result.Err)
+			require.Len(t, result.Traces, 1)
+			if j > 0 {
+				require.Equal(t, received[j-1].SpanCount(), result.Traces[0].SpanCount())
+			}
+		}
+	}
+
+	// The captured streams are replayed to another receiver.
+	target := NewReceiver()
+	defer target.Close()
+	targetConn, err := target.Dial(ctx)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, targetConn.Close()) }()
+
+	statuses, err := ReplayTo(ctx, targetConn, entries)
+	require.NoError(t, err)
+	require.Len(t, statuses[entries[0].StreamID], 3)
+	require.Len(t, target.Batches(), 3)
+	require.Len(t, target.Traces(), 3)
+}
+
+func TestCaptureClient(t *testing.T) {
+	var buf bytes.Buffer
+	capture := NewCapture(&buf)
+
+	rcv := NewReceiver()
+	defer rcv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := rcv.Dial(ctx, grpc.WithStreamInterceptor(capture.StreamClientInterceptor()))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, conn.Close()) }()
+
+	sendTraces(ctx, t, conn, 2)
+	require.NoError(t, capture.Close())
+
+	entries, err := ReadCapture(&buf)
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	require.Equal(t, CaptureOpen, entries[0].Kind)
+	require.Equal(t, []string{"test"}, entries[0].Metadata.Get("tenant"))
+	require.Equal(t, CaptureClose, entries[5].Kind)
+
+	results := Replay(entries)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		require.NoError(t, result.Err)
+		require.Equal(t, arrowpb.StatusCode_OK, result.Status.StatusCode)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil // import "github.com/f5/otel-arrow-adapter/collector/testutil"
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/exporter"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const (
+	// The value of "type" key in configuration.
+	exporterType = "memory"
+	// The stability level of the exporter.
+	exporterStability = component.StabilityLevelDevelopment
+)
+
+// ExporterConfig is the (empty) configuration of the in-memory exporter.
+type ExporterConfig struct{}
+
+// Sink stores the data received by the in-memory exporters, by signal.
+type Sink struct {
+	Traces  consumertest.TracesSink
+	Logs    consumertest.LogsSink
+	Metrics consumertest.MetricsSink
+}
+
+// Reset deletes the data stored by the sink.
+func (s *Sink) Reset() {
+	s.Traces.Reset()
+	s.Logs.Reset()
+	s.Metrics.Reset()
+}
+
+// NewExporterFactory returns the factory of an in-memory exporter, of type
+// "memory", storing the data it receives in the given sink. The exporter can
+// end a pipeline including the OTLP Arrow receiver to check its output.
+func NewExporterFactory(sink *Sink) exporter.Factory {
+	return exporter.NewFactory(
+		exporterType,
+		func() component.Config { return &ExporterConfig{} },
+		exporter.WithTraces(func(ctx context.Context, set exporter.CreateSettings, cfg component.Config) (exporter.Traces, error) {
+			return exporterhelper.NewTracesExporter(ctx, set, cfg, sink.Traces.ConsumeTraces,
+				exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}))
+		}, exporterStability),
+		exporter.WithLogs(func(ctx context.Context, set exporter.CreateSettings, cfg component.Config) (exporter.Logs, error) {
+			return exporterhelper.NewLogsExporter(ctx, set, cfg, sink.Logs.ConsumeLogs,
+				exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}))
+		}, exporterStability),
+		exporter.WithMetrics(func(ctx context.Context, set exporter.CreateSettings, cfg component.Config) (exporter.Metrics, error) {
+			return exporterhelper.NewMetricsExporter(ctx, set, cfg, sink.Metrics.ConsumeMetrics,
+				exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: false}))
+		}, exporterStability),
+	)
+}
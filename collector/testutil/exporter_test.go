@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/exporter/exportertest"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestExporterFactory(t *testing.T) {
+	sink := &Sink{}
+	factory := NewExporterFactory(sink)
+	cfg := factory.CreateDefaultConfig()
+
+	ctx := context.Background()
+	traces, err := factory.CreateTracesExporter(ctx, exportertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, err)
+	logs, err := factory.CreateLogsExporter(ctx, exportertest.NewNopCreateSettings(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, traces.Start(ctx, componenttest.NewNopHost()))
+	require.NoError(t, logs.Start(ctx, componenttest.NewNopHost()))
+
+	td := ptrace.NewTraces()
+	td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	require.NoError(t, traces.ConsumeTraces(ctx, td))
+
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	require.NoError(t, logs.ConsumeLogs(ctx, ld))
+
+	require.NoError(t, traces.Shutdown(ctx))
+	require.NoError(t, logs.Shutdown(ctx))
+
+	require.Equal(t, 1, sink.Traces.SpanCount())
+	require.Equal(t, 1, sink.Logs.LogRecordCount())
+	require.Equal(t, 0, sink.Metrics.DataPointCount())
+
+	sink.Reset()
+	require.Equal(t, 0, sink.Traces.SpanCount())
+}
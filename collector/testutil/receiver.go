@@ -0,0 +1,249 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides in-memory components to write integration tests
+// of pipelines including the OTLP Arrow receiver and exporter, without
+// listening on a network port.
+package testutil // import "github.com/f5/otel-arrow-adapter/collector/testutil"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// bufferSize is the size of the in-memory connection buffers.
+const bufferSize = 1 << 20
+
+// StatusFunc returns the status code and message sent back for a batch
+// received by a Receiver.
+type StatusFunc func(batch *arrowpb.BatchArrowRecords) (arrowpb.StatusCode, string)
+
+// Receiver is an in-memory OTAP receiver. It serves the Arrow stream services
+// (the generic one and the per-signal ones) over an in-memory connection (see
+// Dial), records all the batches received and decodes them.
+type Receiver struct {
+	arrowpb.UnimplementedArrowStreamServiceServer
+	arrowpb.UnimplementedArrowTracesServiceServer
+	arrowpb.UnimplementedArrowLogsServiceServer
+	arrowpb.UnimplementedArrowMetricsServiceServer
+
+	listener *bufconn.Listener
+	server   *grpc.Server
+
+	lock    sync.Mutex
+	status  StatusFunc
+	batches []*arrowpb.BatchArrowRecords
+	traces  []ptrace.Traces
+	logs    []plog.Logs
+	metrics []pmetric.Metrics
+}
+
+// arrowStream is the server side of the Arrow streams of every service.
+type arrowStream interface {
+	Send(*arrowpb.BatchStatus) error
+	Recv() (*arrowpb.BatchArrowRecords, error)
+}
+
+// NewReceiver creates and starts a Receiver accepting all the batches, with
+// the given options of its gRPC server (e.g. the interceptors of a Capture).
+// Close must be called to stop it.
+func NewReceiver(opts ...grpc.ServerOption) *Receiver {
+	r := &Receiver{
+		listener: bufconn.Listen(bufferSize),
+		server:   grpc.NewServer(opts...),
+	}
+	arrowpb.RegisterArrowStreamServiceServer(r.server, r)
+	arrowpb.RegisterArrowTracesServiceServer(r.server, r)
+	arrowpb.RegisterArrowLogsServiceServer(r.server, r)
+	arrowpb.RegisterArrowMetricsServiceServer(r.server, r)
+
+	go func() {
+		_ = r.server.Serve(r.listener)
+	}()
+	return r
+}
+
+// Dial returns a client connection to the receiver.
+func (r *Receiver) Dial(ctx context.Context, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return r.listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, opts...)
+	return grpc.DialContext(ctx, "bufconn", opts...)
+}
+
+// Close stops the receiver and closes its streams.
+func (r *Receiver) Close() {
+	r.server.Stop()
+}
+
+// SetStatus sets the function returning the status of the next batches, nil
+// accepts all the batches. The batches are decoded whatever their status,
+// but only the data of the accepted batches is recorded.
+func (r *Receiver) SetStatus(fn StatusFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status = fn
+}
+
+// StatusSequence returns a StatusFunc returning the given status codes in
+// order, then StatusCode_OK.
+func StatusSequence(codes ...arrowpb.StatusCode) StatusFunc {
+	var lock sync.Mutex
+	return func(_ *arrowpb.BatchArrowRecords) (arrowpb.StatusCode, string) {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(codes) == 0 {
+			return arrowpb.StatusCode_OK, ""
+		}
+		code := codes[0]
+		codes = codes[1:]
+		return code, code.String()
+	}
+}
+
+// Batches returns the batches received so far, whatever their status.
+func (r *Receiver) Batches() []*arrowpb.BatchArrowRecords {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*arrowpb.BatchArrowRecords(nil), r.batches...)
+}
+
+// Traces returns the traces of the batches accepted so far.
+func (r *Receiver) Traces() []ptrace.Traces {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]ptrace.Traces(nil), r.traces...)
+}
+
+// Logs returns the logs of the batches accepted so far.
+func (r *Receiver) Logs() []plog.Logs {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]plog.Logs(nil), r.logs...)
+}
+
+// Metrics returns the metrics of the batches accepted so far.
+func (r *Receiver) Metrics() []pmetric.Metrics {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]pmetric.Metrics(nil), r.metrics...)
+}
+
+// ArrowStream implements arrowpb.ArrowStreamServiceServer.
+func (r *Receiver) ArrowStream(stream arrowpb.ArrowStreamService_ArrowStreamServer) error {
+	return r.serve(stream)
+}
+
+// ArrowTraces implements arrowpb.ArrowTracesServiceServer.
+func (r *Receiver) ArrowTraces(stream arrowpb.ArrowTracesService_ArrowTracesServer) error {
+	return r.serve(stream)
+}
+
+// ArrowLogs implements arrowpb.ArrowLogsServiceServer.
+func (r *Receiver) ArrowLogs(stream arrowpb.ArrowLogsService_ArrowLogsServer) error {
+	return r.serve(stream)
+}
+
+// ArrowMetrics implements arrowpb.ArrowMetricsServiceServer.
+func (r *Receiver) ArrowMetrics(stream arrowpb.ArrowMetricsService_ArrowMetricsServer) error {
+	return r.serve(stream)
+}
+
+// serve receives the batches of a stream until it is closed. Every stream
+// has its own consumer, like in the OTLP Arrow receiver.
+func (r *Receiver) serve(stream arrowStream) error {
+	consumer := arrowRecord.NewConsumer()
+	defer func() {
+		_ = consumer.Close()
+	}()
+
+	for {
+		batch, err := stream.Recv()
+		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		code, msg := r.receive(consumer, batch)
+		if err := stream.Send(&arrowpb.BatchStatus{
+			BatchId:       batch.BatchId,
+			StatusCode:    code,
+			StatusMessage: msg,
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// receive records and decodes a batch, and returns its status.
+func (r *Receiver) receive(consumer *arrowRecord.Consumer, batch *arrowpb.BatchArrowRecords) (arrowpb.StatusCode, string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.batches = append(r.batches, batch)
+
+	// The batch is decoded even if it is rejected to keep the state of the
+	// IPC streams of the consumer in sync with the producer.
+	traces, logs, metrics, err := decode(consumer, batch)
+	if err != nil {
+		return arrowpb.StatusCode_INVALID_ARGUMENT, err.Error()
+	}
+
+	if r.status != nil {
+		if code, msg := r.status(batch); code != arrowpb.StatusCode_OK {
+			return code, msg
+		}
+	}
+
+	r.traces = append(r.traces, traces...)
+	r.logs = append(r.logs, logs...)
+	r.metrics = append(r.metrics, metrics...)
+	return arrowpb.StatusCode_OK, ""
+}
+
+// decode decodes a batch according to the type of its main payload.
+func decode(consumer *arrowRecord.Consumer, batch *arrowpb.BatchArrowRecords) (traces []ptrace.Traces, logs []plog.Logs, metrics []pmetric.Metrics, err error) {
+	if len(batch.ArrowPayloads) == 0 {
+		return nil, nil, nil, nil
+	}
+	switch batch.ArrowPayloads[0].Type {
+	case arrowpb.ArrowPayloadType_SPANS:
+		traces, err = consumer.TracesFrom(batch)
+	case arrowpb.ArrowPayloadType_LOGS:
+		logs, err = consumer.LogsFrom(batch)
+	case arrowpb.ArrowPayloadType_METRICS:
+		metrics, err = consumer.MetricsFrom(batch)
+	}
+	return traces, logs, metrics, err
+}
@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/datagen"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+func TestReceiver(t *testing.T) {
+	rcv := NewReceiver()
+	defer rcv.Close()
+	rcv.SetStatus(StatusSequence(arrowpb.StatusCode_UNAVAILABLE))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := rcv.Dial(ctx)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, conn.Close()) }()
+
+	stream, err := arrowpb.NewArrowStreamServiceClient(conn).ArrowStream(ctx)
+	require.NoError(t, err)
+
+	producer := arrowRecord.NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+
+	expected := []arrowpb.StatusCode{arrowpb.StatusCode_UNAVAILABLE, arrowpb.StatusCode_OK}
+	spanCount := 0
+	for _, code := range expected {
+		td := dg.Generate(10, time.Minute)
+		spanCount = td.SpanCount()
+		batch, err := producer.BatchArrowRecordsFromTraces(td)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(batch))
+
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, batch.BatchId, resp.BatchId)
+		require.Equal(t, code, resp.StatusCode)
+	}
+	require.NoError(t, stream.CloseSend())
+
+	// Both batches are recorded, only the accepted one is decoded.
+	require.Len(t, rcv.Batches(), 2)
+	traces := rcv.Traces()
+	require.Len(t, traces, 1)
+	require.Equal(t, spanCount, traces[0].SpanCount())
+	require.Empty(t, rcv.Logs())
+	require.Empty(t, rcv.Metrics())
+}
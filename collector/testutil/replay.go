@@ -0,0 +1,143 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil // import "github.com/f5/otel-arrow-adapter/collector/testutil"
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowRecord "github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// ReplayResult is the result of the replay of a captured batch.
+type ReplayResult struct {
+	StreamID uint64
+	Batch    *arrowpb.BatchArrowRecords
+	// Status is the captured status of the batch, nil if the capture
+	// doesn't contain it.
+	Status *arrowpb.BatchStatus
+
+	// The data decoded from the batch and the decoding error.
+	Traces  []ptrace.Traces
+	Logs    []plog.Logs
+	Metrics []pmetric.Metrics
+	Err     error
+}
+
+// Replay decodes the captured batches in order, with one consumer per
+// captured stream like a receiver, and returns the result of every batch.
+// The replay is deterministic: the same capture and options always give the
+// same results.
+func Replay(entries []CaptureEntry, options ...arrowRecord.ConsumerOption) []ReplayResult {
+	type batchKey struct {
+		streamID uint64
+		batchID  int64
+	}
+	statuses := make(map[batchKey]*arrowpb.BatchStatus)
+	for _, entry := range entries {
+		if entry.Kind == CaptureStatus {
+			statuses[batchKey{entry.StreamID, entry.Status.BatchId}] = entry.Status
+		}
+	}
+
+	consumers := make(map[uint64]*arrowRecord.Consumer)
+	defer func() {
+		for _, consumer := range consumers {
+			_ = consumer.Close()
+		}
+	}()
+
+	var results []ReplayResult
+	for _, entry := range entries {
+		switch entry.Kind {
+		case CaptureBatch:
+			consumer := consumers[entry.StreamID]
+			if consumer == nil {
+				consumer = arrowRecord.NewConsumerWithOptions(options...)
+				consumers[entry.StreamID] = consumer
+			}
+			result := ReplayResult{
+				StreamID: entry.StreamID,
+				Batch:    entry.Batch,
+				Status:   statuses[batchKey{entry.StreamID, entry.Batch.BatchId}],
+			}
+			result.Traces, result.Logs, result.Metrics, result.Err = decode(consumer, entry.Batch)
+			results = append(results, result)
+		case CaptureClose:
+			if consumer := consumers[entry.StreamID]; consumer != nil {
+				_ = consumer.Close()
+				delete(consumers, entry.StreamID)
+			}
+		}
+	}
+	return results
+}
+
+// ReplayTo replays the captured streams on conn, e.g. to a receiver under
+// test. The streams are replayed one after the other, in the order of their
+// establishment, with their captured method and metadata. Every batch is
+// sent once the status of the previous one has been received, and the
+// statuses received are returned by stream ID.
+func ReplayTo(ctx context.Context, conn grpc.ClientConnInterface, entries []CaptureEntry) (map[uint64][]*arrowpb.BatchStatus, error) {
+	var order []uint64
+	opens := make(map[uint64]CaptureEntry)
+	batches := make(map[uint64][]*arrowpb.BatchArrowRecords)
+	for _, entry := range entries {
+		switch entry.Kind {
+		case CaptureOpen:
+			order = append(order, entry.StreamID)
+			opens[entry.StreamID] = entry
+		case CaptureBatch:
+			batches[entry.StreamID] = append(batches[entry.StreamID], entry.Batch)
+		}
+	}
+
+	desc := &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}
+	statuses := make(map[uint64][]*arrowpb.BatchStatus)
+	for _, id := range order {
+		open := opens[id]
+		streamCtx, cancel := context.WithCancel(metadata.NewOutgoingContext(ctx, open.Metadata))
+		stream, err := conn.NewStream(streamCtx, desc, open.Method)
+		if err != nil {
+			cancel()
+			return statuses, fmt.Errorf("stream %d: %w", id, err)
+		}
+		for _, batch := range batches[id] {
+			if err := stream.SendMsg(batch); err != nil {
+				cancel()
+				return statuses, fmt.Errorf("stream %d: %w", id, err)
+			}
+			status := &arrowpb.BatchStatus{}
+			if err := stream.RecvMsg(status); err != nil {
+				cancel()
+				return statuses, fmt.Errorf("stream %d: %w", id, err)
+			}
+			statuses[id] = append(statuses[id], status)
+		}
+		err = stream.CloseSend()
+		cancel()
+		if err != nil {
+			return statuses, fmt.Errorf("stream %d: %w", id, err)
+		}
+	}
+	return statuses, nil
+}
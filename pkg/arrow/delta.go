@@ -0,0 +1,118 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Bulk routines used to decode delta-encoded ID columns and to read ID columns
+// without going through the per-row accessors (type switch and null check for
+// every single value).
+//
+// The loops are manually unrolled by 4 to reduce the number of bounds checks
+// and loop iterations, which lets the compiler keep the running sum in a
+// register. The remainder is processed by the generic loop.
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// DeltaDecodeU16 replaces, in place, the deltas by the values they encode
+// (i.e. the prefix sum of the deltas starting at prev). Returns the last
+// decoded value (or prev if values is empty).
+func DeltaDecodeU16(values []uint16, prev uint16) uint16 {
+	i := 0
+	for ; i+4 <= len(values); i += 4 {
+		v := values[i : i+4 : i+4]
+		v[0] += prev
+		v[1] += v[0]
+		v[2] += v[1]
+		v[3] += v[2]
+		prev = v[3]
+	}
+	for ; i < len(values); i++ {
+		prev += values[i]
+		values[i] = prev
+	}
+	return prev
+}
+
+// U16ValuesFromRecord returns a copy of the values of a uint16 column, null
+// values being replaced by 0. The returned slice has one value per row of the
+// record (all 0 if the column is absent).
+func U16ValuesFromRecord(record arrow.Record, fieldID int) ([]uint16, error) {
+	values := make([]uint16, record.NumRows())
+	if fieldID == AbsentFieldID {
+		return values, nil
+	}
+
+	switch arr := record.Column(fieldID).(type) {
+	case nil:
+		return values, nil
+	case *array.Uint16:
+		// Values is a zero-copy view on the Arrow buffer.
+		copy(values, arr.Uint16Values())
+		if arr.NullN() > 0 {
+			for i := range values {
+				if arr.IsNull(i) {
+					values[i] = 0
+				}
+			}
+		}
+		return values, nil
+	default:
+		return nil, werror.WrapWithMsg(ErrInvalidArrayType, "not a uint16 array")
+	}
+}
+
+// U32ValuesFromRecord is the uint32 version of U16ValuesFromRecord.
+func U32ValuesFromRecord(record arrow.Record, fieldID int) ([]uint32, error) {
+	values := make([]uint32, record.NumRows())
+	if fieldID == AbsentFieldID {
+		return values, nil
+	}
+
+	switch arr := record.Column(fieldID).(type) {
+	case nil:
+		return values, nil
+	case *array.Uint32:
+		// Values is a zero-copy view on the Arrow buffer.
+		copy(values, arr.Uint32Values())
+		if arr.NullN() > 0 {
+			for i := range values {
+				if arr.IsNull(i) {
+					values[i] = 0
+				}
+			}
+		}
+		return values, nil
+	default:
+		return nil, werror.WrapWithMsg(ErrInvalidArrayType, "not a uint32 array")
+	}
+}
+
+// DeltaDecodedU16FromRecord returns the decoded values of a delta-encoded
+// uint16 column (null values being equivalent to a delta of 0).
+func DeltaDecodedU16FromRecord(record arrow.Record, fieldID int) ([]uint16, error) {
+	values, err := U16ValuesFromRecord(record, fieldID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	DeltaDecodeU16(values, 0)
+	return values, nil
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltaDecodeU16(t *testing.T) {
+	t.Parallel()
+
+	// Lengths not multiple of 4 exercise the remainder loop.
+	for _, n := range []int{0, 1, 3, 4, 7, 64, 1001} {
+		ids := make([]uint16, n)
+		values := make([]uint16, n)
+		prev := uint16(10)
+		for i := range ids {
+			ids[i] = 10 + uint16(i*3)
+			values[i] = ids[i] - prev
+			prev = ids[i]
+		}
+
+		last := DeltaDecodeU16(values, 10)
+		require.Equal(t, ids, values)
+		require.Equal(t, prev, last)
+	}
+}
+
+func TestDeltaDecodedU16FromRecord(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	schema := arrow.NewSchema([]arrow.Field{{Name: "id", Type: arrow.PrimitiveTypes.Uint16, Nullable: true}}, nil)
+	rb := array.NewRecordBuilder(pool, schema)
+	defer rb.Release()
+	rb.Field(0).(*array.Uint16Builder).AppendValues([]uint16{5, 1, 0, 2, 1}, []bool{true, true, false, true, true})
+	record := rb.NewRecord()
+	defer record.Release()
+
+	ids, err := DeltaDecodedU16FromRecord(record, 0)
+	require.NoError(t, err)
+	// A null delta is equivalent to a delta of 0.
+	require.Equal(t, []uint16{5, 6, 6, 8, 9}, ids)
+
+	ids, err = DeltaDecodedU16FromRecord(record, AbsentFieldID)
+	require.NoError(t, err)
+	require.Equal(t, []uint16{0, 0, 0, 0, 0}, ids)
+}
+
+func BenchmarkDeltaDecodeU16(b *testing.B) {
+	deltas := make([]uint16, 4096)
+	for i := range deltas {
+		deltas[i] = uint16(rand.Intn(3)) //nolint:gosec // only used for benchmarking
+	}
+	values := make([]uint16, len(deltas))
+
+	b.Run("unrolled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(values, deltas)
+			DeltaDecodeU16(values, 0)
+		}
+	})
+
+	b.Run("generic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			copy(values, deltas)
+			var prev uint16
+			for j := range values {
+				prev += values[j]
+				values[j] = prev
+			}
+		}
+	})
+}
+
+func BenchmarkDeltaDecodedU16FromRecord(b *testing.B) {
+	pool := memory.NewGoAllocator()
+	schema := arrow.NewSchema([]arrow.Field{{Name: "id", Type: arrow.PrimitiveTypes.Uint16, Nullable: true}}, nil)
+	rb := array.NewRecordBuilder(pool, schema)
+	defer rb.Release()
+	for i := 0; i < 4096; i++ {
+		rb.Field(0).(*array.Uint16Builder).Append(uint16(rand.Intn(3))) //nolint:gosec // only used for benchmarking
+	}
+	record := rb.NewRecord()
+	defer record.Release()
+
+	b.Run("bulk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := DeltaDecodedU16FromRecord(record, 0)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("per_row", func(b *testing.B) {
+		values := make([]uint16, record.NumRows())
+		for i := 0; i < b.N; i++ {
+			var prev uint16
+			for row := range values {
+				delta, err := U16FromRecord(record, 0, row)
+				require.NoError(b, err)
+				prev += delta
+				values[row] = prev
+			}
+		}
+	})
+}
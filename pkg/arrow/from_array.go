@@ -27,7 +27,8 @@ import (
 )
 
 // I32FromArray returns the int32 value for a specific row in an Arrow array.
-// This Arrow array can be either an int32 array or a dictionary-encoded array.
+// This Arrow array can be either an int32 array, a dictionary-encoded array or
+// a run-end encoded array.
 func I32FromArray(arr arrow.Array, row int) (int32, error) {
 	if arr == nil {
 		return 0, nil
@@ -46,6 +47,8 @@ func I32FromArray(arr arrow.Array, row int) (int32, error) {
 			} else {
 				return i32Arr.Value(arr.GetValueIndex(row)), nil
 			}
+		case *array.RunEndEncoded:
+			return I32FromArray(arr.Values(), arr.GetPhysicalIndex(row))
 		default:
 			return 0, werror.WrapWithMsg(ErrInvalidArrayType, "not an int32 array")
 		}
@@ -90,6 +93,8 @@ func StringFromArray(arr arrow.Array, row int) (string, error) {
 		switch arr := arr.(type) {
 		case *array.String:
 			return arr.Value(row), nil
+		case *array.LargeString:
+			return arr.Value(row), nil
 		case *array.Dictionary:
 			return arr.Dictionary().(*array.String).Value(arr.GetValueIndex(row)), nil
 		default:
@@ -113,6 +118,8 @@ func BinaryFromArray(arr arrow.Array, row int) ([]byte, error) {
 		switch arr := arr.(type) {
 		case *array.Binary:
 			return arr.Value(row), nil
+		case *array.LargeBinary:
+			return arr.Value(row), nil
 		case *array.Dictionary:
 			return arr.Dictionary().(*array.Binary).Value(arr.GetValueIndex(row)), nil
 		default:
@@ -259,6 +266,7 @@ func DurationFromArray(arr arrow.Array, row int) (arrow.Duration, error) {
 }
 
 // U8FromArray returns the uint8 value for a specific row in an Arrow array.
+// This Arrow array can be either a uint8 array or a run-end encoded array.
 func U8FromArray(arr arrow.Array, row int) (uint8, error) {
 	if arr == nil {
 		return 0, nil
@@ -270,6 +278,8 @@ func U8FromArray(arr arrow.Array, row int) (uint8, error) {
 			} else {
 				return arr.Value(row), nil
 			}
+		case *array.RunEndEncoded:
+			return U8FromArray(arr.Values(), arr.GetPhysicalIndex(row))
 		default:
 			return 0, werror.WrapWithMsg(ErrInvalidArrayType, "not a uint8 array")
 		}
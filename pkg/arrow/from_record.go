@@ -46,6 +46,8 @@ func U8FromRecord(record arrow.Record, fieldID int, row int) (uint8, error) {
 		} else {
 			return arr.Value(row), nil
 		}
+	case *array.RunEndEncoded:
+		return U8FromArray(arr.Values(), arr.GetPhysicalIndex(row))
 	default:
 		return 0, werror.WrapWithMsg(ErrInvalidArrayType, "not a uint8 array")
 	}
@@ -185,6 +187,8 @@ func I32FromRecord(record arrow.Record, fieldID int, row int) (int32, error) {
 		} else {
 			return i32Arr.Value(arr.GetValueIndex(row)), nil
 		}
+	case *array.RunEndEncoded:
+		return I32FromArray(arr.Values(), arr.GetPhysicalIndex(row))
 	default:
 		return 0, werror.WrapWithMsg(ErrInvalidArrayType, "not a int32 array")
 	}
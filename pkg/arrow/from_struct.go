@@ -37,7 +37,7 @@ func U16FromStruct(structArr *array.Struct, row int, fieldID int) (uint16, error
 }
 
 // NullableU16FromStruct returns a reference to an uint16 value for a specific
-// row in an Arrow struct or nil if the field doesn't exist.
+// row in an Arrow struct or nil if the field doesn't exist or is null.
 func NullableU16FromStruct(structArr *array.Struct, row int, fieldID int) (*uint16, error) {
 	if fieldID == AbsentFieldID {
 		return nil, nil
@@ -45,7 +45,11 @@ func NullableU16FromStruct(structArr *array.Struct, row int, fieldID int) (*uint
 	if structArr.IsNull(row) {
 		return nil, nil
 	}
-	val, err := U16FromArray(structArr.Field(fieldID), row)
+	arr := structArr.Field(fieldID)
+	if arr.IsNull(row) {
+		return nil, nil
+	}
+	val, err := U16FromArray(arr, row)
 	if err != nil {
 		return nil, err
 	}
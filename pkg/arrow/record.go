@@ -163,12 +163,24 @@ func arrayColValues(arr arrow.Array, row int) []string {
 			str = str[:MaxColSize]
 		}
 		return []string{fmt.Sprintf(MaxValSize, str)}
+	case *array.LargeString:
+		str := c.Value(row)
+		if len(str) > MaxColSize {
+			str = str[:MaxColSize]
+		}
+		return []string{fmt.Sprintf(MaxValSize, str)}
 	case *array.Binary:
 		bin := c.Value(row)
 		if len(bin) > MaxColSize {
 			bin = bin[:MaxColSize]
 		}
 		return []string{fmt.Sprintf(MaxValSize, bin)}
+	case *array.LargeBinary:
+		bin := c.Value(row)
+		if len(bin) > MaxColSize {
+			bin = bin[:MaxColSize]
+		}
+		return []string{fmt.Sprintf(MaxValSize, bin)}
 	case *array.FixedSizeBinary:
 		bin := c.Value(row)
 		if len(bin) > MaxColSize {
@@ -240,6 +252,8 @@ func sparseUnionValue(union *array.SparseUnion, row int) string {
 		switch arr := strArr.(type) {
 		case *array.String:
 			return arr.Value(row)
+		case *array.LargeString:
+			return arr.Value(row)
 		case *array.Dictionary:
 			return arr.Dictionary().(*array.String).Value(arr.GetValueIndex(row))
 		default:
@@ -309,6 +323,8 @@ func binaryFromArray(arr arrow.Array, row int) ([]byte, error) {
 		switch arr := arr.(type) {
 		case *array.Binary:
 			return arr.Value(row), nil
+		case *array.LargeBinary:
+			return arr.Value(row), nil
 		case *array.Dictionary:
 			return arr.Dictionary().(*array.Binary).Value(arr.GetValueIndex(row)), nil
 		default:
@@ -40,12 +40,15 @@ const F64Sig = "F64"
 const BinarySig = "Bin"
 const FixedSizeBinarySig = "FSB"
 const StringSig = "Str"
+const LargeStringSig = "LStr"
+const LargeBinarySig = "LBin"
 const Timestamp = "Tns" // Timestamp in nanoseconds.
 const Duration = "Dur"  // Duration in nanoseconds.
 const DictionarySig = "Dic"
 const DenseUnionSig = "DU"
 const SparseUnionSig = "SU"
 const MapSig = "Map"
+const RunEndEncodedSig = "REE"
 
 // SortableField is a wrapper around arrow.Field that implements sort.Interface.
 type SortableField struct {
@@ -126,6 +129,10 @@ func DataTypeToID(dt arrow.DataType) string {
 		id += StringSig
 	case *arrow.BinaryType:
 		id += BinarySig
+	case *arrow.LargeStringType:
+		id += LargeStringSig
+	case *arrow.LargeBinaryType:
+		id += LargeBinarySig
 	case *arrow.TimestampType:
 		id += Timestamp
 	case *arrow.DurationType:
@@ -186,6 +193,12 @@ func DataTypeToID(dt arrow.DataType) string {
 		id += ">"
 	case *arrow.FixedSizeBinaryType:
 		id += fmt.Sprintf("%s<%d>", FixedSizeBinarySig, t.ByteWidth)
+	case *arrow.RunEndEncodedType:
+		id += RunEndEncodedSig + "<"
+		id += DataTypeToID(t.RunEnds())
+		id += ","
+		id += DataTypeToID(t.Encoded())
+		id += ">"
 	default:
 		panic("unsupported data type " + dt.String())
 	}
@@ -235,6 +248,10 @@ func ShowDataType(dt arrow.DataType, prefix string) {
 		fmt.Printf("String")
 	case *arrow.BinaryType:
 		fmt.Printf("Binary")
+	case *arrow.LargeStringType:
+		fmt.Printf("LargeString")
+	case *arrow.LargeBinaryType:
+		fmt.Printf("LargeBinary")
 	case *arrow.TimestampType:
 		fmt.Printf("Timestamp")
 	case *arrow.DurationType:
@@ -276,6 +293,10 @@ func ShowDataType(dt arrow.DataType, prefix string) {
 		fmt.Printf(">")
 	case *arrow.FixedSizeBinaryType:
 		fmt.Printf("FixedSizeBinary<%d>", t.ByteWidth)
+	case *arrow.RunEndEncodedType:
+		fmt.Printf("RunEndEncoded<")
+		ShowDataType(t.Encoded(), prefix)
+		fmt.Printf(">")
 	default:
 		panic("unsupported data type " + dt.String())
 	}
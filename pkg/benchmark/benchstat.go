@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/f5/otel-arrow-adapter/pkg/benchmark/stats"
+)
+
+// Units used in the benchstat-compatible output.
+const (
+	NsPerOpUnit                = "ns/op"
+	AllocsPerOpUnit            = "allocs/op"
+	UncompressedBytesPerOpUnit = "uncompressed-B/op"
+	CompressedBytesPerOpUnit   = "compressed-B/op"
+)
+
+// ExportBenchstat exports the results in the Go benchmark format so they can
+// be compared with `benchstat` (e.g. `benchstat old.txt new.txt`) or gated
+// with CheckRegressions.
+//
+// One benchmark is emitted per profiled system and batch size, with one line
+// per measured batch (warm-up batches excluded). An operation is the
+// end-to-end processing of a batch (encoding and decoding).
+func (p *Profiler) ExportBenchstat(signal string, filePrefix string) {
+	filename := fmt.Sprintf("%s/%s.bench.txt", p.outputDir, filePrefix)
+	file, err := os.OpenFile(filepath.Clean(filename), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Fatalf("failed creating file: %s", err)
+	}
+
+	dataWriter := bufio.NewWriter(file)
+	_, _ = fmt.Fprintf(dataWriter, "goos: %s\ngoarch: %s\npkg: %s\n", runtime.GOOS, runtime.GOARCH, signal)
+
+	for batchIdx, batchSize := range p.batchSizes {
+		for _, result := range p.benchmarks {
+			summary := result.Summaries[batchIdx]
+			total := stats.AddSummaries(
+				summary.OtlpArrowConversionSec,
+				summary.SerializationSec,
+				summary.CompressionSec,
+				summary.DecompressionSec,
+				summary.DeserializationSec,
+				summary.OtlpConversionSec,
+			)
+			if len(total.Values) == 0 {
+				continue
+			}
+
+			// Mallocs are measured for all the batches of a batch size
+			// (warm-up included).
+			allocsPerOp := float64(summary.CpuMemUsage.Malloc) / float64(len(summary.TotalTimeSec.Values))
+			name := BenchstatName(signal, result.BenchName, result.Tags, batchSize)
+
+			for i, value := range total.Values {
+				_, err = fmt.Fprintf(dataWriter, "%s\t1\t%.0f %s\t%.0f %s\t%.0f %s\t%.0f %s\n",
+					name,
+					value*1e9, NsPerOpUnit,
+					allocsPerOp, AllocsPerOpUnit,
+					summary.UncompressedSizeByte.Values[i], UncompressedBytesPerOpUnit,
+					summary.CompressedSizeByte.Values[i], CompressedBytesPerOpUnit,
+				)
+				if err != nil {
+					panic(fmt.Sprintf("failed writing to file: %s", err))
+				}
+			}
+		}
+	}
+
+	err = dataWriter.Flush()
+	if err != nil {
+		panic(fmt.Sprintf("failed flushing the file: %s", err))
+	}
+
+	err = file.Close()
+	if err != nil {
+		panic(fmt.Sprintf("failed closing the file: %s", err))
+	}
+
+	_, _ = fmt.Fprintf(p.writer, "Benchstat results exported to %s\n", filename)
+}
+
+// BenchstatName returns the benchmark name used in the benchstat output for
+// the given signal, profiled system, tags and batch size, e.g.
+// `BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=1024-8`.
+func BenchstatName(signal, benchName, tags string, batchSize int) string {
+	sanitize := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == ' ' || r == '\t' || r == '/' {
+				return '_'
+			}
+			return r
+		}, s)
+	}
+
+	name := fmt.Sprintf("Benchmark%s/%s", sanitize(signal), sanitize(benchName))
+	if tags != "" {
+		name += "/" + sanitize(tags)
+	}
+	return fmt.Sprintf("%s/batch_size=%d-%d", name, batchSize, runtime.GOMAXPROCS(0))
+}
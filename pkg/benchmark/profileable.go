@@ -43,6 +43,15 @@ type ProfileableSystem interface {
 	ShowStats()
 }
 
+// PayloadSizer is optionally implemented by the profileable systems encoding
+// a batch in multiple payloads (e.g. OTel Arrow). PayloadSizes returns the
+// size in bytes of each payload type of the current batch, before
+// compression. It is used for the per-payload breakdown of the reports (see
+// Profiler.ExportReport).
+type PayloadSizer interface {
+	PayloadSizes() map[string]int
+}
+
 func ProfileableSystemID(ps ProfileableSystem) string {
 	return fmt.Sprintf("%s:%s", ps.Name(), strings.Join(ps.Tags()[:], "+"))
 }
@@ -200,3 +200,7 @@ func (s *LogsProfileable) ShowStats() {
 		s.producer.ShowStats()
 	}
 }
+
+func (s *LogsProfileable) PayloadSizes() map[string]int {
+	return payloadSizes(s.batchArrowRecords)
+}
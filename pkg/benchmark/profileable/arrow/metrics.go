@@ -194,3 +194,7 @@ func (s *MetricsProfileable) Clear() {
 }
 func (s *MetricsProfileable) ShowStats() {
 }
+
+func (s *MetricsProfileable) PayloadSizes() map[string]int {
+	return payloadSizes(s.batchArrowRecords)
+}
@@ -0,0 +1,38 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/benchmark"
+)
+
+var (
+	_ benchmark.PayloadSizer = (*TracesProfileable)(nil)
+	_ benchmark.PayloadSizer = (*LogsProfileable)(nil)
+	_ benchmark.PayloadSizer = (*MetricsProfileable)(nil)
+)
+
+// payloadSizes returns the size in bytes of each payload type of the given
+// batches (see benchmark.PayloadSizer).
+func payloadSizes(batches []*v1.BatchArrowRecords) map[string]int {
+	sizes := make(map[string]int)
+	for _, batch := range batches {
+		for _, payload := range batch.ArrowPayloads {
+			sizes[payload.Type.String()] += len(payload.Record)
+		}
+	}
+	return sizes
+}
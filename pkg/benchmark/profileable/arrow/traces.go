@@ -200,3 +200,7 @@ func (s *TracesProfileable) ShowStats() {
 		s.producer.ShowStats()
 	}
 }
+
+func (s *TracesProfileable) PayloadSizes() map[string]int {
+	return payloadSizes(s.batchArrowRecords)
+}
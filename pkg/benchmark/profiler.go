@@ -141,6 +141,12 @@ func (p *Profiler) Profile(profileable ProfileableSystem, maxIter uint64) error
 		compression := stats.NewMetric()
 		decompression := stats.NewMetric()
 		totalTime := stats.NewMetric()
+		var payloadSizes map[string]*stats.Metric
+		payloadBatches := 0
+		payloadSizer, hasPayloadSizes := profileable.(PayloadSizer)
+		if hasPayloadSizes {
+			payloadSizes = make(map[string]*stats.Metric)
+		}
 		var processingResults []string
 
 		profileable.InitBatchSize(p.writer, batchSize)
@@ -178,6 +184,10 @@ func (p *Profiler) Profile(profileable ProfileableSystem, maxIter uint64) error
 				}
 				if batchNum >= p.warmUpIter {
 					uncompressedSize.Record(float64(uncompressedSizeBytes))
+					if hasPayloadSizes {
+						recordPayloadSizes(payloadSizes, payloadSizer.PayloadSizes(), payloadBatches)
+						payloadBatches++
+					}
 				}
 
 				// Compression
@@ -251,6 +261,13 @@ func (p *Profiler) Profile(profileable ProfileableSystem, maxIter uint64) error
 		}
 
 		profileable.ShowStats()
+		var payloadSizeSummaries map[string]*stats.Summary
+		if hasPayloadSizes {
+			payloadSizeSummaries = make(map[string]*stats.Summary, len(payloadSizes))
+			for payloadType, metric := range payloadSizes {
+				payloadSizeSummaries[payloadType] = metric.ComputeSummary()
+			}
+		}
 		currentBenchmark := p.benchmarks[len(p.benchmarks)-1]
 		currentBenchmark.Summaries = append(currentBenchmark.Summaries, stats.BatchSummary{
 			BatchSize:              batchSize,
@@ -266,6 +283,7 @@ func (p *Profiler) Profile(profileable ProfileableSystem, maxIter uint64) error
 			ProcessingResults:      processingResults,
 			CpuMemUsage:            probe.MeasureUsage(),
 			OtlpConversionSec:      otlpConversion.ComputeSummary(),
+			PayloadSizeByte:        payloadSizeSummaries,
 		})
 
 		profileable.EndProfiling(p.writer)
@@ -731,6 +749,25 @@ func (p *Profiler) ExportMetricsBytesCSV(filePrefix string) {
 	_, _ = fmt.Fprintf(p.writer, "Meseasurements of the message sizes exported to %s\n", filename)
 }
 
+// recordPayloadSizes records the payload sizes of a batch, prevBatches being
+// the number of batches already recorded. A payload type absent from a batch
+// is recorded with a size of 0 so the samples of all the payload types stay
+// aligned with the batches.
+func recordPayloadSizes(metrics map[string]*stats.Metric, sizes map[string]int, prevBatches int) {
+	for payloadType := range sizes {
+		if _, ok := metrics[payloadType]; !ok {
+			metric := stats.NewMetric()
+			for i := 0; i < prevBatches; i++ {
+				metric.Record(0)
+			}
+			metrics[payloadType] = metric
+		}
+	}
+	for payloadType, metric := range metrics {
+		metric.Record(float64(sizes[payloadType]))
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,166 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Thresholds defines the maximum relative increases (in percent) tolerated by
+// CheckRegressions. A negative threshold disables the corresponding check.
+type Thresholds struct {
+	MaxNsPerOpIncrease     float64
+	MaxAllocsPerOpIncrease float64
+}
+
+// DefaultThresholds returns the thresholds recommended to gate a change
+// locally (timings are noisier than allocation counts).
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		MaxNsPerOpIncrease:     10.0,
+		MaxAllocsPerOpIncrease: 5.0,
+	}
+}
+
+// Regression describes a benchmark metric exceeding its threshold.
+type Regression struct {
+	Benchmark string
+	Unit      string
+	Baseline  float64
+	Current   float64
+	// Relative increase in percent.
+	Increase float64
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: %s regressed by %.2f%% (%.2f -> %.2f)", r.Benchmark, r.Unit, r.Increase, r.Baseline, r.Current)
+}
+
+// BenchResults contains the values of each benchmark per unit.
+type BenchResults map[string]map[string][]float64
+
+// ParseBenchstat parses results in the Go benchmark format (as produced by
+// `go test -bench` or Profiler.ExportBenchstat). Non-benchmark lines are
+// ignored.
+func ParseBenchstat(r io.Reader) (BenchResults, error) {
+	results := make(BenchResults)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.ParseInt(fields[1], 10, 64); err != nil {
+			// Not a result line (e.g. a benchmark logging something).
+			continue
+		}
+		if len(fields)%2 != 0 {
+			return nil, fmt.Errorf("line %d: invalid benchmark result %q", lineNo, scanner.Text())
+		}
+
+		units, ok := results[fields[0]]
+		if !ok {
+			units = make(map[string][]float64)
+			results[fields[0]] = units
+		}
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value %q: %w", lineNo, fields[i], err)
+			}
+			units[fields[i+1]] = append(units[fields[i+1]], value)
+		}
+	}
+
+	return results, scanner.Err()
+}
+
+// CheckRegressions compares the current results with the baseline results and
+// returns the metrics (ns/op, allocs/op) that regressed beyond the given
+// thresholds. The median of the samples is compared, benchmarks only present
+// in one of the result sets are ignored.
+func CheckRegressions(baseline, current io.Reader, thresholds Thresholds) ([]Regression, error) {
+	baseResults, err := ParseBenchstat(baseline)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	currentResults, err := ParseBenchstat(current)
+	if err != nil {
+		return nil, fmt.Errorf("current: %w", err)
+	}
+
+	names := make([]string, 0, len(currentResults))
+	for name := range currentResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var regressions []Regression
+	for _, name := range names {
+		baseUnits, ok := baseResults[name]
+		if !ok {
+			continue
+		}
+		for _, check := range []struct {
+			unit      string
+			threshold float64
+		}{
+			{NsPerOpUnit, thresholds.MaxNsPerOpIncrease},
+			{AllocsPerOpUnit, thresholds.MaxAllocsPerOpIncrease},
+		} {
+			if check.threshold < 0 {
+				continue
+			}
+			baseValues, currentValues := baseUnits[check.unit], currentResults[name][check.unit]
+			if len(baseValues) == 0 || len(currentValues) == 0 {
+				continue
+			}
+			base, cur := median(baseValues), median(currentValues)
+			if base == 0 {
+				continue
+			}
+			increase := (cur - base) / base * 100.0
+			if increase > check.threshold {
+				regressions = append(regressions, Regression{
+					Benchmark: name,
+					Unit:      check.unit,
+					Baseline:  base,
+					Current:   cur,
+					Increase:  increase,
+				})
+			}
+		}
+	}
+
+	return regressions, nil
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
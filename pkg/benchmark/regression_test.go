@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const baselineResults = `goos: linux
+goarch: amd64
+pkg: Traces
+BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=128-8	1	1000000 ns/op	200 allocs/op	5000 uncompressed-B/op	1000 compressed-B/op
+BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=128-8	1	1100000 ns/op	200 allocs/op	5000 uncompressed-B/op	1000 compressed-B/op
+BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=1024-8	1	5000000 ns/op	900 allocs/op	40000 uncompressed-B/op	8000 compressed-B/op
+PASS
+`
+
+func TestParseBenchstat(t *testing.T) {
+	t.Parallel()
+
+	results, err := ParseBenchstat(strings.NewReader(baselineResults))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, []float64{1000000, 1100000}, results["BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=128-8"][NsPerOpUnit])
+	require.Equal(t, []float64{8000}, results["BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=1024-8"][CompressedBytesPerOpUnit])
+}
+
+func TestCheckRegressions(t *testing.T) {
+	t.Parallel()
+
+	current := `BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=128-8	1	1080000 ns/op	250 allocs/op	5000 uncompressed-B/op	1000 compressed-B/op
+BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=1024-8	1	6000000 ns/op	900 allocs/op	40000 uncompressed-B/op	8000 compressed-B/op
+BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=4096-8	1	9000000 ns/op	900 allocs/op	40000 uncompressed-B/op	8000 compressed-B/op
+`
+
+	regressions, err := CheckRegressions(strings.NewReader(baselineResults), strings.NewReader(current), DefaultThresholds())
+	require.NoError(t, err)
+	require.Len(t, regressions, 2)
+
+	// The time of batch_size=128 is within the threshold (median 1050000 -> 1080000).
+	require.Equal(t, "BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=1024-8", regressions[0].Benchmark)
+	require.Equal(t, NsPerOpUnit, regressions[0].Unit)
+	require.InDelta(t, 20.0, regressions[0].Increase, 1e-9)
+	require.Equal(t, "BenchmarkTraces/OTel_ARROW/stream_mode/batch_size=128-8", regressions[1].Benchmark)
+	require.Equal(t, AllocsPerOpUnit, regressions[1].Unit)
+
+	// Disabled checks.
+	regressions, err = CheckRegressions(strings.NewReader(baselineResults), strings.NewReader(current), Thresholds{MaxNsPerOpIncrease: -1, MaxAllocsPerOpIncrease: -1})
+	require.NoError(t, err)
+	require.Empty(t, regressions)
+}
@@ -0,0 +1,461 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+// Benchmark reports.
+//
+// A report summarizes the results of a profiler run in a markdown and an
+// HTML document: the message sizes and compression ratios, the time spent in
+// each step, the memory usage, and the per-payload breakdown of the systems
+// encoding a batch in multiple payloads (see PayloadSizer). The first
+// profiled system is the baseline of the comparisons. The plots are SVG bar
+// charts, written next to the markdown report and inlined in the HTML one.
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/f5/otel-arrow-adapter/pkg/benchmark/stats"
+)
+
+type (
+	reportSection struct {
+		title       string
+		description string
+		tables      []reportTable
+		plots       []reportPlot
+	}
+
+	reportTable struct {
+		title   string
+		headers []string
+		rows    [][]string
+	}
+
+	// reportPlot is a bar chart with a group of bars per batch size and a
+	// bar per profiled system.
+	reportPlot struct {
+		id     string
+		title  string
+		unit   string
+		groups []string
+		series []plotSeries
+	}
+
+	plotSeries struct {
+		name   string
+		values []float64
+	}
+)
+
+// Colors of the plot series.
+var plotColors = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7"}
+
+// ExportReport exports a report of the results to `<filePrefix>_report.md`
+// and `<filePrefix>_report.html` in the output directory of the profiler.
+// The plots of the markdown report are written to
+// `<filePrefix>_<plot>.svg` files. The dataset describes the profiled
+// dataset in the header of the report (e.g. its file name and size).
+func (p *Profiler) ExportReport(signal, dataset, filePrefix string, maxIter uint64) {
+	sections := p.reportSections()
+	header := []string{
+		fmt.Sprintf("Signal: %s", signal),
+		fmt.Sprintf("Dataset: %s", dataset),
+		fmt.Sprintf("Batch sizes: %s", strings.Trim(fmt.Sprint(p.batchSizes), "[]")),
+		fmt.Sprintf("Iterations: %d (%d warm-up batches excluded)", maxIter, p.warmUpIter),
+		fmt.Sprintf("Platform: %s/%s, %d CPUs, %s", runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.Version()),
+		fmt.Sprintf("Generated at: %s", time.Now().Format(time.RFC3339)),
+	}
+	title := fmt.Sprintf("%s benchmark report", signal)
+
+	for _, section := range sections {
+		for _, plot := range section.plots {
+			filename := fmt.Sprintf("%s/%s_%s.svg", p.outputDir, filePrefix, plot.id)
+			p.writeReportFile(filename, func(w io.Writer) error { return plot.writeSVG(w) })
+		}
+	}
+
+	mdFilename := fmt.Sprintf("%s/%s_report.md", p.outputDir, filePrefix)
+	p.writeReportFile(mdFilename, func(w io.Writer) error {
+		return writeMarkdownReport(w, title, header, sections, filePrefix)
+	})
+	htmlFilename := fmt.Sprintf("%s/%s_report.html", p.outputDir, filePrefix)
+	p.writeReportFile(htmlFilename, func(w io.Writer) error {
+		return writeHTMLReport(w, title, header, sections)
+	})
+
+	_, _ = fmt.Fprintf(p.writer, "Benchmark report exported to %s and %s\n", mdFilename, htmlFilename)
+}
+
+func (p *Profiler) writeReportFile(filename string, write func(io.Writer) error) {
+	file, err := os.OpenFile(filepath.Clean(filename), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		panic(fmt.Sprintf("failed creating file: %s", err))
+	}
+	if err = write(file); err != nil {
+		panic(fmt.Sprintf("failed writing to file: %s", err))
+	}
+	if err = file.Close(); err != nil {
+		panic(fmt.Sprintf("failed closing the file: %s", err))
+	}
+}
+
+// reportSections computes the sections of the report.
+func (p *Profiler) reportSections() []reportSection {
+	if len(p.benchmarks) == 0 {
+		return nil
+	}
+
+	groups := make([]string, len(p.batchSizes))
+	for i, batchSize := range p.batchSizes {
+		groups[i] = fmt.Sprintf("batch size %d", batchSize)
+	}
+	baseline := p.benchmarks[0]
+
+	sizes := reportSection{
+		title:       "Message sizes",
+		description: fmt.Sprintf("Mean size of the messages per batch, the ratios are relative to %s.", systemName(baseline)),
+	}
+	timings := reportSection{
+		title:       "CPU",
+		description: "Mean time spent per batch in each step, in milliseconds.",
+	}
+	memory := reportSection{
+		title:       "Memory",
+		description: "Memory usage per batch size, the warm-up batches included.",
+	}
+	payloads := reportSection{
+		title:       "Per-payload breakdown",
+		description: "Mean uncompressed size of each payload type per batch.",
+	}
+
+	sizesTable := reportTable{headers: []string{"System", "Batch size", "Uncompressed", "Compressed", "Compression ratio", "Compressed size vs baseline"}}
+	timingsTable := reportTable{headers: []string{"System", "Batch size", "OTLP -> OTel Arrow", "Serialization", "Compression", "Decompression", "Deserialization", "OTel Arrow -> OTLP", "Total", "Speedup vs baseline"}}
+	memoryTable := reportTable{headers: []string{"System", "Batch size", "Mallocs/batch", "Alloc bandwidth", "GC count", "Heap"}}
+
+	sizesPlot := reportPlot{id: "compressed_size", title: "Mean compressed size per batch", unit: "bytes", groups: groups}
+	timingsPlot := reportPlot{id: "total_time", title: "Mean total time per batch", unit: "ms", groups: groups}
+	memoryPlot := reportPlot{id: "mallocs", title: "Mallocs per batch", unit: "mallocs", groups: groups}
+
+	for _, result := range p.benchmarks {
+		name := systemName(result)
+		compressedSeries := plotSeries{name: name, values: make([]float64, len(p.batchSizes))}
+		timeSeries := plotSeries{name: name, values: make([]float64, len(p.batchSizes))}
+		mallocSeries := plotSeries{name: name, values: make([]float64, len(p.batchSizes))}
+
+		for batchIdx, batchSize := range p.batchSizes {
+			summary := result.Summaries[batchIdx]
+			baseSummary := baseline.Summaries[batchIdx]
+			batch := fmt.Sprintf("%d", batchSize)
+
+			uncompressed := mean(summary.UncompressedSizeByte)
+			compressed := mean(summary.CompressedSizeByte)
+			sizesTable.rows = append(sizesTable.rows, []string{
+				name, batch,
+				humanize.Bytes(uint64(uncompressed)),
+				humanize.Bytes(uint64(compressed)),
+				ratio(uncompressed, compressed),
+				ratio(compressed, mean(baseSummary.CompressedSizeByte)),
+			})
+			compressedSeries.values[batchIdx] = compressed
+
+			total := totalTimeMs(summary)
+			timingsTable.rows = append(timingsTable.rows, []string{
+				name, batch,
+				millis(summary.OtlpArrowConversionSec),
+				millis(summary.SerializationSec),
+				millis(summary.CompressionSec),
+				millis(summary.DecompressionSec),
+				millis(summary.DeserializationSec),
+				millis(summary.OtlpConversionSec),
+				fmt.Sprintf("%.3f", total),
+				ratio(totalTimeMs(baseSummary), total),
+			})
+			timeSeries.values[batchIdx] = total
+
+			if usage := summary.CpuMemUsage; usage != nil {
+				mallocs := 0.0
+				if summary.TotalTimeSec != nil && len(summary.TotalTimeSec.Values) > 0 {
+					mallocs = float64(usage.Malloc) / float64(len(summary.TotalTimeSec.Values))
+				}
+				memoryTable.rows = append(memoryTable.rows, []string{
+					name, batch,
+					fmt.Sprintf("%.0f", mallocs),
+					humanize.Bytes(uint64(usage.Bandwidth)) + "/s",
+					fmt.Sprintf("%d", usage.GcCount),
+					humanize.Bytes(usage.Heap),
+				})
+				mallocSeries.values[batchIdx] = mallocs
+			}
+
+			if summary.PayloadSizeByte != nil {
+				payloads.tables = append(payloads.tables, payloadTable(name, batchSize, summary.PayloadSizeByte))
+			}
+		}
+
+		sizesPlot.series = append(sizesPlot.series, compressedSeries)
+		timingsPlot.series = append(timingsPlot.series, timeSeries)
+		memoryPlot.series = append(memoryPlot.series, mallocSeries)
+	}
+
+	sizes.tables = []reportTable{sizesTable}
+	sizes.plots = []reportPlot{sizesPlot}
+	timings.tables = []reportTable{timingsTable}
+	timings.plots = []reportPlot{timingsPlot}
+	memory.tables = []reportTable{memoryTable}
+	memory.plots = []reportPlot{memoryPlot}
+
+	sections := []reportSection{sizes, timings, memory}
+	if len(payloads.tables) > 0 {
+		sections = append(sections, payloads)
+	}
+	return sections
+}
+
+// payloadTable returns the breakdown of the payload sizes of a system for a
+// batch size, by decreasing size.
+func payloadTable(name string, batchSize int, sizes map[string]*stats.Summary) reportTable {
+	payloadTypes := make([]string, 0, len(sizes))
+	total := 0.0
+	for payloadType, summary := range sizes {
+		payloadTypes = append(payloadTypes, payloadType)
+		total += summary.Mean
+	}
+	sort.Slice(payloadTypes, func(i, j int) bool {
+		mi, mj := sizes[payloadTypes[i]].Mean, sizes[payloadTypes[j]].Mean
+		if mi != mj {
+			return mi > mj
+		}
+		return payloadTypes[i] < payloadTypes[j]
+	})
+
+	table := reportTable{
+		title:   fmt.Sprintf("%s, batch size %d", name, batchSize),
+		headers: []string{"Payload type", "Uncompressed", "Share"},
+	}
+	for _, payloadType := range payloadTypes {
+		size := sizes[payloadType].Mean
+		share := 0.0
+		if total > 0 {
+			share = 100 * size / total
+		}
+		table.rows = append(table.rows, []string{payloadType, humanize.Bytes(uint64(size)), fmt.Sprintf("%.1f%%", share)})
+	}
+	return table
+}
+
+func systemName(result *stats.ProfilerResult) string {
+	if result.Tags == "" {
+		return result.BenchName
+	}
+	return fmt.Sprintf("%s [%s]", result.BenchName, result.Tags)
+}
+
+func mean(summary *stats.Summary) float64 {
+	if summary == nil {
+		return 0
+	}
+	return summary.Mean
+}
+
+func millis(summary *stats.Summary) string {
+	return fmt.Sprintf("%.3f", mean(summary)*1000)
+}
+
+func totalTimeMs(summary stats.BatchSummary) float64 {
+	return (mean(summary.OtlpArrowConversionSec) +
+		mean(summary.SerializationSec) +
+		mean(summary.CompressionSec) +
+		mean(summary.DecompressionSec) +
+		mean(summary.DeserializationSec) +
+		mean(summary.OtlpConversionSec)) * 1000
+}
+
+// ratio returns a/b formatted as a factor, or "n/a" if b is 0.
+func ratio(a, b float64) string {
+	if b == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%.2fx", a/b)
+}
+
+func writeMarkdownReport(w io.Writer, title string, header []string, sections []reportSection, filePrefix string) error {
+	var sb strings.Builder
+
+	_, _ = fmt.Fprintf(&sb, "# %s\n\n", title)
+	for _, line := range header {
+		_, _ = fmt.Fprintf(&sb, "- %s\n", line)
+	}
+
+	for _, section := range sections {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n%s\n", section.title, section.description)
+		for _, plot := range section.plots {
+			_, _ = fmt.Fprintf(&sb, "\n![%s](%s_%s.svg)\n", plot.title, filePrefix, plot.id)
+		}
+		for _, table := range section.tables {
+			if table.title != "" {
+				_, _ = fmt.Fprintf(&sb, "\n### %s\n", table.title)
+			}
+			_, _ = fmt.Fprintf(&sb, "\n| %s |\n", strings.Join(table.headers, " | "))
+			_, _ = fmt.Fprintf(&sb, "|%s\n", strings.Repeat(" --- |", len(table.headers)))
+			for _, row := range table.rows {
+				_, _ = fmt.Fprintf(&sb, "| %s |\n", strings.Join(row, " | "))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeHTMLReport(w io.Writer, title string, header []string, sections []reportSection) error {
+	var sb strings.Builder
+
+	_, _ = fmt.Fprintf(&sb, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	sb.WriteString("<style>\n" +
+		"body { font-family: sans-serif; margin: 2em; }\n" +
+		"table { border-collapse: collapse; margin: 1em 0; }\n" +
+		"th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }\n" +
+		"th:first-child, td:first-child { text-align: left; }\n" +
+		"th { background: #f0f0f0; }\n" +
+		"</style>\n</head>\n<body>\n")
+	_, _ = fmt.Fprintf(&sb, "<h1>%s</h1>\n<ul>\n", html.EscapeString(title))
+	for _, line := range header {
+		_, _ = fmt.Fprintf(&sb, "<li>%s</li>\n", html.EscapeString(line))
+	}
+	sb.WriteString("</ul>\n")
+
+	for _, section := range sections {
+		_, _ = fmt.Fprintf(&sb, "<h2>%s</h2>\n<p>%s</p>\n", html.EscapeString(section.title), html.EscapeString(section.description))
+		for _, plot := range section.plots {
+			if err := plot.writeSVG(&sb); err != nil {
+				return err
+			}
+		}
+		for _, table := range section.tables {
+			if table.title != "" {
+				_, _ = fmt.Fprintf(&sb, "<h3>%s</h3>\n", html.EscapeString(table.title))
+			}
+			sb.WriteString("<table>\n<tr>")
+			for _, h := range table.headers {
+				_, _ = fmt.Fprintf(&sb, "<th>%s</th>", html.EscapeString(h))
+			}
+			sb.WriteString("</tr>\n")
+			for _, row := range table.rows {
+				sb.WriteString("<tr>")
+				for _, cell := range row {
+					_, _ = fmt.Fprintf(&sb, "<td>%s</td>", html.EscapeString(cell))
+				}
+				sb.WriteString("</tr>\n")
+			}
+			sb.WriteString("</table>\n")
+		}
+	}
+	sb.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeSVG renders the plot as an SVG grouped bar chart.
+func (p *reportPlot) writeSVG(w io.Writer) error {
+	const (
+		width        = 760.0
+		height       = 360.0
+		marginLeft   = 80.0
+		marginRight  = 20.0
+		marginTop    = 40.0
+		marginBottom = 80.0
+		gridLines    = 5
+	)
+	plotWidth := width - marginLeft - marginRight
+	plotHeight := height - marginTop - marginBottom
+
+	maxValue := 0.0
+	for _, series := range p.series {
+		for _, value := range series.values {
+			maxValue = math.Max(maxValue, value)
+		}
+	}
+	if maxValue == 0 {
+		maxValue = 1
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" font-family=\"sans-serif\" font-size=\"11\">\n", width, height)
+	_, _ = fmt.Fprintf(&sb, "<text x=\"%.0f\" y=\"20\" text-anchor=\"middle\" font-size=\"14\">%s (%s)</text>\n", width/2, html.EscapeString(p.title), html.EscapeString(p.unit))
+
+	// Y axis and grid.
+	for i := 0; i <= gridLines; i++ {
+		value := maxValue * float64(i) / gridLines
+		y := marginTop + plotHeight - plotHeight*float64(i)/gridLines
+		_, _ = fmt.Fprintf(&sb, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"#ddd\"/>\n", marginLeft, y, width-marginRight, y)
+		_, _ = fmt.Fprintf(&sb, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"end\">%s</text>\n", marginLeft-6, y+4, formatTick(value))
+	}
+
+	// Bars, one group per batch size.
+	if len(p.groups) > 0 && len(p.series) > 0 {
+		groupWidth := plotWidth / float64(len(p.groups))
+		barWidth := groupWidth * 0.8 / float64(len(p.series))
+		for g, group := range p.groups {
+			x0 := marginLeft + groupWidth*float64(g) + groupWidth*0.1
+			for s, series := range p.series {
+				value := 0.0
+				if g < len(series.values) {
+					value = series.values[g]
+				}
+				barHeight := plotHeight * value / maxValue
+				_, _ = fmt.Fprintf(&sb, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\"><title>%s: %s</title></rect>\n",
+					x0+barWidth*float64(s), marginTop+plotHeight-barHeight, barWidth, barHeight,
+					plotColors[s%len(plotColors)], html.EscapeString(series.name), formatTick(value))
+			}
+			_, _ = fmt.Fprintf(&sb, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\">%s</text>\n", x0+groupWidth*0.4, marginTop+plotHeight+16, html.EscapeString(group))
+		}
+	}
+
+	// Legend.
+	for s, series := range p.series {
+		y := height - marginBottom + 36 + float64(s/2)*16
+		x := marginLeft + float64(s%2)*plotWidth/2
+		_, _ = fmt.Fprintf(&sb, "<rect x=\"%.1f\" y=\"%.1f\" width=\"10\" height=\"10\" fill=\"%s\"/>\n", x, y-9, plotColors[s%len(plotColors)])
+		_, _ = fmt.Fprintf(&sb, "<text x=\"%.1f\" y=\"%.1f\">%s</text>\n", x+14, y, html.EscapeString(series.name))
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatTick formats a value of a plot axis or bar.
+func formatTick(value float64) string {
+	if value >= 1000 {
+		v, prefix := humanize.ComputeSI(value)
+		return fmt.Sprintf("%.1f%s", v, prefix)
+	}
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.3f", value)
+}
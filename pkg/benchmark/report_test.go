@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmark
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/f5/otel-arrow-adapter/pkg/benchmark/stats"
+)
+
+func newTestSummary(values ...float64) *stats.Summary {
+	metric := stats.NewMetric()
+	for _, value := range values {
+		metric.Record(value)
+	}
+	return metric.ComputeSummary()
+}
+
+func newTestBatchSummary(batchSize int, compressed float64, payloadSizes map[string]*stats.Summary) stats.BatchSummary {
+	return stats.BatchSummary{
+		BatchSize:              batchSize,
+		UncompressedSizeByte:   newTestSummary(4*compressed, 4*compressed),
+		CompressedSizeByte:     newTestSummary(compressed, compressed),
+		OtlpArrowConversionSec: newTestSummary(0.001, 0.001),
+		ProcessingSec:          newTestSummary(0, 0),
+		SerializationSec:       newTestSummary(0.002, 0.002),
+		DeserializationSec:     newTestSummary(0.002, 0.002),
+		CompressionSec:         newTestSummary(0.001, 0.001),
+		DecompressionSec:       newTestSummary(0.001, 0.001),
+		TotalTimeSec:           newTestSummary(0.008, 0.008),
+		CpuMemUsage:            &stats.CpuMemUsage{Heap: 1 << 20, Malloc: 1000, Bandwidth: 1 << 30, GcCount: 2},
+		OtlpConversionSec:      newTestSummary(0.001, 0.001),
+		PayloadSizeByte:        payloadSizes,
+	}
+}
+
+func TestExportReport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := &Profiler{
+		batchSizes: []int{128, 1024},
+		writer:     &bytes.Buffer{},
+		outputDir:  dir,
+	}
+	p.benchmarks = []*stats.ProfilerResult{
+		{
+			BenchName: "OTLP",
+			Tags:      "zstd",
+			Summaries: []stats.BatchSummary{
+				newTestBatchSummary(128, 1000, nil),
+				newTestBatchSummary(1024, 8000, nil),
+			},
+		},
+		{
+			BenchName: "OTel_ARROW",
+			Tags:      "zstd+stream mode",
+			Summaries: []stats.BatchSummary{
+				newTestBatchSummary(128, 500, map[string]*stats.Summary{
+					"SPANS":          newTestSummary(1500, 1500),
+					"RESOURCE_ATTRS": newTestSummary(500, 500),
+				}),
+				newTestBatchSummary(1024, 2000, map[string]*stats.Summary{
+					"SPANS":          newTestSummary(6000, 6000),
+					"RESOURCE_ATTRS": newTestSummary(2000, 2000),
+				}),
+			},
+		},
+	}
+
+	p.ExportReport("Traces", "traces.pb (1 MB)", "0_traces", 1)
+
+	md, err := os.ReadFile(filepath.Join(dir, "0_traces_report.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(md), "# Traces benchmark report")
+	require.Contains(t, string(md), "- Dataset: traces.pb (1 MB)")
+	require.Contains(t, string(md), "![Mean compressed size per batch](0_traces_compressed_size.svg)")
+	// The compressed size of OTel Arrow is half, then a quarter, of the
+	// baseline.
+	require.Contains(t, string(md), "| OTel_ARROW [zstd+stream mode] | 128 | 2.0 kB | 500 B | 4.00x | 0.50x |")
+	require.Contains(t, string(md), "| OTel_ARROW [zstd+stream mode] | 1024 | 8.0 kB | 2.0 kB | 4.00x | 0.25x |")
+	// The payloads are sorted by decreasing size.
+	require.Contains(t, string(md), "### OTel_ARROW [zstd+stream mode], batch size 128\n\n| Payload type | Uncompressed | Share |\n| --- | --- | --- |\n| SPANS | 1.5 kB | 75.0% |\n| RESOURCE_ATTRS | 500 B | 25.0% |")
+
+	html, err := os.ReadFile(filepath.Join(dir, "0_traces_report.html"))
+	require.NoError(t, err)
+	require.Contains(t, string(html), "<h2>Per-payload breakdown</h2>")
+	require.Contains(t, string(html), "<svg")
+
+	for _, plot := range []string{"compressed_size", "total_time", "mallocs"} {
+		svg, err := os.ReadFile(filepath.Join(dir, "0_traces_"+plot+".svg"))
+		require.NoError(t, err)
+		require.Contains(t, string(svg), "OTel_ARROW [zstd+stream mode]")
+	}
+}
@@ -49,6 +49,9 @@ type BatchSummary struct {
 	ProcessingResults      []string
 	CpuMemUsage            *CpuMemUsage
 	OtlpConversionSec      *Summary
+	// Uncompressed size of each payload type, nil if the system doesn't
+	// report it.
+	PayloadSizeByte map[string]*Summary
 }
 
 type ProfilerResult struct {
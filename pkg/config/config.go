@@ -20,9 +20,16 @@ package config
 // Main configuration object in the package.
 
 import (
+	"io"
 	"math"
+	"time"
 
 	"github.com/apache/arrow/go/v12/arrow/memory"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
+	dictconfig "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
 )
 
 type Config struct {
@@ -33,20 +40,219 @@ type Config struct {
 	// LimitIndexSize sets the maximum size of a dictionary index
 	// before it is no longer encoded as a dictionary.
 	LimitIndexSize uint64
+	// DictionaryOverflow is the policy applied to the dictionaries
+	// overflowing LimitIndexSize, DictionaryOverflowOverrides overrides it
+	// per field (see WithDictionaryOverflow).
+	DictionaryOverflow          dictconfig.OverflowPolicy
+	DictionaryOverflowOverrides map[string]dictconfig.OverflowPolicy
 	// Zstd enables the use of ZSTD compression for IPC messages.
 	Zstd bool // Use IPC ZSTD compression
 	// Stats enables the collection of statistics about the data being encoded.
 	Stats bool
+	// SeriesID enables the emission of a stable per-series identifier for
+	// each data point (see WithSeriesID).
+	SeriesID bool
+	// OptionalColumnDeactivation is the number of consecutive batches in which
+	// an optional column must only contain nulls before being removed from the
+	// schema (0 = never, see WithOptionalColumnDeactivation).
+	OptionalColumnDeactivation int
+	// StreamRecording is the maximum number of bytes recorded per stream for
+	// the snapshots (0 = no recording, see WithStreamRecording).
+	StreamRecording int
+	// Encryption is the key provider used to encrypt the IPC payloads (nil =
+	// no encryption, see WithEncryption).
+	Encryption encryption.KeyProvider
+	// HashedAttributes is the set of attribute keys whose string values are
+	// replaced by their keyed hash (see WithAttributeHashing).
+	HashedAttributes []string
+	// AttributeHashKey is the HMAC key used to hash the attribute values.
+	AttributeHashKey []byte
+	// LogTemplates enables the extraction of templates from string log
+	// bodies (see WithLogTemplates).
+	LogTemplates bool
+	// BufferRecycling is the maximum number of bytes of freed Arrow buffers
+	// kept by the Producer for reuse (0 = no recycling, see
+	// WithBufferRecycling).
+	BufferRecycling int
+	// MmapAllocator backs the Arrow buffers of the Producer of at least
+	// MmapThreshold bytes with anonymous memory mappings (see
+	// WithMmapAllocator).
+	MmapAllocator bool
+	MmapThreshold int
+	// Strict makes the Producer return an error instead of silently dropping
+	// or degrading data (see WithStrict).
+	Strict bool
+	// CPUBudget is the maximum fraction of the wall-clock time the Producer
+	// may spend encoding and compressing batches before degrading its
+	// encoding (0 = no budget, see WithCPUBudget).
+	CPUBudget float64
+	// TraceIDIndex adds a payload indexing the rows of the spans and logs by
+	// trace ID to each batch (see WithTraceIDIndex).
+	TraceIDIndex bool
+	// EncodingOverrides forces the encoding of some dictionary fields,
+	// keyed by payload and field path (see WithEncodingOverrides).
+	EncodingOverrides map[string]string
+	// EmptyResourceScope is the encoding of the empty resources and scopes
+	// (see WithEmptyResourceScope).
+	EmptyResourceScope EmptyResourceScope
+	// NoSort disables the sorting of the spans, log records, metrics and
+	// attributes before their encoding (see WithNoSort).
+	NoSort bool
+	// MetricSorter, LogSorter and SpanSorter are the names of the
+	// registered sorters of the metrics, log records and spans ("" = the
+	// built-in default, see WithMetricSorter).
+	MetricSorter string
+	LogSorter    string
+	SpanSorter   string
+	// MetricSortKeys, LogSortKeys and SpanSortKeys are the sort keys of
+	// the metrics, log records and spans, they take precedence over the
+	// named sorters (see WithSpanSortKeys).
+	MetricSortKeys []string
+	LogSortKeys    []string
+	SpanSortKeys   []string
+	// Uint64IDs encodes the trace and span IDs of the spans as uint64
+	// columns (see WithExperimentalUint64IDs).
+	Uint64IDs bool
+	// MapFlatteningDepth is the maximum depth of the nested maps of the
+	// attributes flattened into dotted keys (0 = no flattening, see
+	// WithMapFlattening).
+	MapFlatteningDepth int
+	// MemoryLimit is the maximum number of bytes allocated by the Producer
+	// (0 = no limit, see WithMemoryLimit).
+	MemoryLimit uint64
+	// DownscaleExpHistograms enables the reduction of the scale of the
+	// exponential histogram data points to ExpHistogramMaxScale (see
+	// WithExpHistogramMaxScale).
+	DownscaleExpHistograms bool
+	ExpHistogramMaxScale   int32
+	// Analyzer configures the analyzers enabled by Stats (see
+	// WithAnalyzerConfig).
+	Analyzer AnalyzerConfig
+	// MeterProvider exports the producer stats as OpenTelemetry metrics (nil
+	// = no metrics, see WithMeterProvider).
+	MeterProvider metric.MeterProvider
+	// Retention is the retention of each signal stamped in the expiry
+	// column of the main records (see WithRetention).
+	Retention Retention
+	// LargeValues is the policy applied to the string and binary columns
+	// whose data exceeds LargeValueThreshold bytes in a single batch (0 =
+	// just below the 2GB offset limit, see WithLargeValues).
+	LargeValues         dictconfig.LargeValuesPolicy
+	LargeValueThreshold int
+	// MaxRecordBytes is the maximum serialized size of the BatchArrowRecords
+	// messages produced by the Split methods of the Producer (0 = no limit,
+	// see WithMaxRecordBytes).
+	MaxRecordBytes int
+	// ScopeTable encodes the distinct scopes of a batch in a related record
+	// referenced by ID from the main records (see WithScopeTable).
+	ScopeTable bool
+	// WholeResources keeps the rows of each resource contiguous and never
+	// splits a resource across BatchArrowRecords messages (see
+	// WithWholeResources).
+	WholeResources bool
 }
 
 type Option func(*Config)
 
+// AnalyzerConfig configures the sampling and the sinks of the logs, metrics
+// and traces analyzers. The analyzers track the distinct values with
+// HyperLogLog sketches and the distributions with HDR histograms, so their
+// memory is bounded whatever the cardinality of the analyzed data.
+//
+// The text reports are written to Logger, File or Output (in this order of
+// precedence). They are written to stdout only if no sink is configured, i.e.
+// if none of these fields nor MeterProvider is set.
+type AnalyzerConfig struct {
+	// Sampling is the number of batches per analyzed batch (0 or 1 = every
+	// batch is analyzed).
+	Sampling int
+	// Interval is the minimum duration between two reports of an analyzer
+	// (0 = after every analyzed batch).
+	Interval time.Duration
+	// Verbosity is the level of detail of the text reports.
+	Verbosity AnalyzerVerbosity
+	// Output is the writer receiving the text reports.
+	Output io.Writer
+	// Logger receives the text reports, one Info entry per line.
+	Logger *zap.Logger
+	// File is the path of the file the text reports are appended to.
+	File string
+	// MeterProvider exports the statistics of the analyzers as gauges.
+	MeterProvider metric.MeterProvider
+}
+
+// Retention is the retention of the spans, log records and metrics. A zero
+// retention doesn't add the expiry column to the records of the signal.
+type Retention struct {
+	Traces  time.Duration
+	Logs    time.Duration
+	Metrics time.Duration
+}
+
+// AnalyzerVerbosity defines the level of detail of the reports of the
+// analyzers.
+type AnalyzerVerbosity int
+
+const (
+	// AnalyzerDetailed reports the full statistics of the analyzed batches.
+	AnalyzerDetailed AnalyzerVerbosity = iota
+	// AnalyzerSummary only reports the number of analyzed batches.
+	AnalyzerSummary
+)
+
+// EmptyResourceScope defines how the Producer encodes the resources (resp.
+// scopes) without attributes, dropped attributes count or schema URL (resp.
+// name and version).
+type EmptyResourceScope int
+
+const (
+	// EmptyShared encodes all the empty resources (resp. scopes) of a batch as
+	// a single entry with a null attributes ID. The consumer regroups their
+	// records under a single resource (resp. scope).
+	EmptyShared EmptyResourceScope = iota
+	// EmptyPerRecord encodes the empty resource (resp. scope) of each
+	// resource (resp. scope) entry of a batch separately. The consumer
+	// reconstructs the same entries.
+	EmptyPerRecord
+)
+
 // DefaultConfig returns a Config with the following default values:
 //  - Pool: memory.NewGoAllocator()
 //  - InitIndexSize: math.MaxUint16
 //  - LimitIndexSize: math.MaxUint32
+//  - DictionaryOverflow: OverflowFallback
+//  - DictionaryOverflowOverrides: nil
 //  - Stats: false
 //  - Zstd: true
+//  - SeriesID: false
+//  - OptionalColumnDeactivation: 0
+//  - StreamRecording: 0
+//  - Encryption: nil
+//  - HashedAttributes: nil
+//  - LogTemplates: false
+//  - BufferRecycling: 0
+//  - MmapAllocator: false
+//  - MmapThreshold: 0
+//  - Strict: false
+//  - CPUBudget: 0
+//  - TraceIDIndex: false
+//  - EncodingOverrides: nil
+//  - EmptyResourceScope: EmptyShared
+//  - NoSort: false
+//  - MetricSorter, LogSorter, SpanSorter: "" (built-in defaults)
+//  - MetricSortKeys, LogSortKeys, SpanSortKeys: nil
+//  - Uint64IDs: false
+//  - MapFlatteningDepth: 0
+//  - MemoryLimit: 0
+//  - DownscaleExpHistograms: false
+//  - Analyzer: zero value (text reports on stdout after every batch)
+//  - MeterProvider: nil
+//  - Retention: zero value (no expiry column)
+//  - LargeValues: LargeValuesPromote
+//  - LargeValueThreshold: 0
+//  - MaxRecordBytes: 0
+//  - ScopeTable: false
+//  - WholeResources: false
 func DefaultConfig() *Config {
 	return &Config{
 		Pool:           memory.NewGoAllocator(),
@@ -128,6 +334,21 @@ func WithUint64LimitDictIndex() Option {
 	}
 }
 
+// WithDictionaryOverflow sets the policy applied to the dictionary fields
+// whose cardinality exceeds the dictionary index limit (see
+// WithUint16LimitDictIndex): falling back to the base type of the field (the
+// default), widening the index beyond the limit, starting a new dictionary,
+// or failing the batch. The overrides are keyed like the encoding overrides
+// (see WithEncodingOverrides), e.g. "spans.name". Widening avoids the schema
+// churn of the fallback at the cost of memory, resetting bounds the memory at
+// the cost of resending the dictionary entries.
+func WithDictionaryOverflow(policy dictconfig.OverflowPolicy, overrides map[string]dictconfig.OverflowPolicy) Option {
+	return func(cfg *Config) {
+		cfg.DictionaryOverflow = policy
+		cfg.DictionaryOverflowOverrides = overrides
+	}
+}
+
 // WithZstd sets the Producer to use Zstd compression at the Arrow IPC level.
 func WithZstd() Option {
 	return func(cfg *Config) {
@@ -143,8 +364,432 @@ func WithNoZstd() Option {
 }
 
 // WithStats enables the collection of statistics about the data being encoded.
+// The reports of the analyzers are written to stdout unless a sink is
+// configured (see AnalyzerConfig).
 func WithStats() Option {
 	return func(cfg *Config) {
 		cfg.Stats = true
 	}
 }
+
+// WithSeriesID enables the emission of a stable per-series identifier for each
+// data point (number, histogram, exponential histogram and summary). A series
+// is identified by its resource, scope, metric name and data point attributes.
+// The first time a series is seen by a Producer, it is assigned the next
+// available identifier (starting at 1) and this identifier is reused for all
+// subsequent data points of the same series.
+//
+// Identifiers are owned by the Producer and are not affected by schema updates
+// or dictionary resets (i.e. they survive the creation of a new IPC stream for a
+// new schema). The Producer tracks a bounded number of series, the series not
+// seen for a while are evicted and are assigned a new identifier if they
+// reappear. Identifiers are not part of the OTLP data model, a receiver obtains
+// them with Consumer.MetricsWithSeriesIDsFrom and must scope them to the
+// lifetime of the underlying Arrow stream.
+func WithSeriesID() Option {
+	return func(cfg *Config) {
+		cfg.SeriesID = true
+	}
+}
+
+// WithOptionalColumnDeactivation removes optional columns from the schema once
+// they have only contained null values for the given number of consecutive
+// batches. Optional columns are added to the schema the first time a value is
+// observed, this option reclaims the bandwidth used by columns associated with
+// transient fields. Every deactivation triggers a schema update, i.e. a new IPC
+// stream for the corresponding payload type, so this value should not be too
+// small.
+func WithOptionalColumnDeactivation(batches int) Option {
+	return func(cfg *Config) {
+		cfg.OptionalColumnDeactivation = batches
+	}
+}
+
+// WithStreamRecording keeps up to maxBytes of the payloads of each stream so
+// that a snapshot of the Producer (see Producer.WriteSnapshot) contains enough
+// information to rebuild the dictionaries and replay the stream. The recording
+// of a stream stops once the limit is reached.
+func WithStreamRecording(maxBytes int) Option {
+	return func(cfg *Config) {
+		cfg.StreamRecording = maxBytes
+	}
+}
+
+// WithEncryption encrypts the serialized IPC bytes of each ArrowPayload with
+// AES-GCM using the keys of the given provider. This is independent of TLS and
+// requires the consumer to be configured with the same keys (see
+// arrow_record.WithDecryption).
+func WithEncryption(kp encryption.KeyProvider) Option {
+	return func(cfg *Config) {
+		cfg.Encryption = kp
+	}
+}
+
+// WithAttributeHashing replaces the string values of the given attribute keys
+// by their hex-encoded HMAC-SHA256 computed with the given key. Hashed values
+// remain joinable (the same value always produces the same hash) but the raw
+// values are not transmitted. The consumer decodes them as regular strings.
+func WithAttributeHashing(key []byte, attributeKeys ...string) Option {
+	return func(cfg *Config) {
+		cfg.AttributeHashKey = key
+		cfg.HashedAttributes = attributeKeys
+	}
+}
+
+// WithLogTemplates enables the extraction of templates from string log bodies.
+// Similar bodies (e.g. produced by the same printf-like statement) are
+// clustered into a template where the variable parts are replaced by "<*>".
+// The template and the values of its variables are encoded in two separate
+// columns of the log body, which improves the compression of these logs and
+// exposes the templates to the backends. The consumer rebuilds the original
+// bodies.
+func WithLogTemplates() Option {
+	return func(cfg *Config) {
+		cfg.LogTemplates = true
+	}
+}
+
+// WithBufferRecycling keeps up to maxBytes of the buffers released by the Arrow
+// builders and records of a Producer to reuse them for the next batches
+// instead of allocating new ones. This reduces the steady-state allocations
+// per batch at the cost of the retained memory. The kept buffers are released
+// when the Producer is closed.
+func WithBufferRecycling(maxBytes int) Option {
+	return func(cfg *Config) {
+		cfg.BufferRecycling = maxBytes
+	}
+}
+
+// WithMmapAllocator backs the Arrow buffers of at least threshold bytes with
+// anonymous memory mappings instead of the Go heap. The mappings are returned
+// to the operating system when the buffers are released, which avoids the RSS
+// spikes and the GC pressure of the occasional very large batches. A threshold
+// of 0 selects the default, 1MiB. The Go heap is used on the platforms without
+// mmap support.
+func WithMmapAllocator(threshold int) Option {
+	return func(cfg *Config) {
+		cfg.MmapAllocator = true
+		cfg.MmapThreshold = threshold
+	}
+}
+
+// WithStrict makes the Producer return an error wrapping
+// otel.ErrLossyConversion whenever a batch contains data that the conversion
+// would drop or degrade (e.g. attributes with an empty key or without value,
+// metrics of unknown type, data points or exemplars without value), instead of
+// silently encoding a degraded version of the batch. This option is for users
+// prioritizing fidelity over availability.
+func WithStrict() Option {
+	return func(cfg *Config) {
+		cfg.Strict = true
+	}
+}
+
+// WithCPUBudget limits the fraction of the wall-clock time spent by the
+// Producer encoding and compressing batches (e.g. 0.1 for 10% of a core).
+// When the budget is exceeded over a measurement window, the Producer first
+// suspends its optional transforms (e.g. the log templates), then disables the
+// IPC compression. The encoding is restored step by step once the time spent
+// falls back well below the budget. This protects the latency of
+// underprovisioned agents at the cost of a lower compression ratio.
+func WithCPUBudget(fraction float64) Option {
+	return func(cfg *Config) {
+		cfg.CPUBudget = fraction
+	}
+}
+
+// WithTraceIDIndex adds to each traces and logs batch an optional payload
+// mapping every trace ID to the ranges of rows of the main payload (in the
+// order of the decoded spans or log records) carrying it. Receivers
+// correlating traces and logs can then join them without scanning the
+// decoded data. Consumers not interested in the index ignore it.
+func WithTraceIDIndex() Option {
+	return func(cfg *Config) {
+		cfg.TraceIDIndex = true
+	}
+}
+
+// WithEncodingOverrides forces the encoding of some fields that are
+// dictionary encoded by default. The keys are the lower case name of the
+// payload type followed by the dot separated path of the field, e.g.
+// "spans.name" or "span_events.name", and the values are "plain",
+// "dictionary8" or "dictionary16" (see schema.ValidateEncodingOverrides).
+// Overrides of unknown fields are ignored.
+func WithEncodingOverrides(overrides map[string]string) Option {
+	return func(cfg *Config) {
+		cfg.EncodingOverrides = overrides
+	}
+}
+
+// WithMemoryLimit limits the memory allocated by the Producer, i.e. by its
+// builders, records and IPC writers, to limit bytes. A batch exceeding the
+// limit is dropped with an error wrapping arrow_record.MemoryLimitError, and
+// the builders and streams are reset so that the memory held by the partial
+// batch is freed.
+func WithMemoryLimit(limit uint64) Option {
+	return func(cfg *Config) {
+		cfg.MemoryLimit = limit
+	}
+}
+
+// WithEmptyResourceScope sets the encoding of the empty resources and scopes.
+// By default, the empty resources (resp. scopes) of a batch share a single
+// entry, which is the most compact encoding. EmptyPerRecord preserves the
+// resource and scope entries of sparse data (e.g. several resource spans
+// without resource attributes) at the cost of an attributes ID per entry.
+func WithEmptyResourceScope(mode EmptyResourceScope) Option {
+	return func(cfg *Config) {
+		cfg.EmptyResourceScope = mode
+	}
+}
+
+// WithNoSort disables the sorting of the spans, log records, metrics and
+// attributes by the Producer. Sorting improves the compression ratio but costs
+// CPU, and the unsorted encoding preserves the order of the input data.
+func WithNoSort() Option {
+	return func(cfg *Config) {
+		cfg.NoSort = true
+	}
+}
+
+// WithMetricSorter selects the sort order of the metrics by the name it is
+// registered under (see metrics/arrow.RegisterMetricSorter), e.g. a custom
+// order tuned to the attribute distribution of the metrics. The Producer
+// constructor panics if the name is not registered. Ignored with WithNoSort.
+func WithMetricSorter(name string) Option {
+	return func(cfg *Config) {
+		cfg.MetricSorter = name
+	}
+}
+
+// WithLogSorter selects the sort order of the log records by the name it is
+// registered under (see logs/arrow.RegisterLogSorter). Ignored with
+// WithNoSort.
+func WithLogSorter(name string) Option {
+	return func(cfg *Config) {
+		cfg.LogSorter = name
+	}
+}
+
+// WithSpanSorter selects the sort order of the spans by the name it is
+// registered under (see traces/arrow.RegisterSpanSorter). Ignored with
+// WithNoSort.
+func WithSpanSorter(name string) Option {
+	return func(cfg *Config) {
+		cfg.SpanSorter = name
+	}
+}
+
+// WithMetricSortKeys sorts the metrics by the given keys, the first key being
+// the most significant (see metrics/arrow.SortMetricsByKeys for the supported
+// keys). The Producer constructor panics on an unknown key. Ignored with
+// WithNoSort.
+func WithMetricSortKeys(keys ...string) Option {
+	return func(cfg *Config) {
+		cfg.MetricSortKeys = keys
+	}
+}
+
+// WithLogSortKeys sorts the log records by the given keys (see
+// logs/arrow.SortLogsByKeys). Ignored with WithNoSort.
+func WithLogSortKeys(keys ...string) Option {
+	return func(cfg *Config) {
+		cfg.LogSortKeys = keys
+	}
+}
+
+// WithSpanSortKeys sorts the spans by the given keys, e.g. "resource",
+// "scope", "trace_id" to keep the spans of a trace together, or "resource",
+// "scope", "name" to group similar spans (see traces/arrow.SortSpansByKeys).
+// The best ordering for the compression ratio depends on the workload.
+// Ignored with WithNoSort.
+func WithSpanSortKeys(keys ...string) Option {
+	return func(cfg *Config) {
+		cfg.SpanSortKeys = keys
+	}
+}
+
+// WithMapFlattening flattens the attributes whose value is a map into one
+// attribute per entry, with the dotted path of the entry as key (e.g.
+// "http.request.method"), up to maxDepth levels of nesting. The deeper maps
+// are serialized as usual. Flattening improves the compression of deeply
+// nested attributes and makes their entries directly readable by the
+// backends. The flattened attributes are flagged so the Consumer restores
+// the nested maps. The keys of the hashed attributes (see
+// WithAttributeHashing) of a flattened map are dotted paths.
+func WithMapFlattening(maxDepth int) Option {
+	return func(cfg *Config) {
+		cfg.MapFlatteningDepth = maxDepth
+	}
+}
+
+// WithExperimentalUint64IDs encodes the trace IDs of the spans as two uint64
+// columns (trace_id_hi and trace_id_lo, big-endian halves of the ID) and their
+// span and parent span IDs as uint64 columns, instead of fixed size binary
+// columns that some query engines handle poorly. The conversion is lossless
+// and the Consumer decodes both encodings. This encoding is experimental, it
+// doesn't apply to the span links, the log records and the exemplars, and the
+// spans are not covered by the trace ID index (see WithTraceIDIndex).
+func WithExperimentalUint64IDs() Option {
+	return func(cfg *Config) {
+		cfg.Uint64IDs = true
+	}
+}
+
+// WithAnalyzerOutput sends the reports of the analyzers (see WithStats) to the
+// given writer instead of stdout.
+func WithAnalyzerOutput(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.Output = w
+	}
+}
+
+// WithAnalyzerLogger sends the reports of the analyzers (see WithStats) to the
+// given logger, one Info entry per line without the terminal color codes. This
+// is the option to use to enable the analyzers in a collector.
+func WithAnalyzerLogger(logger *zap.Logger) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.Logger = logger
+	}
+}
+
+// WithAnalyzerInterval sets the minimum duration between two reports of an
+// analyzer. The statistics keep being collected for every analyzed batch, only
+// their emission is throttled.
+func WithAnalyzerInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.Interval = interval
+	}
+}
+
+// WithAnalyzerVerbosity sets the level of detail of the reports of the
+// analyzers.
+func WithAnalyzerVerbosity(verbosity AnalyzerVerbosity) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.Verbosity = verbosity
+	}
+}
+
+// WithExpHistogramMaxScale downscales the exponential histogram data points
+// whose scale is greater than maxScale (between -10 and 20). Their adjacent
+// buckets are merged so the number of buckets is bounded, for the receivers
+// with a limited resolution. The downscaling is lossy, only the bucket
+// boundaries are coarser, the count, sum, min, max and zero count of the data
+// points are preserved.
+func WithExpHistogramMaxScale(maxScale int32) Option {
+	return func(cfg *Config) {
+		cfg.DownscaleExpHistograms = true
+		cfg.ExpHistogramMaxScale = maxScale
+	}
+}
+
+// WithAnalyzerConfig enables the analyzers (see WithStats) with the given
+// configuration.
+func WithAnalyzerConfig(analyzer AnalyzerConfig) Option {
+	return func(cfg *Config) {
+		cfg.Stats = true
+		cfg.Analyzer = analyzer
+	}
+}
+
+// WithAnalyzerSampling makes the analyzers analyze 1 batch out of n, to bound
+// their CPU cost on a production workload.
+func WithAnalyzerSampling(n int) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.Sampling = n
+	}
+}
+
+// WithAnalyzerFile appends the text reports of the analyzers to the given
+// file, created if needed.
+func WithAnalyzerFile(path string) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.File = path
+	}
+}
+
+// WithAnalyzerMeterProvider exports the statistics of the analyzers (see
+// AnalyzerStats in the common arrow package) as the gauge
+// arrow_analyzer_stat, with the path of the field and the name of the
+// statistic as attributes. The gauge reports the statistics of the last
+// report of each analyzer.
+func WithAnalyzerMeterProvider(mp metric.MeterProvider) Option {
+	return func(cfg *Config) {
+		cfg.Analyzer.MeterProvider = mp
+	}
+}
+
+// WithMeterProvider exports the producer stats (batches produced, schema
+// updates, dictionary overflows, serialized sizes, ...) as OpenTelemetry
+// metrics created with the given MeterProvider. Unlike WithStats, this option
+// has a low overhead and is suitable for production.
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(cfg *Config) {
+		cfg.MeterProvider = mp
+	}
+}
+
+// WithRetention adds the optional expiry_time_unix_nano column to the main
+// records (spans, logs and metrics) of the signals with a non-zero retention.
+// The expiry of the rows of a batch is the time the batch is produced plus
+// the retention of its signal, so Arrow-native storages can implement the
+// retention by pruning their partitions on this column, without reading the
+// timestamps of the data. The Consumer ignores this column.
+func WithRetention(retention Retention) Option {
+	return func(cfg *Config) {
+		cfg.Retention = retention
+	}
+}
+
+// WithLargeValues sets the policy applied to the string and binary columns
+// (e.g. very large log bodies) whose data exceeds the given number of bytes in
+// a single batch. Arrow Utf8 and Binary columns use int32 offsets and cannot
+// hold more than 2GB per batch. By default these columns are promoted to
+// LargeUtf8 and LargeBinary and the batch is rebuilt. LargeValuesError keeps
+// the column types and makes the Producer fail the batch with
+// schema.ErrValueTooLarge instead, for the consumers not supporting the
+// large types. A threshold of 0 selects the default, just below 2GB.
+func WithLargeValues(policy dictconfig.LargeValuesPolicy, threshold int) Option {
+	return func(cfg *Config) {
+		cfg.LargeValues = policy
+		cfg.LargeValueThreshold = threshold
+	}
+}
+
+// WithMaxRecordBytes limits the serialized size of the BatchArrowRecords
+// messages produced by the SplitArrowRecordsFrom* methods of the Producer,
+// e.g. to the maximum message size of the gRPC transport (4MiB by default).
+// A batch exceeding the limit is split in several messages, each containing
+// a subset of its spans, log records or metric data points. A single item
+// exceeding the limit fails with arrow_record.ErrBatchTooLarge.
+func WithMaxRecordBytes(n int) Option {
+	return func(cfg *Config) {
+		cfg.MaxRecordBytes = n
+	}
+}
+
+// WithScopeTable encodes each distinct instrumentation scope of a batch (name,
+// version, attributes and dropped attributes count) once, in a SCOPES related
+// record, and references it by ID from the spans, log records and metrics
+// instead of repeating the scope struct in every row. This reduces the size of
+// the batches mixing many scopes, e.g. when the same scopes are reported by
+// many resources.
+func WithScopeTable() Option {
+	return func(cfg *Config) {
+		cfg.ScopeTable = true
+	}
+}
+
+// WithWholeResources guarantees that the rows of a resource are contiguous in
+// the main records (spans, log records, metrics), the items being sorted by
+// resource first whatever the configured sorter or sort keys, and that a
+// resource is never divided between two messages by the SplitArrowRecordsFrom*
+// methods of the Producer (see WithMaxRecordBytes), so that receivers can
+// route whole resources without reassembling them across messages. A single
+// resource exceeding the maximum record size fails with
+// arrow_record.ErrBatchTooLarge.
+func WithWholeResources() Option {
+	return func(cfg *Config) {
+		cfg.WholeResources = true
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption implements an optional application-layer encryption
+// envelope (AES-GCM) for the serialized IPC bytes of the ArrowPayload
+// messages. It is independent of TLS and protects the payloads when they go
+// through untrusted intermediaries.
+//
+// Envelope format:
+//
+//	version (1 byte) | key ID length (1 byte) | key ID | stream ID (16 bytes) | nonce (12 bytes) | ciphertext + tag
+//
+// The stream ID is chosen at random by each producer (see NewStreamID). The
+// header of the envelope, stream ID included, is authenticated along with the
+// additional data of the caller, so that a consumer pinning the stream ID of
+// its first payload rejects the payloads encrypted for another stream.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+const (
+	// Version is the version of the envelope format.
+	Version = 1
+	// StreamIDSize is the size of the stream IDs.
+	StreamIDSize = 16
+)
+
+var (
+	ErrInvalidEnvelope = errors.New("invalid encryption envelope")
+	ErrUnknownKey      = errors.New("unknown encryption key")
+	ErrInvalidKey      = errors.New("invalid encryption key")
+	ErrStreamMismatch  = errors.New("encrypted payload of another stream")
+	ErrReplayedBatch   = errors.New("replayed encrypted batch")
+)
+
+// KeyProvider supplies the AES keys (16, 24, or 32 bytes) used to encrypt and
+// decrypt the payloads. Implementations must be safe for concurrent use.
+type KeyProvider interface {
+	// EncryptionKey returns the ID and the value of the key used to encrypt
+	// new payloads. The ID is at most 255 bytes long.
+	EncryptionKey() (id string, key []byte, err error)
+	// DecryptionKey returns the value of the key identified by id.
+	DecryptionKey(id string) ([]byte, error)
+}
+
+// StaticKeyProvider is a KeyProvider based on a fixed set of keys. Keeping
+// the previous keys in the set allows the keys to be rotated without
+// breaking the consumers.
+type StaticKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+var _ KeyProvider = (*StaticKeyProvider)(nil)
+
+// NewStaticKeyProvider creates a StaticKeyProvider encrypting with the key
+// `current` and decrypting with any of the keys.
+func NewStaticKeyProvider(current string, keys map[string][]byte) (*StaticKeyProvider, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, werror.WrapWithContext(ErrUnknownKey, map[string]interface{}{"id": current})
+	}
+	copied := make(map[string][]byte, len(keys))
+	for id, key := range keys {
+		if err := checkKey(id, key); err != nil {
+			return nil, werror.Wrap(err)
+		}
+		copied[id] = append([]byte(nil), key...)
+	}
+	return &StaticKeyProvider{current: current, keys: copied}, nil
+}
+
+// EncryptionKey implements KeyProvider.
+func (p *StaticKeyProvider) EncryptionKey() (string, []byte, error) {
+	return p.current, p.keys[p.current], nil
+}
+
+// DecryptionKey implements KeyProvider.
+func (p *StaticKeyProvider) DecryptionKey(id string) ([]byte, error) {
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, werror.WrapWithContext(ErrUnknownKey, map[string]interface{}{"id": id})
+	}
+	return key, nil
+}
+
+// NewStreamID returns a random stream ID.
+func NewStreamID() ([]byte, error) {
+	streamID := make([]byte, StreamIDSize)
+	if _, err := io.ReadFull(rand.Reader, streamID); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return streamID, nil
+}
+
+// Seal encrypts plaintext for the given stream with the current key of the
+// provider and returns the envelope.
+func Seal(kp KeyProvider, streamID, plaintext, additionalData []byte) ([]byte, error) {
+	if len(streamID) != StreamIDSize {
+		return nil, werror.WrapWithContext(ErrInvalidEnvelope, map[string]interface{}{"stream_id_length": len(streamID)})
+	}
+	id, key, err := kp.EncryptionKey()
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if err := checkKey(id, key); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	headerLen := 2 + len(id) + StreamIDSize
+	envelope := make([]byte, headerLen+aead.NonceSize(), headerLen+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	envelope[0] = Version
+	envelope[1] = byte(len(id))
+	copy(envelope[2:], id)
+	copy(envelope[2+len(id):], streamID)
+	nonce := envelope[headerLen:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return aead.Seal(envelope, nonce, plaintext, authenticatedData(envelope[:headerLen], additionalData)), nil
+}
+
+// Open decrypts an envelope created by Seal with the key it references and
+// returns the plaintext and the stream ID of the envelope.
+func Open(kp KeyProvider, envelope, additionalData []byte) (plaintext, streamID []byte, err error) {
+	if len(envelope) < 2 || envelope[0] != Version {
+		return nil, nil, werror.WrapWithMsg(ErrInvalidEnvelope, "unsupported version")
+	}
+	idLen := int(envelope[1])
+	headerLen := 2 + idLen + StreamIDSize
+	if len(envelope) < headerLen {
+		return nil, nil, werror.WrapWithMsg(ErrInvalidEnvelope, "truncated header")
+	}
+	id := string(envelope[2 : 2+idLen])
+
+	key, err := kp.DecryptionKey(id)
+	if err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+	if len(envelope) < headerLen+aead.NonceSize()+aead.Overhead() {
+		return nil, nil, werror.WrapWithMsg(ErrInvalidEnvelope, "truncated payload")
+	}
+	nonce := envelope[headerLen : headerLen+aead.NonceSize()]
+	plaintext, err = aead.Open(nil, nonce, envelope[headerLen+aead.NonceSize():], authenticatedData(envelope[:headerLen], additionalData))
+	if err != nil {
+		return nil, nil, werror.WrapWithMsg(ErrInvalidEnvelope, err.Error())
+	}
+	return plaintext, envelope[2+idLen : headerLen], nil
+}
+
+// authenticatedData returns the header of an envelope followed by the
+// additional data of the caller.
+func authenticatedData(header, additionalData []byte) []byte {
+	data := make([]byte, 0, len(header)+len(additionalData))
+	data = append(data, header...)
+	return append(data, additionalData...)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, werror.WrapWithMsg(ErrInvalidKey, err.Error())
+	}
+	return cipher.NewGCM(block)
+}
+
+func checkKey(id string, key []byte) error {
+	if len(id) > 255 {
+		return werror.WrapWithMsg(ErrInvalidKey, fmt.Sprintf("key ID longer than 255 bytes: %q", id))
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return werror.WrapWithContext(ErrInvalidKey, map[string]interface{}{"id": id, "length": len(key)})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	t.Parallel()
+
+	kp, err := NewStaticKeyProvider("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 16),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	})
+	require.NoError(t, err)
+
+	streamID, err := NewStreamID()
+	require.NoError(t, err)
+	plaintext := []byte("arrow ipc stream")
+	aad := []byte("0/1")
+
+	envelope, err := Seal(kp, streamID, plaintext, aad)
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(envelope, plaintext))
+
+	opened, openedStreamID, err := Open(kp, envelope, aad)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, opened)
+	require.Equal(t, streamID, openedStreamID)
+
+	// The additional data must match.
+	_, _, err = Open(kp, envelope, []byte("1/1"))
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	// Tampered payloads are rejected.
+	tampered := append([]byte(nil), envelope...)
+	tampered[len(tampered)-1] ^= 1
+	_, _, err = Open(kp, tampered, aad)
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	// The stream ID is authenticated.
+	tampered = append([]byte(nil), envelope...)
+	tampered[2+len("k2")] ^= 1
+	_, _, err = Open(kp, tampered, aad)
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	_, _, err = Open(kp, envelope[:5], aad)
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+
+	_, err = Seal(kp, streamID[:8], plaintext, aad)
+	require.True(t, errors.Is(err, ErrInvalidEnvelope))
+}
+
+func TestKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	old, err := NewStaticKeyProvider("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)})
+	require.NoError(t, err)
+	rotated, err := NewStaticKeyProvider("k2", map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 16),
+		"k2": bytes.Repeat([]byte{2}, 16),
+	})
+	require.NoError(t, err)
+
+	streamID, err := NewStreamID()
+	require.NoError(t, err)
+
+	// Payloads encrypted with the previous key are still readable.
+	envelope, err := Seal(old, streamID, []byte("data"), nil)
+	require.NoError(t, err)
+	_, _, err = Open(rotated, envelope, nil)
+	require.NoError(t, err)
+
+	// Payloads encrypted with the new key require the new key.
+	envelope, err = Seal(rotated, streamID, []byte("data"), nil)
+	require.NoError(t, err)
+	_, _, err = Open(old, envelope, nil)
+	require.True(t, errors.Is(err, ErrUnknownKey))
+}
+
+func TestInvalidKeys(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewStaticKeyProvider("k1", map[string][]byte{"k1": []byte("short")})
+	require.True(t, errors.Is(err, ErrInvalidKey))
+
+	_, err = NewStaticKeyProvider("k2", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 16)})
+	require.True(t, errors.Is(err, ErrUnknownKey))
+}
@@ -0,0 +1,27 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package hostmetrics collects basic host metrics (CPU, memory, disk) from a
+// Linux procfs and lays them out as multivariate metrics: for each group (e.g.
+// CPU time) all the variables sampled at the same time share a single metric,
+// the same timestamp and the same attributes, and only differ by the value of
+// the MultivariateKey attribute. This is the layout for which the Arrow
+// encoding is the most effective and the package is used to exercise and
+// validate the multivariate encoding path end to end. CollectArrow appends the
+// samples directly to the Arrow builders, without building an intermediate
+// pmetric.Metrics.
+package hostmetrics
@@ -0,0 +1,231 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hostmetrics
+
+import (
+	"os"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// MultivariateKey is the attribute identifying the variable of a data point
+// within a multivariate metric.
+const MultivariateKey = "metric"
+
+const scopeName = "github.com/f5/otel-arrow-adapter/pkg/hostmetrics"
+
+// Metric names.
+const (
+	CPUTimeMetric = "system.cpu.time"
+	MemoryMetric  = "system.memory.usage"
+	DiskMetric    = "system.disk.io"
+)
+
+// Collector collects host metrics from a procfs.
+type Collector struct {
+	procPath string
+	hostname string
+	now      func() time.Time
+}
+
+// NewCollector creates a Collector reading the procfs mounted at procPath
+// (usually "/proc").
+func NewCollector(procPath string) *Collector {
+	hostname, _ := os.Hostname()
+	return &Collector{
+		procPath: procPath,
+		hostname: hostname,
+		now:      time.Now,
+	}
+}
+
+// hostMetric is a multivariate metric of a sample, its data points are not
+// attached to any metric so they can be either moved to a pmetric.Metrics or
+// appended to the Arrow builders.
+type hostMetric struct {
+	desc metricsarrow.Metric
+	dps  pmetric.NumberDataPointSlice
+}
+
+// sample samples the host metrics. The start time of the cumulative metrics is
+// the boot time of the host, their counters being reset on boot.
+func (c *Collector) sample() ([]hostMetric, error) {
+	bootTime, err := readBootTime(c.procPath)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	cpus, err := readCPUStats(c.procPath)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	mem, err := readMemInfo(c.procPath)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	disks, err := readDiskStats(c.procPath)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	ts := pcommon.NewTimestampFromTime(c.now())
+	startTime := pcommon.NewTimestampFromTime(bootTime)
+
+	cpuDPs := pmetric.NewNumberDataPointSlice()
+	for _, cpu := range cpus {
+		for _, v := range cpu.values {
+			dp := cpuDPs.AppendEmpty()
+			dp.SetStartTimestamp(startTime)
+			dp.SetTimestamp(ts)
+			dp.Attributes().PutStr("cpu", cpu.name)
+			dp.Attributes().PutStr(MultivariateKey, v.name)
+			dp.SetDoubleValue(v.value)
+		}
+	}
+
+	memDPs := pmetric.NewNumberDataPointSlice()
+	for _, v := range mem {
+		dp := memDPs.AppendEmpty()
+		dp.SetTimestamp(ts)
+		dp.Attributes().PutStr(MultivariateKey, v.name)
+		dp.SetIntValue(int64(v.value))
+	}
+
+	diskDPs := pmetric.NewNumberDataPointSlice()
+	for _, disk := range disks {
+		for _, v := range disk.values {
+			dp := diskDPs.AppendEmpty()
+			dp.SetStartTimestamp(startTime)
+			dp.SetTimestamp(ts)
+			dp.Attributes().PutStr("device", disk.name)
+			dp.Attributes().PutStr(MultivariateKey, v.name)
+			dp.SetIntValue(int64(v.value))
+		}
+	}
+
+	return []hostMetric{
+		{
+			desc: metricsarrow.Metric{
+				Type:                   pmetric.MetricTypeSum,
+				Name:                   CPUTimeMetric,
+				Unit:                   "s",
+				AggregationTemporality: pmetric.AggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			},
+			dps: cpuDPs,
+		},
+		{
+			desc: metricsarrow.Metric{
+				Type: pmetric.MetricTypeGauge,
+				Name: MemoryMetric,
+				Unit: "By",
+			},
+			dps: memDPs,
+		},
+		{
+			desc: metricsarrow.Metric{
+				Type:                   pmetric.MetricTypeSum,
+				Name:                   DiskMetric,
+				AggregationTemporality: pmetric.AggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			},
+			dps: diskDPs,
+		},
+	}, nil
+}
+
+// resource returns the resource and the scope of the host metrics.
+func (c *Collector) resource() (pcommon.Resource, pcommon.InstrumentationScope) {
+	resource := pcommon.NewResource()
+	resource.Attributes().PutStr("host.name", c.hostname)
+	scope := pcommon.NewInstrumentationScope()
+	scope.SetName(scopeName)
+	return resource, scope
+}
+
+// Collect samples the host metrics and returns them as multivariate metrics
+// in a single resource and scope.
+func (c *Collector) Collect() (pmetric.Metrics, error) {
+	hostMetrics, err := c.sample()
+	if err != nil {
+		return pmetric.Metrics{}, werror.Wrap(err)
+	}
+
+	metrics := pmetric.NewMetrics()
+	rm := metrics.ResourceMetrics().AppendEmpty()
+	sm := rm.ScopeMetrics().AppendEmpty()
+	resource, scope := c.resource()
+	resource.MoveTo(rm.Resource())
+	scope.MoveTo(sm.Scope())
+	ms := sm.Metrics()
+
+	for _, hm := range hostMetrics {
+		m := ms.AppendEmpty()
+		m.SetName(hm.desc.Name)
+		m.SetDescription(hm.desc.Description)
+		m.SetUnit(hm.desc.Unit)
+		switch hm.desc.Type {
+		case pmetric.MetricTypeSum:
+			sum := m.SetEmptySum()
+			sum.SetAggregationTemporality(hm.desc.AggregationTemporality)
+			sum.SetIsMonotonic(hm.desc.IsMonotonic)
+			hm.dps.MoveAndAppendTo(sum.DataPoints())
+		case pmetric.MetricTypeGauge:
+			hm.dps.MoveAndAppendTo(m.SetEmptyGauge().DataPoints())
+		}
+	}
+
+	return metrics, nil
+}
+
+// CollectArrow samples the host metrics and appends them directly to the
+// Arrow builders of the given producer, without building a pmetric.Metrics
+// first. The batch decodes to the metrics returned by Collect.
+func (c *Collector) CollectArrow(producer *arrow_record.Producer) (*colarspb.BatchArrowRecords, error) {
+	hostMetrics, err := c.sample()
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	resource, scope := c.resource()
+
+	batch, err := producer.BatchArrowRecordsFromMetricsAppender(func(a *metricsarrow.MetricsAppender) error {
+		a.SetResource(resource, "")
+		a.SetScope(scope, "")
+		for _, hm := range hostMetrics {
+			if err := a.AppendMetric(hm.desc); err != nil {
+				return werror.Wrap(err)
+			}
+			for i := 0; i < hm.dps.Len(); i++ {
+				if err := a.AppendNumberDataPoint(hm.dps.At(i)); err != nil {
+					return werror.Wrap(err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return batch, nil
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hostmetrics
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+)
+
+func newTestCollector() *Collector {
+	c := NewCollector(filepath.Join("testdata", "proc"))
+	c.hostname = "test-host"
+	clock := time.Unix(1700000000, 0)
+	c.now = func() time.Time {
+		clock = clock.Add(10 * time.Second)
+		return clock
+	}
+	return c
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	metrics, err := newTestCollector().Collect()
+	require.NoError(t, err)
+
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 3, ms.Len())
+
+	// 2 CPUs x 8 states, the aggregated `cpu` line is skipped.
+	cpu := ms.At(0)
+	require.Equal(t, CPUTimeMetric, cpu.Name())
+	require.Equal(t, 16, cpu.Sum().DataPoints().Len())
+	require.Equal(t, 15.0, cpu.Sum().DataPoints().At(0).DoubleValue())
+	// The counters start at the boot time of the host.
+	require.Equal(t, int64(1699990000), cpu.Sum().DataPoints().At(0).StartTimestamp().AsTime().Unix())
+
+	mem := ms.At(1)
+	require.Equal(t, MemoryMetric, mem.Name())
+	require.Equal(t, 5, mem.Gauge().DataPoints().Len())
+	require.Equal(t, int64(16303428*1024), mem.Gauge().DataPoints().At(0).IntValue())
+
+	// sda1 has no activity and is skipped.
+	disk := ms.At(2)
+	require.Equal(t, DiskMetric, disk.Name())
+	require.Equal(t, 10, disk.Sum().DataPoints().Len())
+}
+
+// TestMultivariateRoundTrip validates that several successive collections
+// survive an Arrow encoding/decoding round trip on the same stream.
+func TestMultivariateRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	collector := newTestCollector()
+	producer := arrow_record.NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := arrow_record.NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	for i := 0; i < 3; i++ {
+		metrics, err := collector.Collect()
+		require.NoError(t, err)
+
+		batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+		require.NoError(t, err)
+
+		received, err := consumer.MetricsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+		)
+	}
+}
+
+// TestCollectArrow validates that the metrics appended directly to the Arrow
+// builders decode to the metrics built by Collect.
+func TestCollectArrow(t *testing.T) {
+	t.Parallel()
+
+	expected := newTestCollector()
+	collector := newTestCollector()
+	producer := arrow_record.NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := arrow_record.NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	for i := 0; i < 3; i++ {
+		metrics, err := expected.Collect()
+		require.NoError(t, err)
+
+		batch, err := collector.CollectArrow(producer)
+		require.NoError(t, err)
+
+		received, err := consumer.MetricsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+		)
+	}
+}
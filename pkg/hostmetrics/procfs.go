@@ -0,0 +1,183 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hostmetrics
+
+// Minimal parsers for the procfs files used by the collector.
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// ErrNoBootTime is returned when /proc/stat has no boot time.
+var ErrNoBootTime = errors.New("no boot time in /proc/stat")
+
+// userHZ is the number of clock ticks per second used by /proc/stat.
+const userHZ = 100
+
+// sectorSize is the size of a sector reported by /proc/diskstats.
+const sectorSize = 512
+
+type (
+	variable struct {
+		name  string
+		value float64
+	}
+
+	// group is a set of variables sampled for a single entity (a CPU, a
+	// disk, ...).
+	group struct {
+		name   string
+		values []variable
+	}
+)
+
+// readBootTime reads the boot time of the host from /proc/stat.
+func readBootTime(procPath string) (time.Time, error) {
+	var bootTime time.Time
+	found := false
+	err := scanLines(filepath.Join(procPath, "stat"), func(fields []string) error {
+		if fields[0] != "btime" || len(fields) < 2 {
+			return nil
+		}
+		secs, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"key": fields[0]})
+		}
+		bootTime = time.Unix(secs, 0)
+		found = true
+		return nil
+	})
+	if err == nil && !found {
+		err = werror.Wrap(ErrNoBootTime)
+	}
+	return bootTime, err
+}
+
+var cpuStates = []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal"}
+
+// readCPUStats reads the per-CPU times (in seconds) from /proc/stat.
+func readCPUStats(procPath string) ([]group, error) {
+	var cpus []group
+	err := scanLines(filepath.Join(procPath, "stat"), func(fields []string) error {
+		if !strings.HasPrefix(fields[0], "cpu") || fields[0] == "cpu" {
+			return nil
+		}
+		cpu := group{name: fields[0]}
+		for i, state := range cpuStates {
+			if i+1 >= len(fields) {
+				break
+			}
+			ticks, err := strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return werror.WrapWithContext(err, map[string]interface{}{"cpu": fields[0], "state": state})
+			}
+			cpu.values = append(cpu.values, variable{name: state, value: float64(ticks) / userHZ})
+		}
+		cpus = append(cpus, cpu)
+		return nil
+	})
+	return cpus, err
+}
+
+var memInfoKeys = map[string]string{
+	"MemTotal:":     "total",
+	"MemFree:":      "free",
+	"MemAvailable:": "available",
+	"Buffers:":      "buffers",
+	"Cached:":       "cached",
+}
+
+// readMemInfo reads the main memory counters (in bytes) from /proc/meminfo.
+func readMemInfo(procPath string) ([]variable, error) {
+	var mem []variable
+	err := scanLines(filepath.Join(procPath, "meminfo"), func(fields []string) error {
+		name, ok := memInfoKeys[fields[0]]
+		if !ok || len(fields) < 2 {
+			return nil
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"key": fields[0]})
+		}
+		mem = append(mem, variable{name: name, value: float64(kb * 1024)})
+		return nil
+	})
+	return mem, err
+}
+
+// readDiskStats reads the I/O counters of each block device from
+// /proc/diskstats. Devices without any activity are skipped.
+func readDiskStats(procPath string) ([]group, error) {
+	var disks []group
+	err := scanLines(filepath.Join(procPath, "diskstats"), func(fields []string) error {
+		if len(fields) < 14 {
+			return nil
+		}
+		values := make([]uint64, 0, 11)
+		for _, f := range fields[3:14] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return werror.WrapWithContext(err, map[string]interface{}{"device": fields[2]})
+			}
+			values = append(values, v)
+		}
+		if values[0] == 0 && values[4] == 0 {
+			return nil
+		}
+		disks = append(disks, group{
+			name: fields[2],
+			values: []variable{
+				{name: "reads", value: float64(values[0])},
+				{name: "read_bytes", value: float64(values[2] * sectorSize)},
+				{name: "writes", value: float64(values[4])},
+				{name: "write_bytes", value: float64(values[6] * sectorSize)},
+				{name: "io_time_ms", value: float64(values[9])},
+			},
+		})
+		return nil
+	})
+	return disks, err
+}
+
+func scanLines(path string, fn func(fields []string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if err := fn(fields); err != nil {
+			return err
+		}
+	}
+	return werror.Wrap(scanner.Err())
+}
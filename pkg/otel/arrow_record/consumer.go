@@ -16,7 +16,14 @@ package arrow_record
 
 import (
 	"bytes"
-
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
 	"github.com/apache/arrow/go/v12/arrow/ipc"
 	"github.com/apache/arrow/go/v12/arrow/memory"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -24,11 +31,22 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 
 	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
 	common "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/logs/logmetrics"
 	logsotlp "github.com/f5/otel-arrow-adapter/pkg/otel/logs/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/metrics"
 	metricsotlp "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/otlp"
-	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/redact"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/sanitize"
+	pstats "github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+	tracesarrow "github.com/f5/otel-arrow-adapter/pkg/otel/traces/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
 	tracesotlp "github.com/f5/otel-arrow-adapter/pkg/otel/traces/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/servicegraph"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/spanmetrics"
 	"github.com/f5/otel-arrow-adapter/pkg/record_message"
 	"github.com/f5/otel-arrow-adapter/pkg/werror"
 )
@@ -52,26 +70,334 @@ var _ ConsumerAPI = &Consumer{}
 type Consumer struct {
 	streamConsumers map[string]*streamConsumer
 
+	// memLimit is the maximum number of bytes allocated by the records
+	// and dictionaries of each stream (see WithMemoryLimit).
 	memLimit uint64
 
-	tracesConfig *arrow.Config
+	// mmap backs the large buffers of the streams with memory mappings (nil =
+	// Go heap only, see WithMmapAllocator).
+	mmap *common.MmapAllocator
+
+	// expansion bounds the memory allocated to decode the payloads relative
+	// to their compressed size (nil = no limit, see WithMaxExpansion).
+	expansion *common.ExpansionLimit
+
+	tracesConfig *tracesarrow.Config
+
+	// strictSchema makes the consumer return an error when a record contains
+	// columns unknown to this version of the adapter.
+	strictSchema bool
+
+	// unknownColumns is called with the unknown columns skipped, once per
+	// schema (nil = skipped silently, see WithUnknownColumnsHandler).
+	unknownColumns func(err error)
+
+	// strict makes the consumer return an error when the decoded data is
+	// degraded (see WithStrict).
+	strict bool
+
+	// streamRecording is the maximum number of bytes recorded per stream for
+	// the snapshots (0 = no recording).
+	streamRecording int
+
+	// decryption is the key provider used to decrypt the payloads (nil = the
+	// payloads are not encrypted).
+	decryption encryption.KeyProvider
+	// encryptionStreamID is the stream ID of the first decrypted payload,
+	// the payloads of other streams are rejected.
+	encryptionStreamID []byte
+	// lastEncryptedBatch is the ID of the last batch decrypted, the
+	// batches with a lower or equal ID are rejected as replayed.
+	lastEncryptedBatch int64
+
+	// decodeConcurrency is the maximum number of related payloads of a batch
+	// decoded in parallel (1 = sequential decoding).
+	decodeConcurrency int
+
+	// attrsCache caches the decoded resource and scope attributes (nil = no
+	// cache).
+	attrsCache *otlp.AttributesCache
+	// nextStreamID identifies the stream consumers in the keys of attrsCache.
+	nextStreamID uint64
+
+	// traceIDIndex is the trace ID index of the last traces or logs batch
+	// (nil = the batch had no index).
+	traceIDIndex TraceIDIndex
+
+	// spanMetrics aggregates the RED metrics of the decoded spans (nil = no
+	// span metrics).
+	spanMetrics *spanmetrics.Aggregator
+
+	// logMetrics counts the decoded log records matching its rules (nil =
+	// no log metrics).
+	logMetrics *logmetrics.Counter
+
+	// serviceGraph aggregates the service dependency edges of the decoded
+	// spans (nil = no service graph).
+	serviceGraph *servicegraph.Aggregator
+
+	// completeness tracks the root spans of the traces of the decoded
+	// spans (nil = no tracking).
+	completeness *completeness.Tracker
+
+	// hints configures the hints returned by ProducerHints (nil = no
+	// hints).
+	hints *hintsConfig
+
+	// sanitizer repairs the decoded records before their conversion (nil =
+	// no sanitization).
+	sanitizer *sanitize.Sanitizer
+
+	// redactor redacts the decoded records before their conversion (nil =
+	// no redaction), memoizing the redacted values in redaction.
+	redactor  *redact.Redactor
+	redaction *redact.Dictionary
+
+	// schemaResets counts the stream consumers replaced because of a new
+	// schema ID for their payload type (see SchemaResets).
+	schemaResets uint64
+
+	// stats are the general stats of the consumer (see GetAndResetStats).
+	stats *pstats.ConsumerStats
 }
 
+// ConsumerOption is a functional option for the Consumer.
+type ConsumerOption func(*Consumer)
+
 type streamConsumer struct {
+	id          uint64
 	bufReader   *bytes.Reader
 	ipcReader   *ipc.Reader
 	payloadType record_message.PayloadType
+
+	// schemaChecked is true once the schema of the stream has been checked
+	// against the prototype schema of its payload type.
+	schemaChecked bool
+	// knownSchema and knownColumns are used to project the records of the
+	// stream on their known top-level columns. Nil if all the top-level
+	// columns are known.
+	knownSchema  *arrow.Schema
+	knownColumns []int
+
+	lastConsumption time.Time
+	recording       *streamRecording
+
+	// allocator limits the memory used by the records and dictionaries
+	// of the stream.
+	allocator *common.LimitedAllocator
+
+	// dictGeneration is incremented for each payload of the stream carrying
+	// dictionary batches (replacements or deltas), the records of the stream
+	// decoding differently afterwards (see attrsCacheKey).
+	dictGeneration uint64
 }
 
 // NewConsumer creates a new BatchArrowRecords consumer, i.e. a decoder consuming BatchArrowRecords and returning
 // the corresponding OTLP representation (pmetric,Metrics, plog.Logs, ptrace.Traces).
 func NewConsumer() *Consumer {
-	return &Consumer{
+	return NewConsumerWithOptions( /* use default options */ )
+}
+
+// NewConsumerWithOptions creates a new BatchArrowRecords consumer with a set of
+// options.
+func NewConsumerWithOptions(options ...ConsumerOption) *Consumer {
+	c := &Consumer{
 		streamConsumers: make(map[string]*streamConsumer),
 
-		// TODO: configure this limit with a functional option
-		memLimit:     70 << 20,
-		tracesConfig: arrow.DefaultConfig(),
+		memLimit:          DefaultConsumerMemoryLimit,
+		tracesConfig:      tracesarrow.DefaultConfig(),
+		decodeConcurrency: 1,
+		stats:             pstats.NewConsumerStats(),
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	return c
+}
+
+// DefaultConsumerMemoryLimit is the default memory limit of each stream of a
+// Consumer.
+const DefaultConsumerMemoryLimit = 70 << 20
+
+// WithMemoryLimit limits the memory allocated by the records and dictionaries
+// of each stream of the consumer to limit bytes (DefaultConsumerMemoryLimit by
+// default). A batch exceeding the limit is rejected with an error wrapping
+// MemoryLimitError, the records already decoded from the batch are released
+// and the stream is closed, so the producer must start a new stream.
+func WithMemoryLimit(limit uint64) ConsumerOption {
+	return func(c *Consumer) {
+		c.memLimit = limit
+	}
+}
+
+// WithMmapAllocator backs the buffers of at least threshold bytes allocated by
+// the streams of the consumer with anonymous memory mappings instead of the Go
+// heap (see config.WithMmapAllocator). The mappings are returned to the
+// operating system as soon as the decoded records are released, so the
+// occasional giant batches don't inflate the RSS of the receiver. A threshold
+// of 0 selects common.DefaultMmapThreshold. The memory limit of the streams
+// (see WithMemoryLimit) applies to the mapped buffers too.
+func WithMmapAllocator(threshold int) ConsumerOption {
+	return func(c *Consumer) {
+		c.mmap = common.NewMmapAllocator(memory.NewGoAllocator(), threshold)
+	}
+}
+
+// DefaultMinExpansionBytes is the memory that can always be allocated to decode
+// a payload or a batch regardless of the expansion factor (see
+// WithMaxExpansion).
+const DefaultMinExpansionBytes = 1 << 20
+
+// ExpansionLimitError is the error returned by the Consumer when the
+// decompression of a payload or a batch exceeds the expansion factor of the
+// consumer (see WithMaxExpansion).
+type ExpansionLimitError = common.ExpansionError
+
+// WithMaxExpansion limits the memory allocated to decode the compressed
+// payloads to factor times their size: a single IPC buffer can't expand to
+// more than factor times the size of its payload, and the records of a batch
+// to more than factor times the size of the batch payloads, with a minimum of
+// DefaultMinExpansionBytes. A batch exceeding the limit (e.g. a zstd payload
+// crafted to expand to gigabytes) is rejected with an error wrapping
+// ExpansionLimitError before the memory is allocated, and the stream is
+// closed. The factor must leave room for the dictionaries of the streams,
+// which are rebuilt when a delta is received. 0 disables the limit (the
+// default).
+func WithMaxExpansion(factor uint64) ConsumerOption {
+	return func(c *Consumer) {
+		if factor == 0 {
+			c.expansion = nil
+			return
+		}
+		c.expansion = common.NewExpansionLimit(factor, DefaultMinExpansionBytes)
+	}
+}
+
+// WithStrictSchema makes the consumer reject the records containing columns
+// that are unknown to this version of the adapter. By default these columns
+// are skipped (see WithUnknownColumnsHandler) so that newer producers adding
+// optional fields can interoperate with older consumers. This option is
+// mainly useful for testing.
+func WithStrictSchema() ConsumerOption {
+	return func(c *Consumer) {
+		c.strictSchema = true
+	}
+}
+
+// WithUnknownColumnsHandler calls handler once per schema containing columns
+// that are unknown to this version of the adapter, which are skipped, with an
+// error wrapping otel.ErrUnknownColumns in its context the payload type, the
+// schema ID, and the unknown columns. It is not called in strict mode, where
+// the error is returned by the consumer.
+func WithUnknownColumnsHandler(handler func(err error)) ConsumerOption {
+	return func(c *Consumer) {
+		c.unknownColumns = handler
+	}
+}
+
+// WithStrict makes the consumer return an error wrapping
+// otel.ErrLossyConversion instead of silently degrading the decoded data, i.e.
+// when records contain unknown columns (see WithStrictSchema), values of
+// unknown type or metrics of unknown type. This is the consumer side of
+// config.WithStrict.
+func WithStrict() ConsumerOption {
+	return func(c *Consumer) {
+		c.strictSchema = true
+		c.strict = true
+	}
+}
+
+// WithStreamRecording keeps up to maxBytes of the payloads received on each
+// stream so that a snapshot of the Consumer (see Consumer.WriteSnapshot)
+// contains the payloads leading to a decode failure.
+func WithStreamRecording(maxBytes int) ConsumerOption {
+	return func(c *Consumer) {
+		c.streamRecording = maxBytes
+	}
+}
+
+// WithDecryption decrypts the payloads encrypted by a producer configured with
+// config.WithEncryption, using the keys of the given provider.
+func WithDecryption(kp encryption.KeyProvider) ConsumerOption {
+	return func(c *Consumer) {
+		c.decryption = kp
+	}
+}
+
+// WithDecodeConcurrency decodes the independent related payloads of a batch
+// (e.g. the attributes, the span events and the span links) with up to n
+// parallel workers before assembling the OTLP entities. This reduces the
+// decoding latency of large batches at the cost of more goroutines.
+func WithDecodeConcurrency(n int) ConsumerOption {
+	return func(c *Consumer) {
+		c.decodeConcurrency = n
+	}
+}
+
+// WithAttributesCache caches the resource and scope attributes decoded from
+// up to capacity distinct payloads of the consumer's streams. Repeated
+// payloads (e.g. agents sending the same resources in every batch) then reuse
+// the attribute maps decoded for a previous batch, reducing the allocations.
+func WithAttributesCache(capacity int) ConsumerOption {
+	return func(c *Consumer) {
+		c.attrsCache = otlp.NewAttributesCache(capacity)
+	}
+}
+
+// WithSpanMetrics feeds the span records of every decoded traces batch into
+// the given aggregator, which computes the call, error and duration metrics of
+// the spans without materializing them (see spanmetrics.Aggregator.Metrics).
+func WithSpanMetrics(agg *spanmetrics.Aggregator) ConsumerOption {
+	return func(c *Consumer) {
+		c.spanMetrics = agg
+	}
+}
+
+// WithLogMetrics feeds the log records of every decoded logs batch into the
+// given counter, which counts the log records matching its rules (see
+// logmetrics.Counter.Metrics).
+func WithLogMetrics(counter *logmetrics.Counter) ConsumerOption {
+	return func(c *Consumer) {
+		c.logMetrics = counter
+	}
+}
+
+// WithServiceGraph feeds the span and span link records of every decoded
+// traces batch into the given aggregator, which counts the requests between
+// services (see servicegraph.Aggregator.Edges).
+func WithServiceGraph(agg *servicegraph.Aggregator) ConsumerOption {
+	return func(c *Consumer) {
+		c.serviceGraph = agg
+	}
+}
+
+// WithTraceCompleteness feeds the span records of every decoded traces batch
+// into the given tracker, which tracks across batches whether the root span of
+// each trace has been received (see completeness.Tracker.Sweep).
+func WithTraceCompleteness(tracker *completeness.Tracker) ConsumerOption {
+	return func(c *Consumer) {
+		c.completeness = tracker
+	}
+}
+
+// WithSanitizer repairs the records of every decoded batch with the given
+// sanitizer before their conversion into the OTLP representation, i.e. the
+// invalid UTF-8 of the string values, the absurd timestamps, and the negative
+// durations (see sanitize.Sanitizer.Counts).
+func WithSanitizer(s *sanitize.Sanitizer) ConsumerOption {
+	return func(c *Consumer) {
+		c.sanitizer = s
+	}
+}
+
+// WithRedactor redacts the records of every decoded batch with the given
+// redactor before their conversion into the OTLP representation, after their
+// sanitization. The redacted values are memoized by the consumer, the
+// redactor can be shared by the consumers of all the streams.
+func WithRedactor(r *redact.Redactor) ConsumerOption {
+	return func(c *Consumer) {
+		c.redactor = r
+		c.redaction = r.NewDictionary()
 	}
 }
 
@@ -82,28 +408,87 @@ func (c *Consumer) MetricsFrom(bar *colarspb.BatchArrowRecords) ([]pmetric.Metri
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	return c.MetricsFromRecords(records)
+}
+
+// MetricsFromRecords produces an array of [pmetric.Metrics] from the records
+// of a batch, e.g. the records returned by Consume or the records read from
+// Arrow IPC streams. The records are released by this method.
+func (c *Consumer) MetricsFromRecords(records []*record_message.RecordMessage) ([]pmetric.Metrics, error) {
+	result, _, err := c.metricsFromRecords(records, false)
+	return result, err
+}
+
+// MetricsWithSeriesIDsFrom is like MetricsFrom but also returns, for each
+// [pmetric.Metrics], the stable series IDs of its data points when the
+// producer has been configured with config.WithSeriesID.
+func (c *Consumer) MetricsWithSeriesIDsFrom(bar *colarspb.BatchArrowRecords) ([]pmetric.Metrics, []*metricsotlp.SeriesIDs, error) {
+	records, err := c.Consume(bar)
+	if err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+	return c.metricsFromRecords(records, true)
+}
 
+func (c *Consumer) metricsFromRecords(records []*record_message.RecordMessage, withSeriesIDs bool) ([]pmetric.Metrics, []*metricsotlp.SeriesIDs, error) {
 	result := make([]pmetric.Metrics, 0, len(records))
+	var seriesIDs []*metricsotlp.SeriesIDs
 
-	// builds the related entities (i.e. Attributes, Summaries, Histograms, ...)
-	// from the records and returns the main record.
-	relatedData, metricsRecord, err := metricsotlp.RelatedDataFrom(records)
+	relatedData, metricsRecord, err := c.metricsRelatedData(records)
 	if err != nil {
-		return nil, werror.Wrap(err)
+		return nil, nil, werror.Wrap(err)
 	}
 
 	// Process the main record with the related entities.
 	if metricsRecord != nil {
 		// Decode OTLP metrics from the combination of the main record and the
 		// related records.
-		metrics, err := metricsotlp.MetricsFrom(metricsRecord.Record(), relatedData)
+		var metrics pmetric.Metrics
+		var ids *metricsotlp.SeriesIDs
+		if withSeriesIDs {
+			metrics, ids, err = metricsotlp.MetricsWithSeriesIDsFrom(metricsRecord.Record(), relatedData)
+		} else {
+			metrics, err = metricsotlp.MetricsFrom(metricsRecord.Record(), relatedData)
+		}
 		if err != nil {
-			return nil, werror.Wrap(err)
+			return nil, nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument, werror.PayloadType(metricsRecord.PayloadType())))
+		}
+		if c.strict {
+			if err := checkMetricsFidelity(metrics); err != nil {
+				return nil, nil, werror.Wrap(werror.WithCode(err, werror.CodeUnsupported))
+			}
 		}
 		result = append(result, metrics)
+		if withSeriesIDs {
+			seriesIDs = append(seriesIDs, ids)
+		}
 	}
 
-	return result, nil
+	return result, seriesIDs, nil
+}
+
+// MultivariateMetricsFrom decodes a BatchArrowRecords message and groups the
+// number data points sharing their timestamps and attributes, except the
+// given multivariate key, i.e. it reconstructs the multivariate metrics
+// encoded as univariate metrics (see metrics.MultivariateMetricsFrom). The
+// other metric types are ignored.
+func (c *Consumer) MultivariateMetricsFrom(bar *colarspb.BatchArrowRecords, multivariateKey string) ([]metrics.MultivariateMetric, error) {
+	result, err := c.MetricsFrom(bar)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	var mvMetrics []metrics.MultivariateMetric
+	for _, m := range result {
+		mvMetrics = append(mvMetrics, metrics.MultivariateMetricsFrom(m, multivariateKey)...)
+	}
+	return mvMetrics, nil
+}
+
+// metricsRelatedData builds the related entities (i.e. Attributes, Summaries,
+// Histograms, ...) from the records and returns the main record.
+func (c *Consumer) metricsRelatedData(records []*record_message.RecordMessage) (*metricsotlp.RelatedData, *record_message.RecordMessage, error) {
+	return metricsotlp.RelatedDataFrom(records, c.decodeConcurrency, c.attrsCache)
 }
 
 // LogsFrom produces an array of [plog.Logs] from a BatchArrowRecords message.
@@ -112,18 +497,30 @@ func (c *Consumer) LogsFrom(bar *colarspb.BatchArrowRecords) ([]plog.Logs, error
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	return c.LogsFromRecords(records)
+}
 
+// LogsFromRecords produces an array of [plog.Logs] from the records of a
+// batch. The records are released by this method.
+func (c *Consumer) LogsFromRecords(records []*record_message.RecordMessage) ([]plog.Logs, error) {
 	result := make([]plog.Logs, 0, len(records))
 
-	// Compute all related records (i.e. Attributes)
-	relatedData, logsRecord, err := logsotlp.RelatedDataFrom(records)
+	relatedData, logsRecord, err := c.logsRelatedData(records)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
 
 	if logsRecord != nil {
 		// Decode OTLP logs from the combination of the main record and the
 		// related records.
 		logs, err := logsotlp.LogsFrom(logsRecord.Record(), relatedData)
 		if err != nil {
-			return nil, werror.Wrap(err)
+			return nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument, werror.PayloadType(logsRecord.PayloadType())))
+		}
+		if c.strict {
+			if err := checkLogsFidelity(logs); err != nil {
+				return nil, werror.Wrap(werror.WithCode(err, werror.CodeUnsupported))
+			}
 		}
 		result = append(result, logs)
 	}
@@ -131,24 +528,54 @@ func (c *Consumer) LogsFrom(bar *colarspb.BatchArrowRecords) ([]plog.Logs, error
 	return result, nil
 }
 
+// logsRelatedData takes the trace ID index, aggregates the log metrics and
+// computes all the related records (i.e. Attributes) of the records. The main
+// record is returned.
+func (c *Consumer) logsRelatedData(records []*record_message.RecordMessage) (*logsotlp.RelatedData, *record_message.RecordMessage, error) {
+	records, err := c.takeTraceIDIndex(records)
+	if err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+
+	if c.logMetrics != nil {
+		if err := c.logMetrics.Aggregate(records); err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+	}
+
+	return logsotlp.RelatedDataFrom(records, c.decodeConcurrency, c.attrsCache)
+}
+
 // TracesFrom produces an array of [ptrace.Traces] from a BatchArrowRecords message.
 func (c *Consumer) TracesFrom(bar *colarspb.BatchArrowRecords) ([]ptrace.Traces, error) {
 	records, err := c.Consume(bar)
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	return c.TracesFromRecords(records)
+}
 
+// TracesFromRecords produces an array of [ptrace.Traces] from the records of
+// a batch. The records are released by this method.
+func (c *Consumer) TracesFromRecords(records []*record_message.RecordMessage) ([]ptrace.Traces, error) {
 	result := make([]ptrace.Traces, 0, len(records))
 
-	// Compute all related records (i.e. Attributes, Events, and Links)
-	relatedData, tracesRecord, err := tracesotlp.RelatedDataFrom(records, c.tracesConfig)
+	relatedData, tracesRecord, err := c.tracesRelatedData(records)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
 
 	if tracesRecord != nil {
 		// Decode OTLP traces from the combination of the main record and the
 		// related records.
 		traces, err := tracesotlp.TracesFrom(tracesRecord.Record(), relatedData)
 		if err != nil {
-			return nil, werror.Wrap(err)
+			return nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument, werror.PayloadType(tracesRecord.PayloadType())))
+		}
+		if c.strict {
+			if err := checkTracesFidelity(traces); err != nil {
+				return nil, werror.Wrap(werror.WithCode(err, werror.CodeUnsupported))
+			}
 		}
 		result = append(result, traces)
 	}
@@ -156,13 +583,106 @@ func (c *Consumer) TracesFrom(bar *colarspb.BatchArrowRecords) ([]ptrace.Traces,
 	return result, nil
 }
 
+// tracesRelatedData takes the trace ID index, aggregates the span metrics and
+// the service graph, tracks the trace completeness, and computes all the related records (i.e. Attributes,
+// Events, and Links) of the records. The main record is returned.
+func (c *Consumer) tracesRelatedData(records []*record_message.RecordMessage) (*tracesotlp.RelatedData, *record_message.RecordMessage, error) {
+	records, err := c.takeTraceIDIndex(records)
+	if err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+
+	if c.spanMetrics != nil {
+		if err := c.spanMetrics.Aggregate(records); err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+	}
+	if c.serviceGraph != nil {
+		if err := c.serviceGraph.Aggregate(records); err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+	}
+	if c.completeness != nil {
+		if err := c.completeness.Track(records); err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+	}
+
+	return tracesotlp.RelatedDataFrom(records, c.tracesConfig, c.decodeConcurrency, c.attrsCache)
+}
+
+// TraceIDIndex returns the trace ID index of the last batch decoded by
+// TracesFrom or LogsFrom, or nil if the producer did not include one (see
+// config.WithTraceIDIndex).
+func (c *Consumer) TraceIDIndex() TraceIDIndex {
+	return c.traceIDIndex
+}
+
+// takeTraceIDIndex decodes and removes the TRACE_ID_INDEX record from the
+// records of a batch.
+func (c *Consumer) takeTraceIDIndex(records []*record_message.RecordMessage) ([]*record_message.RecordMessage, error) {
+	c.traceIDIndex = nil
+
+	others := records[:0]
+	for _, rm := range records {
+		if rm.PayloadType() != colarspb.ArrowPayloadType_TRACE_ID_INDEX {
+			others = append(others, rm)
+			continue
+		}
+		index, err := TraceIDIndexFrom(rm.Record())
+		rm.Record().Release()
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		c.traceIDIndex = index
+	}
+	return others, nil
+}
+
+// SchemaResets returns the number of schema resets observed by the consumer,
+// i.e. the number of times a payload type changed of schema ID, which forces
+// the decoding of a new schema and new dictionaries.
+func (c *Consumer) SchemaResets() uint64 {
+	return c.schemaResets
+}
+
+// GetAndResetStats returns the stats of the consumer and resets them to zero
+// (see stats.Stats to accumulate them).
+func (c *Consumer) GetAndResetStats() pstats.ConsumerStats {
+	return c.stats.GetAndReset()
+}
+
+// checkEncryptedBatch checks that a decrypted payload was encrypted by the
+// producer of the previous payloads and, for the first payload of a batch,
+// that the batch was not received before.
+func (c *Consumer) checkEncryptedBatch(streamID []byte, batchID int64, first bool) error {
+	switch {
+	case c.encryptionStreamID == nil:
+		c.encryptionStreamID = bytes.Clone(streamID)
+	case !bytes.Equal(c.encryptionStreamID, streamID):
+		return werror.Wrap(encryption.ErrStreamMismatch)
+	case first && batchID <= c.lastEncryptedBatch:
+		return werror.WrapWithContext(encryption.ErrReplayedBatch, map[string]interface{}{"batch_id": batchID})
+	}
+	c.lastEncryptedBatch = batchID
+	return nil
+}
+
 // Consume takes a BatchArrowRecords protobuf message and returns an array of RecordMessage.
 // Note: the records wrapped in the RecordMessage must be released after use by the caller.
 func (c *Consumer) Consume(bar *colarspb.BatchArrowRecords) ([]*record_message.RecordMessage, error) {
 	var ibes []*record_message.RecordMessage
 
+	if c.expansion != nil {
+		compressed := 0
+		for _, payload := range bar.ArrowPayloads {
+			compressed += len(payload.Record)
+		}
+		c.expansion.BeginBatch(compressed)
+	}
+
 	// Transform each individual OtlpArrowPayload into RecordMessage
-	for _, payload := range bar.ArrowPayloads {
+	for i, payload := range bar.ArrowPayloads {
 		// Retrieves (or creates) the stream consumer for the schema id defined in the BatchArrowRecords message.
 		sc := c.streamConsumers[payload.SchemaId]
 		if sc == nil {
@@ -177,38 +697,109 @@ func (c *Consumer) Consume(bar *colarspb.BatchArrowRecords) ([]*record_message.R
 				if sc.payloadType == payload.Type {
 					sc.ipcReader.Release()
 					delete(c.streamConsumers, scID)
+					c.schemaResets++
 				}
 			}
 
 			bufReader := bytes.NewReader([]byte{})
+			c.nextStreamID++
 			sc = &streamConsumer{
+				id:          c.nextStreamID,
 				bufReader:   bufReader,
 				payloadType: payload.Type,
+				recording:   newStreamRecording(c.streamRecording),
 			}
 			c.streamConsumers[payload.SchemaId] = sc
+			c.stats.StreamConsumersCreated++
 		}
 
-		sc.bufReader.Reset(payload.Record)
+		record := payload.Record
+		if c.decryption != nil {
+			var streamID []byte
+			var err error
+			record, streamID, err = encryption.Open(c.decryption, record, payloadAAD(bar.BatchId, payload.SchemaId, payload.Type))
+			if err == nil {
+				err = c.checkEncryptedBatch(streamID, bar.BatchId, i == 0)
+			}
+			if err != nil {
+				return nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument, werror.PayloadType(payload.Type)))
+			}
+		}
+
+		c.stats.PayloadSize(payload.Type.String()).Record(len(payload.Record))
+		sc.lastConsumption = time.Now()
+		sc.recording.record(record)
+		sc.bufReader.Reset(record)
+		if c.expansion != nil {
+			c.expansion.BeginPayload(len(record))
+		}
 		if sc.ipcReader == nil {
+			var base memory.Allocator = memory.NewGoAllocator()
+			if c.mmap != nil {
+				base = c.mmap
+			}
+			sc.allocator = common.NewLimitedAllocator(base, c.memLimit)
+			var mem memory.Allocator = sc.allocator
+			if c.expansion != nil {
+				mem = c.expansion.Allocator(mem)
+			}
 			ipcReader, err := ipc.NewReader(
 				sc.bufReader,
-				ipc.WithAllocator(common.NewLimitedAllocator(memory.NewGoAllocator(), c.memLimit)),
+				ipc.WithAllocator(mem),
 				ipc.WithDictionaryDeltas(true),
 				ipc.WithZstd(),
 			)
 			if err != nil {
-				return nil, werror.Wrap(err)
+				return nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument, werror.PayloadType(payload.Type)))
 			}
 			sc.ipcReader = ipcReader
 		}
 
+		cacheable := c.attrsCache != nil && isCacheablePayload(payload.Type)
+		if cacheable {
+			if hasDicts, err := hasDictionaries(record); err != nil || hasDicts {
+				sc.dictGeneration++
+			}
+		}
+
 		if sc.ipcReader.Next() {
 			rec := sc.ipcReader.Record()
-			// The record returned by Reader.Record() is owned by the Reader.
-			// We need to retain it to be able to use it after the Reader is closed
-			// or after the next call to Reader.Next().
-			rec.Retain()
-			ibes = append(ibes, record_message.NewRecordMessage(bar.BatchId, payload.GetType(), rec))
+
+			if !sc.schemaChecked {
+				if err := c.checkSchema(payload.SchemaId, sc, rec.Schema()); err != nil {
+					return nil, werror.Wrap(werror.WithCode(err, werror.CodeUnsupported, werror.PayloadType(payload.Type)))
+				}
+			}
+
+			if sc.knownColumns != nil {
+				// Skip the unknown columns. The projected record shares the
+				// known columns with the record owned by the Reader.
+				cols := make([]arrow.Array, len(sc.knownColumns))
+				for i, colIdx := range sc.knownColumns {
+					cols[i] = rec.Column(colIdx)
+				}
+				rec = array.NewRecord(sc.knownSchema, cols, rec.NumRows())
+			} else {
+				// The record returned by Reader.Record() is owned by the Reader.
+				// We need to retain it to be able to use it after the Reader is closed
+				// or after the next call to Reader.Next().
+				rec.Retain()
+			}
+			c.stats.PayloadRows[payload.Type.String()] += uint64(rec.NumRows())
+			rm := record_message.NewRecordMessage(bar.BatchId, payload.GetType(), rec)
+			if cacheable {
+				rm.SetCacheKey(attrsCacheKey(sc.id, sc.dictGeneration, record))
+			}
+			ibes = append(ibes, rm)
+		} else if limitErr := sc.allocator.LimitExceeded(); limitErr != nil {
+			// The IPC reader recovers the allocator panics. The stream
+			// state is incomplete, the stream can't be decoded anymore.
+			c.dropStream(payload.SchemaId, sc, ibes)
+			return nil, werror.Wrap(werror.WithCode(limitErr, werror.CodeResourceExhausted, werror.PayloadType(payload.Type)))
+		} else if expansionErr := c.expansionExceeded(); expansionErr != nil {
+			// The payload is invalid and is not retryable.
+			c.dropStream(payload.SchemaId, sc, ibes)
+			return nil, werror.Wrap(werror.WithCode(expansionErr, werror.CodeInvalidArgument, werror.PayloadType(payload.Type)))
 		}
 	}
 
@@ -218,9 +809,127 @@ func (c *Consumer) Consume(bar *colarspb.BatchArrowRecords) ([]*record_message.R
 			"Please consider to increase the memory limit of the consumer.")
 	}
 
+	if c.sanitizer != nil {
+		c.sanitizer.Sanitize(ibes)
+	}
+	if c.redactor != nil {
+		if err := c.redactor.Redact(ibes, c.redaction); err != nil {
+			for _, rm := range ibes {
+				rm.Record().Release()
+			}
+			return nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument))
+		}
+	}
+
+	c.stats.BatchesConsumed++
 	return ibes, nil
 }
 
+// dropStream releases the records already decoded from a batch and closes the
+// stream of a payload that can't be decoded anymore.
+func (c *Consumer) dropStream(schemaID string, sc *streamConsumer, ibes []*record_message.RecordMessage) {
+	for _, rm := range ibes {
+		rm.Record().Release()
+	}
+	sc.ipcReader.Release()
+	delete(c.streamConsumers, schemaID)
+}
+
+// expansionExceeded returns the error of the last allocation exceeding the
+// expansion limit of the consumer, nil if there was none.
+func (c *Consumer) expansionExceeded() error {
+	if c.expansion == nil {
+		return nil
+	}
+	return c.expansion.Exceeded()
+}
+
+// isCacheablePayload returns true for the payload types decoded through the
+// attributes cache.
+func isCacheablePayload(payloadType record_message.PayloadType) bool {
+	return payloadType == colarspb.ArrowPayloadType_RESOURCE_ATTRS ||
+		payloadType == colarspb.ArrowPayloadType_SCOPE_ATTRS
+}
+
+// attrsCacheKey returns the cache key of a payload received by a stream
+// consumer. The dictionary columns of a record are indices into the
+// dictionaries of its stream, so a payload only decodes to the same attributes
+// as an identical previous payload of the same stream if no dictionary batch
+// has been received in-between, i.e. if the dictionary generation is the same.
+// A payload carrying dictionary batches starts a new generation itself, its
+// decoding depending on the dictionaries it replaces or extends.
+func attrsCacheKey(streamID uint64, dictGeneration uint64, payload []byte) string {
+	digest := sha256.Sum256(payload)
+	return fmt.Sprintf("%d/%d/%x", streamID, dictGeneration, digest)
+}
+
+// hasDictionaries returns true if the IPC payload contains a dictionary batch.
+func hasDictionaries(payload []byte) (bool, error) {
+	reader := ipc.NewMessageReader(bytes.NewReader(payload))
+	defer reader.Release()
+
+	for {
+		msg, err := reader.Message()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, werror.Wrap(err)
+		}
+		if msg.Type() == ipc.MessageDictionaryBatch {
+			return true, nil
+		}
+	}
+}
+
+// checkSchema compares the schema of a new stream with the prototype schema of
+// its payload type. Unknown columns are reported once per schema and skipped,
+// or rejected in strict mode. Unknown payload types are left to the decoders.
+func (c *Consumer) checkSchema(schemaID string, sc *streamConsumer, schema *arrow.Schema) error {
+	prototype, ok := PrototypeSchemas()[sc.payloadType]
+	if !ok {
+		sc.schemaChecked = true
+		return nil
+	}
+
+	unknown := unknownColumns(prototype, schema)
+	if len(unknown) == 0 {
+		sc.schemaChecked = true
+		return nil
+	}
+
+	err := werror.WrapWithContext(otel.ErrUnknownColumns, map[string]interface{}{
+		"payload_type": sc.payloadType.String(),
+		"schema_id":    schemaID,
+		"columns":      unknown,
+	})
+	if c.strictSchema {
+		return err
+	}
+	if c.unknownColumns != nil {
+		c.unknownColumns(err)
+	}
+
+	// Only the top-level columns are removed from the records, unknown
+	// nested fields are ignored by the decoders.
+	fields := make([]arrow.Field, 0, len(schema.Fields()))
+	knownColumns := make([]int, 0, len(schema.Fields()))
+	for i, field := range schema.Fields() {
+		if len(prototype.FieldIndices(field.Name)) > 0 {
+			fields = append(fields, field)
+			knownColumns = append(knownColumns, i)
+		}
+	}
+	if len(knownColumns) < len(schema.Fields()) {
+		metadata := schema.Metadata()
+		sc.knownSchema = arrow.NewSchema(fields, &metadata)
+		sc.knownColumns = knownColumns
+	}
+	sc.schemaChecked = true
+
+	return nil
+}
+
 // Close closes the consumer and all its ipc readers.
 func (c *Consumer) Close() error {
 	for _, sc := range c.streamConsumers {
@@ -0,0 +1,397 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+)
+
+func TestConsumerSkipsUnknownColumns(t *testing.T) {
+	t.Parallel()
+
+	logs := GenerateLogs(0, 10)
+	bar := batchWithUnknownColumn(t, logs)
+
+	var reported []error
+	consumer := NewConsumerWithOptions(WithUnknownColumnsHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	received, err := consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+	assert.Equiv(t,
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+	)
+	require.Equal(t, 1, len(reported))
+	require.True(t, errors.Is(reported[0], otel.ErrUnknownColumns))
+}
+
+func TestStrictConsumerRejectsUnknownColumns(t *testing.T) {
+	t.Parallel()
+
+	bar := batchWithUnknownColumn(t, GenerateLogs(0, 10))
+
+	consumer := NewConsumerWithOptions(WithStrictSchema())
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	_, err := consumer.LogsFrom(bar)
+	require.True(t, errors.Is(err, otel.ErrUnknownColumns))
+}
+
+func TestConsumerDecryptsPayloads(t *testing.T) {
+	t.Parallel()
+
+	keys, err := encryption.NewStaticKeyProvider("k1", map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+	otherKeys, err := encryption.NewStaticKeyProvider("k1", map[string][]byte{"k1": bytes.Repeat([]byte{2}, 32)})
+	require.NoError(t, err)
+
+	producer := NewProducerWithOptions(config.WithEncryption(keys))
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	logs := GenerateLogs(0, 10)
+	bar, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	consumer := NewConsumerWithOptions(WithDecryption(keys))
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	received, err := consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+	assert.Equiv(t,
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+	)
+
+	// A batch can't be replayed.
+	_, err = consumer.LogsFrom(bar)
+	require.True(t, errors.Is(err, encryption.ErrReplayedBatch))
+
+	next, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	_, err = consumer.LogsFrom(next)
+	require.NoError(t, err)
+
+	// The payloads of another producer are rejected, even when they
+	// are encrypted with the same key.
+	otherProducer := NewProducerWithOptions(config.WithEncryption(keys))
+	defer func() { require.NoError(t, otherProducer.Close()) }()
+
+	other, err := otherProducer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	_, err = consumer.LogsFrom(other)
+	require.True(t, errors.Is(err, encryption.ErrStreamMismatch))
+
+	// A consumer with another key can't decrypt the payloads.
+	wrongConsumer := NewConsumerWithOptions(WithDecryption(otherKeys))
+	defer func() { require.NoError(t, wrongConsumer.Close()) }()
+
+	_, err = wrongConsumer.LogsFrom(bar)
+	require.True(t, errors.Is(err, encryption.ErrInvalidEnvelope))
+}
+
+func TestAttributeHashing(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("secret")
+	producer := NewProducerWithOptions(config.WithAttributeHashing(key, "user.id"))
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, user := range []string{"alice", "bob", "alice"} {
+		record := records.AppendEmpty()
+		record.Attributes().PutStr("user.id", user)
+		record.Attributes().PutStr("http.method", "GET")
+	}
+
+	bar, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	received, err := consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	hash := func(value string) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	users := map[string]int{}
+	receivedRecords := received[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := 0; i < receivedRecords.Len(); i++ {
+		attrs := receivedRecords.At(i).Attributes()
+		user, _ := attrs.Get("user.id")
+		users[user.Str()]++
+		method, _ := attrs.Get("http.method")
+		require.Equal(t, "GET", method.Str())
+	}
+	require.Equal(t, map[string]int{hash("alice"): 2, hash("bob"): 1}, users)
+}
+
+func TestLogTemplates(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithLogTemplates())
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	bodies := []string{
+		"connection from 10.0.0.1 port 5432 accepted",
+		"connection from 10.0.0.2 port 5433 accepted",
+		"connection from 10.0.0.2  port 5433 closed",
+		"cache miss",
+		"single",
+		"literal <*> wildcard",
+		"",
+	}
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, body := range bodies {
+		records.AppendEmpty().Body().SetStr(body)
+	}
+
+	bar, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	received, err := consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	var receivedBodies []string
+	receivedRecords := received[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := 0; i < receivedRecords.Len(); i++ {
+		receivedBodies = append(receivedBodies, receivedRecords.At(i).Body().Str())
+	}
+	require.ElementsMatch(t, bodies, receivedBodies)
+}
+
+func TestStrictProducer(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithStrict())
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	logs := plog.NewLogs()
+	record := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.Attributes().PutStr("http.method", "GET")
+	_, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	// Attribute without value.
+	record.Attributes().PutEmpty("unset")
+	_, err = producer.BatchArrowRecordsFromLogs(logs)
+	require.True(t, errors.Is(err, otel.ErrLossyConversion))
+
+	// Metric of unknown type.
+	metrics := pmetric.NewMetrics()
+	metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("untyped")
+	_, err = producer.BatchArrowRecordsFromMetrics(metrics)
+	require.True(t, errors.Is(err, otel.ErrLossyConversion))
+
+	// Exemplar without value.
+	metrics = pmetric.NewMetrics()
+	metric := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	metric.SetName("requests")
+	dp := metric.SetEmptySum().DataPoints().AppendEmpty()
+	dp.SetIntValue(1)
+	dp.Exemplars().AppendEmpty()
+	_, err = producer.BatchArrowRecordsFromMetrics(metrics)
+	require.True(t, errors.Is(err, otel.ErrLossyConversion))
+}
+
+func TestStrictConsumer(t *testing.T) {
+	t.Parallel()
+
+	// A non-strict producer encodes the metric of unknown type, which is
+	// decoded as a metric without data.
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	metrics := pmetric.NewMetrics()
+	metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("untyped")
+	bar, err := producer.BatchArrowRecordsFromMetrics(metrics)
+	require.NoError(t, err)
+
+	consumer := NewConsumerWithOptions(WithStrict())
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	_, err = consumer.MetricsFrom(bar)
+	require.True(t, errors.Is(err, otel.ErrLossyConversion))
+}
+
+// batchWithUnknownColumn encodes the given logs and adds a column unknown to
+// the consumer to the main logs record, as a newer producer would do.
+func batchWithUnknownColumn(t *testing.T, logs plog.Logs) *colarspb.BatchArrowRecords {
+	producer := NewProducerWithOptions(config.WithNoZstd())
+	defer func() { require.NoError(t, producer.Close()) }()
+	bar, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+	rms, err := consumer.Consume(bar)
+	require.NoError(t, err)
+
+	newRms := make([]*record_message.RecordMessage, 0, len(rms))
+	for _, rm := range rms {
+		record := rm.Record()
+		if rm.PayloadType() == colarspb.ArrowPayloadType_LOGS {
+			record = withExtraColumn(record)
+			rm.Record().Release()
+		}
+		newRms = append(newRms, record_message.NewRelatedDataMessage(rm.PayloadType().String(), record, rm.PayloadType()))
+	}
+
+	newProducer := NewProducerWithOptions(config.WithNoZstd())
+	defer func() { require.NoError(t, newProducer.Close()) }()
+	bar, err = newProducer.Produce(newRms)
+	require.NoError(t, err)
+	return bar
+}
+
+func withExtraColumn(record arrow.Record) arrow.Record {
+	b := array.NewInt64Builder(memory.NewGoAllocator())
+	defer b.Release()
+	for i := 0; i < int(record.NumRows()); i++ {
+		b.Append(int64(i))
+	}
+	extra := b.NewArray()
+	defer extra.Release()
+
+	fields := append(append([]arrow.Field{}, record.Schema().Fields()...), arrow.Field{Name: "future_field", Type: arrow.PrimitiveTypes.Int64})
+	cols := append(append([]arrow.Array{}, record.Columns()...), extra)
+	return array.NewRecord(arrow.NewSchema(fields, nil), cols, record.NumRows())
+}
+
+func TestConsumerProducerHints(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+	bar, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+	_, err = consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Nil(t, consumer.ProducerHints())
+
+	// Any memory use is above the threshold of the hinting consumer.
+	hinting := NewConsumerWithOptions(WithProducerHints(1e-12, "logs.body.str"))
+	defer func() { require.NoError(t, hinting.Close()) }()
+	require.Equal(t, &ProducerHints{PlainEncodingFields: []string{"logs.body.str"}}, hinting.ProducerHints())
+	require.Nil(t, hinting.ProducerHints())
+
+	_, err = hinting.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Equal(t, &ProducerHints{ResetDictionaries: true}, hinting.ProducerHints())
+}
+
+func TestConsumerSchemaResets(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("hello")
+	bar, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	_, err = consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), consumer.SchemaResets())
+
+	// A new field changes the schema of the logs payload.
+	logRecord := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	logRecord.SetSeverityText("INFO")
+	logRecord.Attributes().PutStr("key", "value")
+	bar, err = producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	_, err = consumer.LogsFrom(bar)
+	require.NoError(t, err)
+	require.NotZero(t, consumer.SchemaResets())
+}
+
+func TestHasDictionaries(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewGoAllocator()
+	encode := func(dt arrow.DataType, appendValue func(array.Builder)) []byte {
+		schema := arrow.NewSchema([]arrow.Field{{Name: "value", Type: dt}}, nil)
+		rb := array.NewRecordBuilder(pool, schema)
+		defer rb.Release()
+		appendValue(rb.Field(0))
+		record := rb.NewRecord()
+		defer record.Release()
+
+		var buf bytes.Buffer
+		writer := ipc.NewWriter(&buf, ipc.WithSchema(schema), ipc.WithAllocator(pool), ipc.WithDictionaryDeltas(true))
+		require.NoError(t, writer.Write(record))
+		require.NoError(t, writer.Close())
+		return buf.Bytes()
+	}
+
+	withDict := encode(&arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Uint8, ValueType: arrow.BinaryTypes.String}, func(b array.Builder) {
+		require.NoError(t, b.(*array.BinaryDictionaryBuilder).AppendString("service"))
+	})
+	hasDicts, err := hasDictionaries(withDict)
+	require.NoError(t, err)
+	require.True(t, hasDicts)
+
+	withoutDict := encode(arrow.PrimitiveTypes.Uint16, func(b array.Builder) {
+		b.(*array.Uint16Builder).Append(1)
+	})
+	hasDicts, err = hasDictionaries(withoutDict)
+	require.NoError(t, err)
+	require.False(t, hasDicts)
+}
@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import "time"
+
+// cpuBudgetWindow is the period over which the time spent encoding is compared
+// to the CPU budget (see config.WithCPUBudget).
+const cpuBudgetWindow = 10 * time.Second
+
+// Encoding levels of a Producer with a CPU budget, from the most to the least
+// expensive.
+const (
+	// EncodingFull is the configured encoding.
+	EncodingFull = iota
+	// EncodingNoTransforms suspends the optional transforms (e.g. the log
+	// templates).
+	EncodingNoTransforms
+	// EncodingNoCompression also disables the IPC compression.
+	EncodingNoCompression
+)
+
+// cpuBudget measures the fraction of the wall-clock time spent encoding and
+// derives the encoding level of the Producer. A nil cpuBudget means no budget.
+type cpuBudget struct {
+	budget float64
+
+	windowStart time.Time
+	spent       time.Duration
+	level       int
+}
+
+func newCPUBudget(budget float64) *cpuBudget {
+	if budget <= 0 {
+		return nil
+	}
+	return &cpuBudget{budget: budget}
+}
+
+// charge adds the time spent encoding a batch. At the end of each window, the
+// level is degraded by one step if the budget was exceeded, or restored by one
+// step if less than half of the budget was used. It returns the new level and
+// whether it changed.
+func (b *cpuBudget) charge(now time.Time, spent time.Duration) (level int, changed bool) {
+	if b.windowStart.IsZero() {
+		b.windowStart = now.Add(-spent)
+	}
+	b.spent += spent
+
+	elapsed := now.Sub(b.windowStart)
+	if elapsed < cpuBudgetWindow {
+		return b.level, false
+	}
+
+	used := float64(b.spent) / float64(elapsed)
+	b.windowStart = now
+	b.spent = 0
+
+	switch {
+	case used > b.budget && b.level < EncodingNoCompression:
+		b.level++
+		return b.level, true
+	case used < b.budget/2 && b.level > EncodingFull:
+		b.level--
+		return b.level, true
+	}
+	return b.level, false
+}
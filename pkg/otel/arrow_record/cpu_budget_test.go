@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+)
+
+func TestCPUBudget(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, newCPUBudget(0))
+
+	b := newCPUBudget(0.1)
+	now := time.Now()
+
+	// 2s spent over a 10s window exceeds the budget.
+	level, changed := b.charge(now, time.Second)
+	require.Equal(t, EncodingFull, level)
+	require.False(t, changed)
+	now = now.Add(cpuBudgetWindow - time.Second)
+	level, changed = b.charge(now, time.Second)
+	require.Equal(t, EncodingNoTransforms, level)
+	require.True(t, changed)
+
+	now = now.Add(cpuBudgetWindow)
+	level, changed = b.charge(now, 2*time.Second)
+	require.Equal(t, EncodingNoCompression, level)
+	require.True(t, changed)
+
+	// The level can't be degraded further.
+	now = now.Add(cpuBudgetWindow)
+	level, changed = b.charge(now, 2*time.Second)
+	require.Equal(t, EncodingNoCompression, level)
+	require.False(t, changed)
+
+	// Within the budget but above half of it, the level is kept.
+	now = now.Add(cpuBudgetWindow)
+	level, changed = b.charge(now, 700*time.Millisecond)
+	require.Equal(t, EncodingNoCompression, level)
+	require.False(t, changed)
+
+	// Well below the budget, the encoding is restored step by step.
+	now = now.Add(cpuBudgetWindow)
+	level, changed = b.charge(now, 100*time.Millisecond)
+	require.Equal(t, EncodingNoTransforms, level)
+	require.True(t, changed)
+	now = now.Add(cpuBudgetWindow)
+	level, changed = b.charge(now, 100*time.Millisecond)
+	require.Equal(t, EncodingFull, level)
+	require.True(t, changed)
+}
+
+func TestProducerEncodingLevel(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithCPUBudget(0.5), config.WithLogTemplates())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	roundTrip := func() string {
+		logs := GenerateLogs(0, 10)
+		bar, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+		received, err := consumer.LogsFrom(bar)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+		)
+		return bar.ArrowPayloads[0].SchemaId
+	}
+
+	require.Equal(t, EncodingFull, producer.EncodingLevel())
+	schemaID := roundTrip()
+
+	// Suspending the transforms keeps the streams.
+	require.NoError(t, producer.setEncodingLevel(EncodingNoTransforms))
+	require.Equal(t, schemaID, roundTrip())
+
+	// Disabling the compression restarts the streams.
+	require.NoError(t, producer.setEncodingLevel(EncodingNoCompression))
+	require.False(t, producer.zstd)
+	uncompressedSchemaID := roundTrip()
+	require.NotEqual(t, schemaID, uncompressedSchemaID)
+
+	require.NoError(t, producer.setEncodingLevel(EncodingFull))
+	require.True(t, producer.zstd)
+	require.NotEqual(t, uncompressedSchemaID, roundTrip())
+}
@@ -0,0 +1,222 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Dry runs of the encoding of OTLP data.
+//
+// A DryRun encodes sample data with a given set of producer options and
+// reports the resulting Arrow schemas, the encoding of their fields and the
+// sizes of the serialized records, the encoded batches are discarded. This
+// allows to preview the effect of a configuration change (e.g. encoding
+// overrides, optional columns, sorting) before deploying it.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+type (
+	// DryRun encodes OTLP data without producing any output.
+	DryRun struct {
+		producer *Producer
+		// schemas are the schemas of the records of the batch being
+		// encoded, in the order of its payloads.
+		schemas []*arrow.Schema
+		report  DryRunReport
+	}
+
+	// DryRunReport is the result of a dry run.
+	DryRunReport struct {
+		Batches int `json:"batches"`
+		// OTLPBytes is the size of the OTLP protobuf encoding of the data.
+		OTLPBytes int `json:"otlp_bytes"`
+		// ArrowBytes is the size of the serialized Arrow records of all
+		// the batches.
+		ArrowBytes int              `json:"arrow_bytes"`
+		Payloads   []*PayloadReport `json:"payloads"`
+	}
+
+	// PayloadReport describes the records of a payload type.
+	PayloadReport struct {
+		PayloadType string `json:"payload_type"`
+		// Schema is the schema of the last record of the payload type.
+		Schema string          `json:"schema"`
+		Fields []FieldEncoding `json:"fields"`
+		// SchemaUpdates is the number of schema changes after the first
+		// record.
+		SchemaUpdates int   `json:"schema_updates"`
+		Rows          int64 `json:"rows"`
+		Bytes         int   `json:"bytes"`
+
+		schema *arrow.Schema
+	}
+
+	// FieldEncoding is the encoding of a leaf field of a schema.
+	FieldEncoding struct {
+		// Path is the lower case name of the payload type followed by
+		// the dot separated path of the field, as used by
+		// config.WithEncodingOverrides.
+		Path string `json:"path"`
+		Type string `json:"type"`
+		// Encoding is "plain", "dictionary8" or "dictionary16".
+		Encoding string `json:"encoding"`
+	}
+)
+
+var _ ProducerObserver = (*DryRun)(nil)
+
+// NewDryRun creates a dry run encoding the data with a producer configured
+// with the given options.
+//
+// The method Close MUST be called when the dry run is not used anymore.
+func NewDryRun(options ...cfg.Option) *DryRun {
+	d := &DryRun{producer: NewProducerWithOptions(options...)}
+	d.producer.SetObserver(d)
+	return d
+}
+
+// Traces encodes a batch of traces.
+func (d *DryRun) Traces(traces ptrace.Traces) error {
+	d.report.OTLPBytes += (&ptrace.ProtoMarshaler{}).TracesSize(traces)
+	_, err := d.producer.BatchArrowRecordsFromTraces(traces)
+	return d.addBatch(err)
+}
+
+// Logs encodes a batch of logs.
+func (d *DryRun) Logs(logs plog.Logs) error {
+	d.report.OTLPBytes += (&plog.ProtoMarshaler{}).LogsSize(logs)
+	_, err := d.producer.BatchArrowRecordsFromLogs(logs)
+	return d.addBatch(err)
+}
+
+// Metrics encodes a batch of metrics.
+func (d *DryRun) Metrics(metrics pmetric.Metrics) error {
+	d.report.OTLPBytes += (&pmetric.ProtoMarshaler{}).MetricsSize(metrics)
+	_, err := d.producer.BatchArrowRecordsFromMetrics(metrics)
+	return d.addBatch(err)
+}
+
+// OnRecord keeps the schema of the records of the batch being encoded.
+func (d *DryRun) OnRecord(record arrow.Record, _ record_message.PayloadType) {
+	d.schemas = append(d.schemas, record.Schema())
+}
+
+// addBatch adds the payloads of the last batch to the report.
+func (d *DryRun) addBatch(err error) error {
+	schemas := d.schemas
+	d.schemas = nil
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	d.report.Batches++
+	for i, info := range d.producer.LastBatchPayloads() {
+		payload := d.payloadReport(info.PayloadType)
+		if i < len(schemas) && !schemas[i].Equal(payload.schema) {
+			if payload.schema != nil {
+				payload.SchemaUpdates++
+			}
+			payload.schema = schemas[i]
+			payload.Schema = schemas[i].String()
+			payload.Fields = fieldEncodings(strings.ToLower(info.PayloadType.String()), schemas[i].Fields())
+		}
+		payload.Rows += info.Rows
+		payload.Bytes += info.Bytes
+		d.report.ArrowBytes += info.Bytes
+	}
+	return nil
+}
+
+func (d *DryRun) payloadReport(payloadType record_message.PayloadType) *PayloadReport {
+	name := payloadType.String()
+	for _, payload := range d.report.Payloads {
+		if payload.PayloadType == name {
+			return payload
+		}
+	}
+	payload := &PayloadReport{PayloadType: name}
+	d.report.Payloads = append(d.report.Payloads, payload)
+	return payload
+}
+
+// Report returns the report of the batches encoded so far.
+func (d *DryRun) Report() *DryRunReport {
+	return &d.report
+}
+
+// Close closes the underlying producer.
+func (d *DryRun) Close() error {
+	return d.producer.Close()
+}
+
+// fieldEncodings returns the encoding of the leaf fields, the fields of the
+// structs and of the list elements are walked recursively.
+func fieldEncodings(path string, fields []arrow.Field) []FieldEncoding {
+	var encodings []FieldEncoding
+	for _, field := range fields {
+		fieldPath := path + "." + field.Name
+		switch dt := field.Type.(type) {
+		case *arrow.StructType:
+			encodings = append(encodings, fieldEncodings(fieldPath, dt.Fields())...)
+		case *arrow.ListType:
+			encodings = append(encodings, fieldEncodings(path, []arrow.Field{{Name: field.Name, Type: dt.Elem()}})...)
+		case *arrow.DictionaryType:
+			encodings = append(encodings, FieldEncoding{
+				Path:     fieldPath,
+				Type:     dt.ValueType.String(),
+				Encoding: fmt.Sprintf("dictionary%d", dt.IndexType.(arrow.FixedWidthDataType).BitWidth()),
+			})
+		case *arrow.RunEndEncodedType:
+			encodings = append(encodings, FieldEncoding{
+				Path:     fieldPath,
+				Type:     dt.Encoded().String(),
+				Encoding: schema.RunEndEncodedEncoding,
+			})
+		default:
+			encodings = append(encodings, FieldEncoding{
+				Path:     fieldPath,
+				Type:     dt.String(),
+				Encoding: schema.PlainEncoding,
+			})
+		}
+	}
+	return encodings
+}
+
+// Show writes the report to w.
+func (r *DryRunReport) Show(w io.Writer) {
+	ratio := 0.0
+	if r.OTLPBytes > 0 {
+		ratio = float64(r.ArrowBytes) / float64(r.OTLPBytes)
+	}
+	fmt.Fprintf(w, "Batches: %d, OTLP: %d bytes, Arrow: %d bytes (%.2f)\n", r.Batches, r.OTLPBytes, r.ArrowBytes, ratio)
+	for _, payload := range r.Payloads {
+		fmt.Fprintf(w, "\n== %s: %d rows, %d bytes, %d schema updates\n", payload.PayloadType, payload.Rows, payload.Bytes, payload.SchemaUpdates)
+		for _, field := range payload.Fields {
+			fmt.Fprintf(w, "  %-50s %-24s %s\n", field.Path, field.Type, field.Encoding)
+		}
+	}
+}
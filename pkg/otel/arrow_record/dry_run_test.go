@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Parallel()
+
+	newLogs := func() plog.Logs {
+		logs := plog.NewLogs()
+		lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for i := 0; i < 10; i++ {
+			lr := lrs.AppendEmpty()
+			lr.SetTimestamp(1)
+			lr.Body().SetStr("body")
+			lr.SetSeverityText("INFO")
+		}
+		return logs
+	}
+	logsPayload := func(report *DryRunReport) *PayloadReport {
+		for _, payload := range report.Payloads {
+			if payload.PayloadType == "LOGS" {
+				return payload
+			}
+		}
+		t.Fatal("LOGS payload not found")
+		return nil
+	}
+	severityText := func(report *DryRunReport) string {
+		for _, field := range logsPayload(report).Fields {
+			if field.Path == "logs.severity_text" {
+				return field.Encoding
+			}
+		}
+		t.Fatal("logs.severity_text field not found")
+		return ""
+	}
+	dryRun := func(options ...config.Option) *DryRunReport {
+		d := NewDryRun(options...)
+		defer func() { require.NoError(t, d.Close()) }()
+		for i := 0; i < 2; i++ {
+			require.NoError(t, d.Logs(newLogs()))
+		}
+		return d.Report()
+	}
+
+	report := dryRun(config.WithNoZstd())
+	require.Equal(t, 2, report.Batches)
+	require.Greater(t, report.OTLPBytes, 0)
+	require.Greater(t, report.ArrowBytes, 0)
+	payload := logsPayload(report)
+	require.Equal(t, int64(20), payload.Rows)
+	require.Equal(t, 0, payload.SchemaUpdates)
+	require.NotEmpty(t, payload.Schema)
+	require.Equal(t, "dictionary8", severityText(report))
+
+	var shown bytes.Buffer
+	report.Show(&shown)
+	require.Contains(t, shown.String(), "Batches: 2,")
+	require.Regexp(t, `logs\.severity_text +\S+ +dictionary8`, shown.String())
+
+	// Preview of an encoding override.
+	report = dryRun(config.WithNoZstd(), config.WithEncodingOverrides(map[string]string{"logs.severity_text": "plain"}))
+	require.Equal(t, "plain", severityText(report))
+}
@@ -0,0 +1,64 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+)
+
+// TestProducerConsumerLogEventNames checks the round trip of the event names
+// of the log records, mixed with log records without event name.
+func TestProducerConsumerLogEventNames(t *testing.T) {
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i, name := range []string{"session.start", "", "session.end", "session.start"} {
+		lr := records.AppendEmpty()
+		lr.SetTimestamp(pcommon.Timestamp(i + 1))
+		lr.SetEventName(name)
+		lr.Body().SetStr("event")
+	}
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	producer := NewProducerWithOptions(config.WithAllocator(pool))
+	defer func() {
+		require.NoError(t, producer.Close())
+	}()
+
+	batch, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	received, err := consumer.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+	)
+}
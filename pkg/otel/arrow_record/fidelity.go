@@ -0,0 +1,220 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Detection of the OTLP entities that can't be converted without loss.
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// checkTracesFidelity returns an otel.ErrLossyConversion error if the given
+// traces contain an entity that the conversion would drop or degrade.
+func checkTracesFidelity(traces ptrace.Traces) error {
+	rss := traces.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		if err := checkAttrsFidelity("resource", rs.Resource().Attributes()); err != nil {
+			return err
+		}
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			ss := sss.At(j)
+			if err := checkAttrsFidelity("scope", ss.Scope().Attributes()); err != nil {
+				return err
+			}
+			spans := ss.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				if err := checkAttrsFidelity("span", span.Attributes()); err != nil {
+					return err
+				}
+				events := span.Events()
+				for l := 0; l < events.Len(); l++ {
+					if err := checkAttrsFidelity("span event", events.At(l).Attributes()); err != nil {
+						return err
+					}
+				}
+				links := span.Links()
+				for l := 0; l < links.Len(); l++ {
+					if err := checkAttrsFidelity("span link", links.At(l).Attributes()); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkLogsFidelity returns an otel.ErrLossyConversion error if the given
+// logs contain an entity that the conversion would drop or degrade.
+func checkLogsFidelity(logs plog.Logs) error {
+	rls := logs.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		rl := rls.At(i)
+		if err := checkAttrsFidelity("resource", rl.Resource().Attributes()); err != nil {
+			return err
+		}
+		sls := rl.ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			sl := sls.At(j)
+			if err := checkAttrsFidelity("scope", sl.Scope().Attributes()); err != nil {
+				return err
+			}
+			records := sl.LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				if err := checkAttrsFidelity("log record", records.At(k).Attributes()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkMetricsFidelity returns an otel.ErrLossyConversion error if the given
+// metrics contain an entity that the conversion would drop or degrade.
+func checkMetricsFidelity(metrics pmetric.Metrics) error {
+	rms := metrics.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		if err := checkAttrsFidelity("resource", rm.Resource().Attributes()); err != nil {
+			return err
+		}
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			if err := checkAttrsFidelity("scope", sm.Scope().Attributes()); err != nil {
+				return err
+			}
+			ms := sm.Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				if err := checkMetricFidelity(ms.At(k)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkMetricFidelity(metric pmetric.Metric) error {
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		return checkNumberDataPointsFidelity(metric.Name(), metric.Gauge().DataPoints())
+	case pmetric.MetricTypeSum:
+		return checkNumberDataPointsFidelity(metric.Name(), metric.Sum().DataPoints())
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			if err := checkAttrsFidelity("summary data point", dps.At(i).Attributes()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := checkAttrsFidelity("histogram data point", dp.Attributes()); err != nil {
+				return err
+			}
+			if err := checkExemplarsFidelity(metric.Name(), dp.Exemplars()); err != nil {
+				return err
+			}
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			if err := checkAttrsFidelity("exponential histogram data point", dp.Attributes()); err != nil {
+				return err
+			}
+			if err := checkExemplarsFidelity(metric.Name(), dp.Exemplars()); err != nil {
+				return err
+			}
+		}
+	default:
+		return werror.WrapWithContext(otel.ErrLossyConversion, map[string]interface{}{
+			"reason": "unknown metric type",
+			"metric": metric.Name(),
+		})
+	}
+	return nil
+}
+
+func checkNumberDataPointsFidelity(name string, dps pmetric.NumberDataPointSlice) error {
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		if dp.ValueType() == pmetric.NumberDataPointValueTypeEmpty {
+			return werror.WrapWithContext(otel.ErrLossyConversion, map[string]interface{}{
+				"reason": "number data point without value",
+				"metric": name,
+			})
+		}
+		if err := checkAttrsFidelity("number data point", dp.Attributes()); err != nil {
+			return err
+		}
+		if err := checkExemplarsFidelity(name, dp.Exemplars()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkExemplarsFidelity(name string, exemplars pmetric.ExemplarSlice) error {
+	for i := 0; i < exemplars.Len(); i++ {
+		exemplar := exemplars.At(i)
+		if exemplar.ValueType() == pmetric.ExemplarValueTypeEmpty {
+			return werror.WrapWithContext(otel.ErrLossyConversion, map[string]interface{}{
+				"reason": "exemplar without value",
+				"metric": name,
+			})
+		}
+		if err := checkAttrsFidelity("exemplar", exemplar.FilteredAttributes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAttrsFidelity detects the attributes that are dropped (empty keys) or
+// can't be represented (values without type) by the conversion.
+func checkAttrsFidelity(entity string, attrs pcommon.Map) error {
+	var err error
+	attrs.Range(func(key string, value pcommon.Value) bool {
+		switch {
+		case key == "":
+			err = werror.WrapWithContext(otel.ErrLossyConversion, map[string]interface{}{
+				"reason": "empty attribute key",
+				"entity": entity,
+			})
+		case value.Type() == pcommon.ValueTypeEmpty:
+			err = werror.WrapWithContext(otel.ErrLossyConversion, map[string]interface{}{
+				"reason": "unsupported attribute type",
+				"entity": entity,
+				"key":    key,
+			})
+		}
+		return err == nil
+	})
+	return err
+}
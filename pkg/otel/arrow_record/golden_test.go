@@ -0,0 +1,99 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/stretchr/testify/require"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/datagen"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/internal/golden"
+)
+
+// Golden-file tests protecting the wire format against accidental changes.
+// Run `go test -run TestGolden -update` to regenerate the golden files after
+// an intended change of the encoding.
+
+const (
+	goldenSeed    = 42
+	goldenBatches = 3
+)
+
+// newGoldenEntropy resets all the sources of randomness used by the data
+// generators so that the corpus is identical from one run to another.
+func newGoldenEntropy() datagen.TestEntropy {
+	gofakeit.Seed(goldenSeed)
+	return datagen.NewTestEntropy(goldenSeed)
+}
+
+// newGoldenProducer returns a producer without compression to make the golden
+// files independent of the zstd implementation.
+func newGoldenProducer(t *testing.T) *Producer {
+	producer := NewProducerWithOptions(config.WithNoZstd())
+	t.Cleanup(func() {
+		require.NoError(t, producer.Close())
+	})
+	return producer
+}
+
+func TestGoldenTraces(t *testing.T) {
+	ent := newGoldenEntropy()
+	dg := datagen.NewTracesGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	producer := newGoldenProducer(t)
+
+	batches := make([]*arrowpb.BatchArrowRecords, 0, goldenBatches)
+	for i := 0; i < goldenBatches; i++ {
+		batch, err := producer.BatchArrowRecordsFromTraces(dg.Generate(10, time.Minute))
+		require.NoError(t, err)
+		batches = append(batches, batch)
+	}
+
+	golden.AssertBatches(t, "traces", batches)
+}
+
+func TestGoldenLogs(t *testing.T) {
+	ent := newGoldenEntropy()
+	dg := datagen.NewLogsGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	producer := newGoldenProducer(t)
+
+	batches := make([]*arrowpb.BatchArrowRecords, 0, goldenBatches)
+	for i := 0; i < goldenBatches; i++ {
+		batch, err := producer.BatchArrowRecordsFromLogs(dg.Generate(10, time.Minute))
+		require.NoError(t, err)
+		batches = append(batches, batch)
+	}
+
+	golden.AssertBatches(t, "logs", batches)
+}
+
+func TestGoldenMetrics(t *testing.T) {
+	ent := newGoldenEntropy()
+	dg := datagen.NewMetricsGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	producer := newGoldenProducer(t)
+
+	batches := make([]*arrowpb.BatchArrowRecords, 0, goldenBatches)
+	for i := 0; i < goldenBatches; i++ {
+		batch, err := producer.BatchArrowRecordsFromMetrics(dg.GenerateAllKindOfMetrics(10, time.Minute))
+		require.NoError(t, err)
+		batches = append(batches, batch)
+	}
+
+	golden.AssertBatches(t, "metrics", batches)
+}
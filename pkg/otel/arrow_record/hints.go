@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// ProducerHints are the suggestions a Consumer sends back to the Producer of
+// its streams, in the batch statuses of the OTel Arrow protocol. Producers are
+// free to ignore them.
+type ProducerHints struct {
+	// ResetDictionaries asks the producer to reset the dictionaries of its
+	// streams, the memory they use on the consumer side is close to its
+	// limit.
+	ResetDictionaries bool
+	// PlainEncodingFields lists the fields the producer should not
+	// dictionary encode, using the keys of config.WithEncodingOverrides.
+	PlainEncodingFields []string
+}
+
+// hintsConfig configures the hints of a Consumer (see WithProducerHints).
+type hintsConfig struct {
+	memoryFraction      float64
+	plainEncodingFields []string
+
+	// plainEncodingSent is true once the plain encoding fields have been
+	// returned by ProducerHints.
+	plainEncodingSent bool
+}
+
+// WithProducerHints makes ProducerHints ask for a dictionary reset when the
+// memory used by a stream of the consumer exceeds the given fraction of its
+// memory limit, and suggest plain encoding for the given fields.
+func WithProducerHints(memoryFraction float64, plainEncodingFields ...string) ConsumerOption {
+	return func(c *Consumer) {
+		c.hints = &hintsConfig{
+			memoryFraction:      memoryFraction,
+			plainEncodingFields: plainEncodingFields,
+		}
+	}
+}
+
+// ProducerHints returns the hints to send to the producer after the last
+// consumed batch, or nil if there is nothing to suggest. The plain encoding
+// fields are only returned once.
+func (c *Consumer) ProducerHints() *ProducerHints {
+	if c.hints == nil {
+		return nil
+	}
+
+	var hints ProducerHints
+	if c.hints.memoryFraction > 0 {
+		threshold := uint64(c.hints.memoryFraction * float64(c.memLimit))
+		for _, sc := range c.streamConsumers {
+			if sc.allocator != nil && sc.allocator.Inuse() > threshold {
+				hints.ResetDictionaries = true
+				break
+			}
+		}
+	}
+	if !c.hints.plainEncodingSent && len(c.hints.plainEncodingFields) > 0 {
+		hints.PlainEncodingFields = c.hints.plainEncodingFields
+		c.hints.plainEncodingSent = true
+	}
+
+	if !hints.ResetDictionaries && len(hints.PlainEncodingFields) == 0 {
+		return nil
+	}
+	return &hints
+}
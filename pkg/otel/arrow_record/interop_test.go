@@ -0,0 +1,147 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	"github.com/f5/otel-arrow-adapter/pkg/datagen"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/internal/golden"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/internal/interop"
+)
+
+// Wire-level compatibility tests with the other implementations of the
+// protocol (see the interop package). Run `go test -run TestInterop -update`
+// to regenerate the fixtures consumed by the other implementations.
+
+// TestInteropRustToGo decodes the streams produced by the Rust implementation.
+func TestInteropRustToGo(t *testing.T) {
+	for _, fixture := range interop.Load(t, interop.RustToGo) {
+		fixture := fixture
+		t.Run(fixture.Name, func(t *testing.T) {
+			assertFixtureDecodes(t, fixture)
+		})
+	}
+}
+
+// TestInteropGoToRust generates the streams consumed by the Rust
+// implementation. Without `-update`, the fixtures previously generated are
+// decoded to check that the current consumer still accepts them.
+func TestInteropGoToRust(t *testing.T) {
+	if golden.Update() {
+		for _, fixture := range goToRustFixtures(t) {
+			require.NoError(t, interop.Write(interop.GoToRust, fixture))
+		}
+		return
+	}
+
+	for _, fixture := range interop.Load(t, interop.GoToRust) {
+		fixture := fixture
+		t.Run(fixture.Name, func(t *testing.T) {
+			assertFixtureDecodes(t, fixture)
+		})
+	}
+}
+
+// assertFixtureDecodes decodes the batches of a fixture with a single consumer
+// (i.e. a single stream) and compares them with the expected OTLP requests.
+func assertFixtureDecodes(t *testing.T, fixture interop.Fixture) {
+	consumer := NewConsumerWithOptions(WithStrictSchema())
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	for i, batch := range fixture.Batches {
+		var actual []byte
+		var err error
+
+		switch fixture.Signal {
+		case interop.Traces:
+			received, decodeErr := consumer.TracesFrom(batch)
+			require.NoError(t, decodeErr, "batch %d", i)
+			require.Equal(t, 1, len(received), "batch %d", i)
+			actual, err = ptraceotlp.NewExportRequestFromTraces(received[0]).MarshalJSON()
+		case interop.Logs:
+			received, decodeErr := consumer.LogsFrom(batch)
+			require.NoError(t, decodeErr, "batch %d", i)
+			require.Equal(t, 1, len(received), "batch %d", i)
+			actual, err = plogotlp.NewExportRequestFromLogs(received[0]).MarshalJSON()
+		case interop.Metrics:
+			received, decodeErr := consumer.MetricsFrom(batch)
+			require.NoError(t, decodeErr, "batch %d", i)
+			require.Equal(t, 1, len(received), "batch %d", i)
+			actual, err = pmetricotlp.NewExportRequestFromMetrics(received[0]).MarshalJSON()
+		}
+		require.NoError(t, err)
+
+		assert.EquivFromBytes(t, fixture.Expected[i], actual)
+	}
+}
+
+// goToRustFixtures encodes the golden corpus of each signal. Zstd is disabled
+// so that the fixtures don't depend on the compression support of the other
+// implementation.
+func goToRustFixtures(t *testing.T) []interop.Fixture {
+	var fixtures []interop.Fixture
+
+	ent := newGoldenEntropy()
+	tracesGen := datagen.NewTracesGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	producer := newGoldenProducer(t)
+	fixture := interop.Fixture{Name: "traces", Signal: interop.Traces}
+	for i := 0; i < goldenBatches; i++ {
+		traces := tracesGen.Generate(10, time.Minute)
+		expected, err := ptraceotlp.NewExportRequestFromTraces(traces).MarshalJSON()
+		require.NoError(t, err)
+		batch, err := producer.BatchArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+		fixture.Append(batch, expected)
+	}
+	fixtures = append(fixtures, fixture)
+
+	ent = newGoldenEntropy()
+	logsGen := datagen.NewLogsGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	producer = newGoldenProducer(t)
+	fixture = interop.Fixture{Name: "logs", Signal: interop.Logs}
+	for i := 0; i < goldenBatches; i++ {
+		logs := logsGen.Generate(10, time.Minute)
+		expected, err := plogotlp.NewExportRequestFromLogs(logs).MarshalJSON()
+		require.NoError(t, err)
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+		fixture.Append(batch, expected)
+	}
+	fixtures = append(fixtures, fixture)
+
+	ent = newGoldenEntropy()
+	metricsGen := datagen.NewMetricsGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	producer = newGoldenProducer(t)
+	fixture = interop.Fixture{Name: "metrics", Signal: interop.Metrics}
+	for i := 0; i < goldenBatches; i++ {
+		metrics := metricsGen.GenerateAllKindOfMetrics(10, time.Minute)
+		expected, err := pmetricotlp.NewExportRequestFromMetrics(metrics).MarshalJSON()
+		require.NoError(t, err)
+		batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+		require.NoError(t, err)
+		fixture.Append(batch, expected)
+	}
+	fixtures = append(fixtures, fixture)
+
+	return fixtures
+}
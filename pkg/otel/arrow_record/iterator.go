@@ -0,0 +1,165 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	logsotlp "github.com/f5/otel-arrow-adapter/pkg/otel/logs/otlp"
+	metricsotlp "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/otlp"
+	tracesotlp "github.com/f5/otel-arrow-adapter/pkg/otel/traces/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// errStopIteration is returned to the decoders when the iteration is stopped
+// by the caller. It is never returned by the Iterator.
+var errStopIteration = errors.New("iteration stopped")
+
+// Iterator decodes the records of a batch incrementally, one resource at a
+// time, instead of materializing the entire batch. Every payload yielded is
+// independent of the next ones, so it can be processed and released before
+// the rest of the batch is decoded, which lowers the peak memory of large
+// batches.
+//
+// An Iterator is created by Consumer.Iterate and must be consumed by one of
+// Traces, Logs, or Metrics, according to the signal of the batch, or closed.
+type Iterator struct {
+	consumer *Consumer
+	records  []*record_message.RecordMessage
+}
+
+// Iterate extracts the records from a BatchArrowRecords message and returns an
+// Iterator over the decoded payloads. The records are decoded lazily by the
+// Iterator.
+func (c *Consumer) Iterate(bar *colarspb.BatchArrowRecords) (*Iterator, error) {
+	records, err := c.Consume(bar)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return &Iterator{consumer: c, records: records}, nil
+}
+
+// Traces decodes the records as traces and passes them to yield one resource
+// at a time. The iteration stops when yield returns false.
+func (it *Iterator) Traces(yield func(ptrace.Traces) bool) error {
+	records := it.take()
+	c := it.consumer
+
+	relatedData, tracesRecord, err := c.tracesRelatedData(records)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	if tracesRecord == nil {
+		return nil
+	}
+
+	err = tracesotlp.TracesByResourceFrom(tracesRecord.Record(), relatedData, func(traces ptrace.Traces) error {
+		if c.strict {
+			if err := checkTracesFidelity(traces); err != nil {
+				return werror.Wrap(err)
+			}
+		}
+		if !yield(traces) {
+			return errStopIteration
+		}
+		return nil
+	})
+	return iterationError(err)
+}
+
+// Logs decodes the records as logs and passes them to yield one resource at a
+// time. The iteration stops when yield returns false.
+func (it *Iterator) Logs(yield func(plog.Logs) bool) error {
+	records := it.take()
+	c := it.consumer
+
+	relatedData, logsRecord, err := c.logsRelatedData(records)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	if logsRecord == nil {
+		return nil
+	}
+
+	err = logsotlp.LogsByResourceFrom(logsRecord.Record(), relatedData, func(logs plog.Logs) error {
+		if c.strict {
+			if err := checkLogsFidelity(logs); err != nil {
+				return werror.Wrap(err)
+			}
+		}
+		if !yield(logs) {
+			return errStopIteration
+		}
+		return nil
+	})
+	return iterationError(err)
+}
+
+// Metrics decodes the records as metrics and passes them to yield one
+// resource at a time. The iteration stops when yield returns false.
+func (it *Iterator) Metrics(yield func(pmetric.Metrics) bool) error {
+	records := it.take()
+	c := it.consumer
+
+	relatedData, metricsRecord, err := c.metricsRelatedData(records)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	if metricsRecord == nil {
+		return nil
+	}
+
+	err = metricsotlp.MetricsByResourceFrom(metricsRecord.Record(), relatedData, func(metrics pmetric.Metrics) error {
+		if c.strict {
+			if err := checkMetricsFidelity(metrics); err != nil {
+				return werror.Wrap(err)
+			}
+		}
+		if !yield(metrics) {
+			return errStopIteration
+		}
+		return nil
+	})
+	return iterationError(err)
+}
+
+// Close releases the records that have not been decoded. It is a no-op once
+// the Iterator has been consumed.
+func (it *Iterator) Close() {
+	for _, record := range it.take() {
+		record.Record().Release()
+	}
+}
+
+// take returns the records of the Iterator, which can only be consumed once.
+func (it *Iterator) take() []*record_message.RecordMessage {
+	records := it.records
+	it.records = nil
+	return records
+}
+
+// iterationError hides the error used to stop the decoders early.
+func iterationError(err error) error {
+	if err == nil || errors.Is(err, errStopIteration) {
+		return nil
+	}
+	return werror.Wrap(err)
+}
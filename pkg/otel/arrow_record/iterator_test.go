@@ -0,0 +1,91 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+func newIteratorLogs(resources, records int) plog.Logs {
+	logs := plog.NewLogs()
+	for r := 0; r < resources; r++ {
+		rl := logs.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", r))
+		lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+		for i := 0; i < records; i++ {
+			lr := lrs.AppendEmpty()
+			lr.SetTimestamp(1)
+			lr.Body().SetStr(fmt.Sprintf("log record %d", i))
+		}
+	}
+	return logs
+}
+
+func TestIteratorLogs(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithNoZstd())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	// Every resource is yielded separately.
+	batch, err := producer.BatchArrowRecordsFromLogs(newIteratorLogs(3, 5))
+	require.NoError(t, err)
+	it, err := consumer.Iterate(batch)
+	require.NoError(t, err)
+	services := make(map[string]int)
+	require.NoError(t, it.Logs(func(logs plog.Logs) bool {
+		require.Equal(t, 1, logs.ResourceLogs().Len())
+		service, ok := logs.ResourceLogs().At(0).Resource().Attributes().Get("service.name")
+		require.True(t, ok)
+		services[service.Str()] = logs.LogRecordCount()
+		return true
+	}))
+	require.Equal(t, map[string]int{"service-0": 5, "service-1": 5, "service-2": 5}, services)
+
+	// The iteration stops when yield returns false.
+	batch, err = producer.BatchArrowRecordsFromLogs(newIteratorLogs(3, 5))
+	require.NoError(t, err)
+	it, err = consumer.Iterate(batch)
+	require.NoError(t, err)
+	count := 0
+	require.NoError(t, it.Logs(func(logs plog.Logs) bool {
+		count++
+		return false
+	}))
+	require.Equal(t, 1, count)
+
+	// An Iterator that is not consumed is closed.
+	batch, err = producer.BatchArrowRecordsFromLogs(newIteratorLogs(1, 5))
+	require.NoError(t, err)
+	it, err = consumer.Iterate(batch)
+	require.NoError(t, err)
+	it.Close()
+
+	// The consumer keeps decoding the stream after an early stop.
+	batch, err = producer.BatchArrowRecordsFromLogs(newIteratorLogs(2, 5))
+	require.NoError(t, err)
+	received, err := consumer.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+	require.Equal(t, 10, received[0].LogRecordCount())
+}
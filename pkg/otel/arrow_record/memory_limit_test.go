@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+func newMemoryLimitLogs(count int) plog.Logs {
+	logs := plog.NewLogs()
+	lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < count; i++ {
+		lr := lrs.AppendEmpty()
+		lr.SetTimestamp(1)
+		lr.Body().SetStr(fmt.Sprintf("log record %d with a body large enough to fill the builders", i))
+	}
+	return logs
+}
+
+func TestProducerMemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithNoZstd(), config.WithMemoryLimit(256<<10))
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	_, err := producer.BatchArrowRecordsFromLogs(newMemoryLimitLogs(100000))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, MemoryLimitError{}))
+
+	// The producer recovers and encodes the next batches on new streams.
+	for i := 0; i < 2; i++ {
+		batch, err := producer.BatchArrowRecordsFromLogs(newMemoryLimitLogs(10))
+		require.NoError(t, err)
+		received, err := consumer.LogsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		require.Equal(t, 10, received[0].LogRecordCount())
+	}
+}
+
+func TestConsumerMemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithNoZstd())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumerWithOptions(WithMemoryLimit(64 << 10))
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromLogs(newMemoryLimitLogs(100000))
+	require.NoError(t, err)
+	_, err = consumer.LogsFrom(batch)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, MemoryLimitError{}))
+
+	// The error is retryable and identifies the payload type.
+	var coded *werror.CodedError
+	require.True(t, errors.As(err, &coded))
+	require.Equal(t, werror.CodeResourceExhausted, werror.CodeOf(err))
+	require.True(t, werror.CodeOf(err).Retryable())
+	_, ok := coded.Value(werror.PayloadTypeKey)
+	require.True(t, ok)
+
+	// The stream of the rejected batch is closed.
+	require.Equal(t, 0, len(consumer.streamConsumers))
+}
+
+func TestConsumerMaxExpansion(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumerWithOptions(WithMaxExpansion(2))
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	// Identical log records compress to a tiny fraction of their size.
+	logs := plog.NewLogs()
+	lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 200000; i++ {
+		lr := lrs.AppendEmpty()
+		lr.SetTimestamp(1)
+		lr.Body().SetStr("the same body")
+	}
+
+	batch, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	_, err = consumer.LogsFrom(batch)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ExpansionLimitError{}))
+
+	// The error is permanent and the stream of the batch is closed.
+	require.Equal(t, werror.CodeInvalidArgument, werror.CodeOf(err))
+	require.False(t, werror.CodeOf(err).Retryable())
+	require.Equal(t, 0, len(consumer.streamConsumers))
+
+	// The same batch is decoded without limit.
+	unlimited := NewConsumer()
+	defer func() { require.NoError(t, unlimited.Close()) }()
+	received, err := unlimited.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 200000, received[0].LogRecordCount())
+}
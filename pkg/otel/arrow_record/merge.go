@@ -0,0 +1,337 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Time-ordered merge of OTAP streams.
+//
+// The archives of OTAP data are made of segments, i.e. independent streams of
+// BatchArrowRecords messages, each encoded by its own Producer. The merge
+// functions decode several segments and re-encode their data, ordered by
+// timestamp, into a single segment of larger batches (compaction). As the
+// data is re-encoded by a single Producer, the segments can have different
+// schemas (e.g. segments encoded by different versions of the producer or
+// with different options).
+//
+// The merge is a k-way merge of the resources (i.e. ResourceSpans,
+// ResourceLogs, ResourceMetrics) of the segments, ordered by the oldest
+// timestamp of their items. The batches of each segment are expected to be in
+// chronological order, as written by an exporter. The items of a resource are
+// not reordered.
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// BatchSource returns the next BatchArrowRecords message of a segment, or
+// io.EOF at the end of the segment.
+type BatchSource func() (*colarspb.BatchArrowRecords, error)
+
+// BatchSink receives the BatchArrowRecords messages of the merged segment.
+type BatchSink func(*colarspb.BatchArrowRecords) error
+
+// MergeTraces merges the traces of the sources into batches of at least
+// batchSize spans (0 = one batch per resource) sent to the sink, ordered by
+// the oldest start time of the spans of their resources. The batches are
+// encoded by a new Producer configured with options.
+func MergeTraces(sources []BatchSource, sink BatchSink, batchSize int, options ...cfg.Option) error {
+	return merge(tracesMerge, sources, sink, batchSize, options)
+}
+
+// MergeLogs merges the logs of the sources into batches of at least batchSize
+// log records (0 = one batch per resource) sent to the sink, ordered by the
+// oldest timestamp (or observed timestamp if absent) of the log records of
+// their resources. The batches are encoded by a new Producer configured with
+// options.
+func MergeLogs(sources []BatchSource, sink BatchSink, batchSize int, options ...cfg.Option) error {
+	return merge(logsMerge, sources, sink, batchSize, options)
+}
+
+// MergeMetrics merges the metrics of the sources into batches of at least
+// batchSize data points (0 = one batch per resource) sent to the sink, ordered
+// by the oldest timestamp of the data points of their resources. The batches
+// are encoded by a new Producer configured with options.
+func MergeMetrics(sources []BatchSource, sink BatchSink, batchSize int, options ...cfg.Option) error {
+	return merge(metricsMerge, sources, sink, batchSize, options)
+}
+
+// mergeSignal defines the operations of the merge on the data T of a signal.
+type mergeSignal[T any] struct {
+	empty  func() T
+	count  func(T) int
+	decode func(*Consumer, *colarspb.BatchArrowRecords) ([]T, error)
+	encode func(*Producer, T) (*colarspb.BatchArrowRecords, error)
+	resLen func(T) int
+	// oldest returns the oldest timestamp of the items of the data.
+	oldest func(T) pcommon.Timestamp
+	// moveRes moves the resource i of src to dst.
+	moveRes func(dst, src T, i int)
+}
+
+// mergeEntry is a single resource of a segment.
+type mergeEntry[T any] struct {
+	timestamp pcommon.Timestamp
+	data      T
+}
+
+// mergeCursor iterates over the resources of a segment.
+type mergeCursor[T any] struct {
+	s        *mergeSignal[T]
+	source   BatchSource
+	consumer *Consumer
+	pending  []mergeEntry[T]
+	// order breaks the ties between the cursors to keep the merge stable.
+	order int
+}
+
+// fill decodes the next batches of the segment until a resource is
+// available, it returns false at the end of the segment.
+func (c *mergeCursor[T]) fill() (bool, error) {
+	for len(c.pending) == 0 {
+		bar, err := c.source()
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, werror.Wrap(err)
+		}
+		batches, err := c.s.decode(c.consumer, bar)
+		if err != nil {
+			return false, werror.WrapWithContext(err, map[string]interface{}{"batch_id": bar.BatchId})
+		}
+		for _, batch := range batches {
+			for i := 0; i < c.s.resLen(batch); i++ {
+				res := c.s.empty()
+				c.s.moveRes(res, batch, i)
+				c.pending = append(c.pending, mergeEntry[T]{timestamp: c.s.oldest(res), data: res})
+			}
+		}
+		sort.SliceStable(c.pending, func(i, j int) bool {
+			return c.pending[i].timestamp < c.pending[j].timestamp
+		})
+	}
+	return true, nil
+}
+
+// mergeHeap orders the cursors by the timestamp of their next resource.
+type mergeHeap[T any] []*mergeCursor[T]
+
+func (h mergeHeap[T]) Len() int { return len(h) }
+func (h mergeHeap[T]) Less(i, j int) bool {
+	ti, tj := h[i].pending[0].timestamp, h[j].pending[0].timestamp
+	if ti != tj {
+		return ti < tj
+	}
+	return h[i].order < h[j].order
+}
+func (h mergeHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap[T]) Push(x any)   { *h = append(*h, x.(*mergeCursor[T])) }
+func (h *mergeHeap[T]) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+func merge[T any](s *mergeSignal[T], sources []BatchSource, sink BatchSink, batchSize int, options []cfg.Option) (err error) {
+	producer := NewProducerWithOptions(options...)
+	defer func() {
+		if closeErr := producer.Close(); err == nil && closeErr != nil {
+			err = werror.Wrap(closeErr)
+		}
+	}()
+
+	h := make(mergeHeap[T], 0, len(sources))
+	for i, source := range sources {
+		c := &mergeCursor[T]{s: s, source: source, consumer: NewConsumer(), order: i}
+		defer func() { _ = c.consumer.Close() }()
+		ok, err := c.fill()
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"source": i})
+		}
+		if ok {
+			h = append(h, c)
+		}
+	}
+	heap.Init(&h)
+
+	pending := s.empty()
+	flush := func() error {
+		if s.resLen(pending) == 0 {
+			return nil
+		}
+		bar, err := s.encode(producer, pending)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		pending = s.empty()
+		return sink(bar)
+	}
+
+	for h.Len() > 0 {
+		c := h[0]
+		entry := c.pending[0]
+		c.pending = c.pending[1:]
+		s.moveRes(pending, entry.data, 0)
+		if s.count(pending) >= batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		ok, err := c.fill()
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"source": c.order})
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return flush()
+}
+
+var tracesMerge = &mergeSignal[ptrace.Traces]{
+	empty:  ptrace.NewTraces,
+	count:  ptrace.Traces.SpanCount,
+	decode: (*Consumer).TracesFrom,
+	encode: (*Producer).BatchArrowRecordsFromTraces,
+	resLen: func(traces ptrace.Traces) int { return traces.ResourceSpans().Len() },
+	oldest: func(traces ptrace.Traces) pcommon.Timestamp {
+		var oldest pcommon.Timestamp
+		rss := traces.ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			sss := rss.At(i).ScopeSpans()
+			for j := 0; j < sss.Len(); j++ {
+				spans := sss.At(j).Spans()
+				for k := 0; k < spans.Len(); k++ {
+					oldest = older(oldest, spans.At(k).StartTimestamp())
+				}
+			}
+		}
+		return oldest
+	},
+	moveRes: func(dst, src ptrace.Traces, i int) {
+		src.ResourceSpans().At(i).MoveTo(dst.ResourceSpans().AppendEmpty())
+	},
+}
+
+var logsMerge = &mergeSignal[plog.Logs]{
+	empty:  plog.NewLogs,
+	count:  plog.Logs.LogRecordCount,
+	decode: (*Consumer).LogsFrom,
+	encode: (*Producer).BatchArrowRecordsFromLogs,
+	resLen: func(logs plog.Logs) int { return logs.ResourceLogs().Len() },
+	oldest: func(logs plog.Logs) pcommon.Timestamp {
+		var oldest pcommon.Timestamp
+		rls := logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				lrs := sls.At(j).LogRecords()
+				for k := 0; k < lrs.Len(); k++ {
+					ts := lrs.At(k).Timestamp()
+					if ts == 0 {
+						ts = lrs.At(k).ObservedTimestamp()
+					}
+					oldest = older(oldest, ts)
+				}
+			}
+		}
+		return oldest
+	},
+	moveRes: func(dst, src plog.Logs, i int) {
+		src.ResourceLogs().At(i).MoveTo(dst.ResourceLogs().AppendEmpty())
+	},
+}
+
+var metricsMerge = &mergeSignal[pmetric.Metrics]{
+	empty:  pmetric.NewMetrics,
+	count:  pmetric.Metrics.DataPointCount,
+	decode: (*Consumer).MetricsFrom,
+	encode: (*Producer).BatchArrowRecordsFromMetrics,
+	resLen: func(metrics pmetric.Metrics) int { return metrics.ResourceMetrics().Len() },
+	oldest: func(metrics pmetric.Metrics) pcommon.Timestamp {
+		var oldest pcommon.Timestamp
+		rms := metrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			sms := rms.At(i).ScopeMetrics()
+			for j := 0; j < sms.Len(); j++ {
+				ms := sms.At(j).Metrics()
+				for k := 0; k < ms.Len(); k++ {
+					oldest = older(oldest, oldestDataPoint(ms.At(k)))
+				}
+			}
+		}
+		return oldest
+	},
+	moveRes: func(dst, src pmetric.Metrics, i int) {
+		src.ResourceMetrics().At(i).MoveTo(dst.ResourceMetrics().AppendEmpty())
+	},
+}
+
+// oldestDataPoint returns the oldest timestamp of the data points of a
+// metric, 0 if the metric has no data points.
+func oldestDataPoint(m pmetric.Metric) pcommon.Timestamp {
+	var oldest pcommon.Timestamp
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			oldest = older(oldest, dps.At(i).Timestamp())
+		}
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			oldest = older(oldest, dps.At(i).Timestamp())
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			oldest = older(oldest, dps.At(i).Timestamp())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			oldest = older(oldest, dps.At(i).Timestamp())
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			oldest = older(oldest, dps.At(i).Timestamp())
+		}
+	}
+	return oldest
+}
+
+// older returns the oldest of two timestamps, ignoring the zero timestamps.
+func older(a, b pcommon.Timestamp) pcommon.Timestamp {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
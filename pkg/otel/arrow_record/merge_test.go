@@ -0,0 +1,104 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+// mergeSegment encodes a segment of traces batches, each resource having a
+// single span starting at one of the given timestamps.
+func mergeSegment(t *testing.T, batches [][]pcommon.Timestamp, options ...config.Option) BatchSource {
+	producer := NewProducerWithOptions(options...)
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	var segment []*colarspb.BatchArrowRecords
+	for _, timestamps := range batches {
+		traces := ptrace.NewTraces()
+		for _, ts := range timestamps {
+			rs := traces.ResourceSpans().AppendEmpty()
+			rs.Resource().Attributes().PutInt("start", int64(ts))
+			span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.SetName("span")
+			span.SetStartTimestamp(ts)
+			span.SetEndTimestamp(ts + 1)
+		}
+		bar, err := producer.BatchArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+		segment = append(segment, bar)
+	}
+
+	return func() (*colarspb.BatchArrowRecords, error) {
+		if len(segment) == 0 {
+			return nil, io.EOF
+		}
+		bar := segment[0]
+		segment = segment[1:]
+		return bar, nil
+	}
+}
+
+func TestMergeTraces(t *testing.T) {
+	t.Parallel()
+
+	sources := []BatchSource{
+		mergeSegment(t, [][]pcommon.Timestamp{{1, 4}, {7, 10}}),
+		// The resources of a batch don't have to be ordered, and the
+		// segments can be encoded with different options.
+		mergeSegment(t, [][]pcommon.Timestamp{{5, 2}, {8}}, config.WithNoDictionary()),
+		mergeSegment(t, nil),
+		mergeSegment(t, [][]pcommon.Timestamp{{3, 6, 9}}),
+	}
+
+	var merged []*colarspb.BatchArrowRecords
+	err := MergeTraces(sources, func(bar *colarspb.BatchArrowRecords) error {
+		merged = append(merged, bar)
+		return nil
+	}, 3)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(merged))
+
+	// The merged segment is decoded by a single consumer.
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	var starts [][]pcommon.Timestamp
+	for _, bar := range merged {
+		received, err := consumer.TracesFrom(bar)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+
+		var batch []pcommon.Timestamp
+		rss := received[0].ResourceSpans()
+		for i := 0; i < rss.Len(); i++ {
+			batch = append(batch, rss.At(i).ScopeSpans().At(0).Spans().At(0).StartTimestamp())
+		}
+		starts = append(starts, batch)
+	}
+
+	// The producer may reorder the resources of a batch.
+	expected := [][]pcommon.Timestamp{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}, {10}}
+	for i := range expected {
+		require.ElementsMatch(t, expected[i], starts[i])
+	}
+}
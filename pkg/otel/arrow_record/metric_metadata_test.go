@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+)
+
+// TestProducerConsumerMetricMetadata checks the round trip of the metadata of
+// the metrics, mixed with metrics without metadata.
+func TestProducerConsumerMetricMetadata(t *testing.T) {
+	metrics := pmetric.NewMetrics()
+	metricSlice := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	for i, origin := range []string{"prometheus", "", "statsd"} {
+		metric := metricSlice.AppendEmpty()
+		metric.SetName("metric")
+		if origin != "" {
+			metric.Metadata().PutStr("origin", origin)
+			metric.Metadata().PutInt("revision", int64(i))
+		}
+		dp := metric.SetEmptyGauge().DataPoints().AppendEmpty()
+		dp.SetTimestamp(pcommon.Timestamp(i + 1))
+		dp.SetIntValue(int64(i))
+	}
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	producer := NewProducerWithOptions(config.WithAllocator(pool))
+	defer func() {
+		require.NoError(t, producer.Close())
+	}()
+
+	batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	received, err := consumer.MetricsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+	)
+}
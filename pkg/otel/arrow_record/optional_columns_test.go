@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+)
+
+func TestOptionalColumnDeactivation(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithNoZstd(), config.WithOptionalColumnDeactivation(2))
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	newLogs := func(severityText string) plog.Logs {
+		logs := plog.NewLogs()
+		lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for i := 0; i < 10; i++ {
+			lr := lrs.AppendEmpty()
+			lr.SetTimestamp(1)
+			lr.Body().SetStr("body")
+			lr.SetSeverityText(severityText)
+		}
+		return logs
+	}
+	severityText := func() builder.OptionalColumn {
+		for _, c := range producer.OptionalColumns()[v1.ArrowPayloadType_LOGS] {
+			if c.Path == constants.SeverityText {
+				return c
+			}
+		}
+		t.Fatal("severity_text column not found")
+		return builder.OptionalColumn{}
+	}
+	roundTrip := func(logs plog.Logs) {
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+		received, err := consumer.LogsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+		)
+	}
+
+	require.False(t, severityText().Active)
+
+	roundTrip(newLogs("INFO"))
+	require.True(t, severityText().Active)
+	require.Equal(t, 0.0, severityText().NullRatio)
+
+	// The severity text disappears, the column stays active until it has been
+	// null for 2 consecutive batches.
+	roundTrip(newLogs(""))
+	require.True(t, severityText().Active)
+	require.Equal(t, 1.0, severityText().NullRatio)
+	require.Equal(t, 1, severityText().AllNullBatches)
+
+	roundTrip(newLogs(""))
+	require.False(t, severityText().Active)
+	require.Equal(t, uint64(30), severityText().Rows)
+	require.Equal(t, uint64(20), severityText().Nulls)
+
+	// The column is reactivated as soon as a value is observed.
+	roundTrip(newLogs("WARN"))
+	require.True(t, severityText().Active)
+
+	stats := producer.GetAndResetStats()
+	require.Equal(t, uint64(1), stats.RecordBuilderStats.OptionalColumnsDeactivated)
+}
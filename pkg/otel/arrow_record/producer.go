@@ -16,6 +16,7 @@ package arrow_record
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"sort"
@@ -31,6 +32,7 @@ import (
 	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	carrow "github.com/f5/otel-arrow-adapter/pkg/arrow"
 	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/encryption"
 	acommon "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
@@ -58,11 +60,21 @@ type ProducerAPI interface {
 
 var _ ProducerAPI = &Producer{}
 
+// MemoryLimitError is the error returned by the Producer and the Consumer when
+// a batch exceeds their memory limit (see config.WithMemoryLimit and
+// WithMemoryLimit). The batch is dropped and the state of the affected
+// streams is reset.
+type MemoryLimitError = acommon.LimitError
+
 // Producer is a BatchArrowRecords producer.
 type (
 	Producer struct {
-		pool            memory.Allocator // Use a custom memory allocator
-		zstd            bool             // Use IPC ZSTD compression
+		config          *cfg.Config
+		basePool        memory.Allocator            // Allocator of the configuration
+		pool            memory.Allocator            // Use a custom memory allocator
+		limiter         *acommon.LimitedAllocator   // nil if the memory is not limited
+		recycler        *acommon.RecyclingAllocator // nil if buffer recycling is disabled
+		zstd            bool                        // Use IPC ZSTD compression
 		streamProducers map[string]*streamProducer
 		nextSchemaId    int64
 		batchId         int64
@@ -79,9 +91,41 @@ type (
 
 		// General stats for the producer
 		stats *pstats.ProducerStats
+		// Reporter of the schema stats, nil if they are disabled
+		statsReporter *acommon.AnalyzerReporter
+		// OpenTelemetry metrics of the stats, nil if no MeterProvider is
+		// configured
+		metrics *pstats.ProducerMetrics
 
 		// Producer observer
 		observer ProducerObserver
+
+		// Payloads of the last batch produced
+		lastBatch []PayloadInfo
+
+		// CPU budget, nil if the encoding time is not limited
+		cpuBudget *cpuBudget
+
+		// Ratio between the encoded and estimated sizes of the last
+		// chunk of a split batch, 0 if unknown (see split.go)
+		splitRatio float64
+
+		// Random ID of the producer authenticated with its encrypted
+		// payloads, nil until the first payload is encrypted
+		encryptionStreamID []byte
+	}
+
+	// PayloadInfo describes an ArrowPayload of a produced batch.
+	PayloadInfo struct {
+		PayloadType record_message.PayloadType
+		// SchemaID is the schema ID of the ArrowPayload (i.e. the ID of its
+		// IPC stream).
+		SchemaID string
+		// Rows is the number of rows of the encoded record.
+		Rows int64
+		// Bytes is the size of the serialized (and possibly encrypted)
+		// record.
+		Bytes int
 	}
 
 	ProducerObserver interface {
@@ -104,6 +148,7 @@ type (
 		lastProduction time.Time
 		schema         *arrow.Schema
 		payloadType    record_message.PayloadType
+		recording      *streamRecording
 	}
 )
 
@@ -129,45 +174,217 @@ func NewProducerWithOptions(options ...cfg.Option) *Producer {
 		stats.SchemaStatsEnabled = true
 	}
 
+	p := &Producer{
+		config:          conf,
+		basePool:        conf.Pool,
+		zstd:            conf.Zstd,
+		streamProducers: make(map[string]*streamProducer),
+		batchId:         0,
+
+		stats: stats,
+
+		cpuBudget: newCPUBudget(conf.CPUBudget),
+	}
+	if conf.Stats {
+		reporter, err := acommon.NewAnalyzerReporter(conf)
+		if err != nil {
+			panic(err)
+		}
+		p.statsReporter = reporter
+	}
+	if conf.MeterProvider != nil {
+		metrics, err := pstats.NewProducerMetrics(conf.MeterProvider)
+		if err != nil {
+			panic(err)
+		}
+		p.metrics = metrics
+	}
+	if err := p.initBuilders(); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// initBuilders creates the allocators and the builders of the producer.
+func (p *Producer) initBuilders() error {
+	conf, stats := p.config, p.stats
+	conf.Pool = p.basePool
+
+	if conf.MmapAllocator {
+		conf.Pool = acommon.NewMmapAllocator(conf.Pool, conf.MmapThreshold)
+	}
+
+	p.limiter = nil
+	if conf.MemoryLimit > 0 {
+		p.limiter = acommon.NewLimitedAllocator(conf.Pool, conf.MemoryLimit)
+		conf.Pool = p.limiter
+	}
+
+	p.recycler = nil
+	if conf.BufferRecycling > 0 {
+		p.recycler = acommon.NewRecyclingAllocator(conf.Pool, uint64(conf.BufferRecycling))
+		conf.Pool = p.recycler
+	}
+	p.pool = conf.Pool
+
 	// Record builders
-	metricsRecordBuilder := builder.NewRecordBuilderExt(conf.Pool, metricsarrow.MetricsSchema, config.NewDictionary(conf.LimitIndexSize), stats)
-	metricsRecordBuilder.SetLabel("metrics")
-	logsRecordBuilder := builder.NewRecordBuilderExt(conf.Pool, logsarrow.LogsSchema, config.NewDictionary(conf.LimitIndexSize), stats)
-	logsRecordBuilder.SetLabel("logs")
-	tracesRecordBuilder := builder.NewRecordBuilderExt(conf.Pool, tracesarrow.TracesSchema, config.NewDictionary(conf.LimitIndexSize), stats)
-	tracesRecordBuilder.SetLabel("traces")
+	p.metricsRecordBuilder = builder.NewRecordBuilderExt(conf.Pool, schema.WithEncodingOverrides(metricsarrow.MetricsSchema, "metrics", conf.EncodingOverrides), config.NewDictionary(conf.LimitIndexSize).WithOverflow(conf.DictionaryOverflow, "metrics", conf.DictionaryOverflowOverrides), stats)
+	p.metricsRecordBuilder.SetLabel("metrics")
+	p.metricsRecordBuilder.SetOptionalColumnDeactivation(conf.OptionalColumnDeactivation)
+	p.metricsRecordBuilder.SetLargeValues(conf.LargeValues, conf.LargeValueThreshold)
+	p.logsRecordBuilder = builder.NewRecordBuilderExt(conf.Pool, schema.WithEncodingOverrides(logsarrow.LogsSchema, "logs", conf.EncodingOverrides), config.NewDictionary(conf.LimitIndexSize).WithOverflow(conf.DictionaryOverflow, "logs", conf.DictionaryOverflowOverrides), stats)
+	p.logsRecordBuilder.SetLabel("logs")
+	p.logsRecordBuilder.SetOptionalColumnDeactivation(conf.OptionalColumnDeactivation)
+	p.logsRecordBuilder.SetLargeValues(conf.LargeValues, conf.LargeValueThreshold)
+	p.tracesRecordBuilder = builder.NewRecordBuilderExt(conf.Pool, schema.WithEncodingOverrides(tracesarrow.TracesSchema, "spans", conf.EncodingOverrides), config.NewDictionary(conf.LimitIndexSize).WithOverflow(conf.DictionaryOverflow, "spans", conf.DictionaryOverflowOverrides), stats)
+	p.tracesRecordBuilder.SetLabel("traces")
+	p.tracesRecordBuilder.SetOptionalColumnDeactivation(conf.OptionalColumnDeactivation)
+	p.tracesRecordBuilder.SetLargeValues(conf.LargeValues, conf.LargeValueThreshold)
 
 	// Entity builders
-	metricsBuilder, err := metricsarrow.NewMetricsBuilder(metricsRecordBuilder, metricsarrow.NewConfig(conf), stats)
+	metricsConf, logsConf, tracesConf := metricsarrow.NewConfig(conf), logsarrow.NewConfig(conf), tracesarrow.NewConfig(conf)
+	if conf.NoSort {
+		metricsConf, logsConf, tracesConf = metricsarrow.NewNoSortConfig(conf), logsarrow.NewNoSortConfig(conf), tracesarrow.NewNoSortConfig(conf)
+	} else if err := applySorters(conf, metricsConf, logsConf, tracesConf); err != nil {
+		return werror.Wrap(err)
+	}
+	if conf.WholeResources {
+		if err := sortByResourceFirst(conf, metricsConf, logsConf, tracesConf); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+
+	var err error
+	if p.metricsBuilder, err = metricsarrow.NewMetricsBuilder(p.metricsRecordBuilder, metricsConf, stats); err != nil {
+		return werror.Wrap(err)
+	}
+	if p.logsBuilder, err = logsarrow.NewLogsBuilder(p.logsRecordBuilder, logsConf, stats); err != nil {
+		return werror.Wrap(err)
+	}
+	if p.tracesBuilder, err = tracesarrow.NewTracesBuilder(p.tracesRecordBuilder, tracesConf, stats); err != nil {
+		return werror.Wrap(err)
+	}
+	p.logsBuilder.SuspendTemplates(p.EncodingLevel() >= EncodingNoTransforms)
+	return nil
+}
+
+// applySorters replaces the default sorters of the metrics, log records and
+// spans by the sort keys or the registered sorters selected in the
+// configuration, if any.
+func applySorters(conf *cfg.Config, metricsConf *metricsarrow.Config, logsConf *logsarrow.Config, tracesConf *tracesarrow.Config) error {
+	var err error
+
+	switch {
+	case len(conf.MetricSortKeys) > 0:
+		metricsConf.Metric.Sorter, err = metricsarrow.SortMetricsByKeys(conf.MetricSortKeys...)
+	case conf.MetricSorter != "":
+		metricsConf.Metric.Sorter, err = metricsarrow.NewMetricSorter(conf.MetricSorter)
+	}
 	if err != nil {
-		panic(err)
+		return werror.Wrap(err)
 	}
 
-	logsBuilder, err := logsarrow.NewLogsBuilder(logsRecordBuilder, logsarrow.NewConfig(conf), stats)
+	switch {
+	case len(conf.LogSortKeys) > 0:
+		logsConf.Log.Sorter, err = logsarrow.SortLogsByKeys(conf.LogSortKeys...)
+	case conf.LogSorter != "":
+		logsConf.Log.Sorter, err = logsarrow.NewLogSorter(conf.LogSorter)
+	}
 	if err != nil {
-		panic(err)
+		return werror.Wrap(err)
 	}
 
-	tracesBuilder, err := tracesarrow.NewTracesBuilder(tracesRecordBuilder, tracesarrow.NewConfig(conf), stats)
+	switch {
+	case len(conf.SpanSortKeys) > 0:
+		tracesConf.Span.Sorter, err = tracesarrow.SortSpansByKeys(conf.SpanSortKeys...)
+	case conf.SpanSorter != "":
+		tracesConf.Span.Sorter, err = tracesarrow.NewSpanSorter(conf.SpanSorter)
+	}
 	if err != nil {
-		panic(err)
+		return werror.Wrap(err)
 	}
+	return nil
+}
 
-	return &Producer{
-		pool:            conf.Pool,
-		zstd:            conf.Zstd,
-		streamProducers: make(map[string]*streamProducer),
-		batchId:         0,
+// sortByResourceFirst replaces the sorters of the metrics, log records and
+// spans which do not sort the items by resource first, so that the rows of
+// each resource are contiguous (see config.WithWholeResources). The
+// built-in sorters other than "unsorted" already sort by resource first.
+func sortByResourceFirst(conf *cfg.Config, metricsConf *metricsarrow.Config, logsConf *logsarrow.Config, tracesConf *tracesarrow.Config) error {
+	var err error
 
-		metricsBuilder: metricsBuilder,
-		logsBuilder:    logsBuilder,
-		tracesBuilder:  tracesBuilder,
+	if keys := resourceFirstKeys(conf.MetricSortKeys, conf.NoSort || conf.MetricSorter == metricsarrow.UnsortedMetricSorter); keys != nil {
+		if metricsConf.Metric.Sorter, err = metricsarrow.SortMetricsByKeys(keys...); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+	if keys := resourceFirstKeys(conf.LogSortKeys, conf.NoSort || conf.LogSorter == logsarrow.UnsortedLogSorter); keys != nil {
+		if logsConf.Log.Sorter, err = logsarrow.SortLogsByKeys(keys...); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+	if keys := resourceFirstKeys(conf.SpanSortKeys, conf.NoSort || conf.SpanSorter == tracesarrow.UnsortedSpanSorter); keys != nil {
+		if tracesConf.Span.Sorter, err = tracesarrow.SortSpansByKeys(keys...); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+	return nil
+}
 
-		metricsRecordBuilder: metricsRecordBuilder,
-		logsRecordBuilder:    logsRecordBuilder,
-		tracesRecordBuilder:  tracesRecordBuilder,
+// resourceFirstKeys returns the sort keys sorting the items by resource first
+// and then in the order of the given sort keys, or nil if the configured
+// sorter already sorts them by resource first.
+func resourceFirstKeys(keys []string, unsorted bool) []string {
+	switch {
+	case len(keys) > 0 && keys[0] != "resource":
+		return append([]string{"resource"}, keys...)
+	case len(keys) == 0 && unsorted:
+		return []string{"resource"}
+	}
+	return nil
+}
 
-		stats: stats,
+// releaseBuilders releases the builders and the buffers kept by the
+// allocators of the producer.
+func (p *Producer) releaseBuilders() {
+	p.metricsBuilder.Release()
+	p.logsBuilder.Release()
+	p.tracesBuilder.Release()
+
+	p.metricsRecordBuilder.Release()
+	p.logsRecordBuilder.Release()
+	p.tracesRecordBuilder.Release()
+
+	if p.recycler != nil {
+		p.recycler.Release()
+	}
+}
+
+// recoverMemoryLimit converts the panic raised when the memory limit of the
+// producer is exceeded (see config.WithMemoryLimit) into a MemoryLimitError.
+// The builders and the streams hold the partial state of the failed batch,
+// so they are replaced and the next batches are sent on new streams. Other
+// panics are propagated.
+func (p *Producer) recoverMemoryLimit(err *error) {
+	if p.limiter == nil {
+		return
+	}
+	if r := recover(); r != nil {
+		limitErr, ok := r.(MemoryLimitError)
+		if !ok {
+			panic(r)
+		}
+		*err = werror.Wrap(limitErr)
+	}
+	if *err == nil || !errors.Is(*err, MemoryLimitError{}) {
+		return
+	}
+
+	// The partial records and builders are dropped with their allocators,
+	// the garbage collector reclaims their buffers.
+	p.streamProducers = make(map[string]*streamProducer)
+	if initErr := p.initBuilders(); initErr != nil {
+		*err = werror.Wrap(initErr)
 	}
 }
 
@@ -177,7 +394,16 @@ func (p *Producer) SetObserver(observer ProducerObserver) {
 }
 
 // BatchArrowRecordsFromMetrics produces a BatchArrowRecords message from a [pmetric.Metrics] messages.
-func (p *Producer) BatchArrowRecordsFromMetrics(metrics pmetric.Metrics) (*colarspb.BatchArrowRecords, error) {
+func (p *Producer) BatchArrowRecordsFromMetrics(metrics pmetric.Metrics) (bar *colarspb.BatchArrowRecords, err error) {
+	defer p.recoverMemoryLimit(&err)
+	start := time.Now()
+
+	if p.config.Strict {
+		if err := checkMetricsFidelity(metrics); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
 	// Builds a main Record and n related Records from the metrics passed in
 	// parameter. All these Arrow records are wrapped into a BatchArrowRecords
 	// and will be released by the Producer.Produce method.
@@ -191,6 +417,37 @@ func (p *Producer) BatchArrowRecordsFromMetrics(metrics pmetric.Metrics) (*colar
 		return nil, werror.Wrap(err)
 	}
 
+	return p.produceMetrics(record, start)
+}
+
+// BatchArrowRecordsFromMetricsAppender produces a BatchArrowRecords message
+// from the metrics appended by fn, without building a [pmetric.Metrics] first
+// (see metricsarrow.MetricsAppender). fn is called again after a schema
+// update, it must append the same metrics every time. The strict mode checks
+// are not applied to these metrics.
+func (p *Producer) BatchArrowRecordsFromMetricsAppender(fn func(*metricsarrow.MetricsAppender) error) (bar *colarspb.BatchArrowRecords, err error) {
+	defer p.recoverMemoryLimit(&err)
+	start := time.Now()
+
+	record, err := buildRecord(func() (arrow.Record, error) {
+		// Related entity builder must be reset before each use.
+		// This is especially important after a schema update.
+		p.metricsBuilder.RelatedData().Reset()
+		if err := p.metricsBuilder.AppendWith(fn); err != nil {
+			return nil, err
+		}
+		return p.metricsBuilder.Build()
+	})
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	return p.produceMetrics(record, start)
+}
+
+// produceMetrics builds the related records of the given main metrics record
+// and produces the BatchArrowRecords message.
+func (p *Producer) produceMetrics(record arrow.Record, start time.Time) (*colarspb.BatchArrowRecords, error) {
 	// builds the related records (e.g. INT_SUM, INT_GAUGE, INT_GAUGE_ATTRS, ...)
 	rms, err := p.metricsBuilder.RelatedData().BuildRecordMessages()
 	if err != nil {
@@ -208,11 +465,25 @@ func (p *Producer) BatchArrowRecordsFromMetrics(metrics pmetric.Metrics) (*colar
 		return nil, werror.Wrap(err)
 	}
 	p.stats.MetricsBatchesProduced++
+	p.updateMetrics()
+
+	if err := p.chargeCPUBudget(start); err != nil {
+		return nil, werror.Wrap(err)
+	}
 	return bar, nil
 }
 
 // BatchArrowRecordsFromLogs produces a BatchArrowRecords message from a [plog.Logs] messages.
-func (p *Producer) BatchArrowRecordsFromLogs(ls plog.Logs) (*colarspb.BatchArrowRecords, error) {
+func (p *Producer) BatchArrowRecordsFromLogs(ls plog.Logs) (bar *colarspb.BatchArrowRecords, err error) {
+	defer p.recoverMemoryLimit(&err)
+	start := time.Now()
+
+	if p.config.Strict {
+		if err := checkLogsFidelity(ls); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
 	// Builds a main Record and n related Records from the logs passed in
 	// parameter. All these Arrow records are wrapped into a BatchArrowRecords
 	// and will be released by the Producer.Produce method.
@@ -234,16 +505,36 @@ func (p *Producer) BatchArrowRecordsFromLogs(ls plog.Logs) (*colarspb.BatchArrow
 	// in the collector.
 	rms = append([]*record_message.RecordMessage{record_message.NewLogsMessage(schemaID, record)}, rms...)
 
-	bar, err := p.Produce(rms)
+	if p.config.TraceIDIndex {
+		if rms, err = p.appendTraceIDIndex(rms, record); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	bar, err = p.Produce(rms)
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
 	p.stats.LogsBatchesProduced++
+	p.updateMetrics()
+
+	if err := p.chargeCPUBudget(start); err != nil {
+		return nil, werror.Wrap(err)
+	}
 	return bar, nil
 }
 
 // BatchArrowRecordsFromTraces produces a BatchArrowRecords message from a [ptrace.Traces] messages.
-func (p *Producer) BatchArrowRecordsFromTraces(ts ptrace.Traces) (*colarspb.BatchArrowRecords, error) {
+func (p *Producer) BatchArrowRecordsFromTraces(ts ptrace.Traces) (bar *colarspb.BatchArrowRecords, err error) {
+	defer p.recoverMemoryLimit(&err)
+	start := time.Now()
+
+	if p.config.Strict {
+		if err := checkTracesFidelity(ts); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
 	// Builds a main Record and n related Records from the traces passed in
 	// parameter. All these Arrow records are wrapped into a BatchArrowRecords
 	// and will be released by the Producer.Produce method.
@@ -265,11 +556,22 @@ func (p *Producer) BatchArrowRecordsFromTraces(ts ptrace.Traces) (*colarspb.Batc
 	// in the collector.
 	rms = append([]*record_message.RecordMessage{record_message.NewTraceMessage(schemaID, record)}, rms...)
 
-	bar, err := p.Produce(rms)
+	if p.config.TraceIDIndex {
+		if rms, err = p.appendTraceIDIndex(rms, record); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	bar, err = p.Produce(rms)
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
 	p.stats.TracesBatchesProduced++
+	p.updateMetrics()
+
+	if err := p.chargeCPUBudget(start); err != nil {
+		return nil, werror.Wrap(err)
+	}
 	return bar, nil
 }
 
@@ -300,33 +602,149 @@ func (p *Producer) TracesBuilder() *tracesarrow.TracesBuilder {
 	return p.tracesBuilder
 }
 
+// OptionalColumns returns, for each payload type, the state of the optional
+// columns of the corresponding record builder (see
+// config.WithOptionalColumnDeactivation).
+func (p *Producer) OptionalColumns() map[record_message.PayloadType][]builder.OptionalColumn {
+	columns := map[record_message.PayloadType][]builder.OptionalColumn{
+		colarspb.ArrowPayloadType_METRICS: p.metricsRecordBuilder.OptionalColumns(),
+		colarspb.ArrowPayloadType_LOGS:    p.logsRecordBuilder.OptionalColumns(),
+		colarspb.ArrowPayloadType_SPANS:   p.tracesRecordBuilder.OptionalColumns(),
+	}
+
+	for _, s := range p.metricsBuilder.RelatedData().Schemas() {
+		columns[s.PayloadType.PayloadType()] = p.metricsBuilder.RelatedData().RecordBuilderExt(s.PayloadType).OptionalColumns()
+	}
+	for _, s := range p.logsBuilder.RelatedData().Schemas() {
+		columns[s.PayloadType.PayloadType()] = p.logsBuilder.RelatedData().RecordBuilderExt(s.PayloadType).OptionalColumns()
+	}
+	for _, s := range p.tracesBuilder.RelatedData().Schemas() {
+		columns[s.PayloadType.PayloadType()] = p.tracesBuilder.RelatedData().RecordBuilderExt(s.PayloadType).OptionalColumns()
+	}
+
+	return columns
+}
+
 // Close closes all stream producers.
 func (p *Producer) Close() error {
-	p.metricsBuilder.Release()
-	p.logsBuilder.Release()
-	p.tracesBuilder.Release()
+	if p.statsReporter != nil {
+		_ = p.statsReporter.Close()
+	}
+	if err := p.closeStreamProducers(); err != nil {
+		p.releaseBuilders()
+		return werror.Wrap(err)
+	}
+	p.updateMetrics()
+	p.releaseBuilders()
+	return nil
+}
 
-	p.metricsRecordBuilder.Release()
-	p.logsRecordBuilder.Release()
-	p.tracesRecordBuilder.Release()
+// Reset drops the state accumulated by the producer since its creation or
+// its last reset, i.e. the dictionaries, the schema evolutions and the
+// buffers kept by the builders. The builders are released and recreated, and
+// the next batches are sent on new streams (i.e. with new schema IDs) that
+// the consumers decode from scratch. This bounds the memory used by a
+// long-lived producer without recreating it.
+//
+// Reset must not be called concurrently with the encoding of a batch.
+func (p *Producer) Reset() error {
+	if err := p.closeStreamProducers(); err != nil {
+		return werror.Wrap(err)
+	}
+	p.releaseBuilders()
+	if err := p.initBuilders(); err != nil {
+		return werror.Wrap(err)
+	}
+	p.updateMetrics()
+	return nil
+}
 
-	for _, sp := range p.streamProducers {
-		if err := sp.ipcWriter.Close(); err != nil {
-			return werror.Wrap(err)
+// updateMetrics exports the variations of the stats since the last batch to
+// the OpenTelemetry metrics, if enabled (see config.WithMeterProvider).
+func (p *Producer) updateMetrics() {
+	if p.metrics != nil {
+		p.metrics.Update(context.Background(), p.stats)
+	}
+}
+
+// closeStreamProducers closes all the stream producers, the next batches are
+// sent on new streams (i.e. with new schema IDs).
+func (p *Producer) closeStreamProducers() error {
+	for ssID, sp := range p.streamProducers {
+		if sp.ipcWriter != nil {
+			if err := sp.ipcWriter.Close(); err != nil {
+				return werror.Wrap(err)
+			}
 		}
 		p.stats.StreamProducersClosed++
+		delete(p.streamProducers, ssID)
 	}
 	return nil
 }
 
+// EncodingLevel returns the current encoding level of the producer, i.e.
+// EncodingFull unless the CPU budget has been exceeded (see
+// config.WithCPUBudget).
+func (p *Producer) EncodingLevel() int {
+	if p.cpuBudget == nil {
+		return EncodingFull
+	}
+	return p.cpuBudget.level
+}
+
+// chargeCPUBudget charges the time spent encoding a batch since start to the
+// CPU budget and applies the resulting encoding level. The wall-clock time
+// is used as the encoding runs on the calling goroutine.
+func (p *Producer) chargeCPUBudget(start time.Time) error {
+	if p.cpuBudget == nil {
+		return nil
+	}
+	now := time.Now()
+	level, changed := p.cpuBudget.charge(now, now.Sub(start))
+	if !changed {
+		return nil
+	}
+	return p.setEncodingLevel(level)
+}
+
+// setEncodingLevel suspends or resumes the optional transforms and the IPC
+// compression according to the encoding level.
+func (p *Producer) setEncodingLevel(level int) error {
+	p.logsBuilder.SuspendTemplates(level >= EncodingNoTransforms)
+
+	zstd := p.config.Zstd && level < EncodingNoCompression
+	if zstd == p.zstd {
+		return nil
+	}
+	p.zstd = zstd
+
+	// The compression is an option of the IPC writer of each stream, the
+	// streams are restarted to apply the new setting.
+	return p.closeStreamProducers()
+}
+
 // GetAndResetStats returns the stats and resets them.
 func (p *Producer) GetAndResetStats() pstats.ProducerStats {
 	return p.stats.GetAndReset()
 }
 
+// appendTraceIDIndex appends the trace ID index of the main record (see
+// config.WithTraceIDIndex) to the record messages of a batch.
+func (p *Producer) appendTraceIDIndex(rms []*record_message.RecordMessage, record arrow.Record) ([]*record_message.RecordMessage, error) {
+	rm, err := traceIDIndexMessage(p.pool, record)
+	if err != nil {
+		return rms, werror.Wrap(err)
+	}
+	if rm != nil {
+		rms = append(rms, rm)
+	}
+	return rms, nil
+}
+
 // Produce takes a slice of RecordMessage and returns the corresponding BatchArrowRecords protobuf message.
 func (p *Producer) Produce(rms []*record_message.RecordMessage) (*colarspb.BatchArrowRecords, error) {
 	oapl := make([]*colarspb.ArrowPayload, len(rms))
+	infos := make([]PayloadInfo, len(rms))
 
 	for i, rm := range rms {
 		err := func() error {
@@ -359,6 +777,7 @@ func (p *Producer) Produce(rms []*record_message.RecordMessage) (*colarspb.Batch
 					output:      buf,
 					schemaID:    fmt.Sprintf("%d", p.nextSchemaId),
 					payloadType: rm.PayloadType(),
+					recording:   newStreamRecording(p.config.StreamRecording),
 				}
 				p.streamProducers[rm.SchemaID()] = sp
 				p.nextSchemaId++
@@ -386,25 +805,49 @@ func (p *Producer) Produce(rms []*record_message.RecordMessage) (*colarspb.Batch
 
 			err := sp.ipcWriter.Write(rm.Record())
 			if err != nil {
+				if p.limiter != nil {
+					// The IPC writer recovers the allocator panics.
+					if limitErr := p.limiter.LimitExceeded(); limitErr != nil {
+						return werror.Wrap(limitErr)
+					}
+				}
 				return werror.Wrap(err)
 			}
 			outputBuf := sp.output.Bytes()
 			buf := make([]byte, len(outputBuf))
 			copy(buf, outputBuf)
+			sp.recording.record(buf)
 
-			if p.stats.SchemaStatsEnabled {
-				// ToDo Create option to display this info
-				fmt.Printf("Record %q -> %d bytes\n", rm.PayloadType().String(), len(buf))
+			if p.statsReporter != nil {
+				fmt.Fprintf(p.statsReporter.Output(), "Record %q -> %d bytes\n", rm.PayloadType().String(), len(buf))
 			}
 
 			// Reset the buffer
 			sp.output.Reset()
 
+			if p.config.Encryption != nil {
+				if p.encryptionStreamID == nil {
+					if p.encryptionStreamID, err = encryption.NewStreamID(); err != nil {
+						return werror.Wrap(err)
+					}
+				}
+				buf, err = encryption.Seal(p.config.Encryption, p.encryptionStreamID, buf, payloadAAD(p.batchId, sp.schemaID, rm.PayloadType()))
+				if err != nil {
+					return werror.Wrap(err)
+				}
+			}
+
 			oapl[i] = &colarspb.ArrowPayload{
 				SchemaId: sp.schemaID,
 				Type:     rm.PayloadType(),
 				Record:   buf,
 			}
+			infos[i] = PayloadInfo{
+				PayloadType: rm.PayloadType(),
+				SchemaID:    sp.schemaID,
+				Rows:        rm.Record().NumRows(),
+				Bytes:       len(buf),
+			}
 			return nil
 		}()
 		if err != nil {
@@ -412,8 +855,22 @@ func (p *Producer) Produce(rms []*record_message.RecordMessage) (*colarspb.Batch
 		}
 	}
 
+	batchBytes := 0
+	for _, info := range infos {
+		p.stats.PayloadSize(info.PayloadType.String()).Record(info.Bytes)
+		if p.metrics != nil {
+			p.metrics.RecordPayloadSize(context.Background(), info.PayloadType.String(), info.Bytes)
+		}
+		batchBytes += info.Bytes
+	}
+	p.stats.BatchSizes.Record(batchBytes)
+	if p.metrics != nil {
+		p.metrics.RecordBatchSize(context.Background(), batchBytes)
+	}
+
 	batchId := p.batchId
 	p.batchId++
+	p.lastBatch = infos
 
 	return &colarspb.BatchArrowRecords{
 		BatchId:       batchId,
@@ -421,6 +878,44 @@ func (p *Producer) Produce(rms []*record_message.RecordMessage) (*colarspb.Batch
 	}, nil
 }
 
+// LastBatchPayloads returns the payload type, schema ID, number of rows and
+// serialized size of each ArrowPayload of the last batch produced, in the
+// order of the payloads in the batch. This lets callers make routing or
+// observability decisions without decoding the batch.
+func (p *Producer) LastBatchPayloads() []PayloadInfo {
+	return p.lastBatch
+}
+
+// payloadAAD returns the additional authenticated data binding an encrypted
+// payload to its batch, IPC stream, and payload type.  The envelope binds it
+// to the producer (see encryption.Seal).
+func payloadAAD(batchID int64, schemaID string, payloadType record_message.PayloadType) []byte {
+	return []byte(fmt.Sprintf("%d/%s/%d", batchID, schemaID, payloadType))
+}
+
+// AnalyzerStats returns the statistics collected by the logs, metrics and
+// traces analyzers (see config.WithStats), nil if the stats are disabled.
+func (p *Producer) AnalyzerStats() []acommon.AnalyzerStat {
+	var stats []acommon.AnalyzerStat
+	if a := p.logsBuilder.Analyzer(); a != nil {
+		stats = append(stats, acommon.AnalyzerStats(a)...)
+	}
+	if a := p.metricsBuilder.Analyzer(); a != nil {
+		stats = append(stats, acommon.AnalyzerStats(a)...)
+	}
+	if a := p.tracesBuilder.Analyzer(); a != nil {
+		stats = append(stats, acommon.AnalyzerStats(a)...)
+	}
+	return stats
+}
+
+// AnalyzerStatsRecord returns the statistics collected by the analyzers as an
+// Arrow record (see acommon.AnalyzerStatsSchema), the record is empty if the
+// stats are disabled. The caller must release the record.
+func (p *Producer) AnalyzerStatsRecord() arrow.Record {
+	return acommon.AnalyzerStatsRecord(p.pool, p.AnalyzerStats())
+}
+
 func (p *Producer) ShowStats() {
 	type TimeSchema struct {
 		time   time.Time
@@ -448,6 +943,21 @@ func (p *Producer) ShowStats() {
 }
 
 func recordBuilder[T pmetric.Metrics | plog.Logs | ptrace.Traces](builder func() (acommon.EntityBuilder[T], error), entity T) (record arrow.Record, err error) {
+	return buildRecord(func() (arrow.Record, error) {
+		tb, err := builder()
+		if err != nil {
+			return nil, err
+		}
+		if err := tb.Append(entity); err != nil {
+			return nil, err
+		}
+		return tb.Build()
+	})
+}
+
+// buildRecord calls build until the record is built with an up-to-date
+// schema.
+func buildRecord(build func() (arrow.Record, error)) (record arrow.Record, err error) {
 	schemaNotUpToDateCount := 0
 
 	// Build an Arrow Record from an OTEL entity.
@@ -455,17 +965,7 @@ func recordBuilder[T pmetric.Metrics | plog.Logs | ptrace.Traces](builder func()
 	// If a dictionary overflow is observed (see AdaptiveSchema, index type), during
 	// the conversion, the record must be build again with an updated schema.
 	for {
-		var tb acommon.EntityBuilder[T]
-
-		if tb, err = builder(); err != nil {
-			return
-		}
-
-		if err = tb.Append(entity); err != nil {
-			return
-		}
-
-		record, err = tb.Build()
+		record, err = build()
 		if err != nil {
 			if record != nil {
 				record.Release()
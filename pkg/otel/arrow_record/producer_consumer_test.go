@@ -15,23 +15,50 @@
 package arrow_record
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
 	"github.com/apache/arrow/go/v12/arrow/memory"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/protobuf/proto"
 
 	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/config"
 	"github.com/f5/otel-arrow-adapter/pkg/datagen"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/assert"
+	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	cfg "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/logs/logmetrics"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+	metricsotlp "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/redact"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/sanitize"
+	pstats "github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+	tracesarrow "github.com/f5/otel-arrow-adapter/pkg/otel/traces/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/servicegraph"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/traces/spanmetrics"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
 )
 
 // Fuzz-tests the consumer on a sequence of two OTLP protobuf inputs.
@@ -375,3 +402,2091 @@ func TestProducerConsumerMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestProducerConsumerBufferRecycling(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	dg := datagen.NewLogsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+
+	// Check memory leak issue.
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	producer := NewProducerWithOptions(config.WithAllocator(pool), config.WithBufferRecycling(1<<20))
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+	consumer := NewConsumer()
+
+	// The buffers of the first batches are recycled by the next ones.
+	for i := 0; i < 5; i++ {
+		logs := dg.Generate(10, time.Minute)
+
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+
+		received, err := consumer.LogsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+
+		assert.Equiv(
+			t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+		)
+	}
+}
+
+func TestProducerConsumerDecodeConcurrency(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	metricsGen := datagen.NewMetricsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+
+	producer := NewProducer()
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+	consumer := NewConsumerWithOptions(WithDecodeConcurrency(4))
+
+	for i := 0; i < 3; i++ {
+		traces := tracesGen.Generate(100, time.Minute)
+		batch, err := producer.BatchArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+
+		receivedTraces, err := consumer.TracesFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(receivedTraces))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+			[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+		)
+
+		metrics := metricsGen.GenerateAllKindOfMetrics(100, time.Minute)
+		batch, err = producer.BatchArrowRecordsFromMetrics(metrics)
+		require.NoError(t, err)
+
+		receivedMetrics, err := consumer.MetricsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(receivedMetrics))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(receivedMetrics[0])},
+		)
+	}
+}
+
+func TestProducerConsumerAttributesCache(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := tracesGen.Generate(100, time.Minute)
+
+	producer := NewProducer()
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+	consumer := NewConsumerWithOptions(WithAttributesCache(16))
+
+	// The same traces are sent repeatedly, the resource and scope
+	// attributes payloads of the last batches are identical.
+	const batches = 5
+	for i := 0; i < batches; i++ {
+		batch, err := producer.BatchArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+
+		receivedTraces, err := consumer.TracesFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(receivedTraces))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+			[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+		)
+	}
+
+	require.Greater(t, consumer.attrsCache.Len(), 0)
+	require.Less(t, consumer.attrsCache.Len(), 2*batches)
+}
+
+func TestProducerConsumerTraceIDIndex(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := tracesGen.Generate(100, time.Minute)
+
+	producer := NewProducerWithOptions(config.WithTraceIDIndex())
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+	consumer := NewConsumer()
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	receivedTraces, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedTraces))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+	)
+
+	// Collect the trace IDs of the received spans in traversal order.
+	var traceIDs [][16]byte
+	for i, rss := 0, receivedTraces[0].ResourceSpans(); i < rss.Len(); i++ {
+		for j, sss := 0, rss.At(i).ScopeSpans(); j < sss.Len(); j++ {
+			for k, spans := 0, sss.At(j).Spans(); k < spans.Len(); k++ {
+				traceIDs = append(traceIDs, spans.At(k).TraceID())
+			}
+		}
+	}
+
+	index := consumer.TraceIDIndex()
+	require.NotEmpty(t, index)
+	indexed := 0
+	for _, r := range index {
+		require.LessOrEqual(t, int(r.Start+r.Count), len(traceIDs))
+		for pos := r.Start; pos < r.Start+r.Count; pos++ {
+			require.Equal(t, r.TraceID, traceIDs[pos])
+		}
+		require.Contains(t, index.Ranges(r.TraceID), r)
+		indexed += int(r.Count)
+	}
+	require.Equal(t, len(traceIDs), indexed)
+
+	// Without the option, no index is sent.
+	producer2 := NewProducer()
+	defer func() { require.NoError(t, producer2.Close()) }()
+	consumer2 := NewConsumer()
+	batch, err = producer2.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	_, err = consumer2.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Nil(t, consumer2.TraceIDIndex())
+}
+
+func TestProducerConsumerSpanMetrics(t *testing.T) {
+	traces := ptrace.NewTraces()
+	start := time.Unix(1000, 0)
+	// More than two resources, the delta-encoded resource IDs repeat.
+	services := []string{"frontend", "backend", "gateway"}
+	for _, service := range services {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		spans := rs.ScopeSpans().AppendEmpty().Spans()
+		for i, ms := range []int{1, 5, 300} {
+			span := spans.AppendEmpty()
+			span.SetName("GET")
+			span.SetKind(ptrace.SpanKindServer)
+			span.SetTraceID([16]byte{1, byte(i)})
+			span.SetSpanID([8]byte{1, byte(i)})
+			span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+			span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(time.Duration(ms) * time.Millisecond)))
+			if i == 2 {
+				span.Status().SetCode(ptrace.StatusCodeError)
+			}
+		}
+	}
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	bounds := []time.Duration{2 * time.Millisecond, 100 * time.Millisecond}
+	agg := spanmetrics.NewAggregator(bounds, 0)
+	consumer := NewConsumerWithOptions(WithSpanMetrics(agg))
+	// The same spans aggregated from their OTLP representation.
+	pdataAgg := spanmetrics.NewAggregator(bounds, 0)
+
+	const batches = 2
+	for i := 0; i < batches; i++ {
+		batch, err := producer.BatchArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+
+		receivedTraces, err := consumer.TracesFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(receivedTraces))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+			[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+		)
+		pdataAgg.AggregateTraces(traces)
+	}
+
+	for _, md := range []pmetric.Metrics{agg.Metrics(time.Now()), pdataAgg.Metrics(time.Now())} {
+		require.Equal(t, len(services), md.ResourceMetrics().Len())
+		for i, service := range []string{"backend", "frontend", "gateway"} {
+			rm := md.ResourceMetrics().At(i)
+			name, ok := rm.Resource().Attributes().Get("service.name")
+			require.True(t, ok)
+			require.Equal(t, service, name.Str())
+
+			metrics := rm.ScopeMetrics().At(0).Metrics()
+			require.Equal(t, 3, metrics.Len())
+
+			calls := metrics.At(0)
+			require.Equal(t, spanmetrics.CallsMetric, calls.Name())
+			require.Equal(t, 1, calls.Sum().DataPoints().Len())
+			require.Equal(t, int64(3*batches), calls.Sum().DataPoints().At(0).IntValue())
+			kind, ok := calls.Sum().DataPoints().At(0).Attributes().Get(spanmetrics.SpanKindKey)
+			require.True(t, ok)
+			require.Equal(t, ptrace.SpanKindServer.String(), kind.Str())
+
+			errors := metrics.At(1)
+			require.Equal(t, spanmetrics.ErrorsMetric, errors.Name())
+			require.Equal(t, int64(batches), errors.Sum().DataPoints().At(0).IntValue())
+
+			duration := metrics.At(2)
+			require.Equal(t, spanmetrics.DurationMetric, duration.Name())
+			hdp := duration.Histogram().DataPoints().At(0)
+			require.Equal(t, uint64(3*batches), hdp.Count())
+			require.Equal(t, float64(306*batches), hdp.Sum())
+			require.Equal(t, []uint64{batches, batches, batches}, hdp.BucketCounts().AsRaw())
+		}
+	}
+}
+
+// TestSpanMetricsExpiry checks that the series without new spans are removed
+// once expired, and restart with a new start time.
+func TestSpanMetricsExpiry(t *testing.T) {
+	newTraces := func(service string) ptrace.Traces {
+		traces := ptrace.NewTraces()
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetName("GET")
+		return traces
+	}
+
+	agg := spanmetrics.NewAggregator(nil, time.Minute)
+	agg.AggregateTraces(newTraces("frontend"))
+	agg.AggregateTraces(newTraces("backend"))
+	require.Equal(t, 2, agg.Metrics(time.Now()).ResourceMetrics().Len())
+
+	// Both series are expired an hour later.
+	require.Equal(t, 0, agg.Metrics(time.Now().Add(time.Hour)).ResourceMetrics().Len())
+
+	agg.AggregateTraces(newTraces("frontend"))
+	md := agg.Metrics(time.Now())
+	require.Equal(t, 1, md.ResourceMetrics().Len())
+	calls := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+	require.Equal(t, int64(1), calls.Sum().DataPoints().At(0).IntValue())
+}
+
+func TestProducerConsumerLogMetrics(t *testing.T) {
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i, severity := range []plog.SeverityNumber{
+		plog.SeverityNumberDebug,
+		plog.SeverityNumberInfo,
+		plog.SeverityNumberWarn,
+		plog.SeverityNumberError,
+		plog.SeverityNumberFatal,
+	} {
+		lr := records.AppendEmpty()
+		lr.SetTimestamp(pcommon.Timestamp(1000 + i))
+		lr.SetSeverityNumber(severity)
+		lr.Body().SetStr(fmt.Sprintf("log %d", i))
+		if i%2 == 0 {
+			lr.Attributes().PutStr("component", "db")
+		} else {
+			lr.Attributes().PutStr("component", "http")
+		}
+	}
+
+	counter, err := logmetrics.NewCounter(
+		logmetrics.Rule{Name: "logs"},
+		logmetrics.Rule{Name: "errors", MinSeverity: plog.SeverityNumberError},
+		logmetrics.Rule{Name: "db", Attributes: map[string]string{"component": "db"}},
+		logmetrics.Rule{Name: "db_errors", MinSeverity: plog.SeverityNumberError, Attributes: map[string]string{"component": "db"}},
+	)
+	require.NoError(t, err)
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumerWithOptions(WithLogMetrics(counter))
+
+	const batches = 2
+	for i := 0; i < batches; i++ {
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+
+		receivedLogs, err := consumer.LogsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(receivedLogs))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(receivedLogs[0])},
+		)
+	}
+
+	metrics := counter.Metrics(time.Now()).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 4, metrics.Len())
+	for i, expected := range []int64{5, 2, 3, 1} {
+		require.Equal(t, expected*batches, metrics.At(i).Sum().DataPoints().At(0).IntValue(), metrics.At(i).Name())
+	}
+
+	_, err = logmetrics.NewCounter(logmetrics.Rule{Name: "logs"}, logmetrics.Rule{Name: "logs"})
+	require.ErrorIs(t, err, logmetrics.ErrDuplicateRuleName)
+	_, err = logmetrics.NewCounter(logmetrics.Rule{})
+	require.ErrorIs(t, err, logmetrics.ErrMissingRuleName)
+}
+
+func TestProducerConsumerServiceGraph(t *testing.T) {
+	traces := ptrace.NewTraces()
+	newSpans := func(service string) ptrace.SpanSlice {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		return rs.ScopeSpans().AppendEmpty().Spans()
+	}
+	newSpan := func(spans ptrace.SpanSlice, id, parentID byte, kind ptrace.SpanKind) ptrace.Span {
+		span := spans.AppendEmpty()
+		span.SetName("op")
+		span.SetTraceID([16]byte{1})
+		span.SetSpanID([8]byte{id})
+		if parentID != 0 {
+			span.SetParentSpanID([8]byte{parentID})
+		}
+		span.SetKind(kind)
+		return span
+	}
+
+	// frontend calls backend twice (one failure), backend produces a
+	// message consumed by worker, and worker links to a frontend span.
+	frontend := newSpans("frontend")
+	newSpan(frontend, 1, 0, ptrace.SpanKindServer)
+	newSpan(frontend, 2, 1, ptrace.SpanKindClient)
+	newSpan(frontend, 3, 1, ptrace.SpanKindClient).Status().SetCode(ptrace.StatusCodeError)
+	backend := newSpans("backend")
+	newSpan(backend, 4, 2, ptrace.SpanKindServer)
+	newSpan(backend, 5, 3, ptrace.SpanKindServer)
+	newSpan(backend, 6, 4, ptrace.SpanKindProducer)
+	worker := newSpans("worker")
+	consumerSpan := newSpan(worker, 7, 6, ptrace.SpanKindConsumer)
+	link := consumerSpan.Links().AppendEmpty()
+	link.SetTraceID([16]byte{1})
+	link.SetSpanID([8]byte{1})
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	agg := servicegraph.NewAggregator()
+	consumer := NewConsumerWithOptions(WithServiceGraph(agg))
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	receivedTraces, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedTraces))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+	)
+
+	require.Equal(t, []servicegraph.EdgeCounts{
+		{Edge: servicegraph.Edge{Client: "backend", Server: "worker", ConnectionType: servicegraph.ConnectionMessaging}, Requests: 1},
+		{Edge: servicegraph.Edge{Client: "frontend", Server: "backend", ConnectionType: servicegraph.ConnectionRequest}, Requests: 2, Failed: 1},
+		{Edge: servicegraph.Edge{Client: "frontend", Server: "worker", ConnectionType: servicegraph.ConnectionLink}, Requests: 1},
+	}, agg.Edges())
+
+	metrics := agg.Metrics(time.Now()).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, metrics.Len())
+	require.Equal(t, servicegraph.RequestsMetric, metrics.At(0).Name())
+	require.Equal(t, 3, metrics.At(0).Sum().DataPoints().Len())
+	require.Equal(t, servicegraph.FailedRequestsMetric, metrics.At(1).Name())
+	require.Equal(t, int64(1), metrics.At(1).Sum().DataPoints().At(1).IntValue())
+}
+
+func TestProducerConsumerTraceCompleteness(t *testing.T) {
+	newTraces := func(spans ...[3]byte) ptrace.Traces {
+		traces := ptrace.NewTraces()
+		ss := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+		for _, s := range spans {
+			span := ss.AppendEmpty()
+			span.SetName("op")
+			span.SetTraceID([16]byte{s[0]})
+			span.SetSpanID([8]byte{s[1]})
+			if s[2] != 0 {
+				span.SetParentSpanID([8]byte{s[2]})
+			}
+		}
+		return traces
+	}
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	tracker := completeness.NewTracker(completeness.Config{CompletionDelay: time.Second, Timeout: 2 * time.Second})
+	var notified []completeness.TraceStatus
+	tracker.AddListener(func(trace completeness.TraceStatus) {
+		notified = append(notified, trace)
+	})
+	consumer := NewConsumerWithOptions(WithTraceCompleteness(tracker))
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	// The root span of trace 1 arrives in the second batch, trace 2 never
+	// gets its root span.
+	for _, traces := range []ptrace.Traces{
+		newTraces([3]byte{1, 2, 1}, [3]byte{1, 3, 2}, [3]byte{2, 5, 4}),
+		newTraces([3]byte{1, 1, 0}, [3]byte{2, 6, 4}),
+	} {
+		batch, err := producer.BatchArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+		_, err = consumer.TracesFrom(batch)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, completeness.Counts{Active: 2}, tracker.Counts())
+	require.Empty(t, tracker.Sweep(time.Now()))
+
+	// Trace 1 is complete after the completion delay, trace 2 is
+	// incomplete after the timeout.
+	settled := tracker.Sweep(time.Now().Add(1500 * time.Millisecond))
+	require.Equal(t, 1, len(settled))
+	require.Equal(t, pcommon.TraceID([16]byte{1}), settled[0].TraceID)
+	require.True(t, settled[0].Root)
+	require.Equal(t, uint64(3), settled[0].Spans)
+
+	settled = tracker.Sweep(time.Now().Add(time.Minute))
+	require.Equal(t, 1, len(settled))
+	require.Equal(t, pcommon.TraceID([16]byte{2}), settled[0].TraceID)
+	require.False(t, settled[0].Root)
+	require.Equal(t, uint64(2), settled[0].Spans)
+
+	require.Equal(t, 2, len(notified))
+	require.Equal(t, completeness.Counts{Complete: 1, Incomplete: 1}, tracker.Counts())
+
+	metrics := tracker.Metrics(time.Now()).ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 4, metrics.Len())
+	require.Equal(t, completeness.CompleteMetric, metrics.At(0).Name())
+	require.Equal(t, int64(1), metrics.At(0).Sum().DataPoints().At(0).IntValue())
+	require.Equal(t, completeness.ActiveMetric, metrics.At(3).Name())
+	require.Equal(t, int64(0), metrics.At(3).Gauge().DataPoints().At(0).IntValue())
+}
+
+func TestProducerConsumerSanitizer(t *testing.T) {
+	future := time.Now().Add(2 * sanitize.DefaultMaxClockSkew)
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+
+	invalid := spans.AppendEmpty()
+	invalid.SetName("bad\xffname")
+	invalid.Attributes().PutStr("key", "val\xfe")
+	invalid.SetStartTimestamp(1000)
+	invalid.SetEndTimestamp(2000)
+
+	negative := spans.AppendEmpty()
+	negative.SetName("negative")
+	negative.SetStartTimestamp(5000)
+	negative.SetEndTimestamp(3000)
+
+	absurd := spans.AppendEmpty()
+	absurd.SetName("absurd")
+	absurd.SetStartTimestamp(pcommon.NewTimestampFromTime(future))
+	absurd.SetEndTimestamp(pcommon.NewTimestampFromTime(future.Add(10)))
+
+	sanitizer := sanitize.NewSanitizer(sanitize.DefaultConfig())
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumerWithOptions(WithSanitizer(sanitizer))
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	receivedTraces, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedTraces))
+
+	received := map[string]ptrace.Span{}
+	receivedSpans := receivedTraces[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	for i := 0; i < receivedSpans.Len(); i++ {
+		received[receivedSpans.At(i).Name()] = receivedSpans.At(i)
+	}
+
+	span, ok := received["bad\uFFFDname"]
+	require.True(t, ok)
+	value, ok := span.Attributes().Get("key")
+	require.True(t, ok)
+	require.Equal(t, "val\uFFFD", value.Str())
+	require.Equal(t, pcommon.Timestamp(2000), span.EndTimestamp())
+
+	span = received["negative"]
+	require.Equal(t, pcommon.Timestamp(5000), span.StartTimestamp())
+	require.Equal(t, pcommon.Timestamp(5000), span.EndTimestamp())
+
+	span = received["absurd"]
+	require.Less(t, span.StartTimestamp().AsTime(), future)
+	require.Equal(t, span.StartTimestamp()+10, span.EndTimestamp())
+
+	require.Equal(t, sanitize.Counts{
+		InvalidUTF8:       2,
+		ClampedTimestamps: 1,
+		NegativeDurations: 1,
+	}, sanitizer.Counts())
+}
+
+func TestProducerConsumerRedaction(t *testing.T) {
+	const card = "1234-5678-9012-3456"
+
+	traces := ptrace.NewTraces()
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+	for i := 0; i < 3; i++ {
+		span := spans.AppendEmpty()
+		span.SetName(fmt.Sprintf("span-%d", i))
+		// Distinct values which are equal once redacted.
+		span.Attributes().PutStr("card", fmt.Sprintf("card 1234-5678-9012-345%d", i))
+		span.Attributes().PutStr("user", fmt.Sprintf("user-%d", i))
+		span.Attributes().PutEmptyMap("payment").PutStr("card", card)
+		span.Status().SetMessage("declined: " + card)
+	}
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 3; i++ {
+		records.AppendEmpty().Body().SetStr(fmt.Sprintf("payment %d with card %s", i, card))
+	}
+
+	redactionCfg := redact.DefaultConfig()
+	redactionCfg.AllowAllKeys = false
+	redactionCfg.AllowedKeys = []string{"service.name", "card", "payment"}
+	redactionCfg.BlockedValues = []string{"[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{4}"}
+	redactor, err := redact.NewRedactor(redactionCfg)
+	require.NoError(t, err)
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumerWithOptions(WithRedactor(redactor))
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	receivedTraces, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedTraces))
+
+	receivedRS := receivedTraces[0].ResourceSpans().At(0)
+	service, ok := receivedRS.Resource().Attributes().Get("service.name")
+	require.True(t, ok)
+	require.Equal(t, "checkout", service.Str())
+
+	received := map[string]ptrace.Span{}
+	receivedSpans := receivedRS.ScopeSpans().At(0).Spans()
+	for i := 0; i < receivedSpans.Len(); i++ {
+		received[receivedSpans.At(i).Name()] = receivedSpans.At(i)
+	}
+	require.Equal(t, 3, len(received))
+	for i := 0; i < 3; i++ {
+		span, ok := received[fmt.Sprintf("span-%d", i)]
+		require.True(t, ok)
+		require.Equal(t, 3, span.Attributes().Len())
+		value, _ := span.Attributes().Get("card")
+		require.Equal(t, "card ****", value.Str())
+		value, _ = span.Attributes().Get("user")
+		require.Equal(t, "****", value.Str())
+		value, _ = span.Attributes().Get("payment")
+		require.Equal(t, map[string]interface{}{"card": "****"}, value.Map().AsRaw())
+		require.Equal(t, "declined: ****", span.Status().Message())
+	}
+
+	batch, err = producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	receivedLogs, err := consumer.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedLogs))
+
+	receivedRecords := receivedLogs[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 3, receivedRecords.Len())
+	for i := 0; i < receivedRecords.Len(); i++ {
+		require.Regexp(t, `^payment [0-9] with card \*\*\*\*$`, receivedRecords.At(i).Body().Str())
+	}
+
+	redactionCfg.DictionarySize = 0
+	_, err = redact.NewRedactor(redactionCfg)
+	require.ErrorIs(t, err, redact.ErrInvalidDictionarySize)
+}
+
+func TestProducerConsumerEmptyResourceScope(t *testing.T) {
+	traces := ptrace.NewTraces()
+	for i := 0; i < 3; i++ {
+		rs := traces.ResourceSpans().AppendEmpty()
+		for j := 0; j < 2; j++ {
+			span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+			span.SetName(fmt.Sprintf("span-%d-%d", i, j))
+		}
+	}
+	named := traces.ResourceSpans().AppendEmpty()
+	named.Resource().Attributes().PutStr("service.name", "svc")
+	named.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("named")
+
+	producer := NewProducerWithOptions(config.WithEmptyResourceScope(config.EmptyPerRecord))
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	receivedTraces, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedTraces))
+
+	received := receivedTraces[0].ResourceSpans()
+	require.Equal(t, 4, received.Len())
+
+	names := map[string]bool{}
+	for i := 0; i < received.Len(); i++ {
+		rs := received.At(i)
+		if rs.Resource().Attributes().Len() == 0 {
+			require.Equal(t, 2, rs.ScopeSpans().Len())
+		} else {
+			require.Equal(t, 1, rs.ScopeSpans().Len())
+		}
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+			require.Equal(t, 1, spans.Len())
+			names[spans.At(0).Name()] = true
+		}
+	}
+	require.Equal(t, 7, len(names))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+	)
+}
+
+func TestProducerConsumerUint64IDs(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := tracesGen.Generate(100, time.Minute)
+
+	producer := NewProducerWithOptions(config.WithExperimentalUint64IDs())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	received, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(received[0])},
+	)
+
+	// The fixed size binary columns are replaced by the uint64 columns.
+	batch, err = producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	rms, err := consumer.Consume(batch)
+	require.NoError(t, err)
+	for _, rm := range rms {
+		if rm.PayloadType() == arrowpb.ArrowPayloadType_SPANS {
+			s := rm.Record().Schema()
+			for _, name := range []string{constants.TraceId, constants.SpanId, constants.ParentSpanId} {
+				require.False(t, s.HasField(name), name)
+			}
+			for _, name := range []string{constants.TraceIdHigh, constants.TraceIdLow, constants.SpanIdUint64} {
+				require.True(t, s.HasField(name), name)
+			}
+		}
+		rm.Record().Release()
+	}
+}
+
+// BenchmarkTraceIDEncoding compares the size and the decoding cost of the
+// fixed size binary and uint64 encodings of the trace and span IDs.
+func BenchmarkTraceIDEncoding(b *testing.B) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := dg.Generate(1000, time.Minute)
+
+	for _, bc := range []struct {
+		name    string
+		options []config.Option
+	}{
+		{name: "fixed_size_binary"},
+		{name: "uint64", options: []config.Option{config.WithExperimentalUint64IDs()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			producer := NewProducerWithOptions(bc.options...)
+			defer func() { require.NoError(b, producer.Close()) }()
+			consumer := NewConsumer()
+			defer func() { require.NoError(b, consumer.Close()) }()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			size := 0
+			for i := 0; i < b.N; i++ {
+				batch, err := producer.BatchArrowRecordsFromTraces(traces)
+				require.NoError(b, err)
+				size += proto.Size(batch)
+
+				_, err = consumer.TracesFrom(batch)
+				require.NoError(b, err)
+			}
+			b.ReportMetric(float64(size)/float64(b.N), "bytes/batch")
+		})
+	}
+}
+
+// BenchmarkConsumerAttributesCache measures the allocations saved by the
+// attributes cache on a stream repeatedly sending the same resources and
+// scopes.
+func BenchmarkConsumerAttributesCache(b *testing.B) {
+	ent := datagen.NewTestEntropy(12345)
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+
+	producer := NewProducer()
+	defer func() { _ = producer.Close() }()
+	batches := make([]*arrowpb.BatchArrowRecords, 10)
+	for i := range batches {
+		batch, err := producer.BatchArrowRecordsFromTraces(tracesGen.Generate(100, time.Minute))
+		require.NoError(b, err)
+		batches[i] = batch
+	}
+
+	for _, bench := range []struct {
+		name string
+		opts []ConsumerOption
+	}{
+		{"no_cache", nil},
+		{"cache", []ConsumerOption{WithAttributesCache(16)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				consumer := NewConsumerWithOptions(bench.opts...)
+				for _, batch := range batches {
+					_, err := consumer.TracesFrom(batch)
+					require.NoError(b, err)
+				}
+				require.NoError(b, consumer.Close())
+			}
+		})
+	}
+}
+
+func BenchmarkProducerLogs(b *testing.B) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewLogsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	logs := dg.Generate(100, time.Minute)
+
+	for _, bc := range []struct {
+		name    string
+		options []config.Option
+	}{
+		{name: "default"},
+		{name: "buffer_recycling", options: []config.Option{config.WithBufferRecycling(64 << 20)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			producer := NewProducerWithOptions(bc.options...)
+			defer func() { require.NoError(b, producer.Close()) }()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := producer.BatchArrowRecordsFromLogs(logs)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func TestProducerLastBatchPayloads(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	dg := datagen.NewLogsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	logs := dg.Generate(10, time.Minute)
+
+	producer := NewProducer()
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+	require.Nil(t, producer.LastBatchPayloads())
+
+	batch, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	payloads := producer.LastBatchPayloads()
+	require.Equal(t, len(batch.ArrowPayloads), len(payloads))
+	for i, payload := range payloads {
+		require.Equal(t, batch.ArrowPayloads[i].Type, payload.PayloadType)
+		require.Equal(t, batch.ArrowPayloads[i].SchemaId, payload.SchemaID)
+		require.Equal(t, len(batch.ArrowPayloads[i].Record), payload.Bytes)
+		require.Greater(t, payload.Rows, int64(0))
+	}
+	require.Equal(t, arrowpb.ArrowPayloadType_LOGS, payloads[0].PayloadType)
+	require.Equal(t, int64(logs.LogRecordCount()), payloads[0].Rows)
+}
+
+func TestProducerSortOrder(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	metricsGen := datagen.NewMetricsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+
+	producer := NewProducer()
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+
+	tracesBatch, err := producer.BatchArrowRecordsFromTraces(tracesGen.Generate(100, time.Minute))
+	require.NoError(t, err)
+	metricsBatch, err := producer.BatchArrowRecordsFromMetrics(metricsGen.GenerateAllKindOfMetrics(10, time.Minute))
+	require.NoError(t, err)
+
+	expected := map[arrowpb.ArrowPayloadType][]string{
+		arrowpb.ArrowPayloadType_SPAN_EVENTS:               {constants.Name, constants.ParentID},
+		arrowpb.ArrowPayloadType_SPAN_LINKS:                {constants.TraceId, constants.ParentID},
+		arrowpb.ArrowPayloadType_NUMBER_DATA_POINTS:        {constants.ParentID},
+		arrowpb.ArrowPayloadType_SUMMARY_DATA_POINTS:       {constants.ParentID},
+		arrowpb.ArrowPayloadType_HISTOGRAM_DATA_POINTS:     {constants.ParentID},
+		arrowpb.ArrowPayloadType_EXP_HISTOGRAM_DATA_POINTS: {constants.ParentID},
+	}
+
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	checked := 0
+	for _, batch := range []*arrowpb.BatchArrowRecords{tracesBatch, metricsBatch} {
+		rms, err := consumer.Consume(batch)
+		require.NoError(t, err)
+
+		for _, rm := range rms {
+			sortOrder, ok := expected[rm.PayloadType()]
+			if !ok {
+				continue
+			}
+			record := rm.Record()
+			require.Equal(t, sortOrder, schema.SortOrderFrom(record.Schema()), rm.PayloadType().String())
+			checked++
+
+			// The most significant column is checked directly, the parent
+			// IDs are delta encoded in the order declared by the schema.
+			if len(sortOrder) < 2 {
+				continue
+			}
+			fieldID, err := arrowutils.FieldIDFromSchema(record.Schema(), sortOrder[0])
+			require.NoError(t, err)
+			var prev []byte
+			for row := 0; row < int(record.NumRows()); row++ {
+				var value []byte
+				switch sortOrder[0] {
+				case constants.Name:
+					name, err := arrowutils.StringFromRecord(record, fieldID, row)
+					require.NoError(t, err)
+					value = []byte(name)
+				default:
+					value, err = arrowutils.FixedSizeBinaryFromRecord(record, fieldID, row)
+					require.NoError(t, err)
+				}
+				require.LessOrEqual(t, bytes.Compare(prev, value), 0, rm.PayloadType().String())
+				prev = value
+			}
+		}
+	}
+	require.Greater(t, checked, 0)
+}
+
+func TestProducerConsumerNoSort(t *testing.T) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := dg.Generate(100, time.Minute)
+
+	producer := NewProducerWithOptions(config.WithNoSort())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	received, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(received[0])},
+	)
+}
+
+// spansByNameDesc is a custom span sorter counting its calls.
+type spansByNameDesc struct {
+	calls int
+}
+
+func (s *spansByNameDesc) Sort(spans []*tracesarrow.FlattenedSpan) {
+	s.calls++
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Span.Name() > spans[j].Span.Name()
+	})
+}
+
+func TestProducerConsumerCustomSorter(t *testing.T) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := dg.Generate(100, time.Minute)
+
+	sorter := &spansByNameDesc{}
+	tracesarrow.RegisterSpanSorter("test_name_desc", func() tracesarrow.SpanSorter { return sorter })
+	require.Contains(t, tracesarrow.SpanSorterNames(), "test_name_desc")
+
+	producer := NewProducerWithOptions(config.WithSpanSorter("test_name_desc"))
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	// The batch is re-encoded after each schema update.
+	require.GreaterOrEqual(t, sorter.calls, 1)
+
+	received, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(received[0])},
+	)
+
+	// An unregistered sorter is a configuration error.
+	require.Panics(t, func() { NewProducerWithOptions(config.WithMetricSorter("unknown")) })
+}
+
+// recordCollector is a ProducerObserver retaining the produced records.
+type recordCollector struct {
+	records []*record_message.RecordMessage
+}
+
+func (c *recordCollector) OnRecord(record arrow.Record, payloadType record_message.PayloadType) {
+	record.Retain()
+	c.records = append(c.records, record_message.NewRelatedDataMessage(payloadType.String(), record, payloadType))
+}
+
+func TestConsumerFromRecords(t *testing.T) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := dg.Generate(100, time.Minute)
+
+	collector := &recordCollector{}
+	producer := NewProducer()
+	producer.SetObserver(collector)
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	_, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	// The records are decoded without going through the IPC streams.
+	received, err := consumer.TracesFromRecords(collector.records)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(received[0])},
+	)
+}
+
+func TestProducerEncodingOverrides(t *testing.T) {
+	overrides := map[string]string{
+		"spans.name":       schema.PlainEncoding,
+		"span_events.name": schema.PlainEncoding,
+	}
+	require.NoError(t, ValidateEncodingOverrides(overrides))
+	require.True(t, errors.Is(ValidateEncodingOverrides(map[string]string{"spans.id": "delta"}), schema.ErrUnsupportedEncoding))
+	require.True(t, errors.Is(ValidateEncodingOverrides(map[string]string{"spans.nmae": schema.PlainEncoding}), schema.ErrUnknownOverridePath))
+	require.True(t, errors.Is(ValidateEncodingOverrides(map[string]string{"spans.start_time_unix_nano": schema.PlainEncoding}), schema.ErrIneffectiveOverride))
+
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	tracesGen := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := tracesGen.Generate(100, time.Minute)
+
+	producer := NewProducerWithOptions(config.WithEncodingOverrides(overrides))
+	defer func() {
+		if err := producer.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+	rms, err := consumer.Consume(batch)
+	require.NoError(t, err)
+
+	for _, rm := range rms {
+		switch rm.PayloadType() {
+		case arrowpb.ArrowPayloadType_SPANS, arrowpb.ArrowPayloadType_SPAN_EVENTS:
+			fields := rm.Record().Schema().FieldIndices(constants.Name)
+			require.Equal(t, 1, len(fields), rm.PayloadType().String())
+			require.Equal(t, arrow.STRING, rm.Record().Schema().Field(fields[0]).Type.ID(), rm.PayloadType().String())
+		}
+	}
+
+	// The overridden encodings are transparent to the decoders.
+	producer2 := NewProducerWithOptions(config.WithEncodingOverrides(overrides))
+	defer func() {
+		if err := producer2.Close(); err != nil {
+			t.Error("unexpected fail", err)
+		}
+	}()
+	batch, err = producer2.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+
+	received, err := NewConsumer().TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(received[0])},
+	)
+}
+
+func TestProducerConsumerMapFlattening(t *testing.T) {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	require.NoError(t, rl.Resource().Attributes().FromRaw(map[string]any{
+		"service": map[string]any{"name": "checkout", "version": "1.0"},
+	}))
+	lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 10; i++ {
+		lr := lrs.AppendEmpty()
+		lr.Body().SetStr("request")
+		require.NoError(t, lr.Attributes().FromRaw(map[string]any{
+			"http": map[string]any{
+				"method": "GET",
+				"status": int64(200 + i%2),
+				"request": map[string]any{
+					"headers": map[string]any{"accept": "*/*"},
+				},
+			},
+			"http.method": "not nested",
+		}))
+	}
+
+	collector := &recordCollector{}
+	producer := NewProducerWithOptions(config.WithMapFlattening(2))
+	producer.SetObserver(collector)
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	// The nested maps are flattened in the attribute records.
+	flattened := 0
+	for _, rm := range collector.records {
+		switch rm.PayloadType() {
+		case arrowpb.ArrowPayloadType_RESOURCE_ATTRS, arrowpb.ArrowPayloadType_LOG_ATTRS:
+			require.NotEmpty(t, rm.Record().Schema().FieldIndices(constants.AttributeFlattened), rm.PayloadType().String())
+			flattened++
+		}
+	}
+	require.Equal(t, 2, flattened)
+
+	received, err := consumer.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+	)
+}
+
+func expHistogramMetrics() pmetric.Metrics {
+	metrics := pmetric.NewMetrics()
+	ms := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	m := ms.AppendEmpty()
+	m.SetName("latency")
+	eh := m.SetEmptyExponentialHistogram()
+	eh.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+
+	// Fine scale, buckets on both sides of the index 0.
+	dp := eh.DataPoints().AppendEmpty()
+	dp.SetCount(20)
+	dp.SetSum(42)
+	dp.SetScale(3)
+	dp.SetZeroCount(5)
+	dp.SetZeroThreshold(0.001)
+	dp.Positive().SetOffset(-3)
+	dp.Positive().BucketCounts().FromRaw([]uint64{1, 2, 3, 4, 5})
+	dp.SetMin(0.5)
+	dp.SetMax(12)
+
+	// Negative scale and negative buckets only.
+	dp = eh.DataPoints().AppendEmpty()
+	dp.SetCount(3)
+	dp.SetScale(-2)
+	dp.Negative().SetOffset(2)
+	dp.Negative().BucketCounts().FromRaw([]uint64{1, 0, 2})
+
+	// Only zeros, no buckets.
+	dp = eh.DataPoints().AppendEmpty()
+	dp.SetCount(7)
+	dp.SetScale(5)
+	dp.SetZeroCount(7)
+	dp.SetZeroThreshold(0.25)
+	dp.SetSum(0)
+	dp.SetMin(0)
+	dp.SetMax(0)
+
+	return metrics
+}
+
+func TestProducerConsumerExpHistogramScale(t *testing.T) {
+	metrics := expHistogramMetrics()
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+	require.NoError(t, err)
+	received, err := consumer.MetricsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	assert.Equiv(
+		t,
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+		[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+	)
+}
+
+// TestProducerConsumerExpHistogramOptionalFields checks that the flags, min,
+// max and zero threshold of the exponential histogram data points round-trip,
+// and that their optional columns are not part of the schema when the fields
+// are absent.
+func TestProducerConsumerExpHistogramOptionalFields(t *testing.T) {
+	newMetrics := func(withOptionalFields bool) pmetric.Metrics {
+		metrics := pmetric.NewMetrics()
+		m := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("latency")
+		eh := m.SetEmptyExponentialHistogram()
+		eh.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+		dp := eh.DataPoints().AppendEmpty()
+		dp.SetCount(2)
+		dp.SetZeroCount(2)
+		if withOptionalFields {
+			dp.SetFlags(pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+			dp.SetMin(0)
+			dp.SetZeroThreshold(1e-9)
+
+			dp = eh.DataPoints().AppendEmpty()
+			dp.SetCount(1)
+			dp.SetScale(2)
+			dp.Positive().BucketCounts().FromRaw([]uint64{1})
+			dp.SetMax(1.5)
+		}
+		return metrics
+	}
+
+	for _, withOptionalFields := range []bool{false, true} {
+		metrics := newMetrics(withOptionalFields)
+
+		collector := &recordCollector{}
+		producer := NewProducer()
+		producer.SetObserver(collector)
+		consumer := NewConsumer()
+
+		batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+		require.NoError(t, err)
+
+		dpRecords := 0
+		for _, rm := range collector.records {
+			if rm.PayloadType() == arrowpb.ArrowPayloadType_EXP_HISTOGRAM_DATA_POINTS {
+				dpRecords++
+				schema := rm.Record().Schema()
+				for _, name := range []string{constants.Flags, constants.HistogramMin, constants.HistogramMax, constants.ExpHistogramZeroThreshold} {
+					require.Equal(t, withOptionalFields, len(schema.FieldIndices(name)) > 0, name)
+				}
+			}
+			rm.Record().Release()
+		}
+		require.Equal(t, 1, dpRecords)
+
+		received, err := consumer.MetricsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+		)
+
+		dps := received[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dp := dps.At(i)
+			switch dp.Count() {
+			case 2:
+				require.Equal(t, withOptionalFields, dp.Flags().NoRecordedValue())
+				require.Equal(t, withOptionalFields, dp.HasMin())
+				require.False(t, dp.HasMax())
+				if withOptionalFields {
+					require.Equal(t, 1e-9, dp.ZeroThreshold())
+				} else {
+					require.Zero(t, dp.ZeroThreshold())
+				}
+			case 1:
+				require.False(t, dp.HasMin())
+				require.Equal(t, 1.5, dp.Max())
+				require.Zero(t, dp.ZeroThreshold())
+			}
+		}
+
+		require.NoError(t, producer.Close())
+		require.NoError(t, consumer.Close())
+	}
+}
+
+func TestProducerConsumerExpHistogramMaxScale(t *testing.T) {
+	metrics := expHistogramMetrics()
+
+	producer := NewProducerWithOptions(config.WithExpHistogramMaxScale(1))
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+	require.NoError(t, err)
+	received, err := consumer.MetricsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+
+	// The input is not modified.
+	require.Equal(t, int32(3), metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).ExponentialHistogram().DataPoints().At(0).Scale())
+
+	dps := received[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).ExponentialHistogram().DataPoints()
+	require.Equal(t, 3, dps.Len())
+
+	byCount := make(map[uint64]pmetric.ExponentialHistogramDataPoint)
+	for i := 0; i < dps.Len(); i++ {
+		byCount[dps.At(i).Count()] = dps.At(i)
+	}
+
+	// Scale 3 -> 1: the buckets -3..1 are merged into the buckets -1..0.
+	dp := byCount[20]
+	require.Equal(t, int32(1), dp.Scale())
+	require.Equal(t, uint64(5), dp.ZeroCount())
+	require.Equal(t, 0.001, dp.ZeroThreshold())
+	require.Equal(t, 42.0, dp.Sum())
+	require.Equal(t, 0.5, dp.Min())
+	require.Equal(t, 12.0, dp.Max())
+	require.Equal(t, int32(-1), dp.Positive().Offset())
+	require.Equal(t, []uint64{6, 9}, dp.Positive().BucketCounts().AsRaw())
+
+	// Scale below the max scale, unchanged.
+	dp = byCount[3]
+	require.Equal(t, int32(-2), dp.Scale())
+	require.Zero(t, dp.ZeroThreshold())
+	require.Equal(t, int32(2), dp.Negative().Offset())
+	require.Equal(t, []uint64{1, 0, 2}, dp.Negative().BucketCounts().AsRaw())
+
+	// No buckets, only the scale changes.
+	dp = byCount[7]
+	require.Equal(t, int32(1), dp.Scale())
+	require.Equal(t, uint64(7), dp.ZeroCount())
+	require.Equal(t, 0.25, dp.ZeroThreshold())
+	require.True(t, dp.HasSum())
+	require.True(t, dp.HasMin())
+	require.True(t, dp.HasMax())
+	require.Equal(t, 0, dp.Positive().BucketCounts().Len())
+}
+
+func TestProducerAnalyzerStatsRecord(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	dg := datagen.NewTracesGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+
+	producer := NewProducerWithOptions(config.WithStats(), config.WithAnalyzerOutput(io.Discard))
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	for i := 0; i < 2; i++ {
+		_, err := producer.BatchArrowRecordsFromTraces(dg.Generate(10, time.Minute))
+		require.NoError(t, err)
+	}
+
+	record := producer.AnalyzerStatsRecord()
+	defer record.Release()
+	require.True(t, record.NumRows() > 0)
+
+	stats, err := carrow.AnalyzerStatsFrom(record)
+	require.NoError(t, err)
+
+	values := make(map[string]float64)
+	for _, stat := range stats {
+		values[stat.Path+"/"+stat.Statistic] = stat.Value
+	}
+	require.Equal(t, 2.0, values["TracesAnalyzer.TraceCount/value"])
+	require.Equal(t, 0.0, values["LogsAnalyzer.LogRecordCount/value"])
+	require.Contains(t, values, "TracesAnalyzer.ResourceSpansStats.ScopeSpansStats.SpanStats.Name.DistinctValue/distinct")
+
+	// No analyzer without stats.
+	noStats := NewProducer()
+	defer func() { require.NoError(t, noStats.Close()) }()
+	empty := noStats.AnalyzerStatsRecord()
+	defer empty.Release()
+	require.Equal(t, int64(0), empty.NumRows())
+}
+
+func TestProducerConsumerStatsSnapshot(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	dg := datagen.NewTracesGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	for i := 0; i < 2; i++ {
+		bar, err := producer.BatchArrowRecordsFromTraces(dg.Generate(10, time.Minute))
+		require.NoError(t, err)
+		_, err = consumer.TracesFrom(bar)
+		require.NoError(t, err)
+	}
+
+	s := pstats.NewStats()
+	s.AddProducerStats(producer.GetAndResetStats())
+	s.AddConsumerStats(consumer.GetAndResetStats())
+
+	doc, err := s.Snapshot()
+	require.NoError(t, err)
+	var snapshot pstats.StatsSnapshot
+	require.NoError(t, json.Unmarshal(doc, &snapshot))
+
+	require.Equal(t, uint64(2), snapshot.Producer.TracesBatches)
+	require.Equal(t, uint64(2), snapshot.Consumer.Batches)
+	spans := arrowpb.ArrowPayloadType_SPANS.String()
+	require.Equal(t, uint64(2), snapshot.Producer.Payloads[spans].Sizes.Count)
+	require.Equal(t, snapshot.Producer.Payloads[spans].Sizes, snapshot.Consumer.Payloads[spans].Sizes)
+	require.True(t, snapshot.Consumer.Payloads[spans].Rows > 0)
+}
+
+func TestProducerAnalyzerDistinctEstimates(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	logsGen := datagen.NewLogsGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+	metricsGen := datagen.NewMetricsGenerator(ent, ent.NewStandardResourceAttributes(), ent.NewStandardInstrumentationScopes())
+
+	producer := NewProducerWithOptions(config.WithStats(), config.WithAnalyzerOutput(io.Discard))
+	defer func() { require.NoError(t, producer.Close()) }()
+
+	for i := 0; i < 2; i++ {
+		_, err := producer.BatchArrowRecordsFromLogs(logsGen.Generate(10, time.Minute))
+		require.NoError(t, err)
+		_, err = producer.BatchArrowRecordsFromMetrics(metricsGen.GenerateAllKindOfMetrics(10, time.Minute))
+		require.NoError(t, err)
+	}
+
+	values := make(map[string]float64)
+	for _, stat := range producer.AnalyzerStats() {
+		values[stat.Path+"/"+stat.Statistic] = stat.Value
+	}
+
+	logRecords := "LogsAnalyzer.ResourceLogsStats.ScopeLogsStats.LogRecordStats"
+	// A batch is analyzed again when its record is rebuilt after a schema
+	// update, the distinct estimates are not affected by these repetitions.
+	require.GreaterOrEqual(t, values[logRecords+".TotalCount/value"], 100.0)
+	require.GreaterOrEqual(t, values[logRecords+".SeverityNumber/distinct"], 4.0)
+	require.Contains(t, values, logRecords+".SeverityText.DistinctValue/distinct")
+
+	metrics := "MetricsAnalyzer.ResourceMetricsStats.ScopeMetricsStats.MetricsStats"
+	require.GreaterOrEqual(t, values[metrics+".TotalCount/value"], 120.0)
+	require.Equal(t, 5.0, values[metrics+".Type/distinct"])
+	require.Equal(t, 6.0, values[metrics+".Name.DistinctValue/distinct"])
+}
+
+func TestProducerConsumerRetention(t *testing.T) {
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	logsGen := datagen.NewLogsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	logs := logsGen.Generate(10, time.Minute)
+
+	for _, retention := range []time.Duration{0, time.Hour} {
+		collector := &recordCollector{}
+		producer := NewProducerWithOptions(config.WithRetention(config.Retention{Logs: retention}))
+		producer.SetObserver(collector)
+		consumer := NewConsumer()
+
+		before := time.Now()
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+		after := time.Now()
+
+		logsRecords := 0
+		for _, rm := range collector.records {
+			if rm.PayloadType() == arrowpb.ArrowPayloadType_LOGS {
+				logsRecords++
+				record := rm.Record()
+				fields := record.Schema().FieldIndices(constants.ExpiryTimeUnixNano)
+				if retention == 0 {
+					require.Empty(t, fields)
+				} else {
+					require.Equal(t, 1, len(fields))
+					expiry, ok := record.Column(fields[0]).(*array.Timestamp)
+					require.True(t, ok)
+					require.Equal(t, 0, expiry.NullN())
+					for i := 0; i < expiry.Len(); i++ {
+						v := int64(expiry.Value(i))
+						require.GreaterOrEqual(t, v, before.Add(retention).UnixNano())
+						require.LessOrEqual(t, v, after.Add(retention).UnixNano())
+					}
+				}
+			}
+			rm.Record().Release()
+		}
+		require.Equal(t, 1, logsRecords)
+
+		// The expiry column is transparent to the decoders.
+		received, err := consumer.LogsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+		)
+
+		require.NoError(t, producer.Close())
+		require.NoError(t, consumer.Close())
+	}
+}
+
+func TestProducerConsumerRunEndEncoding(t *testing.T) {
+	overrides := map[string]string{
+		"metrics.metric_type":             schema.RunEndEncodedEncoding,
+		"metrics.aggregation_temporality": schema.RunEndEncodedEncoding,
+	}
+	require.NoError(t, ValidateEncodingOverrides(overrides))
+	// Not supported on string fields.
+	require.True(t, errors.Is(ValidateEncodingOverrides(map[string]string{"metrics.name": schema.RunEndEncodedEncoding}), schema.ErrIneffectiveOverride))
+
+	ent := datagen.NewTestEntropy(int64(rand.Uint64())) //nolint:gosec // only used for testing
+	metricsGen := datagen.NewMetricsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	metrics := metricsGen.GenerateAllKindOfMetrics(10, time.Minute)
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	collector := &recordCollector{}
+	producer := NewProducerWithOptions(config.WithAllocator(pool), config.WithEncodingOverrides(overrides))
+	producer.SetObserver(collector)
+	consumer := NewConsumer()
+
+	// The second round checks that the runs don't leak between batches.
+	for round := 0; round < 2; round++ {
+		batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+		require.NoError(t, err)
+
+		received, err := consumer.MetricsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+		)
+	}
+
+	metricsRecords := 0
+	for _, rm := range collector.records {
+		if rm.PayloadType() == arrowpb.ArrowPayloadType_METRICS {
+			metricsRecords++
+			s := rm.Record().Schema()
+			for name, typeID := range map[string]arrow.Type{
+				constants.MetricType:             arrow.RUN_END_ENCODED,
+				constants.AggregationTemporality: arrow.RUN_END_ENCODED,
+				constants.Name:                   arrow.DICTIONARY,
+			} {
+				fields := s.FieldIndices(name)
+				require.Equal(t, 1, len(fields), name)
+				require.Equal(t, typeID, s.Field(fields[0]).Type.ID(), name)
+			}
+		}
+		rm.Record().Release()
+	}
+	require.Equal(t, 2, metricsRecords)
+
+	require.NoError(t, producer.Close())
+	require.NoError(t, consumer.Close())
+}
+
+func TestProducerConsumerLargeValues(t *testing.T) {
+	logs := plog.NewLogs()
+	lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 10; i++ {
+		lr := lrs.AppendEmpty()
+		lr.SetTimestamp(pcommon.Timestamp(i))
+		lr.Body().SetStr(fmt.Sprintf("%d:%s", i, strings.Repeat("x", 100)))
+	}
+
+	// The body column exceeds the threshold and is promoted to LargeUtf8.
+	collector := &recordCollector{}
+	producer := NewProducerWithOptions(
+		config.WithNoDictionary(),
+		config.WithLargeValues(cfg.LargeValuesPromote, 256),
+	)
+	producer.SetObserver(collector)
+	consumer := NewConsumer()
+
+	batch, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	largeStrings := false
+	for _, rm := range collector.records {
+		if rm.PayloadType() == arrowpb.ArrowPayloadType_LOGS {
+			largeStrings = strings.Contains(arrowutils.SchemaToID(rm.Record().Schema()), arrowutils.LargeStringSig)
+		}
+		rm.Record().Release()
+	}
+	require.True(t, largeStrings)
+
+	received, err := consumer.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(received))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+	)
+
+	require.NoError(t, producer.Close())
+	require.NoError(t, consumer.Close())
+
+	// With the error policy the batch fails.
+	producer = NewProducerWithOptions(
+		config.WithNoDictionary(),
+		config.WithLargeValues(cfg.LargeValuesError, 256),
+	)
+	_, err = producer.BatchArrowRecordsFromLogs(logs)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, schema.ErrValueTooLarge))
+	require.NoError(t, producer.Close())
+}
+
+func TestProducerConsumerScopeTable(t *testing.T) {
+	traces := ptrace.NewTraces()
+	logs := plog.NewLogs()
+	for i := 0; i < 3; i++ {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", fmt.Sprintf("svc-%d", i))
+		rl := logs.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("service.name", fmt.Sprintf("svc-%d", i))
+		for j, name := range []string{"lib-a", "lib-b"} {
+			ss := rs.ScopeSpans().AppendEmpty()
+			ss.Scope().SetName(name)
+			ss.Scope().SetVersion("1.0")
+			ss.Scope().Attributes().PutInt("lib.index", int64(j))
+			ss.Spans().AppendEmpty().SetName(fmt.Sprintf("span-%d-%d", i, j))
+
+			sl := rl.ScopeLogs().AppendEmpty()
+			ss.Scope().CopyTo(sl.Scope())
+			sl.LogRecords().AppendEmpty().Body().SetStr(fmt.Sprintf("log-%d-%d", i, j))
+		}
+	}
+
+	producer := NewProducerWithOptions(config.WithScopeTable())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	// The 6 scope entries of each batch share 2 rows of the scope table.
+	scopeTableRows := func() int64 {
+		for _, p := range producer.LastBatchPayloads() {
+			if p.PayloadType == arrowpb.ArrowPayloadType_SCOPES {
+				return p.Rows
+			}
+		}
+		return 0
+	}
+
+	batch, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), scopeTableRows())
+
+	receivedTraces, err := consumer.TracesFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedTraces))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(traces)},
+		[]json.Marshaler{ptraceotlp.NewExportRequestFromTraces(receivedTraces[0])},
+	)
+
+	batch, err = producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), scopeTableRows())
+
+	receivedLogs, err := consumer.LogsFrom(batch)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(receivedLogs))
+	assert.Equiv(
+		t,
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+		[]json.Marshaler{plogotlp.NewExportRequestFromLogs(receivedLogs[0])},
+	)
+}
+
+func TestProducerReset(t *testing.T) {
+	logs := plog.NewLogs()
+	lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < 10; i++ {
+		lr := lrs.AppendEmpty()
+		lr.SetTimestamp(pcommon.Timestamp(i))
+		lr.Body().SetStr(fmt.Sprintf("log-%d", i))
+	}
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	schemaIDs := func() map[string]bool {
+		ids := make(map[string]bool)
+		for _, p := range producer.LastBatchPayloads() {
+			ids[p.SchemaID] = true
+		}
+		return ids
+	}
+
+	var previous map[string]bool
+	for i := 0; i < 3; i++ {
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+
+		// The batches following a reset are sent on new streams.
+		current := schemaIDs()
+		for id := range current {
+			require.False(t, previous[id])
+		}
+		previous = current
+
+		received, err := consumer.LogsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+		)
+
+		require.NoError(t, producer.Reset())
+	}
+}
+
+func TestConsumerResourcesFrom(t *testing.T) {
+	logs := plog.NewLogs()
+	for i := 0; i < 2; i++ {
+		rl := logs.ResourceLogs().AppendEmpty()
+		rl.SetSchemaUrl("https://opentelemetry.io/schemas/1.20.0")
+		rl.Resource().Attributes().PutStr("tenant", fmt.Sprintf("tenant-%d", i))
+		for j, name := range []string{"lib-a", "lib-b"} {
+			sl := rl.ScopeLogs().AppendEmpty()
+			sl.Scope().SetName(name)
+			sl.Scope().Attributes().PutInt("lib.index", int64(j))
+			sl.LogRecords().AppendEmpty().Body().SetStr(fmt.Sprintf("log-%d-%d", i, j))
+		}
+	}
+
+	for _, options := range [][]config.Option{nil, {config.WithScopeTable()}} {
+		producer := NewProducerWithOptions(options...)
+		consumer := NewConsumer()
+
+		batch, err := producer.BatchArrowRecordsFromLogs(logs)
+		require.NoError(t, err)
+
+		resources, records, err := consumer.ResourcesFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(resources))
+
+		// The entries may be reordered by the producer.
+		tenants := make(map[string]bool)
+		for _, resource := range resources {
+			tenant, ok := resource.Resource.Attributes().Get("tenant")
+			require.True(t, ok)
+			tenants[tenant.Str()] = true
+			require.Equal(t, "https://opentelemetry.io/schemas/1.20.0", resource.SchemaUrl)
+			require.Equal(t, 2, len(resource.Scopes))
+			for _, scope := range resource.Scopes {
+				index, ok := scope.Scope.Attributes().Get("lib.index")
+				require.True(t, ok)
+				require.Equal(t, fmt.Sprintf("lib-%c", 'a'+index.Int()), scope.Scope.Name())
+			}
+		}
+		require.Equal(t, map[string]bool{"tenant-0": true, "tenant-1": true}, tenants)
+
+		// The records are still decodable after the partial decoding.
+		received, err := consumer.LogsFromRecords(records)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(logs)},
+			[]json.Marshaler{plogotlp.NewExportRequestFromLogs(received[0])},
+		)
+
+		require.NoError(t, consumer.Close())
+		require.NoError(t, producer.Close())
+	}
+}
+
+func TestConsumerRecordsFrom(t *testing.T) {
+	logs := plog.NewLogs()
+	sl := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	bodies := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		lr := sl.LogRecords().AppendEmpty()
+		lr.SetSeverityText("INFO")
+		lr.Body().SetStr(fmt.Sprintf("log-%d", i))
+		bodies[fmt.Sprintf("log-%d", i)] = true
+	}
+
+	producer := NewProducer()
+	consumer := NewConsumer()
+
+	batch, err := producer.BatchArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+
+	records, err := consumer.RecordsFrom(batch)
+	require.NoError(t, err)
+	require.Contains(t, records.PayloadTypes(), arrowpb.ArrowPayloadType_LOGS)
+
+	column, err := records.Column(arrowpb.ArrowPayloadType_LOGS, "body.str")
+	require.NoError(t, err)
+	require.True(t, column.Present())
+	require.Equal(t, 3, column.Len())
+	received := make(map[string]bool)
+	for row := 0; row < column.Len(); row++ {
+		body, err := column.String(row)
+		require.NoError(t, err)
+		received[body] = true
+	}
+	require.Equal(t, bodies, received)
+
+	// The absent columns return zero values.
+	column, err = records.Column(arrowpb.ArrowPayloadType_LOGS, "body.bytes")
+	require.NoError(t, err)
+	require.False(t, column.Present())
+	require.Equal(t, 0, column.Len())
+	body, err := column.Binary(0)
+	require.NoError(t, err)
+	require.Nil(t, body)
+
+	_, err = records.Column(arrowpb.ArrowPayloadType_LOGS, "severity_text.value")
+	require.ErrorIs(t, err, ErrInvalidColumnPath)
+
+	records.Release()
+	require.NoError(t, consumer.Close())
+	require.NoError(t, producer.Close())
+}
+
+func seriesIDsMetrics() pmetric.Metrics {
+	metrics := pmetric.NewMetrics()
+	ms := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	gauge := ms.AppendEmpty()
+	gauge.SetName("gauge")
+	gdps := gauge.SetEmptyGauge().DataPoints()
+	for i := 0; i < 2; i++ {
+		dp := gdps.AppendEmpty()
+		dp.Attributes().PutStr("host", fmt.Sprintf("host-%d", i))
+		dp.SetIntValue(int64(i))
+	}
+
+	histogram := ms.AppendEmpty()
+	histogram.SetName("histogram")
+	hdp := histogram.SetEmptyHistogram().DataPoints().AppendEmpty()
+	hdp.Attributes().PutStr("host", "host-0")
+	hdp.SetCount(1)
+
+	ehistogram := ms.AppendEmpty()
+	ehistogram.SetName("ehistogram")
+	ehdp := ehistogram.SetEmptyExponentialHistogram().DataPoints().AppendEmpty()
+	ehdp.Attributes().PutStr("host", "host-0")
+	ehdp.SetCount(2)
+
+	summary := ms.AppendEmpty()
+	summary.SetName("summary")
+	sdp := summary.SetEmptySummary().DataPoints().AppendEmpty()
+	sdp.Attributes().PutStr("host", "host-0")
+	sdp.SetCount(3)
+
+	return metrics
+}
+
+// seriesKeys returns the key (metric name and attributes) of each data point
+// of the given metrics by position.
+func seriesKeys(metrics pmetric.Metrics) map[metricsotlp.DataPointPosition]string {
+	keys := make(map[metricsotlp.DataPointPosition]string)
+	rms := metrics.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				m := ms.At(k)
+				var attrs []pcommon.Map
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					for l := 0; l < m.Gauge().DataPoints().Len(); l++ {
+						attrs = append(attrs, m.Gauge().DataPoints().At(l).Attributes())
+					}
+				case pmetric.MetricTypeHistogram:
+					for l := 0; l < m.Histogram().DataPoints().Len(); l++ {
+						attrs = append(attrs, m.Histogram().DataPoints().At(l).Attributes())
+					}
+				case pmetric.MetricTypeExponentialHistogram:
+					for l := 0; l < m.ExponentialHistogram().DataPoints().Len(); l++ {
+						attrs = append(attrs, m.ExponentialHistogram().DataPoints().At(l).Attributes())
+					}
+				case pmetric.MetricTypeSummary:
+					for l := 0; l < m.Summary().DataPoints().Len(); l++ {
+						attrs = append(attrs, m.Summary().DataPoints().At(l).Attributes())
+					}
+				}
+				for l, a := range attrs {
+					pos := metricsotlp.DataPointPosition{ResourceMetrics: i, ScopeMetrics: j, Metric: k, DataPoint: l}
+					keys[pos] = fmt.Sprint(m.Name(), a.AsRaw())
+				}
+			}
+		}
+	}
+	return keys
+}
+
+func TestProducerConsumerSeriesIDs(t *testing.T) {
+	metrics := seriesIDsMetrics()
+
+	producer := NewProducerWithOptions(config.WithSeriesID())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	idsBySeries := make(map[string]uint32)
+	for round := 0; round < 2; round++ {
+		batch, err := producer.BatchArrowRecordsFromMetrics(metrics)
+		require.NoError(t, err)
+
+		received, seriesIDs, err := consumer.MetricsWithSeriesIDsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		require.Equal(t, 1, len(seriesIDs))
+
+		// The series IDs are not added to the data point attributes.
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(metrics)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+		)
+
+		// Every data point has a series ID, stable across batches and
+		// distinct for distinct series.
+		keys := seriesKeys(received[0])
+		require.Equal(t, len(keys), seriesIDs[0].Len())
+		seen := make(map[uint32]string)
+		for pos, key := range keys {
+			seriesID, ok := seriesIDs[0].SeriesID(pos)
+			require.True(t, ok, "no series ID for %s", key)
+			require.NotZero(t, seriesID)
+			if round == 0 {
+				idsBySeries[key] = seriesID
+			}
+			require.Equal(t, idsBySeries[key], seriesID, "series %s", key)
+			if other, found := seen[seriesID]; found {
+				require.Equal(t, other, key)
+			}
+			seen[seriesID] = key
+		}
+	}
+}
+
+func TestProducerConsumerMetricsAppender(t *testing.T) {
+	ts := pcommon.Timestamp(time.Now().UnixNano())
+
+	// The expected metrics, appended below without building them.
+	expected := pmetric.NewMetrics()
+	rm := expected.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "appender")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("scope")
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("requests")
+	sum.SetUnit("1")
+	sum.SetEmptySum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	sum.Sum().SetIsMonotonic(true)
+	for i := 0; i < 2; i++ {
+		dp := sum.Sum().DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("code", fmt.Sprint(200+i))
+		dp.SetTimestamp(ts)
+		dp.SetIntValue(int64(10 + i))
+	}
+	histogram := sm.Metrics().AppendEmpty()
+	histogram.SetName("latency")
+	histogram.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	hdp := histogram.Histogram().DataPoints().AppendEmpty()
+	hdp.SetTimestamp(ts)
+	hdp.SetCount(3)
+	hdp.SetSum(1.5)
+	hdp.ExplicitBounds().FromRaw([]float64{1})
+	hdp.BucketCounts().FromRaw([]uint64{2, 1})
+
+	producer := NewProducer()
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	for round := 0; round < 2; round++ {
+		batch, err := producer.BatchArrowRecordsFromMetricsAppender(func(a *metricsarrow.MetricsAppender) error {
+			a.SetResource(rm.Resource(), "")
+			a.SetScope(sm.Scope(), "")
+			if err := a.AppendMetric(metricsarrow.Metric{
+				Type:                   pmetric.MetricTypeSum,
+				Name:                   "requests",
+				Unit:                   "1",
+				AggregationTemporality: pmetric.AggregationTemporalityCumulative,
+				IsMonotonic:            true,
+			}); err != nil {
+				return err
+			}
+			dps := sum.Sum().DataPoints()
+			for i := 0; i < dps.Len(); i++ {
+				if err := a.AppendNumberDataPoint(dps.At(i)); err != nil {
+					return err
+				}
+			}
+			if err := a.AppendMetric(metricsarrow.Metric{
+				Type:                   pmetric.MetricTypeHistogram,
+				Name:                   "latency",
+				AggregationTemporality: pmetric.AggregationTemporalityDelta,
+			}); err != nil {
+				return err
+			}
+			return a.AppendHistogramDataPoints(histogram.Histogram().DataPoints())
+		})
+		require.NoError(t, err)
+
+		received, err := consumer.MetricsFrom(batch)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(received))
+		assert.Equiv(
+			t,
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(expected)},
+			[]json.Marshaler{pmetricotlp.NewExportRequestFromMetrics(received[0])},
+		)
+	}
+
+	// A data point of another type than the current metric is rejected and
+	// the rows appended before are discarded.
+	_, err := producer.BatchArrowRecordsFromMetricsAppender(func(a *metricsarrow.MetricsAppender) error {
+		if err := a.AppendMetric(metricsarrow.Metric{Type: pmetric.MetricTypeGauge, Name: "gauge"}); err != nil {
+			return err
+		}
+		return a.AppendHistogramDataPoints(histogram.Histogram().DataPoints())
+	})
+	require.ErrorIs(t, err, metricsarrow.ErrUnexpectedDataPoint)
+}
@@ -0,0 +1,210 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Zero-copy access to the Arrow records of a batch.
+//
+// RecordsFrom returns the Arrow records of a batch by payload type, without
+// converting them to OTLP, for the users computing directly over the Arrow
+// data. The columns are accessed through handles resolved from their path
+// (e.g. `body.str` for the string bodies of the LOGS record), reading the
+// values in place as the OTLP decoders do (e.g. through the dictionary of a
+// dictionary encoded column). The values are read as encoded: the
+// delta-encoded IDs (e.g. `id` and `parent_id`) are not decoded, and the
+// attributes are in the records of the attributes payload types.
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// ErrInvalidColumnPath is returned when a column path goes through a column
+// which is not a struct.
+var ErrInvalidColumnPath = errors.New("invalid column path")
+
+type (
+	// Records are the Arrow records of a batch, by payload type (see
+	// RecordsFrom). The records must be released after use.
+	Records struct {
+		records map[record_message.PayloadType]arrow.Record
+	}
+
+	// Column is a handle on a column of a record. An absent column (e.g. an
+	// optional column without data in the batch) is a valid handle
+	// returning zero values.
+	Column struct {
+		arr arrow.Array
+	}
+)
+
+// RecordsFrom returns the Arrow records of a BatchArrowRecords message
+// without converting them to OTLP.
+func (c *Consumer) RecordsFrom(bar *colarspb.BatchArrowRecords) (*Records, error) {
+	rms, err := c.Consume(bar)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	records := &Records{records: make(map[record_message.PayloadType]arrow.Record, len(rms))}
+	for _, rm := range rms {
+		if _, ok := records.records[rm.PayloadType()]; ok {
+			ReleaseRecords(rms)
+			return nil, werror.WrapWithContext(otel.ErrDuplicatePayloadType, map[string]interface{}{"payload_type": rm.PayloadType().String()})
+		}
+		records.records[rm.PayloadType()] = rm.Record()
+	}
+	return records, nil
+}
+
+// PayloadTypes returns the payload types of the records, sorted.
+func (r *Records) PayloadTypes() []record_message.PayloadType {
+	payloadTypes := make([]record_message.PayloadType, 0, len(r.records))
+	for payloadType := range r.records {
+		payloadTypes = append(payloadTypes, payloadType)
+	}
+	sort.Slice(payloadTypes, func(i, j int) bool { return payloadTypes[i] < payloadTypes[j] })
+	return payloadTypes
+}
+
+// Record returns the record of the given payload type, or nil if the batch
+// doesn't contain this payload type. The record is owned by Records.
+func (r *Records) Record(payloadType record_message.PayloadType) arrow.Record {
+	return r.records[payloadType]
+}
+
+// Column returns a handle on the column of the given payload type at the
+// given path, the names of the nested fields being separated by dots (e.g.
+// `resource.id`). The handle of an absent column (or payload type) returns
+// zero values.
+func (r *Records) Column(payloadType record_message.PayloadType, path string) (*Column, error) {
+	record, ok := r.records[payloadType]
+	if !ok {
+		return &Column{}, nil
+	}
+
+	names := strings.Split(path, ".")
+	fieldIDs := record.Schema().FieldIndices(names[0])
+	if len(fieldIDs) == 0 {
+		return &Column{}, nil
+	}
+	arr := record.Column(fieldIDs[0])
+
+	for i, name := range names[1:] {
+		structArr, ok := arr.(*array.Struct)
+		if !ok {
+			return nil, werror.WrapWithContext(ErrInvalidColumnPath, map[string]interface{}{"path": path, "field": strings.Join(names[:i+1], ".")})
+		}
+		fieldID, ok := structArr.DataType().(*arrow.StructType).FieldIdx(name)
+		if !ok {
+			return &Column{}, nil
+		}
+		arr = structArr.Field(fieldID)
+	}
+	return &Column{arr: arr}, nil
+}
+
+// Release releases the records.
+func (r *Records) Release() {
+	for _, record := range r.records {
+		record.Release()
+	}
+	r.records = nil
+}
+
+// Present returns true if the column is present in the record.
+func (c *Column) Present() bool {
+	return c.arr != nil
+}
+
+// Array returns the Arrow array of the column, nil if the column is absent.
+func (c *Column) Array() arrow.Array {
+	return c.arr
+}
+
+// Len returns the number of rows of the column, 0 if the column is absent.
+func (c *Column) Len() int {
+	if c.arr == nil {
+		return 0
+	}
+	return c.arr.Len()
+}
+
+// IsNull returns true if the value of the given row is null or the column is
+// absent.
+func (c *Column) IsNull(row int) bool {
+	return c.arr == nil || c.arr.IsNull(row)
+}
+
+func (c *Column) String(row int) (string, error) {
+	return arrowutils.StringFromArray(c.arr, row)
+}
+
+func (c *Column) Binary(row int) ([]byte, error) {
+	return arrowutils.BinaryFromArray(c.arr, row)
+}
+
+func (c *Column) FixedSizeBinary(row int) ([]byte, error) {
+	return arrowutils.FixedSizeBinaryFromArray(c.arr, row)
+}
+
+func (c *Column) Bool(row int) (bool, error) {
+	return arrowutils.BoolFromArray(c.arr, row)
+}
+
+func (c *Column) U8(row int) (uint8, error) {
+	return arrowutils.U8FromArray(c.arr, row)
+}
+
+func (c *Column) U16(row int) (uint16, error) {
+	return arrowutils.U16FromArray(c.arr, row)
+}
+
+func (c *Column) U32(row int) (uint32, error) {
+	return arrowutils.U32FromArray(c.arr, row)
+}
+
+func (c *Column) U64(row int) (uint64, error) {
+	return arrowutils.U64FromArray(c.arr, row)
+}
+
+func (c *Column) I32(row int) (int32, error) {
+	return arrowutils.I32FromArray(c.arr, row)
+}
+
+func (c *Column) I64(row int) (int64, error) {
+	return arrowutils.I64FromArray(c.arr, row)
+}
+
+func (c *Column) F64(row int) (float64, error) {
+	return arrowutils.F64FromArray(c.arr, row)
+}
+
+func (c *Column) Timestamp(row int) (arrow.Timestamp, error) {
+	return arrowutils.TimestampFromArray(c.arr, row)
+}
+
+func (c *Column) Duration(row int) (arrow.Duration, error) {
+	return arrowutils.DurationFromArray(c.arr, row)
+}
@@ -0,0 +1,117 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Partial decoding of the batches for routing.
+//
+// Gateways routing the batches on the resource attributes (e.g. a tenant or
+// a service name) only need the resources and scopes of a batch. They are
+// decoded from the resource and scope columns of the main record and from the
+// RESOURCE_ATTRS, SCOPE_ATTRS, and SCOPES records, the other records (data
+// points, spans, log bodies, ...) are left encoded.
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// ResourcesFrom decodes only the resources and scopes of a BatchArrowRecords
+// message. The records of the batch are returned with the resources, they
+// must then be decoded with the *FromRecords method of their signal (e.g.
+// LogsFromRecords), or released with ReleaseRecords if the batch is not
+// decoded. As the IPC streams of the consumer have consumed the batch, it
+// must not be passed again to the consumer.
+func (c *Consumer) ResourcesFrom(bar *colarspb.BatchArrowRecords) ([]otlp.ResourceEntry, []*record_message.RecordMessage, error) {
+	records, err := c.Consume(bar)
+	if err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+
+	resources, err := c.ResourcesFromRecords(records)
+	if err != nil {
+		ReleaseRecords(records)
+		return nil, nil, werror.Wrap(err)
+	}
+	return resources, records, nil
+}
+
+// ResourcesFromRecords decodes only the resources and scopes of the records
+// of a batch (see ResourcesFrom).
+// Note: This method does not consume the records.
+func (c *Consumer) ResourcesFromRecords(records []*record_message.RecordMessage) ([]otlp.ResourceEntry, error) {
+	var mainRecord arrow.Record
+	var scopeTableRecord arrow.Record
+	resAttrs := otlp.NewAttributes16Store()
+	scopeAttrs := otlp.NewAttributes16Store()
+
+	for _, rm := range records {
+		switch rm.PayloadType() {
+		case colarspb.ArrowPayloadType_SPANS, colarspb.ArrowPayloadType_LOGS, colarspb.ArrowPayloadType_METRICS:
+			if mainRecord != nil {
+				return nil, werror.WrapWithContext(otel.ErrDuplicatePayloadType, map[string]interface{}{"payload_type": rm.PayloadType().String()})
+			}
+			mainRecord = rm.Record()
+		case colarspb.ArrowPayloadType_RESOURCE_ATTRS:
+			// The stores consume the records, which are retained for
+			// their owner.
+			rm.Record().Retain()
+			if err := c.attrsCache.Attributes16StoreFrom(rm.CacheKey(), rm.Record(), resAttrs); err != nil {
+				return nil, werror.Wrap(err)
+			}
+		case colarspb.ArrowPayloadType_SCOPE_ATTRS:
+			rm.Record().Retain()
+			if err := c.attrsCache.Attributes16StoreFrom(rm.CacheKey(), rm.Record(), scopeAttrs); err != nil {
+				return nil, werror.Wrap(err)
+			}
+		case colarspb.ArrowPayloadType_SCOPES:
+			if scopeTableRecord != nil {
+				return nil, werror.Wrap(otel.ErrMultipleScopeTableRecords)
+			}
+			scopeTableRecord = rm.Record()
+		}
+	}
+	if mainRecord == nil {
+		return nil, nil
+	}
+
+	// The scope table depends on the scope attributes.
+	var scopeTable *otlp.ScopeTable
+	if scopeTableRecord != nil {
+		scopeTableRecord.Retain()
+		var err error
+		if scopeTable, err = otlp.ScopeTableFrom(scopeTableRecord, scopeAttrs); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	resources, err := otlp.ResourceEntriesFrom(mainRecord, resAttrs, scopeAttrs, scopeTable)
+	if err != nil {
+		return nil, werror.Wrap(werror.WithCode(err, werror.CodeInvalidArgument))
+	}
+	return resources, nil
+}
+
+// ReleaseRecords releases the records of a batch returned by the Consume or
+// ResourcesFrom methods.
+func ReleaseRecords(records []*record_message.RecordMessage) {
+	for _, rm := range records {
+		rm.Record().Release()
+	}
+}
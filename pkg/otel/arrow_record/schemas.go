@@ -0,0 +1,155 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Detection of the columns sent by a producer that are unknown to this
+// version of the consumer.
+
+import (
+	"sync"
+
+	"github.com/apache/arrow/go/v12/arrow"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	acommon "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	logsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/logs/arrow"
+	metricsarrow "github.com/f5/otel-arrow-adapter/pkg/otel/metrics/arrow"
+	tracesarrow "github.com/f5/otel-arrow-adapter/pkg/otel/traces/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+)
+
+var (
+	prototypeSchemasOnce sync.Once
+	prototypeSchemas     map[record_message.PayloadType]*arrow.Schema
+	// overridePrototypes are the prototype schemas keyed by the prefix of
+	// their encoding overrides.
+	overridePrototypes map[string]*arrow.Schema
+)
+
+// PrototypeSchemas returns the prototype schema (i.e. including all the
+// optional fields) of every payload type supported by this version of the
+// adapter.
+func PrototypeSchemas() map[record_message.PayloadType]*arrow.Schema {
+	prototypeSchemasOnce.Do(func() {
+		schemas := map[record_message.PayloadType]*arrow.Schema{
+			colarspb.ArrowPayloadType_METRICS: metricsarrow.MetricsSchema,
+			colarspb.ArrowPayloadType_LOGS:    logsarrow.LogsSchema,
+			colarspb.ArrowPayloadType_SPANS:   tracesarrow.TracesSchema,
+
+			colarspb.ArrowPayloadType_TRACE_ID_INDEX: TraceIDIndexSchema,
+		}
+		// The prefixes of the main records are the ones of the producer.
+		overrides := map[string]*arrow.Schema{
+			"metrics": metricsarrow.MetricsSchema,
+			"logs":    logsarrow.LogsSchema,
+			"spans":   tracesarrow.TracesSchema,
+		}
+
+		// The related record schemas are declared by the entity builders.
+		producer := NewProducer()
+		defer func() { _ = producer.Close() }()
+
+		for _, related := range [][]acommon.SchemaWithPayload{
+			producer.MetricsBuilder().RelatedData().Schemas(),
+			producer.LogsBuilder().RelatedData().Schemas(),
+			producer.TracesBuilder().RelatedData().Schemas(),
+		} {
+			for _, s := range related {
+				schemas[s.PayloadType.PayloadType()] = s.Schema
+				overrides[s.PayloadType.OverridePrefix()] = s.Schema
+			}
+		}
+
+		prototypeSchemas = schemas
+		overridePrototypes = overrides
+	})
+	return prototypeSchemas
+}
+
+// ValidateEncodingOverrides checks that the encoding overrides of a producer
+// (see config.WithEncodingOverrides) use supported encodings and apply to
+// fields of the prototype schemas (see schema.ValidateEncodingOverrides).
+func ValidateEncodingOverrides(overrides map[string]string) error {
+	PrototypeSchemas()
+	return schema.ValidateEncodingOverrides(overrides, overridePrototypes)
+}
+
+// unknownColumns returns the dot-separated paths of the columns of the given
+// schema that are not part of the prototype schema. Only field names are
+// compared, the data types can legitimately differ (e.g. dictionary encoding).
+func unknownColumns(prototype *arrow.Schema, schema *arrow.Schema) []string {
+	var unknown []string
+	for _, field := range schema.Fields() {
+		indices := prototype.FieldIndices(field.Name)
+		if len(indices) == 0 {
+			unknown = append(unknown, field.Name)
+			continue
+		}
+		unknown = appendUnknownFields(unknown, field.Name, prototype.Field(indices[0]).Type, field.Type)
+	}
+	return unknown
+}
+
+func appendUnknownFields(unknown []string, path string, prototype arrow.DataType, dt arrow.DataType) []string {
+	prototype = valueType(prototype)
+
+	switch dt := valueType(dt).(type) {
+	case *arrow.StructType:
+		protoStruct, ok := prototype.(*arrow.StructType)
+		if !ok {
+			return unknown
+		}
+		for _, field := range dt.Fields() {
+			protoField, found := protoStruct.FieldByName(field.Name)
+			if !found {
+				unknown = append(unknown, path+"."+field.Name)
+				continue
+			}
+			unknown = appendUnknownFields(unknown, path+"."+field.Name, protoField.Type, field.Type)
+		}
+	case *arrow.ListType:
+		if protoList, ok := prototype.(*arrow.ListType); ok {
+			unknown = appendUnknownFields(unknown, path, protoList.Elem(), dt.Elem())
+		}
+	case arrow.UnionType:
+		protoUnion, ok := prototype.(arrow.UnionType)
+		if !ok {
+			return unknown
+		}
+		protoFields := make(map[string]arrow.DataType, len(protoUnion.Fields()))
+		for _, field := range protoUnion.Fields() {
+			protoFields[field.Name] = field.Type
+		}
+		for _, field := range dt.Fields() {
+			protoType, found := protoFields[field.Name]
+			if !found {
+				unknown = append(unknown, path+"."+field.Name)
+				continue
+			}
+			unknown = appendUnknownFields(unknown, path+"."+field.Name, protoType, field.Type)
+		}
+	}
+
+	return unknown
+}
+
+// valueType returns the value type of a dictionary type or the type itself.
+func valueType(dt arrow.DataType) arrow.DataType {
+	if dict, ok := dt.(*arrow.DictionaryType); ok {
+		return dict.ValueType
+	}
+	return dt
+}
@@ -0,0 +1,184 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Estimation of the size of the pdata items, used to split the batches
+// exceeding config.MaxRecordBytes (see split.go).
+//
+// The estimates approximate the size of the OTLP protobuf representation of
+// the items without serializing them: each field counts for its value plus a
+// few bytes of tag and length. Only their relative value matters, the budget
+// of the chunks being scaled by the ratio of the encoded to estimated sizes.
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+const (
+	// fieldOverhead is the estimated tag and length size of a field.
+	fieldOverhead = 2
+	// fixed64Size is the estimated size of a fixed 64-bit field (e.g. a
+	// timestamp or a double).
+	fixed64Size = 9
+	// idsSize is the estimated size of a trace ID and a span ID.
+	idsSize = 16 + 8 + 2*fieldOverhead
+)
+
+func valueSize(v pcommon.Value) int {
+	switch v.Type() {
+	case pcommon.ValueTypeStr:
+		return len(v.Str()) + fieldOverhead
+	case pcommon.ValueTypeBytes:
+		return v.Bytes().Len() + fieldOverhead
+	case pcommon.ValueTypeInt, pcommon.ValueTypeDouble:
+		return fixed64Size
+	case pcommon.ValueTypeBool:
+		return fieldOverhead
+	case pcommon.ValueTypeMap:
+		return mapSize(v.Map()) + fieldOverhead
+	case pcommon.ValueTypeSlice:
+		size := fieldOverhead
+		slice := v.Slice()
+		for i := 0; i < slice.Len(); i++ {
+			size += valueSize(slice.At(i)) + fieldOverhead
+		}
+		return size
+	}
+	return fieldOverhead
+}
+
+func mapSize(m pcommon.Map) int {
+	size := 0
+	m.Range(func(k string, v pcommon.Value) bool {
+		size += len(k) + valueSize(v) + 2*fieldOverhead
+		return true
+	})
+	return size
+}
+
+func resourceSize(r pcommon.Resource, schemaURL string) int {
+	return mapSize(r.Attributes()) + len(schemaURL) + 2*fieldOverhead
+}
+
+func scopeSize(s pcommon.InstrumentationScope, schemaURL string) int {
+	return len(s.Name()) + len(s.Version()) + mapSize(s.Attributes()) + len(schemaURL) + 4*fieldOverhead
+}
+
+func spanSize(span ptrace.Span) int {
+	size := idsSize + 8 + len(span.TraceState().AsRaw()) + len(span.Name()) + 2*fixed64Size + 4*fieldOverhead
+	size += mapSize(span.Attributes())
+
+	events := span.Events()
+	for i := 0; i < events.Len(); i++ {
+		event := events.At(i)
+		size += len(event.Name()) + fixed64Size + mapSize(event.Attributes()) + 2*fieldOverhead
+	}
+
+	links := span.Links()
+	for i := 0; i < links.Len(); i++ {
+		link := links.At(i)
+		size += idsSize + len(link.TraceState().AsRaw()) + mapSize(link.Attributes()) + 2*fieldOverhead
+	}
+
+	return size + len(span.Status().Message()) + 2*fieldOverhead
+}
+
+func logRecordSize(lr plog.LogRecord) int {
+	size := 2*fixed64Size + len(lr.SeverityText()) + idsSize + 4*fieldOverhead
+	return size + valueSize(lr.Body()) + mapSize(lr.Attributes())
+}
+
+func resourceSpansSize(rs ptrace.ResourceSpans) int {
+	size := resourceSize(rs.Resource(), rs.SchemaUrl())
+	for i, sss := 0, rs.ScopeSpans(); i < sss.Len(); i++ {
+		ss := sss.At(i)
+		size += scopeSize(ss.Scope(), ss.SchemaUrl())
+		for j, spans := 0, ss.Spans(); j < spans.Len(); j++ {
+			size += spanSize(spans.At(j))
+		}
+	}
+	return size
+}
+
+func resourceLogsSize(rl plog.ResourceLogs) int {
+	size := resourceSize(rl.Resource(), rl.SchemaUrl())
+	for i, sls := 0, rl.ScopeLogs(); i < sls.Len(); i++ {
+		sl := sls.At(i)
+		size += scopeSize(sl.Scope(), sl.SchemaUrl())
+		for j, records := 0, sl.LogRecords(); j < records.Len(); j++ {
+			size += logRecordSize(records.At(j))
+		}
+	}
+	return size
+}
+
+func resourceMetricsSize(rm pmetric.ResourceMetrics) int {
+	size := resourceSize(rm.Resource(), rm.SchemaUrl())
+	for i, sms := 0, rm.ScopeMetrics(); i < sms.Len(); i++ {
+		sm := sms.At(i)
+		size += scopeSize(sm.Scope(), sm.SchemaUrl())
+		for j, metrics := 0, sm.Metrics(); j < metrics.Len(); j++ {
+			m := metrics.At(j)
+			size += metricSize(m)
+			for k, n := 0, dataPointCount(m); k < n; k++ {
+				size += dataPointSize(m, k)
+			}
+		}
+	}
+	return size
+}
+
+func metricSize(m pmetric.Metric) int {
+	return len(m.Name()) + len(m.Description()) + len(m.Unit()) + 4*fieldOverhead
+}
+
+func exemplarsSize(exemplars pmetric.ExemplarSlice) int {
+	size := 0
+	for i := 0; i < exemplars.Len(); i++ {
+		size += idsSize + 2*fixed64Size + mapSize(exemplars.At(i).FilteredAttributes()) + fieldOverhead
+	}
+	return size
+}
+
+// dataPointSize returns the estimated size of the i-th data point of m.
+func dataPointSize(m pmetric.Metric, i int) int {
+	// Start and end timestamps, flags.
+	size := 2*fixed64Size + 2*fieldOverhead
+
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dp := m.Gauge().DataPoints().At(i)
+		size += fixed64Size + mapSize(dp.Attributes()) + exemplarsSize(dp.Exemplars())
+	case pmetric.MetricTypeSum:
+		dp := m.Sum().DataPoints().At(i)
+		size += fixed64Size + mapSize(dp.Attributes()) + exemplarsSize(dp.Exemplars())
+	case pmetric.MetricTypeHistogram:
+		dp := m.Histogram().DataPoints().At(i)
+		size += 4*fixed64Size + mapSize(dp.Attributes()) + exemplarsSize(dp.Exemplars())
+		size += 8*(dp.BucketCounts().Len()+dp.ExplicitBounds().Len()) + 2*fieldOverhead
+	case pmetric.MetricTypeExponentialHistogram:
+		dp := m.ExponentialHistogram().DataPoints().At(i)
+		size += 6*fixed64Size + mapSize(dp.Attributes()) + exemplarsSize(dp.Exemplars())
+		size += 8*(dp.Positive().BucketCounts().Len()+dp.Negative().BucketCounts().Len()) + 6*fieldOverhead
+	case pmetric.MetricTypeSummary:
+		dp := m.Summary().DataPoints().At(i)
+		size += 2*fixed64Size + mapSize(dp.Attributes())
+		size += dp.QuantileValues().Len() * (2*fixed64Size + fieldOverhead)
+	}
+	return size
+}
@@ -0,0 +1,316 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Snapshots of the state of the streams of a Producer or a Consumer.
+//
+// A snapshot is a zip archive containing a `manifest.json` file (kind,
+// configuration, stats and streams) and one Arrow IPC stream file per stream
+// (`streams/<n>.arrows`). When stream recording is enabled (see
+// config.WithStreamRecording and WithStreamRecording), the stream file
+// contains all the payloads exchanged on the stream since its creation, which
+// is enough to rebuild the dictionaries and to replay a decode failure.
+// Otherwise the stream file only contains the schema of the stream.
+//
+// Snapshots are meant to be attached to bug reports and loaded with the
+// `tools/stream_inspector` tool.
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	pstats "github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// SnapshotVersion is the version of the snapshot format.
+const SnapshotVersion = 1
+
+const manifestFile = "manifest.json"
+
+// Kinds of snapshot.
+const (
+	ProducerSnapshot = "producer"
+	ConsumerSnapshot = "consumer"
+)
+
+type (
+	// Snapshot is the content of a snapshot archive.
+	Snapshot struct {
+		Version   int                    `json:"version"`
+		Kind      string                 `json:"kind"`
+		CreatedAt time.Time              `json:"created_at"`
+		Config    map[string]interface{} `json:"config"`
+		// Stats is only defined for producer snapshots.
+		Stats   *pstats.ProducerStats `json:"stats,omitempty"`
+		Streams []StreamSnapshot      `json:"streams"`
+	}
+
+	// StreamSnapshot is the state of a single stream.
+	StreamSnapshot struct {
+		SchemaID    string    `json:"schema_id"`
+		PayloadType string    `json:"payload_type"`
+		Schema      string    `json:"schema"`
+		LastUpdate  time.Time `json:"last_update"`
+		File        string    `json:"file"`
+		// Recorded is true if File contains the payloads of the stream and
+		// not only its schema.
+		Recorded bool `json:"recorded"`
+		// Truncated is true if the recording limit has been reached, in which
+		// case File only contains the beginning of the stream.
+		Truncated bool `json:"truncated"`
+
+		// Data is the content of File (Arrow IPC stream format).
+		Data []byte `json:"-"`
+	}
+
+	// streamRecording keeps the payloads of a stream up to a limit. A nil
+	// streamRecording records nothing.
+	streamRecording struct {
+		data      []byte
+		limit     int
+		truncated bool
+	}
+)
+
+func newStreamRecording(limit int) *streamRecording {
+	if limit <= 0 {
+		return nil
+	}
+	return &streamRecording{limit: limit}
+}
+
+// record appends the payload to the recording. Once the limit is reached the
+// recording is frozen so that it remains a valid prefix of the stream.
+func (r *streamRecording) record(payload []byte) {
+	if r == nil || r.truncated {
+		return
+	}
+	if len(r.data)+len(payload) > r.limit {
+		r.truncated = true
+		return
+	}
+	r.data = append(r.data, payload...)
+}
+
+// snapshot returns the stream file content and the recording flags.
+func (r *streamRecording) snapshot(schema *arrow.Schema) (data []byte, recorded bool, truncated bool, err error) {
+	if r != nil && len(r.data) > 0 {
+		return r.data, true, r.truncated, nil
+	}
+	if schema == nil {
+		return nil, false, false, nil
+	}
+
+	// Schema-only stream.
+	var buf bytes.Buffer
+	w := ipc.NewWriter(&buf, ipc.WithSchema(schema))
+	if err = w.Close(); err != nil {
+		return nil, false, false, werror.Wrap(err)
+	}
+	return buf.Bytes(), false, r != nil && r.truncated, nil
+}
+
+// WriteSnapshot writes a snapshot of the producer and of its streams to w.
+func (p *Producer) WriteSnapshot(w io.Writer) error {
+	snapshot := Snapshot{
+		Version:   SnapshotVersion,
+		Kind:      ProducerSnapshot,
+		CreatedAt: time.Now(),
+		Config: map[string]interface{}{
+			"zstd":                         p.zstd,
+			"init_index_size":              p.config.InitIndexSize,
+			"limit_index_size":             p.config.LimitIndexSize,
+			"stats":                        p.config.Stats,
+			"series_id":                    p.config.SeriesID,
+			"optional_column_deactivation": p.config.OptionalColumnDeactivation,
+			"stream_recording":             p.config.StreamRecording,
+			"cpu_budget":                   p.config.CPUBudget,
+			"trace_id_index":               p.config.TraceIDIndex,
+		},
+		Stats: p.stats,
+	}
+
+	for schemaID, sp := range p.streamProducers {
+		data, recorded, truncated, err := sp.recording.snapshot(sp.schema)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		snapshot.Streams = append(snapshot.Streams, StreamSnapshot{
+			SchemaID:    schemaID,
+			PayloadType: sp.payloadType.String(),
+			Schema:      schemaString(sp.schema),
+			LastUpdate:  sp.lastProduction,
+			Recorded:    recorded,
+			Truncated:   truncated,
+			Data:        data,
+		})
+	}
+
+	return werror.Wrap(writeSnapshot(w, &snapshot))
+}
+
+// WriteSnapshot writes a snapshot of the consumer and of its streams to w.
+func (c *Consumer) WriteSnapshot(w io.Writer) error {
+	snapshot := Snapshot{
+		Version:   SnapshotVersion,
+		Kind:      ConsumerSnapshot,
+		CreatedAt: time.Now(),
+		Config: map[string]interface{}{
+			"mem_limit":          c.memLimit,
+			"strict_schema":      c.strictSchema,
+			"decode_concurrency": c.decodeConcurrency,
+			"stream_recording":   c.streamRecording,
+			"attributes_cache":   c.attrsCache != nil,
+			"span_metrics":       c.spanMetrics != nil,
+			"log_metrics":        c.logMetrics != nil,
+			"service_graph":      c.serviceGraph != nil,
+			"producer_hints":     c.hints != nil,
+			"sanitizer":          c.sanitizer != nil,
+			"redactor":           c.redactor != nil,
+		},
+	}
+
+	for schemaID, sc := range c.streamConsumers {
+		var schema *arrow.Schema
+		if sc.ipcReader != nil {
+			schema = sc.ipcReader.Schema()
+		}
+		data, recorded, truncated, err := sc.recording.snapshot(schema)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		snapshot.Streams = append(snapshot.Streams, StreamSnapshot{
+			SchemaID:    schemaID,
+			PayloadType: sc.payloadType.String(),
+			Schema:      schemaString(schema),
+			LastUpdate:  sc.lastConsumption,
+			Recorded:    recorded,
+			Truncated:   truncated,
+			Data:        data,
+		})
+	}
+
+	return werror.Wrap(writeSnapshot(w, &snapshot))
+}
+
+func writeSnapshot(w io.Writer, snapshot *Snapshot) error {
+	sort.Slice(snapshot.Streams, func(i, j int) bool {
+		return snapshot.Streams[i].LastUpdate.Before(snapshot.Streams[j].LastUpdate)
+	})
+
+	zw := zip.NewWriter(w)
+
+	for i := range snapshot.Streams {
+		stream := &snapshot.Streams[i]
+		if stream.Data == nil {
+			continue
+		}
+		stream.File = fmt.Sprintf("streams/%d.arrows", i)
+		fw, err := zw.Create(stream.File)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		if _, err = fw.Write(stream.Data); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+
+	fw, err := zw.Create(manifestFile)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	encoder := json.NewEncoder(fw)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(snapshot); err != nil {
+		return werror.Wrap(err)
+	}
+
+	return werror.Wrap(zw.Close())
+}
+
+// ReadSnapshot reads a snapshot archive written by WriteSnapshot.
+func ReadSnapshot(r io.ReaderAt, size int64) (*Snapshot, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, ok := files[manifestFile]
+	if !ok {
+		return nil, werror.WrapWithMsg(otel.ErrInvalidSnapshot, "manifest not found")
+	}
+	var snapshot Snapshot
+	if err = readJSON(manifest, &snapshot); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, werror.WrapWithContext(otel.ErrInvalidSnapshot, map[string]interface{}{"version": snapshot.Version})
+	}
+
+	for i := range snapshot.Streams {
+		stream := &snapshot.Streams[i]
+		if stream.File == "" {
+			continue
+		}
+		f, ok := files[stream.File]
+		if !ok {
+			return nil, werror.WrapWithContext(otel.ErrInvalidSnapshot, map[string]interface{}{"missing_file": stream.File})
+		}
+		if stream.Data, err = readAll(f); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	return &snapshot, nil
+}
+
+func readJSON(f *zip.File, v interface{}) error {
+	data, err := readAll(f)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func readAll(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+func schemaString(schema *arrow.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	return schema.String()
+}
@@ -0,0 +1,91 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	producer := NewProducerWithOptions(config.WithNoZstd(), config.WithStreamRecording(1<<20))
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := NewConsumerWithOptions(WithStreamRecording(1 << 20))
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	for i := 0; i < 2; i++ {
+		batch, err := producer.BatchArrowRecordsFromLogs(GenerateLogs(0, 10))
+		require.NoError(t, err)
+		_, err = consumer.LogsFrom(batch)
+		require.NoError(t, err)
+	}
+
+	for _, writeSnapshot := range []func(*bytes.Buffer) error{
+		func(buf *bytes.Buffer) error { return producer.WriteSnapshot(buf) },
+		func(buf *bytes.Buffer) error { return consumer.WriteSnapshot(buf) },
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, writeSnapshot(&buf))
+
+		snapshot, err := ReadSnapshot(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		require.Equal(t, SnapshotVersion, snapshot.Version)
+
+		var logsStream *StreamSnapshot
+		for i := range snapshot.Streams {
+			if snapshot.Streams[i].PayloadType == colarspb.ArrowPayloadType_LOGS.String() {
+				logsStream = &snapshot.Streams[i]
+			}
+		}
+		require.NotNil(t, logsStream, "%s snapshot without logs stream", snapshot.Kind)
+		require.True(t, logsStream.Recorded)
+		require.False(t, logsStream.Truncated)
+		require.NotEmpty(t, logsStream.Schema)
+
+		// The recorded stream can be replayed from the beginning.
+		reader, err := ipc.NewReader(bytes.NewReader(logsStream.Data), ipc.WithDictionaryDeltas(true), ipc.WithZstd())
+		require.NoError(t, err)
+		records := 0
+		for reader.Next() {
+			records++
+		}
+		require.NoError(t, reader.Err())
+		reader.Release()
+		require.Equal(t, 2, records)
+	}
+}
+
+func TestStreamRecordingLimit(t *testing.T) {
+	t.Parallel()
+
+	recording := newStreamRecording(10)
+	recording.record([]byte("12345"))
+	recording.record([]byte("678901"))
+	recording.record([]byte("2"))
+	require.Equal(t, []byte("12345"), recording.data)
+	require.True(t, recording.truncated)
+
+	// No recording by default.
+	require.Nil(t, newStreamRecording(0))
+	newStreamRecording(0).record([]byte("ignored"))
+}
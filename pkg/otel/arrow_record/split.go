@@ -0,0 +1,505 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+// Splitting of the batches exceeding config.MaxRecordBytes.
+//
+// The items of a batch (spans, log records or metric data points) are grouped
+// in chunks by cumulative size, each chunk being encoded as a batch on the
+// current streams. The size of the items is estimated from their OTLP
+// representation (see size.go), the budget of a chunk being the limit scaled
+// by the ratio between the encoded and estimated sizes of the previous chunk.
+// The resources, scopes and metrics are duplicated in the chunks they span,
+// unless config.WholeResources is set, in which case the chunks are only cut
+// between resources.
+//
+// A chunk whose encoded size still exceeds the limit is dropped. As its
+// payloads may carry schemas and dictionary deltas that the consumer will
+// never receive, the next batches are sent on new streams and the chunk is
+// split again with half its size as budget.
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/proto"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// splitHeadroom is the fraction of config.MaxRecordBytes left unused by the
+// chunk budget to absorb the estimation error.
+const splitHeadroom = 0.1
+
+// ErrBatchTooLarge is returned when a single item (span, log record or data
+// point) is encoded in a batch exceeding config.MaxRecordBytes.
+var ErrBatchTooLarge = errors.New("batch exceeds the maximum record size")
+
+// SplitArrowRecordsFromTraces produces one or more BatchArrowRecords messages
+// from a [ptrace.Traces] message, each of them serialized in at most
+// config.MaxRecordBytes bytes (see config.WithMaxRecordBytes).
+func (p *Producer) SplitArrowRecordsFromTraces(ts ptrace.Traces) ([]*colarspb.BatchArrowRecords, error) {
+	bars, _, err := p.SplitArrowRecordsWithDataFromTraces(ts)
+	return bars, err
+}
+
+// SplitArrowRecordsWithDataFromTraces is SplitArrowRecordsFromTraces also
+// returning the traces encoded in each BatchArrowRecords message, so that the
+// messages which were not acknowledged can be sent again without the others.
+func (p *Producer) SplitArrowRecordsWithDataFromTraces(ts ptrace.Traces) ([]*colarspb.BatchArrowRecords, []ptrace.Traces, error) {
+	return splitBatches(p, ts, splitTraces, p.BatchArrowRecordsFromTraces)
+}
+
+// SplitArrowRecordsFromLogs produces one or more BatchArrowRecords messages
+// from a [plog.Logs] message, each of them serialized in at most
+// config.MaxRecordBytes bytes (see config.WithMaxRecordBytes).
+func (p *Producer) SplitArrowRecordsFromLogs(ls plog.Logs) ([]*colarspb.BatchArrowRecords, error) {
+	bars, _, err := p.SplitArrowRecordsWithDataFromLogs(ls)
+	return bars, err
+}
+
+// SplitArrowRecordsWithDataFromLogs is SplitArrowRecordsFromLogs also
+// returning the logs encoded in each BatchArrowRecords message (see
+// SplitArrowRecordsWithDataFromTraces).
+func (p *Producer) SplitArrowRecordsWithDataFromLogs(ls plog.Logs) ([]*colarspb.BatchArrowRecords, []plog.Logs, error) {
+	return splitBatches(p, ls, splitLogs, p.BatchArrowRecordsFromLogs)
+}
+
+// SplitArrowRecordsFromMetrics produces one or more BatchArrowRecords messages
+// from a [pmetric.Metrics] message, each of them serialized in at most
+// config.MaxRecordBytes bytes (see config.WithMaxRecordBytes). A metric with
+// many data points is split across batches.
+func (p *Producer) SplitArrowRecordsFromMetrics(ms pmetric.Metrics) ([]*colarspb.BatchArrowRecords, error) {
+	bars, _, err := p.SplitArrowRecordsWithDataFromMetrics(ms)
+	return bars, err
+}
+
+// SplitArrowRecordsWithDataFromMetrics is SplitArrowRecordsFromMetrics also
+// returning the metrics encoded in each BatchArrowRecords message (see
+// SplitArrowRecordsWithDataFromTraces).
+func (p *Producer) SplitArrowRecordsWithDataFromMetrics(ms pmetric.Metrics) ([]*colarspb.BatchArrowRecords, []pmetric.Metrics, error) {
+	return splitBatches(p, ms, splitMetrics, p.BatchArrowRecordsFromMetrics)
+}
+
+// chunk is a part of a batch and its estimated size.
+type chunk[T any] struct {
+	data T
+	size int
+}
+
+func splitBatches[T any](
+	p *Producer,
+	data T,
+	split func(T, int, bool) []chunk[T],
+	produce func(T) (*colarspb.BatchArrowRecords, error),
+) ([]*colarspb.BatchArrowRecords, []T, error) {
+	limit := p.config.MaxRecordBytes
+	if limit <= 0 {
+		bar, err := produce(data)
+		if err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+		return []*colarspb.BatchArrowRecords{bar}, []T{data}, nil
+	}
+
+	ratio := p.splitRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+	budget := int(float64(limit) * (1 - splitHeadroom) / ratio)
+	return splitChunks(p, split(data, budget, p.config.WholeResources), split, produce)
+}
+
+func splitChunks[T any](
+	p *Producer,
+	chunks []chunk[T],
+	split func(T, int, bool) []chunk[T],
+	produce func(T) (*colarspb.BatchArrowRecords, error),
+) ([]*colarspb.BatchArrowRecords, []T, error) {
+	limit := p.config.MaxRecordBytes
+	bars := make([]*colarspb.BatchArrowRecords, 0, len(chunks))
+	datas := make([]T, 0, len(chunks))
+
+	for _, c := range chunks {
+		batchID := p.batchId
+		bar, err := produce(c.data)
+		if err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+
+		size := proto.Size(bar)
+		if c.size > 0 {
+			p.splitRatio = float64(size) / float64(c.size)
+		}
+		if size <= limit {
+			bars = append(bars, bar)
+			datas = append(datas, c.data)
+			continue
+		}
+
+		// The batch is dropped, the next batches are sent on new streams.
+		if err := p.closeStreamProducers(); err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+		p.batchId = batchID
+
+		halves := split(c.data, c.size/2, p.config.WholeResources)
+		if len(halves) < 2 {
+			return nil, nil, werror.WrapWithContext(ErrBatchTooLarge, map[string]interface{}{
+				"size":  size,
+				"limit": limit,
+			})
+		}
+		more, moreData, err := splitChunks(p, halves, split, produce)
+		if err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+		bars = append(bars, more...)
+		datas = append(datas, moreData...)
+	}
+	return bars, datas, nil
+}
+
+// splitTraces splits ts in chunks of spans whose cumulative estimated size is
+// at most budget, a single span exceeding the budget forming its own chunk.
+// The empty resources and scopes are kept in the chunk of the items
+// preceding them. If wholeResources is set, the chunks are made of whole
+// resources, a single resource exceeding the budget forming its own chunk.
+func splitTraces(ts ptrace.Traces, budget int, wholeResources bool) []chunk[ptrace.Traces] {
+	var chunks []chunk[ptrace.Traces]
+	var part *chunk[ptrace.Traces]
+	var destRS ptrace.ResourceSpans
+	var destSS ptrace.ScopeSpans
+	haveRS, haveSS := false, false
+
+	// open returns the chunk of an item of the given size, starting a new
+	// chunk if the current one cannot hold it.
+	open := func(size int, rsSize, ssSize int) {
+		if !haveRS {
+			size += rsSize
+		}
+		if !haveSS {
+			size += ssSize
+		}
+		if part != nil && (part.size == 0 || part.size+size <= budget) {
+			return
+		}
+		chunks = append(chunks, chunk[ptrace.Traces]{data: ptrace.NewTraces()})
+		part = &chunks[len(chunks)-1]
+		haveRS, haveSS = false, false
+	}
+
+	for i, rss := 0, ts.ResourceSpans(); i < rss.Len(); i++ {
+		rs := rss.At(i)
+		rsSize := resourceSize(rs.Resource(), rs.SchemaUrl())
+		haveRS = false
+		if wholeResources {
+			size := resourceSpansSize(rs)
+			open(size, 0, 0)
+			rs.CopyTo(part.data.ResourceSpans().AppendEmpty())
+			part.size += size
+			continue
+		}
+		openRS := func() {
+			if !haveRS {
+				destRS = part.data.ResourceSpans().AppendEmpty()
+				rs.Resource().CopyTo(destRS.Resource())
+				destRS.SetSchemaUrl(rs.SchemaUrl())
+				part.size += rsSize
+				haveRS = true
+			}
+		}
+
+		for j, sss := 0, rs.ScopeSpans(); j < sss.Len(); j++ {
+			ss := sss.At(j)
+			ssSize := scopeSize(ss.Scope(), ss.SchemaUrl())
+			haveSS = false
+			openSS := func() {
+				openRS()
+				if !haveSS {
+					destSS = destRS.ScopeSpans().AppendEmpty()
+					ss.Scope().CopyTo(destSS.Scope())
+					destSS.SetSchemaUrl(ss.SchemaUrl())
+					part.size += ssSize
+					haveSS = true
+				}
+			}
+
+			spans := ss.Spans()
+			for k := 0; k < spans.Len(); k++ {
+				size := spanSize(spans.At(k))
+				open(size, rsSize, ssSize)
+				openSS()
+				spans.At(k).CopyTo(destSS.Spans().AppendEmpty())
+				part.size += size
+			}
+			if spans.Len() == 0 {
+				open(0, rsSize, ssSize)
+				openSS()
+			}
+		}
+		if rs.ScopeSpans().Len() == 0 {
+			open(0, rsSize, 0)
+			openRS()
+		}
+	}
+	return chunks
+}
+
+// splitLogs splits ls in chunks of log records whose cumulative estimated
+// size is at most budget (see splitTraces).
+func splitLogs(ls plog.Logs, budget int, wholeResources bool) []chunk[plog.Logs] {
+	var chunks []chunk[plog.Logs]
+	var part *chunk[plog.Logs]
+	var destRL plog.ResourceLogs
+	var destSL plog.ScopeLogs
+	haveRL, haveSL := false, false
+
+	open := func(size int, rlSize, slSize int) {
+		if !haveRL {
+			size += rlSize
+		}
+		if !haveSL {
+			size += slSize
+		}
+		if part != nil && (part.size == 0 || part.size+size <= budget) {
+			return
+		}
+		chunks = append(chunks, chunk[plog.Logs]{data: plog.NewLogs()})
+		part = &chunks[len(chunks)-1]
+		haveRL, haveSL = false, false
+	}
+
+	for i, rls := 0, ls.ResourceLogs(); i < rls.Len(); i++ {
+		rl := rls.At(i)
+		rlSize := resourceSize(rl.Resource(), rl.SchemaUrl())
+		haveRL = false
+		if wholeResources {
+			size := resourceLogsSize(rl)
+			open(size, 0, 0)
+			rl.CopyTo(part.data.ResourceLogs().AppendEmpty())
+			part.size += size
+			continue
+		}
+		openRL := func() {
+			if !haveRL {
+				destRL = part.data.ResourceLogs().AppendEmpty()
+				rl.Resource().CopyTo(destRL.Resource())
+				destRL.SetSchemaUrl(rl.SchemaUrl())
+				part.size += rlSize
+				haveRL = true
+			}
+		}
+
+		for j, sls := 0, rl.ScopeLogs(); j < sls.Len(); j++ {
+			sl := sls.At(j)
+			slSize := scopeSize(sl.Scope(), sl.SchemaUrl())
+			haveSL = false
+			openSL := func() {
+				openRL()
+				if !haveSL {
+					destSL = destRL.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(destSL.Scope())
+					destSL.SetSchemaUrl(sl.SchemaUrl())
+					part.size += slSize
+					haveSL = true
+				}
+			}
+
+			records := sl.LogRecords()
+			for k := 0; k < records.Len(); k++ {
+				size := logRecordSize(records.At(k))
+				open(size, rlSize, slSize)
+				openSL()
+				records.At(k).CopyTo(destSL.LogRecords().AppendEmpty())
+				part.size += size
+			}
+			if records.Len() == 0 {
+				open(0, rlSize, slSize)
+				openSL()
+			}
+		}
+		if rl.ScopeLogs().Len() == 0 {
+			open(0, rlSize, 0)
+			openRL()
+		}
+	}
+	return chunks
+}
+
+// splitMetrics splits ms in chunks of data points whose cumulative estimated
+// size is at most budget (see splitTraces). A metric spanning several chunks
+// is duplicated in each of them with its data points of the chunk.
+func splitMetrics(ms pmetric.Metrics, budget int, wholeResources bool) []chunk[pmetric.Metrics] {
+	var chunks []chunk[pmetric.Metrics]
+	var part *chunk[pmetric.Metrics]
+	var destRM pmetric.ResourceMetrics
+	var destSM pmetric.ScopeMetrics
+	var destM pmetric.Metric
+	haveRM, haveSM, haveM := false, false, false
+
+	open := func(size int, rmSize, smSize, mSize int) {
+		if !haveRM {
+			size += rmSize
+		}
+		if !haveSM {
+			size += smSize
+		}
+		if !haveM {
+			size += mSize
+		}
+		if part != nil && (part.size == 0 || part.size+size <= budget) {
+			return
+		}
+		chunks = append(chunks, chunk[pmetric.Metrics]{data: pmetric.NewMetrics()})
+		part = &chunks[len(chunks)-1]
+		haveRM, haveSM, haveM = false, false, false
+	}
+
+	for i, rms := 0, ms.ResourceMetrics(); i < rms.Len(); i++ {
+		rm := rms.At(i)
+		rmSize := resourceSize(rm.Resource(), rm.SchemaUrl())
+		haveRM = false
+		if wholeResources {
+			size := resourceMetricsSize(rm)
+			open(size, 0, 0, 0)
+			rm.CopyTo(part.data.ResourceMetrics().AppendEmpty())
+			part.size += size
+			continue
+		}
+		openRM := func() {
+			if !haveRM {
+				destRM = part.data.ResourceMetrics().AppendEmpty()
+				rm.Resource().CopyTo(destRM.Resource())
+				destRM.SetSchemaUrl(rm.SchemaUrl())
+				part.size += rmSize
+				haveRM = true
+			}
+		}
+
+		for j, sms := 0, rm.ScopeMetrics(); j < sms.Len(); j++ {
+			sm := sms.At(j)
+			smSize := scopeSize(sm.Scope(), sm.SchemaUrl())
+			haveSM = false
+			openSM := func() {
+				openRM()
+				if !haveSM {
+					destSM = destRM.ScopeMetrics().AppendEmpty()
+					sm.Scope().CopyTo(destSM.Scope())
+					destSM.SetSchemaUrl(sm.SchemaUrl())
+					part.size += smSize
+					haveSM = true
+				}
+			}
+
+			for k, metrics := 0, sm.Metrics(); k < metrics.Len(); k++ {
+				m := metrics.At(k)
+				mSize := metricSize(m)
+				haveM = false
+				openM := func() {
+					openSM()
+					if !haveM {
+						destM = appendEmptyMetric(destSM.Metrics(), m)
+						part.size += mSize
+						haveM = true
+					}
+				}
+
+				n := dataPointCount(m)
+				for l := 0; l < n; l++ {
+					size := dataPointSize(m, l)
+					open(size, rmSize, smSize, mSize)
+					openM()
+					copyDataPoint(m, l, destM)
+					part.size += size
+				}
+				if n == 0 {
+					open(0, rmSize, smSize, mSize)
+					openM()
+				}
+			}
+			if sm.Metrics().Len() == 0 {
+				open(0, rmSize, smSize, 0)
+				openSM()
+			}
+		}
+		if rm.ScopeMetrics().Len() == 0 {
+			open(0, rmSize, 0, 0)
+			openRM()
+		}
+	}
+	return chunks
+}
+
+// appendEmptyMetric appends a metric with the name, description, unit, type
+// and properties of m to metrics, without the data points of m.
+func appendEmptyMetric(metrics pmetric.MetricSlice, m pmetric.Metric) pmetric.Metric {
+	dest := metrics.AppendEmpty()
+	dest.SetName(m.Name())
+	dest.SetDescription(m.Description())
+	dest.SetUnit(m.Unit())
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dest.SetEmptyGauge()
+	case pmetric.MetricTypeSum:
+		sum := dest.SetEmptySum()
+		sum.SetAggregationTemporality(m.Sum().AggregationTemporality())
+		sum.SetIsMonotonic(m.Sum().IsMonotonic())
+	case pmetric.MetricTypeHistogram:
+		dest.SetEmptyHistogram().SetAggregationTemporality(m.Histogram().AggregationTemporality())
+	case pmetric.MetricTypeExponentialHistogram:
+		dest.SetEmptyExponentialHistogram().SetAggregationTemporality(m.ExponentialHistogram().AggregationTemporality())
+	case pmetric.MetricTypeSummary:
+		dest.SetEmptySummary()
+	}
+	return dest
+}
+
+// dataPointCount returns the number of data points of m.
+func dataPointCount(m pmetric.Metric) int {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		return m.Gauge().DataPoints().Len()
+	case pmetric.MetricTypeSum:
+		return m.Sum().DataPoints().Len()
+	case pmetric.MetricTypeHistogram:
+		return m.Histogram().DataPoints().Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		return m.ExponentialHistogram().DataPoints().Len()
+	case pmetric.MetricTypeSummary:
+		return m.Summary().DataPoints().Len()
+	}
+	return 0
+}
+
+// copyDataPoint appends a copy of the i-th data point of m to dest, a metric
+// of the same type.
+func copyDataPoint(m pmetric.Metric, i int, dest pmetric.Metric) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		m.Gauge().DataPoints().At(i).CopyTo(dest.Gauge().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSum:
+		m.Sum().DataPoints().At(i).CopyTo(dest.Sum().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeHistogram:
+		m.Histogram().DataPoints().At(i).CopyTo(dest.Histogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeExponentialHistogram:
+		m.ExponentialHistogram().DataPoints().At(i).CopyTo(dest.ExponentialHistogram().DataPoints().AppendEmpty())
+	case pmetric.MetricTypeSummary:
+		m.Summary().DataPoints().At(i).CopyTo(dest.Summary().DataPoints().AppendEmpty())
+	}
+}
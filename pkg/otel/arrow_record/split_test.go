@@ -0,0 +1,268 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/datagen"
+)
+
+func TestSplitArrowRecordsFromTraces(t *testing.T) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewTracesGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	traces := dg.Generate(500, time.Minute)
+
+	// Without limit a single batch is produced.
+	producer := NewProducer()
+	bars, err := producer.SplitArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(bars))
+	limit := proto.Size(bars[0]) / 2
+	require.NoError(t, producer.Close())
+
+	producer = NewProducerWithOptions(config.WithMaxRecordBytes(limit))
+	consumer := NewConsumer()
+	for round := 0; round < 2; round++ {
+		bars, err = producer.SplitArrowRecordsFromTraces(traces)
+		require.NoError(t, err)
+		require.Greater(t, len(bars), 1)
+
+		spans := 0
+		for i, bar := range bars {
+			require.LessOrEqual(t, proto.Size(bar), limit)
+			if i > 0 {
+				// The batch IDs of the dropped batches are reused.
+				require.Equal(t, bars[i-1].BatchId+1, bar.BatchId)
+			}
+			received, err := consumer.TracesFrom(bar)
+			require.NoError(t, err)
+			for _, td := range received {
+				spans += td.SpanCount()
+			}
+		}
+		require.Equal(t, traces.SpanCount(), spans)
+	}
+	require.NoError(t, producer.Close())
+	require.NoError(t, consumer.Close())
+}
+
+func TestSplitArrowRecordsWithDataFromLogs(t *testing.T) {
+	ent := datagen.NewTestEntropy(12345)
+	dg := datagen.NewLogsGenerator(
+		ent,
+		ent.NewStandardResourceAttributes(),
+		ent.NewStandardInstrumentationScopes(),
+	)
+	logs := dg.Generate(500, time.Minute)
+
+	producer := NewProducer()
+	bars, err := producer.SplitArrowRecordsFromLogs(logs)
+	require.NoError(t, err)
+	limit := proto.Size(bars[0]) / 2
+	require.NoError(t, producer.Close())
+
+	// Each batch is returned with the logs it encodes.
+	producer = NewProducerWithOptions(config.WithMaxRecordBytes(limit))
+	consumer := NewConsumer()
+	bars, parts, err := producer.SplitArrowRecordsWithDataFromLogs(logs)
+	require.NoError(t, err)
+	require.Greater(t, len(bars), 1)
+	require.Equal(t, len(bars), len(parts))
+
+	records := 0
+	for i, bar := range bars {
+		received, err := consumer.LogsFrom(bar)
+		require.NoError(t, err)
+		count := 0
+		for _, ld := range received {
+			count += ld.LogRecordCount()
+		}
+		require.Equal(t, parts[i].LogRecordCount(), count)
+		records += count
+	}
+	require.Equal(t, logs.LogRecordCount(), records)
+	require.NoError(t, producer.Close())
+	require.NoError(t, consumer.Close())
+}
+
+func TestSplitArrowRecordsItemTooLarge(t *testing.T) {
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("a single log record")
+
+	producer := NewProducerWithOptions(config.WithMaxRecordBytes(16))
+	_, err := producer.SplitArrowRecordsFromLogs(logs)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrBatchTooLarge))
+	require.NoError(t, producer.Close())
+}
+
+func TestSplitArrowRecordsFromMetrics(t *testing.T) {
+	// A single metric with many data points.
+	metrics := pmetric.NewMetrics()
+	sum := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	sum.SetName("requests")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	for i := 0; i < 1000; i++ {
+		dp := sum.Sum().DataPoints().AppendEmpty()
+		dp.SetIntValue(int64(i))
+		dp.Attributes().PutStr("path", fmt.Sprintf("/api/v1/items/%d", i))
+	}
+
+	producer := NewProducer()
+	bars, err := producer.SplitArrowRecordsFromMetrics(metrics)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(bars))
+	limit := proto.Size(bars[0]) / 4
+	require.NoError(t, producer.Close())
+
+	producer = NewProducerWithOptions(config.WithMaxRecordBytes(limit))
+	consumer := NewConsumer()
+	bars, err = producer.SplitArrowRecordsFromMetrics(metrics)
+	require.NoError(t, err)
+	require.Greater(t, len(bars), 1)
+
+	points := 0
+	for _, bar := range bars {
+		require.LessOrEqual(t, proto.Size(bar), limit)
+		received, err := consumer.MetricsFrom(bar)
+		require.NoError(t, err)
+		for _, md := range received {
+			require.Equal(t, 1, md.MetricCount())
+			points += md.DataPointCount()
+		}
+	}
+	require.Equal(t, metrics.DataPointCount(), points)
+	require.NoError(t, producer.Close())
+	require.NoError(t, consumer.Close())
+}
+
+func TestSplitLogs(t *testing.T) {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "a")
+	sl := rl.ScopeLogs().AppendEmpty()
+	for i := 0; i < 3; i++ {
+		sl.LogRecords().AppendEmpty().Body().SetInt(int64(i))
+	}
+	// An empty scope and an empty resource after the last log record.
+	rl.ScopeLogs().AppendEmpty().Scope().SetName("empty")
+	logs.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("service.name", "b")
+
+	// A budget of two log records.
+	recordSize := logRecordSize(sl.LogRecords().At(0))
+	budget := resourceSize(rl.Resource(), "") + scopeSize(sl.Scope(), "") + 2*recordSize
+	chunks := splitLogs(logs, budget, false)
+	require.Equal(t, 2, len(chunks))
+
+	first, second := chunks[0].data, chunks[1].data
+	require.Equal(t, 2, first.LogRecordCount())
+	require.Equal(t, 1, first.ResourceLogs().Len())
+	require.Equal(t, budget, chunks[0].size)
+
+	// The resource spanning both chunks is duplicated, the empty scope
+	// and resource are kept in the last chunk.
+	require.Equal(t, 1, second.LogRecordCount())
+	require.Equal(t, 2, second.ResourceLogs().Len())
+	require.Equal(t, 2, second.ResourceLogs().At(0).ScopeLogs().Len())
+	require.Equal(t, int64(2), second.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Int())
+
+	// Without budget constraint a single chunk is equivalent to the logs.
+	chunks = splitLogs(logs, math.MaxInt, false)
+	require.Equal(t, 1, len(chunks))
+	expected, err := (&plog.JSONMarshaler{}).MarshalLogs(logs)
+	require.NoError(t, err)
+	actual, err := (&plog.JSONMarshaler{}).MarshalLogs(chunks[0].data)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+}
+
+func TestSplitArrowRecordsWholeResources(t *testing.T) {
+	traces := ptrace.NewTraces()
+	for r := 0; r < 8; r++ {
+		rs := traces.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", fmt.Sprintf("service-%d", r))
+		spans := rs.ScopeSpans().AppendEmpty().Spans()
+		for i := 0; i < 50; i++ {
+			span := spans.AppendEmpty()
+			span.SetName(fmt.Sprintf("span-%d-%d", r, i))
+			span.Attributes().PutInt("index", int64(i))
+		}
+	}
+
+	producer := NewProducer()
+	bars, err := producer.SplitArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	limit := proto.Size(bars[0]) / 3
+	require.NoError(t, producer.Close())
+
+	producer = NewProducerWithOptions(config.WithMaxRecordBytes(limit), config.WithWholeResources())
+	consumer := NewConsumer()
+	bars, err = producer.SplitArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	require.Greater(t, len(bars), 1)
+
+	// Each resource is received whole in a single batch.
+	services := map[string]int{}
+	for _, bar := range bars {
+		require.LessOrEqual(t, proto.Size(bar), limit)
+		received, err := consumer.TracesFrom(bar)
+		require.NoError(t, err)
+		for _, td := range received {
+			for i := 0; i < td.ResourceSpans().Len(); i++ {
+				rs := td.ResourceSpans().At(i)
+				name, ok := rs.Resource().Attributes().Get("service.name")
+				require.True(t, ok)
+				services[name.Str()]++
+				require.Equal(t, 50, rs.ScopeSpans().At(0).Spans().Len())
+			}
+		}
+	}
+	require.Equal(t, 8, len(services))
+	for _, count := range services {
+		require.Equal(t, 1, count)
+	}
+	require.NoError(t, producer.Close())
+	require.NoError(t, consumer.Close())
+
+	// A single resource exceeding the limit cannot be split.
+	producer = NewProducerWithOptions(config.WithMaxRecordBytes(limit/8), config.WithWholeResources())
+	_, err = producer.SplitArrowRecordsFromTraces(traces)
+	require.True(t, errors.Is(err, ErrBatchTooLarge))
+	require.NoError(t, producer.Close())
+}
+
+func TestResourceFirstKeys(t *testing.T) {
+	require.Nil(t, resourceFirstKeys(nil, false))
+	require.Nil(t, resourceFirstKeys([]string{"resource", "trace_id"}, false))
+	require.Equal(t, []string{"resource"}, resourceFirstKeys(nil, true))
+	require.Equal(t, []string{"resource", "trace_id", "name"}, resourceFirstKeys([]string{"trace_id", "name"}, false))
+}
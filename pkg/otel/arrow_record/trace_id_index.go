@@ -0,0 +1,160 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow_record
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// traceIDIndexSchemaID is the schema ID of the trace ID index records, their
+// schema never changes.
+const traceIDIndexSchemaID = "trace-id-index"
+
+// TraceIDIndexSchema is the Arrow schema of the TRACE_ID_INDEX payload. Each
+// row maps a trace ID to a range of consecutive rows of the main payload.
+var TraceIDIndexSchema = arrow.NewSchema([]arrow.Field{
+	{Name: constants.TraceId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}},
+	{Name: constants.IndexStart, Type: arrow.PrimitiveTypes.Uint32},
+	{Name: constants.IndexCount, Type: arrow.PrimitiveTypes.Uint32},
+}, nil)
+
+// TraceIDRange is a range of consecutive spans or log records sharing the same
+// trace ID. Start is the position of the first span (or log record) in the
+// traversal order of the decoded data, i.e. resource by resource, scope by
+// scope.
+type TraceIDRange struct {
+	TraceID [16]byte
+	Start   uint32
+	Count   uint32
+}
+
+// TraceIDIndex lists the trace ID ranges of a batch in the order of the rows.
+// A trace ID may appear in several ranges.
+type TraceIDIndex []TraceIDRange
+
+// Ranges returns the ranges of the given trace ID.
+func (idx TraceIDIndex) Ranges(traceID [16]byte) []TraceIDRange {
+	var ranges []TraceIDRange
+	for _, r := range idx {
+		if r.TraceID == traceID {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// traceIDIndexMessage builds the TRACE_ID_INDEX record of a main spans or logs
+// record. The rows without a trace ID are not indexed. Returns nil if the
+// record has no trace ID column.
+func traceIDIndexMessage(pool memory.Allocator, record arrow.Record) (*record_message.RecordMessage, error) {
+	fieldID, err := arrowutils.FieldIDFromSchema(record.Schema(), constants.TraceId)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if fieldID == arrowutils.AbsentFieldID {
+		return nil, nil
+	}
+
+	rb := array.NewRecordBuilder(pool, TraceIDIndexSchema)
+	defer rb.Release()
+	tidb := rb.Field(0).(*array.FixedSizeBinaryBuilder)
+	startb := rb.Field(1).(*array.Uint32Builder)
+	countb := rb.Field(2).(*array.Uint32Builder)
+
+	var current []byte
+	var start, count uint32
+	flush := func() {
+		if count > 0 {
+			tidb.Append(current)
+			startb.Append(start)
+			countb.Append(count)
+		}
+	}
+
+	rows := int(record.NumRows())
+	for row := 0; row < rows; row++ {
+		traceID, err := arrowutils.FixedSizeBinaryFromRecord(record, fieldID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		if len(traceID) != 16 || isZeroTraceID(traceID) {
+			flush()
+			current, count = nil, 0
+			continue
+		}
+		if count > 0 && string(traceID) == string(current) {
+			count++
+			continue
+		}
+		flush()
+		current, start, count = traceID, uint32(row), 1
+	}
+	flush()
+
+	return record_message.NewRelatedDataMessage(traceIDIndexSchemaID, rb.NewRecord(), colarspb.ArrowPayloadType_TRACE_ID_INDEX), nil
+}
+
+func isZeroTraceID(traceID []byte) bool {
+	for _, b := range traceID {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceIDIndexFrom decodes a TRACE_ID_INDEX record.
+func TraceIDIndexFrom(record arrow.Record) (TraceIDIndex, error) {
+	tidID, err := arrowutils.MandatoryFieldIDFromSchema(record.Schema(), constants.TraceId)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	startID, err := arrowutils.MandatoryFieldIDFromSchema(record.Schema(), constants.IndexStart)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	countID, err := arrowutils.MandatoryFieldIDFromSchema(record.Schema(), constants.IndexCount)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	rows := int(record.NumRows())
+	index := make(TraceIDIndex, 0, rows)
+	for row := 0; row < rows; row++ {
+		var r TraceIDRange
+
+		traceID, err := arrowutils.FixedSizeBinaryFromRecord(record, tidID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		copy(r.TraceID[:], traceID)
+		if r.Start, err = arrowutils.U32FromRecord(record, startID, row); err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		if r.Count, err = arrowutils.U32FromRecord(record, countID, row); err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		index = append(index, r)
+	}
+	return index, nil
+}
@@ -17,6 +17,7 @@ package arrow
 import (
 	"fmt"
 	"os"
+	"sync"
 
 	"github.com/apache/arrow/go/v12/arrow/memory"
 )
@@ -25,6 +26,8 @@ type LimitedAllocator struct {
 	mem   memory.Allocator
 	inuse uint64
 	limit uint64
+	// exceeded is the error of the last allocation beyond the limit.
+	exceeded *LimitError
 }
 
 func NewLimitedAllocator(mem memory.Allocator, limit uint64) *LimitedAllocator {
@@ -64,6 +67,7 @@ func (l *LimitedAllocator) Allocate(size int) []byte {
 		// Write the error to stderr so that it is visible even if the
 		// panic is caught.
 		os.Stderr.WriteString(err.Error() + "\n")
+		l.exceeded = &err
 		panic(err)
 	}
 
@@ -85,6 +89,7 @@ func (l *LimitedAllocator) Reallocate(size int, b []byte) []byte {
 		// Write the error to stderr so that it is visible even if the
 		// panic is caught.
 		os.Stderr.WriteString(err.Error() + "\n")
+		l.exceeded = &err
 		panic(err)
 	}
 
@@ -95,9 +100,316 @@ func (l *LimitedAllocator) Reallocate(size int, b []byte) []byte {
 	return res
 }
 
+// LimitExceeded returns and clears the error of the last allocation that
+// exceeded the limit, nil if there was none. The Arrow IPC reader and
+// writer recover the panic raised by the allocator and return an untyped
+// error instead.
+func (l *LimitedAllocator) LimitExceeded() error {
+	if l.exceeded == nil {
+		return nil
+	}
+	err := *l.exceeded
+	l.exceeded = nil
+	return err
+}
+
+// Inuse returns the number of bytes currently allocated.
+func (l *LimitedAllocator) Inuse() uint64 {
+	return l.inuse
+}
+
 func (l *LimitedAllocator) Free(b []byte) {
 	l.mem.Free(b)
 
 	// This update will be skipped if Free() panics.
 	l.inuse -= uint64(len(b))
 }
+
+// ExpansionLimit bounds the memory allocated to decode compressed Arrow IPC
+// payloads relative to their compressed size, which protects the consumers
+// from payloads declaring uncompressed buffers of a much larger size (i.e.
+// compression bombs). A single allocation can't exceed factor times the size
+// of the payload being decoded, and the allocations of a batch can't exceed
+// factor times the size of its payloads. Both bounds are at least minBytes so
+// that the small payloads and the dictionaries of a stream can be decoded.
+//
+// An ExpansionLimit is not safe for concurrent use, the allocators returned by
+// Allocator share its state.
+type ExpansionLimit struct {
+	factor   uint64
+	minBytes uint64
+
+	// payload and maxBuffer are the size of the current payload and the
+	// bound of its single allocations.
+	payload   uint64
+	maxBuffer uint64
+	// compressed and maxBatch are the size of the payloads and the bound of
+	// the allocations of the current batch.
+	compressed uint64
+	maxBatch   uint64
+	// allocated is the number of bytes allocated since the beginning of the
+	// current batch.
+	allocated uint64
+
+	// exceeded is the error of the last allocation beyond the limit.
+	exceeded *ExpansionError
+}
+
+// ExpansionError is the error of an allocation exceeding an ExpansionLimit.
+type ExpansionError struct {
+	// Scope is "buffer" for a single allocation, "batch" for the
+	// allocations of a batch.
+	Scope      string
+	Compressed uint64
+	Expanded   uint64
+	Factor     uint64
+}
+
+var _ error = ExpansionError{}
+
+func (ee ExpansionError) Error() string {
+	return fmt.Sprintf("%s expansion from %d to %d bytes exceeds factor %d", ee.Scope, ee.Compressed, ee.Expanded, ee.Factor)
+}
+
+func (_ ExpansionError) Is(tgt error) bool {
+	_, ok := tgt.(ExpansionError)
+	return ok
+}
+
+// NewExpansionLimit creates an ExpansionLimit of the given factor, with
+// bounds of at least minBytes.
+func NewExpansionLimit(factor, minBytes uint64) *ExpansionLimit {
+	return &ExpansionLimit{
+		factor:   factor,
+		minBytes: minBytes,
+	}
+}
+
+// BeginBatch resets the allocations counted for the batch and sets its bound
+// from the total size of its payloads.
+func (e *ExpansionLimit) BeginBatch(compressed int) {
+	e.compressed = uint64(compressed)
+	e.maxBatch = e.bound(e.compressed)
+	e.allocated = 0
+}
+
+// BeginPayload sets the bound of the single allocations from the size of the
+// next payload to decode.
+func (e *ExpansionLimit) BeginPayload(compressed int) {
+	e.payload = uint64(compressed)
+	e.maxBuffer = e.bound(e.payload)
+}
+
+func (e *ExpansionLimit) bound(compressed uint64) uint64 {
+	if compressed*e.factor < e.minBytes {
+		return e.minBytes
+	}
+	return compressed * e.factor
+}
+
+// Exceeded returns and clears the error of the last allocation that exceeded
+// the limit, nil if there was none.
+func (e *ExpansionLimit) Exceeded() error {
+	if e.exceeded == nil {
+		return nil
+	}
+	err := *e.exceeded
+	e.exceeded = nil
+	return err
+}
+
+// Allocator returns an allocator checking the allocations made with mem
+// against the limit.
+func (e *ExpansionLimit) Allocator(mem memory.Allocator) memory.Allocator {
+	return &expansionAllocator{
+		mem:   mem,
+		limit: e,
+	}
+}
+
+// check panics with an ExpansionError if an allocation of size bytes, growing
+// the allocated memory by change bytes, exceeds the limit.
+func (e *ExpansionLimit) check(size, change uint64) {
+	var err *ExpansionError
+	if size > e.maxBuffer {
+		err = &ExpansionError{
+			Scope:      "buffer",
+			Compressed: e.payload,
+			Expanded:   size,
+			Factor:     e.factor,
+		}
+	} else if e.allocated+change > e.maxBatch {
+		err = &ExpansionError{
+			Scope:      "batch",
+			Compressed: e.compressed,
+			Expanded:   e.allocated + change,
+			Factor:     e.factor,
+		}
+	}
+	if err != nil {
+		e.exceeded = err
+		panic(*err)
+	}
+	e.allocated += change
+}
+
+type expansionAllocator struct {
+	mem   memory.Allocator
+	limit *ExpansionLimit
+}
+
+var _ memory.Allocator = &expansionAllocator{}
+
+func (a *expansionAllocator) Allocate(size int) []byte {
+	a.limit.check(uint64(size), uint64(size))
+	return a.mem.Allocate(size)
+}
+
+func (a *expansionAllocator) Reallocate(size int, b []byte) []byte {
+	var change uint64
+	if size > len(b) {
+		change = uint64(size - len(b))
+	}
+	a.limit.check(uint64(size), change)
+	return a.mem.Reallocate(size, b)
+}
+
+func (a *expansionAllocator) Free(b []byte) {
+	a.mem.Free(b)
+}
+
+// RecyclingAllocator is an allocator that keeps the buffers freed by the
+// Arrow builders and records to serve the next allocations of a similar size
+// instead of allocating new buffers. In steady state, the buffers of a batch
+// are recycled from the buffers of the previous batches, which removes most of
+// the allocations performed by the builders.
+//
+// Buffers are grouped by size classes (powers of two) and the total size of
+// the buffers kept for reuse is bounded. Release must be called to return the
+// kept buffers to the underlying allocator.
+type RecyclingAllocator struct {
+	mem   memory.Allocator
+	limit uint64
+
+	lock     sync.Mutex
+	classes  map[*byte]int
+	free     map[int][][]byte
+	kept     uint64
+	released bool
+}
+
+var _ memory.Allocator = &RecyclingAllocator{}
+
+// NewRecyclingAllocator creates a RecyclingAllocator keeping at most limit
+// bytes of freed buffers.
+func NewRecyclingAllocator(mem memory.Allocator, limit uint64) *RecyclingAllocator {
+	return &RecyclingAllocator{
+		mem:     mem,
+		limit:   limit,
+		classes: make(map[*byte]int),
+		free:    make(map[int][][]byte),
+	}
+}
+
+func (r *RecyclingAllocator) Allocate(size int) []byte {
+	if size == 0 {
+		return r.mem.Allocate(size)
+	}
+
+	class := sizeClass(size)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if bufs := r.free[class]; len(bufs) > 0 {
+		buf := bufs[len(bufs)-1]
+		r.free[class] = bufs[:len(bufs)-1]
+		r.kept -= uint64(class)
+
+		// The underlying allocator returns zeroed buffers.
+		for i := range buf {
+			buf[i] = 0
+		}
+		return buf[:size]
+	}
+
+	buf := r.mem.Allocate(class)
+	r.classes[&buf[0]] = class
+	return buf[:size]
+}
+
+func (r *RecyclingAllocator) Reallocate(size int, b []byte) []byte {
+	if cap(b) == 0 {
+		return r.Allocate(size)
+	}
+
+	r.lock.Lock()
+	class, ok := r.classes[&b[:1][0]]
+	r.lock.Unlock()
+
+	if ok && size <= class {
+		nb := b[:size]
+		for i := len(b); i < size; i++ {
+			nb[i] = 0
+		}
+		return nb
+	}
+
+	nb := r.Allocate(size)
+	copy(nb, b)
+	r.Free(b)
+	return nb
+}
+
+func (r *RecyclingAllocator) Free(b []byte) {
+	if cap(b) == 0 {
+		r.mem.Free(b)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	ptr := &b[:1][0]
+	class, ok := r.classes[ptr]
+	if !ok {
+		r.mem.Free(b)
+		return
+	}
+
+	buf := b[:class]
+	if r.released || r.kept+uint64(class) > r.limit {
+		delete(r.classes, ptr)
+		r.mem.Free(buf)
+		return
+	}
+
+	r.free[class] = append(r.free[class], buf)
+	r.kept += uint64(class)
+}
+
+// Release returns the kept buffers to the underlying allocator. Buffers freed
+// after the release are no longer kept.
+func (r *RecyclingAllocator) Release() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for class, bufs := range r.free {
+		for _, buf := range bufs {
+			delete(r.classes, &buf[0])
+			r.mem.Free(buf)
+		}
+		delete(r.free, class)
+	}
+	r.kept = 0
+	r.released = true
+}
+
+// sizeClass returns the smallest power of two greater than or equal to size.
+func sizeClass(size int) int {
+	class := 1
+	for class < size {
+		class <<= 1
+	}
+	return class
+}
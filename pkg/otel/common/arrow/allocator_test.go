@@ -49,7 +49,130 @@ func TestLimitedAllocator(t *testing.T) {
 	require.True(t, errors.Is(capture.(error), LimitError{}))
 	require.Equal(t, "allocation size 1 exceeds limit 1000000 (in-use=1000000)", capture.(error).Error())
 
+	// The error remains available when the panic is recovered elsewhere.
+	require.Equal(t, capture, limit.LimitExceeded())
+	require.NoError(t, limit.LimitExceeded())
+
 	limit.Free(b)
 
 	check.AssertSize(t, 0)
 }
+
+func TestRecyclingAllocator(t *testing.T) {
+	check := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	recycler := NewRecyclingAllocator(check, 4096)
+
+	b := recycler.Allocate(1000)
+	require.Equal(t, 1000, len(b))
+	check.AssertSize(t, 1024)
+	b[0] = 1
+	recycler.Free(b)
+
+	// The freed buffer is reused and zeroed.
+	b2 := recycler.Allocate(900)
+	require.Equal(t, 900, len(b2))
+	require.Equal(t, byte(0), b2[0])
+	check.AssertSize(t, 1024)
+
+	// Growing within the size class keeps the buffer.
+	b2[0] = 2
+	b2 = recycler.Reallocate(1024, b2)
+	require.Equal(t, 1024, len(b2))
+	require.Equal(t, byte(2), b2[0])
+	check.AssertSize(t, 1024)
+
+	// Growing beyond the size class moves the data.
+	b2 = recycler.Reallocate(2000, b2)
+	require.Equal(t, byte(2), b2[0])
+	check.AssertSize(t, 1024+2048)
+
+	// Buffers exceeding the limit are not kept.
+	b3 := recycler.Allocate(4096)
+	recycler.Free(b2)
+	recycler.Free(b3)
+	check.AssertSize(t, 1024+2048)
+
+	recycler.Release()
+	check.AssertSize(t, 0)
+}
+
+func TestExpansionLimit(t *testing.T) {
+	check := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	limit := NewExpansionLimit(10, 100)
+	mem := limit.Allocator(check)
+
+	recoverError := func(f func()) (err error) {
+		defer func() {
+			if ret := recover(); ret != nil {
+				err = ret.(error)
+			}
+		}()
+		f()
+		return nil
+	}
+
+	// The bounds are at least the minimum.
+	limit.BeginBatch(50)
+	limit.BeginPayload(5)
+	b1 := mem.Allocate(100)
+	require.NoError(t, limit.Exceeded())
+
+	// A single buffer can't exceed factor times its payload.
+	limit.BeginPayload(20)
+	err := recoverError(func() { _ = mem.Allocate(201) })
+	require.True(t, errors.Is(err, ExpansionError{}))
+	require.Equal(t, "buffer expansion from 20 to 201 bytes exceeds factor 10", err.Error())
+	require.Equal(t, err, limit.Exceeded())
+	require.NoError(t, limit.Exceeded())
+
+	// The allocations of a batch can't exceed factor times its payloads.
+	b2 := mem.Allocate(200)
+	b3 := mem.Allocate(200)
+	err = recoverError(func() { _ = mem.Allocate(1) })
+	require.Equal(t, ExpansionError{Scope: "batch", Compressed: 50, Expanded: 501, Factor: 10}, err)
+
+	// The next batch starts with a new budget.
+	limit.BeginBatch(50)
+	b4 := mem.Allocate(200)
+
+	for _, b := range [][]byte{b1, b2, b3, b4} {
+		mem.Free(b)
+	}
+	check.AssertSize(t, 0)
+}
+
+func TestMmapAllocator(t *testing.T) {
+	if !mmapSupported {
+		t.Skip("mmap is not supported on this platform")
+	}
+
+	check := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	mmap := NewMmapAllocator(check, 1<<16)
+
+	// Small buffers are served by the underlying allocator.
+	small := mmap.Allocate(100)
+	require.Equal(t, 100, len(small))
+	check.AssertSize(t, 100)
+	require.Equal(t, uint64(0), mmap.Mapped())
+
+	// Growing beyond the threshold moves the buffer to a mapping.
+	small[0] = 1
+	large := mmap.Reallocate(1<<16, small)
+	require.Equal(t, 1<<16, len(large))
+	require.Equal(t, byte(1), large[0])
+	require.Equal(t, byte(0), large[len(large)-1])
+	check.AssertSize(t, 0)
+	require.Equal(t, uint64(1<<16), mmap.Mapped())
+
+	// Growing beyond the mapping remaps the buffer.
+	large[100] = 2
+	large = mmap.Reallocate(1<<17+1, large)
+	require.Equal(t, 1<<17+1, len(large))
+	require.Equal(t, byte(2), large[100])
+	require.GreaterOrEqual(t, mmap.Mapped(), uint64(1<<17+1))
+
+	// The mappings are released when the buffers are freed.
+	mmap.Free(large)
+	require.Equal(t, uint64(0), mmap.Mapped())
+	check.AssertSize(t, 0)
+}
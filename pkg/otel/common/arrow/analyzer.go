@@ -20,6 +20,7 @@ package arrow
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"sort"
 	"strings"
@@ -70,18 +71,18 @@ func (s *ScopeStats) UpdateWith(scope pcommon.InstrumentationScope) {
 	s.AttributesStats.UpdateWith(scope.Attributes(), scope.DroppedAttributesCount())
 }
 
-func (s *ScopeStats) ShowStats(indent string) {
+func (s *ScopeStats) ShowStats(w io.Writer, indent string) {
 	if !s.Name.IsPresent() && !s.Version.IsPresent() && !s.AttributesStats.IsPresent() {
-		fmt.Printf("%s%sNo Scope%s\n", indent, Grey, ColorReset)
+		fmt.Fprintf(w, "%s%sNo Scope%s\n", indent, Grey, ColorReset)
 		return
 	}
 
-	print(Green)
-	fmt.Printf("%sScope%s (Missing=%d)\n", indent, ColorReset, s.Missing)
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%sScope%s (Missing=%d)\n", indent, ColorReset, s.Missing)
 	indent += "  "
-	s.Name.ShowStats("Name", indent)
-	s.Version.ShowStats("Version", indent)
-	s.AttributesStats.ShowStats(indent, "Attributes", Green)
+	s.Name.ShowStats(w, "Name", indent)
+	s.Version.ShowStats(w, "Version", indent)
+	s.AttributesStats.ShowStats(w, indent, "Attributes", Green)
 }
 
 func (r *ResourceStats) UpdateWith(res pcommon.Resource) {
@@ -97,10 +98,10 @@ func (r *ResourceStats) UpdateWith(res pcommon.Resource) {
 	r.AttributesStats.UpdateWith(attrs, dac)
 }
 
-func (r *ResourceStats) ShowStats(indent string) {
-	print(Green)
-	fmt.Printf("%sResource%s (Missing=%d)\n", indent, ColorReset, r.Missing)
-	r.AttributesStats.ShowStats(indent+"  ", "Attributes", Green)
+func (r *ResourceStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%sResource%s (Missing=%d)\n", indent, ColorReset, r.Missing)
+	r.AttributesStats.ShowStats(w, indent+"  ", "Attributes", Green)
 }
 
 func (s *StatusStats) UpdateWith(status ptrace.Status) {
@@ -128,28 +129,28 @@ func (s *StatusStats) UpdateWith(status ptrace.Status) {
 	s.TotalCount++
 }
 
-func (s *StatusStats) ShowStats(indent string) {
+func (s *StatusStats) ShowStats(w io.Writer, indent string) {
 	if s.TotalCount == 0 {
-		print(Grey)
-		fmt.Printf("%sNo Status%s\n", indent, ColorReset)
+		fmt.Fprint(w, Grey)
+		fmt.Fprintf(w, "%sNo Status%s\n", indent, ColorReset)
 		return
 	}
 
-	print(Green)
-	fmt.Printf("%sStatus%s (Missing=%d)\n", indent, ColorReset, s.Missing)
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%sStatus%s (Missing=%d)\n", indent, ColorReset, s.Missing)
 
 	indent = indent + "  "
 
-	print(Green)
-	fmt.Printf("%sCode%s |Missing|Distinct|\n", indent, ColorReset)
-	fmt.Printf("%s     |%7d|%8d|\n", indent, s.MissingCode, s.CodeDistinctValue.Estimate())
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%sCode%s |Missing|Distinct|\n", indent, ColorReset)
+	fmt.Fprintf(w, "%s     |%7d|%8d|\n", indent, s.MissingCode, s.CodeDistinctValue.Estimate())
 
 	if s.MessageLenDistribution.TotalCount() == 0 {
-		fmt.Printf("%s%sNo message%s\n", indent, Grey, ColorReset)
+		fmt.Fprintf(w, "%s%sNo message%s\n", indent, Grey, ColorReset)
 	} else {
-		print(Green)
-		fmt.Printf("%sMessage%s |Missing|Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indent, ColorReset)
-		fmt.Printf("%s        |%7d|%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indent, s.MissingMessage, s.MessageDistincValue.Estimate(), s.MessageLenDistribution.Min(), s.MessageLenDistribution.Max(), s.MessageLenDistribution.Mean(), s.MessageLenDistribution.StdDev(), s.MessageLenDistribution.ValueAtQuantile(50), s.MessageLenDistribution.ValueAtQuantile(99))
+		fmt.Fprint(w, Green)
+		fmt.Fprintf(w, "%sMessage%s |Missing|Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indent, ColorReset)
+		fmt.Fprintf(w, "%s        |%7d|%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indent, s.MissingMessage, s.MessageDistincValue.Estimate(), s.MessageLenDistribution.Min(), s.MessageLenDistribution.Max(), s.MessageLenDistribution.Mean(), s.MessageLenDistribution.StdDev(), s.MessageLenDistribution.ValueAtQuantile(50), s.MessageLenDistribution.ValueAtQuantile(99))
 	}
 }
 
@@ -349,86 +350,86 @@ func (a *AttributesStats) IsPresent() bool {
 	return a.TotalCount > 0
 }
 
-func (a *AttributesStats) ShowStats(indent string, title string, color string) {
+func (a *AttributesStats) ShowStats(w io.Writer, indent string, title string, color string) {
 	if !a.IsPresent() {
-		print(Grey)
-		fmt.Printf("%sNo %s%s\n", indent, title, ColorReset)
+		fmt.Fprint(w, Grey)
+		fmt.Fprintf(w, "%sNo %s%s\n", indent, title, ColorReset)
 		return
 	}
 
-	fmt.Printf("%s%s%s%s |Missing|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, color, title, ColorReset)
-	fmt.Printf("%s%s |%7d|%7d|%7d|%7.1f|%7.1f|%7d|%7d|\n", indent, strings.Repeat(" ", len(title)),
+	fmt.Fprintf(w, "%s%s%s%s |Missing|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, color, title, ColorReset)
+	fmt.Fprintf(w, "%s%s |%7d|%7d|%7d|%7.1f|%7.1f|%7d|%7d|\n", indent, strings.Repeat(" ", len(title)),
 		a.Missing, a.Distribution.Min(), a.Distribution.Max(), a.Distribution.Mean(), a.Distribution.StdDev(), a.Distribution.ValueAtQuantile(50), a.Distribution.ValueAtQuantile(99),
 	)
 	indentChildren := indent + "  "
 
-	print(Cyan)
-	fmt.Printf("%sType%s     | Total|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indentChildren, ColorReset)
+	fmt.Fprint(w, Cyan)
+	fmt.Fprintf(w, "%sType%s     | Total|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indentChildren, ColorReset)
 	if a.I64TypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sI64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sI64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.I64TypeDistribution.TotalCount(), a.I64TypeDistribution.Min(), a.I64TypeDistribution.Max(), a.I64TypeDistribution.Mean(), a.I64TypeDistribution.StdDev(), a.I64TypeDistribution.ValueAtQuantile(50), a.I64TypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.F64TypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sF64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sF64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.F64TypeDistribution.TotalCount(), a.F64TypeDistribution.Min(), a.F64TypeDistribution.Max(), a.F64TypeDistribution.Mean(), a.F64TypeDistribution.StdDev(), a.F64TypeDistribution.ValueAtQuantile(50), a.F64TypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.BoolTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sBool   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sBool   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.BoolTypeDistribution.TotalCount(), a.BoolTypeDistribution.Min(), a.BoolTypeDistribution.Max(), a.BoolTypeDistribution.Mean(), a.BoolTypeDistribution.StdDev(), a.BoolTypeDistribution.ValueAtQuantile(50), a.BoolTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.StringTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sString |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sString |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.StringTypeDistribution.TotalCount(), a.StringTypeDistribution.Min(), a.StringTypeDistribution.Max(), a.StringTypeDistribution.Mean(), a.StringTypeDistribution.StdDev(), a.StringTypeDistribution.ValueAtQuantile(50), a.StringTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.BinaryTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sBinary |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sBinary |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.BinaryTypeDistribution.TotalCount(), a.BinaryTypeDistribution.Min(), a.BinaryTypeDistribution.Max(), a.BinaryTypeDistribution.Mean(), a.BinaryTypeDistribution.StdDev(), a.BinaryTypeDistribution.ValueAtQuantile(50), a.BinaryTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.ListTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sList   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sList   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.ListTypeDistribution.TotalCount(), a.ListTypeDistribution.Min(), a.ListTypeDistribution.Max(), a.ListTypeDistribution.Mean(), a.ListTypeDistribution.StdDev(), a.ListTypeDistribution.ValueAtQuantile(50), a.ListTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.MapTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sMap    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sMap    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.MapTypeDistribution.TotalCount(), a.MapTypeDistribution.Min(), a.MapTypeDistribution.Max(), a.MapTypeDistribution.Mean(), a.MapTypeDistribution.StdDev(), a.MapTypeDistribution.ValueAtQuantile(50), a.MapTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 
-	print(Cyan)
-	fmt.Printf("%sKey%s      |Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indentChildren, ColorReset)
-	fmt.Printf("%s         |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren,
+	fmt.Fprint(w, Cyan)
+	fmt.Fprintf(w, "%sKey%s      |Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indentChildren, ColorReset)
+	fmt.Fprintf(w, "%s         |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren,
 		a.KeyDistinctValue.Estimate(), a.KeyLenDistribution.Min(), a.KeyLenDistribution.Max(), a.KeyLenDistribution.Mean(), a.KeyLenDistribution.StdDev(), a.KeyLenDistribution.ValueAtQuantile(50), a.KeyLenDistribution.ValueAtQuantile(99),
 	)
 
-	print(Cyan)
-	fmt.Printf("%sValue%s    |Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indentChildren, ColorReset)
+	fmt.Fprint(w, Cyan)
+	fmt.Fprintf(w, "%sValue%s    |Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indentChildren, ColorReset)
 	if a.I64DistinctValue.Estimate() > 0 {
-		fmt.Printf("%sI64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.I64DistinctValue.Estimate())
+		fmt.Fprintf(w, "%sI64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.I64DistinctValue.Estimate())
 	}
 	if a.F64DistinctValue.Estimate() > 0 {
-		fmt.Printf("%sF64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.F64DistinctValue.Estimate())
+		fmt.Fprintf(w, "%sF64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.F64DistinctValue.Estimate())
 	}
 	if a.StringDistinctValue.Estimate() > 0 {
-		fmt.Printf("%sString |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.StringDistinctValue.Estimate(),
+		fmt.Fprintf(w, "%sString |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.StringDistinctValue.Estimate(),
 			a.StringLenDistribution.Min(), a.StringLenDistribution.Max(), a.StringLenDistribution.Mean(), a.StringLenDistribution.StdDev(), a.StringLenDistribution.ValueAtQuantile(50), a.StringLenDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.BinaryDistinctValue.Estimate() > 0 {
-		fmt.Printf("%sBinary |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.BinaryDistinctValue.Estimate(),
+		fmt.Fprintf(w, "%sBinary |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.BinaryDistinctValue.Estimate(),
 			a.BinaryLenDistribution.Min(), a.BinaryLenDistribution.Max(), a.BinaryLenDistribution.Mean(), a.BinaryLenDistribution.StdDev(), a.BinaryLenDistribution.ValueAtQuantile(50), a.BinaryLenDistribution.ValueAtQuantile(99),
 		)
 	}
 
 	if a.DACDistinctValue.Estimate() > 0 {
-		print(Green)
-		fmt.Printf("%sDroppedAttributesCount%s |Distinct|   Total|%%Distinct|\n", indent, ColorReset)
-		fmt.Printf("%s                       |%8d|%8d|%8.1f%%|\n", indent,
+		fmt.Fprint(w, Green)
+		fmt.Fprintf(w, "%sDroppedAttributesCount%s |Distinct|   Total|%%Distinct|\n", indent, ColorReset)
+		fmt.Fprintf(w, "%s                       |%8d|%8d|%8.1f%%|\n", indent,
 			a.DACDistinctValue.Estimate(), a.TotalCount, float64(a.DACDistinctValue.Estimate())/float64(a.TotalCount)*100,
 		)
 	}
@@ -508,72 +509,72 @@ func (a *AnyValueStats) IsPresent() bool {
 	return a.TotalCount > 0
 }
 
-func (a *AnyValueStats) ShowStats(indent string, title string, color string) {
+func (a *AnyValueStats) ShowStats(w io.Writer, indent string, title string, color string) {
 	if !a.IsPresent() {
-		print(Grey)
-		fmt.Printf("%sNo %s%s\n", indent, title, ColorReset)
+		fmt.Fprint(w, Grey)
+		fmt.Fprintf(w, "%sNo %s%s\n", indent, title, ColorReset)
 		return
 	}
 
-	fmt.Printf("%s%s%s%s |Missing|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, color, title, ColorReset)
-	fmt.Printf("%s%s |%7d|\n", indent, strings.Repeat(" ", len(title)),
+	fmt.Fprintf(w, "%s%s%s%s |Missing|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, color, title, ColorReset)
+	fmt.Fprintf(w, "%s%s |%7d|\n", indent, strings.Repeat(" ", len(title)),
 		a.Missing,
 	)
 	indentChildren := indent + "  "
 
-	print(Cyan)
-	fmt.Printf("%sType%s     | Total|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indentChildren, ColorReset)
+	fmt.Fprint(w, Cyan)
+	fmt.Fprintf(w, "%sType%s     | Total|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indentChildren, ColorReset)
 	if a.I64TypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sI64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sI64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.I64TypeDistribution.TotalCount(), a.I64TypeDistribution.Min(), a.I64TypeDistribution.Max(), a.I64TypeDistribution.Mean(), a.I64TypeDistribution.StdDev(), a.I64TypeDistribution.ValueAtQuantile(50), a.I64TypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.F64TypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sF64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sF64    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.F64TypeDistribution.TotalCount(), a.F64TypeDistribution.Min(), a.F64TypeDistribution.Max(), a.F64TypeDistribution.Mean(), a.F64TypeDistribution.StdDev(), a.F64TypeDistribution.ValueAtQuantile(50), a.F64TypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.BoolTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sBool   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sBool   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.BoolTypeDistribution.TotalCount(), a.BoolTypeDistribution.Min(), a.BoolTypeDistribution.Max(), a.BoolTypeDistribution.Mean(), a.BoolTypeDistribution.StdDev(), a.BoolTypeDistribution.ValueAtQuantile(50), a.BoolTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.StringTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sString |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sString |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.StringTypeDistribution.TotalCount(), a.StringTypeDistribution.Min(), a.StringTypeDistribution.Max(), a.StringTypeDistribution.Mean(), a.StringTypeDistribution.StdDev(), a.StringTypeDistribution.ValueAtQuantile(50), a.StringTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.BinaryTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sBinary |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sBinary |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.BinaryTypeDistribution.TotalCount(), a.BinaryTypeDistribution.Min(), a.BinaryTypeDistribution.Max(), a.BinaryTypeDistribution.Mean(), a.BinaryTypeDistribution.StdDev(), a.BinaryTypeDistribution.ValueAtQuantile(50), a.BinaryTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.ListTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sList   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sList   |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.ListTypeDistribution.TotalCount(), a.ListTypeDistribution.Min(), a.ListTypeDistribution.Max(), a.ListTypeDistribution.Mean(), a.ListTypeDistribution.StdDev(), a.ListTypeDistribution.ValueAtQuantile(50), a.ListTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.MapTypeDistribution.TotalCount() > 0 {
-		fmt.Printf("%sMap    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
+		fmt.Fprintf(w, "%sMap    |%6d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indentChildren+"  ",
 			a.MapTypeDistribution.TotalCount(), a.MapTypeDistribution.Min(), a.MapTypeDistribution.Max(), a.MapTypeDistribution.Mean(), a.MapTypeDistribution.StdDev(), a.MapTypeDistribution.ValueAtQuantile(50), a.MapTypeDistribution.ValueAtQuantile(99),
 		)
 	}
 
-	print(Cyan)
-	fmt.Printf("%sValue%s    |Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indentChildren, ColorReset)
+	fmt.Fprint(w, Cyan)
+	fmt.Fprintf(w, "%sValue%s    |Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indentChildren, ColorReset)
 	if a.I64DistinctValue.Estimate() > 0 {
-		fmt.Printf("%sI64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.I64DistinctValue.Estimate())
+		fmt.Fprintf(w, "%sI64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.I64DistinctValue.Estimate())
 	}
 	if a.F64DistinctValue.Estimate() > 0 {
-		fmt.Printf("%sF64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.F64DistinctValue.Estimate())
+		fmt.Fprintf(w, "%sF64    |%8d|     NA|     NA|      NA|       NA|     NA|     NA|\n", indentChildren+"  ", a.F64DistinctValue.Estimate())
 	}
 	if a.StringDistinctValue.Estimate() > 0 {
-		fmt.Printf("%sString |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.StringDistinctValue.Estimate(),
+		fmt.Fprintf(w, "%sString |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.StringDistinctValue.Estimate(),
 			a.StringLenDistribution.Min(), a.StringLenDistribution.Max(), a.StringLenDistribution.Mean(), a.StringLenDistribution.StdDev(), a.StringLenDistribution.ValueAtQuantile(50), a.StringLenDistribution.ValueAtQuantile(99),
 		)
 	}
 	if a.BinaryDistinctValue.Estimate() > 0 {
-		fmt.Printf("%sBinary |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.BinaryDistinctValue.Estimate(),
+		fmt.Fprintf(w, "%sBinary |%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indentChildren+"  ", a.BinaryDistinctValue.Estimate(),
 			a.BinaryLenDistribution.Min(), a.BinaryLenDistribution.Max(), a.BinaryLenDistribution.Mean(), a.BinaryLenDistribution.StdDev(), a.BinaryLenDistribution.ValueAtQuantile(50), a.BinaryLenDistribution.ValueAtQuantile(99),
 		)
 	}
@@ -595,16 +596,16 @@ func (s *StringStats) UpdateWith(str string) {
 	s.DistinctValue.Insert([]byte(str))
 }
 
-func (s *StringStats) ShowStats(name string, indent string) {
+func (s *StringStats) ShowStats(w io.Writer, name string, indent string) {
 	if !s.IsPresent() {
-		print(Grey)
-		fmt.Printf("%sNo %s%s\n", indent, name, ColorReset)
+		fmt.Fprint(w, Grey)
+		fmt.Fprintf(w, "%sNo %s%s\n", indent, name, ColorReset)
 		return
 	}
 
-	print(Green)
-	fmt.Printf("%s%s%s |Missing|Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indent, name, ColorReset)
-	fmt.Printf("%s%s |%7d|%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indent, strings.Repeat(" ", len(name)),
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%s%s%s |Missing|Distinct|Len Min|Len Max|Len Mean|Len Stdev|Len P50|Len P99|\n", indent, name, ColorReset)
+	fmt.Fprintf(w, "%s%s |%7d|%8d|%7d|%7d|%8.2f|%9.2f|%7d|%7d|\n", indent, strings.Repeat(" ", len(name)),
 		s.Missing, s.DistinctValue.Estimate(), s.LenDistribution.Min(), s.LenDistribution.Max(), s.LenDistribution.Mean(), s.LenDistribution.StdDev(), s.LenDistribution.ValueAtQuantile(50), s.LenDistribution.ValueAtQuantile(99))
 }
 
@@ -694,19 +695,19 @@ func (t *TimeIntervalStats) UpdateWithSpans(spans []ptrace.Span) {
 	t.TotalCount += int64(len(spans))
 }
 
-func (t *TimeIntervalStats) ShowStats(indent string) {
-	print(Green)
-	fmt.Printf("%sStartTimestamp%s (Distinct=%d)\n", indent, ColorReset, t.StartTimeDistinctValue.Estimate())
-	print(Green)
-	fmt.Printf("%sEndTimestamp%s (Distinct=%d)\n", indent, ColorReset, t.EndTimeDistinctValue.Estimate())
+func (t *TimeIntervalStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%sStartTimestamp%s (Distinct=%d)\n", indent, ColorReset, t.StartTimeDistinctValue.Estimate())
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%sEndTimestamp%s (Distinct=%d)\n", indent, ColorReset, t.EndTimeDistinctValue.Estimate())
 
 	indent += "  "
-	print(Cyan)
-	fmt.Printf("%sTime interval%s |Distinct|   Total|%%Distinct|\n", indent, ColorReset)
+	fmt.Fprint(w, Cyan)
+	fmt.Fprintf(w, "%sTime interval%s |Distinct|   Total|%%Distinct|\n", indent, ColorReset)
 	indent += "  "
-	fmt.Printf("%sEnd-Start   |%8d|%8d|%8.1f%%|\n", indent, t.IntervalDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.IntervalDistinctValue.Estimate())/float64(t.TotalCount))
-	fmt.Printf("%sStart delta |%8d|%8d|%8.1f%%|\n", indent, t.StartDeltaDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.StartDeltaDistinctValue.Estimate())/float64(t.TotalCount))
-	fmt.Printf("%sEnd delta   |%8d|%8d|%8.1f%%|\n", indent, t.EndDeltaDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.EndDeltaDistinctValue.Estimate())/float64(t.TotalCount))
+	fmt.Fprintf(w, "%sEnd-Start   |%8d|%8d|%8.1f%%|\n", indent, t.IntervalDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.IntervalDistinctValue.Estimate())/float64(t.TotalCount))
+	fmt.Fprintf(w, "%sStart delta |%8d|%8d|%8.1f%%|\n", indent, t.StartDeltaDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.StartDeltaDistinctValue.Estimate())/float64(t.TotalCount))
+	fmt.Fprintf(w, "%sEnd delta   |%8d|%8d|%8.1f%%|\n", indent, t.EndDeltaDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.EndDeltaDistinctValue.Estimate())/float64(t.TotalCount))
 }
 
 func NewTimestampStats() *TimestampStats {
@@ -722,10 +723,10 @@ func (t *TimestampStats) UpdateWith(timestamp pcommon.Timestamp) {
 	t.TotalCount++
 }
 
-func (t *TimestampStats) ShowStats(title, indent string) {
-	print(Green)
-	fmt.Printf("%s%s%s |Distinct|   Total|%%Distinct|\n", indent, title, ColorReset)
-	fmt.Printf("%s%s |%8d|%8d|%8.1f%%|\n", indent, strings.Repeat(" ", len(title)), t.TimeDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.TimeDistinctValue.Estimate())/float64(t.TotalCount))
+func (t *TimestampStats) ShowStats(w io.Writer, title, indent string) {
+	fmt.Fprint(w, Green)
+	fmt.Fprintf(w, "%s%s%s |Distinct|   Total|%%Distinct|\n", indent, title, ColorReset)
+	fmt.Fprintf(w, "%s%s |%8d|%8d|%8.1f%%|\n", indent, strings.Repeat(" ", len(title)), t.TimeDistinctValue.Estimate(), t.TotalCount, 100.0*float64(t.TimeDistinctValue.Estimate())/float64(t.TotalCount))
 }
 
 type SchemaUrlStats struct {
@@ -743,8 +744,8 @@ func (s *SchemaUrlStats) UpdateWith(schemaUrl string) {
 	}
 }
 
-func (s *SchemaUrlStats) ShowStats(indent string) {
-	fmt.Printf("%sSchemaUrl string length distribution (total-count, missing, min, max, mean, stdev, p50, p99): %d, %d, %d, %d, %f, %f, %d, %d\n", indent,
+func (s *SchemaUrlStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%sSchemaUrl string length distribution (total-count, missing, min, max, mean, stdev, p50, p99): %d, %d, %d, %d, %f, %f, %d, %d\n", indent,
 		s.SizeDistribution.TotalCount(), s.Missing, s.SizeDistribution.Min(), s.SizeDistribution.Max(), s.SizeDistribution.Mean(), s.SizeDistribution.StdDev(), s.SizeDistribution.ValueAtQuantile(50), s.SizeDistribution.ValueAtQuantile(99),
 	)
 }
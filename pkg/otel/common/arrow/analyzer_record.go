@@ -0,0 +1,190 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Export of the statistics collected by the logs, metrics and traces analyzers
+// as an Arrow record, so they can be recorded and queried instead of being
+// scraped from the console.
+
+import (
+	"reflect"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/axiomhq/hyperloglog"
+
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Names of the statistics exported for each field of an analyzer.
+const (
+	// StatValue is the value of a counter (e.g. TotalCount, Missing).
+	StatValue = "value"
+	// StatDistinct is the estimated number of distinct values.
+	StatDistinct = "distinct"
+	// StatCount, StatMin, StatMax, StatMean, StatStdDev, StatP50 and StatP99
+	// describe a distribution (e.g. a number of attributes or a length).
+	StatCount  = "count"
+	StatMin    = "min"
+	StatMax    = "max"
+	StatMean   = "mean"
+	StatStdDev = "stddev"
+	StatP50    = "p50"
+	StatP99    = "p99"
+)
+
+// AnalyzerStatsSchema is the Arrow schema of the analyzer statistics records.
+// Each row is a statistic of a field of the analyzer.
+var AnalyzerStatsSchema = arrow.NewSchema([]arrow.Field{
+	{Name: constants.AnalyzerStatPath, Type: arrow.BinaryTypes.String},
+	{Name: constants.AnalyzerStatName, Type: arrow.BinaryTypes.String},
+	{Name: constants.AnalyzerStatValue, Type: arrow.PrimitiveTypes.Float64},
+}, nil)
+
+var (
+	sketchType    = reflect.TypeOf((*hyperloglog.Sketch)(nil))
+	histogramType = reflect.TypeOf((*hdrhistogram.Histogram)(nil))
+)
+
+// AnalyzerStat is a statistic of a field of an analyzer. The field is
+// identified by its path from the analyzer, e.g.
+// "TracesAnalyzer.ResourceSpansStats.ScopeSpansStats.SpanStats.Name.DistinctValue".
+type AnalyzerStat struct {
+	Path      string
+	Statistic string
+	Value     float64
+}
+
+// AnalyzerStats returns the statistics collected so far by the given analyzer.
+// The counters, the cardinality sketches and the distributions of the
+// analyzer are exported, the fields that are not initialized are skipped.
+func AnalyzerStats(analyzer Analyzer) []AnalyzerStat {
+	v := reflect.ValueOf(analyzer)
+	var stats []AnalyzerStat
+	collectAnalyzerStats(v, reflect.Indirect(v).Type().Name(), &stats)
+	return stats
+}
+
+func collectAnalyzerStats(v reflect.Value, path string, stats *[]AnalyzerStat) {
+	add := func(statistic string, value float64) {
+		*stats = append(*stats, AnalyzerStat{Path: path, Statistic: statistic, Value: value})
+	}
+
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return
+	}
+
+	switch v.Type() {
+	case sketchType:
+		add(StatDistinct, float64(v.Interface().(*hyperloglog.Sketch).Estimate()))
+		return
+	case histogramType:
+		h := v.Interface().(*hdrhistogram.Histogram)
+		add(StatCount, float64(h.TotalCount()))
+		if h.TotalCount() > 0 {
+			add(StatMin, float64(h.Min()))
+			add(StatMax, float64(h.Max()))
+			add(StatMean, h.Mean())
+			add(StatStdDev, h.StdDev())
+			add(StatP50, float64(h.ValueAtQuantile(50)))
+			add(StatP99, float64(h.ValueAtQuantile(99)))
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		collectAnalyzerStats(v.Elem(), path, stats)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			collectAnalyzerStats(v.Field(i), path+"."+t.Field(i).Name, stats)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		add(StatValue, float64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		add(StatValue, float64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		add(StatValue, v.Float())
+	}
+}
+
+// AnalyzerStatsRecord builds an Arrow record (see AnalyzerStatsSchema) from
+// the given statistics.
+//
+// Once the record is no longer needed, Release() should be called to free the
+// memory.
+func AnalyzerStatsRecord(pool memory.Allocator, stats []AnalyzerStat) arrow.Record {
+	rb := array.NewRecordBuilder(pool, AnalyzerStatsSchema)
+	defer rb.Release()
+	pathb := rb.Field(0).(*array.StringBuilder)
+	statb := rb.Field(1).(*array.StringBuilder)
+	valueb := rb.Field(2).(*array.Float64Builder)
+
+	rb.Reserve(len(stats))
+	for _, stat := range stats {
+		pathb.Append(stat.Path)
+		statb.Append(stat.Statistic)
+		valueb.Append(stat.Value)
+	}
+
+	return rb.NewRecord()
+}
+
+// AnalyzerStatsFrom decodes an analyzer statistics record.
+func AnalyzerStatsFrom(record arrow.Record) ([]AnalyzerStat, error) {
+	pathID, err := arrowutils.MandatoryFieldIDFromSchema(record.Schema(), constants.AnalyzerStatPath)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	statID, err := arrowutils.MandatoryFieldIDFromSchema(record.Schema(), constants.AnalyzerStatName)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	valueID, err := arrowutils.MandatoryFieldIDFromSchema(record.Schema(), constants.AnalyzerStatValue)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	rows := int(record.NumRows())
+	stats := make([]AnalyzerStat, 0, rows)
+	for row := 0; row < rows; row++ {
+		path, err := arrowutils.StringFromRecord(record, pathID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		statistic, err := arrowutils.StringFromRecord(record, statID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		value, err := arrowutils.F64FromRecord(record, valueID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		stats = append(stats, AnalyzerStat{Path: path, Statistic: statistic, Value: value})
+	}
+
+	return stats, nil
+}
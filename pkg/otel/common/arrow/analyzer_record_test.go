@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"testing"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/axiomhq/hyperloglog"
+	"github.com/stretchr/testify/require"
+)
+
+type statsAnalyzer struct {
+	testAnalyzer
+
+	Count    int64
+	Names    *hyperloglog.Sketch
+	Sizes    *hdrhistogram.Histogram
+	Empty    *hdrhistogram.Histogram
+	Nested   *StringStats
+	Unset    *StringStats
+	internal int64
+}
+
+func TestAnalyzerStatsRecord(t *testing.T) {
+	t.Parallel()
+
+	analyzer := &statsAnalyzer{
+		Count:    3,
+		Names:    hyperloglog.New16(),
+		Sizes:    hdrhistogram.New(1, 1000, 2),
+		Empty:    hdrhistogram.New(1, 1000, 2),
+		Nested:   NewStringStats(),
+		internal: 42,
+	}
+	analyzer.Names.Insert([]byte("a"))
+	analyzer.Names.Insert([]byte("b"))
+	RequireNoError(analyzer.Sizes.RecordValue(10))
+	RequireNoError(analyzer.Sizes.RecordValue(20))
+	analyzer.Nested.UpdateWith("abc")
+
+	stats := AnalyzerStats(analyzer)
+
+	values := make(map[string]float64)
+	for _, stat := range stats {
+		values[stat.Path+"/"+stat.Statistic] = stat.Value
+	}
+	require.Equal(t, 3.0, values["statsAnalyzer.Count/value"])
+	require.Equal(t, 2.0, values["statsAnalyzer.Names/distinct"])
+	require.Equal(t, 2.0, values["statsAnalyzer.Sizes/count"])
+	require.Equal(t, 10.0, values["statsAnalyzer.Sizes/min"])
+	require.Equal(t, 0.0, values["statsAnalyzer.Empty/count"])
+	require.NotContains(t, values, "statsAnalyzer.Empty/min")
+	require.Equal(t, 1.0, values["statsAnalyzer.Nested.DistinctValue/distinct"])
+	require.Equal(t, 3.0, values["statsAnalyzer.Nested.LenDistribution/max"])
+	for path := range values {
+		require.NotContains(t, path, "Unset")
+		require.NotContains(t, path, "internal")
+	}
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	record := AnalyzerStatsRecord(pool, stats)
+	defer record.Release()
+	require.Equal(t, int64(len(stats)), record.NumRows())
+
+	decoded, err := AnalyzerStatsFrom(record)
+	require.NoError(t, err)
+	require.Equal(t, stats, decoded)
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Emission of the reports of the logs, metrics and traces analyzers.
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// colorCodes matches the terminal color codes used by the analyzers.
+var colorCodes = regexp.MustCompile("\033\\[[0-9;]*m")
+
+// Analyzer is implemented by the logs, metrics and traces analyzers.
+type Analyzer interface {
+	// ShowSummary writes the number of requests analyzed so far.
+	ShowSummary(w io.Writer, indent string)
+	// ShowStats writes the summary followed by the detailed statistics.
+	ShowStats(w io.Writer, indent string)
+}
+
+// analyzerStatMetric is the name of the gauge exporting the statistics of
+// the analyzers (see WithAnalyzerMeterProvider).
+const analyzerStatMetric = "arrow_analyzer_stat"
+
+// AnalyzerReporter decides which batches are analyzed and when and where the
+// statistics of an analyzer are reported (see cfg.AnalyzerConfig).
+type AnalyzerReporter struct {
+	output     io.Writer // nil if the text reports are disabled
+	file       *os.File  // nil if the text reports are not written to a file
+	sampling   int
+	batches    int
+	interval   time.Duration
+	verbosity  cfg.AnalyzerVerbosity
+	lastReport time.Time
+
+	// Statistics of the last report, observed by the gauge of the metrics
+	// sink.
+	mu           sync.Mutex
+	stats        []AnalyzerStat
+	registration metric.Registration
+}
+
+// NewAnalyzerReporter creates a reporter from the analyzer configuration of
+// the given configuration. A nil configuration reports the detailed
+// statistics on stdout after every batch.
+func NewAnalyzerReporter(conf *cfg.Config) (*AnalyzerReporter, error) {
+	if conf == nil {
+		return &AnalyzerReporter{output: os.Stdout}, nil
+	}
+
+	ac := conf.Analyzer
+	r := &AnalyzerReporter{
+		sampling:  ac.Sampling,
+		interval:  ac.Interval,
+		verbosity: ac.Verbosity,
+	}
+
+	switch {
+	case ac.Logger != nil:
+		r.output = NewZapWriter(ac.Logger)
+	case ac.File != "":
+		file, err := os.OpenFile(ac.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"file": ac.File})
+		}
+		r.file = file
+		r.output = file
+	case ac.Output != nil:
+		r.output = ac.Output
+	case ac.MeterProvider == nil:
+		r.output = os.Stdout
+	}
+
+	if ac.MeterProvider != nil {
+		if err := r.registerGauge(ac.MeterProvider); err != nil {
+			_ = r.Close()
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	return r, nil
+}
+
+func (r *AnalyzerReporter) registerGauge(mp metric.MeterProvider) error {
+	meter := mp.Meter("github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow")
+	gauge, err := meter.Float64ObservableGauge(
+		analyzerStatMetric,
+		metric.WithDescription("Statistics of the fields of the data analyzed by the Arrow producer, by field path and statistic."))
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	r.registration, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		for _, stat := range r.stats {
+			o.ObserveFloat64(gauge, stat.Value, metric.WithAttributes(
+				attribute.String(constants.AnalyzerStatPath, stat.Path),
+				attribute.String(constants.AnalyzerStatName, stat.Statistic),
+			))
+		}
+		return nil
+	}, gauge)
+
+	return werror.Wrap(err)
+}
+
+// Output returns the writer receiving the text reports, io.Discard if they
+// are disabled.
+func (r *AnalyzerReporter) Output() io.Writer {
+	if r.output == nil {
+		return io.Discard
+	}
+	return r.output
+}
+
+// Sample returns true if the current batch must be analyzed, i.e. for 1
+// batch out of the configured sampling.
+func (r *AnalyzerReporter) Sample() bool {
+	r.batches++
+	if r.sampling <= 1 || r.batches >= r.sampling {
+		r.batches = 0
+		return true
+	}
+	return false
+}
+
+// Report reports the statistics of the given analyzer unless the previous
+// report is more recent than the configured interval.
+func (r *AnalyzerReporter) Report(analyzer Analyzer) {
+	now := time.Now()
+	if r.interval > 0 && !r.lastReport.IsZero() && now.Sub(r.lastReport) < r.interval {
+		return
+	}
+	r.lastReport = now
+
+	if r.output != nil {
+		if r.verbosity == cfg.AnalyzerSummary {
+			analyzer.ShowSummary(r.output, "")
+		} else {
+			analyzer.ShowStats(r.output, "")
+		}
+	}
+
+	if r.registration != nil {
+		stats := AnalyzerStats(analyzer)
+		r.mu.Lock()
+		r.stats = stats
+		r.mu.Unlock()
+	}
+}
+
+// Close unregisters the gauge of the metrics sink and closes the file of the
+// text reports.
+func (r *AnalyzerReporter) Close() error {
+	var err error
+	if r.registration != nil {
+		err = r.registration.Unregister()
+		r.registration = nil
+	}
+	if r.file != nil {
+		if closeErr := r.file.Close(); err == nil {
+			err = closeErr
+		}
+		r.file = nil
+	}
+	return werror.Wrap(err)
+}
+
+// ZapWriter is an io.Writer logging every line written to it as an Info entry
+// of a zap.Logger. The terminal color codes are removed and the empty lines
+// are skipped.
+type ZapWriter struct {
+	mu     sync.Mutex
+	logger *zap.Logger
+	buf    bytes.Buffer
+}
+
+// NewZapWriter creates a ZapWriter logging to the given logger.
+func NewZapWriter(logger *zap.Logger) *ZapWriter {
+	return &ZapWriter{logger: logger}
+}
+
+// Write buffers p and logs the complete lines. It never fails.
+func (z *ZapWriter) Write(p []byte) (int, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	z.buf.Write(p)
+	for {
+		line, err := z.buf.ReadString('\n')
+		if err != nil {
+			// Incomplete line, kept for the next write.
+			z.buf.WriteString(line)
+			break
+		}
+		line = colorCodes.ReplaceAllString(line[:len(line)-1], "")
+		if strings.TrimSpace(line) != "" {
+			z.logger.Info(line)
+		}
+	}
+
+	return len(p), nil
+}
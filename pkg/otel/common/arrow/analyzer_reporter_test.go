@@ -0,0 +1,193 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+)
+
+type testAnalyzer struct{}
+
+func (a *testAnalyzer) ShowSummary(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s%ssummary%s\n", indent, Green, ColorReset)
+}
+
+func (a *testAnalyzer) ShowStats(w io.Writer, indent string) {
+	a.ShowSummary(w, indent)
+	fmt.Fprintf(w, "%s  details\n", indent)
+}
+
+func TestAnalyzerReporterOutput(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	conf := cfg.DefaultConfig()
+	cfg.WithAnalyzerOutput(&buf)(conf)
+
+	reporter, err := NewAnalyzerReporter(conf)
+	require.NoError(t, err)
+	reporter.Report(&testAnalyzer{})
+	reporter.Report(&testAnalyzer{})
+
+	expected := Green + "summary" + ColorReset + "\n  details\n"
+	require.Equal(t, expected+expected, buf.String())
+}
+
+func TestAnalyzerReporterSummaryAndInterval(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	conf := cfg.DefaultConfig()
+	cfg.WithAnalyzerOutput(&buf)(conf)
+	cfg.WithAnalyzerVerbosity(cfg.AnalyzerSummary)(conf)
+	cfg.WithAnalyzerInterval(time.Hour)(conf)
+
+	reporter, err := NewAnalyzerReporter(conf)
+	require.NoError(t, err)
+	reporter.Report(&testAnalyzer{})
+	// Throttled by the interval.
+	reporter.Report(&testAnalyzer{})
+
+	require.Equal(t, Green+"summary"+ColorReset+"\n", buf.String())
+}
+
+func TestAnalyzerReporterLogger(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+	conf := cfg.DefaultConfig()
+	cfg.WithAnalyzerOutput(&bytes.Buffer{})(conf)
+	cfg.WithAnalyzerLogger(zap.New(core))(conf)
+
+	reporter, err := NewAnalyzerReporter(conf)
+	require.NoError(t, err)
+	reporter.Report(&testAnalyzer{})
+
+	var lines []string
+	for _, entry := range logs.All() {
+		lines = append(lines, entry.Message)
+	}
+	require.Equal(t, []string{"summary", "  details"}, lines)
+}
+
+func TestAnalyzerReporterSampling(t *testing.T) {
+	t.Parallel()
+
+	conf := cfg.DefaultConfig()
+	cfg.WithAnalyzerSampling(3)(conf)
+
+	reporter, err := NewAnalyzerReporter(conf)
+	require.NoError(t, err)
+
+	var sampled []bool
+	for i := 0; i < 6; i++ {
+		sampled = append(sampled, reporter.Sample())
+	}
+	require.Equal(t, []bool{false, false, true, false, false, true}, sampled)
+}
+
+func TestAnalyzerReporterFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "analyzer.log")
+	conf := cfg.DefaultConfig()
+	cfg.WithAnalyzerFile(path)(conf)
+	cfg.WithAnalyzerVerbosity(cfg.AnalyzerSummary)(conf)
+
+	for i := 0; i < 2; i++ {
+		reporter, err := NewAnalyzerReporter(conf)
+		require.NoError(t, err)
+		reporter.Report(&testAnalyzer{})
+		require.NoError(t, reporter.Close())
+	}
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	expected := Green + "summary" + ColorReset + "\n"
+	require.Equal(t, expected+expected, string(content))
+}
+
+func TestAnalyzerReporterMeterProvider(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	conf := cfg.DefaultConfig()
+	cfg.WithAnalyzerMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))(conf)
+
+	reporter, err := NewAnalyzerReporter(conf)
+	require.NoError(t, err)
+	// No text sink when only the metrics are configured.
+	require.Equal(t, io.Discard, reporter.Output())
+
+	reporter.Report(&statsAnalyzer{Count: 3})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+	metric := rm.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, analyzerStatMetric, metric.Name)
+	dps := metric.Data.(metricdata.Gauge[float64]).DataPoints
+	require.Len(t, dps, 1)
+	require.Equal(t, 3.0, dps[0].Value)
+	path, _ := dps[0].Attributes.Value(attribute.Key(constants.AnalyzerStatPath))
+	require.Equal(t, "statsAnalyzer.Count", path.AsString())
+
+	require.NoError(t, reporter.Close())
+	rm = metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			require.Empty(t, m.Data.(metricdata.Gauge[float64]).DataPoints)
+		}
+	}
+}
+
+func TestZapWriterPartialLines(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+	w := NewZapWriter(zap.New(core))
+
+	_, err := w.Write([]byte("first li"))
+	require.NoError(t, err)
+	require.Equal(t, 0, logs.Len())
+
+	_, err = w.Write([]byte("ne\n\nsecond line\nthird"))
+	require.NoError(t, err)
+
+	var lines []string
+	for _, entry := range logs.All() {
+		lines = append(lines, entry.Message)
+	}
+	require.Equal(t, []string{"first line", "second line"}, lines)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Keyed hashing of the string values of configured attribute keys.
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+// maxHashedValues is the number of hashed values kept by an AttributeHasher
+// before its cache is reset.
+const maxHashedValues = 1 << 16
+
+// AttributeHasher replaces the string values of a configured set of attribute
+// keys by their hex-encoded HMAC-SHA256. The hash is deterministic for a given
+// key, so hashed values can still be joined and grouped, but the raw values
+// never leave the producer. The consumer decodes the hashed values as regular
+// strings.
+//
+// A nil AttributeHasher leaves all the values untouched.
+type AttributeHasher struct {
+	key   []byte
+	keys  map[string]bool
+	cache map[string]string
+}
+
+// NewAttributeHasher returns an AttributeHasher for the hashed attribute keys
+// of the configuration, or nil if no attribute key is hashed.
+func NewAttributeHasher(conf *cfg.Config) *AttributeHasher {
+	if len(conf.HashedAttributes) == 0 {
+		return nil
+	}
+	keys := make(map[string]bool, len(conf.HashedAttributes))
+	for _, key := range conf.HashedAttributes {
+		keys[key] = true
+	}
+	return &AttributeHasher{
+		key:   conf.AttributeHashKey,
+		keys:  keys,
+		cache: make(map[string]string),
+	}
+}
+
+// Value returns the value to encode for the given attribute.
+func (h *AttributeHasher) Value(key, value string) string {
+	if h == nil || !h.keys[key] {
+		return value
+	}
+	if hashed, ok := h.cache[value]; ok {
+		return hashed
+	}
+	if len(h.cache) >= maxHashedValues {
+		h.cache = make(map[string]string)
+	}
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(value))
+	hashed := hex.EncodeToString(mac.Sum(nil))
+	h.cache[value] = hashed
+	return hashed
+}
+
+// HashMap replaces in place the string values of the hashed keys of the
+// attributes, for the data sent without being encoded by the producer (e.g.
+// with standard OTLP).
+func (h *AttributeHasher) HashMap(attrs pcommon.Map) {
+	if h == nil {
+		return
+	}
+	attrs.Range(func(key string, value pcommon.Value) bool {
+		if h.keys[key] && value.Type() == pcommon.ValueTypeStr {
+			value.SetStr(h.Value(key, value.Str()))
+		}
+		return true
+	})
+}
@@ -74,6 +74,9 @@ type (
 		ParentID uint16
 		Key      string
 		Value    *pcommon.Value
+		// Flattened is true when the attribute is an entry of a
+		// nested map (see MapFlattener).
+		Flattened bool
 	}
 
 	// Attrs16Sorter is used to sort attributes with 16-bit ParentIDs.
@@ -88,6 +91,9 @@ type (
 		ParentID uint32
 		Key      string
 		Value    *pcommon.Value
+		// Flattened is true when the attribute is an entry of a
+		// nested map (see MapFlattener).
+		Flattened bool
 	}
 
 	// Attrs32Sorter is used to sort attributes with 32-bit ParentIDs.
@@ -104,6 +110,11 @@ type (
 		attrsMapCount uint16
 		attrs         []Attr16
 		sorter        Attrs16Sorter
+		flattener     *MapFlattener
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointAttrsMapCount uint16
+		checkpointLen           int
 	}
 
 	// Attributes32Accumulator accumulates attributes for the scope of an entire
@@ -113,6 +124,11 @@ type (
 		attrsMapCount uint32
 		attrs         []Attr32
 		sorter        Attrs32Sorter
+		flattener     *MapFlattener
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointAttrsMapCount uint32
+		checkpointLen           int
 	}
 )
 
@@ -213,10 +229,13 @@ func (c *Attributes16Accumulator) Append(attrs pcommon.Map) (int64, error) {
 	}
 
 	attrs.Range(func(k string, v pcommon.Value) bool {
-		c.attrs = append(c.attrs, Attr16{
-			ParentID: ID,
-			Key:      k,
-			Value:    &v,
+		c.flattener.Flatten(k, v, func(key string, value pcommon.Value, flattened bool) {
+			c.attrs = append(c.attrs, Attr16{
+				ParentID:  ID,
+				Key:       key,
+				Value:     &value,
+				Flattened: flattened,
+			})
 		})
 		return true
 	})
@@ -226,6 +245,21 @@ func (c *Attributes16Accumulator) Append(attrs pcommon.Map) (int64, error) {
 	return int64(ID), nil
 }
 
+// AppendEmpty reserves an ID for an empty set of attributes, so that the
+// resources (resp. scopes) without attributes can be told apart by the
+// consumer (see config.EmptyPerRecord).
+func (c *Attributes16Accumulator) AppendEmpty() int64 {
+	ID := c.attrsMapCount
+
+	if c.attrsMapCount == math.MaxUint16 {
+		panic("The maximum number of group of attributes has been reached (max is uint16).")
+	}
+
+	c.attrsMapCount++
+
+	return int64(ID)
+}
+
 func (c *Attributes16Accumulator) AppendWithID(parentID uint16, attrs pcommon.Map) error {
 	if attrs.Len() == 0 {
 		return nil
@@ -241,10 +275,13 @@ func (c *Attributes16Accumulator) AppendWithID(parentID uint16, attrs pcommon.Ma
 			return true
 		}
 
-		c.attrs = append(c.attrs, Attr16{
-			ParentID: parentID,
-			Key:      key,
-			Value:    &v,
+		c.flattener.Flatten(key, v, func(key string, value pcommon.Value, flattened bool) {
+			c.attrs = append(c.attrs, Attr16{
+				ParentID:  parentID,
+				Key:       key,
+				Value:     &value,
+				Flattened: flattened,
+			})
 		})
 
 		return true
@@ -266,6 +303,21 @@ func (c *Attributes16Accumulator) Sort() {
 func (c *Attributes16Accumulator) Reset() {
 	c.attrsMapCount = 0
 	c.attrs = c.attrs[:0]
+	c.checkpointAttrsMapCount = 0
+	c.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (c *Attributes16Accumulator) Checkpoint() {
+	c.checkpointAttrsMapCount = c.attrsMapCount
+	c.checkpointLen = len(c.attrs)
+}
+
+// Rollback discards the attributes appended since the last checkpoint.
+func (c *Attributes16Accumulator) Rollback() {
+	c.attrsMapCount = c.checkpointAttrsMapCount
+	c.attrs = c.attrs[:c.checkpointLen]
 }
 
 func NewAttributes32Accumulator(sorter Attrs32Sorter) *Attributes32Accumulator {
@@ -294,10 +346,13 @@ func (c *Attributes32Accumulator) Append(ID uint32, attrs pcommon.Map) error {
 			return true
 		}
 
-		c.attrs = append(c.attrs, Attr32{
-			ParentID: ID,
-			Key:      key,
-			Value:    &v,
+		c.flattener.Flatten(key, v, func(key string, value pcommon.Value, flattened bool) {
+			c.attrs = append(c.attrs, Attr32{
+				ParentID:  ID,
+				Key:       key,
+				Value:     &value,
+				Flattened: flattened,
+			})
 		})
 
 		return true
@@ -319,6 +374,21 @@ func (c *Attributes32Accumulator) Sort() {
 func (c *Attributes32Accumulator) Reset() {
 	c.attrsMapCount = 0
 	c.attrs = c.attrs[:0]
+	c.checkpointAttrsMapCount = 0
+	c.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (c *Attributes32Accumulator) Checkpoint() {
+	c.checkpointAttrsMapCount = c.attrsMapCount
+	c.checkpointLen = len(c.attrs)
+}
+
+// Rollback discards the attributes appended since the last checkpoint.
+func (c *Attributes32Accumulator) Rollback() {
+	c.attrsMapCount = c.checkpointAttrsMapCount
+	c.attrs = c.attrs[:c.checkpointLen]
 }
 
 func Equal(a, b *pcommon.Value) bool {
@@ -26,7 +26,6 @@ import (
 	"github.com/apache/arrow/go/v12/arrow"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 
-	"github.com/f5/otel-arrow-adapter/pkg/otel/common"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
@@ -48,6 +47,7 @@ var (
 		{Name: constants.AttributeBool, Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
 		{Name: constants.AttributeBytes, Type: arrow.BinaryTypes.Binary, Metadata: schema.Metadata(schema.Dictionary16), Nullable: true},
 		{Name: constants.AttributeSer, Type: arrow.BinaryTypes.Binary, Metadata: schema.Metadata(schema.Dictionary16), Nullable: true},
+		{Name: constants.AttributeFlattened, Type: arrow.FixedWidthTypes.Boolean, Metadata: schema.Metadata(schema.Optional), Nullable: true},
 	}, nil)
 )
 
@@ -59,13 +59,9 @@ type (
 
 		pib   *builder.Uint16Builder
 		keyb  *builder.StringBuilder
-		typeb *builder.Uint8Builder
-		strb  *builder.StringBuilder
-		i64b  *builder.Int64Builder
-		f64b  *builder.Float64Builder
-		boolb *builder.BooleanBuilder
-		binb  *builder.BinaryBuilder
-		serb  *builder.BinaryBuilder
+		flatb *builder.BooleanBuilder
+
+		values attrValuesBuilder
 
 		accumulator *Attributes16Accumulator
 		payloadType *PayloadType
@@ -102,13 +98,20 @@ func NewAttrs16BuilderWithEncoding(rBuilder *builder.RecordBuilderExt, payloadTy
 func (b *Attrs16Builder) init() {
 	b.pib = b.builder.Uint16Builder(constants.ParentID)
 	b.keyb = b.builder.StringBuilder(constants.AttributeKey)
-	b.typeb = b.builder.Uint8Builder(constants.AttributeType)
-	b.strb = b.builder.StringBuilder(constants.AttributeStr)
-	b.i64b = b.builder.Int64Builder(constants.AttributeInt)
-	b.f64b = b.builder.Float64Builder(constants.AttributeDouble)
-	b.boolb = b.builder.BooleanBuilder(constants.AttributeBool)
-	b.binb = b.builder.BinaryBuilder(constants.AttributeBytes)
-	b.serb = b.builder.BinaryBuilder(constants.AttributeSer)
+	b.flatb = b.builder.BooleanBuilder(constants.AttributeFlattened)
+	b.values.init(b.builder)
+}
+
+// SetAttributeHasher sets the hasher applied to the string values of the
+// attributes (nil = no hashing).
+func (b *Attrs16Builder) SetAttributeHasher(hasher *AttributeHasher) {
+	b.values.hasher = hasher
+}
+
+// SetMapFlattener sets the flattener applied to the nested maps of the
+// attributes (nil = no flattening).
+func (b *Attrs16Builder) SetMapFlattener(flattener *MapFlattener) {
+	b.accumulator.flattener = flattener
 }
 
 func (b *Attrs16Builder) Accumulator() *Attributes16Accumulator {
@@ -127,72 +130,10 @@ func (b *Attrs16Builder) TryBuild() (record arrow.Record, err error) {
 	for _, attr := range b.accumulator.attrs {
 		b.pib.Append(b.accumulator.sorter.Encode(attr.ParentID, attr.Key, attr.Value))
 		b.keyb.Append(attr.Key)
+		b.flatb.AppendNonFalse(attr.Flattened)
 
-		switch attr.Value.Type() {
-		case pcommon.ValueTypeStr:
-			b.typeb.Append(uint8(pcommon.ValueTypeStr))
-			b.strb.Append(attr.Value.Str())
-			b.i64b.AppendNull()
-			b.f64b.AppendNull()
-			b.boolb.AppendNull()
-			b.binb.AppendNull()
-			b.serb.AppendNull()
-		case pcommon.ValueTypeInt:
-			b.typeb.Append(uint8(pcommon.ValueTypeInt))
-			b.i64b.Append(attr.Value.Int())
-			b.strb.AppendNull()
-			b.f64b.AppendNull()
-			b.boolb.AppendNull()
-			b.binb.AppendNull()
-			b.serb.AppendNull()
-		case pcommon.ValueTypeDouble:
-			b.typeb.Append(uint8(pcommon.ValueTypeDouble))
-			b.f64b.Append(attr.Value.Double())
-			b.strb.AppendNull()
-			b.i64b.AppendNull()
-			b.boolb.AppendNull()
-			b.binb.AppendNull()
-			b.serb.AppendNull()
-		case pcommon.ValueTypeBool:
-			b.typeb.Append(uint8(pcommon.ValueTypeBool))
-			b.boolb.Append(attr.Value.Bool())
-			b.strb.AppendNull()
-			b.i64b.AppendNull()
-			b.f64b.AppendNull()
-			b.binb.AppendNull()
-			b.serb.AppendNull()
-		case pcommon.ValueTypeBytes:
-			b.typeb.Append(uint8(pcommon.ValueTypeBytes))
-			b.binb.Append(attr.Value.Bytes().AsRaw())
-			b.strb.AppendNull()
-			b.i64b.AppendNull()
-			b.f64b.AppendNull()
-			b.boolb.AppendNull()
-			b.serb.AppendNull()
-		case pcommon.ValueTypeSlice:
-			cborData, err := common.Serialize(attr.Value)
-			if err != nil {
-				break
-			}
-			b.typeb.Append(uint8(pcommon.ValueTypeSlice))
-			b.serb.Append(cborData)
-			b.strb.AppendNull()
-			b.i64b.AppendNull()
-			b.f64b.AppendNull()
-			b.boolb.AppendNull()
-			b.binb.AppendNull()
-		case pcommon.ValueTypeMap:
-			cborData, err := common.Serialize(attr.Value)
-			if err != nil {
-				break
-			}
-			b.typeb.Append(uint8(pcommon.ValueTypeMap))
-			b.serb.Append(cborData)
-			b.strb.AppendNull()
-			b.i64b.AppendNull()
-			b.f64b.AppendNull()
-			b.boolb.AppendNull()
-			b.binb.AppendNull()
+		if err = b.values.append(attr.Key, attr.Value); err != nil {
+			return nil, werror.Wrap(err)
 		}
 	}
 
@@ -256,6 +197,14 @@ func (b *Attrs16Builder) Reset() {
 	b.accumulator.Reset()
 }
 
+func (b *Attrs16Builder) Checkpoint() {
+	b.accumulator.Checkpoint()
+}
+
+func (b *Attrs16Builder) Rollback() {
+	b.accumulator.Rollback()
+}
+
 // Release releases the memory allocated by the builder.
 func (b *Attrs16Builder) Release() {
 	if !b.released {
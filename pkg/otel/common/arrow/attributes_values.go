@@ -0,0 +1,116 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Value columns shared by all the attribute payload types.
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// attrValuesBuilder builds the value columns (type, str, int, double, bool,
+// bytes, ser) of an attribute record. These columns are identical for all the
+// attribute payload types, only the parent ID column differs (see
+// Attrs16Builder and Attrs32Builder), so any change to the encoding of the
+// attribute values applies uniformly to all of them.
+type attrValuesBuilder struct {
+	typeb *builder.Uint8Builder
+	strb  *builder.StringBuilder
+	i64b  *builder.Int64Builder
+	f64b  *builder.Float64Builder
+	boolb *builder.BooleanBuilder
+	binb  *builder.BinaryBuilder
+	serb  *builder.BinaryBuilder
+
+	// hasher is applied to the string values (nil = no hashing).
+	hasher *AttributeHasher
+}
+
+func (b *attrValuesBuilder) init(rb *builder.RecordBuilderExt) {
+	b.typeb = rb.Uint8Builder(constants.AttributeType)
+	b.strb = rb.StringBuilder(constants.AttributeStr)
+	b.i64b = rb.Int64Builder(constants.AttributeInt)
+	b.f64b = rb.Float64Builder(constants.AttributeDouble)
+	b.boolb = rb.BooleanBuilder(constants.AttributeBool)
+	b.binb = rb.BinaryBuilder(constants.AttributeBytes)
+	b.serb = rb.BinaryBuilder(constants.AttributeSer)
+}
+
+// append appends the value of the attribute with the given key.
+func (b *attrValuesBuilder) append(key string, value *pcommon.Value) error {
+	switch value.Type() {
+	case pcommon.ValueTypeStr:
+		b.typeb.Append(uint8(pcommon.ValueTypeStr))
+		b.strb.Append(b.hasher.Value(key, value.Str()))
+		b.i64b.AppendNull()
+		b.f64b.AppendNull()
+		b.boolb.AppendNull()
+		b.binb.AppendNull()
+		b.serb.AppendNull()
+	case pcommon.ValueTypeInt:
+		b.typeb.Append(uint8(pcommon.ValueTypeInt))
+		b.i64b.Append(value.Int())
+		b.strb.AppendNull()
+		b.f64b.AppendNull()
+		b.boolb.AppendNull()
+		b.binb.AppendNull()
+		b.serb.AppendNull()
+	case pcommon.ValueTypeDouble:
+		b.typeb.Append(uint8(pcommon.ValueTypeDouble))
+		b.f64b.Append(value.Double())
+		b.strb.AppendNull()
+		b.i64b.AppendNull()
+		b.boolb.AppendNull()
+		b.binb.AppendNull()
+		b.serb.AppendNull()
+	case pcommon.ValueTypeBool:
+		b.typeb.Append(uint8(pcommon.ValueTypeBool))
+		b.boolb.Append(value.Bool())
+		b.strb.AppendNull()
+		b.i64b.AppendNull()
+		b.f64b.AppendNull()
+		b.binb.AppendNull()
+		b.serb.AppendNull()
+	case pcommon.ValueTypeBytes:
+		b.typeb.Append(uint8(pcommon.ValueTypeBytes))
+		b.binb.Append(value.Bytes().AsRaw())
+		b.strb.AppendNull()
+		b.i64b.AppendNull()
+		b.f64b.AppendNull()
+		b.boolb.AppendNull()
+		b.serb.AppendNull()
+	case pcommon.ValueTypeSlice, pcommon.ValueTypeMap:
+		cborData, err := common.Serialize(value)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		b.typeb.Append(uint8(value.Type()))
+		b.serb.Append(cborData)
+		b.strb.AppendNull()
+		b.i64b.AppendNull()
+		b.f64b.AppendNull()
+		b.boolb.AppendNull()
+		b.binb.AppendNull()
+	}
+	return nil
+}
@@ -21,4 +21,6 @@ import "errors"
 
 var (
 	ErrBuilderAlreadyReleased = errors.New("builder already released")
+	ErrUnknownSorter          = errors.New("unknown sorter")
+	ErrUnknownSortKey         = errors.New("unknown sort key")
 )
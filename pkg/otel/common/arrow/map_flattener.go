@@ -0,0 +1,137 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Flattening of the nested maps of the attributes into dotted keys.
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+const (
+	// flattenedKeySeparator separates the keys of the nested maps in a
+	// flattened key, e.g. "http.request.method".
+	flattenedKeySeparator = '.'
+	// flattenedKeyEscape escapes the separators (and itself) found in the
+	// keys of the nested maps, so the nesting can be restored exactly.
+	flattenedKeyEscape = '\\'
+)
+
+// MapFlattener replaces the attributes whose value is a non-empty map by one
+// attribute per entry of the map, recursively up to a maximum depth, with the
+// dotted path of the entry as key. The leaves are encoded in the regular
+// value columns instead of a serialized map, which compresses better and is
+// directly usable by the backends reading the Arrow records. The flattened
+// attributes are flagged (see constants.AttributeFlattened) so the consumer
+// restores the nesting with PutFlattened.
+//
+// A nil MapFlattener leaves all the attributes untouched.
+type MapFlattener struct {
+	maxDepth int
+}
+
+// NewMapFlattener returns a MapFlattener for the maximum flattening depth of
+// the configuration, or nil if the maps are not flattened.
+func NewMapFlattener(conf *cfg.Config) *MapFlattener {
+	if conf.MapFlatteningDepth <= 0 {
+		return nil
+	}
+	return &MapFlattener{maxDepth: conf.MapFlatteningDepth}
+}
+
+// Flatten calls fn with the attribute itself, or with every leaf of its
+// value if it is a non-empty map. The maps nested deeper than the maximum
+// depth and the empty maps are leaves, they are serialized as usual.
+func (f *MapFlattener) Flatten(key string, value pcommon.Value, fn func(key string, value pcommon.Value, flattened bool)) {
+	if f == nil || value.Type() != pcommon.ValueTypeMap || value.Map().Len() == 0 {
+		fn(key, value, false)
+		return
+	}
+	f.flatten(escapeFlattenedKey(key), value.Map(), 1, fn)
+}
+
+func (f *MapFlattener) flatten(prefix string, m pcommon.Map, depth int, fn func(key string, value pcommon.Value, flattened bool)) {
+	m.Range(func(k string, v pcommon.Value) bool {
+		path := prefix + string(flattenedKeySeparator) + escapeFlattenedKey(k)
+		if depth < f.maxDepth && v.Type() == pcommon.ValueTypeMap && v.Map().Len() > 0 {
+			f.flatten(path, v.Map(), depth+1, fn)
+		} else {
+			fn(path, v, true)
+		}
+		return true
+	})
+}
+
+// PutFlattened puts the value of a flattened attribute in the given map,
+// creating the nested maps of its path.
+func PutFlattened(m pcommon.Map, path string, value pcommon.Value) {
+	keys := splitFlattenedKey(path)
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := m.Get(key)
+		if ok && nested.Type() == pcommon.ValueTypeMap {
+			m = nested.Map()
+		} else {
+			m = m.PutEmptyMap(key)
+		}
+	}
+	value.CopyTo(m.PutEmpty(keys[len(keys)-1]))
+}
+
+func escapeFlattenedKey(key string) string {
+	if !strings.ContainsAny(key, string([]byte{flattenedKeySeparator, flattenedKeyEscape})) {
+		return key
+	}
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		if key[i] == flattenedKeySeparator || key[i] == flattenedKeyEscape {
+			sb.WriteByte(flattenedKeyEscape)
+		}
+		sb.WriteByte(key[i])
+	}
+	return sb.String()
+}
+
+// splitFlattenedKey splits a flattened key on its unescaped separators and
+// unescapes the keys.
+func splitFlattenedKey(path string) []string {
+	var keys []string
+	var sb strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == flattenedKeyEscape && i+1 < len(path):
+			i++
+			sb.WriteByte(path[i])
+		case path[i] == flattenedKeySeparator:
+			keys = append(keys, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(path[i])
+		}
+	}
+	return append(keys, sb.String())
+}
+
+// FlattenedRootKey returns the key of the top-level attribute a flattened key
+// belongs to, e.g. "http" for "http.request.method".
+func FlattenedRootKey(path string) string {
+	return splitFlattenedKey(path)[0]
+}
@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+)
+
+func TestMapFlattener(t *testing.T) {
+	attrs := pcommon.NewMap()
+	require.NoError(t, attrs.FromRaw(map[string]any{
+		"service": "checkout",
+		"http": map[string]any{
+			"method": "GET",
+			"request": map[string]any{
+				"size":    int64(42),
+				"headers": map[string]any{"accept": "*/*"},
+			},
+			"empty": map[string]any{},
+		},
+		"a.b": map[string]any{`c\d`: true},
+	}))
+
+	conf := cfg.DefaultConfig()
+	require.Nil(t, NewMapFlattener(conf))
+	conf.MapFlatteningDepth = 2
+	flattener := NewMapFlattener(conf)
+
+	flat := map[string]any{}
+	restored := pcommon.NewMap()
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		flattener.Flatten(k, v, func(key string, value pcommon.Value, flattened bool) {
+			flat[key] = value.AsRaw()
+			if flattened {
+				PutFlattened(restored, key, value)
+			} else {
+				value.CopyTo(restored.PutEmpty(key))
+			}
+		})
+		return true
+	})
+
+	require.Equal(t, map[string]any{
+		"service":              "checkout",
+		"http.method":          "GET",
+		"http.request.size":    int64(42),
+		"http.request.headers": map[string]any{"accept": "*/*"},
+		"http.empty":           map[string]any{},
+		`a\.b.c\\d`:            true,
+	}, flat)
+	require.Equal(t, attrs.AsRaw(), restored.AsRaw())
+}
@@ -0,0 +1,137 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"sync"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+)
+
+// DefaultMmapThreshold is the default size from which the MmapAllocator
+// serves the allocations with anonymous memory mappings.
+const DefaultMmapThreshold = 1 << 20
+
+// MmapAllocator is an allocator serving the allocations of at least threshold
+// bytes with anonymous memory mappings instead of the Go heap. The mappings
+// are returned to the operating system as soon as the buffers are freed, so
+// the occasional very large batches don't inflate the heap (and the RSS) of
+// the process until the next garbage collections. The smaller allocations
+// are served by the underlying allocator.
+//
+// On the platforms without mmap support, all the allocations are served by
+// the underlying allocator.
+type MmapAllocator struct {
+	mem       memory.Allocator
+	threshold int
+
+	lock sync.Mutex
+	// mappings are the mappings currently in use, by address of their first
+	// byte.
+	mappings map[*byte][]byte
+	mapped   uint64
+}
+
+var _ memory.Allocator = &MmapAllocator{}
+
+// NewMmapAllocator creates a MmapAllocator mapping the allocations of at least
+// threshold bytes (DefaultMmapThreshold if threshold <= 0).
+func NewMmapAllocator(mem memory.Allocator, threshold int) *MmapAllocator {
+	if threshold <= 0 {
+		threshold = DefaultMmapThreshold
+	}
+	return &MmapAllocator{
+		mem:       mem,
+		threshold: threshold,
+		mappings:  make(map[*byte][]byte),
+	}
+}
+
+func (m *MmapAllocator) Allocate(size int) []byte {
+	if size < m.threshold {
+		return m.mem.Allocate(size)
+	}
+
+	// The mappings are zeroed by the operating system.
+	buf, err := mmap(size)
+	if err != nil {
+		return m.mem.Allocate(size)
+	}
+
+	m.lock.Lock()
+	m.mappings[&buf[0]] = buf
+	m.mapped += uint64(len(buf))
+	m.lock.Unlock()
+
+	return buf[:size]
+}
+
+func (m *MmapAllocator) Reallocate(size int, b []byte) []byte {
+	if cap(b) == 0 {
+		return m.Allocate(size)
+	}
+
+	m.lock.Lock()
+	mapping, ok := m.mappings[&b[:1][0]]
+	m.lock.Unlock()
+
+	switch {
+	case ok && size <= len(mapping):
+		nb := mapping[:size]
+		for i := len(b); i < size; i++ {
+			nb[i] = 0
+		}
+		return nb
+	case !ok && size < m.threshold:
+		return m.mem.Reallocate(size, b)
+	}
+
+	nb := m.Allocate(size)
+	copy(nb, b)
+	m.Free(b)
+	return nb
+}
+
+func (m *MmapAllocator) Free(b []byte) {
+	if cap(b) == 0 {
+		m.mem.Free(b)
+		return
+	}
+
+	ptr := &b[:1][0]
+
+	m.lock.Lock()
+	mapping, ok := m.mappings[ptr]
+	if ok {
+		delete(m.mappings, ptr)
+		m.mapped -= uint64(len(mapping))
+	}
+	m.lock.Unlock()
+
+	if !ok {
+		m.mem.Free(b)
+		return
+	}
+	// A failure leaks the mapping, there is nothing else to do.
+	_ = munmap(mapping)
+}
+
+// Mapped returns the number of bytes currently mapped by the allocator.
+func (m *MmapAllocator) Mapped() uint64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.mapped
+}
@@ -0,0 +1,33 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package arrow
+
+import "errors"
+
+// mmapSupported is true on the platforms backing the MmapAllocator with
+// memory mappings.
+const mmapSupported = false
+
+var errMmapUnsupported = errors.New("mmap is not supported on this platform")
+
+func mmap(_ int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+func munmap(_ []byte) error {
+	return errMmapUnsupported
+}
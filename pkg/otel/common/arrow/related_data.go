@@ -21,10 +21,13 @@ package arrow
 // For example, `attributes` are related to `resource`, `span`, ...
 
 import (
+	"strings"
+
 	"github.com/apache/arrow/go/v12/arrow"
 
 	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
 	cfg "github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
 	config "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/stats"
@@ -42,14 +45,20 @@ type (
 		Schema() *arrow.Schema
 		PayloadType() *PayloadType
 		Reset()
+		// Checkpoint and Rollback discard the data accumulated by a batch
+		// that fails to append (see builder.RecordBuilderExt.Checkpoint).
+		Checkpoint()
+		Rollback()
 		Release()
 	}
 
 	// RelatedRecordsManager manages all related record builders for a given
 	// main OTel entity.
 	RelatedRecordsManager struct {
-		cfg   *cfg.Config
-		stats *stats.ProducerStats
+		cfg       *cfg.Config
+		stats     *stats.ProducerStats
+		hasher    *AttributeHasher
+		flattener *MapFlattener
 
 		builders    []RelatedRecordBuilder
 		builderExts []*builder.RecordBuilderExt
@@ -72,6 +81,7 @@ type (
 
 		ResourceAttrs                *PayloadType
 		ScopeAttrs                   *PayloadType
+		Scopes                       *PayloadType
 		Metric                       *PayloadType
 		NumberDataPoints             *PayloadType
 		NumberDataPointAttrs         *PayloadType
@@ -87,6 +97,7 @@ type (
 		ExpHistogramAttrs            *PayloadType
 		ExpHistogramExemplars        *PayloadType
 		ExpHistogramExemplarAttrs    *PayloadType
+		MetricMetadata               *PayloadType
 		LogRecordAttrs               *PayloadType
 		SpanAttrs                    *PayloadType
 		Event                        *PayloadType
@@ -127,6 +138,10 @@ var (
 			prefix:      "scope-attrs",
 			payloadType: colarspb.ArrowPayloadType_SCOPE_ATTRS,
 		},
+		Scopes: &PayloadType{
+			prefix:      "scopes",
+			payloadType: colarspb.ArrowPayloadType_SCOPES,
+		},
 		NumberDataPoints: &PayloadType{
 			prefix:      "number-dps",
 			payloadType: colarspb.ArrowPayloadType_NUMBER_DATA_POINTS,
@@ -183,6 +198,10 @@ var (
 			prefix:      "exp-histogram-dp-exemplar-attrs",
 			payloadType: colarspb.ArrowPayloadType_EXP_HISTOGRAM_DP_EXEMPLAR_ATTRS,
 		},
+		MetricMetadata: &PayloadType{
+			prefix:      "metric-metadata",
+			payloadType: colarspb.ArrowPayloadType_METRIC_METADATA,
+		},
 		LogRecordAttrs: &PayloadType{
 			prefix:      "logs-attrs",
 			payloadType: colarspb.ArrowPayloadType_LOG_ATTRS,
@@ -214,19 +233,29 @@ func NewRelatedRecordsManager(cfg *cfg.Config, stats *stats.ProducerStats) *Rela
 	return &RelatedRecordsManager{
 		cfg:         cfg,
 		stats:       stats,
+		hasher:      NewAttributeHasher(cfg),
+		flattener:   NewMapFlattener(cfg),
 		builders:    make([]RelatedRecordBuilder, 0),
 		builderExts: make([]*builder.RecordBuilderExt, 0),
 	}
 }
 
-func (m *RelatedRecordsManager) Declare(payloadType *PayloadType, parentPayloadType *PayloadType, schema *arrow.Schema, rrBuilder func(b *builder.RecordBuilderExt) RelatedRecordBuilder) RelatedRecordBuilder {
-	builderExt := builder.NewRecordBuilderExt(m.cfg.Pool, schema, config.NewDictionary(m.cfg.LimitIndexSize), m.stats)
+func (m *RelatedRecordsManager) Declare(payloadType *PayloadType, parentPayloadType *PayloadType, protoSchema *arrow.Schema, rrBuilder func(b *builder.RecordBuilderExt) RelatedRecordBuilder) RelatedRecordBuilder {
+	builderExt := builder.NewRecordBuilderExt(m.cfg.Pool, schema.WithEncodingOverrides(protoSchema, payloadType.OverridePrefix(), m.cfg.EncodingOverrides), config.NewDictionary(m.cfg.LimitIndexSize).WithOverflow(m.cfg.DictionaryOverflow, payloadType.OverridePrefix(), m.cfg.DictionaryOverflowOverrides), m.stats)
 	builderExt.SetLabel(payloadType.SchemaPrefix())
+	builderExt.SetOptionalColumnDeactivation(m.cfg.OptionalColumnDeactivation)
+	builderExt.SetLargeValues(m.cfg.LargeValues, m.cfg.LargeValueThreshold)
 	rBuilder := rrBuilder(builderExt)
+	if hb, ok := rBuilder.(interface{ SetAttributeHasher(*AttributeHasher) }); ok {
+		hb.SetAttributeHasher(m.hasher)
+	}
+	if fb, ok := rBuilder.(interface{ SetMapFlattener(*MapFlattener) }); ok {
+		fb.SetMapFlattener(m.flattener)
+	}
 	m.builders = append(m.builders, rBuilder)
 	m.builderExts = append(m.builderExts, builderExt)
 	m.schemas = append(m.schemas, SchemaWithPayload{
-		Schema:            schema,
+		Schema:            protoSchema,
 		PayloadType:       payloadType,
 		ParentPayloadType: parentPayloadType,
 	})
@@ -263,6 +292,21 @@ func (m *RelatedRecordsManager) Reset() {
 	}
 }
 
+// Checkpoint marks the current state of the related record builders as the
+// state restored by the next call to Rollback.
+func (m *RelatedRecordsManager) Checkpoint() {
+	for _, b := range m.builders {
+		b.Checkpoint()
+	}
+}
+
+// Rollback discards the related data accumulated since the last checkpoint.
+func (m *RelatedRecordsManager) Rollback() {
+	for _, b := range m.builders {
+		b.Rollback()
+	}
+}
+
 func (m *RelatedRecordsManager) Release() {
 	for _, b := range m.builders {
 		b.Release()
@@ -285,6 +329,12 @@ func (p *PayloadType) SchemaPrefix() string {
 	return p.prefix
 }
 
+// OverridePrefix returns the prefix of the encoding overrides of the fields
+// of this payload type (see config.WithEncodingOverrides).
+func (p *PayloadType) OverridePrefix() string {
+	return strings.ToLower(p.payloadType.String())
+}
+
 func (p *PayloadType) PayloadType() record_message.PayloadType {
 	return p.payloadType
 }
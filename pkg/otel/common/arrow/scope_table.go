@@ -0,0 +1,247 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Scope table record builder (see config.WithScopeTable).
+//
+// The distinct instrumentation scopes of a batch are accumulated while the
+// main records are built, each scope receiving the next ID of the table. The
+// main records reference the scopes by ID instead of repeating the scope
+// struct in every row.
+
+import (
+	"errors"
+	"math"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+var (
+	// ScopeTableSchema is the Arrow schema of the scope table related record.
+	// The attributes of the scopes are stored in the scope attributes related
+	// record with the ID of the scope as parent ID.
+	ScopeTableSchema = arrow.NewSchema([]arrow.Field{
+		{Name: constants.ID, Type: arrow.PrimitiveTypes.Uint16, Metadata: schema.Metadata(schema.DeltaEncoding)},
+		{Name: constants.Name, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
+		{Name: constants.Version, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
+		{Name: constants.DroppedAttributesCount, Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
+	}, nil)
+)
+
+type (
+	// ScopeTableBuilder is an Arrow builder for the scope table.
+	ScopeTableBuilder struct {
+		released bool
+
+		builder *builder.RecordBuilderExt
+
+		ib   *builder.Uint16DeltaBuilder // `id` builder
+		nb   *builder.StringBuilder      // `name` builder
+		vb   *builder.StringBuilder      // `version` builder
+		dacb *builder.Uint32Builder      // `dropped_attributes_count` builder
+
+		accumulator *ScopeTableAccumulator
+	}
+
+	// ScopeTableAccumulator deduplicates the scopes of a batch and assigns
+	// them consecutive IDs.
+	ScopeTableAccumulator struct {
+		idsByKey  map[string]uint16
+		scopes    []pcommon.InstrumentationScope
+		attrsAccu *Attributes16Accumulator
+
+		// The number of scopes restored by Rollback (see Checkpoint).
+		checkpointLen int
+	}
+)
+
+// NewScopeTableBuilder creates a new ScopeTableBuilder.
+func NewScopeTableBuilder(rBuilder *builder.RecordBuilderExt) *ScopeTableBuilder {
+	b := &ScopeTableBuilder{
+		released: false,
+		builder:  rBuilder,
+		accumulator: &ScopeTableAccumulator{
+			idsByKey: make(map[string]uint16),
+		},
+	}
+
+	b.init()
+	return b
+}
+
+func (b *ScopeTableBuilder) init() {
+	ib := b.builder.Uint16DeltaBuilder(constants.ID)
+	// The scopes are appended in the order of their IDs.
+	ib.SetMaxDelta(1)
+
+	b.ib = ib
+	b.nb = b.builder.StringBuilder(constants.Name)
+	b.vb = b.builder.StringBuilder(constants.Version)
+	b.dacb = b.builder.Uint32Builder(constants.DroppedAttributesCount)
+}
+
+// SetAttributesAccumulator sets the accumulator receiving the attributes of
+// the scopes.
+func (b *ScopeTableBuilder) SetAttributesAccumulator(accu *Attributes16Accumulator) {
+	b.accumulator.attrsAccu = accu
+}
+
+func (b *ScopeTableBuilder) Accumulator() *ScopeTableAccumulator {
+	return b.accumulator
+}
+
+func (b *ScopeTableBuilder) TryBuild() (record arrow.Record, err error) {
+	if b.released {
+		return nil, werror.Wrap(ErrBuilderAlreadyReleased)
+	}
+
+	b.builder.Reserve(len(b.accumulator.scopes))
+
+	for ID, scope := range b.accumulator.scopes {
+		b.ib.Append(uint16(ID))
+		b.nb.AppendNonEmpty(scope.Name())
+		b.vb.AppendNonEmpty(scope.Version())
+		b.dacb.AppendNonZero(scope.DroppedAttributesCount())
+	}
+
+	record, err = b.builder.NewRecord()
+	if err != nil {
+		b.init()
+	}
+
+	return
+}
+
+func (b *ScopeTableBuilder) IsEmpty() bool {
+	return len(b.accumulator.scopes) == 0
+}
+
+func (b *ScopeTableBuilder) Build() (arrow.Record, error) {
+	schemaNotUpToDateCount := 0
+
+	var record arrow.Record
+	var err error
+
+	// Loop until the record is built successfully.
+	// Intermediaries steps may be required to update the schema.
+	for {
+		record, err = b.TryBuild()
+		if err != nil {
+			if record != nil {
+				record.Release()
+			}
+
+			switch {
+			case errors.Is(err, schema.ErrSchemaNotUpToDate):
+				schemaNotUpToDateCount++
+				if schemaNotUpToDateCount > 5 {
+					panic("Too many consecutive schema updates. This shouldn't happen.")
+				}
+			default:
+				return nil, werror.Wrap(err)
+			}
+		} else {
+			break
+		}
+	}
+
+	return record, werror.Wrap(err)
+}
+
+func (b *ScopeTableBuilder) SchemaID() string {
+	return b.builder.SchemaID()
+}
+
+func (b *ScopeTableBuilder) Schema() *arrow.Schema {
+	return b.builder.Schema()
+}
+
+func (b *ScopeTableBuilder) PayloadType() *PayloadType {
+	return PayloadTypes.Scopes
+}
+
+func (b *ScopeTableBuilder) Reset() {
+	b.accumulator.Reset()
+}
+
+func (b *ScopeTableBuilder) Checkpoint() {
+	b.accumulator.Checkpoint()
+}
+
+func (b *ScopeTableBuilder) Rollback() {
+	b.accumulator.Rollback()
+}
+
+// Release releases the memory allocated by the builder.
+func (b *ScopeTableBuilder) Release() {
+	if !b.released {
+		b.builder.Release()
+		b.released = true
+	}
+}
+
+// Append returns the ID of the given scope in the table, the scope and its
+// attributes are added to the table the first time its key is seen. The key
+// identifies the scope entries to merge (see otlp.ScopeEntryID).
+func (c *ScopeTableAccumulator) Append(key string, scope pcommon.InstrumentationScope) (uint16, error) {
+	if ID, ok := c.idsByKey[key]; ok {
+		return ID, nil
+	}
+
+	if len(c.scopes) == math.MaxUint16 {
+		panic("The maximum number of scopes has been reached (max is uint16).")
+	}
+
+	ID := uint16(len(c.scopes))
+	if err := c.attrsAccu.AppendWithID(ID, scope.Attributes()); err != nil {
+		return 0, werror.Wrap(err)
+	}
+	c.idsByKey[key] = ID
+	c.scopes = append(c.scopes, scope)
+
+	return ID, nil
+}
+
+func (c *ScopeTableAccumulator) Reset() {
+	c.idsByKey = make(map[string]uint16)
+	c.scopes = c.scopes[:0]
+	c.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the table as the state restored by
+// the next call to Rollback. The attributes of the scopes are checkpointed
+// with their own accumulator.
+func (c *ScopeTableAccumulator) Checkpoint() {
+	c.checkpointLen = len(c.scopes)
+}
+
+// Rollback discards the scopes added to the table since the last checkpoint.
+func (c *ScopeTableAccumulator) Rollback() {
+	for key, ID := range c.idsByKey {
+		if int(ID) >= c.checkpointLen {
+			delete(c.idsByKey, key)
+		}
+	}
+	c.scopes = c.scopes[:c.checkpointLen]
+}
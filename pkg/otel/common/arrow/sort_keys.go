@@ -0,0 +1,91 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+import (
+	"sort"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Comparator returns a negative number, zero or a positive number when a is
+// respectively before, equal to or after b for a given sort key.
+type Comparator[T any] func(a, b T) int
+
+// KeySorter sorts the items by a list of sort keys, the first key being the
+// most significant. It is the configurable counterpart of the sorters with
+// hard-coded orderings (e.g. SpansByResourceSpanIdScopeSpanIdNameTraceId).
+type KeySorter[T any] struct {
+	comparators []Comparator[T]
+}
+
+// NewKeySorter creates a KeySorter from the names of the sort keys, looked up
+// in the comparators supported by a type of item. An empty list of keys
+// keeps the items in their original order.
+func NewKeySorter[T any](keys []string, comparators map[string]Comparator[T]) (*KeySorter[T], error) {
+	sorter := &KeySorter[T]{}
+	for _, key := range keys {
+		comparator, ok := comparators[key]
+		if !ok {
+			return nil, werror.WrapWithContext(ErrUnknownSortKey, map[string]interface{}{
+				"key":       key,
+				"supported": SortKeyNames(comparators),
+			})
+		}
+		sorter.comparators = append(sorter.comparators, comparator)
+	}
+	return sorter, nil
+}
+
+// Sort sorts the items by the sort keys, the order of the items with the
+// same keys is preserved.
+func (s *KeySorter[T]) Sort(items []T) {
+	if len(s.comparators) == 0 {
+		return
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, comparator := range s.comparators {
+			if cmp := comparator(items[i], items[j]); cmp != 0 {
+				return cmp < 0
+			}
+		}
+		return false
+	})
+}
+
+// SortKeyNames returns the sorted names of the given comparators.
+func SortKeyNames[T any](comparators map[string]Comparator[T]) []string {
+	names := make([]string, 0, len(comparators))
+	for name := range comparators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompareUint64 compares two unsigned integers (e.g. timestamps).
+func CompareUint64(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
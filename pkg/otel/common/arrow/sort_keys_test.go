@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySorter(t *testing.T) {
+	type item struct {
+		name string
+		ts   uint64
+	}
+	comparators := map[string]Comparator[item]{
+		"name": func(a, b item) int { return strings.Compare(a.name, b.name) },
+		"ts":   func(a, b item) int { return CompareUint64(a.ts, b.ts) },
+	}
+	items := func() []item {
+		return []item{{"b", 1}, {"a", 2}, {"b", 0}, {"a", 1}}
+	}
+
+	sorter, err := NewKeySorter([]string{"name", "ts"}, comparators)
+	require.NoError(t, err)
+	sorted := items()
+	sorter.Sort(sorted)
+	require.Equal(t, []item{{"a", 1}, {"a", 2}, {"b", 0}, {"b", 1}}, sorted)
+
+	// The sort is stable.
+	sorter, err = NewKeySorter([]string{"name"}, comparators)
+	require.NoError(t, err)
+	sorted = items()
+	sorter.Sort(sorted)
+	require.Equal(t, []item{{"a", 2}, {"a", 1}, {"b", 1}, {"b", 0}}, sorted)
+
+	// No keys, no sort.
+	sorter, err = NewKeySorter(nil, comparators)
+	require.NoError(t, err)
+	sorted = items()
+	sorter.Sort(sorted)
+	require.Equal(t, items(), sorted)
+
+	_, err = NewKeySorter([]string{"name", "id"}, comparators)
+	require.True(t, errors.Is(err, ErrUnknownSortKey))
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// SorterRegistry maps names to the constructors of the sorters of a given
+// type (e.g. MetricSorter), so the sort orders can be selected by name in the
+// Producer configuration and custom sort orders can be supplied by the users.
+// A constructor is called for every Producer because some sorters are
+// stateful.
+type SorterRegistry[S any] struct {
+	mu       sync.RWMutex
+	sorters  map[string]func() S
+	typeName string
+}
+
+// NewSorterRegistry creates an empty registry, typeName is only used in the
+// error messages.
+func NewSorterRegistry[S any](typeName string) *SorterRegistry[S] {
+	return &SorterRegistry[S]{
+		sorters:  make(map[string]func() S),
+		typeName: typeName,
+	}
+}
+
+// Register adds or replaces the sorter registered under the given name.
+func (r *SorterRegistry[S]) Register(name string, newSorter func() S) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sorters[name] = newSorter
+}
+
+// New creates the sorter registered under the given name, or returns
+// ErrUnknownSorter.
+func (r *SorterRegistry[S]) New(name string) (S, error) {
+	r.mu.RLock()
+	newSorter, ok := r.sorters[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		var zero S
+		return zero, werror.WrapWithContext(ErrUnknownSorter, map[string]interface{}{
+			"type": r.typeName,
+			"name": name,
+		})
+	}
+	return newSorter(), nil
+}
+
+// Names returns the sorted names of the registered sorters.
+func (r *SorterRegistry[S]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.sorters))
+	for name := range r.sorters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
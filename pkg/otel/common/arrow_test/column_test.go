@@ -0,0 +1,104 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	acommon "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+)
+
+var columnsSchema = arrow.NewSchema([]arrow.Field{
+	{Name: Timestamp, Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: acommon.Metadata(acommon.Optional)},
+	{Name: U32, Type: arrow.PrimitiveTypes.Uint32, Metadata: acommon.Metadata(acommon.Optional, acommon.Dictionary8)},
+	{Name: F64, Type: arrow.PrimitiveTypes.Float64},
+	{Name: String, Type: arrow.BinaryTypes.String, Metadata: acommon.Metadata(acommon.Optional, acommon.Dictionary8)},
+	{Name: I64, Type: arrow.PrimitiveTypes.Int64, Metadata: acommon.Metadata(acommon.Optional)},
+}, nil)
+
+func TestAppendArrays(t *testing.T) {
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	recordBuilderExt := builder.NewRecordBuilderExt(pool, columnsSchema, DictConfig, producerStats)
+	defer recordBuilderExt.Release()
+
+	// The columns collected by the caller.
+	tsBuilder := array.NewTimestampBuilder(pool, arrow.FixedWidthTypes.Timestamp_ns.(*arrow.TimestampType))
+	tsBuilder.AppendValues([]arrow.Timestamp{1, 2, 3}, nil)
+	timestamps := tsBuilder.NewArray()
+	defer timestamps.Release()
+
+	u32Builder := array.NewUint32Builder(pool)
+	u32Builder.AppendValues([]uint32{7, 7, 8}, []bool{true, false, true})
+	u32s := u32Builder.NewArray()
+	defer u32s.Release()
+
+	f64Builder := array.NewFloat64Builder(pool)
+	f64Builder.AppendValues([]float64{0.5, 1.5, 2.5}, nil)
+	f64s := f64Builder.NewArray()
+	defer f64s.Release()
+
+	stringBuilder := array.NewStringBuilder(pool)
+	stringBuilder.AppendValues([]string{"a", "", "a"}, nil)
+	strings := stringBuilder.NewArray()
+	defer strings.Release()
+
+	// Only zeros, the optional column stays out of the schema.
+	i64Builder := array.NewInt64Builder(pool)
+	i64Builder.AppendValues([]int64{0, 0, 0}, nil)
+	i64s := i64Builder.NewArray()
+	defer i64s.Release()
+
+	var record arrow.Record
+	for {
+		require.NoError(t, recordBuilderExt.TimestampBuilder(Timestamp).AppendArray(timestamps))
+		require.NoError(t, recordBuilderExt.Uint32Builder(U32).AppendArray(u32s))
+		require.NoError(t, recordBuilderExt.Float64Builder(F64).AppendArray(f64s))
+		require.NoError(t, recordBuilderExt.StringBuilder(String).AppendArray(strings))
+		require.NoError(t, recordBuilderExt.Int64Builder(I64).AppendArray(i64s))
+
+		var err error
+		record, err = recordBuilderExt.NewRecord()
+		if err == nil {
+			break
+		}
+		require.True(t, errors.Is(err, acommon.ErrSchemaNotUpToDate))
+	}
+	defer record.Release()
+
+	json, err := record.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"timestamp":"1970-01-01 00:00:00.000000001","u32":7,"f64":0.5,"string":"a"},
+		{"timestamp":"1970-01-01 00:00:00.000000002","u32":null,"f64":1.5,"string":null},
+		{"timestamp":"1970-01-01 00:00:00.000000003","u32":8,"f64":2.5,"string":"a"}
+	]`, string(json))
+
+	// The arrays must match the type of the fields.
+	err = recordBuilderExt.Float64Builder(F64).AppendArray(u32s)
+	require.True(t, errors.Is(err, acommon.ErrArrayTypeMismatch))
+}
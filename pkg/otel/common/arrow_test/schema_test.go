@@ -18,6 +18,7 @@
 package arrow_test
 
 import (
+	"errors"
 	"math"
 	"strconv"
 	"testing"
@@ -657,6 +658,60 @@ func TestDictionaryOverflow(t *testing.T) {
 	//)
 }
 
+func TestCheckpointRollback(t *testing.T) {
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	recordBuilderExt := builder.NewRecordBuilderExt(pool, protoSchema, DictConfig, stats.NewProducerStats())
+	defer recordBuilderExt.Release()
+
+	rootBuilder := NewRootBuilderFrom(recordBuilderExt)
+	rootData := RootData{
+		u8:     1,
+		string: "a",
+	}
+	AppendAndJsonAssert(t, &rootData, rootBuilder, "[{\"root\":{\"u8\":1,\"string\":\"a\"}}\n]")
+
+	// A row is half-written (the struct and u8 are appended but not string)
+	// before an error.
+	recordBuilderExt.Checkpoint()
+	err := rootBuilder.builder.Append(&rootData, func() error {
+		rootBuilder.u8.AppendNonZero(rootData.u8)
+		return errors.New("append failure")
+	})
+	assert.Error(t, err)
+	recordBuilderExt.Rollback()
+	rootBuilder.init()
+
+	// The next record only contains the rows appended after the rollback.
+	rootData = RootData{
+		u8:     2,
+		string: "b",
+	}
+	AppendAndJsonAssert(t, &rootData, rootBuilder, "[{\"root\":{\"u8\":2,\"string\":\"b\"}}\n]")
+
+	// The rows appended before the checkpoint are preserved.
+	rootData = RootData{
+		u8:     3,
+		string: "c",
+	}
+	assert.NoError(t, rootBuilder.Append(&rootData))
+	recordBuilderExt.Checkpoint()
+	err = rootBuilder.builder.Append(&rootData, func() error {
+		rootBuilder.u8.AppendNonZero(4)
+		return errors.New("append failure")
+	})
+	assert.Error(t, err)
+	recordBuilderExt.Rollback()
+	rootBuilder.init()
+
+	rootData = RootData{
+		u8:     5,
+		string: "e",
+	}
+	AppendAndJsonAssert(t, &rootData, rootBuilder, "[{\"root\":{\"u8\":3,\"string\":\"c\"}}\n,{\"root\":{\"u8\":5,\"string\":\"e\"}}\n]")
+}
+
 func assertDictionary(t *testing.T, expectedIndex arrow.DataType, expectedItem arrow.DataType, dictType arrow.DataType) {
 	if dict, ok := dictType.(*arrow.DictionaryType); ok {
 		if dict.IndexType != expectedIndex {
@@ -28,6 +28,8 @@ var (
 
 	ErrInvalidSpanIDLength  = errors.New("invalid span id length")
 	ErrInvalidTraceIDLength = errors.New("invalid trace id length")
+	ErrInvalidSpanKind      = errors.New("invalid span kind")
+	ErrInvalidStatusCode    = errors.New("invalid span status code")
 
 	ErrNotArraySparseUnion = errors.New("not an arrow array.SparseUnion")
 	ErrNotArrayMap         = errors.New("not an arrow array.Map")
@@ -0,0 +1,21 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hashmap implements specialized open-addressing hash tables used by
+// the Arrow builders to assign per-batch identifiers (e.g. the resource and
+// scope IDs of the logs optimizer, the metric series IDs). Compared to the
+// built-in maps, they can be reset between batches without releasing their
+// slots, so a table is naturally pre-sized from the statistics of the previous
+// batches.
+package hashmap
@@ -0,0 +1,145 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashmap
+
+import "hash/maphash"
+
+const (
+	minCapacity = 8
+
+	// The table grows when it is more than 3/4 full.
+	maxLoadNum = 3
+	maxLoadDen = 4
+)
+
+// StringMap is an open-addressing hash table (linear probing) mapping string
+// keys to uint32 values.
+//
+// Each slot stores the full hash of its key so most probes are resolved
+// without comparing strings. The zero hash is reserved to mark empty slots.
+type StringMap struct {
+	seed  maphash.Seed
+	slots []stringSlot
+	mask  uint64
+	count int
+}
+
+type stringSlot struct {
+	hash  uint64
+	key   string
+	value uint32
+}
+
+// NewStringMap creates a StringMap able to store `capacity` entries without
+// growing.
+func NewStringMap(capacity int) *StringMap {
+	m := &StringMap{seed: maphash.MakeSeed()}
+	m.init(capacity)
+	return m
+}
+
+func (m *StringMap) init(capacity int) {
+	size := minCapacity
+	for size*maxLoadNum/maxLoadDen < capacity {
+		size <<= 1
+	}
+	m.slots = make([]stringSlot, size)
+	m.mask = uint64(size - 1)
+	m.count = 0
+}
+
+// hash returns the hash of a key.
+func (m *StringMap) hash(key string) uint64 {
+	return fixHash(maphash.String(m.seed, key))
+}
+
+// Get returns the value associated with the key.
+func (m *StringMap) Get(key string) (uint32, bool) {
+	hash := m.hash(key)
+	for i := hash & m.mask; ; i = (i + 1) & m.mask {
+		slot := &m.slots[i]
+		if slot.hash == 0 {
+			return 0, false
+		}
+		if slot.hash == hash && slot.key == key {
+			return slot.value, true
+		}
+	}
+}
+
+// GetOrInsert returns the value associated with the key if present (and
+// true), otherwise inserts the given value (and returns it with false).
+func (m *StringMap) GetOrInsert(key string, value uint32) (uint32, bool) {
+	hash := m.hash(key)
+	if (m.count+1)*maxLoadDen > len(m.slots)*maxLoadNum {
+		m.grow()
+	}
+
+	for i := hash & m.mask; ; i = (i + 1) & m.mask {
+		slot := &m.slots[i]
+		if slot.hash == 0 {
+			slot.hash = hash
+			slot.key = key
+			slot.value = value
+			m.count++
+			return value, false
+		}
+		if slot.hash == hash && slot.key == key {
+			return slot.value, true
+		}
+	}
+}
+
+// Len returns the number of entries.
+func (m *StringMap) Len() int {
+	return m.count
+}
+
+// Reset removes all the entries but keeps the allocated slots, so the next
+// batch can be accumulated without growing the table.
+func (m *StringMap) Reset() {
+	if m.count == 0 {
+		return
+	}
+	for i := range m.slots {
+		m.slots[i] = stringSlot{}
+	}
+	m.count = 0
+}
+
+func (m *StringMap) grow() {
+	old := m.slots
+	m.slots = make([]stringSlot, len(old)*2)
+	m.mask = uint64(len(m.slots) - 1)
+
+	for _, slot := range old {
+		if slot.hash == 0 {
+			continue
+		}
+		i := slot.hash & m.mask
+		for m.slots[i].hash != 0 {
+			i = (i + 1) & m.mask
+		}
+		m.slots[i] = slot
+	}
+}
+
+// fixHash makes sure a hash is never 0 (reserved for empty slots).
+func fixHash(h uint64) uint64 {
+	if h == 0 {
+		return 1
+	}
+	return h
+}
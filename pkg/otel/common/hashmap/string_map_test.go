@@ -0,0 +1,82 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringMap(t *testing.T) {
+	t.Parallel()
+
+	m := NewStringMap(0)
+	const n = 10000
+
+	for i := 0; i < n; i++ {
+		v, found := m.GetOrInsert(fmt.Sprintf("key-%d", i), uint32(i))
+		require.False(t, found)
+		require.Equal(t, uint32(i), v)
+	}
+	require.Equal(t, n, m.Len())
+
+	for i := 0; i < n; i++ {
+		v, found := m.GetOrInsert(fmt.Sprintf("key-%d", i), 0)
+		require.True(t, found)
+		require.Equal(t, uint32(i), v)
+	}
+	_, found := m.Get("missing")
+	require.False(t, found)
+
+	// Reset keeps the capacity.
+	capacity := len(m.slots)
+	m.Reset()
+	require.Equal(t, 0, m.Len())
+	require.Equal(t, capacity, len(m.slots))
+	_, found = m.Get("key-1")
+	require.False(t, found)
+}
+
+func BenchmarkStringMap(b *testing.B) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("resource-%d", i)
+	}
+
+	b.Run("hashmap", func(b *testing.B) {
+		m := NewStringMap(len(keys))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.Reset()
+			for j, k := range keys {
+				m.GetOrInsert(k, uint32(j))
+			}
+		}
+	})
+
+	b.Run("builtin", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := make(map[string]uint32)
+			for j, k := range keys {
+				if _, ok := m[k]; !ok {
+					m[k] = uint32(j)
+				}
+			}
+		}
+	})
+}
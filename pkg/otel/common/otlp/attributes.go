@@ -43,59 +43,64 @@ type (
 		Bool                 int
 		Bytes                int
 		Ser                  int
+		Flattened            int
 	}
 
-	// Attributes16Store is a store for attributes.
-	// The attributes are stored in a map by ID. This ID represents the
-	// identifier of the main entity (span, event, link, etc.) to which the
-	// attributes are attached. So the maximum number of attributes per entity
-	// is not limited.
-	Attributes16Store struct {
-		lastID         uint16
-		attributesByID map[uint16]*pcommon.Map
+	// ParentID is the type of the parent IDs of the attribute records, i.e.
+	// uint16 or uint32 depending on the payload type.
+	ParentID interface {
+		~uint16 | ~uint32
 	}
 
-	// Attributes32Store is a store for attributes.
+	// AttributesStore is a store for attributes.
 	// The attributes are stored in a map by ID. This ID represents the
 	// identifier of the main entity (span, event, link, etc.) to which the
 	// attributes are attached. So the maximum number of attributes per entity
 	// is not limited.
-	Attributes32Store struct {
-		lastID         uint32
-		attributesByID map[uint32]*pcommon.Map
+	AttributesStore[T ParentID] struct {
+		lastID         T
+		attributesByID map[T]*pcommon.Map
 	}
 
-	Attrs16ParentIdDecoder struct {
-		prevParentID uint16
+	// AttrsParentIdDecoder decodes the parent IDs of an attribute record.
+	AttrsParentIdDecoder[T ParentID] struct {
+		prevParentID T
 		prevKey      string
 		prevValue    *pcommon.Value
 		encodingType int
 	}
 
-	Attrs32ParentIdDecoder struct {
-		prevParentID uint32
-		prevKey      string
-		prevValue    *pcommon.Value
-		encodingType int
-	}
+	// Attributes16Store is a store for attributes with 16-bit parent IDs
+	// (resource, scope, span, log record attributes).
+	Attributes16Store = AttributesStore[uint16]
+
+	// Attributes32Store is a store for attributes with 32-bit parent IDs
+	// (span event, span link, data point and exemplar attributes).
+	Attributes32Store = AttributesStore[uint32]
+
+	Attrs16ParentIdDecoder = AttrsParentIdDecoder[uint16]
+	Attrs32ParentIdDecoder = AttrsParentIdDecoder[uint32]
 )
 
+// NewAttributesStore creates a new AttributesStore.
+func NewAttributesStore[T ParentID]() *AttributesStore[T] {
+	return &AttributesStore[T]{
+		attributesByID: make(map[T]*pcommon.Map),
+	}
+}
+
 // NewAttributes16Store creates a new Attributes16Store.
 func NewAttributes16Store() *Attributes16Store {
-	return &Attributes16Store{
-		attributesByID: make(map[uint16]*pcommon.Map),
-	}
+	return NewAttributesStore[uint16]()
 }
 
 // NewAttributes32Store creates a new Attributes32Store.
 func NewAttributes32Store() *Attributes32Store {
-	return &Attributes32Store{
-		attributesByID: make(map[uint32]*pcommon.Map),
-	}
+	return NewAttributesStore[uint32]()
 }
 
 // AttributesByDeltaID returns the attributes for the given Delta ID.
-func (s *Attributes16Store) AttributesByDeltaID(ID uint16) *pcommon.Map {
+func (s *AttributesStore[T]) AttributesByDeltaID(ID T) *pcommon.Map {
 	s.lastID += ID
 	if m, ok := s.attributesByID[s.lastID]; ok {
 		return m
@@ -104,33 +109,34 @@ func (s *Attributes16Store) AttributesByDeltaID(ID uint16) *pcommon.Map {
 }
 
 // AttributesByID returns the attributes for the given ID.
-func (s *Attributes16Store) AttributesByID(ID uint16) *pcommon.Map {
+func (s *AttributesStore[T]) AttributesByID(ID T) *pcommon.Map {
 	if m, ok := s.attributesByID[ID]; ok {
 		return m
 	}
 	return nil
 }
 
-// AttributesByID returns the attributes for the given ID.
-func (s *Attributes32Store) AttributesByID(ID uint32) *pcommon.Map {
-	if m, ok := s.attributesByID[ID]; ok {
-		return m
+// Attributes16StoreFrom creates an Attribute16Store from an arrow.Record.
+// Note: This function consume the record.
+func Attributes16StoreFrom(record arrow.Record, store *Attributes16Store) error {
+	defer record.Release()
+
+	attrIDS, err := SchemaToAttributeIDs(record.Schema())
+	if err != nil {
+		return werror.Wrap(err)
 	}
-	return nil
-}
 
-// AttributesByDeltaID returns the attributes for the given Delta ID.
-func (s *Attributes32Store) AttributesByDeltaID(ID uint32) *pcommon.Map {
-	s.lastID += ID
-	if m, ok := s.attributesByID[s.lastID]; ok {
-		return m
+	parentIDs, err := arrowutils.U16ValuesFromRecord(record, attrIDS.ParentID)
+	if err != nil {
+		return werror.Wrap(err)
 	}
-	return nil
+
+	return attributesStoreFrom(record, attrIDS, parentIDs, NewAttrs16ParentIdDecoder(), store)
 }
 
-// Attributes16StoreFrom creates an Attribute16Store from an arrow.Record.
+// Attributes32StoreFrom creates an Attributes32Store from an arrow.Record.
 // Note: This function consume the record.
-func Attributes16StoreFrom(record arrow.Record, store *Attributes16Store) error {
+func Attributes32StoreFrom(record arrow.Record, store *Attributes32Store) error {
 	defer record.Release()
 
 	attrIDS, err := SchemaToAttributeIDs(record.Schema())
@@ -138,79 +144,37 @@ func Attributes16StoreFrom(record arrow.Record, store *Attributes16Store) error
 		return werror.Wrap(err)
 	}
 
-	attrsCount := int(record.NumRows())
+	parentIDs, err := arrowutils.U32ValuesFromRecord(record, attrIDS.ParentID)
+	if err != nil {
+		return werror.Wrap(err)
+	}
 
-	parentIdDecoder := NewAttrs16ParentIdDecoder()
+	return attributesStoreFrom(record, attrIDS, parentIDs, NewAttrs32ParentIdDecoder(), store)
+}
+
+// attributesStoreFrom reads all key/value tuples from the record and
+// reconstructs the attributes map by ID. This is common to all the attribute
+// payload types, only the width of the parent IDs differs.
+func attributesStoreFrom[T ParentID](record arrow.Record, attrIDS *AttributeIDs, parentIDs []T, parentIdDecoder *AttrsParentIdDecoder[T], store *AttributesStore[T]) error {
+	attrsCount := int(record.NumRows())
 
-	// Read all key/value tuples from the record and reconstruct the attributes
-	// map by ID.
 	for i := 0; i < attrsCount; i++ {
 		key, err := arrowutils.StringFromRecord(record, attrIDS.Key, i)
 		if err != nil {
 			return werror.Wrap(err)
 		}
 
-		vType, err := arrowutils.U8FromRecord(record, attrIDS.Type, i)
+		value, err := attributeValueFromRecord(record, attrIDS, i)
 		if err != nil {
 			return werror.Wrap(err)
 		}
 
-		value := pcommon.NewValueEmpty()
-		switch pcommon.ValueType(vType) {
-		case pcommon.ValueTypeStr:
-			v, err := arrowutils.StringFromRecord(record, attrIDS.Str, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetStr(v)
-		case pcommon.ValueTypeInt:
-			v, err := arrowutils.I64FromRecord(record, attrIDS.Int, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetInt(v)
-		case pcommon.ValueTypeDouble:
-			v, err := arrowutils.F64FromRecord(record, attrIDS.Double, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetDouble(v)
-		case pcommon.ValueTypeBool:
-			v, err := arrowutils.BoolFromRecord(record, attrIDS.Bool, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetBool(v)
-		case pcommon.ValueTypeBytes:
-			v, err := arrowutils.BinaryFromRecord(record, attrIDS.Bytes, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetEmptyBytes().FromRaw(v)
-		case pcommon.ValueTypeSlice:
-			v, err := arrowutils.BinaryFromRecord(record, attrIDS.Ser, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			if err = common.Deserialize(v, value); err != nil {
-				return werror.Wrap(err)
-			}
-		case pcommon.ValueTypeMap:
-			v, err := arrowutils.BinaryFromRecord(record, attrIDS.Ser, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			if err = common.Deserialize(v, value); err != nil {
-				return werror.Wrap(err)
-			}
-		default:
-			// silently ignore unknown types to avoid DOS attacks
-		}
-
-		deltaOrParentID, err := arrowutils.U16FromRecord(record, attrIDS.ParentID, i)
+		flattened, err := arrowutils.BoolFromRecord(record, attrIDS.Flattened, i)
 		if err != nil {
 			return werror.Wrap(err)
 		}
+
+		deltaOrParentID := parentIDs[i]
 		parentID := parentIdDecoder.Decode(deltaOrParentID, key, &value)
 
 		m, ok := store.attributesByID[parentID]
@@ -219,106 +183,70 @@ func Attributes16StoreFrom(record arrow.Record, store *Attributes16Store) error
 			m = &newMap
 			store.attributesByID[parentID] = m
 		}
+		if flattened {
+			carrow.PutFlattened(*m, key, value)
+			continue
+		}
 		value.CopyTo(m.PutEmpty(key))
 	}
 
 	return nil
 }
 
-// Attributes32StoreFrom creates an Attributes32Store from an arrow.Record.
-// Note: This function consume the record.
-func Attributes32StoreFrom(record arrow.Record, store *Attributes32Store) error {
-	defer record.Release()
+// attributeValueFromRecord returns the value of the attribute at the given
+// row.
+func attributeValueFromRecord(record arrow.Record, attrIDS *AttributeIDs, row int) (pcommon.Value, error) {
+	value := pcommon.NewValueEmpty()
 
-	attrIDS, err := SchemaToAttributeIDs(record.Schema())
+	vType, err := arrowutils.U8FromRecord(record, attrIDS.Type, row)
 	if err != nil {
-		return werror.Wrap(err)
+		return value, werror.Wrap(err)
 	}
 
-	attrsCount := int(record.NumRows())
-
-	parentIdDecoder := NewAttrs32ParentIdDecoder()
-
-	// Read all key/value tuples from the record and reconstruct the attributes
-	// map by ID.
-	for i := 0; i < attrsCount; i++ {
-		key, err := arrowutils.StringFromRecord(record, attrIDS.Key, i)
+	switch pcommon.ValueType(vType) {
+	case pcommon.ValueTypeStr:
+		v, err := arrowutils.StringFromRecord(record, attrIDS.Str, row)
 		if err != nil {
-			return werror.Wrap(err)
+			return value, werror.Wrap(err)
 		}
-
-		vType, err := arrowutils.U8FromRecord(record, attrIDS.Type, i)
+		value.SetStr(v)
+	case pcommon.ValueTypeInt:
+		v, err := arrowutils.I64FromRecord(record, attrIDS.Int, row)
 		if err != nil {
-			return werror.Wrap(err)
+			return value, werror.Wrap(err)
 		}
-		value := pcommon.NewValueEmpty()
-		switch pcommon.ValueType(vType) {
-		case pcommon.ValueTypeStr:
-			v, err := arrowutils.StringFromRecord(record, attrIDS.Str, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetStr(v)
-		case pcommon.ValueTypeInt:
-			v, err := arrowutils.I64FromRecord(record, attrIDS.Int, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetInt(v)
-		case pcommon.ValueTypeDouble:
-			v, err := arrowutils.F64FromRecord(record, attrIDS.Double, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetDouble(v)
-		case pcommon.ValueTypeBool:
-			v, err := arrowutils.BoolFromRecord(record, attrIDS.Bool, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetBool(v)
-		case pcommon.ValueTypeBytes:
-			v, err := arrowutils.BinaryFromRecord(record, attrIDS.Bytes, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			value.SetEmptyBytes().FromRaw(v)
-		case pcommon.ValueTypeSlice:
-			v, err := arrowutils.BinaryFromRecord(record, attrIDS.Ser, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			if err = common.Deserialize(v, value); err != nil {
-				return werror.Wrap(err)
-			}
-		case pcommon.ValueTypeMap:
-			v, err := arrowutils.BinaryFromRecord(record, attrIDS.Ser, i)
-			if err != nil {
-				return werror.Wrap(err)
-			}
-			if err = common.Deserialize(v, value); err != nil {
-				return werror.Wrap(err)
-			}
-		default:
-			// silently ignore unknown types to avoid DOS attacks
+		value.SetInt(v)
+	case pcommon.ValueTypeDouble:
+		v, err := arrowutils.F64FromRecord(record, attrIDS.Double, row)
+		if err != nil {
+			return value, werror.Wrap(err)
 		}
-
-		deltaOrParentID, err := arrowutils.U32FromRecord(record, attrIDS.ParentID, i)
+		value.SetDouble(v)
+	case pcommon.ValueTypeBool:
+		v, err := arrowutils.BoolFromRecord(record, attrIDS.Bool, row)
 		if err != nil {
-			return werror.Wrap(err)
+			return value, werror.Wrap(err)
 		}
-		parentID := parentIdDecoder.Decode(deltaOrParentID, key, &value)
-
-		m, ok := store.attributesByID[parentID]
-		if !ok {
-			newMap := pcommon.NewMap()
-			m = &newMap
-			store.attributesByID[parentID] = m
+		value.SetBool(v)
+	case pcommon.ValueTypeBytes:
+		v, err := arrowutils.BinaryFromRecord(record, attrIDS.Bytes, row)
+		if err != nil {
+			return value, werror.Wrap(err)
 		}
-		value.CopyTo(m.PutEmpty(key))
+		value.SetEmptyBytes().FromRaw(v)
+	case pcommon.ValueTypeSlice, pcommon.ValueTypeMap:
+		v, err := arrowutils.BinaryFromRecord(record, attrIDS.Ser, row)
+		if err != nil {
+			return value, werror.Wrap(err)
+		}
+		if err = common.Deserialize(v, value); err != nil {
+			return value, werror.Wrap(err)
+		}
+	default:
+		// silently ignore unknown types to avoid DOS attacks
 	}
 
-	return nil
+	return value, nil
 }
 
 // SchemaToAttributeIDs pre-computes the field IDs for the attributes record.
@@ -367,6 +295,10 @@ func SchemaToAttributeIDs(schema *arrow.Schema) (*AttributeIDs, error) {
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	flattened, err := arrowutils.FieldIDFromSchema(schema, constants.AttributeFlattened)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
 
 	return &AttributeIDs{
 		ParentID:             parentID,
@@ -379,46 +311,25 @@ func SchemaToAttributeIDs(schema *arrow.Schema) (*AttributeIDs, error) {
 		Bool:                 vBool,
 		Bytes:                vBytes,
 		Ser:                  vSer,
+		Flattened:            flattened,
 	}, nil
 }
 
 func NewAttrs16ParentIdDecoder() *Attrs16ParentIdDecoder {
-	return &Attrs16ParentIdDecoder{
-		encodingType: carrow.ParentIdDeltaGroupEncoding,
-	}
+	return NewAttrsParentIdDecoder[uint16]()
 }
 
-func (d *Attrs16ParentIdDecoder) Decode(deltaOrParentID uint16, key string, value *pcommon.Value) uint16 {
-	switch d.encodingType {
-	case carrow.ParentIdNoEncoding:
-		return deltaOrParentID
-	case carrow.ParentIdDeltaEncoding:
-		decodedParentID := d.prevParentID + deltaOrParentID
-		d.prevParentID = decodedParentID
-		return decodedParentID
-	case carrow.ParentIdDeltaGroupEncoding:
-		if d.prevKey == key && carrow.Equal(d.prevValue, value) {
-			parentID := d.prevParentID + deltaOrParentID
-			d.prevParentID = parentID
-			return parentID
-		} else {
-			d.prevKey = key
-			d.prevValue = value
-			d.prevParentID = deltaOrParentID
-			return deltaOrParentID
-		}
-	default:
-		panic("unknown attrs16 parent ID encoding type")
-	}
+func NewAttrs32ParentIdDecoder() *Attrs32ParentIdDecoder {
+	return NewAttrsParentIdDecoder[uint32]()
 }
 
-func NewAttrs32ParentIdDecoder() *Attrs32ParentIdDecoder {
-	return &Attrs32ParentIdDecoder{
+func NewAttrsParentIdDecoder[T ParentID]() *AttrsParentIdDecoder[T] {
+	return &AttrsParentIdDecoder[T]{
 		encodingType: carrow.ParentIdDeltaGroupEncoding,
 	}
 }
 
-func (d *Attrs32ParentIdDecoder) Decode(deltaOrParentID uint32, key string, value *pcommon.Value) uint32 {
+func (d *AttrsParentIdDecoder[T]) Decode(deltaOrParentID T, key string, value *pcommon.Value) T {
 	switch d.encodingType {
 	case carrow.ParentIdNoEncoding:
 		return deltaOrParentID
@@ -438,6 +349,6 @@ func (d *Attrs32ParentIdDecoder) Decode(deltaOrParentID uint32, key string, valu
 			return deltaOrParentID
 		}
 	default:
-		panic("unknown attrs32 parent ID encoding type")
+		panic("unknown attrs parent ID encoding type")
 	}
 }
@@ -0,0 +1,118 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package otlp
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// AttributesCache is a LRU cache of the attribute maps decoded from the
+// resource and scope attribute records of a consumer. Agents usually send
+// the same resources and scopes in every batch, so the same records are
+// received again and again; a cache hit reuses the maps decoded for a
+// previous batch instead of materializing identical maps.
+//
+// The entries are keyed by a digest of the received payload computed by the
+// caller. The cached maps are shared by the batches and must be treated as
+// read-only, the decoders copy them into the OTLP entities.
+type AttributesCache struct {
+	capacity int
+
+	// lock protects the fields below, the resource and scope attributes
+	// may be decoded concurrently.
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type attributesCacheEntry struct {
+	key   string
+	attrs map[uint16]*pcommon.Map
+}
+
+// NewAttributesCache creates a cache of at most capacity decoded attribute
+// records.
+func NewAttributesCache(capacity int) *AttributesCache {
+	return &AttributesCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Attributes16StoreFrom fills the store with the attributes of the record, or
+// with the cached attributes of a previous record having the same key.
+// Without a cache (nil receiver) or a key, this is equivalent to the
+// Attributes16StoreFrom function.
+func (c *AttributesCache) Attributes16StoreFrom(key string, record arrow.Record, store *Attributes16Store) error {
+	if c == nil || key == "" {
+		return Attributes16StoreFrom(record, store)
+	}
+
+	if attrs, ok := c.get(key); ok {
+		record.Release()
+		store.attributesByID = attrs
+		return nil
+	}
+
+	if err := Attributes16StoreFrom(record, store); err != nil {
+		return err
+	}
+	c.put(key, store.attributesByID)
+	return nil
+}
+
+// Len returns the number of cached attribute records.
+func (c *AttributesCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *AttributesCache) get(key string) (map[uint16]*pcommon.Map, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*attributesCacheEntry).attrs, true
+}
+
+func (c *AttributesCache) put(key string, attrs map[uint16]*pcommon.Map) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&attributesCacheEntry{key: key, attrs: attrs})
+
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*attributesCacheEntry).key)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package otlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+	cfg "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+)
+
+func buildAttrs16Record(t *testing.T, pool memory.Allocator, attrs []pcommon.Map) (arrow.Record, *carrow.Attrs16Builder) {
+	rb := builder.NewRecordBuilderExt(pool, carrow.AttrsSchema16, cfg.NewDictionary(math.MaxUint16), stats.NewProducerStats())
+	b := carrow.NewAttrs16BuilderWithEncoding(rb, carrow.PayloadTypes.ResourceAttrs, &carrow.Attrs16Config{Sorter: carrow.SortAttrs16ByKeyValueParentId()})
+	for i, m := range attrs {
+		require.NoError(t, b.Accumulator().AppendWithID(uint16(i), m))
+	}
+	record, err := b.Build()
+	require.NoError(t, err)
+	return record, b
+}
+
+// TestAttributesCache checks that the attributes of a cached record are
+// reused and that the least recently used records are evicted.
+func TestAttributesCache(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+	attrs := testAttributes()
+	cache := NewAttributesCache(1)
+
+	record, b := buildAttrs16Record(t, pool, attrs)
+	defer b.Release()
+	first := NewAttributes16Store()
+	require.NoError(t, cache.Attributes16StoreFrom("a", record, first))
+	require.Equal(t, 1, cache.Len())
+
+	record, b = buildAttrs16Record(t, pool, attrs)
+	defer b.Release()
+	second := NewAttributes16Store()
+	require.NoError(t, cache.Attributes16StoreFrom("a", record, second))
+	for i, m := range attrs {
+		require.Equal(t, m.AsRaw(), second.AttributesByID(uint16(i)).AsRaw())
+		// The maps decoded for the first record are shared.
+		require.Same(t, first.AttributesByID(uint16(i)), second.AttributesByID(uint16(i)))
+	}
+
+	// Evicts "a".
+	record, b = buildAttrs16Record(t, pool, attrs[1:])
+	defer b.Release()
+	require.NoError(t, cache.Attributes16StoreFrom("b", record, NewAttributes16Store()))
+	require.Equal(t, 1, cache.Len())
+
+	record, b = buildAttrs16Record(t, pool, attrs)
+	defer b.Release()
+	third := NewAttributes16Store()
+	require.NoError(t, cache.Attributes16StoreFrom("a", record, third))
+	require.NotSame(t, first.AttributesByID(0), third.AttributesByID(0))
+	require.Equal(t, attrs[0].AsRaw(), third.AttributesByID(0).AsRaw())
+}
+
+// TestAttributesCacheDisabled checks that a nil cache decodes every record.
+func TestAttributesCacheDisabled(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+	attrs := testAttributes()
+	var cache *AttributesCache
+
+	record, b := buildAttrs16Record(t, pool, attrs)
+	defer b.Release()
+	store := NewAttributes16Store()
+	require.NoError(t, cache.Attributes16StoreFrom("a", record, store))
+	for i, m := range attrs {
+		require.Equal(t, m.AsRaw(), store.AttributesByID(uint16(i)).AsRaw())
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package otlp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+	cfg "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+)
+
+func testAttributes() []pcommon.Map {
+	attrs1 := pcommon.NewMap()
+	attrs1.PutStr("str", "value")
+	attrs1.PutInt("int", 1)
+	attrs1.PutDouble("double", 1.5)
+	attrs1.PutBool("bool", true)
+	attrs1.PutEmptyBytes("bytes").FromRaw([]byte("bytes"))
+	attrs1.PutEmptySlice("slice").AppendEmpty().SetStr("item")
+	attrs1.PutEmptyMap("map").PutStr("nested", "value")
+
+	attrs2 := pcommon.NewMap()
+	attrs2.PutStr("str", "value")
+	attrs2.PutInt("int", 2)
+
+	return []pcommon.Map{attrs1, attrs2}
+}
+
+// TestAttributesStoreFrom checks that the attributes with 16-bit and 32-bit
+// parent IDs are decoded identically.
+func TestAttributesStoreFrom(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+	producerStats := stats.NewProducerStats()
+	attrs := testAttributes()
+
+	rb16 := builder.NewRecordBuilderExt(pool, carrow.AttrsSchema16, cfg.NewDictionary(math.MaxUint16), producerStats)
+	b16 := carrow.NewAttrs16BuilderWithEncoding(rb16, carrow.PayloadTypes.SpanAttrs, &carrow.Attrs16Config{Sorter: carrow.SortAttrs16ByKeyValueParentId()})
+	defer b16.Release()
+	for i, m := range attrs {
+		require.NoError(t, b16.Accumulator().AppendWithID(uint16(i), m))
+	}
+	record16, err := b16.Build()
+	require.NoError(t, err)
+	store16 := NewAttributes16Store()
+	require.NoError(t, Attributes16StoreFrom(record16, store16))
+
+	rb32 := builder.NewRecordBuilderExt(pool, carrow.AttrsSchema32, cfg.NewDictionary(math.MaxUint16), producerStats)
+	b32 := carrow.NewAttrs32BuilderWithEncoding(rb32, carrow.PayloadTypes.EventAttrs, &carrow.Attrs32Config{Sorter: carrow.SortAttrs32ByKeyValueParentId()})
+	defer b32.Release()
+	for i, m := range attrs {
+		require.NoError(t, b32.Accumulator().Append(uint32(i), m))
+	}
+	record32, err := b32.Build()
+	require.NoError(t, err)
+	store32 := NewAttributes32Store()
+	require.NoError(t, Attributes32StoreFrom(record32, store32))
+
+	for i, m := range attrs {
+		require.Equal(t, m.AsRaw(), store16.AttributesByID(uint16(i)).AsRaw())
+		require.Equal(t, m.AsRaw(), store32.AttributesByID(uint32(i)).AsRaw())
+	}
+}
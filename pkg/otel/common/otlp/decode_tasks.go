@@ -0,0 +1,63 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package otlp
+
+import "sync"
+
+// DecodeTasks is a set of independent decoding tasks, e.g. one per related
+// payload of a batch.
+type DecodeTasks []func() error
+
+// Run runs the tasks with at most `concurrency` tasks in parallel, or
+// sequentially if concurrency <= 1. It returns the error of the first failing
+// task (in the order of the tasks). All the tasks started are completed when
+// Run returns.
+func (t DecodeTasks) Run(concurrency int) error {
+	if concurrency <= 1 || len(t) <= 1 {
+		for _, task := range t {
+			if err := task(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, len(t))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, task := range t {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, task func() error) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = task()
+		}(i, task)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
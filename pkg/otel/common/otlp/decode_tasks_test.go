@@ -0,0 +1,54 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package otlp
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTasks(t *testing.T) {
+	t.Parallel()
+
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	for _, concurrency := range []int{0, 1, 4} {
+		var count int32
+		tasks := DecodeTasks{
+			func() error { atomic.AddInt32(&count, 1); return nil },
+			func() error { atomic.AddInt32(&count, 1); return nil },
+			func() error { atomic.AddInt32(&count, 1); return nil },
+		}
+		require.NoError(t, tasks.Run(concurrency))
+		require.Equal(t, int32(3), atomic.LoadInt32(&count))
+
+		// The error of the first failing task is returned.
+		tasks = DecodeTasks{
+			func() error { return nil },
+			func() error { return errFirst },
+			func() error { return errSecond },
+		}
+		require.ErrorIs(t, tasks.Run(concurrency), errFirst)
+	}
+
+	require.NoError(t, DecodeTasks(nil).Run(4))
+}
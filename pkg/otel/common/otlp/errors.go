@@ -26,4 +26,5 @@ var (
 	ErrParentIDMissing     = errors.New("parent id missing")
 	ErrInvalidAttrName     = errors.New("invalid attribute name")
 	ErrMissingTypeMetadata = errors.New("missing type metadata")
+	ErrUnknownScopeID      = errors.New("unknown scope id")
 )
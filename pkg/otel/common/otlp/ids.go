@@ -38,6 +38,17 @@ func ResourceID(r pcommon.Resource, schemaUrl string) string {
 	return b.String()
 }
 
+// ResourceEntryID returns the ID of the resource of the i-th resource entry
+// of a batch (see ResourceID). When perRecord is true, the ID of an empty
+// resource is unique to its entry (see config.EmptyPerRecord).
+func ResourceEntryID(r pcommon.Resource, schemaUrl string, perRecord bool, i int) string {
+	ID := ResourceID(r, schemaUrl)
+	if perRecord && r.Attributes().Len() == 0 && r.DroppedAttributesCount() == 0 && schemaUrl == "" {
+		ID += "|#" + strconv.Itoa(i)
+	}
+	return ID
+}
+
 func AttributesId(attrs pcommon.Map, b *strings.Builder) {
 	tmp := make(common.AttributeEntries, 0, attrs.Len())
 	attrs.Range(func(k string, v pcommon.Value) bool {
@@ -107,3 +118,50 @@ func ScopeID(is pcommon.InstrumentationScope, schemaUrl string) string {
 	b.WriteString(schemaUrl)
 	return b.String()
 }
+
+// ScopeEntryID returns the ID of the scope of the j-th scope entry of the
+// i-th resource entry of a batch (see ScopeID). When perRecord is true, the ID
+// of an empty scope is unique to its entry (see config.EmptyPerRecord).
+func ScopeEntryID(is pcommon.InstrumentationScope, schemaUrl string, perRecord bool, i, j int) string {
+	ID := ScopeID(is, schemaUrl)
+	if perRecord && is.Name() == "" && is.Version() == "" && is.Attributes().Len() == 0 &&
+		is.DroppedAttributesCount() == 0 && schemaUrl == "" {
+		ID += "|#" + strconv.Itoa(i) + "." + strconv.Itoa(j)
+	}
+	return ID
+}
+
+// EntryTracker detects the rows of a record starting a new resource (or
+// scope) entry. The attributes IDs of the resources and scopes are
+// delta-encoded: a non-zero delta, or a change between an entry without
+// attributes (nil ID) and an entry with attributes, starts a new entry.
+type EntryTracker struct {
+	started bool
+	null    bool
+	prevID  uint16
+}
+
+// Reset makes the next row start a new entry, e.g. the first scope of a new
+// resource.
+func (t *EntryTracker) Reset() {
+	t.started = false
+}
+
+// IsNew returns true if the row with the given attributes ID starts a new
+// entry.
+func (t *EntryTracker) IsNew(ID *uint16) bool {
+	isNew := !t.started || (ID == nil) != t.null || (ID != nil && *ID != 0)
+	t.started = true
+	t.null = ID == nil
+	return isNew
+}
+
+// IsNewID returns true if the row with the given scope table ID starts a new
+// entry. Unlike the attributes IDs, the scope table IDs are not delta-encoded
+// (see config.WithScopeTable).
+func (t *EntryTracker) IsNewID(ID uint16) bool {
+	isNew := !t.started || ID != t.prevID
+	t.started = true
+	t.prevID = ID
+	return isNew
+}
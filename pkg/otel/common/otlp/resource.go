@@ -88,3 +88,33 @@ func ResourceIDFromRecord(record arrow.Record, row int, resIDs *ResourceIds) (ui
 	}
 	return arrowutils.U16FromStruct(resStruct, row, resIDs.ID)
 }
+
+// NullableResourceIDFromRecord returns the delta-encoded attributes ID of the
+// resource of the given row, or nil if the resource has no attributes (see
+// EntryTracker).
+func NullableResourceIDFromRecord(record arrow.Record, row int, resIDs *ResourceIds) (*uint16, error) {
+	resStruct, err := arrowutils.StructFromRecord(record, resIDs.Resource, row)
+	if err != nil {
+		return nil, err
+	}
+	return arrowutils.NullableU16FromStruct(resStruct, row, resIDs.ID)
+}
+
+// ResourceAttributesFromRecord returns the attributes of the resource of the
+// given row, or nil if the resource has no attributes. As the resource IDs are
+// delta-encoded, it must be called when the resource changes, in the order of
+// the rows (see UpdateResourceFromRecord).
+func ResourceAttributesFromRecord(record arrow.Record, row int, resIds *ResourceIds, attrsStore *Attributes16Store) (*pcommon.Map, error) {
+	resArr, err := arrowutils.StructFromRecord(record, resIds.Resource, row)
+	if err != nil {
+		return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+	}
+	ID, err := arrowutils.NullableU16FromStruct(resArr, row, resIds.ID)
+	if err != nil {
+		return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+	}
+	if ID == nil {
+		return nil, nil
+	}
+	return attrsStore.AttributesByDeltaID(*ID), nil
+}
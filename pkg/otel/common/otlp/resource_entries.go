@@ -0,0 +1,107 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+type (
+	// ResourceEntry is a resource entry of a batch (i.e. a ResourceSpans,
+	// ResourceLogs, or ResourceMetrics) without its data.
+	ResourceEntry struct {
+		Resource  pcommon.Resource
+		SchemaUrl string
+		Scopes    []ScopeEntry
+	}
+
+	// ScopeEntry is a scope entry of a resource entry (i.e. a ScopeSpans,
+	// ScopeLogs, or ScopeMetrics) without its data.
+	ScopeEntry struct {
+		Scope     pcommon.InstrumentationScope
+		SchemaUrl string
+	}
+)
+
+// ResourceEntriesFrom decodes the resource and scope entries of a main record
+// (i.e. SPANS, LOGS, or METRICS). Only the resource and scope columns are
+// read, the spans, log records, and metrics are not decoded. The attributes
+// are looked up in the given stores, and the scopes in the scope table if
+// the record references one (see config.WithScopeTable).
+// Note: This function does not consume the record.
+func ResourceEntriesFrom(
+	record arrow.Record,
+	resAttrs *Attributes16Store,
+	scopeAttrs *Attributes16Store,
+	scopeTable *ScopeTable,
+) ([]ResourceEntry, error) {
+	schema := record.Schema()
+	resIDs, err := NewResourceIdsFromSchema(schema)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	scopeIDs, err := NewScopeIdsFromSchema(schema)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	schemaUrlID, err := arrowutils.FieldIDFromSchema(schema, constants.SchemaUrl)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	var entries []ResourceEntry
+	var resEntries, scopeEntries EntryTracker
+	rows := int(record.NumRows())
+
+	for row := 0; row < rows; row++ {
+		resID, err := NullableResourceIDFromRecord(record, row, resIDs)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		if resEntries.IsNew(resID) {
+			resource := pcommon.NewResource()
+			schemaUrl, err := UpdateResourceFromRecord(resource, record, row, resIDs, resAttrs)
+			if err != nil {
+				return nil, werror.Wrap(err)
+			}
+			entries = append(entries, ResourceEntry{Resource: resource, SchemaUrl: schemaUrl})
+			scopeEntries.Reset()
+		}
+
+		newScope, err := IsNewScopeEntry(record, row, scopeIDs, &scopeEntries)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if newScope {
+			scope := pcommon.NewInstrumentationScope()
+			if err = UpdateScopeFromRecord(scope, record, row, scopeIDs, scopeAttrs, scopeTable); err != nil {
+				return nil, werror.Wrap(err)
+			}
+			schemaUrl, err := arrowutils.StringFromRecord(record, schemaUrlID, row)
+			if err != nil {
+				return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+			}
+			entry := &entries[len(entries)-1]
+			entry.Scopes = append(entry.Scopes, ScopeEntry{Scope: scope, SchemaUrl: schemaUrl})
+		}
+	}
+
+	return entries, nil
+}
@@ -29,6 +29,10 @@ type ScopeIds struct {
 	Version                int
 	ID                     int
 	DroppedAttributesCount int
+	// TableID is the field ID of the scope ID column, present instead of the
+	// scope struct when the scopes are encoded in a scope table (see
+	// config.WithScopeTable).
+	TableID int
 }
 
 func NewScopeIdsFromSchema(schema *arrow.Schema) (*ScopeIds, error) {
@@ -41,22 +45,57 @@ func NewScopeIdsFromSchema(schema *arrow.Schema) (*ScopeIds, error) {
 	versionID, _ := arrowutils.FieldIDFromStruct(scopeDT, constants.Version)
 	droppedAttributesCountID, _ := arrowutils.FieldIDFromStruct(scopeDT, constants.DroppedAttributesCount)
 	ID, _ := arrowutils.FieldIDFromStruct(scopeDT, constants.ID)
+	tableID, err := arrowutils.FieldIDFromSchema(schema, constants.ScopeID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
 	return &ScopeIds{
 		Scope:                  scopeID,
 		Name:                   nameID,
 		Version:                versionID,
 		DroppedAttributesCount: droppedAttributesCountID,
 		ID:                     ID,
+		TableID:                tableID,
 	}, nil
 }
 
+// IsNewScopeEntry returns true if the given row starts a new scope entry (see
+// EntryTracker).
+func IsNewScopeEntry(record arrow.Record, row int, ids *ScopeIds, tracker *EntryTracker) (bool, error) {
+	if ids.TableID != arrowutils.AbsentFieldID {
+		ID, err := arrowutils.U16FromRecord(record, ids.TableID, row)
+		if err != nil {
+			return false, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		return tracker.IsNewID(ID), nil
+	}
+
+	ID, err := NullableScopeIDFromRecord(record, row, ids)
+	if err != nil {
+		return false, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+	}
+	return tracker.IsNew(ID), nil
+}
+
 func UpdateScopeFromRecord(
 	s pcommon.InstrumentationScope,
 	record arrow.Record,
 	row int,
 	ids *ScopeIds,
 	attrsStore *Attributes16Store,
+	scopeTable *ScopeTable,
 ) error {
+	if ids.TableID != arrowutils.AbsentFieldID {
+		ID, err := arrowutils.U16FromRecord(record, ids.TableID, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		if !scopeTable.CopyTo(ID, s) {
+			return werror.WrapWithContext(ErrUnknownScopeID, map[string]interface{}{"row": row, "scope_id": ID})
+		}
+		return nil
+	}
+
 	scopeArray, err := arrowutils.StructFromRecord(record, ids.Scope, row)
 	if err != nil {
 		return werror.WrapWithContext(err, map[string]interface{}{"row": row})
@@ -98,3 +137,14 @@ func ScopeIDFromRecord(record arrow.Record, row int, IDs *ScopeIds) (uint16, err
 	return arrowutils.U16FromStruct(scopeStruct, row, IDs.ID)
 
 }
+
+// NullableScopeIDFromRecord returns the delta-encoded attributes ID of the
+// scope of the given row, or nil if the scope has no attributes (see
+// EntryTracker).
+func NullableScopeIDFromRecord(record arrow.Record, row int, IDs *ScopeIds) (*uint16, error) {
+	scopeStruct, err := arrowutils.StructFromRecord(record, IDs.Scope, row)
+	if err != nil {
+		return nil, err
+	}
+	return arrowutils.NullableU16FromStruct(scopeStruct, row, IDs.ID)
+}
@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// ScopeTable is the set of distinct scopes of a batch, referenced by ID from
+// the main record (see config.WithScopeTable).
+type ScopeTable struct {
+	scopesByID map[uint16]pcommon.InstrumentationScope
+}
+
+// ScopeTableFrom creates a ScopeTable from a SCOPES record, the attributes of
+// the scopes are looked up by scope ID in the given store.
+// Note: This function consume the record.
+func ScopeTableFrom(record arrow.Record, attrsStore *Attributes16Store) (*ScopeTable, error) {
+	defer record.Release()
+
+	schema := record.Schema()
+	IDField, err := arrowutils.MandatoryFieldIDFromSchema(schema, constants.ID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	nameID, err := arrowutils.FieldIDFromSchema(schema, constants.Name)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	versionID, err := arrowutils.FieldIDFromSchema(schema, constants.Version)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	droppedAttributesCountID, err := arrowutils.FieldIDFromSchema(schema, constants.DroppedAttributesCount)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	// IDs are delta-encoded, they are decoded in bulk.
+	IDs, err := arrowutils.DeltaDecodedU16FromRecord(record, IDField)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	table := &ScopeTable{
+		scopesByID: make(map[uint16]pcommon.InstrumentationScope, len(IDs)),
+	}
+
+	for row, ID := range IDs {
+		name, err := arrowutils.StringFromRecord(record, nameID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		version, err := arrowutils.StringFromRecord(record, versionID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		droppedAttributesCount, err := arrowutils.U32FromRecord(record, droppedAttributesCountID, row)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+
+		scope := pcommon.NewInstrumentationScope()
+		scope.SetName(name)
+		scope.SetVersion(version)
+		scope.SetDroppedAttributesCount(droppedAttributesCount)
+		if attrs := attrsStore.AttributesByID(ID); attrs != nil {
+			attrs.CopyTo(scope.Attributes())
+		}
+		table.scopesByID[ID] = scope
+	}
+
+	return table, nil
+}
+
+// CopyTo copies the scope with the given ID to dest. It returns false if the
+// table doesn't contain this ID.
+func (t *ScopeTable) CopyTo(ID uint16, dest pcommon.InstrumentationScope) bool {
+	if t == nil {
+		return false
+	}
+	scope, ok := t.scopesByID[ID]
+	if !ok {
+		return false
+	}
+	scope.CopyTo(dest)
+	return true
+}
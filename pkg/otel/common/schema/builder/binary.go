@@ -42,6 +42,8 @@ func (b *BinaryBuilder) Append(value []byte) {
 
 		switch builder := b.builder.(type) {
 		case *array.BinaryBuilder:
+			b.appendBinary(builder, value)
+		case *array.LargeBinaryBuilder:
 			builder.Append(value)
 		case *array.BinaryDictionaryBuilder:
 			if err := builder.Append(value); err != nil {
@@ -72,6 +74,8 @@ func (b *BinaryBuilder) AppendNonNil(value []byte) {
 
 		switch builder := b.builder.(type) {
 		case *array.BinaryBuilder:
+			b.appendBinary(builder, value)
+		case *array.LargeBinaryBuilder:
 			builder.Append(value)
 		case *array.BinaryDictionaryBuilder:
 			if err := builder.Append(value); err != nil {
@@ -102,6 +106,20 @@ func (b *BinaryBuilder) AppendNull() {
 	}
 }
 
+// appendBinary appends a value to a Binary builder or, if the data of the
+// current batch is getting close to the int32 offset limit, requests the
+// promotion of the field to LargeBinary. In the latter case a null is
+// appended instead as the batch will be rebuilt with the new schema (or
+// dropped with the LargeValuesError policy).
+func (b *BinaryBuilder) appendBinary(builder *array.BinaryBuilder, value []byte) {
+	if b.transformNode.ExceedsLargeValueThreshold(builder.DataLen(), len(value)) {
+		b.transformNode.PromoteToLarge()
+		builder.AppendNull()
+		return
+	}
+	builder.Append(value)
+}
+
 // FixedSizeBinaryBuilder is a wrapper around the arrow FixedSizeBinaryBuilder.
 type FixedSizeBinaryBuilder struct {
 	builder       array.Builder
@@ -0,0 +1,113 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package builder
+
+// Checkpoint and rollback of a record builder.
+//
+// An entity builder appends a pdata batch column by column, so an error in the
+// middle of a batch leaves some columns (and struct children) longer than the
+// others. Such a builder can't produce a valid record anymore, and the rows
+// left behind would be mixed with the rows of the next batch. Arrow builders
+// can't be truncated, so the rollback replaces the underlying record builder
+// with an empty one with the same schema.
+//
+// The rows appended before a checkpoint are preserved by moving them out of
+// the record builder when the checkpoint is taken: they are kept as pending
+// records and concatenated in front of the rows of the builder by NewRecord.
+// The pending records are dropped with the rows of the builder when the schema
+// is updated, the batch being appended again in this case.
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Checkpoint marks the current state of the builder as the state restored by
+// the next call to Rollback.
+func (rb *RecordBuilderExt) Checkpoint() {
+	if rb.rows() > 0 {
+		rb.pending = append(rb.pending, rb.recordBuilder.NewRecord())
+	}
+}
+
+// Rollback discards the rows appended since the last checkpoint. The
+// underlying array builders are replaced, so the builders returned by the
+// XXXBuilder methods before the rollback must be retrieved again.
+func (rb *RecordBuilderExt) Rollback() {
+	newRecBuilder := array.NewRecordBuilder(rb.allocator, rb.recordBuilder.Schema())
+	rb.recordBuilder.Release()
+	rb.recordBuilder = newRecBuilder
+	rb.stats.RecordBuilderStats.Rollbacks++
+}
+
+// rows returns the number of rows of the builder, i.e. the length of its
+// first column.
+func (rb *RecordBuilderExt) rows() int {
+	if len(rb.recordBuilder.Fields()) == 0 {
+		return 0
+	}
+	return rb.recordBuilder.Field(0).Len()
+}
+
+// withPending returns the concatenation of the pending records and the given
+// record, built from the rows appended after the last checkpoint. The input
+// records are released.
+func (rb *RecordBuilderExt) withPending(record arrow.Record) (arrow.Record, error) {
+	records := append(rb.pending, record)
+	rb.pending = nil
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+
+	var rows int64
+	for _, r := range records {
+		rows += r.NumRows()
+	}
+
+	columns := make([]arrow.Array, 0, record.NumCols())
+	defer func() {
+		for _, column := range columns {
+			column.Release()
+		}
+	}()
+	chunks := make([]arrow.Array, len(records))
+	for i := 0; i < int(record.NumCols()); i++ {
+		for j, r := range records {
+			chunks[j] = r.Column(i)
+		}
+		column, err := array.Concatenate(chunks, rb.allocator)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"field": record.ColumnName(i)})
+		}
+		columns = append(columns, column)
+	}
+
+	return array.NewRecord(record.Schema(), columns, rows), nil
+}
+
+// releasePending releases the pending records.
+func (rb *RecordBuilderExt) releasePending() {
+	for _, r := range rb.pending {
+		r.Release()
+	}
+	rb.pending = nil
+}
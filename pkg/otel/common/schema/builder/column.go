@@ -0,0 +1,219 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package builder
+
+// This file implements the appending of whole Arrow arrays into the builders.
+// This is an advanced API for the integrations already collecting their data
+// in a columnar form (e.g. a scraper collecting values into slices), which can
+// skip the construction of the intermediate pdata entities.
+//
+// The arrays follow the same rules as the values appended one by one, i.e.
+// the values of an array appended to an optional field missing from the
+// schema trigger a schema update, and the batch must be rebuilt once the
+// schema is updated (see RecordBuilderExt.NewRecord).
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/update"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// AppendArray appends all the values of a Uint8 array.
+func (b *Uint8Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Uint8)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Uint8, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Uint8Values())
+}
+
+// AppendArray appends all the values of a Uint16 array.
+func (b *Uint16Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Uint16)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Uint16, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Uint16Values())
+}
+
+// AppendArray appends all the values of a Uint32 array.
+func (b *Uint32Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Uint32)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Uint32, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Uint32Values())
+}
+
+// AppendArray appends all the values of a Uint64 array.
+func (b *Uint64Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Uint64)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Uint64, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Uint64Values())
+}
+
+// AppendArray appends all the values of an Int32 array.
+func (b *Int32Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Int32)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Int32, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Int32Values())
+}
+
+// AppendArray appends all the values of an Int64 array.
+func (b *Int64Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Int64)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Int64, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Int64Values())
+}
+
+// AppendArray appends all the values of a Float64 array.
+func (b *Float64Builder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Float64)
+	if !ok {
+		return arrayTypeMismatch(arrow.PrimitiveTypes.Float64, arr)
+	}
+	return appendArray(b.builder, b.transformNode, b.updateRequest, typed, typed.Float64Values())
+}
+
+// AppendArray appends all the values of a Timestamp array of the same unit
+// as the field.
+func (b *TimestampBuilder) AppendArray(arr arrow.Array) error {
+	typed, ok := arr.(*array.Timestamp)
+	if !ok {
+		return arrayTypeMismatch(nil, arr)
+	}
+	// A nil *array.TimestampBuilder must not be converted into a non-nil
+	// array.Builder.
+	var builder array.Builder
+	if b.builder != nil {
+		builder = b.builder
+	}
+	return appendArray(builder, b.transformNode, b.updateRequest, typed, typed.TimestampValues())
+}
+
+// AppendArray appends all the values of a String array. As for Append, the
+// empty strings are appended as nulls.
+func (b *StringBuilder) AppendArray(arr arrow.Array) error {
+	strings, ok := arr.(*array.String)
+	if !ok {
+		return arrayTypeMismatch(arrow.BinaryTypes.String, arr)
+	}
+
+	if b.builder == nil {
+		for i := 0; i < strings.Len(); i++ {
+			if strings.IsValid(i) {
+				// The first value requests a schema update.
+				b.Append(strings.Value(i))
+				break
+			}
+		}
+		return nil
+	}
+
+	for i := 0; i < strings.Len(); i++ {
+		if strings.IsNull(i) {
+			b.AppendNull()
+		} else {
+			b.Append(strings.Value(i))
+		}
+	}
+	return nil
+}
+
+// appendArray appends the values of arr (i.e. values and the validity of arr)
+// to the builder of a field, or requests a schema update if the builder is nil
+// (i.e. optional field not present in the schema) and arr contains non-zero
+// values.
+func appendArray[T comparable](b array.Builder, transformNode *schema.TransformNode, updateRequest *update.SchemaUpdateRequest, arr arrow.Array, values []T) error {
+	if b == nil {
+		var zero T
+		for i, value := range values {
+			if value != zero && arr.IsValid(i) {
+				// If the builder is nil, then the transform node is not optional.
+				transformNode.RemoveOptional()
+				updateRequest.Inc()
+				break
+			}
+		}
+		return nil
+	}
+
+	switch builder := b.(type) {
+	case array.DictionaryBuilder:
+		valueType := builder.Type().(*arrow.DictionaryType).ValueType
+		if !arrow.TypeEqual(valueType, arr.DataType()) {
+			return arrayTypeMismatch(valueType, arr)
+		}
+		if err := builder.AppendArray(arr); err != nil {
+			return werror.Wrap(err)
+		}
+	case *array.RunEndEncodedBuilder:
+		valueType := builder.Type().(*arrow.RunEndEncodedType).Encoded()
+		if !arrow.TypeEqual(valueType, arr.DataType()) {
+			return arrayTypeMismatch(valueType, arr)
+		}
+		var r run[T]
+		for i, value := range values {
+			if arr.IsNull(i) {
+				r.appendNull(builder)
+			} else {
+				r.append(builder, value)
+			}
+		}
+	case interface{ AppendValues([]T, []bool) }:
+		if !arrow.TypeEqual(b.Type(), arr.DataType()) {
+			return arrayTypeMismatch(b.Type(), arr)
+		}
+		builder.AppendValues(values, validity(arr))
+	default:
+		// Should never happen.
+		panic("unknown builder type")
+	}
+	return nil
+}
+
+// validity returns the validity of the values of arr as expected by the
+// AppendValues methods of the Arrow builders, nil if all the values are
+// valid.
+func validity(arr arrow.Array) []bool {
+	if arr.NullN() == 0 {
+		return nil
+	}
+	valid := make([]bool, arr.Len())
+	for i := range valid {
+		valid[i] = arr.IsValid(i)
+	}
+	return valid
+}
+
+func arrayTypeMismatch(expected arrow.DataType, arr arrow.Array) error {
+	context := map[string]interface{}{"array_type": arr.DataType().String()}
+	if expected != nil {
+		context["builder_type"] = expected.String()
+	}
+	return werror.WrapWithContext(schema.ErrArrayTypeMismatch, context)
+}
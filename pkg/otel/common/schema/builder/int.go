@@ -29,6 +29,9 @@ type Int32Builder struct {
 	builder       array.Builder
 	transformNode *schema.TransformNode
 	updateRequest *update.SchemaUpdateRequest
+
+	// run tracks the current run of run-end encoded fields.
+	run run[int32]
 }
 
 // Append appends a value to the underlying builder and updates the
@@ -43,6 +46,8 @@ func (b *Int32Builder) Append(value int32) {
 				// Should never happen.
 				panic(err)
 			}
+		case *array.RunEndEncodedBuilder:
+			b.run.append(builder, value)
 		default:
 			// Should never happen.
 			panic("unknown builder type")
@@ -62,7 +67,7 @@ func (b *Int32Builder) Append(value int32) {
 func (b *Int32Builder) AppendNonZero(value int32) {
 	if b.builder != nil {
 		if value == 0 {
-			b.builder.AppendNull()
+			b.appendNull()
 			return
 		}
 
@@ -74,6 +79,8 @@ func (b *Int32Builder) AppendNonZero(value int32) {
 				// Should never happen.
 				panic(err)
 			}
+		case *array.RunEndEncodedBuilder:
+			b.run.append(builder, value)
 		default:
 			// Should never happen.
 			panic("unknown builder type")
@@ -94,9 +101,17 @@ func (b *Int32Builder) AppendNonZero(value int32) {
 // in the data.
 func (b *Int32Builder) AppendNull() {
 	if b.builder != nil {
-		b.builder.AppendNull()
+		b.appendNull()
+		return
+	}
+}
+
+func (b *Int32Builder) appendNull() {
+	if builder, ok := b.builder.(*array.RunEndEncodedBuilder); ok {
+		b.run.appendNull(builder)
 		return
 	}
+	b.builder.AppendNull()
 }
 
 // Int64Builder is a wrapper around the arrow Int64Builder.
@@ -266,9 +266,10 @@ func (b *MapBuilder) KeySparseUnionBuilder() *SparseUnionBuilder {
 }
 
 func (b *MapBuilder) ItemStringBuilder() *StringBuilder {
-	var valueBuilder *array.StringBuilder
+	// The item builder may have been promoted to its large type.
+	var valueBuilder array.Builder
 	if b.builder != nil {
-		valueBuilder = b.builder.ItemBuilder().(*array.StringBuilder)
+		valueBuilder = b.builder.ItemBuilder()
 	}
 
 	return NewStringBuilder(
@@ -279,9 +280,9 @@ func (b *MapBuilder) ItemStringBuilder() *StringBuilder {
 }
 
 func (b *MapBuilder) ItemBinaryBuilder() *BinaryBuilder {
-	var valueBuilder *array.BinaryBuilder
+	var valueBuilder array.Builder
 	if b.builder != nil {
-		valueBuilder = b.builder.ItemBuilder().(*array.BinaryBuilder)
+		valueBuilder = b.builder.ItemBuilder()
 	}
 
 	return &BinaryBuilder{
@@ -0,0 +1,181 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package builder
+
+// Telemetry and deactivation policy for optional columns.
+//
+// Optional columns are added to the schema the first time a non-null value is
+// appended. Without a deactivation policy they stay in the schema for the
+// lifetime of the stream, even if the corresponding field disappears from the
+// data.
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+)
+
+// OptionalColumn describes the state of an optional column of a record
+// builder.
+type OptionalColumn struct {
+	// Path is the dot-separated path of the column in the prototype schema.
+	Path string
+	// Active is true if the column is currently part of the schema.
+	Active bool
+	// NullRatio is the ratio of null values observed in the last batch
+	// containing this column (0 if the column has never been active).
+	NullRatio float64
+	// Rows is the total number of rows observed for this column.
+	Rows uint64
+	// Nulls is the total number of null values observed for this column.
+	Nulls uint64
+	// AllNullBatches is the number of consecutive batches in which the column
+	// has only contained null values.
+	AllNullBatches int
+}
+
+// SetOptionalColumnDeactivation sets the number of consecutive batches in
+// which an active optional column must only contain null values before being
+// removed from the schema. 0 (the default) disables the deactivation.
+func (rb *RecordBuilderExt) SetOptionalColumnDeactivation(batches int) {
+	rb.deactivateAfter = batches
+}
+
+// OptionalColumns returns the state of all the optional columns of the
+// prototype schema, in schema order.
+func (rb *RecordBuilderExt) OptionalColumns() []OptionalColumn {
+	var columns []OptionalColumn
+	for _, node := range rb.transformTree.Children {
+		columns = rb.appendOptionalColumns(columns, "", node)
+	}
+	return columns
+}
+
+func (rb *RecordBuilderExt) appendOptionalColumns(columns []OptionalColumn, path string, node *schema.TransformNode) []OptionalColumn {
+	path = joinPath(path, node.Name())
+
+	if node.IsOptional() {
+		column := OptionalColumn{Path: path, Active: node.IsActive()}
+		if stats, ok := rb.optionalColumns[path]; ok {
+			column.NullRatio = stats.NullRatio
+			column.Rows = stats.Rows
+			column.Nulls = stats.Nulls
+			column.AllNullBatches = stats.AllNullBatches
+		}
+		columns = append(columns, column)
+	}
+
+	for _, child := range node.Children {
+		columns = rb.appendOptionalColumns(columns, path, child)
+	}
+	return columns
+}
+
+// trackOptionalColumns updates the null statistics of the active optional
+// columns of the record and deactivates the ones that have been null for too
+// long. A deactivation triggers a schema update request.
+func (rb *RecordBuilderExt) trackOptionalColumns(record arrow.Record) {
+	// The batch following a deactivation is the same batch rebuilt with the
+	// new schema, it must not be counted twice.
+	if rb.optionalColumnsRebuild {
+		rb.optionalColumnsRebuild = false
+		return
+	}
+
+	fields := record.Schema().Fields()
+	for i := range fields {
+		node := rb.transformTree.Child(fields[i].Name)
+		if node != nil {
+			rb.trackOptionalColumn("", &fields[i], node, record.Column(i))
+		}
+	}
+}
+
+func (rb *RecordBuilderExt) trackOptionalColumn(path string, field *arrow.Field, node *schema.TransformNode, column arrow.Array) {
+	path = joinPath(path, field.Name)
+
+	if node.IsOptional() && column.Len() > 0 {
+		stats, ok := rb.optionalColumns[path]
+		if !ok {
+			stats = &OptionalColumn{Path: path}
+			rb.optionalColumns[path] = stats
+		}
+		nulls := nullCount(column)
+		stats.Rows += uint64(column.Len())
+		stats.Nulls += uint64(nulls)
+		stats.NullRatio = float64(nulls) / float64(column.Len())
+
+		if nulls == column.Len() {
+			stats.AllNullBatches++
+		} else {
+			stats.AllNullBatches = 0
+		}
+
+		if rb.deactivateAfter > 0 && stats.AllNullBatches >= rb.deactivateAfter && node.DeactivateOptional() {
+			stats.AllNullBatches = 0
+			rb.optionalColumnsRebuild = true
+			rb.updateRequest.Inc()
+			rb.stats.RecordBuilderStats.OptionalColumnsDeactivated++
+			return
+		}
+	}
+
+	switch dt := field.Type.(type) {
+	case *arrow.StructType:
+		structColumn := column.(*array.Struct)
+		for i, subField := range dt.Fields() {
+			if child := node.Child(subField.Name); child != nil {
+				rb.trackOptionalColumn(path, &subField, child, structColumn.Field(i))
+			}
+		}
+	case *arrow.ListType:
+		elemField := dt.ElemField()
+		if len(node.Children) == 1 {
+			rb.trackOptionalColumn(path, &elemField, node.Children[0], column.(*array.List).ListValues())
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+// nullCount returns the number of null values of the column. The nulls of a
+// run-end encoded column are carried by its values.
+func nullCount(column arrow.Array) int {
+	ree, ok := column.(*array.RunEndEncoded)
+	if !ok {
+		return column.NullN()
+	}
+
+	values := ree.Values()
+	if values.NullN() == 0 {
+		return 0
+	}
+	nulls := 0
+	for i := 0; i < ree.Len(); i++ {
+		if values.IsNull(ree.GetPhysicalIndex(i)) {
+			nulls++
+		}
+	}
+	return nulls
+}
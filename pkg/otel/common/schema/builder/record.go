@@ -19,6 +19,7 @@ package builder
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/apache/arrow/go/v12/arrow"
 	"github.com/apache/arrow/go/v12/arrow/array"
@@ -57,6 +58,9 @@ type RecordBuilderExt struct {
 	// Map dictId to transform node
 	dictTransformNodes map[string]*transform.DictionaryField
 
+	// The large value configuration shared by the Utf8 and Binary fields.
+	largeValues *builder.LargeValues
+
 	// The pending schema update requests.
 	updateRequest *update.SchemaUpdateRequest
 
@@ -68,6 +72,26 @@ type RecordBuilderExt struct {
 	// stats is a set of counters that are incremented when certain events occur.
 	stats *stats.ProducerStats
 
+	// reserveStats is used to pre-size the builders from the previous
+	// batches.
+	reserveStats *reserveStats
+
+	// optionalColumns keeps the null statistics of the optional columns
+	// (see OptionalColumns).
+	optionalColumns map[string]*OptionalColumn
+
+	// deactivateAfter is the number of consecutive all-null batches after
+	// which an optional column is removed from the schema (0 = never).
+	deactivateAfter int
+
+	// optionalColumnsRebuild is true when the current batch is rebuilt after
+	// the deactivation of optional columns.
+	optionalColumnsRebuild bool
+
+	// pending are the rows moved out of the record builder by the checkpoints
+	// (see Checkpoint), they precede the rows of the record builder.
+	pending []arrow.Record
+
 	// Label is a string that is used to identify the source of the data.
 	// [optional].
 	label string
@@ -85,8 +109,11 @@ func NewRecordBuilderExt(
 	evts := &events.Events{
 		DictionariesWithOverflow:     make(map[string]bool),
 		DictionariesIndexTypeChanged: make(map[string]string),
+		DictionariesReset:            make(map[string]bool),
+		ValuesPromotedToLarge:        make(map[string]bool),
 	}
-	transformTree, dictTransformNodes := schema.NewTransformTreeFrom(protoSchema, dictConfig, schemaUpdateRequest, evts)
+	largeValues := builder.NewLargeValues(builder.LargeValuesPromote, 0)
+	transformTree, dictTransformNodes := schema.NewTransformTreeFrom(protoSchema, dictConfig, largeValues, schemaUpdateRequest, evts)
 	s := schema.NewSchemaFrom(protoSchema, transformTree)
 	schemaID := carrow.SchemaToID(s)
 	recordBuilder := array.NewRecordBuilder(allocator, s)
@@ -97,10 +124,13 @@ func NewRecordBuilderExt(
 		protoSchema:        protoSchema,
 		transformTree:      transformTree,
 		dictTransformNodes: dictTransformNodes,
+		largeValues:        largeValues,
 		updateRequest:      schemaUpdateRequest,
 		schemaID:           schemaID,
 		events:             evts,
 		stats:              stats,
+		reserveStats:       newReserveStats(),
+		optionalColumns:    make(map[string]*OptionalColumn),
 	}
 }
 
@@ -112,6 +142,14 @@ func (rb *RecordBuilderExt) SetLabel(label string) {
 	rb.label = label
 }
 
+// SetLargeValues sets the policy applied to the Utf8 and Binary fields whose
+// data exceeds the given threshold in a single batch (0 = just below the
+// int32 offset limit, see builder.LargeValues). Fields already promoted to
+// their large type keep it.
+func (rb *RecordBuilderExt) SetLargeValues(policy builder.LargeValuesPolicy, threshold int) {
+	*rb.largeValues = *builder.NewLargeValues(policy, threshold)
+}
+
 func (rb *RecordBuilderExt) Events() *events.Events {
 	return rb.events
 }
@@ -124,11 +162,8 @@ func (rb *RecordBuilderExt) Schema() *arrow.Schema {
 	return rb.recordBuilder.Schema()
 }
 
-func (rb *RecordBuilderExt) Reserve(size int) {
-	rb.recordBuilder.Reserve(size)
-}
-
 func (rb *RecordBuilderExt) Release() {
+	rb.releasePending()
 	rb.recordBuilder.Release()
 }
 
@@ -147,7 +182,18 @@ func (rb *RecordBuilderExt) NewRecord() (arrow.Record, error) {
 		return nil, werror.Wrap(schema.ErrSchemaNotUpToDate)
 	}
 
+	rb.collectReserveStats()
 	record := rb.recordBuilder.NewRecord()
+	if len(rb.pending) > 0 {
+		var err error
+		if record, err = rb.withPending(record); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	// Collect the fields that have exceeded the large value threshold with
+	// the LargeValuesError policy, the values of the batch are incomplete.
+	largeValuePaths := rb.transformTree.TakeLargeValueOverflows(nil)
 
 	// Detect dictionary overflow
 	fields := rb.recordBuilder.Schema().Fields()
@@ -156,7 +202,27 @@ func (rb *RecordBuilderExt) NewRecord() (arrow.Record, error) {
 		rb.detectDictionaryOverflow(&fields[fieldIdx], columns[fieldIdx])
 	}
 
-	// If dictionary overflow is detected, update the schema
+	// Dictionaries with the OverflowError policy fail the batch, the builder
+	// is rebuilt so the next batch starts with new dictionaries.
+	if paths := rb.overflowedDictionaries(); len(paths) > 0 {
+		record.Release()
+		rb.UpdateSchema()
+		return nil, werror.WrapWithContext(schema.ErrDictionaryOverflow, map[string]interface{}{"paths": paths})
+	}
+
+	if len(largeValuePaths) > 0 {
+		record.Release()
+		return nil, werror.WrapWithContext(schema.ErrValueTooLarge, map[string]interface{}{"paths": largeValuePaths})
+	}
+
+	// Track the null ratio of the optional columns, this may deactivate some
+	// of them.
+	if rb.IsSchemaUpToDate() {
+		rb.trackOptionalColumns(record)
+	}
+
+	// If dictionary overflow is detected or optional columns have been
+	// deactivated, update the schema
 	if !rb.IsSchemaUpToDate() {
 		record.Release()
 		rb.UpdateSchema()
@@ -214,6 +280,19 @@ func (rb *RecordBuilderExt) detectDictionaryOverflow(field *arrow.Field, column
 	}
 }
 
+// overflowedDictionaries returns the sorted paths of the dictionaries that
+// have overflowed with the OverflowError policy.
+func (rb *RecordBuilderExt) overflowedDictionaries() []string {
+	var paths []string
+	for _, dictTransform := range rb.dictTransformNodes {
+		if dictTransform.TakeOverflow() {
+			paths = append(paths, dictTransform.Path())
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
 func (rb *RecordBuilderExt) IsSchemaUpToDate() bool {
 	return rb.updateRequest.Count() == 0
 }
@@ -259,6 +338,7 @@ func (rb *RecordBuilderExt) UpdateSchema() {
 	//	panic(err)
 	//}
 
+	rb.releasePending()
 	rb.recordBuilder.Release()
 	rb.recordBuilder = newRecBuilder
 	rb.schemaID = carrow.SchemaToID(s)
@@ -802,6 +882,11 @@ func (rb *RecordBuilderExt) VisitDataType(builder array.Builder, dt arrow.DataTy
 		fmt.Printf(">")
 	case *arrow.FixedSizeBinaryType:
 		fmt.Printf("FixedSizeBinary<%d>", t.ByteWidth)
+	case *arrow.RunEndEncodedType:
+		reeBuilder := builder.(*array.RunEndEncodedBuilder)
+		fmt.Printf("RunEndEncoded<")
+		rb.VisitDataType(reeBuilder.ValueBuilder(), t.Encoded(), "", prefix)
+		fmt.Printf(">")
 	default:
 		panic("unsupported data type " + dt.String())
 	}
@@ -0,0 +1,158 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package builder
+
+// Pre-sizing of the child builders of a record builder.
+//
+// Arrow builders double their capacity each time they are full, which means a
+// batch of N rows built from empty builders costs log2(N) reallocations and
+// copies per column. The top-level columns are reserved by the caller with the
+// exact number of rows of the batch, struct children share the same number of
+// rows, and list values are reserved from a rolling average of the number of
+// items per row observed in the previous batches.
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+)
+
+const (
+	// minBuilderCapacity is the initial capacity allocated by Arrow builders
+	// when they are not reserved.
+	minBuilderCapacity = 32
+
+	// itemsPerRowSmoothing is the weight of the last batch in the rolling
+	// average of items per row.
+	itemsPerRowSmoothing = 0.5
+)
+
+// reserveStats keeps, per builder path, the rolling statistics used to
+// pre-size the builders and the capacity reserved for the current batch.
+type reserveStats struct {
+	// itemsPerRow is the rolling average of items per parent row of list
+	// value builders.
+	itemsPerRow map[string]float64
+
+	// reserved is the capacity reserved for the current batch.
+	reserved map[string]int
+}
+
+func newReserveStats() *reserveStats {
+	return &reserveStats{
+		itemsPerRow: make(map[string]float64),
+		reserved:    make(map[string]int),
+	}
+}
+
+// Reserve reserves capacity for size rows in all the builders of the record,
+// including the nested struct and list builders.
+func (rb *RecordBuilderExt) Reserve(size int) {
+	rb.recordBuilder.Reserve(size)
+
+	fields := rb.recordBuilder.Schema().Fields()
+	for i := range fields {
+		b := rb.recordBuilder.Field(i)
+		rb.reserveStats.reserved[fields[i].Name] = b.Len() + size
+		rb.reserveChildren(fields[i].Name, b, size)
+	}
+}
+
+func (rb *RecordBuilderExt) reserveChildren(path string, b array.Builder, size int) {
+	switch b := b.(type) {
+	case *array.StructBuilder:
+		fields := b.Type().(*arrow.StructType).Fields()
+		for i := range fields {
+			child := b.FieldBuilder(i)
+			childPath := path + "." + fields[i].Name
+			child.Reserve(size)
+			rb.reserveStats.reserved[childPath] = child.Len() + size
+			rb.reserveChildren(childPath, child, size)
+		}
+	case *array.ListBuilder:
+		itemsPerRow, ok := rb.reserveStats.itemsPerRow[path]
+		if !ok {
+			return
+		}
+		items := int(itemsPerRow * float64(size))
+		if items == 0 {
+			return
+		}
+		values := b.ValueBuilder()
+		valuesPath := path + "[]"
+		values.Reserve(items)
+		rb.reserveStats.reserved[valuesPath] = values.Len() + items
+		rb.reserveChildren(valuesPath, values, items)
+	}
+}
+
+// collectReserveStats updates the rolling statistics from the builders of the
+// current batch and counts the reallocations that the reservations didn't
+// avoid. Must be called before the builders are reset by NewRecord.
+func (rb *RecordBuilderExt) collectReserveStats() {
+	fields := rb.recordBuilder.Schema().Fields()
+	for i := range fields {
+		rb.collectBuilderStats(fields[i].Name, rb.recordBuilder.Field(i))
+	}
+
+	for path := range rb.reserveStats.reserved {
+		delete(rb.reserveStats.reserved, path)
+	}
+}
+
+func (rb *RecordBuilderExt) collectBuilderStats(path string, b array.Builder) {
+	rb.countReallocations(path, b.Len())
+
+	switch b := b.(type) {
+	case *array.StructBuilder:
+		fields := b.Type().(*arrow.StructType).Fields()
+		for i := range fields {
+			rb.collectBuilderStats(path+"."+fields[i].Name, b.FieldBuilder(i))
+		}
+	case *array.ListBuilder:
+		values := b.ValueBuilder()
+		if b.Len() > 0 {
+			itemsPerRow := float64(values.Len()) / float64(b.Len())
+			if prev, ok := rb.reserveStats.itemsPerRow[path]; ok {
+				itemsPerRow = itemsPerRowSmoothing*itemsPerRow + (1-itemsPerRowSmoothing)*prev
+			}
+			rb.reserveStats.itemsPerRow[path] = itemsPerRow
+		}
+		rb.collectBuilderStats(path+"[]", values)
+	}
+}
+
+// countReallocations estimates the number of times the builder identified by
+// path has grown beyond its reserved capacity while building the current
+// batch. Arrow builders double their capacity on each reallocation.
+func (rb *RecordBuilderExt) countReallocations(path string, length int) {
+	if length == 0 {
+		return
+	}
+
+	capacity := rb.reserveStats.reserved[path]
+	if capacity == 0 {
+		capacity = minBuilderCapacity
+	} else {
+		rb.stats.RecordBuilderStats.BuilderReservations++
+	}
+
+	for capacity < length {
+		capacity *= 2
+		rb.stats.RecordBuilderStats.BuilderReallocations++
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package builder
+
+import (
+	"github.com/apache/arrow/go/v12/arrow/array"
+)
+
+// run tracks the last run appended to a run-end encoded builder so that
+// consecutive equal values (or nulls) extend the current run instead of
+// starting a new one.
+type run[T comparable] struct {
+	// runs is the number of runs of the builder after the last append. The
+	// last run can only be extended if the builder has not been reset (i.e.
+	// a record has been built) since.
+	runs  int
+	value T
+	valid bool
+}
+
+// open returns true if the last run of the builder has been appended by this
+// run tracker.
+func (r *run[T]) open(b *array.RunEndEncodedBuilder) bool {
+	runs := b.ValueBuilder().Len()
+	return runs > 0 && runs == r.runs
+}
+
+// append appends a value to the run-end encoded builder.
+func (r *run[T]) append(b *array.RunEndEncodedBuilder, value T) {
+	if r.open(b) && r.valid && r.value == value {
+		b.ContinueRun(1)
+		return
+	}
+
+	values, ok := b.ValueBuilder().(interface{ Append(T) })
+	if !ok {
+		// Should never happen.
+		panic("unknown run-end encoded value builder type")
+	}
+	b.Append(1)
+	values.Append(value)
+	r.runs = b.ValueBuilder().Len()
+	r.value = value
+	r.valid = true
+}
+
+// appendNull appends a null to the run-end encoded builder.
+func (r *run[T]) appendNull(b *array.RunEndEncodedBuilder) {
+	if r.open(b) && !r.valid {
+		b.ContinueRun(1)
+		return
+	}
+
+	b.AppendNull()
+	r.runs = b.ValueBuilder().Len()
+	r.valid = false
+}
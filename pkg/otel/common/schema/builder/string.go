@@ -57,6 +57,8 @@ func (b *StringBuilder) Append(value string) {
 
 		switch builder := b.builder.(type) {
 		case *array.StringBuilder:
+			b.appendString(builder, value)
+		case *array.LargeStringBuilder:
 			builder.Append(value)
 		case *array.BinaryDictionaryBuilder:
 			if err := builder.AppendString(value); err != nil {
@@ -84,6 +86,8 @@ func (b *StringBuilder) AppendNonEmpty(value string) {
 
 		switch builder := b.builder.(type) {
 		case *array.StringBuilder:
+			b.appendString(builder, value)
+		case *array.LargeStringBuilder:
 			builder.Append(value)
 		case *array.BinaryDictionaryBuilder:
 			if err := builder.AppendString(value); err != nil {
@@ -103,3 +107,17 @@ func (b *StringBuilder) AppendNonEmpty(value string) {
 		b.updateRequest.Inc()
 	}
 }
+
+// appendString appends a value to a Utf8 builder or, if the data of the
+// current batch is getting close to the int32 offset limit, requests the
+// promotion of the field to LargeUtf8. In the latter case a null is appended
+// instead as the batch will be rebuilt with the new schema (or dropped with
+// the LargeValuesError policy).
+func (b *StringBuilder) appendString(builder *array.StringBuilder, value string) {
+	if b.transformNode.ExceedsLargeValueThreshold(builder.DataLen(), len(value)) {
+		b.transformNode.PromoteToLarge()
+		builder.AppendNull()
+		return
+	}
+	builder.Append(value)
+}
@@ -31,6 +31,9 @@ type Uint8Builder struct {
 	builder       array.Builder
 	transformNode *schema.TransformNode
 	updateRequest *update.SchemaUpdateRequest
+
+	// run tracks the current run of run-end encoded fields.
+	run run[uint8]
 }
 
 func (b *Uint8Builder) Append(value uint8) {
@@ -43,6 +46,8 @@ func (b *Uint8Builder) Append(value uint8) {
 				// Should never happen.
 				panic(err)
 			}
+		case *array.RunEndEncodedBuilder:
+			b.run.append(builder, value)
 		default:
 			// Should never happen.
 			panic("unknown builder type")
@@ -69,10 +74,14 @@ func (b *Uint8Builder) AppendNonZero(value uint8) {
 					// Should never happen.
 					panic(err)
 				}
+			case *array.RunEndEncodedBuilder:
+				b.run.append(builder, value)
 			default:
 				// Should never happen.
 				panic("unknown builder type")
 			}
+		} else if builder, ok := b.builder.(*array.RunEndEncodedBuilder); ok {
+			b.run.appendNull(builder)
 		} else {
 			b.builder.AppendNull()
 		}
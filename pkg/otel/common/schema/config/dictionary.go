@@ -17,7 +17,30 @@
 
 package builder
 
-import "math"
+import (
+	"math"
+	"strings"
+)
+
+// OverflowPolicy defines what happens to a dictionary field when its
+// cardinality exceeds the maximum cardinality of its configuration.
+type OverflowPolicy int
+
+const (
+	// OverflowFallback converts the dictionary field to its base type
+	// (default).
+	OverflowFallback OverflowPolicy = iota
+	// OverflowWiden keeps the dictionary encoding and widens its index type up
+	// to uint64, whatever the maximum cardinality.
+	OverflowWiden
+	// OverflowReset keeps the index type and starts a new dictionary. The
+	// field falls back to its base type if the values of a single batch
+	// overflow the index.
+	OverflowReset
+	// OverflowError makes the record builder return ErrDictionaryOverflow
+	// and start a new dictionary.
+	OverflowError
+)
 
 // Dictionary is a configuration for a dictionary field.
 // The MaxCard is the maximum cardinality of the dictionary field. If the
@@ -26,9 +49,16 @@ import "math"
 //
 // if MaxCard is equal to 0, then the dictionary field will be converted to its
 // base type no matter what.
+//
+// Overflow is the policy applied when the cardinality exceeds MaxCard, it can
+// be overridden per field in FieldOverflow, keyed by the dot separated path
+// of the field (e.g. "resource.schema_url").
 type Dictionary struct {
 	MinCard uint64
 	MaxCard uint64
+
+	Overflow      OverflowPolicy
+	FieldOverflow map[string]OverflowPolicy
 }
 
 // NewDictionary creates a new dictionary configuration with the given maximum
@@ -57,7 +87,40 @@ func NewDictionaryFrom(minCard uint64, dicProto *Dictionary) *Dictionary {
 		minCard = dicProto.MaxCard
 	}
 	return &Dictionary{
-		MinCard: minCard,
-		MaxCard: dicProto.MaxCard,
+		MinCard:       minCard,
+		MaxCard:       dicProto.MaxCard,
+		Overflow:      dicProto.Overflow,
+		FieldOverflow: dicProto.FieldOverflow,
+	}
+}
+
+// WithOverflow sets the default overflow policy and the per field overflow
+// policies of the dictionary configuration. The keys of the field policies
+// starting with the given prefix followed by a dot are kept without this
+// prefix (e.g. "spans.name" becomes "name" for the prefix "spans"), the
+// other keys are ignored.
+func (d *Dictionary) WithOverflow(policy OverflowPolicy, prefix string, fieldPolicies map[string]OverflowPolicy) *Dictionary {
+	d.Overflow = policy
+	d.FieldOverflow = nil
+
+	prefix += "."
+	for path, fieldPolicy := range fieldPolicies {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if d.FieldOverflow == nil {
+			d.FieldOverflow = make(map[string]OverflowPolicy)
+		}
+		d.FieldOverflow[strings.TrimPrefix(path, prefix)] = fieldPolicy
+	}
+	return d
+}
+
+// OverflowPolicy returns the overflow policy of the field with the given
+// path.
+func (d *Dictionary) OverflowPolicyOf(path string) OverflowPolicy {
+	if policy, ok := d.FieldOverflow[path]; ok {
+		return policy
 	}
+	return d.Overflow
 }
@@ -0,0 +1,67 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package builder
+
+import "math"
+
+// DefaultLargeValueThreshold is the default number of bytes of string or
+// binary data in a single column of a batch beyond which the column exceeds
+// its Utf8 or Binary type. These types use int32 offsets, so the threshold
+// keeps a safety margin below 2GB.
+const DefaultLargeValueThreshold = math.MaxInt32 - (256 << 20)
+
+// LargeValuesPolicy defines what happens to a Utf8 or Binary field when the
+// data of a batch exceeds the large value threshold.
+type LargeValuesPolicy int
+
+const (
+	// LargeValuesPromote promotes the field to LargeUtf8 or LargeBinary
+	// (int64 offsets) and rebuilds the batch (default). The promotion is
+	// permanent for the lifetime of the record builder.
+	LargeValuesPromote LargeValuesPolicy = iota
+	// LargeValuesError keeps the type of the field and makes the record
+	// builder return ErrValueTooLarge, the batch is dropped.
+	LargeValuesError
+)
+
+// LargeValues is the configuration shared by the Utf8 and Binary fields of a
+// record builder. Threshold is the number of bytes of data of a single column
+// of a batch beyond which Policy is applied, it is capped by
+// DefaultLargeValueThreshold.
+type LargeValues struct {
+	Policy    LargeValuesPolicy
+	Threshold int
+}
+
+// NewLargeValues creates a new large value configuration with the given
+// policy and threshold. A threshold of 0 (or beyond the default) selects
+// DefaultLargeValueThreshold.
+func NewLargeValues(policy LargeValuesPolicy, threshold int) *LargeValues {
+	if threshold <= 0 || threshold > DefaultLargeValueThreshold {
+		threshold = DefaultLargeValueThreshold
+	}
+	return &LargeValues{
+		Policy:    policy,
+		Threshold: threshold,
+	}
+}
+
+// Exceeds returns true if appending a value of valueLen bytes to a column
+// already containing dataLen bytes of data exceeds the threshold.
+func (l *LargeValues) Exceeds(dataLen, valueLen int) bool {
+	return dataLen+valueLen > l.Threshold
+}
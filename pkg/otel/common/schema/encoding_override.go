@@ -0,0 +1,211 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package schema
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v12/arrow"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Encodings that can be forced on a field of a prototype schema (see
+// WithEncodingOverrides).
+const (
+	PlainEncoding         = "plain"
+	Dictionary8Encoding   = "dictionary8"
+	Dictionary16Encoding  = "dictionary16"
+	RunEndEncodedEncoding = RunEndEncodingValue
+)
+
+// ValidateEncodingOverrides checks the overrides of the given map against the
+// prototype schemas, keyed by the prefix of their overrides (e.g. "spans" or
+// "span_events"). Every override must use a supported encoding and apply to
+// a field of a prototype schema, i.e. a dictionary encoded field, or an int32
+// or uint8 field for the run-end encoding. Delta encodings are bound to the
+// record builders and decoders, they can't be overridden.
+func ValidateEncodingOverrides(overrides map[string]string, prototypes map[string]*arrow.Schema) error {
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		encoding := overrides[path]
+		errContext := map[string]interface{}{
+			"path":     path,
+			"encoding": encoding,
+		}
+
+		switch encoding {
+		case PlainEncoding, Dictionary8Encoding, Dictionary16Encoding, RunEndEncodedEncoding:
+		default:
+			return werror.WrapWithContext(ErrUnsupportedEncoding, errContext)
+		}
+
+		field := overriddenField(path, prototypes)
+		if field == nil {
+			return werror.WrapWithContext(ErrUnknownOverridePath, errContext)
+		}
+		if !overridable(field, encoding) {
+			return werror.WrapWithContext(ErrIneffectiveOverride, errContext)
+		}
+	}
+	return nil
+}
+
+// overriddenField returns the field of the prototype schemas designated by
+// the path of an override, or nil if there is none.
+func overriddenField(path string, prototypes map[string]*arrow.Schema) *arrow.Field {
+	for prefix, prototype := range prototypes {
+		names, ok := strings.CutPrefix(path, prefix+".")
+		if !ok {
+			continue
+		}
+		fields := prototype.Fields()
+		var field *arrow.Field
+		for _, name := range strings.Split(names, ".") {
+			field = nil
+			for i := range fields {
+				if fields[i].Name == name {
+					field = &fields[i]
+					break
+				}
+			}
+			if field == nil {
+				break
+			}
+			fields = nil
+			if st, ok := field.Type.(*arrow.StructType); ok {
+				fields = st.Fields()
+			}
+		}
+		if field != nil {
+			return field
+		}
+	}
+	return nil
+}
+
+// WithEncodingOverrides returns a copy of the prototype schema in which the
+// encoding of the overridden fields is replaced. The overrides are keyed by
+// the prefix followed by the dot separated path of the field, e.g.
+// "spans.resource.schema_url". Only the fields dictionary encoded in the
+// prototype schema can be overridden, the other overrides and the
+// unsupported encodings are ignored (see ValidateEncodingOverrides). The
+// run-end encoding can only be forced on the int32 and uint8 fields that are
+// not delta encoded (e.g. "metrics.metric_type"), dictionary encoded or not.
+func WithEncodingOverrides(prototype *arrow.Schema, prefix string, overrides map[string]string) *arrow.Schema {
+	if len(overrides) == 0 {
+		return prototype
+	}
+
+	protoFields := prototype.Fields()
+	fields := make([]arrow.Field, 0, len(protoFields))
+	for i := 0; i < len(protoFields); i++ {
+		fields = append(fields, overrideField(prefix, &protoFields[i], overrides))
+	}
+
+	metadata := prototype.Metadata()
+	return arrow.NewSchema(fields, &metadata)
+}
+
+func overrideField(path string, prototype *arrow.Field, overrides map[string]string) arrow.Field {
+	path += "." + prototype.Name
+	field := *prototype
+
+	if encoding, ok := overrides[path]; ok && overridable(&field, encoding) {
+		field.Metadata = overrideMetadata(field.Metadata, encoding)
+	}
+
+	if st, ok := field.Type.(*arrow.StructType); ok {
+		children := make([]arrow.Field, 0, len(st.Fields()))
+		for _, child := range st.Fields() {
+			child := child
+			children = append(children, overrideField(path, &child, overrides))
+		}
+		field.Type = arrow.StructOf(children...)
+	}
+
+	return field
+}
+
+// overridable returns true if the encoding of a prototype field can be
+// replaced by the given one.
+func overridable(field *arrow.Field, encoding string) bool {
+	if encoding == RunEndEncodedEncoding {
+		return runEndEncodable(field)
+	}
+	return field.Metadata.FindKey(DictionaryKey) != -1
+}
+
+// runEndEncodable returns true if the record builders support the run-end
+// encoding of the field.
+func runEndEncodable(field *arrow.Field) bool {
+	if field.Metadata.FindKey(EncodingKey) != -1 {
+		return false
+	}
+	switch field.Type.ID() {
+	case arrow.INT32, arrow.UINT8:
+		return true
+	default:
+		return false
+	}
+}
+
+// overrideMetadata replaces the dictionary (or run-end) metadata of a field
+// by the one corresponding to the given encoding, the other keys are kept.
+// Unsupported encodings leave the metadata unchanged.
+func overrideMetadata(metadata arrow.Metadata, encoding string) arrow.Metadata {
+	var indexWidth string
+	runEnd := false
+	switch encoding {
+	case PlainEncoding:
+	case Dictionary8Encoding:
+		indexWidth = "8"
+	case Dictionary16Encoding:
+		indexWidth = "16"
+	case RunEndEncodedEncoding:
+		runEnd = true
+	default:
+		return metadata
+	}
+
+	keys := make([]string, 0, len(metadata.Keys())+1)
+	values := make([]string, 0, len(metadata.Values())+1)
+	for i, key := range metadata.Keys() {
+		if key == DictionaryKey || (key == EncodingKey && metadata.Values()[i] == RunEndEncodingValue) {
+			continue
+		}
+		keys = append(keys, key)
+		values = append(values, metadata.Values()[i])
+	}
+	if indexWidth != "" {
+		keys = append(keys, DictionaryKey)
+		values = append(values, indexWidth)
+	}
+	if runEnd {
+		keys = append(keys, EncodingKey)
+		values = append(values, RunEndEncodingValue)
+	}
+
+	return arrow.NewMetadata(keys, values)
+}
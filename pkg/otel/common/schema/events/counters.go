@@ -25,4 +25,12 @@ type Events struct {
 
 	// Dictionary fields that have their dictionary index type changed.
 	DictionariesIndexTypeChanged map[string]string
+
+	// Dictionary fields that have overflowed and that have been reset (see
+	// config.OverflowReset).
+	DictionariesReset map[string]bool
+
+	// String and binary fields that have been promoted to LargeUtf8 or
+	// LargeBinary to avoid an offset overflow.
+	ValuesPromotedToLarge map[string]bool
 }
@@ -19,29 +19,42 @@ package schema
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/apache/arrow/go/v12/arrow"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/enums"
 )
 
-// Metadata constants used to mark fields as optional or dictionary.
+// Metadata constants used to mark fields as optional or dictionary (see the
+// enums package).
 
-type MetadataKey int
+type MetadataKey = enums.MetadataKey
 
 const (
-	Optional MetadataKey = iota
-	Dictionary8
-	Dictionary16
-	DeltaEncoding
-
-	OptionalKey   = "#optional"
-	DictionaryKey = "#dictionary"
-	EncodingKey   = "encoding"
-
-	DeltaEncodingValue = "delta"
+	Optional       = enums.Optional
+	Dictionary8    = enums.Dictionary8
+	Dictionary16   = enums.Dictionary16
+	DeltaEncoding  = enums.DeltaEncoding
+	RunEndEncoding = enums.RunEndEncoding
+
+	OptionalKey   = enums.OptionalKey
+	DictionaryKey = enums.DictionaryKey
+	EncodingKey   = enums.EncodingKey
+	SortOrderKey  = enums.SortOrderKey
+
+	DeltaEncodingValue  = enums.DeltaEncodingValue
+	RunEndEncodingValue = enums.RunEndEncodingValue
 )
 
 var (
-	ErrSchemaNotUpToDate = errors.New("schema not up to date")
+	ErrSchemaNotUpToDate   = errors.New("schema not up to date")
+	ErrUnsupportedEncoding = errors.New("unsupported encoding override")
+	ErrUnknownOverridePath = errors.New("encoding override of an unknown field")
+	ErrIneffectiveOverride = errors.New("encoding override not applicable to the field")
+	ErrArrayTypeMismatch   = errors.New("array type not matching the builder type")
+	ErrDictionaryOverflow  = errors.New("dictionary overflow")
+	ErrValueTooLarge       = errors.New("string or binary column too large")
 )
 
 // Metadata returns a map of Arrow metadata for the given metadata keys.
@@ -57,11 +70,32 @@ func Metadata(keys ...MetadataKey) arrow.Metadata {
 			m[DictionaryKey] = "16"
 		case DeltaEncoding:
 			m[EncodingKey] = DeltaEncodingValue
+		case RunEndEncoding:
+			m[EncodingKey] = RunEndEncodingValue
 		}
 	}
 	return arrow.MetadataFrom(m)
 }
 
+// SortOrder returns the schema metadata declaring that the rows of a
+// record are sorted by the given columns, most significant first.
+func SortOrder(columns ...string) *arrow.Metadata {
+	md := arrow.NewMetadata([]string{SortOrderKey}, []string{strings.Join(columns, ",")})
+	return &md
+}
+
+// SortOrderFrom returns the columns by which the rows of the records
+// using the given schema are sorted, or nil if the schema doesn't
+// declare a sort order.
+func SortOrderFrom(s *arrow.Schema) []string {
+	md := s.Metadata()
+	idx := md.FindKey(SortOrderKey)
+	if idx < 0 || md.Values()[idx] == "" {
+		return nil
+	}
+	return strings.Split(md.Values()[idx], ",")
+}
+
 // NewSchemaFrom creates a new schema from a prototype schema and a transformation tree.
 func NewSchemaFrom(prototype *arrow.Schema, transformTree *TransformNode) *arrow.Schema {
 	protoFields := prototype.Fields()
@@ -59,6 +59,17 @@ type DictionaryField struct {
 	indexTypes   []arrow.DataType
 	currentIndex int
 
+	// policy applied when the cardinality overflows the index types.
+	policy cfg.OverflowPolicy
+
+	// resetPending is true when the dictionary has been reset and the batch
+	// that overflowed it has not been rebuilt yet (see cfg.OverflowReset).
+	resetPending bool
+
+	// overflowed is true when the dictionary has overflowed with the
+	// cfg.OverflowError policy (see TakeOverflow).
+	overflowed bool
+
 	schemaUpdateRequest *update.SchemaUpdateRequest
 	events              *events.Events
 }
@@ -104,6 +115,19 @@ func (t *DictionaryField) CumulativeTotal() uint64 {
 	return t.cumulativeTotal
 }
 
+// TakeOverflow returns true if the dictionary has overflowed with the
+// cfg.OverflowError policy since the last call.
+func (t *DictionaryField) TakeOverflow() bool {
+	overflowed := t.overflowed
+	t.overflowed = false
+	return overflowed
+}
+
+// Path returns the dot separated path of the dictionary field.
+func (t *DictionaryField) Path() string {
+	return t.path
+}
+
 func (t *DictionaryField) IndexType() arrow.DataType {
 	if t.indexTypes == nil {
 		return nil
@@ -162,13 +186,38 @@ func (t *DictionaryField) updateIndexType(stats *stats.RecordBuilderStats) {
 		t.currentIndex++
 	}
 	if t.currentIndex >= len(t.indexTypes) {
+		stats.DictionaryOverflowDetected++
+
+		switch t.policy {
+		case cfg.OverflowReset:
+			if !t.resetPending {
+				// The rebuild of the batch starts a new dictionary with the
+				// same index type.
+				t.currentIndex = currentIndex
+				t.resetPending = true
+				t.schemaUpdateRequest.Inc()
+				t.events.DictionariesReset[t.path] = true
+				return
+			}
+			// The values of a single batch overflow the index, fall back to
+			// the base type.
+		case cfg.OverflowError:
+			t.currentIndex = currentIndex
+			t.overflowed = true
+			return
+		}
+
+		t.resetPending = false
 		t.indexTypes = nil
 		t.indexMaxCard = nil
 		t.currentIndex = 0
 		t.schemaUpdateRequest.Inc()
 		t.events.DictionariesWithOverflow[t.path] = true
-		stats.DictionaryOverflowDetected++
-	} else if t.currentIndex != currentIndex {
+		return
+	}
+
+	t.resetPending = false
+	if t.currentIndex != currentIndex {
 		t.schemaUpdateRequest.Inc()
 		t.events.DictionariesIndexTypeChanged[t.path] = t.indexTypes[t.currentIndex].Name()
 		stats.DictionaryIndexTypeChanged++
@@ -179,13 +228,20 @@ func (t *DictionaryField) initIndices(config *cfg.Dictionary) {
 	t.indexTypes = nil
 	t.indexMaxCard = nil
 	t.currentIndex = 0
+	t.policy = cfg.OverflowFallback
 
 	if config == nil || config.MaxCard == 0 {
 		return
 	}
 
-	t.indexTypes = indexTypesRange(config.MinCard, config.MaxCard)
-	t.indexMaxCard = indexMaxCardRange(config.MinCard, config.MaxCard)
+	t.policy = config.OverflowPolicyOf(t.path)
+	maxCard := config.MaxCard
+	if t.policy == cfg.OverflowWiden {
+		maxCard = math.MaxUint64
+	}
+
+	t.indexTypes = indexTypesRange(config.MinCard, maxCard)
+	t.indexMaxCard = indexMaxCardRange(config.MinCard, maxCard)
 }
 
 func indexTypesRange(minCard uint64, maxCard uint64) []arrow.DataType {
@@ -33,6 +33,8 @@ import (
 var evts = &events.Events{
 	DictionariesWithOverflow:     make(map[string]bool),
 	DictionariesIndexTypeChanged: make(map[string]string),
+	DictionariesReset:            make(map[string]bool),
+	ValuesPromotedToLarge:        make(map[string]bool),
 }
 
 func TestNoDictionary(t *testing.T) {
@@ -185,3 +187,62 @@ func TestDictUint64Overflow(t *testing.T) {
 	assert.Equal(t, arrow.PrimitiveTypes.Uint64, dict.IndexType(), "index type should be uint64")
 	assert.Equal(t, 0, schemaUpdateRequest.Count())
 }
+
+func TestDictOverflowWiden(t *testing.T) {
+	rbStats := &stats.RecordBuilderStats{}
+	schemaUpdateRequest := update.NewSchemaUpdateRequest()
+	dictConfig := cfg.NewDictionary(math.MaxUint8).WithOverflow(cfg.OverflowWiden, "spans", nil)
+
+	dict := NewDictionaryField("name", "1", dictConfig, schemaUpdateRequest, evts)
+	assert.Equal(t, arrow.PrimitiveTypes.Uint8, dict.IndexType(), "index type should be uint8")
+
+	dict.SetCardinality(math.MaxUint8+1, rbStats)
+	assert.Equal(t, arrow.PrimitiveTypes.Uint16, dict.IndexType(), "index type should be uint16 (widened)")
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+}
+
+func TestDictOverflowReset(t *testing.T) {
+	rbStats := &stats.RecordBuilderStats{}
+	schemaUpdateRequest := update.NewSchemaUpdateRequest()
+	dictConfig := cfg.NewDictionary(math.MaxUint8).WithOverflow(cfg.OverflowFallback, "spans", map[string]cfg.OverflowPolicy{
+		"spans.name": cfg.OverflowReset,
+	})
+
+	dict := NewDictionaryField("name", "1", dictConfig, schemaUpdateRequest, evts)
+
+	// The first overflow resets the dictionary.
+	dict.SetCardinality(math.MaxUint8+1, rbStats)
+	assert.Equal(t, arrow.PrimitiveTypes.Uint8, dict.IndexType(), "index type should be uint8 (reset)")
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+	assert.True(t, evts.DictionariesReset["name"])
+	schemaUpdateRequest.Reset()
+
+	// The rebuilt batch fits in the new dictionary.
+	dict.SetCardinality(100, rbStats)
+	assert.Equal(t, arrow.PrimitiveTypes.Uint8, dict.IndexType(), "index type should be uint8")
+	assert.Equal(t, 0, schemaUpdateRequest.Count())
+
+	dict.SetCardinality(math.MaxUint8+1, rbStats)
+	assert.Equal(t, arrow.PrimitiveTypes.Uint8, dict.IndexType(), "index type should be uint8 (reset)")
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+	schemaUpdateRequest.Reset()
+
+	// The rebuilt batch overflows the new dictionary.
+	dict.SetCardinality(math.MaxUint8+1, rbStats)
+	assert.Nil(t, dict.IndexType(), "index type should be nil (overflow)")
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+}
+
+func TestDictOverflowError(t *testing.T) {
+	rbStats := &stats.RecordBuilderStats{}
+	schemaUpdateRequest := update.NewSchemaUpdateRequest()
+	dictConfig := cfg.NewDictionary(math.MaxUint8).WithOverflow(cfg.OverflowError, "spans", nil)
+
+	dict := NewDictionaryField("name", "1", dictConfig, schemaUpdateRequest, evts)
+
+	dict.SetCardinality(math.MaxUint8+1, rbStats)
+	assert.Equal(t, arrow.PrimitiveTypes.Uint8, dict.IndexType(), "index type should be uint8")
+	assert.Equal(t, 0, schemaUpdateRequest.Count())
+	assert.True(t, dict.TakeOverflow())
+	assert.False(t, dict.TakeOverflow())
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package transform
+
+import (
+	"github.com/apache/arrow/go/v12/arrow"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
+	events "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/events"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/update"
+)
+
+// LargeValueField is a FieldTransform that promotes Utf8 and Binary fields to
+// LargeUtf8 and LargeBinary (int64 offsets) once the data of a batch exceeds
+// the large value threshold. The promotion is permanent for the lifetime of
+// the record builder. With the cfg.LargeValuesError policy the field keeps
+// its type and the overflow is reported instead (see TakeOverflow).
+type LargeValueField struct {
+	path   string
+	config *cfg.LargeValues
+	large  bool
+
+	// overflowed is true when the threshold has been exceeded with the
+	// cfg.LargeValuesError policy.
+	overflowed bool
+
+	schemaUpdateRequest *update.SchemaUpdateRequest
+	events              *events.Events
+}
+
+func NewLargeValueField(
+	path string,
+	config *cfg.LargeValues,
+	schemaUpdateRequest *update.SchemaUpdateRequest,
+	events *events.Events,
+) *LargeValueField {
+	return &LargeValueField{
+		path:                path,
+		config:              config,
+		schemaUpdateRequest: schemaUpdateRequest,
+		events:              events,
+	}
+}
+
+// Exceeds returns true if appending a value of valueLen bytes to a column
+// already containing dataLen bytes of data exceeds the threshold.
+func (t *LargeValueField) Exceeds(dataLen, valueLen int) bool {
+	return t.config.Exceeds(dataLen, valueLen)
+}
+
+// Promote requests a schema update to switch the field to its large type, or
+// records the overflow with the cfg.LargeValuesError policy. Does nothing if
+// the field has already been promoted.
+func (t *LargeValueField) Promote() {
+	if t.config.Policy == cfg.LargeValuesError {
+		t.overflowed = true
+		return
+	}
+	if t.large {
+		return
+	}
+	t.large = true
+	t.schemaUpdateRequest.Inc()
+	t.events.ValuesPromotedToLarge[t.path] = true
+}
+
+// IsLarge returns true if the field has been promoted to its large type.
+func (t *LargeValueField) IsLarge() bool {
+	return t.large
+}
+
+// TakeOverflow returns true if the threshold has been exceeded with the
+// cfg.LargeValuesError policy since the last call.
+func (t *LargeValueField) TakeOverflow() bool {
+	overflowed := t.overflowed
+	t.overflowed = false
+	return overflowed
+}
+
+// Path returns the path of the field.
+func (t *LargeValueField) Path() string {
+	return t.path
+}
+
+func (t *LargeValueField) Transform(field *arrow.Field) *arrow.Field {
+	if !t.large {
+		return field
+	}
+	switch field.Type.ID() {
+	case arrow.STRING:
+		return &arrow.Field{Name: field.Name, Type: arrow.BinaryTypes.LargeString, Nullable: field.Nullable, Metadata: field.Metadata}
+	case arrow.BINARY:
+		return &arrow.Field{Name: field.Name, Type: arrow.BinaryTypes.LargeBinary, Nullable: field.Nullable, Metadata: field.Metadata}
+	default:
+		return field
+	}
+}
+
+func (t *LargeValueField) RevertCounters() {}
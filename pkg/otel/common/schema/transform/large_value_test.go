@@ -0,0 +1,79 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package transform
+
+import (
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/stretchr/testify/assert"
+
+	cfg "github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/update"
+)
+
+func TestLargeStringPromotion(t *testing.T) {
+	schemaUpdateRequest := update.NewSchemaUpdateRequest()
+	field := &arrow.Field{Name: "body", Type: arrow.BinaryTypes.String}
+
+	ls := NewLargeValueField("body", cfg.NewLargeValues(cfg.LargeValuesPromote, 0), schemaUpdateRequest, evts)
+	assert.Equal(t, arrow.BinaryTypes.String, ls.Transform(field).Type)
+	assert.Equal(t, 0, schemaUpdateRequest.Count())
+
+	ls.Promote()
+	assert.True(t, ls.IsLarge())
+	assert.Equal(t, arrow.BinaryTypes.LargeString, ls.Transform(field).Type)
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+	assert.True(t, evts.ValuesPromotedToLarge["body"])
+
+	// A second promotion doesn't trigger a new schema update.
+	ls.Promote()
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+
+	// Dictionary fields are left untouched.
+	dictField := &arrow.Field{Name: "body", Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Uint8, ValueType: arrow.BinaryTypes.String}}
+	assert.Equal(t, dictField.Type, ls.Transform(dictField).Type)
+}
+
+func TestLargeBinaryPromotion(t *testing.T) {
+	schemaUpdateRequest := update.NewSchemaUpdateRequest()
+	field := &arrow.Field{Name: "payload", Type: arrow.BinaryTypes.Binary}
+
+	lb := NewLargeValueField("payload", cfg.NewLargeValues(cfg.LargeValuesPromote, 10), schemaUpdateRequest, evts)
+	assert.False(t, lb.Exceeds(5, 5))
+	assert.True(t, lb.Exceeds(5, 6))
+
+	lb.Promote()
+	assert.Equal(t, arrow.BinaryTypes.LargeBinary, lb.Transform(field).Type)
+	assert.Equal(t, 1, schemaUpdateRequest.Count())
+	assert.True(t, evts.ValuesPromotedToLarge["payload"])
+}
+
+func TestLargeValueOverflowError(t *testing.T) {
+	schemaUpdateRequest := update.NewSchemaUpdateRequest()
+	field := &arrow.Field{Name: "body", Type: arrow.BinaryTypes.String}
+
+	lv := NewLargeValueField("body", cfg.NewLargeValues(cfg.LargeValuesError, 10), schemaUpdateRequest, evts)
+	lv.Promote()
+
+	// The field keeps its type and the overflow is reported once.
+	assert.False(t, lv.IsLarge())
+	assert.Equal(t, arrow.BinaryTypes.String, lv.Transform(field).Type)
+	assert.Equal(t, 0, schemaUpdateRequest.Count())
+	assert.True(t, lv.TakeOverflow())
+	assert.False(t, lv.TakeOverflow())
+}
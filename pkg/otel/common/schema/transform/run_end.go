@@ -0,0 +1,35 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package transform
+
+import "github.com/apache/arrow/go/v12/arrow"
+
+// RunEndEncodedField is a FieldTransform that converts a field to its run-end
+// encoded representation (int32 run ends). Run-end encoding is an alternative
+// to dictionary encoding for low-cardinality columns whose values repeat over
+// long runs of consecutive rows (e.g. metric type, severity).
+type RunEndEncodedField struct{}
+
+func (t *RunEndEncodedField) Transform(field *arrow.Field) *arrow.Field {
+	if field.Type.ID() == arrow.RUN_END_ENCODED {
+		return field
+	}
+	reeType := arrow.RunEndEncodedOf(arrow.PrimitiveTypes.Int32, field.Type)
+	return &arrow.Field{Name: field.Name, Type: reeType, Nullable: field.Nullable, Metadata: field.Metadata}
+}
+
+func (t *RunEndEncodedField) RevertCounters() {}
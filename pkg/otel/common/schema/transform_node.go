@@ -39,6 +39,7 @@ type FieldTransform interface {
 // It can be a leaf node or a node with children.
 type TransformNode struct {
 	name       string
+	optional   bool
 	transforms []FieldTransform
 	Children   []*TransformNode
 }
@@ -58,9 +59,20 @@ type TransformNode struct {
 // the number of unique values is higher than the size of dictIndexType.
 // If dictIndexType is nil, then fields marked as dictionary fields are not
 // converted to their dictionary representation.
+//
+// Run-end encoded fields:
+// Fields marked as run-end encoded in the prototype schema are converted to
+// their run-end encoded representation. This encoding replaces the dictionary
+// encoding if both are specified.
+//
+// Large values:
+// Utf8 and Binary fields are promoted to LargeUtf8 and LargeBinary once the
+// data of a batch exceeds the threshold of largeValues (see
+// cfg.LargeValuesPolicy).
 func NewTransformTreeFrom(
 	prototype *arrow.Schema,
 	dictConfig *cfg.Dictionary,
+	largeValues *cfg.LargeValues,
 	schemaUpdateRequest *update.SchemaUpdateRequest,
 	events *events.Events,
 ) (*TransformNode, map[string]*transform2.DictionaryField) {
@@ -74,6 +86,7 @@ func NewTransformTreeFrom(
 			"",
 			&protoFields[i],
 			dictConfig,
+			largeValues,
 			dictTransformNodes,
 			schemaUpdateRequest,
 			events,
@@ -87,6 +100,7 @@ func newTransformNodeFrom(
 	path string,
 	prototype *arrow.Field,
 	dictConfig *cfg.Dictionary,
+	largeValues *cfg.LargeValues,
 	dictTransformNodes map[string]*transform2.DictionaryField,
 	schemaUpdateRequest *update.SchemaUpdateRequest,
 	events *events.Events,
@@ -103,14 +117,24 @@ func newTransformNodeFrom(
 	// NoField transformation.
 	metadata := prototype.Metadata
 	keyIdx := metadata.FindKey(OptionalKey)
-	if keyIdx != -1 || prototype.Nullable {
+	optional := keyIdx != -1 || prototype.Nullable
+	if optional {
 		transforms = append(transforms, &transform2.NoField{})
 	}
 
+	// Check if the field is a run-end encoded field and if so, convert it to
+	// its run-end encoded representation by emitting a RunEndEncodedField
+	// transformation.
+	keyIdx = metadata.FindKey(EncodingKey)
+	runEndEncoded := keyIdx != -1 && metadata.Values()[keyIdx] == RunEndEncodingValue
+	if runEndEncoded {
+		transforms = append(transforms, &transform2.RunEndEncodedField{})
+	}
+
 	// Check if the field is a dictionary field and if so, convert it to its
 	// dictionary representation by emitting a DictionaryField transformation.
 	keyIdx = metadata.FindKey(DictionaryKey)
-	if keyIdx != -1 {
+	if keyIdx != -1 && !runEndEncoded {
 		initialDictIndexWidth := metadata.Values()[keyIdx]
 		var localDictConfig *cfg.Dictionary
 
@@ -134,7 +158,15 @@ func newTransformNodeFrom(
 		transforms = append(transforms, &transform2.IdentityField{})
 	}
 
-	node := TransformNode{name: prototype.Name, transforms: transforms}
+	// Utf8 and Binary fields can be promoted to LargeUtf8 and LargeBinary if
+	// the data of a batch gets close to the int32 offset limit. This
+	// transformation is applied last so that dictionary fields are left
+	// untouched.
+	if prototype.Type.ID() == arrow.STRING || prototype.Type.ID() == arrow.BINARY {
+		transforms = append(transforms, transform2.NewLargeValueField(path, largeValues, schemaUpdateRequest, events))
+	}
+
+	node := TransformNode{name: prototype.Name, optional: optional, transforms: transforms}
 
 	switch dt := prototype.Type.(type) {
 	case *arrow.DictionaryType:
@@ -149,6 +181,7 @@ func newTransformNodeFrom(
 				path,
 				&child,
 				dictConfig,
+				largeValues,
 				dictTransformNodes,
 				schemaUpdateRequest,
 				events,
@@ -161,6 +194,7 @@ func newTransformNodeFrom(
 			path,
 			&elemField,
 			dictConfig,
+			largeValues,
 			dictTransformNodes,
 			schemaUpdateRequest,
 			events,
@@ -172,6 +206,7 @@ func newTransformNodeFrom(
 				path,
 				&child,
 				dictConfig,
+				largeValues,
 				dictTransformNodes,
 				schemaUpdateRequest,
 				events,
@@ -184,6 +219,7 @@ func newTransformNodeFrom(
 			path,
 			&keyField,
 			dictConfig,
+			largeValues,
 			dictTransformNodes,
 			schemaUpdateRequest,
 			events,
@@ -194,6 +230,7 @@ func newTransformNodeFrom(
 			path,
 			&valueField,
 			dictConfig,
+			largeValues,
 			dictTransformNodes,
 			schemaUpdateRequest,
 			events,
@@ -225,6 +262,99 @@ func (t *TransformNode) RemoveOptional() {
 	}
 }
 
+// ExceedsLargeValueThreshold returns true if appending a value of valueLen
+// bytes to the current Utf8 or Binary column, already containing dataLen
+// bytes of data, exceeds the large value threshold of the field.
+func (t *TransformNode) ExceedsLargeValueThreshold(dataLen, valueLen int) bool {
+	if lv := t.largeValueField(); lv != nil {
+		return lv.Exceeds(dataLen, valueLen)
+	}
+	return dataLen+valueLen > cfg.DefaultLargeValueThreshold
+}
+
+// PromoteToLarge requests the promotion of the current Utf8 or Binary field
+// to LargeUtf8 or LargeBinary (see transform.LargeValueField). This will take
+// effect on the next cycle of appending data.
+func (t *TransformNode) PromoteToLarge() {
+	if lv := t.largeValueField(); lv != nil {
+		lv.Promote()
+	}
+}
+
+// TakeLargeValueOverflows appends to paths the paths of the fields of this
+// subtree whose large value threshold has been exceeded with the
+// LargeValuesError policy since the last call.
+func (t *TransformNode) TakeLargeValueOverflows(paths []string) []string {
+	if lv := t.largeValueField(); lv != nil && lv.TakeOverflow() {
+		paths = append(paths, lv.Path())
+	}
+	for _, child := range t.Children {
+		paths = child.TakeLargeValueOverflows(paths)
+	}
+	return paths
+}
+
+func (t *TransformNode) largeValueField() *transform2.LargeValueField {
+	for _, transform := range t.transforms {
+		if lv, ok := transform.(*transform2.LargeValueField); ok {
+			return lv
+		}
+	}
+	return nil
+}
+
+// Name returns the name of the field associated with this node.
+func (t *TransformNode) Name() string {
+	return t.name
+}
+
+// IsOptional returns true if the field is marked as optional (or nullable) in
+// the prototype schema.
+func (t *TransformNode) IsOptional() bool {
+	return t.optional
+}
+
+// IsActive returns true if the field is currently part of the target schema,
+// i.e. it is not removed by a NoField transformation.
+func (t *TransformNode) IsActive() bool {
+	for _, transform := range t.transforms {
+		if _, ok := transform.(*transform2.NoField); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// DeactivateOptional removes an optional field from the target schema again.
+// This is the inverse of RemoveOptional and will take effect on the next
+// schema update. Does nothing if the field is not optional or already
+// inactive.
+func (t *TransformNode) DeactivateOptional() bool {
+	if !t.optional || !t.IsActive() {
+		return false
+	}
+
+	n := 0
+	for _, transform := range t.transforms {
+		if _, ok := transform.(*transform2.IdentityField); !ok {
+			t.transforms[n] = transform
+			n++
+		}
+	}
+	t.transforms = append([]FieldTransform{&transform2.NoField{}}, t.transforms[:n]...)
+	return true
+}
+
+// Child returns the child node with the given name or nil if not found.
+func (t *TransformNode) Child(name string) *TransformNode {
+	for _, child := range t.Children {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
 func (t *TransformNode) RevertCounters() {
 	for _, transform := range t.transforms {
 		transform.RevertCounters()
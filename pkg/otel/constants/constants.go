@@ -21,8 +21,10 @@ const TimeUnixNano string = "time_unix_nano"
 const StartTimeUnixNano string = "start_time_unix_nano"
 const DurationTimeUnixNano string = "duration_time_unix_nano"
 const ObservedTimeUnixNano string = "observed_time_unix_nano"
+const ExpiryTimeUnixNano string = "expiry_time_unix_nano"
 const SeverityNumber string = "severity_number"
 const SeverityText string = "severity_text"
+const EventName string = "event_name"
 const DroppedAttributesCount string = "dropped_attributes_count"
 const DroppedEventsCount string = "dropped_events_count"
 const DroppedLinksCount string = "dropped_links_count"
@@ -31,10 +33,15 @@ const TraceId string = "trace_id"
 const TraceState string = "trace_state"
 const SpanId string = "span_id"
 const ParentSpanId string = "parent_span_id"
+const TraceIdHigh string = "trace_id_hi"
+const TraceIdLow string = "trace_id_lo"
+const SpanIdUint64 string = "span_id_u64"
+const ParentSpanIdUint64 string = "parent_span_id_u64"
 const Attributes string = "attributes"
 const Resource string = "resource"
 const ScopeMetrics string = "scope_metrics"
 const Scope string = "scope"
+const ScopeID string = "scope_id"
 const Name string = "name"
 const KIND string = "kind"
 const Version string = "version"
@@ -61,6 +68,7 @@ const HistogramBucketCounts string = "bucket_counts"
 const HistogramExplicitBounds string = "explicit_bounds"
 const ExpHistogramScale string = "scale"
 const ExpHistogramZeroCount string = "zero_count"
+const ExpHistogramZeroThreshold string = "zero_threshold"
 const ExpHistogramPositive string = "positive"
 const ExpHistogramNegative string = "negative"
 const ExpHistogramOffset string = "offset"
@@ -78,6 +86,7 @@ const SharedLinkAttributes string = "shared_link_attributes"
 
 const ID string = "id"
 const ParentID string = "parent_id"
+const SeriesID string = "series_id"
 
 const MetricType string = "metric_type"
 
@@ -92,6 +101,10 @@ const AttributeBool string = "bool"
 const AttributeBytes string = "bytes"
 const AttributeSer string = "ser"
 
+// AttributeFlattened flags the attributes that are entries of a nested map
+// flattened into a dotted key (see config.WithMapFlattening).
+const AttributeFlattened string = "flattened"
+
 // Log body
 
 const BodyType string = "type"
@@ -101,3 +114,16 @@ const BodyDouble string = "double"
 const BodyBool string = "bool"
 const BodyBytes string = "bytes"
 const BodySer string = "ser"
+const BodyTemplate string = "tmpl"
+const BodyTemplateVars string = "tmpl_vars"
+
+// Trace ID index
+
+const IndexStart string = "start"
+const IndexCount string = "count"
+
+// Analyzer statistics
+
+const AnalyzerStatPath string = "path"
+const AnalyzerStatName string = "statistic"
+const AnalyzerStatValue string = "value"
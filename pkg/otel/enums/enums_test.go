@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enums
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+)
+
+func TestMetricType(t *testing.T) {
+	t.Parallel()
+
+	for _, pt := range []pmetric.MetricType{
+		pmetric.MetricTypeEmpty,
+		pmetric.MetricTypeGauge,
+		pmetric.MetricTypeSum,
+		pmetric.MetricTypeHistogram,
+		pmetric.MetricTypeExponentialHistogram,
+		pmetric.MetricTypeSummary,
+	} {
+		mt := MetricTypeFrom(pt)
+		// Codes are part of the wire format.
+		require.Equal(t, uint8(pt), uint8(mt))
+		require.Equal(t, pt, mt.PdataType())
+		require.Equal(t, pt.String(), mt.String())
+
+		parsed, err := ParseMetricType(mt.String())
+		require.NoError(t, err)
+		require.Equal(t, mt, parsed)
+	}
+
+	_, err := ParseMetricType("Unknown")
+	require.True(t, errors.Is(err, ErrUnknownMetricType))
+}
+
+func TestPayloadType(t *testing.T) {
+	t.Parallel()
+
+	for _, pt := range PayloadTypes() {
+		parsed, err := ParsePayloadType(pt.String())
+		require.NoError(t, err)
+		require.Equal(t, pt, parsed)
+	}
+
+	parsed, err := ParsePayloadType("spans")
+	require.NoError(t, err)
+	require.Equal(t, v1.ArrowPayloadType_SPANS, parsed)
+
+	_, err = ParsePayloadType("profiles")
+	require.True(t, errors.Is(err, ErrUnknownPayloadType))
+}
+
+func TestMetadataKey(t *testing.T) {
+	t.Parallel()
+
+	for _, key := range []MetadataKey{Optional, Dictionary8, Dictionary16, DeltaEncoding, RunEndEncoding} {
+		parsed, err := ParseMetadataKey(key.String())
+		require.NoError(t, err)
+		require.Equal(t, key, parsed)
+	}
+
+	_, err := ParseMetadataKey("compressed")
+	require.True(t, errors.Is(err, ErrUnknownMetadataKey))
+}
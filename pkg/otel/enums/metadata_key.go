@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enums
+
+import "github.com/f5/otel-arrow-adapter/pkg/werror"
+
+// MetadataKey identifies a piece of Arrow field metadata used by the OTel
+// Arrow schemas to mark fields as optional, dictionary encoded, delta
+// encoded or run-end encoded.
+type MetadataKey int
+
+const (
+	Optional MetadataKey = iota
+	Dictionary8
+	Dictionary16
+	DeltaEncoding
+	RunEndEncoding
+)
+
+// Names and values of the Arrow field metadata.
+const (
+	OptionalKey   = "#optional"
+	DictionaryKey = "#dictionary"
+	EncodingKey   = "encoding"
+
+	DeltaEncodingValue  = "delta"
+	RunEndEncodingValue = "run_end"
+
+	// SortOrderKey is a schema level metadata key listing, comma
+	// separated and most significant first, the columns by which the
+	// rows of a record are sorted.
+	SortOrderKey = "sort_order"
+)
+
+var metadataKeyNames = map[MetadataKey]string{
+	Optional:       "optional",
+	Dictionary8:    "dictionary8",
+	Dictionary16:   "dictionary16",
+	DeltaEncoding:  "delta_encoding",
+	RunEndEncoding: "run_end_encoding",
+}
+
+// String returns the name of the metadata key (e.g. "dictionary8").
+func (k MetadataKey) String() string {
+	if name, ok := metadataKeyNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseMetadataKey returns the metadata key corresponding to the given name
+// (as returned by String).
+func ParseMetadataKey(name string) (MetadataKey, error) {
+	for k, n := range metadataKeyNames {
+		if n == name {
+			return k, nil
+		}
+	}
+	return 0, werror.WrapWithContext(ErrUnknownMetadataKey, map[string]interface{}{"name": name})
+}
@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enums
+
+import (
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// MetricType is the code stored in the `metric_type` column of the metrics
+// record.
+//
+// The codes are part of the wire format and have the same values as the
+// corresponding `pmetric.MetricType`. They are stored as an uint8 as we don't
+// expect more than 256 metric types.
+type MetricType uint8
+
+const (
+	MetricTypeEmpty                MetricType = 0
+	MetricTypeGauge                MetricType = 1
+	MetricTypeSum                  MetricType = 2
+	MetricTypeHistogram            MetricType = 3
+	MetricTypeExponentialHistogram MetricType = 4
+	MetricTypeSummary              MetricType = 5
+)
+
+var metricTypeNames = map[MetricType]string{
+	MetricTypeEmpty:                "Empty",
+	MetricTypeGauge:                "Gauge",
+	MetricTypeSum:                  "Sum",
+	MetricTypeHistogram:            "Histogram",
+	MetricTypeExponentialHistogram: "ExponentialHistogram",
+	MetricTypeSummary:              "Summary",
+}
+
+// MetricTypeFrom converts a pdata metric type into its OTel Arrow code.
+func MetricTypeFrom(t pmetric.MetricType) MetricType {
+	switch t {
+	case pmetric.MetricTypeGauge:
+		return MetricTypeGauge
+	case pmetric.MetricTypeSum:
+		return MetricTypeSum
+	case pmetric.MetricTypeHistogram:
+		return MetricTypeHistogram
+	case pmetric.MetricTypeExponentialHistogram:
+		return MetricTypeExponentialHistogram
+	case pmetric.MetricTypeSummary:
+		return MetricTypeSummary
+	default:
+		return MetricTypeEmpty
+	}
+}
+
+// PdataType converts an OTel Arrow metric type code into a pdata metric type.
+func (t MetricType) PdataType() pmetric.MetricType {
+	switch t {
+	case MetricTypeGauge:
+		return pmetric.MetricTypeGauge
+	case MetricTypeSum:
+		return pmetric.MetricTypeSum
+	case MetricTypeHistogram:
+		return pmetric.MetricTypeHistogram
+	case MetricTypeExponentialHistogram:
+		return pmetric.MetricTypeExponentialHistogram
+	case MetricTypeSummary:
+		return pmetric.MetricTypeSummary
+	default:
+		return pmetric.MetricTypeEmpty
+	}
+}
+
+// String returns the name of the metric type (e.g. "Gauge").
+func (t MetricType) String() string {
+	if name, ok := metricTypeNames[t]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// ParseMetricType returns the metric type corresponding to the given name
+// (as returned by String).
+func ParseMetricType(name string) (MetricType, error) {
+	for t, n := range metricTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+	return MetricTypeEmpty, werror.WrapWithContext(ErrUnknownMetricType, map[string]interface{}{"name": name})
+}
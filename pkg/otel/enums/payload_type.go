@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enums
+
+import (
+	"sort"
+	"strings"
+
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// PayloadType is the type of an OTel Arrow payload (i.e. the type of the
+// record it contains).
+type PayloadType = v1.ArrowPayloadType
+
+// ParsePayloadType returns the payload type corresponding to the given name.
+// The name is case-insensitive (e.g. "SPANS", "spans").
+func ParsePayloadType(name string) (PayloadType, error) {
+	value, ok := v1.ArrowPayloadType_value[strings.ToUpper(name)]
+	if !ok {
+		return v1.ArrowPayloadType_UNKNOWN, werror.WrapWithContext(ErrUnknownPayloadType, map[string]interface{}{"name": name})
+	}
+	return PayloadType(value), nil
+}
+
+// PayloadTypes returns all the known payload types sorted by value
+// (UNKNOWN excluded).
+func PayloadTypes() []PayloadType {
+	types := make([]PayloadType, 0, len(v1.ArrowPayloadType_name))
+	for value := range v1.ArrowPayloadType_name {
+		if value != int32(v1.ArrowPayloadType_UNKNOWN) {
+			types = append(types, PayloadType(value))
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
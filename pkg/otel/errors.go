@@ -24,6 +24,10 @@ import (
 var (
 	ErrMultipleTracesRecords     = errors.New("multiple traces records found")
 	ErrMultipleSpanEventsRecords = errors.New("multiple span events records found")
+	ErrMultipleScopeTableRecords = errors.New("multiple scope table records found")
 	ErrDuplicatePayloadType      = errors.New("duplicate payload type")
 	UnknownPayloadType           = errors.New("unknown payload type")
+	ErrUnknownColumns            = errors.New("unknown columns")
+	ErrInvalidSnapshot           = errors.New("invalid snapshot")
+	ErrLossyConversion           = errors.New("lossy conversion")
 )
@@ -0,0 +1,267 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golden implements golden-file tests for the OTel Arrow encoding.
+//
+// The encoded form of a fixed corpus is checked into the testdata directory
+// of the calling package. Tests fail as soon as a single byte of the encoding
+// changes, which protects against accidental wire-format changes. A missing
+// golden file is a failure as well. Running the tests with the `-update` flag
+// is the only way to create or regenerate the golden files, it also reports a
+// human-readable diff of the schemas.
+package golden
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"google.golang.org/protobuf/proto"
+
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// Update returns true if the tests are run with the `-update` flag.
+func Update() bool {
+	return *update
+}
+
+// Dir is the directory (relative to the package under test) containing the
+// golden files.
+const Dir = "testdata/golden"
+
+// AssertBatches compares the encoding of the given batches with the golden
+// file `<Dir>/<name>.bin`. A companion file `<name>.schemas.txt` describes the
+// schema of each payload and is used to report schema changes.
+func AssertBatches(t *testing.T, name string, batches []*v1.BatchArrowRecords) {
+	t.Helper()
+
+	encoded, err := Encode(batches)
+	if err != nil {
+		t.Fatalf("failed to encode batches: %v", err)
+	}
+	schemas, err := DescribeSchemas(batches)
+	if err != nil {
+		t.Fatalf("failed to describe schemas: %v", err)
+	}
+
+	binPath := filepath.Join(Dir, name+".bin")
+	schemasPath := filepath.Join(Dir, name+".schemas.txt")
+
+	if *update {
+		oldSchemas, _ := os.ReadFile(schemasPath)
+		if err := os.MkdirAll(Dir, 0o755); err != nil {
+			t.Fatalf("failed to create %s: %v", Dir, err)
+		}
+		if err := os.WriteFile(binPath, encoded, 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", binPath, err)
+		}
+		if err := os.WriteFile(schemasPath, []byte(schemas), 0o600); err != nil {
+			t.Fatalf("failed to write %s: %v", schemasPath, err)
+		}
+		if diff := Diff(string(oldSchemas), schemas); diff != "" {
+			t.Logf("golden schemas of %q updated:\n%s", name, diff)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(binPath)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s not found, run `go test -update` to create it", binPath)
+	}
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", binPath, err)
+	}
+	if bytes.Equal(expected, encoded) {
+		return
+	}
+
+	expectedSchemas, err := os.ReadFile(schemasPath)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", schemasPath, err)
+	}
+	diff := Diff(string(expectedSchemas), schemas)
+	if diff == "" {
+		diff = "no schema change, the content of the payloads differs\n"
+	}
+	t.Errorf("encoding of %q differs from golden file %s (%d bytes, expected %d bytes), run `go test -update` if this change is intended.\n%s",
+		name, binPath, len(encoded), len(expected), diff)
+}
+
+// Encode serializes the given batches deterministically. Each batch is
+// prefixed with its length (uvarint).
+func Encode(batches []*v1.BatchArrowRecords) ([]byte, error) {
+	var buf bytes.Buffer
+	var lenBuf [binary.MaxVarintLen64]byte
+	opts := proto.MarshalOptions{Deterministic: true}
+
+	for _, batch := range batches {
+		data, err := opts.Marshal(batch)
+		if err != nil {
+			return nil, err
+		}
+		n := binary.PutUvarint(lenBuf[:], uint64(len(data)))
+		buf.Write(lenBuf[:n])
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode is the inverse of Encode.
+func Decode(data []byte) ([]*v1.BatchArrowRecords, error) {
+	var batches []*v1.BatchArrowRecords
+
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < size {
+			return nil, fmt.Errorf("invalid batch length prefix")
+		}
+		data = data[n:]
+
+		batch := &v1.BatchArrowRecords{}
+		if err := proto.Unmarshal(data[:size], batch); err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+		data = data[size:]
+	}
+	return batches, nil
+}
+
+// DescribeSchemas returns a human-readable description of the payloads of
+// the given batches (payload type, stream, size and schema).
+func DescribeSchemas(batches []*v1.BatchArrowRecords) (string, error) {
+	var sb strings.Builder
+	streams := make(map[string]*bytes.Buffer)
+	schemas := make(map[string]*arrow.Schema)
+
+	for i, batch := range batches {
+		for j, payload := range batch.ArrowPayloads {
+			stream, ok := streams[payload.SchemaId]
+			if !ok {
+				stream = &bytes.Buffer{}
+				streams[payload.SchemaId] = stream
+			}
+			stream.Write(payload.Record)
+
+			if _, ok := schemas[payload.SchemaId]; !ok {
+				// The first payload of a stream contains its schema.
+				reader, err := ipc.NewReader(bytes.NewReader(stream.Bytes()))
+				if err != nil {
+					return "", err
+				}
+				schemas[payload.SchemaId] = reader.Schema()
+				reader.Release()
+			}
+
+			_, _ = fmt.Fprintf(&sb, "batch %d, payload %d: %s (stream %s, %d bytes)\n",
+				i, j, payload.Type.String(), payload.SchemaId, len(payload.Record))
+			describeFields(&sb, schemas[payload.SchemaId].Fields(), "  ")
+		}
+	}
+	return sb.String(), nil
+}
+
+func describeFields(sb *strings.Builder, fields []arrow.Field, indent string) {
+	for _, field := range fields {
+		_, _ = fmt.Fprintf(sb, "%s%s: %s", indent, field.Name, field.Type.Name())
+		if field.Nullable {
+			sb.WriteString(" (nullable)")
+		}
+		if field.HasMetadata() {
+			_, _ = fmt.Fprintf(sb, " %v", field.Metadata)
+		}
+		sb.WriteString("\n")
+
+		switch dt := field.Type.(type) {
+		case *arrow.StructType:
+			describeFields(sb, dt.Fields(), indent+"  ")
+		case *arrow.ListType:
+			describeFields(sb, []arrow.Field{dt.ElemField()}, indent+"  ")
+		case arrow.UnionType:
+			describeFields(sb, dt.Fields(), indent+"  ")
+		case *arrow.DictionaryType:
+			_, _ = fmt.Fprintf(sb, "%s  dictionary<%s, %s>\n", indent, dt.IndexType.Name(), dt.ValueType.Name())
+		case *arrow.RunEndEncodedType:
+			_, _ = fmt.Fprintf(sb, "%s  run_end_encoded<%s, %s>\n", indent, dt.RunEnds().Name(), dt.Encoded().Name())
+		}
+	}
+}
+
+// Diff returns a line-based diff between the two texts (empty if they are
+// identical). Removed lines are prefixed with `-`, added lines with `+`.
+func Diff(before, after string) string {
+	if before == after {
+		return ""
+	}
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+
+	// Longest common subsequence of lines.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Changed lines are preceded by the payload header they belong to.
+	var sb strings.Builder
+	header, headerPrinted := "", false
+	printHeader := func() {
+		if !headerPrinted && header != "" {
+			_, _ = fmt.Fprintf(&sb, " %s\n", header)
+			headerPrinted = true
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			if strings.HasPrefix(a[i], "batch ") {
+				header, headerPrinted = a[i], false
+			}
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			printHeader()
+			_, _ = fmt.Fprintf(&sb, "+%s\n", b[j])
+			j++
+		default:
+			printHeader()
+			_, _ = fmt.Fprintf(&sb, "-%s\n", a[i])
+			i++
+		}
+	}
+	return sb.String()
+}
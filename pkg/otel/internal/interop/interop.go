@@ -0,0 +1,180 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package interop implements the fixtures used to check the wire-level
+// compatibility between this implementation and the other implementations of
+// the OTel Arrow protocol (e.g. the Rust implementation).
+//
+// A fixture is a stream of BatchArrowRecords exchanged in one direction and
+// stored in `<Dir>/<direction>/<name>.bin` (golden.Encode format), along with
+// the expected OTLP representation of each batch in `<name>.json` (one OTLP
+// JSON export request per line, in batch order). The name of a fixture starts
+// with its signal (`traces`, `logs` or `metrics`).
+//
+// The `go-to-rust` fixtures are generated by the tests of this repository
+// (`-update` flag) and must be decoded by the other implementation. The
+// `rust-to-go` fixtures are produced by the other implementation from the
+// expected JSON files and are decoded by the tests of this repository. Both
+// directions share a single stream per fixture, so that dictionary deltas and
+// schema changes across batches are covered.
+package interop
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/internal/golden"
+)
+
+// Dir is the directory (relative to the package under test) containing the
+// interop fixtures.
+const Dir = "testdata/interop"
+
+// Directions of the exchange.
+const (
+	GoToRust = "go-to-rust"
+	RustToGo = "rust-to-go"
+)
+
+// Signals supported by the fixtures.
+const (
+	Traces  = "traces"
+	Logs    = "logs"
+	Metrics = "metrics"
+)
+
+// Fixture is a stream of batches and the expected OTLP representation of
+// each batch.
+type Fixture struct {
+	Name    string
+	Signal  string
+	Batches []*v1.BatchArrowRecords
+	// Expected contains the OTLP JSON export request of each batch.
+	Expected [][]byte
+}
+
+// Append adds a batch and its expected OTLP JSON export request.
+func (f *Fixture) Append(batch *v1.BatchArrowRecords, expected []byte) {
+	f.Batches = append(f.Batches, batch)
+	f.Expected = append(f.Expected, expected)
+}
+
+// Load returns all the fixtures of the given direction. The test fails if
+// there is no fixture for one of the signals.
+func Load(t *testing.T, direction string) []Fixture {
+	t.Helper()
+
+	dir := filepath.Join(Dir, direction)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.bin"))
+	if err != nil {
+		t.Fatalf("failed to list %s: %v", dir, err)
+	}
+	sort.Strings(paths)
+
+	fixtures := make([]Fixture, 0, len(paths))
+	signals := make(map[string]bool)
+	for _, path := range paths {
+		fixture, err := read(path)
+		if err != nil {
+			t.Fatalf("failed to read fixture %s: %v", path, err)
+		}
+		fixtures = append(fixtures, fixture)
+		signals[fixture.Signal] = true
+	}
+	for _, signal := range []string{Traces, Logs, Metrics} {
+		if !signals[signal] {
+			t.Fatalf("no %s interop fixture found in %s", signal, dir)
+		}
+	}
+	return fixtures
+}
+
+// Write stores a fixture in the given direction.
+func Write(direction string, fixture Fixture) error {
+	dir := filepath.Join(Dir, direction)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	encoded, err := golden.Encode(fixture.Batches)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, fixture.Name+".bin"), encoded, 0o600); err != nil {
+		return err
+	}
+
+	var expected bytes.Buffer
+	for _, e := range fixture.Expected {
+		expected.Write(e)
+		expected.WriteByte('\n')
+	}
+	return os.WriteFile(filepath.Join(dir, fixture.Name+".json"), expected.Bytes(), 0o600)
+}
+
+func read(binPath string) (Fixture, error) {
+	name := strings.TrimSuffix(filepath.Base(binPath), ".bin")
+	fixture := Fixture{Name: name, Signal: signalOf(name)}
+	if fixture.Signal == "" {
+		return fixture, fmt.Errorf("unknown signal for fixture %q", name)
+	}
+
+	data, err := os.ReadFile(binPath)
+	if err != nil {
+		return fixture, err
+	}
+	if fixture.Batches, err = golden.Decode(data); err != nil {
+		return fixture, err
+	}
+
+	jsonFile, err := os.Open(strings.TrimSuffix(binPath, ".bin") + ".json")
+	if err != nil {
+		return fixture, err
+	}
+	defer func() { _ = jsonFile.Close() }()
+
+	scanner := bufio.NewScanner(jsonFile)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		fixture.Expected = append(fixture.Expected, append([]byte(nil), line...))
+	}
+	if err := scanner.Err(); err != nil {
+		return fixture, err
+	}
+
+	if len(fixture.Expected) != len(fixture.Batches) {
+		return fixture, fmt.Errorf("%d batches but %d expected OTLP requests", len(fixture.Batches), len(fixture.Expected))
+	}
+	return fixture, nil
+}
+
+func signalOf(name string) string {
+	for _, signal := range []string{Traces, Logs, Metrics} {
+		if strings.HasPrefix(name, signal) {
+			return signal
+		}
+	}
+	return ""
+}
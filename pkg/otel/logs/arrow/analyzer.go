@@ -19,9 +19,12 @@ package arrow
 
 import (
 	"fmt"
+	"io"
+	"strconv"
 
 	"github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/axiomhq/hyperloglog"
+	"go.opentelemetry.io/collector/pdata/plog"
 
 	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
 )
@@ -102,69 +105,83 @@ func (t *LogsAnalyzer) Analyze(logs *LogsOptimized) {
 	t.ResourceLogsStats.UpdateWith(logs)
 }
 
-func (t *LogsAnalyzer) ShowStats(indent string) {
-	println()
-	print(carrow.Green)
-	fmt.Printf("%s%d ExportLogServiceRequest processed\n", indent, t.LogRecordCount)
-	print(carrow.ColorReset)
-	indent += "  "
-	t.ResourceLogsStats.ShowStats(indent)
+// ShowSummary writes the number of requests analyzed so far.
+func (t *LogsAnalyzer) ShowSummary(w io.Writer, indent string) {
+	fmt.Fprintln(w)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%s%d ExportLogServiceRequest processed\n", indent, t.LogRecordCount)
+	fmt.Fprint(w, carrow.ColorReset)
+}
+
+// ShowStats writes the summary followed by the detailed statistics.
+func (t *LogsAnalyzer) ShowStats(w io.Writer, indent string) {
+	t.ShowSummary(w, indent)
+	t.ResourceLogsStats.ShowStats(w, indent+"  ")
 }
 
 func (r *ResourceLogsStats) UpdateWith(logs *LogsOptimized) {
-	// ToDo I will update this in a future PR. Only used for troubleshooting compression ratio issues.
-	//resLogs := logs.ResourceLogs
-	//
-	//for ID := range logs.ResourceLogsIdx {
-	//	r.ResLogsIDsDistinct.Insert([]byte(ID))
-	//}
-	//
-	//r.TotalCount += int64(len(resLogs))
-	//carrow.RequireNoError(r.Distribution.RecordValue(int64(len(resLogs))))
-	//
-	//for _, rs := range resLogs {
-	//	r.ResourceStats.UpdateWith(rs.Resource)
-	//	r.ScopeLogsStats.UpdateWith(rs.ScopeLogs, rs.ScopeLogsIdx)
-	//	r.SchemaUrlStats.UpdateWith(rs.ResourceSchemaUrl)
-	//}
+	prevResID := -1
+	prevScopeID := -1
+
+	resLogsCount := 0
+	scopeLogsCount := 0
+	logsPerScopeLogs := 0
+
+	for _, log := range logs.Logs {
+		if prevResID != log.ResScope.ResourceLogsID {
+			prevResID = log.ResScope.ResourceLogsID
+			resLogsCount++
+			r.ResLogsIDsDistinct.Insert([]byte(strconv.Itoa(prevResID)))
+			r.ResourceStats.UpdateWith(log.ResScope.Resource)
+			r.SchemaUrlStats.UpdateWith(log.ResScope.ResourceSchemaUrl)
+		}
+
+		if prevScopeID != log.ResScope.ScopeLogsID {
+			prevScopeID = log.ResScope.ScopeLogsID
+			scopeLogsCount++
+			r.ScopeLogsStats.UpdateWith(log.ResScope)
+			r.ScopeLogsStats.LogRecordStats.RecordScopeLogsSize(logsPerScopeLogs)
+			logsPerScopeLogs = 0
+		}
+
+		r.ScopeLogsStats.LogRecordStats.UpdateWith(log.Log)
+		logsPerScopeLogs++
+	}
+	r.ScopeLogsStats.LogRecordStats.RecordScopeLogsSize(logsPerScopeLogs)
+
+	r.TotalCount += int64(resLogsCount)
+	carrow.RequireNoError(r.Distribution.RecordValue(int64(resLogsCount)))
+	carrow.RequireNoError(r.ScopeLogsStats.Distribution.RecordValue(int64(scopeLogsCount)))
 }
 
-func (r *ResourceLogsStats) ShowStats(indent string) {
-	fmt.Printf("%s                                 |         Distribution per request        |\n", indent)
-	print(carrow.Green)
-	fmt.Printf("%sResourceLogs%s  |    Total|Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s              |%9d|%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
+func (r *ResourceLogsStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s                                 |         Distribution per request        |\n", indent)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sResourceLogs%s  |    Total|Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s              |%9d|%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
 		r.TotalCount, r.ResLogsIDsDistinct.Estimate(), r.Distribution.Min(), r.Distribution.Max(), r.Distribution.Mean(), r.Distribution.StdDev(), r.Distribution.ValueAtQuantile(50), r.Distribution.ValueAtQuantile(99),
 	)
 	indent += "  "
-	r.ResourceStats.ShowStats(indent)
-	r.ScopeLogsStats.ShowStats(indent)
-	r.SchemaUrlStats.ShowStats(indent)
+	r.ResourceStats.ShowStats(w, indent)
+	r.ScopeLogsStats.ShowStats(w, indent)
+	r.SchemaUrlStats.ShowStats(w, indent)
+}
+
+func (s *ScopeLogsStats) UpdateWith(resScope *ResScope) {
+	s.ScopeLogsIDsDistinct.Insert([]byte(strconv.Itoa(resScope.ScopeLogsID)))
+	s.ScopeStats.UpdateWith(resScope.Scope)
+	s.SchemaUrlStats.UpdateWith(resScope.ScopeSchemaUrl)
 }
 
-//func (s *ScopeLogsStats) UpdateWith(scopeLogs []*ScopeLogGroup, scopeLogsIdx map[string]int) {
-//	carrow.RequireNoError(s.Distribution.RecordValue(int64(len(scopeLogs))))
-//
-//	for ID := range scopeLogsIdx {
-//		s.ScopeLogsIDsDistinct.Insert([]byte(ID))
-//	}
-//
-//	for _, sl := range scopeLogs {
-//		s.ScopeStats.UpdateWith(sl.Scope)
-//		s.LogRecordStats.UpdateWith(sl)
-//		s.SchemaUrlStats.UpdateWith(sl.ScopeSchemaUrl)
-//	}
-//}
-
-func (s *ScopeLogsStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sScopeLogs%s  |Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s           |%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
+func (s *ScopeLogsStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sScopeLogs%s  |Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s           |%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
 		s.ScopeLogsIDsDistinct.Estimate(), s.Distribution.Min(), s.Distribution.Max(), s.Distribution.Mean(), s.Distribution.StdDev(), s.Distribution.ValueAtQuantile(50), s.Distribution.ValueAtQuantile(99),
 	)
-	s.ScopeStats.ShowStats(indent + "  ")
-	s.LogRecordStats.ShowStats(indent + "  ")
-	s.SchemaUrlStats.ShowStats(indent + "  ")
+	s.ScopeStats.ShowStats(w, indent+"  ")
+	s.LogRecordStats.ShowStats(w, indent+"  ")
+	s.SchemaUrlStats.ShowStats(w, indent+"  ")
 }
 
 func NewLogRecordStats() *LogRecordStats {
@@ -181,38 +198,40 @@ func NewLogRecordStats() *LogRecordStats {
 	}
 }
 
-//func (s *LogRecordStats) UpdateWith(sl *ScopeLogGroup) {
-//	logs := sl.Logs
-//	carrow.RequireNoError(s.Distribution.RecordValue(int64(len(logs))))
-//
-//	for _, logRecord := range logs {
-//		s.TimeUnixNano.UpdateWith(logRecord.Timestamp())
-//		s.ObservedTimeUnixNano.UpdateWith(logRecord.ObservedTimestamp())
-//		s.Attributes.UpdateWith(logRecord.Attributes(), logRecord.DroppedAttributesCount())
-//		s.SpanID.Insert([]byte(logRecord.SpanID().String()))
-//		s.TraceID.Insert([]byte(logRecord.TraceID().String()))
-//		s.SeverityNumber.Insert([]byte(strconv.Itoa(int(logRecord.SeverityNumber()))))
-//		s.SeverityText.UpdateWith(logRecord.SeverityText())
-//		s.Body.UpdateWith(logRecord.Body())
-//	}
-//
-//	s.TotalCount += int64(len(logs))
-//}
-
-func (s *LogRecordStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sLogRecords%s |   Total|   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s           |%8d|%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
+// RecordScopeLogsSize records the number of log records of a scope logs, the
+// empty scope logs are ignored.
+func (s *LogRecordStats) RecordScopeLogsSize(count int) {
+	if count > 0 {
+		carrow.RequireNoError(s.Distribution.RecordValue(int64(count)))
+	}
+}
+
+func (s *LogRecordStats) UpdateWith(logRecord plog.LogRecord) {
+	s.TimeUnixNano.UpdateWith(logRecord.Timestamp())
+	s.ObservedTimeUnixNano.UpdateWith(logRecord.ObservedTimestamp())
+	s.Attributes.UpdateWith(logRecord.Attributes(), logRecord.DroppedAttributesCount())
+	s.SpanID.Insert([]byte(logRecord.SpanID().String()))
+	s.TraceID.Insert([]byte(logRecord.TraceID().String()))
+	s.SeverityNumber.Insert([]byte(strconv.Itoa(int(logRecord.SeverityNumber()))))
+	s.SeverityText.UpdateWith(logRecord.SeverityText())
+	s.Body.UpdateWith(logRecord.Body())
+	s.TotalCount++
+}
+
+func (s *LogRecordStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sLogRecords%s |   Total|   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s           |%8d|%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
 		s.TotalCount, s.Distribution.Min(), s.Distribution.Max(), s.Distribution.Mean(), s.Distribution.StdDev(), s.Distribution.ValueAtQuantile(50), s.Distribution.ValueAtQuantile(99),
 	)
 	indent += "  "
-	s.TimeUnixNano.ShowStats("TimeUnixNano", indent)
-	s.ObservedTimeUnixNano.ShowStats("ObservedTimeUnixNano", indent)
-	fmt.Printf("%s             |Distinct|   Total|%%Distinct|\n", indent)
-	fmt.Printf("%s%sSpanID%s       |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SpanID.Estimate(), s.TotalCount, 100.0*float64(s.SpanID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sTraceID%s      |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.TraceID.Estimate(), s.TotalCount, 100.0*float64(s.TraceID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sSeverityNumber%s|%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SeverityNumber.Estimate(), s.TotalCount, 100.0*float64(s.SeverityNumber.Estimate())/float64(s.TotalCount))
-	s.SeverityText.ShowStats("SeverityText", indent)
-	s.Attributes.ShowStats(indent, "Attributes", carrow.Green)
-	s.Body.ShowStats(indent, "Body", carrow.Green)
+	s.TimeUnixNano.ShowStats(w, "TimeUnixNano", indent)
+	s.ObservedTimeUnixNano.ShowStats(w, "ObservedTimeUnixNano", indent)
+	fmt.Fprintf(w, "%s             |Distinct|   Total|%%Distinct|\n", indent)
+	fmt.Fprintf(w, "%s%sSpanID%s       |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SpanID.Estimate(), s.TotalCount, 100.0*float64(s.SpanID.Estimate())/float64(s.TotalCount))
+	fmt.Fprintf(w, "%s%sTraceID%s      |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.TraceID.Estimate(), s.TotalCount, 100.0*float64(s.TraceID.Estimate())/float64(s.TotalCount))
+	fmt.Fprintf(w, "%s%sSeverityNumber%s|%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SeverityNumber.Estimate(), s.TotalCount, 100.0*float64(s.SeverityNumber.Estimate())/float64(s.TotalCount))
+	s.SeverityText.ShowStats(w, "SeverityText", indent)
+	s.Attributes.ShowStats(w, indent, "Attributes", carrow.Green)
+	s.Body.ShowStats(w, indent, "Body", carrow.Green)
 }
@@ -18,10 +18,14 @@
 package arrow
 
 import (
+	"strconv"
+	"time"
+
 	"github.com/apache/arrow/go/v12/arrow"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 
+	"github.com/f5/otel-arrow-adapter/pkg/config"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common"
 	acommon "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
@@ -37,6 +41,9 @@ var (
 		{Name: constants.ID, Type: arrow.PrimitiveTypes.Uint16, Metadata: schema.Metadata(schema.Optional, schema.DeltaEncoding)},
 		{Name: constants.Resource, Type: acommon.ResourceDT, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.Scope, Type: acommon.ScopeDT, Metadata: schema.Metadata(schema.Optional)},
+		// ID of the scope in the scope table, replacing the scope struct (see
+		// config.WithScopeTable).
+		{Name: constants.ScopeID, Type: arrow.PrimitiveTypes.Uint16, Metadata: schema.Metadata(schema.Optional)},
 		// This schema URL applies to the span and span events (the schema URL
 		// for the resource is in the resource struct).
 		{Name: constants.SchemaUrl, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
@@ -46,6 +53,7 @@ var (
 		{Name: constants.SpanId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 8}, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
 		{Name: constants.SeverityNumber, Type: arrow.PrimitiveTypes.Int32, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
 		{Name: constants.SeverityText, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
+		{Name: constants.EventName, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
 		{Name: constants.Body, Type: arrow.StructOf([]arrow.Field{
 			{Name: constants.BodyType, Type: arrow.PrimitiveTypes.Uint8},
 			{Name: constants.BodyStr, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary16)},
@@ -54,9 +62,16 @@ var (
 			{Name: constants.BodyBool, Type: arrow.FixedWidthTypes.Boolean, Metadata: schema.Metadata(schema.Optional)},
 			{Name: constants.BodyBytes, Type: arrow.BinaryTypes.Binary, Metadata: schema.Metadata(schema.Optional, schema.Dictionary16)},
 			{Name: constants.BodySer, Type: arrow.BinaryTypes.Binary, Metadata: schema.Metadata(schema.Optional, schema.Dictionary16)},
+			// Template extracted from a string body (see config.WithLogTemplates),
+			// the dictionary index of a template identifies it.
+			{Name: constants.BodyTemplate, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Optional, schema.Dictionary16)},
+			// CBOR-encoded list of the values of the template variables.
+			{Name: constants.BodyTemplateVars, Type: arrow.BinaryTypes.Binary, Metadata: schema.Metadata(schema.Optional)},
 		}...)},
 		{Name: constants.DroppedAttributesCount, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.Flags, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
+		// Expiry of the log records (see config.WithRetention).
+		{Name: constants.ExpiryTimeUnixNano, Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: schema.Metadata(schema.Optional)},
 	}, nil)
 )
 
@@ -68,6 +83,7 @@ type LogsBuilder struct {
 
 	rb    *acommon.ResourceBuilder        // `resource` builder
 	scb   *acommon.ScopeBuilder           // `scope` builder
+	scidb *builder.Uint16Builder          // scope id builder (scope table)
 	sschb *builder.StringBuilder          // scope `schema_url` builder
 	ib    *builder.Uint16DeltaBuilder     //  id builder
 	tub   *builder.TimestampBuilder       // `time_unix_nano` builder
@@ -76,6 +92,7 @@ type LogsBuilder struct {
 	sidb  *builder.FixedSizeBinaryBuilder // `span_id` builder
 	snb   *builder.Int32Builder           // `severity_number` builder
 	stb   *builder.StringBuilder          // `severity_text` builder
+	enb   *builder.StringBuilder          // `event_name` builder
 
 	bodyb *builder.StructBuilder // `body` builder
 	typeb *builder.Uint8Builder
@@ -85,12 +102,34 @@ type LogsBuilder struct {
 	boolb *builder.BooleanBuilder
 	binb  *builder.BinaryBuilder
 	serb  *builder.BinaryBuilder
+	tmplb *builder.StringBuilder // body `tmpl` builder
+	varsb *builder.BinaryBuilder // body `tmpl_vars` builder
 
-	dacb *builder.Uint32Builder // `dropped_attributes_count` builder
-	fb   *builder.Uint32Builder // `flags` builder
+	dacb *builder.Uint32Builder    // `dropped_attributes_count` builder
+	fb   *builder.Uint32Builder    // `flags` builder
+	expb *builder.TimestampBuilder // `expiry_time_unix_nano` builder
 
 	optimizer *LogsOptimizer
 	analyzer  *LogsAnalyzer
+	reporter  *acommon.AnalyzerReporter
+
+	// emptyPerRecord reserves an attributes ID for each resource and scope
+	// entry without attributes (see config.EmptyPerRecord).
+	emptyPerRecord bool
+	// scopeTable references the scopes by their ID in the scope table (see
+	// config.WithScopeTable).
+	scopeTable bool
+
+	// templates is nil when the extraction of log body templates is
+	// disabled.
+	templates *TemplateMiner
+	// templatesSuspended temporarily disables the extraction of log body
+	// templates (see SuspendTemplates).
+	templatesSuspended bool
+
+	// retention is the retention stamped in the expiry column, 0 if the
+	// column is disabled (see config.WithRetention).
+	retention time.Duration
 
 	relatedData *RelatedData
 }
@@ -103,6 +142,9 @@ func NewLogsBuilder(
 ) (*LogsBuilder, error) {
 	var optimizer *LogsOptimizer
 	var analyzer *LogsAnalyzer
+	var reporter *acommon.AnalyzerReporter
+
+	emptyPerRecord := cfg.Global != nil && cfg.Global.EmptyResourceScope == config.EmptyPerRecord
 
 	relatedData, err := NewRelatedData(cfg, stats)
 	if err != nil {
@@ -110,18 +152,32 @@ func NewLogsBuilder(
 	}
 
 	if stats.SchemaStatsEnabled {
-		optimizer = NewLogsOptimizer(cfg.Log.Sorter)
+		optimizer = NewLogsOptimizer(cfg.Log.Sorter, emptyPerRecord)
 		analyzer = NewLogsAnalyzer()
+		reporter, err = acommon.NewAnalyzerReporter(cfg.Global)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
 	} else {
-		optimizer = NewLogsOptimizer(cfg.Log.Sorter)
+		optimizer = NewLogsOptimizer(cfg.Log.Sorter, emptyPerRecord)
 	}
 
 	b := &LogsBuilder{
-		released:    false,
-		builder:     recordBuilder,
-		optimizer:   optimizer,
-		analyzer:    analyzer,
-		relatedData: relatedData,
+		released:       false,
+		builder:        recordBuilder,
+		optimizer:      optimizer,
+		analyzer:       analyzer,
+		reporter:       reporter,
+		emptyPerRecord: emptyPerRecord,
+		scopeTable:     cfg.Global != nil && cfg.Global.ScopeTable,
+		relatedData:    relatedData,
+	}
+
+	if cfg.Global != nil && cfg.Global.LogTemplates {
+		b.templates = NewTemplateMiner()
+	}
+	if cfg.Global != nil {
+		b.retention = cfg.Global.Retention.Logs
 	}
 
 	if err := b.init(); err != nil {
@@ -140,6 +196,7 @@ func (b *LogsBuilder) init() error {
 	b.ib = ib
 	b.rb = acommon.ResourceBuilderFrom(b.builder.StructBuilder(constants.Resource))
 	b.scb = acommon.ScopeBuilderFrom(b.builder.StructBuilder(constants.Scope))
+	b.scidb = b.builder.Uint16Builder(constants.ScopeID)
 	b.sschb = b.builder.StringBuilder(constants.SchemaUrl)
 
 	b.tub = b.builder.TimestampBuilder(constants.TimeUnixNano)
@@ -148,6 +205,7 @@ func (b *LogsBuilder) init() error {
 	b.sidb = b.builder.FixedSizeBinaryBuilder(constants.SpanId)
 	b.snb = b.builder.Int32Builder(constants.SeverityNumber)
 	b.stb = b.builder.StringBuilder(constants.SeverityText)
+	b.enb = b.builder.StringBuilder(constants.EventName)
 
 	b.bodyb = b.builder.StructBuilder(constants.Body)
 	b.typeb = b.bodyb.Uint8Builder(constants.BodyType)
@@ -157,17 +215,33 @@ func (b *LogsBuilder) init() error {
 	b.boolb = b.bodyb.BooleanBuilder(constants.BodyBool)
 	b.binb = b.bodyb.BinaryBuilder(constants.BodyBytes)
 	b.serb = b.bodyb.BinaryBuilder(constants.BodySer)
+	b.tmplb = b.bodyb.StringBuilder(constants.BodyTemplate)
+	b.varsb = b.bodyb.BinaryBuilder(constants.BodyTemplateVars)
 
 	b.dacb = b.builder.Uint32Builder(constants.DroppedAttributesCount)
 	b.fb = b.builder.Uint32Builder(constants.Flags)
+	b.expb = b.builder.TimestampBuilder(constants.ExpiryTimeUnixNano)
 
 	return nil
 }
 
+// SuspendTemplates suspends (or resumes) the extraction of log body templates
+// when it is enabled, e.g. to reduce the encoding cost. The templates already
+// mined are kept.
+func (b *LogsBuilder) SuspendTemplates(suspended bool) {
+	b.templatesSuspended = suspended
+}
+
 func (b *LogsBuilder) RelatedData() *RelatedData {
 	return b.relatedData
 }
 
+// Analyzer returns the analyzer of the builder, nil if the schema stats are
+// disabled (see config.WithStats).
+func (b *LogsBuilder) Analyzer() *LogsAnalyzer {
+	return b.analyzer
+}
+
 // Build builds an Arrow Record from the builder.
 //
 // Once the array is no longer needed, Release() must be called to free the
@@ -188,16 +262,45 @@ func (b *LogsBuilder) Build() (record arrow.Record, err error) {
 	return
 }
 
+// checkpoint marks the beginning of a batch, i.e. the state restored by
+// rollback.
+func (b *LogsBuilder) checkpoint() {
+	b.builder.Checkpoint()
+	b.relatedData.Checkpoint()
+}
+
+// rollback discards the rows and the related data appended since the
+// beginning of the current batch and retrieves the new underlying builders.
+func (b *LogsBuilder) rollback() error {
+	b.builder.Rollback()
+	b.relatedData.Rollback()
+	if err := b.init(); err != nil {
+		return werror.Wrap(err)
+	}
+	return nil
+}
+
 // Append appends a new set of resource logs to the builder.
 func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 	if b.released {
 		return werror.Wrap(acommon.ErrBuilderAlreadyReleased)
 	}
 
+	// The rows of a batch that fails to append are discarded, so that they
+	// are not mixed with the rows of the next batch.
+	b.checkpoint()
+	defer func() {
+		if err != nil {
+			if rollbackErr := b.rollback(); rollbackErr != nil {
+				err = werror.WrapWithContext(err, map[string]interface{}{"rollback_error": rollbackErr.Error()})
+			}
+		}
+	}()
+
 	optimLogs := b.optimizer.Optimize(logs)
-	if b.analyzer != nil {
+	if b.analyzer != nil && b.reporter.Sample() {
 		b.analyzer.Analyze(optimLogs)
-		b.analyzer.ShowStats("")
+		b.reporter.Report(b.analyzer)
 	}
 
 	attrsAccu := b.relatedData.AttrsBuilders().LogRecord().Accumulator()
@@ -206,6 +309,12 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 	resLogID := -1
 	scopeLogID := -1
 	var resID, scopeID int64
+	var scopeTableID uint16
+
+	var expiry arrow.Timestamp
+	if b.retention > 0 {
+		expiry = arrow.Timestamp(time.Now().Add(b.retention).UnixNano())
+	}
 
 	b.builder.Reserve(len(optimLogs.Logs))
 
@@ -229,22 +338,39 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 			if err != nil {
 				return werror.Wrap(err)
 			}
+			if resID < 0 && b.emptyPerRecord {
+				resID = b.relatedData.AttrsBuilders().Resource().Accumulator().AppendEmpty()
+			}
 		}
 		if err = b.rb.AppendWithID(resID, logRec.ResScope.Resource, logRec.ResScope.ResourceSchemaUrl); err != nil {
 			return werror.Wrap(err)
 		}
 
 		// Scope logs
-		if scopeLogID != logRec.ResScope.ScopeLogsID {
-			scopeLogID = logRec.ResScope.ScopeLogsID
-			scopeID, err = b.relatedData.AttrsBuilders().scope.Accumulator().Append(logRec.ResScope.Scope.Attributes())
-			if err != nil {
+		if b.scopeTable {
+			if scopeLogID != logRec.ResScope.ScopeLogsID {
+				scopeLogID = logRec.ResScope.ScopeLogsID
+				scopeTableID, err = b.relatedData.ScopeTableBuilder().Accumulator().Append(strconv.Itoa(scopeLogID), logRec.ResScope.Scope)
+				if err != nil {
+					return werror.Wrap(err)
+				}
+			}
+			b.scidb.Append(scopeTableID)
+		} else {
+			if scopeLogID != logRec.ResScope.ScopeLogsID {
+				scopeLogID = logRec.ResScope.ScopeLogsID
+				scopeID, err = b.relatedData.AttrsBuilders().scope.Accumulator().Append(logRec.ResScope.Scope.Attributes())
+				if err != nil {
+					return werror.Wrap(err)
+				}
+				if scopeID < 0 && b.emptyPerRecord {
+					scopeID = b.relatedData.AttrsBuilders().scope.Accumulator().AppendEmpty()
+				}
+			}
+			if err = b.scb.AppendWithAttrsID(scopeID, logRec.ResScope.Scope); err != nil {
 				return werror.Wrap(err)
 			}
 		}
-		if err = b.scb.AppendWithAttrsID(scopeID, logRec.ResScope.Scope); err != nil {
-			return werror.Wrap(err)
-		}
 		b.sschb.AppendNonEmpty(logRec.ResScope.ScopeSchemaUrl)
 
 		b.tub.Append(arrow.Timestamp(log.Timestamp()))
@@ -255,21 +381,13 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 		b.sidb.Append(sib[:])
 		b.snb.AppendNonZero(int32(log.SeverityNumber()))
 		b.stb.AppendNonEmpty(log.SeverityText())
+		b.enb.AppendNonEmpty(log.EventName())
 
 		// Log record body
 		body := log.Body()
 		switch body.Type() {
 		case pcommon.ValueTypeStr:
-			err = b.bodyb.Append(body, func() error {
-				b.typeb.Append(uint8(pcommon.ValueTypeStr))
-				b.strb.Append(body.Str())
-				b.i64b.AppendNull()
-				b.f64b.AppendNull()
-				b.boolb.AppendNull()
-				b.binb.AppendNull()
-				b.serb.AppendNull()
-				return nil
-			})
+			err = b.appendStrBody(body)
 			if err != nil {
 				return werror.Wrap(err)
 			}
@@ -282,6 +400,8 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 				b.boolb.AppendNull()
 				b.binb.AppendNull()
 				b.serb.AppendNull()
+				b.tmplb.AppendNull()
+				b.varsb.AppendNull()
 				return nil
 			})
 			if err != nil {
@@ -296,6 +416,8 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 				b.boolb.AppendNull()
 				b.binb.AppendNull()
 				b.serb.AppendNull()
+				b.tmplb.AppendNull()
+				b.varsb.AppendNull()
 				return nil
 			})
 			if err != nil {
@@ -310,6 +432,8 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 				b.f64b.AppendNull()
 				b.binb.AppendNull()
 				b.serb.AppendNull()
+				b.tmplb.AppendNull()
+				b.varsb.AppendNull()
 				return nil
 			})
 			if err != nil {
@@ -324,6 +448,8 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 				b.f64b.AppendNull()
 				b.boolb.AppendNull()
 				b.serb.AppendNull()
+				b.tmplb.AppendNull()
+				b.varsb.AppendNull()
 				return nil
 			})
 			if err != nil {
@@ -342,6 +468,8 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 				b.f64b.AppendNull()
 				b.boolb.AppendNull()
 				b.binb.AppendNull()
+				b.tmplb.AppendNull()
+				b.varsb.AppendNull()
 				return nil
 			})
 			if err != nil {
@@ -360,6 +488,8 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 				b.f64b.AppendNull()
 				b.boolb.AppendNull()
 				b.binb.AppendNull()
+				b.tmplb.AppendNull()
+				b.varsb.AppendNull()
 				return nil
 			})
 			if err != nil {
@@ -380,10 +510,60 @@ func (b *LogsBuilder) Append(logs plog.Logs) (err error) {
 		b.dacb.AppendNonZero(log.DroppedAttributesCount())
 
 		b.fb.Append(uint32(log.Flags()))
+
+		if expiry != 0 {
+			b.expb.Append(expiry)
+		}
 	}
 	return nil
 }
 
+// appendStrBody appends a string body, as a template and its variables if
+// the body matches a template.
+func (b *LogsBuilder) appendStrBody(body pcommon.Value) error {
+	var template string
+	var vars []string
+	var ok bool
+
+	if b.templates != nil && !b.templatesSuspended {
+		template, vars, ok = b.templates.Extract(body.Str())
+	}
+
+	var cborVars []byte
+	if len(vars) > 0 {
+		varsValue := pcommon.NewValueSlice()
+		varsSlice := varsValue.Slice()
+		varsSlice.EnsureCapacity(len(vars))
+		for _, v := range vars {
+			varsSlice.AppendEmpty().SetStr(v)
+		}
+		var err error
+		cborVars, err = common.Serialize(&varsValue)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+	}
+
+	return b.bodyb.Append(body, func() error {
+		b.typeb.Append(uint8(pcommon.ValueTypeStr))
+		if ok {
+			b.strb.AppendNull()
+			b.tmplb.Append(template)
+			b.varsb.AppendNonNil(cborVars)
+		} else {
+			b.strb.Append(body.Str())
+			b.tmplb.AppendNull()
+			b.varsb.AppendNull()
+		}
+		b.i64b.AppendNull()
+		b.f64b.AppendNull()
+		b.boolb.AppendNull()
+		b.binb.AppendNull()
+		b.serb.AppendNull()
+		return nil
+	})
+}
+
 // Release releases the memory allocated by the builder.
 func (b *LogsBuilder) Release() {
 	if !b.released {
@@ -391,6 +571,9 @@ func (b *LogsBuilder) Release() {
 		b.released = true
 
 		b.relatedData.Release()
+		if b.reporter != nil {
+			_ = b.reporter.Close()
+		}
 	}
 }
 
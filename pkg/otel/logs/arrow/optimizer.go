@@ -24,12 +24,21 @@ import (
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/hashmap"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
 )
 
 type (
 	LogsOptimizer struct {
 		sorter LogSorter
+
+		// Resource and scope IDs, reset (but not reallocated) for each batch.
+		resLogsIDs   *hashmap.StringMap
+		scopeLogsIDs *hashmap.StringMap
+
+		// emptyPerRecord keeps the empty resources and scopes of the
+		// resource and scope logs separate (see config.EmptyPerRecord).
+		emptyPerRecord bool
 	}
 
 	LogsOptimized struct {
@@ -61,9 +70,12 @@ type (
 	LogsByResourceLogsIDScopeLogsIDTraceID struct{}
 )
 
-func NewLogsOptimizer(sorter LogSorter) *LogsOptimizer {
+func NewLogsOptimizer(sorter LogSorter, emptyPerRecord bool) *LogsOptimizer {
 	return &LogsOptimizer{
-		sorter: sorter,
+		sorter:         sorter,
+		resLogsIDs:     hashmap.NewStringMap(0),
+		scopeLogsIDs:   hashmap.NewStringMap(0),
+		emptyPerRecord: emptyPerRecord,
 	}
 }
 
@@ -72,38 +84,32 @@ func (t *LogsOptimizer) Optimize(logs plog.Logs) *LogsOptimized {
 		Logs: make([]*FlattenedLog, 0, 32),
 	}
 
-	resLogsIDs := make(map[string]int)
-	scopeLogsIDs := make(map[string]int)
+	resLogsIDs := t.resLogsIDs
+	scopeLogsIDs := t.scopeLogsIDs
+	resLogsIDs.Reset()
+	scopeLogsIDs.Reset()
 
 	resLogsSlice := logs.ResourceLogs()
 	for i := 0; i < resLogsSlice.Len(); i++ {
 		resLogs := resLogsSlice.At(i)
 		resource := resLogs.Resource()
 		resourceSchemaUrl := resLogs.SchemaUrl()
-		ID := otlp.ResourceID(resource, resourceSchemaUrl)
-		resLogsID, found := resLogsIDs[ID]
-		if !found {
-			resLogsID = len(resLogsIDs)
-			resLogsIDs[ID] = resLogsID
-		}
+		ID := otlp.ResourceEntryID(resource, resourceSchemaUrl, t.emptyPerRecord, i)
+		resLogsID, _ := resLogsIDs.GetOrInsert(ID, uint32(resLogsIDs.Len()))
 
 		scopeLogs := resLogs.ScopeLogs()
 		for j := 0; j < scopeLogs.Len(); j++ {
 			scopeSpan := scopeLogs.At(j)
 			scope := scopeSpan.Scope()
 			scopeSchemaUrl := scopeSpan.SchemaUrl()
-			ID = otlp.ScopeID(scope, scopeSchemaUrl)
-			scopeLogsID, found := scopeLogsIDs[ID]
-			if !found {
-				scopeLogsID = len(scopeLogsIDs)
-				scopeLogsIDs[ID] = scopeLogsID
-			}
+			ID = otlp.ScopeEntryID(scope, scopeSchemaUrl, t.emptyPerRecord, i, j)
+			scopeLogsID, _ := scopeLogsIDs.GetOrInsert(ID, uint32(scopeLogsIDs.Len()))
 
 			resScope := &ResScope{
-				ResourceLogsID:    resLogsID,
+				ResourceLogsID:    int(resLogsID),
 				Resource:          resource,
 				ResourceSchemaUrl: resourceSchemaUrl,
-				ScopeLogsID:       scopeLogsID,
+				ScopeLogsID:       int(scopeLogsID),
 				Scope:             scope,
 				ScopeSchemaUrl:    scopeSchemaUrl,
 			}
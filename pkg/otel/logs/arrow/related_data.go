@@ -32,7 +32,8 @@ type (
 	RelatedData struct {
 		relatedRecordsManager *carrow.RelatedRecordsManager
 
-		attrsBuilders *AttrsBuilders
+		attrsBuilders     *AttrsBuilders
+		scopeTableBuilder *carrow.ScopeTableBuilder
 	}
 
 	// AttrsBuilders groups together AttrsBuilder instances used to build related
@@ -56,6 +57,12 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 		return carrow.NewAttrs16BuilderWithEncoding(b, carrow.PayloadTypes.ScopeAttrs, cfg.Attrs.Scope)
 	})
 
+	scopeTableBuilder := rrManager.Declare(carrow.PayloadTypes.Scopes, carrow.PayloadTypes.Logs, carrow.ScopeTableSchema, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
+		sb := carrow.NewScopeTableBuilder(b)
+		sb.SetAttributesAccumulator(attrsScopeBuilder.(*carrow.Attrs16Builder).Accumulator())
+		return sb
+	})
+
 	attrsLogRecordBuilder := rrManager.Declare(carrow.PayloadTypes.LogRecordAttrs, carrow.PayloadTypes.Logs, carrow.AttrsSchema16, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
 		return carrow.NewAttrs16BuilderWithEncoding(b, carrow.PayloadTypes.LogRecordAttrs, cfg.Attrs.Log)
 	})
@@ -67,6 +74,7 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 			scope:     attrsScopeBuilder.(*carrow.Attrs16Builder),
 			logRecord: attrsLogRecordBuilder.(*carrow.Attrs16Builder),
 		},
+		scopeTableBuilder: scopeTableBuilder.(*carrow.ScopeTableBuilder),
 	}, nil
 }
 
@@ -82,6 +90,10 @@ func (r *RelatedData) AttrsBuilders() *AttrsBuilders {
 	return r.attrsBuilders
 }
 
+func (r *RelatedData) ScopeTableBuilder() *carrow.ScopeTableBuilder {
+	return r.scopeTableBuilder
+}
+
 func (r *RelatedData) RecordBuilderExt(payloadType *carrow.PayloadType) *builder.RecordBuilderExt {
 	return r.relatedRecordsManager.RecordBuilderExt(payloadType)
 }
@@ -90,6 +102,17 @@ func (r *RelatedData) Reset() {
 	r.relatedRecordsManager.Reset()
 }
 
+// Checkpoint marks the current state of the related data as the state restored
+// by the next call to Rollback.
+func (r *RelatedData) Checkpoint() {
+	r.relatedRecordsManager.Checkpoint()
+}
+
+// Rollback discards the related data appended since the last checkpoint.
+func (r *RelatedData) Rollback() {
+	r.relatedRecordsManager.Rollback()
+}
+
 func (r *RelatedData) BuildRecordMessages() ([]*record_message.RecordMessage, error) {
 	return r.relatedRecordsManager.BuildRecordMessages()
 }
@@ -0,0 +1,85 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package arrow
+
+import (
+	"bytes"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Names of the built-in log sorters (see RegisterLogSorter).
+const (
+	UnsortedLogSorter             = "unsorted"
+	ByResourceScopeTraceLogSorter = "resource_scope_trace_id"
+	DefaultLogSorter              = ByResourceScopeTraceLogSorter
+)
+
+var logSorters = arrow.NewSorterRegistry[LogSorter]("log")
+
+func init() {
+	RegisterLogSorter(UnsortedLogSorter, func() LogSorter { return UnsortedLogs() })
+	RegisterLogSorter(ByResourceScopeTraceLogSorter, func() LogSorter { return SortLogsByResourceLogsIDScopeLogsIDTraceID() })
+}
+
+// RegisterLogSorter registers a custom sort order of the log records under
+// the given name, which can then be selected with config.WithLogSorter. A
+// sorter registered with the name of a built-in sorter replaces it.
+func RegisterLogSorter(name string, newSorter func() LogSorter) {
+	logSorters.Register(name, newSorter)
+}
+
+// NewLogSorter creates the log sorter registered under the given name.
+func NewLogSorter(name string) (LogSorter, error) {
+	return logSorters.New(name)
+}
+
+// LogSorterNames returns the names of the registered log sorters.
+func LogSorterNames() []string {
+	return logSorters.Names()
+}
+
+// logSortKeys are the sort keys supported by SortLogsByKeys.
+var logSortKeys = map[string]arrow.Comparator[*FlattenedLog]{
+	"resource": func(a, b *FlattenedLog) int { return a.ResScope.ResourceLogsID - b.ResScope.ResourceLogsID },
+	"scope":    func(a, b *FlattenedLog) int { return a.ResScope.ScopeLogsID - b.ResScope.ScopeLogsID },
+	"trace_id": func(a, b *FlattenedLog) int {
+		traceA, traceB := a.Log.TraceID(), b.Log.TraceID()
+		return bytes.Compare(traceA[:], traceB[:])
+	},
+	"span_id": func(a, b *FlattenedLog) int {
+		spanA, spanB := a.Log.SpanID(), b.Log.SpanID()
+		return bytes.Compare(spanA[:], spanB[:])
+	},
+	"severity": func(a, b *FlattenedLog) int { return int(a.Log.SeverityNumber()) - int(b.Log.SeverityNumber()) },
+	"timestamp": func(a, b *FlattenedLog) int {
+		return arrow.CompareUint64(uint64(a.Log.Timestamp()), uint64(b.Log.Timestamp()))
+	},
+}
+
+// SortLogsByKeys creates a sorter of the log records by the given sort keys,
+// the first key being the most significant, among "resource", "scope",
+// "trace_id", "span_id", "severity" and "timestamp". The default sorter is
+// equivalent to the keys resource, scope, trace_id.
+func SortLogsByKeys(keys ...string) (LogSorter, error) {
+	sorter, err := arrow.NewKeySorter(keys, logSortKeys)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return sorter, nil
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+// Extraction of templates from string log bodies.
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// TemplateWildcard is the token used in a template in place of a
+	// variable.
+	TemplateWildcard = "<*>"
+
+	// templateSimilarity is the minimum fraction of tokens that a body must
+	// share with a template to be merged into it.
+	templateSimilarity = 0.5
+
+	// maxTemplates is the maximum number of templates tracked by a miner
+	// before it forgets them and starts over.
+	maxTemplates = 1024
+)
+
+type (
+	// TemplateMiner clusters string log bodies into templates following a
+	// simplified version of the Drain algorithm. Bodies are split into tokens
+	// on single spaces, tokens containing a digit are considered as variables,
+	// and bodies with the same number of tokens and the same first token are
+	// compared with the known templates of their group. A body is merged into
+	// the most similar template if enough tokens are equal, the positions that
+	// differ becoming variables. Otherwise, a new template is created.
+	//
+	// Templates evolve as bodies are merged, so the same body may produce
+	// different templates over time. This doesn't prevent the reconstruction
+	// of the body as each log record carries its own template and variables.
+	TemplateMiner struct {
+		groups map[templateGroup][]*logTemplate
+		count  int
+	}
+
+	templateGroup struct {
+		tokenCount int
+		firstToken string
+	}
+
+	logTemplate struct {
+		tokens []string
+	}
+)
+
+// NewTemplateMiner creates a new TemplateMiner.
+func NewTemplateMiner() *TemplateMiner {
+	return &TemplateMiner{
+		groups: make(map[templateGroup][]*logTemplate),
+	}
+}
+
+// Extract returns the template of the given body and the values of its
+// variables. ok is false if the body can't be represented as a template, in
+// which case the body must be encoded as is.
+func (m *TemplateMiner) Extract(body string) (template string, vars []string, ok bool) {
+	tokens := strings.Split(body, " ")
+	if len(tokens) < 2 {
+		return "", nil, false
+	}
+	for _, token := range tokens {
+		// A body containing the wildcard could not be reconstructed.
+		if token == TemplateWildcard {
+			return "", nil, false
+		}
+	}
+
+	group := templateGroup{tokenCount: len(tokens), firstToken: tokens[0]}
+	if isVariable(tokens[0]) {
+		group.firstToken = TemplateWildcard
+	}
+
+	tmpl := m.bestMatch(m.groups[group], tokens)
+	if tmpl != nil {
+		tmpl.merge(tokens)
+	} else {
+		if m.count >= maxTemplates {
+			m.groups = make(map[templateGroup][]*logTemplate)
+			m.count = 0
+		}
+		tmpl = newLogTemplate(tokens)
+		m.groups[group] = append(m.groups[group], tmpl)
+		m.count++
+	}
+
+	for i, token := range tmpl.tokens {
+		if token == TemplateWildcard {
+			vars = append(vars, tokens[i])
+		}
+	}
+
+	return strings.Join(tmpl.tokens, " "), vars, true
+}
+
+func (m *TemplateMiner) bestMatch(templates []*logTemplate, tokens []string) *logTemplate {
+	var best *logTemplate
+	bestSimilarity := templateSimilarity
+
+	for _, tmpl := range templates {
+		similarity := tmpl.similarity(tokens)
+		if similarity >= bestSimilarity {
+			best = tmpl
+			bestSimilarity = similarity
+		}
+	}
+
+	return best
+}
+
+func newLogTemplate(tokens []string) *logTemplate {
+	tmpl := &logTemplate{tokens: make([]string, len(tokens))}
+	for i, token := range tokens {
+		if isVariable(token) {
+			tmpl.tokens[i] = TemplateWildcard
+		} else {
+			tmpl.tokens[i] = token
+		}
+	}
+	return tmpl
+}
+
+// similarity returns the fraction of tokens matching the template, i.e.
+// equal to a constant token or at the position of a variable.
+func (t *logTemplate) similarity(tokens []string) float64 {
+	equal := 0
+	for i, token := range t.tokens {
+		if token == TemplateWildcard || token == tokens[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(tokens))
+}
+
+// merge replaces the tokens of the template that differ from the given
+// tokens with a wildcard.
+func (t *logTemplate) merge(tokens []string) {
+	for i, token := range t.tokens {
+		if token != TemplateWildcard && token != tokens[i] {
+			t.tokens[i] = TemplateWildcard
+		}
+	}
+}
+
+// ExpandTemplate rebuilds a body from its template and the values of its
+// variables. It returns false if the number of variables doesn't match the
+// template.
+func ExpandTemplate(template string, vars []string) (string, bool) {
+	tokens := strings.Split(template, " ")
+	next := 0
+	for i, token := range tokens {
+		if token != TemplateWildcard {
+			continue
+		}
+		if next >= len(vars) {
+			return "", false
+		}
+		tokens[i] = vars[next]
+		next++
+	}
+	if next != len(vars) {
+		return "", false
+	}
+	return strings.Join(tokens, " "), true
+}
+
+func isVariable(token string) bool {
+	for _, r := range token {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
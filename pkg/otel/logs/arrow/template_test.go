@@ -0,0 +1,75 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package arrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateMiner(t *testing.T) {
+	t.Parallel()
+
+	miner := NewTemplateMiner()
+
+	template, vars, ok := miner.Extract("user 42 logged in from web")
+	require.True(t, ok)
+	require.Equal(t, "user <*> logged in from web", template)
+	require.Equal(t, []string{"42"}, vars)
+
+	// The differing token is merged into the existing template.
+	template, vars, ok = miner.Extract("user 7 logged in from mobile")
+	require.True(t, ok)
+	require.Equal(t, "user <*> logged in from <*>", template)
+	require.Equal(t, []string{"7", "mobile"}, vars)
+
+	// Different number of tokens, new template.
+	template, vars, ok = miner.Extract("user 7 logged out")
+	require.True(t, ok)
+	require.Equal(t, "user <*> logged out", template)
+	require.Equal(t, []string{"7"}, vars)
+
+	_, _, ok = miner.Extract("single")
+	require.False(t, ok)
+	_, _, ok = miner.Extract("literal <*> wildcard")
+	require.False(t, ok)
+}
+
+func TestExpandTemplate(t *testing.T) {
+	t.Parallel()
+
+	miner := NewTemplateMiner()
+	for _, body := range []string{
+		"GET /api/v1/users 200 12ms",
+		"GET /api/v1/orders 500 3ms",
+		"double  space 1",
+		" leading space 2",
+	} {
+		template, vars, ok := miner.Extract(body)
+		require.True(t, ok)
+		expanded, ok := ExpandTemplate(template, vars)
+		require.True(t, ok)
+		require.Equal(t, body, expanded)
+	}
+
+	_, ok := ExpandTemplate("a <*> b <*>", []string{"1"})
+	require.False(t, ok)
+	_, ok = ExpandTemplate("a b", []string{"1"})
+	require.False(t, ok)
+}
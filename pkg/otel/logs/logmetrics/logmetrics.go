@@ -0,0 +1,224 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logmetrics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	logsotlp "github.com/f5/otel-arrow-adapter/pkg/otel/logs/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// ScopeName is the instrumentation scope of the metrics.
+const ScopeName = "github.com/f5/otel-arrow-adapter/pkg/otel/logs/logmetrics"
+
+var (
+	ErrMissingRuleName   = errors.New("log metrics rule without name")
+	ErrDuplicateRuleName = errors.New("duplicate log metrics rule name")
+)
+
+type (
+	// Rule counts the log records matching all its predicates in a metric.
+	Rule struct {
+		// Name is the name of the metric.
+		Name string
+		// Description is the description of the metric.
+		Description string
+
+		// MinSeverity matches the log records with a severity number
+		// greater than or equal to MinSeverity. The unspecified
+		// severity matches all the log records.
+		MinSeverity plog.SeverityNumber
+		// Attributes matches the log records having all these
+		// attributes, with the same values once converted to strings.
+		Attributes map[string]string
+	}
+
+	// Counter counts the log records of OTLP Arrow logs batches matching
+	// its rules. The counts are cumulative since the creation of the
+	// counter.
+	Counter struct {
+		rules []Rule
+		start pcommon.Timestamp
+
+		// needAttrs is true if a rule has attribute predicates, the
+		// log record attributes are only decoded in this case.
+		needAttrs bool
+
+		// lock protects counts.
+		lock   sync.Mutex
+		counts []uint64
+	}
+)
+
+// NewCounter creates a Counter for the given rules. Returns an error if a
+// rule has no name or if two rules have the same name.
+func NewCounter(rules ...Rule) (*Counter, error) {
+	names := make(map[string]bool, len(rules))
+	needAttrs := false
+
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return nil, werror.Wrap(ErrMissingRuleName)
+		}
+		if names[rule.Name] {
+			return nil, werror.WrapWithContext(ErrDuplicateRuleName, map[string]interface{}{"name": rule.Name})
+		}
+		names[rule.Name] = true
+		needAttrs = needAttrs || len(rule.Attributes) > 0
+	}
+
+	return &Counter{
+		rules:     rules,
+		start:     pcommon.NewTimestampFromTime(time.Now()),
+		needAttrs: needAttrs,
+		counts:    make([]uint64, len(rules)),
+	}, nil
+}
+
+// Aggregate counts the log records of a decoded OTLP Arrow logs batch, as
+// returned by the Consume method of the consumer. The severity is read from
+// its column, the log record attributes are only decoded if a rule has
+// attribute predicates.
+// Note: This function does not consume the records.
+func (c *Counter) Aggregate(records []*record_message.RecordMessage) error {
+	var logsRecord *record_message.RecordMessage
+	logAttrs := otlp.NewAttributes16Store()
+
+	for _, record := range records {
+		switch record.PayloadType() {
+		case colarspb.ArrowPayloadType_LOGS:
+			if logsRecord != nil {
+				return werror.Wrap(otel.ErrMultipleTracesRecords)
+			}
+			logsRecord = record
+		case colarspb.ArrowPayloadType_LOG_ATTRS:
+			if !c.needAttrs {
+				continue
+			}
+			// The store consumes the record, which is
+			// retained for its owner.
+			record.Record().Retain()
+			if err := otlp.Attributes16StoreFrom(record.Record(), logAttrs); err != nil {
+				return werror.Wrap(err)
+			}
+		}
+	}
+	if logsRecord == nil {
+		return nil
+	}
+
+	record := logsRecord.Record()
+	ids, err := logsotlp.SchemaToIDs(record.Schema())
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	// IDs are delta-encoded, they are decoded in bulk.
+	var IDs []uint16
+	if c.needAttrs {
+		if IDs, err = arrowutils.DeltaDecodedU16FromRecord(record, ids.ID); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+
+	counts := make([]uint64, len(c.rules))
+	rows := int(record.NumRows())
+
+	for row := 0; row < rows; row++ {
+		severity, err := arrowutils.I32FromRecord(record, ids.SeverityNumber, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+
+		var attrs *pcommon.Map
+		if c.needAttrs {
+			attrs = logAttrs.AttributesByID(IDs[row])
+		}
+
+		for i := range c.rules {
+			if c.rules[i].matches(plog.SeverityNumber(severity), attrs) {
+				counts[i]++
+			}
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i, count := range counts {
+		c.counts[i] += count
+	}
+	return nil
+}
+
+// matches returns true if the log record with the given severity and
+// attributes (nil = no attributes) matches all the predicates of the rule.
+func (r *Rule) matches(severity plog.SeverityNumber, attrs *pcommon.Map) bool {
+	if severity < r.MinSeverity {
+		return false
+	}
+	for key, expected := range r.Attributes {
+		if attrs == nil {
+			return false
+		}
+		v, ok := attrs.Get(key)
+		if !ok || v.AsString() != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// Metrics returns the current value of the counts, one cumulative sum per
+// rule in the order of the rules.
+func (c *Counter) Metrics(now time.Time) pmetric.Metrics {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(ScopeName)
+	ts := pcommon.NewTimestampFromTime(now)
+
+	for i, rule := range c.rules {
+		m := sm.Metrics().AppendEmpty()
+		m.SetName(rule.Name)
+		m.SetDescription(rule.Description)
+		sum := m.SetEmptySum()
+		sum.SetIsMonotonic(true)
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+		dp := sum.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(c.start)
+		dp.SetTimestamp(ts)
+		dp.SetIntValue(int64(c.counts[i]))
+	}
+	return md
+}
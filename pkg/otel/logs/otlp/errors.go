@@ -20,5 +20,6 @@ package otlp
 import "errors"
 
 var (
-	ErrBodyNotSparseUnion = errors.New("body is not a sparse union")
+	ErrBodyNotSparseUnion  = errors.New("body is not a sparse union")
+	ErrInvalidTemplateVars = errors.New("invalid log body template variables")
 )
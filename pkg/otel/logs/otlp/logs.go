@@ -23,6 +23,7 @@ import (
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	larrow "github.com/f5/otel-arrow-adapter/pkg/otel/logs/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/werror"
 )
 
@@ -39,6 +40,7 @@ type LogRecordIDs struct {
 	SpanID               int
 	SeverityNumber       int
 	SeverityText         int
+	EventName            int
 
 	Body       int
 	BodyType   int
@@ -49,6 +51,9 @@ type LogRecordIDs struct {
 	BodyBytes  int
 	BodySer    int
 
+	BodyTemplate     int
+	BodyTemplateVars int
+
 	DropAttributesCount int
 	Flags               int
 }
@@ -56,6 +61,29 @@ type LogRecordIDs struct {
 // LogsFrom creates a [plog.Logs] from the given Arrow Record.
 // Note: This function consume the record.
 func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error) {
+	return logsFrom(record, relatedData, nil)
+}
+
+// LogsByResourceFrom decodes the given Arrow Record one resource at a time,
+// each [plog.Logs] passed to yield contains the log records of a single resource.
+// The decoding stops at the first error returned by yield, which is
+// returned as is.
+// Note: This function consume the record.
+func LogsByResourceFrom(record arrow.Record, relatedData *RelatedData, yield func(plog.Logs) error) error {
+	logs, err := logsFrom(record, relatedData, yield)
+	if err != nil {
+		return err
+	}
+	if logs.ResourceLogs().Len() == 0 {
+		return nil
+	}
+	return yield(logs)
+}
+
+// logsFrom decodes the record. When yield is not nil, the log records of each
+// resource are passed to yield once complete and the log records of the last
+// resource are returned.
+func logsFrom(record arrow.Record, relatedData *RelatedData, yield func(plog.Logs) error) (plog.Logs, error) {
 	defer record.Release()
 
 	logs := plog.NewLogs()
@@ -69,6 +97,12 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 		return logs, werror.Wrap(err)
 	}
 
+	// IDs are delta-encoded, they are decoded in bulk.
+	IDs, err := arrowutils.DeltaDecodedU16FromRecord(record, logRecordIDs.ID)
+	if err != nil {
+		return logs, werror.Wrap(err)
+	}
+
 	var resLogs plog.ResourceLogs
 	var scopeLogsSlice plog.ScopeLogsSlice
 	var logRecordSlice plog.LogRecordSlice
@@ -76,20 +110,25 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 	resLogsSlice := logs.ResourceLogs()
 	rows := int(record.NumRows())
 
-	prevResID := None
-	prevScopeID := None
+	var resEntries, scopeEntries otlp.EntryTracker
 
 	for row := 0; row < rows; row++ {
 		// Process resource logs, resource, schema url (resource)
-		resID, err := otlp.ResourceIDFromRecord(record, row, logRecordIDs.Resource)
+		resID, err := otlp.NullableResourceIDFromRecord(record, row, logRecordIDs.Resource)
 		if err != nil {
 			return logs, werror.Wrap(err)
 		}
-		if prevResID != int(resID) {
-			prevResID = int(resID)
+		if resEntries.IsNew(resID) {
+			if yield != nil && resLogsSlice.Len() != 0 {
+				if err := yield(logs); err != nil {
+					return logs, err
+				}
+				logs = plog.NewLogs()
+				resLogsSlice = logs.ResourceLogs()
+			}
 			resLogs = resLogsSlice.AppendEmpty()
 			scopeLogsSlice = resLogs.ScopeLogs()
-			prevScopeID = None
+			scopeEntries.Reset()
 			schemaUrl, err := otlp.UpdateResourceFromRecord(resLogs.Resource(), record, row, logRecordIDs.Resource, relatedData.ResAttrMapStore)
 			if err != nil {
 				return logs, werror.Wrap(err)
@@ -98,15 +137,14 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 		}
 
 		// Process scope logs, scope, schema url (scope)
-		scopeID, err := otlp.ScopeIDFromRecord(record, row, logRecordIDs.Scope)
+		newScope, err := otlp.IsNewScopeEntry(record, row, logRecordIDs.Scope, &scopeEntries)
 		if err != nil {
 			return logs, werror.Wrap(err)
 		}
-		if prevScopeID != int(scopeID) {
-			prevScopeID = int(scopeID)
+		if newScope {
 			scopeLogs := scopeLogsSlice.AppendEmpty()
 			logRecordSlice = scopeLogs.LogRecords()
-			if err = otlp.UpdateScopeFromRecord(scopeLogs.Scope(), record, row, logRecordIDs.Scope, relatedData.ScopeAttrMapStore); err != nil {
+			if err = otlp.UpdateScopeFromRecord(scopeLogs.Scope(), record, row, logRecordIDs.Scope, relatedData.ScopeAttrMapStore, relatedData.ScopeTable); err != nil {
 				return logs, werror.Wrap(err)
 			}
 
@@ -119,11 +157,7 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 
 		// Process log record fields
 		logRecord := logRecordSlice.AppendEmpty()
-		deltaID, err := arrowutils.U16FromRecord(record, logRecordIDs.ID, row)
-		if err != nil {
-			return logs, werror.Wrap(err)
-		}
-		ID := relatedData.LogRecordIDFromDelta(deltaID)
+		ID := IDs[row]
 
 		timeUnixNano, err := arrowutils.TimestampFromRecord(record, logRecordIDs.TimeUnixNano, row)
 		if err != nil {
@@ -157,6 +191,10 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 		if err != nil {
 			return logs, werror.WrapWithContext(err, map[string]interface{}{"row": row})
 		}
+		eventName, err := arrowutils.StringFromRecord(record, logRecordIDs.EventName, row)
+		if err != nil {
+			return logs, werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
 
 		// Read the body value based on the body type
 		bodyStruct, err := arrowutils.StructFromRecord(record, logRecordIDs.Body, row)
@@ -173,9 +211,9 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 			body := logRecord.Body()
 			switch pcommon.ValueType(bodyType) {
 			case pcommon.ValueTypeStr:
-				v, err := arrowutils.StringFromStruct(bodyStruct, row, logRecordIDs.BodyStr)
+				v, err := strBodyFromStruct(bodyStruct, row, logRecordIDs)
 				if err != nil {
-					return logs, werror.Wrap(err)
+					return logs, werror.WrapWithContext(err, map[string]interface{}{"row": row})
 				}
 				body.SetStr(v)
 			case pcommon.ValueTypeInt:
@@ -249,6 +287,7 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 		logRecord.SetSpanID(sid)
 		logRecord.SetSeverityNumber(plog.SeverityNumber(severityNumber))
 		logRecord.SetSeverityText(severityText)
+		logRecord.SetEventName(eventName)
 		logRecord.SetDroppedAttributesCount(droppedAttributesCount)
 		logRecord.SetFlags(plog.LogRecordFlags(flags))
 	}
@@ -256,6 +295,48 @@ func LogsFrom(record arrow.Record, relatedData *RelatedData) (plog.Logs, error)
 	return logs, nil
 }
 
+// strBodyFromStruct returns the string body of the given row, rebuilt from
+// its template and variables when the body has been encoded as a template.
+func strBodyFromStruct(bodyStruct arrow.Array, row int, ids *LogRecordIDs) (string, error) {
+	template, err := arrowutils.StringFromStruct(bodyStruct, row, ids.BodyTemplate)
+	if err != nil {
+		return "", werror.Wrap(err)
+	}
+	if template == "" {
+		str, err := arrowutils.StringFromStruct(bodyStruct, row, ids.BodyStr)
+		if err != nil {
+			return "", werror.Wrap(err)
+		}
+		return str, nil
+	}
+
+	var vars []string
+	cborVars, err := arrowutils.BinaryFromStruct(bodyStruct, row, ids.BodyTemplateVars)
+	if err != nil {
+		return "", werror.Wrap(err)
+	}
+	if cborVars != nil {
+		varsValue := pcommon.NewValueEmpty()
+		if err = common.Deserialize(cborVars, varsValue); err != nil {
+			return "", werror.Wrap(err)
+		}
+		if varsValue.Type() != pcommon.ValueTypeSlice {
+			return "", werror.Wrap(ErrInvalidTemplateVars)
+		}
+		varsSlice := varsValue.Slice()
+		vars = make([]string, varsSlice.Len())
+		for i := 0; i < varsSlice.Len(); i++ {
+			vars[i] = varsSlice.At(i).Str()
+		}
+	}
+
+	str, ok := larrow.ExpandTemplate(template, vars)
+	if !ok {
+		return "", werror.WrapWithContext(ErrInvalidTemplateVars, map[string]interface{}{"template": template, "vars": len(vars)})
+	}
+	return str, nil
+}
+
 func SchemaToIDs(schema *arrow.Schema) (*LogRecordIDs, error) {
 	ID, _ := arrowutils.FieldIDFromSchema(schema, constants.ID)
 	resourceIDs, err := otlp.NewResourceIdsFromSchema(schema)
@@ -274,6 +355,7 @@ func SchemaToIDs(schema *arrow.Schema) (*LogRecordIDs, error) {
 	spanID, _ := arrowutils.FieldIDFromSchema(schema, constants.SpanId)
 	severityNumber, _ := arrowutils.FieldIDFromSchema(schema, constants.SeverityNumber)
 	severityText, _ := arrowutils.FieldIDFromSchema(schema, constants.SeverityText)
+	eventName, _ := arrowutils.FieldIDFromSchema(schema, constants.EventName)
 
 	body, bodyDT, err := arrowutils.StructFieldIDFromSchema(schema, constants.Body)
 	if err != nil {
@@ -308,6 +390,9 @@ func SchemaToIDs(schema *arrow.Schema) (*LogRecordIDs, error) {
 		return nil, werror.Wrap(err)
 	}
 
+	bTemplate := arrowutils.OptionalFieldIDFromStruct(bodyDT, constants.BodyTemplate)
+	bTemplateVars := arrowutils.OptionalFieldIDFromStruct(bodyDT, constants.BodyTemplateVars)
+
 	droppedAttributesCount, _ := arrowutils.FieldIDFromSchema(schema, constants.DroppedAttributesCount)
 	flags, _ := arrowutils.FieldIDFromSchema(schema, constants.Flags)
 
@@ -322,6 +407,7 @@ func SchemaToIDs(schema *arrow.Schema) (*LogRecordIDs, error) {
 		SpanID:               spanID,
 		SeverityNumber:       severityNumber,
 		SeverityText:         severityText,
+		EventName:            eventName,
 
 		Body:       body,
 		BodyType:   bType,
@@ -332,6 +418,9 @@ func SchemaToIDs(schema *arrow.Schema) (*LogRecordIDs, error) {
 		BodyBytes:  bBytes,
 		BodySer:    bSer,
 
+		BodyTemplate:     bTemplate,
+		BodyTemplateVars: bTemplateVars,
+
 		DropAttributesCount: droppedAttributesCount,
 		Flags:               flags,
 	}, nil
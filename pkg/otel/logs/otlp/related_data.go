@@ -29,9 +29,12 @@ import (
 
 type (
 	RelatedData struct {
-		LogRecordID           uint16
+		// Deprecated: see LogRecordIDFromDelta.
+		LogRecordID uint16
+
 		ResAttrMapStore       *otlp.Attributes16Store
 		ScopeAttrMapStore     *otlp.Attributes16Store
+		ScopeTable            *otlp.ScopeTable
 		LogRecordAttrMapStore *otlp.Attributes16Store
 	}
 )
@@ -44,38 +47,48 @@ func NewRelatedData() *RelatedData {
 	}
 }
 
+// LogRecordIDFromDelta accumulates a delta-encoded ID into LogRecordID.
+//
+// Deprecated: LogsFrom decodes the IDs in bulk with
+// arrowutils.DeltaDecodedU16FromRecord and no longer calls this method.
 func (r *RelatedData) LogRecordIDFromDelta(delta uint16) uint16 {
 	r.LogRecordID += delta
 	return r.LogRecordID
 }
 
-func RelatedDataFrom(records []*record_message.RecordMessage) (relatedData *RelatedData, logsRecord *record_message.RecordMessage, err error) {
+func RelatedDataFrom(records []*record_message.RecordMessage, concurrency int, attrsCache *otlp.AttributesCache) (relatedData *RelatedData, logsRecord *record_message.RecordMessage, err error) {
 	defer func() {
 		for _, record := range records {
 			record.Record().Release()
 		}
 	}()
 
+	var scopeTableRecord *record_message.RecordMessage
+	var attrsTasks otlp.DecodeTasks
+
 	relatedData = NewRelatedData()
 
 	// Create the attribute map stores for all the attribute records.
 	for _, record := range records {
+		record := record
 		switch record.PayloadType() {
 		case colarspb.ArrowPayloadType_RESOURCE_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.ResAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return attrsCache.Attributes16StoreFrom(record.CacheKey(), record.Record(), relatedData.ResAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_SCOPE_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.ScopeAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
+			attrsTasks = append(attrsTasks, func() error {
+				return attrsCache.Attributes16StoreFrom(record.CacheKey(), record.Record(), relatedData.ScopeAttrMapStore)
+			})
+		case colarspb.ArrowPayloadType_SCOPES:
+			if scopeTableRecord != nil {
+				return nil, nil, werror.Wrap(otel.ErrMultipleScopeTableRecords)
 			}
+			scopeTableRecord = record
 		case colarspb.ArrowPayloadType_LOG_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.LogRecordAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes16StoreFrom(record.Record(), relatedData.LogRecordAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_LOGS:
 			if logsRecord != nil {
 				return nil, nil, werror.Wrap(otel.ErrMultipleTracesRecords)
@@ -86,5 +99,17 @@ func RelatedDataFrom(records []*record_message.RecordMessage) (relatedData *Rela
 		}
 	}
 
+	if err = attrsTasks.Run(concurrency); err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+
+	// The scope table depends on the scope attributes.
+	if scopeTableRecord != nil {
+		relatedData.ScopeTable, err = otlp.ScopeTableFrom(scopeTableRecord.Record(), relatedData.ScopeAttrMapStore)
+		if err != nil {
+			return nil, nil, werror.Wrap(err)
+		}
+	}
+
 	return
 }
@@ -109,7 +109,7 @@ func CheckEncodeDecode(
 		require.Error(t, acommon.ErrSchemaNotUpToDate)
 	}
 
-	relatedData, _, err := logsotlp.RelatedDataFrom(relatedRecords)
+	relatedData, _, err := logsotlp.RelatedDataFrom(relatedRecords, 1, nil)
 	require.NoError(t, err)
 
 	// Convert the Arrow records back to OTLP.
@@ -169,7 +169,7 @@ func CheckEncodeMessUpDecode(
 	// Mix up the Arrow records in such a way as to make decoding impossible.
 	mainRecordChanged, record, relatedRecords := common.MixUpArrowRecords(rng, record, relatedRecords)
 
-	relatedData, _, err := logsotlp.RelatedDataFrom(relatedRecords)
+	relatedData, _, err := logsotlp.RelatedDataFrom(relatedRecords, 1, nil)
 
 	// Convert the Arrow records back to OTLP.
 	_, err = logsotlp.LogsFrom(record, relatedData)
@@ -17,13 +17,14 @@
 
 package arrow
 
-// ToDo the metrics analyzer is not complete yet. It is a work in progress and will be completed in a future PRs. The analyzer is only used for troubleshooting and debugging purposes.
-
 import (
 	"fmt"
+	"io"
 
 	"github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/axiomhq/hyperloglog"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 
 	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
 )
@@ -54,16 +55,21 @@ type (
 	MetricsStats struct {
 		TotalCount        int64
 		Distribution      *hdrhistogram.Histogram
-		Attributes        *carrow.AttributesStats
-		SharedAttributes  *carrow.AttributesStats
-		TimeIntervalStats *carrow.TimeIntervalStats
 		Name              *carrow.StringStats
-		SpanID            *hyperloglog.Sketch
-		TraceID           *hyperloglog.Sketch
-		ParentSpanID      *hyperloglog.Sketch
-		Kind              *hyperloglog.Sketch
-		TraceState        *hyperloglog.Sketch
-		StatusStats       *carrow.StatusStats
+		Description       *carrow.StringStats
+		Unit              *carrow.StringStats
+		Type              *hyperloglog.Sketch
+		DataPoints        *hdrhistogram.Histogram
+		StartTimeUnixNano *carrow.TimestampStats
+		TimeUnixNano      *carrow.TimestampStats
+		Attributes        *carrow.AttributesStats
+	}
+
+	// dataPoint is the subset of methods shared by all the data point types.
+	dataPoint interface {
+		Attributes() pcommon.Map
+		StartTimestamp() pcommon.Timestamp
+		Timestamp() pcommon.Timestamp
 	}
 )
 
@@ -100,138 +106,174 @@ func (t *MetricsAnalyzer) Analyze(metrics *MetricsOptimized) {
 	t.ResourceMetricsStats.UpdateWith(metrics)
 }
 
-func (t *MetricsAnalyzer) ShowStats(indent string) {
-	println()
-	print(carrow.Green)
-	fmt.Printf("%s%d ExportMetricsServiceRequest processed\n", indent, t.MetricCount)
-	print(carrow.ColorReset)
-	indent += "  "
-	t.ResourceMetricsStats.ShowStats(indent)
+// ShowSummary writes the number of requests analyzed so far.
+func (t *MetricsAnalyzer) ShowSummary(w io.Writer, indent string) {
+	fmt.Fprintln(w)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%s%d ExportMetricsServiceRequest processed\n", indent, t.MetricCount)
+	fmt.Fprint(w, carrow.ColorReset)
+}
+
+// ShowStats writes the summary followed by the detailed statistics.
+func (t *MetricsAnalyzer) ShowStats(w io.Writer, indent string) {
+	t.ShowSummary(w, indent)
+	t.ResourceMetricsStats.ShowStats(w, indent+"  ")
 }
 
 func (r *ResourceMetricsStats) UpdateWith(metrics *MetricsOptimized) {
-	// ToDo adapt the analyzer to the new metrics format
-	//resMetrics := metrics.ResourceMetrics
-	//
-	//for ID := range metrics.ResourceMetricsIdx {
-	//	r.ResMetricsIDsDistinct.Insert([]byte(ID))
-	//}
-	//
-	//r.TotalCount += int64(len(resMetrics))
-	//carrow.RequireNoError(r.Distribution.RecordValue(int64(len(resMetrics))))
-	//
-	//for _, rs := range resMetrics {
-	//	r.ResourceStats.UpdateWith(rs.Resource)
-	//	r.ScopeMetricsStats.UpdateWith(rs.ScopeMetrics, rs.ScopeMetricsIdx)
-	//	r.SchemaUrlStats.UpdateWith(rs.ResourceSchemaUrl)
-	//}
+	prevResID := ""
+	prevScopeID := ""
+
+	resMetricsCount := 0
+	scopeMetricsCount := 0
+	metricsPerScopeMetrics := 0
+
+	for _, metric := range metrics.Metrics {
+		if resMetricsCount == 0 || prevResID != metric.ResourceMetricsID {
+			prevResID = metric.ResourceMetricsID
+			resMetricsCount++
+			r.ResMetricsIDsDistinct.Insert([]byte(metric.ResourceMetricsID))
+			r.ResourceStats.UpdateWith(metric.Resource)
+			r.SchemaUrlStats.UpdateWith(metric.ResourceSchemaUrl)
+		}
+
+		if scopeMetricsCount == 0 || prevScopeID != metric.ScopeMetricsID {
+			prevScopeID = metric.ScopeMetricsID
+			scopeMetricsCount++
+			r.ScopeMetricsStats.UpdateWith(metric)
+			r.ScopeMetricsStats.MetricsStats.RecordScopeMetricsSize(metricsPerScopeMetrics)
+			metricsPerScopeMetrics = 0
+		}
+
+		r.ScopeMetricsStats.MetricsStats.UpdateWith(metric.Metric)
+		metricsPerScopeMetrics++
+	}
+	r.ScopeMetricsStats.MetricsStats.RecordScopeMetricsSize(metricsPerScopeMetrics)
+
+	r.TotalCount += int64(resMetricsCount)
+	carrow.RequireNoError(r.Distribution.RecordValue(int64(resMetricsCount)))
+	carrow.RequireNoError(r.ScopeMetricsStats.Distribution.RecordValue(int64(scopeMetricsCount)))
 }
 
-func (r *ResourceMetricsStats) ShowStats(indent string) {
-	fmt.Printf("%s                                 |         Distribution per request        |\n", indent)
-	print(carrow.Green)
-	fmt.Printf("%sResourceMetrics%s |    Total|Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s              |%9d|%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
+func (r *ResourceMetricsStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s                                 |         Distribution per request        |\n", indent)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sResourceMetrics%s |    Total|Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s              |%9d|%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
 		r.TotalCount, r.ResMetricsIDsDistinct.Estimate(), r.Distribution.Min(), r.Distribution.Max(), r.Distribution.Mean(), r.Distribution.StdDev(), r.Distribution.ValueAtQuantile(50), r.Distribution.ValueAtQuantile(99),
 	)
 	indent += "  "
-	r.ResourceStats.ShowStats(indent)
-	r.ScopeMetricsStats.ShowStats(indent)
-	r.SchemaUrlStats.ShowStats(indent)
+	r.ResourceStats.ShowStats(w, indent)
+	r.ScopeMetricsStats.ShowStats(w, indent)
+	r.SchemaUrlStats.ShowStats(w, indent)
 }
 
-// ToDo adapt the analyzer to the new metrics format
-//func (s *ScopeMetricsStats) UpdateWith(scopeMetrics []*ScopeMetricsGroup, scopeMetricsIdx map[string]int) {
-//	carrow.RequireNoError(s.Distribution.RecordValue(int64(len(scopeMetrics))))
-//
-//	for ID := range scopeMetricsIdx {
-//		s.ScopeMetricsIDsDistinct.Insert([]byte(ID))
-//	}
-//
-//	for _, ss := range scopeMetrics {
-//		s.ScopeStats.UpdateWith(ss.Scope)
-//		s.MetricsStats.UpdateWith(ss)
-//		s.SchemaUrlStats.UpdateWith(ss.ScopeSchemaUrl)
-//	}
-//}
-
-func (s *ScopeMetricsStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sScopeMetrics%s |Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s           |%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
+func (s *ScopeMetricsStats) UpdateWith(metric *FlattenedMetric) {
+	s.ScopeMetricsIDsDistinct.Insert([]byte(metric.ScopeMetricsID))
+	s.ScopeStats.UpdateWith(metric.Scope)
+	s.SchemaUrlStats.UpdateWith(metric.ScopeSchemaUrl)
+}
+
+func (s *ScopeMetricsStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sScopeMetrics%s |Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s           |%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
 		s.ScopeMetricsIDsDistinct.Estimate(), s.Distribution.Min(), s.Distribution.Max(), s.Distribution.Mean(), s.Distribution.StdDev(), s.Distribution.ValueAtQuantile(50), s.Distribution.ValueAtQuantile(99),
 	)
-	s.ScopeStats.ShowStats(indent + "  ")
-	s.MetricsStats.ShowStats(indent + "  ")
-	s.SchemaUrlStats.ShowStats(indent + "  ")
+	s.ScopeStats.ShowStats(w, indent+"  ")
+	s.MetricsStats.ShowStats(w, indent+"  ")
+	s.SchemaUrlStats.ShowStats(w, indent+"  ")
 }
 
 func NewMetricsStats() *MetricsStats {
 	return &MetricsStats{
 		Distribution:      hdrhistogram.New(0, 1000000, 2),
-		Attributes:        carrow.NewAttributesStats(),
-		SharedAttributes:  carrow.NewAttributesStats(),
-		TimeIntervalStats: carrow.NewTimeIntervalStats(),
-		SpanID:            hyperloglog.New16(),
-		TraceID:           hyperloglog.New16(),
-		ParentSpanID:      hyperloglog.New16(),
 		Name:              carrow.NewStringStats(),
-		Kind:              hyperloglog.New16(),
-		TraceState:        hyperloglog.New16(),
-		StatusStats:       carrow.NewStatusStats(),
+		Description:       carrow.NewStringStats(),
+		Unit:              carrow.NewStringStats(),
+		Type:              hyperloglog.New16(),
+		DataPoints:        hdrhistogram.New(0, 1000000, 2),
+		StartTimeUnixNano: carrow.NewTimestampStats(),
+		TimeUnixNano:      carrow.NewTimestampStats(),
+		Attributes:        carrow.NewAttributesStats(),
+	}
+}
+
+// RecordScopeMetricsSize records the number of metrics of a scope metrics, the
+// empty scope metrics are ignored.
+func (s *MetricsStats) RecordScopeMetricsSize(count int) {
+	if count > 0 {
+		carrow.RequireNoError(s.Distribution.RecordValue(int64(count)))
+	}
+}
+
+func (s *MetricsStats) UpdateWith(metric pmetric.Metric) {
+	s.Name.UpdateWith(metric.Name())
+	s.Description.UpdateWith(metric.Description())
+	s.Unit.UpdateWith(metric.Unit())
+	s.Type.Insert([]byte(metric.Type().String()))
+
+	dpCount := 0
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := metric.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			s.updateWithDataPoint(dps.At(i))
+		}
+		dpCount = dps.Len()
+	case pmetric.MetricTypeSum:
+		dps := metric.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			s.updateWithDataPoint(dps.At(i))
+		}
+		dpCount = dps.Len()
+	case pmetric.MetricTypeHistogram:
+		dps := metric.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			s.updateWithDataPoint(dps.At(i))
+		}
+		dpCount = dps.Len()
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := metric.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			s.updateWithDataPoint(dps.At(i))
+		}
+		dpCount = dps.Len()
+	case pmetric.MetricTypeSummary:
+		dps := metric.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			s.updateWithDataPoint(dps.At(i))
+		}
+		dpCount = dps.Len()
 	}
+	carrow.RequireNoError(s.DataPoints.RecordValue(int64(dpCount)))
+
+	s.TotalCount++
 }
 
-// ToDo adapt the analyzer to the new metrics format
-//func (s *MetricsStats) UpdateWith(sm *ScopeMetricsGroup) {
-//	metrics := sm.Metrics
-//	carrow.RequireNoError(s.Distribution.RecordValue(int64(len(metrics))))
-
-//sharedAttrs := pcommon.NewMap()
-//sm.SharedData.sharedAttributes.CopyTo(sharedAttrs)
-//s.SharedAttributes.UpdateWith(sharedAttrs, 0)
-//
-//s.TimeIntervalStats.UpdateWithSpans(metrics)
-//
-//for _, span := range metrics {
-//	s.Attributes.UpdateWith(span.Attributes(), span.DroppedAttributesCount())
-//	s.Name.UpdateWith(span.Name())
-//	s.SpanID.Insert([]byte(span.SpanID().String()))
-//	s.TraceID.Insert([]byte(span.TraceID().String()))
-//	s.ParentSpanID.Insert([]byte(span.ParentSpanID().String()))
-//	s.Kind.Insert([]byte(span.Kind().String()))
-//	s.TraceState.Insert([]byte(span.TraceState().AsRaw()))
-//	s.Events.UpdateWith(span.Events(), span.DroppedEventsCount(), sm.SharedData.sharedEventAttributes)
-//	s.Links.UpdateWith(span.Links(), span.DroppedLinksCount(), sm.SharedData.sharedLinkAttributes)
-//
-//	b := make([]byte, 8)
-//	binary.LittleEndian.PutUint64(b, uint64(span.DroppedEventsCount()))
-//	s.DropEventsCount.Insert(b)
-//	binary.LittleEndian.PutUint64(b, uint64(span.DroppedLinksCount()))
-//	s.DropLinksCount.Insert(b)
-//	s.StatusStats.UpdateWith(span.Status())
-//}
-
-//	s.TotalCount += int64(len(metrics))
-//}
-
-func (s *MetricsStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sSpans%s |   Total|   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s      |%8d|%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
+func (s *MetricsStats) updateWithDataPoint(dp dataPoint) {
+	s.StartTimeUnixNano.UpdateWith(dp.StartTimestamp())
+	s.TimeUnixNano.UpdateWith(dp.Timestamp())
+	s.Attributes.UpdateWith(dp.Attributes(), 0)
+}
+
+func (s *MetricsStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sMetrics%s |   Total|   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s        |%8d|%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
 		s.TotalCount, s.Distribution.Min(), s.Distribution.Max(), s.Distribution.Mean(), s.Distribution.StdDev(), s.Distribution.ValueAtQuantile(50), s.Distribution.ValueAtQuantile(99),
 	)
 	indent += "  "
-	s.TimeIntervalStats.ShowStats(indent)
-	s.Name.ShowStats("Name", indent)
-	fmt.Printf("%s             |Distinct|   Total|%%Distinct|\n", indent)
-	fmt.Printf("%s%sSpanID%s       |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SpanID.Estimate(), s.TotalCount, 100.0*float64(s.SpanID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sTraceID%s      |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.TraceID.Estimate(), s.TotalCount, 100.0*float64(s.TraceID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sParentSpanID%s |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.ParentSpanID.Estimate(), s.TotalCount, 100.0*float64(s.ParentSpanID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sKind%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.Kind.Estimate())
-	fmt.Printf("%s%sTraceState%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.TraceState.Estimate())
-
-	s.Attributes.ShowStats(indent, "Attributes", carrow.Green)
-	s.SharedAttributes.ShowStats(indent, "SharedAttributes", carrow.Cyan)
-	s.StatusStats.ShowStats(indent)
+	s.Name.ShowStats(w, "Name", indent)
+	s.Description.ShowStats(w, "Description", indent)
+	s.Unit.ShowStats(w, "Unit", indent)
+	fmt.Fprintf(w, "%s%sType%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.Type.Estimate())
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sDataPoints%s |   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s           |%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
+		s.DataPoints.Min(), s.DataPoints.Max(), s.DataPoints.Mean(), s.DataPoints.StdDev(), s.DataPoints.ValueAtQuantile(50), s.DataPoints.ValueAtQuantile(99),
+	)
+	s.StartTimeUnixNano.ShowStats(w, "StartTimeUnixNano", indent)
+	s.TimeUnixNano.ShowStats(w, "TimeUnixNano", indent)
+	s.Attributes.ShowStats(w, indent, "Attributes", carrow.Green)
 }
@@ -0,0 +1,232 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+// Direct append of metrics to the Arrow metrics builders.
+
+import (
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+type (
+	// MetricsAppender appends metrics to a MetricsBuilder without building a
+	// [pmetric.Metrics] first. It is intended for the sources already grouping
+	// their data points by resource, scope and metric (e.g. scrapers and
+	// aggregators): the metric rows are appended to the main record in the
+	// order of the calls, and the data points are appended directly to the
+	// accumulators of the related records.
+	//
+	// The rows are not sorted, so the data points of a metric must be
+	// appended after the metric and before the next one, and the metrics of a
+	// resource (resp. scope) should be appended contiguously.
+	MetricsAppender struct {
+		b *MetricsBuilder
+
+		// metric contains the resource and scope of the current metric, its
+		// Metric field is unused.
+		metric   FlattenedMetric
+		resScope resourceScopeState
+
+		resources int
+		scopes    int
+
+		// metricID is the ID of the current metric, valid if hasMetric.
+		metricID   uint16
+		metricType pmetric.MetricType
+		name       string
+		hasMetric  bool
+
+		expiry arrow.Timestamp
+	}
+
+	// Metric describes a metric appended with MetricsAppender.AppendMetric.
+	Metric struct {
+		Type        pmetric.MetricType
+		Name        string
+		Description string
+		Unit        string
+		// AggregationTemporality is ignored for gauges and summaries.
+		AggregationTemporality pmetric.AggregationTemporality
+		// IsMonotonic is ignored for all the types but sums.
+		IsMonotonic bool
+	}
+)
+
+// AppendWith appends the metrics appended by fn with a MetricsAppender. As
+// with Append, the rows are discarded if fn or the append fails. fn is called
+// again on the next build after a schema update, it must append the same
+// metrics every time.
+func (b *MetricsBuilder) AppendWith(fn func(*MetricsAppender) error) (err error) {
+	if b.released {
+		return werror.Wrap(carrow.ErrBuilderAlreadyReleased)
+	}
+
+	b.checkpoint()
+	defer func() {
+		if err != nil {
+			if rollbackErr := b.rollback(); rollbackErr != nil {
+				err = werror.WrapWithContext(err, map[string]interface{}{"rollback_error": rollbackErr.Error()})
+			}
+		}
+	}()
+
+	a := &MetricsAppender{b: b}
+	a.SetResource(pcommon.NewResource(), "")
+	if b.retention > 0 {
+		a.expiry = arrow.Timestamp(time.Now().Add(b.retention).UnixNano())
+	}
+
+	return fn(a)
+}
+
+// SetResource sets the resource of the metrics appended next and resets their
+// scope to the empty scope. The resource must not be modified until the
+// record is built.
+func (a *MetricsAppender) SetResource(resource pcommon.Resource, schemaUrl string) {
+	a.metric.ResourceMetricsID = otlp.ResourceEntryID(resource, schemaUrl, a.b.emptyPerRecord, a.resources)
+	a.metric.Resource = resource
+	a.metric.ResourceSchemaUrl = schemaUrl
+	a.resources++
+	a.scopes = 0
+	a.SetScope(pcommon.NewInstrumentationScope(), "")
+}
+
+// SetScope sets the scope of the metrics appended next. The scope must not be
+// modified until the record is built.
+func (a *MetricsAppender) SetScope(scope pcommon.InstrumentationScope, schemaUrl string) {
+	a.metric.ScopeMetricsID = otlp.ScopeEntryID(scope, schemaUrl, a.b.emptyPerRecord, a.resources-1, a.scopes)
+	a.metric.Scope = scope
+	a.metric.ScopeSchemaUrl = schemaUrl
+	a.scopes++
+}
+
+// AppendMetric appends a metric with the current resource and scope, the data
+// points appended next belong to this metric.
+func (a *MetricsAppender) AppendMetric(metric Metric) error {
+	b := a.b
+
+	if a.hasMetric {
+		a.metricID++
+	}
+	a.hasMetric = true
+	a.metricType = metric.Type
+	a.name = metric.Name
+
+	b.ib.Append(a.metricID)
+	if err := b.appendResourceScope(&a.resScope, &a.metric); err != nil {
+		return werror.Wrap(err)
+	}
+	b.appendMetricDesc(metric.Type, metric.Name, metric.Description, metric.Unit)
+
+	switch metric.Type {
+	case pmetric.MetricTypeSum:
+		b.atb.Append(int32(metric.AggregationTemporality))
+		b.imb.Append(metric.IsMonotonic)
+	case pmetric.MetricTypeHistogram, pmetric.MetricTypeExponentialHistogram:
+		b.atb.Append(int32(metric.AggregationTemporality))
+		b.imb.AppendNull()
+	case pmetric.MetricTypeGauge, pmetric.MetricTypeSummary, pmetric.MetricTypeEmpty:
+		b.atb.AppendNull()
+		b.imb.AppendNull()
+	default:
+		return werror.WrapWithContext(ErrUnknownMetricType, map[string]interface{}{"type": metric.Type})
+	}
+
+	if a.expiry != 0 {
+		b.expb.Append(a.expiry)
+	}
+	return nil
+}
+
+// AppendNumberDataPoint appends a data point to the current metric, which
+// must be a gauge or a sum. The data point must not be modified until the
+// record is built.
+func (a *MetricsAppender) AppendNumberDataPoint(dp pmetric.NumberDataPoint) error {
+	if err := a.checkMetricType(pmetric.MetricTypeGauge, pmetric.MetricTypeSum); err != nil {
+		return err
+	}
+	a.b.relatedData.NumberDPBuilder().Accumulator().AppendWithSeriesID(a.metricID, a.seriesID(dp.Attributes()), &dp)
+	return nil
+}
+
+// AppendHistogramDataPoints appends data points to the current metric, which
+// must be a histogram. The data points must not be modified until the record
+// is built.
+func (a *MetricsAppender) AppendHistogramDataPoints(dps pmetric.HistogramDataPointSlice) error {
+	if err := a.checkMetricType(pmetric.MetricTypeHistogram); err != nil {
+		return err
+	}
+	a.b.relatedData.HistogramDPBuilder().Accumulator().AppendWithSeriesIDs(a.metricID, dps, a.seriesIDs())
+	return nil
+}
+
+// AppendExponentialHistogramDataPoints appends data points to the current
+// metric, which must be an exponential histogram. The data points must not be
+// modified until the record is built.
+func (a *MetricsAppender) AppendExponentialHistogramDataPoints(dps pmetric.ExponentialHistogramDataPointSlice) error {
+	if err := a.checkMetricType(pmetric.MetricTypeExponentialHistogram); err != nil {
+		return err
+	}
+	a.b.relatedData.EHistogramDPBuilder().Accumulator().AppendWithSeriesIDs(a.metricID, dps, a.seriesIDs())
+	return nil
+}
+
+// AppendSummaryDataPoints appends data points to the current metric, which
+// must be a summary. The data points must not be modified until the record is
+// built.
+func (a *MetricsAppender) AppendSummaryDataPoints(dps pmetric.SummaryDataPointSlice) error {
+	if err := a.checkMetricType(pmetric.MetricTypeSummary); err != nil {
+		return err
+	}
+	a.b.relatedData.SummaryDPBuilder().Accumulator().AppendWithSeriesIDs(a.metricID, dps, a.seriesIDs())
+	return nil
+}
+
+func (a *MetricsAppender) checkMetricType(types ...pmetric.MetricType) error {
+	if a.hasMetric {
+		for _, t := range types {
+			if a.metricType == t {
+				return nil
+			}
+		}
+	}
+	return werror.WrapWithContext(ErrUnexpectedDataPoint, map[string]interface{}{"metric": a.name, "type": a.metricType.String()})
+}
+
+// seriesID returns the stable series identifier of a data point of the
+// current metric or 0 if this feature is disabled.
+func (a *MetricsAppender) seriesID(attrs pcommon.Map) uint32 {
+	if a.b.series == nil {
+		return 0
+	}
+	return a.b.series.seriesID(a.metric.ResourceMetricsID, a.metric.ScopeMetricsID, a.name, attrs)
+}
+
+// seriesIDs returns the function returning the stable series identifier of a
+// data point of the current metric, or nil if this feature is disabled.
+func (a *MetricsAppender) seriesIDs() func(pcommon.Map) uint32 {
+	if a.b.series == nil {
+		return nil
+	}
+	return a.seriesID
+}
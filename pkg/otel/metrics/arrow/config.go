@@ -47,6 +47,7 @@ type (
 	AttrsConfig struct {
 		Resource                *arrow.Attrs16Config
 		Scope                   *arrow.Attrs16Config
+		MetricMetadata          *arrow.Attrs16Config
 		NumberDataPoint         *arrow.Attrs32Config
 		NumberDataPointExemplar *arrow.Attrs32Config
 		Summary                 *arrow.Attrs32Config
@@ -125,6 +126,9 @@ func NewConfig(globalConf *cfg.Config) *Config {
 			Scope: &arrow.Attrs16Config{
 				Sorter: arrow.SortAttrs16ByKeyValueParentId(),
 			},
+			MetricMetadata: &arrow.Attrs16Config{
+				Sorter: arrow.SortAttrs16ByKeyValueParentId(),
+			},
 			NumberDataPoint: &arrow.Attrs32Config{
 				Sorter: arrow.SortAttrs32ByKeyValueParentId(),
 			},
@@ -184,6 +188,9 @@ func NewNoSortConfig(globalConf *cfg.Config) *Config {
 			Scope: &arrow.Attrs16Config{
 				Sorter: arrow.UnsortedAttrs16(),
 			},
+			MetricMetadata: &arrow.Attrs16Config{
+				Sorter: arrow.UnsortedAttrs16(),
+			},
 			NumberDataPoint: &arrow.Attrs32Config{
 				Sorter: arrow.UnsortedAttrs32(),
 			},
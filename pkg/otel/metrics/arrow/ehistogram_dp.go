@@ -31,6 +31,7 @@ import (
 	"sort"
 
 	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
 	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
@@ -61,7 +62,11 @@ var (
 		{Name: constants.Flags, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.HistogramMin, Type: arrow.PrimitiveTypes.Float64, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.HistogramMax, Type: arrow.PrimitiveTypes.Float64, Metadata: schema.Metadata(schema.Optional)},
-	}, nil)
+		{Name: constants.ExpHistogramZeroThreshold, Type: arrow.PrimitiveTypes.Float64, Metadata: schema.Metadata(schema.Optional)},
+		// Stable identifier of the series the DP belongs to (see
+		// config.WithSeriesID). Absent if the option is not enabled.
+		{Name: constants.SeriesID, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
+	}, schema.SortOrder(constants.ParentID))
 )
 
 type (
@@ -83,17 +88,26 @@ type (
 		pb    *EHistogramDataPointBucketsBuilder // positive buckets builder
 		nbb   *EHistogramDataPointBucketsBuilder // negative buckets builder
 		fb    *builder.Uint32Builder             // flags builder
+		sib   *builder.Uint32Builder             // series_id builder
 		hmib  *builder.Float64Builder            // histogram_min builder
 		hmab  *builder.Float64Builder            // histogram_max builder
+		ztb   *builder.Float64Builder            // zero_threshold builder
 
 		dataPointAccumulator *EHDPAccumulator
 		attrsAccu            *carrow.Attributes32Accumulator
 		exemplarAccumulator  *ExemplarAccumulator
 		config               *ExpHistogramConfig
+
+		// downscale enables the reduction of the scale of the data points
+		// whose scale is greater than maxScale (see SetMaxScale).
+		downscale bool
+		maxScale  int32
 	}
 
 	EHDP struct {
 		ParentID uint16
+		// SeriesID is the stable series identifier of the DP, 0 if absent.
+		SeriesID uint32
 		Orig     *pmetric.ExponentialHistogramDataPoint
 	}
 
@@ -101,6 +115,10 @@ type (
 		groupCount uint32
 		ehdps      []EHDP
 		sorter     EHistogramSorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointGroupCount uint32
+		checkpointLen        int
 	}
 
 	EHistogramSorter interface {
@@ -142,8 +160,19 @@ func (b *EHistogramDataPointBuilder) init() {
 	b.pb = EHistogramDataPointBucketsBuilderFrom(b.builder.StructBuilder(constants.ExpHistogramPositive))
 	b.nbb = EHistogramDataPointBucketsBuilderFrom(b.builder.StructBuilder(constants.ExpHistogramNegative))
 	b.fb = b.builder.Uint32Builder(constants.Flags)
+	b.sib = b.builder.Uint32Builder(constants.SeriesID)
 	b.hmib = b.builder.Float64Builder(constants.HistogramMin)
 	b.hmab = b.builder.Float64Builder(constants.HistogramMax)
+	b.ztb = b.builder.Float64Builder(constants.ExpHistogramZeroThreshold)
+}
+
+// SetMaxScale makes the builder downscale the data points whose scale is
+// greater than maxScale. The buckets of these data points are merged into the
+// buckets of maxScale, their count, sum, min, max, zero count and zero
+// threshold are unchanged.
+func (b *EHistogramDataPointBuilder) SetMaxScale(maxScale int32) {
+	b.downscale = true
+	b.maxScale = maxScale
 }
 
 func (b *EHistogramDataPointBuilder) SetAttributesAccumulator(accu *carrow.Attributes32Accumulator) {
@@ -206,6 +235,14 @@ func (b *EHistogramDataPointBuilder) Reset() {
 	b.dataPointAccumulator.Reset()
 }
 
+func (b *EHistogramDataPointBuilder) Checkpoint() {
+	b.dataPointAccumulator.Checkpoint()
+}
+
+func (b *EHistogramDataPointBuilder) Rollback() {
+	b.dataPointAccumulator.Rollback()
+}
+
 func (b *EHistogramDataPointBuilder) PayloadType() *carrow.PayloadType {
 	return carrow.PayloadTypes.ExpHistogram
 }
@@ -245,12 +282,19 @@ func (b *EHistogramDataPointBuilder) TryBuild(attrsAccu *carrow.Attributes32Accu
 		b.tunb.Append(arrow.Timestamp(ehdp.Timestamp()))
 
 		b.AppendCountSum(*ehdp)
-		b.sb.AppendNonZero(ehdp.Scale())
+		scale := ehdp.Scale()
+		shift := int32(0)
+		if b.downscale && scale > b.maxScale {
+			shift = scale - b.maxScale
+			scale = b.maxScale
+		}
+		b.sb.AppendNonZero(scale)
 		b.zcb.Append(ehdp.ZeroCount())
-		if err := b.pb.Append(ehdp.Positive()); err != nil {
+		b.ztb.AppendNonZero(ehdp.ZeroThreshold())
+		if err := b.pb.AppendDownscaled(ehdp.Positive(), shift); err != nil {
 			return nil, werror.Wrap(err)
 		}
-		if err := b.nbb.Append(ehdp.Negative()); err != nil {
+		if err := b.nbb.AppendDownscaled(ehdp.Negative(), shift); err != nil {
 			return nil, werror.Wrap(err)
 		}
 
@@ -259,6 +303,7 @@ func (b *EHistogramDataPointBuilder) TryBuild(attrsAccu *carrow.Attributes32Accu
 			return nil, werror.Wrap(err)
 		}
 		b.fb.Append(uint32(ehdp.Flags()))
+		b.sib.AppendNonZero(ehdpRec.SeriesID)
 
 		b.AppendMinMax(*ehdp)
 	}
@@ -283,8 +328,10 @@ func (b *EHistogramDataPointBuilder) AppendExemplars(ID uint32, hdp pmetric.Expo
 
 func (b *EHistogramDataPointBuilder) AppendCountSum(hdp pmetric.ExponentialHistogramDataPoint) {
 	b.hcb.Append(hdp.Count())
+	// A zero sum, min or max is appended as is, a null means that the value
+	// is absent (e.g. a data point with only a zero count).
 	if hdp.HasSum() {
-		b.hsb.AppendNonZero(hdp.Sum())
+		b.hsb.Append(hdp.Sum())
 	} else {
 		b.hsb.AppendNull()
 	}
@@ -292,12 +339,12 @@ func (b *EHistogramDataPointBuilder) AppendCountSum(hdp pmetric.ExponentialHisto
 
 func (b *EHistogramDataPointBuilder) AppendMinMax(hdp pmetric.ExponentialHistogramDataPoint) {
 	if hdp.HasMin() {
-		b.hmib.AppendNonZero(hdp.Min())
+		b.hmib.Append(hdp.Min())
 	} else {
 		b.hmib.AppendNull()
 	}
 	if hdp.HasMax() {
-		b.hmab.AppendNonZero(hdp.Max())
+		b.hmab.Append(hdp.Max())
 	} else {
 		b.hmab.AppendNull()
 	}
@@ -315,9 +362,21 @@ func (a *EHDPAccumulator) IsEmpty() bool {
 	return len(a.ehdps) == 0
 }
 
+// Append appends a slice of exponential histogram data points to the accumulator.
 func (a *EHDPAccumulator) Append(
 	metricID uint16,
 	ehdps pmetric.ExponentialHistogramDataPointSlice,
+) {
+	a.AppendWithSeriesIDs(metricID, ehdps, nil)
+}
+
+// AppendWithSeriesIDs appends a slice of exponential histogram data points and their
+// stable series identifiers, returned by seriesID given the attributes of each
+// data point (nil if disabled), to the accumulator.
+func (a *EHDPAccumulator) AppendWithSeriesIDs(
+	metricID uint16,
+	ehdps pmetric.ExponentialHistogramDataPointSlice,
+	seriesID func(pcommon.Map) uint32,
 ) {
 	if a.groupCount == math.MaxUint32 {
 		panic("The maximum number of group of exponential histogram data points has been reached (max is uint32).")
@@ -330,8 +389,13 @@ func (a *EHDPAccumulator) Append(
 	for i := 0; i < ehdps.Len(); i++ {
 		ehdp := ehdps.At(i)
 
+		var ID uint32
+		if seriesID != nil {
+			ID = seriesID(ehdp.Attributes())
+		}
 		a.ehdps = append(a.ehdps, EHDP{
 			ParentID: metricID,
+			SeriesID: ID,
 			Orig:     &ehdp,
 		})
 	}
@@ -342,6 +406,21 @@ func (a *EHDPAccumulator) Append(
 func (a *EHDPAccumulator) Reset() {
 	a.groupCount = 0
 	a.ehdps = a.ehdps[:0]
+	a.checkpointGroupCount = 0
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *EHDPAccumulator) Checkpoint() {
+	a.checkpointGroupCount = a.groupCount
+	a.checkpointLen = len(a.ehdps)
+}
+
+// Rollback discards the exponential histogram data points appended since the last checkpoint.
+func (a *EHDPAccumulator) Rollback() {
+	a.groupCount = a.checkpointGroupCount
+	a.ehdps = a.ehdps[:a.checkpointLen]
 }
 
 // No sorting
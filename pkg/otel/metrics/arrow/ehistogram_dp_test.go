@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+func TestDownscaleBuckets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		offset         int32
+		counts         []uint64
+		shift          int32
+		expectedOffset int32
+		expectedCounts []uint64
+	}{
+		{"positive offset", 4, []uint64{1, 2, 3, 4}, 1, 2, []uint64{3, 7}},
+		{"unaligned offset", 3, []uint64{1, 2, 3}, 1, 1, []uint64{1, 5}},
+		{"negative offset", -3, []uint64{1, 2, 3, 4, 5}, 2, -1, []uint64{6, 9}},
+		{"single bucket", -1, []uint64{7}, 3, -1, []uint64{7}},
+		{"large shift", -5, []uint64{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}, 20, -1, []uint64{5, 5}},
+		{"no buckets", 9, nil, 2, 2, nil},
+	}
+
+	for _, tt := range tests {
+		counts := pcommon.NewUInt64Slice()
+		counts.FromRaw(tt.counts)
+
+		offset, merged := downscaleBuckets(tt.offset, counts, tt.shift, nil)
+		require.Equal(t, tt.expectedOffset, offset, tt.name)
+		require.Equal(t, tt.expectedCounts, merged, tt.name)
+
+		var total uint64
+		for _, c := range merged {
+			total += c
+		}
+		var expectedTotal uint64
+		for _, c := range tt.counts {
+			expectedTotal += c
+		}
+		require.Equal(t, expectedTotal, total, tt.name)
+	}
+}
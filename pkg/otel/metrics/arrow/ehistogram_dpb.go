@@ -17,6 +17,7 @@ package arrow
 import (
 	"github.com/apache/arrow/go/v12/arrow"
 	"github.com/apache/arrow/go/v12/arrow/array"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
 	acommon "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
@@ -43,6 +44,8 @@ type EHistogramDataPointBucketsBuilder struct {
 	ob   *builder.Int32Builder  // offset builder
 	bclb *builder.ListBuilder   // exp histogram bucket counts list builder
 	bcb  *builder.Uint64Builder // exp histogram bucket counts builder
+
+	downscaled []uint64 // buffer of the downscaled bucket counts
 }
 
 // EHistogramDataPointBucketsBuilderFrom creates a new EHistogramDataPointBucketsBuilder from an existing StructBuilder.
@@ -86,23 +89,59 @@ func (b *EHistogramDataPointBucketsBuilder) Append(hdpb pmetric.ExponentialHisto
 		return werror.Wrap(acommon.ErrBuilderAlreadyReleased)
 	}
 
+	bc := hdpb.BucketCounts()
+	return b.appendBuckets(hdpb, hdpb.Offset(), bc.Len(), bc.At)
+}
+
+// AppendDownscaled appends the given buckets after reducing their scale by
+// shift, i.e. after merging each group of 2^shift adjacent buckets into a
+// single bucket. A shift <= 0 appends the buckets unchanged.
+func (b *EHistogramDataPointBucketsBuilder) AppendDownscaled(hdpb pmetric.ExponentialHistogramDataPointBuckets, shift int32) error {
+	if shift <= 0 {
+		return b.Append(hdpb)
+	}
+	if b.released {
+		return werror.Wrap(acommon.ErrBuilderAlreadyReleased)
+	}
+
+	var offset int32
+	offset, b.downscaled = downscaleBuckets(hdpb.Offset(), hdpb.BucketCounts(), shift, b.downscaled[:0])
+	counts := b.downscaled
+	return b.appendBuckets(hdpb, offset, len(counts), func(i int) uint64 { return counts[i] })
+}
+
+func (b *EHistogramDataPointBucketsBuilder) appendBuckets(hdpb pmetric.ExponentialHistogramDataPointBuckets, offset int32, bcc int, bucketCount func(int) uint64) error {
 	// If the offset is 0 and there are no bucket counts, no need to append
 	// anything.
-	if hdpb.Offset() == 0 && hdpb.BucketCounts().Len() == 0 {
+	if offset == 0 && bcc == 0 {
 		b.builder.AppendNull()
 		return nil
 	}
 
 	return b.builder.Append(hdpb, func() error {
-		b.ob.AppendNonZero(hdpb.Offset())
+		b.ob.AppendNonZero(offset)
 
-		bc := hdpb.BucketCounts()
-		bcc := bc.Len()
 		return b.bclb.Append(bcc, func() error {
 			for i := 0; i < bcc; i++ {
-				b.bcb.Append(bc.At(i))
+				b.bcb.Append(bucketCount(i))
 			}
 			return nil
 		})
 	})
 }
+
+// downscaleBuckets merges the buckets starting at the given offset into the
+// buckets of a scale reduced by shift (> 0). The bucket of index i becomes the
+// bucket of index i >> shift (arithmetic shift, so the negative indexes are
+// rounded towards the lower buckets). The merged counts are appended to dst.
+func downscaleBuckets(offset int32, counts pcommon.UInt64Slice, shift int32, dst []uint64) (int32, []uint64) {
+	newOffset := offset >> shift
+	for i := 0; i < counts.Len(); i++ {
+		idx := int((offset+int32(i))>>shift - newOffset)
+		for idx >= len(dst) {
+			dst = append(dst, 0)
+		}
+		dst[idx] += counts.At(i)
+	}
+	return newOffset, dst
+}
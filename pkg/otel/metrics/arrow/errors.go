@@ -21,4 +21,7 @@ import "errors"
 
 var (
 	ErrUnknownMetricType = errors.New("unknown metric type")
+	// ErrUnexpectedDataPoint is returned by MetricsAppender for a data point
+	// appended without metric or to a metric of another type.
+	ErrUnexpectedDataPoint = errors.New("data point of an unexpected type")
 )
@@ -75,6 +75,10 @@ type (
 		groupCount uint32
 		exemplars  []Exemplar
 		sorter     ExemplarSorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointGroupCount uint32
+		checkpointLen        int
 	}
 
 	ExemplarParentIdEncoder struct {
@@ -235,6 +239,14 @@ func (b *ExemplarBuilder) Reset() {
 	b.accumulator.Reset()
 }
 
+func (b *ExemplarBuilder) Checkpoint() {
+	b.accumulator.Checkpoint()
+}
+
+func (b *ExemplarBuilder) Rollback() {
+	b.accumulator.Rollback()
+}
+
 func (b *ExemplarBuilder) PayloadType() *carrow.PayloadType {
 	return b.payloadType
 }
@@ -286,6 +298,21 @@ func (a *ExemplarAccumulator) Append(dpID uint32, exemplars pmetric.ExemplarSlic
 func (a *ExemplarAccumulator) Reset() {
 	a.groupCount = 0
 	a.exemplars = a.exemplars[:0]
+	a.checkpointGroupCount = 0
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *ExemplarAccumulator) Checkpoint() {
+	a.checkpointGroupCount = a.groupCount
+	a.checkpointLen = len(a.exemplars)
+}
+
+// Rollback discards the exemplars appended since the last checkpoint.
+func (a *ExemplarAccumulator) Rollback() {
+	a.groupCount = a.checkpointGroupCount
+	a.exemplars = a.exemplars[:a.checkpointLen]
 }
 
 func NewExemplarParentIdEncoder(encoderType int) *ExemplarParentIdEncoder {
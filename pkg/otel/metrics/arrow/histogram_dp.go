@@ -36,6 +36,7 @@ import (
 	"errors"
 	"math"
 
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
 	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
@@ -62,7 +63,10 @@ var (
 		{Name: constants.Flags, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.HistogramMin, Type: arrow.PrimitiveTypes.Float64, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.HistogramMax, Type: arrow.PrimitiveTypes.Float64, Metadata: schema.Metadata(schema.Optional)},
-	}, nil)
+		// Stable identifier of the series the DP belongs to (see
+		// config.WithSeriesID). Absent if the option is not enabled.
+		{Name: constants.SeriesID, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
+	}, schema.SortOrder(constants.ParentID))
 )
 
 type (
@@ -84,6 +88,7 @@ type (
 		heblb *builder.ListBuilder      // histogram_explicit_bounds list builder
 		hebb  *builder.Float64Builder   // histogram_explicit_bounds builder
 		fb    *builder.Uint32Builder    // flags builder
+		sib   *builder.Uint32Builder    // series_id builder
 		hmib  *builder.Float64Builder   // histogram_min builder
 		hmab  *builder.Float64Builder   // histogram_max builder
 
@@ -95,6 +100,8 @@ type (
 
 	HDP struct {
 		ParentID uint16
+		// SeriesID is the stable series identifier of the DP, 0 if absent.
+		SeriesID uint32
 		Orig     *pmetric.HistogramDataPoint
 	}
 
@@ -102,6 +109,10 @@ type (
 		groupCount uint32
 		hdps       []HDP
 		sorter     HistogramSorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointGroupCount uint32
+		checkpointLen        int
 	}
 
 	HistogramSorter interface {
@@ -143,6 +154,7 @@ func (b *HistogramDataPointBuilder) init() {
 	b.hbcb = b.hbclb.Uint64Builder()
 	b.hebb = b.heblb.Float64Builder()
 	b.fb = b.builder.Uint32Builder(constants.Flags)
+	b.sib = b.builder.Uint32Builder(constants.SeriesID)
 	b.hmib = b.builder.Float64Builder(constants.HistogramMin)
 	b.hmab = b.builder.Float64Builder(constants.HistogramMax)
 }
@@ -207,6 +219,14 @@ func (b *HistogramDataPointBuilder) Reset() {
 	b.dataPointAccumulator.Reset()
 }
 
+func (b *HistogramDataPointBuilder) Checkpoint() {
+	b.dataPointAccumulator.Checkpoint()
+}
+
+func (b *HistogramDataPointBuilder) Rollback() {
+	b.dataPointAccumulator.Rollback()
+}
+
 func (b *HistogramDataPointBuilder) PayloadType() *carrow.PayloadType {
 	return carrow.PayloadTypes.Histogram
 }
@@ -283,6 +303,7 @@ func (b *HistogramDataPointBuilder) TryBuild(attrsAccu *carrow.Attributes32Accum
 		}
 
 		b.fb.Append(uint32(hdp.Flags()))
+		b.sib.AppendNonZero(hdpRec.SeriesID)
 
 		if hdp.HasMin() {
 			b.hmib.AppendNonZero(hdp.Min())
@@ -315,9 +336,21 @@ func (a *HDPAccumulator) IsEmpty() bool {
 	return len(a.hdps) == 0
 }
 
+// Append appends a slice of histogram data points to the accumulator.
 func (a *HDPAccumulator) Append(
 	parentID uint16,
 	hdps pmetric.HistogramDataPointSlice,
+) {
+	a.AppendWithSeriesIDs(parentID, hdps, nil)
+}
+
+// AppendWithSeriesIDs appends a slice of histogram data points and their
+// stable series identifiers, returned by seriesID given the attributes of each
+// data point (nil if disabled), to the accumulator.
+func (a *HDPAccumulator) AppendWithSeriesIDs(
+	parentID uint16,
+	hdps pmetric.HistogramDataPointSlice,
+	seriesID func(pcommon.Map) uint32,
 ) {
 	if a.groupCount == math.MaxUint32 {
 		panic("The maximum number of group of histogram data points has been reached (max is uint32).")
@@ -330,8 +363,13 @@ func (a *HDPAccumulator) Append(
 	for i := 0; i < hdps.Len(); i++ {
 		hdp := hdps.At(i)
 
+		var ID uint32
+		if seriesID != nil {
+			ID = seriesID(hdp.Attributes())
+		}
 		a.hdps = append(a.hdps, HDP{
 			ParentID: parentID,
+			SeriesID: ID,
 			Orig:     &hdp,
 		})
 	}
@@ -342,6 +380,21 @@ func (a *HDPAccumulator) Append(
 func (a *HDPAccumulator) Reset() {
 	a.groupCount = 0
 	a.hdps = a.hdps[:0]
+	a.checkpointGroupCount = 0
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *HDPAccumulator) Checkpoint() {
+	a.checkpointGroupCount = a.groupCount
+	a.checkpointLen = len(a.hdps)
+}
+
+// Rollback discards the histogram data points appended since the last checkpoint.
+func (a *HDPAccumulator) Rollback() {
+	a.groupCount = a.checkpointGroupCount
+	a.hdps = a.hdps[:a.checkpointLen]
 }
 
 // No sorting
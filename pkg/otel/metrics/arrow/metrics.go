@@ -15,13 +15,18 @@
 package arrow
 
 import (
+	"time"
+
 	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
+	"github.com/f5/otel-arrow-adapter/pkg/config"
 	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/enums"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/stats"
 	"github.com/f5/otel-arrow-adapter/pkg/werror"
 )
@@ -32,6 +37,9 @@ var (
 		{Name: constants.ID, Type: arrow.PrimitiveTypes.Uint16, Metadata: schema.Metadata(schema.DeltaEncoding)},
 		{Name: constants.Resource, Type: carrow.ResourceDT, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.Scope, Type: carrow.ScopeDT, Metadata: schema.Metadata(schema.Optional)},
+		// ID of the scope in the scope table, replacing the scope struct (see
+		// config.WithScopeTable).
+		{Name: constants.ScopeID, Type: arrow.PrimitiveTypes.Uint16, Metadata: schema.Metadata(schema.Optional)},
 		// This schema URL applies to the span and span events (the schema URL
 		// for the resource is in the resource struct).
 		{Name: constants.SchemaUrl, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
@@ -41,6 +49,8 @@ var (
 		{Name: constants.Unit, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
 		{Name: constants.AggregationTemporality, Type: arrow.PrimitiveTypes.Int32, Metadata: schema.Metadata(schema.Optional, schema.Dictionary8)},
 		{Name: constants.IsMonotonic, Type: arrow.FixedWidthTypes.Boolean, Metadata: schema.Metadata(schema.Optional)},
+		// Expiry of the metrics (see config.WithRetention).
+		{Name: constants.ExpiryTimeUnixNano, Type: arrow.FixedWidthTypes.Timestamp_ns, Metadata: schema.Metadata(schema.Optional)},
 	}, nil)
 )
 
@@ -51,6 +61,7 @@ type MetricsBuilder struct {
 	builder *builder.RecordBuilderExt   // Record builder
 	rb      *carrow.ResourceBuilder     // `resource` builder
 	scb     *carrow.ScopeBuilder        // `scope` builder
+	scidb   *builder.Uint16Builder      // scope id builder (scope table)
 	sschb   *builder.StringBuilder      // scope `schema_url` builder
 	ib      *builder.Uint16DeltaBuilder //  id builder
 	mtb     *builder.Uint8Builder       // metric type builder
@@ -59,9 +70,25 @@ type MetricsBuilder struct {
 	ub      *builder.StringBuilder      // metric unit builder
 	atb     *builder.Int32Builder       // aggregation temporality builder
 	imb     *builder.BooleanBuilder     // is monotonic builder
+	expb    *builder.TimestampBuilder   // expiry time unix nano builder
 
 	optimizer *MetricsOptimizer
 	analyzer  *MetricsAnalyzer
+	reporter  *carrow.AnalyzerReporter
+
+	// emptyPerRecord reserves an attributes ID for each resource and scope
+	// entry without attributes (see config.EmptyPerRecord).
+	emptyPerRecord bool
+	// scopeTable references the scopes by their ID in the scope table (see
+	// config.WithScopeTable).
+	scopeTable bool
+
+	// series is nil if stable series identifiers are disabled.
+	series *SeriesRegistry
+
+	// retention is the retention stamped in the expiry column, 0 if the
+	// column is disabled (see config.WithRetention).
+	retention time.Duration
 
 	relatedData *RelatedData
 }
@@ -74,6 +101,9 @@ func NewMetricsBuilder(
 ) (*MetricsBuilder, error) {
 	var optimizer *MetricsOptimizer
 	var analyzer *MetricsAnalyzer
+	var reporter *carrow.AnalyzerReporter
+
+	emptyPerRecord := cfg.Global != nil && cfg.Global.EmptyResourceScope == config.EmptyPerRecord
 
 	relatedData, err := NewRelatedData(cfg, stats)
 	if err != nil {
@@ -81,18 +111,32 @@ func NewMetricsBuilder(
 	}
 
 	if stats.SchemaStatsEnabled {
-		optimizer = NewMetricsOptimizer(cfg.Metric.Sorter)
+		optimizer = NewMetricsOptimizer(cfg.Metric.Sorter, emptyPerRecord)
 		analyzer = NewMetricsAnalyzer()
+		reporter, err = carrow.NewAnalyzerReporter(cfg.Global)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
 	} else {
-		optimizer = NewMetricsOptimizer(cfg.Metric.Sorter)
+		optimizer = NewMetricsOptimizer(cfg.Metric.Sorter, emptyPerRecord)
 	}
 
 	b := &MetricsBuilder{
-		released:    false,
-		builder:     rBuilder,
-		optimizer:   optimizer,
-		analyzer:    analyzer,
-		relatedData: relatedData,
+		released:       false,
+		builder:        rBuilder,
+		optimizer:      optimizer,
+		analyzer:       analyzer,
+		reporter:       reporter,
+		emptyPerRecord: emptyPerRecord,
+		scopeTable:     cfg.Global != nil && cfg.Global.ScopeTable,
+		relatedData:    relatedData,
+	}
+
+	if cfg.Global != nil && cfg.Global.SeriesID {
+		b.series = NewSeriesRegistry(DefaultMaxSeries)
+	}
+	if cfg.Global != nil {
+		b.retention = cfg.Global.Retention.Metrics
 	}
 
 	if err := b.init(); err != nil {
@@ -110,6 +154,7 @@ func (b *MetricsBuilder) init() error {
 
 	b.rb = carrow.ResourceBuilderFrom(b.builder.StructBuilder(constants.Resource))
 	b.scb = carrow.ScopeBuilderFrom(b.builder.StructBuilder(constants.Scope))
+	b.scidb = b.builder.Uint16Builder(constants.ScopeID)
 	b.sschb = b.builder.StringBuilder(constants.SchemaUrl)
 
 	b.mtb = b.builder.Uint8Builder(constants.MetricType)
@@ -118,6 +163,7 @@ func (b *MetricsBuilder) init() error {
 	b.ub = b.builder.StringBuilder(constants.Unit)
 	b.atb = b.builder.Int32Builder(constants.AggregationTemporality)
 	b.imb = b.builder.BooleanBuilder(constants.IsMonotonic)
+	b.expb = b.builder.TimestampBuilder(constants.ExpiryTimeUnixNano)
 
 	return nil
 }
@@ -130,6 +176,12 @@ func (b *MetricsBuilder) RelatedData() *RelatedData {
 	return b.relatedData
 }
 
+// Analyzer returns the analyzer of the builder, nil if the schema stats are
+// disabled (see config.WithStats).
+func (b *MetricsBuilder) Analyzer() *MetricsAnalyzer {
+	return b.analyzer
+}
+
 // Build builds an Arrow Record from the builder.
 //
 // Once the array is no longer needed, Release() must be called to free the
@@ -150,22 +202,54 @@ func (b *MetricsBuilder) Build() (record arrow.Record, err error) {
 	return
 }
 
+// checkpoint marks the beginning of a batch, i.e. the state restored by
+// rollback.
+func (b *MetricsBuilder) checkpoint() {
+	b.builder.Checkpoint()
+	b.relatedData.Checkpoint()
+}
+
+// rollback discards the rows and the related data appended since the
+// beginning of the current batch and retrieves the new underlying builders.
+func (b *MetricsBuilder) rollback() error {
+	b.builder.Rollback()
+	b.relatedData.Rollback()
+	if err := b.init(); err != nil {
+		return werror.Wrap(err)
+	}
+	return nil
+}
+
 // Append appends a new set of resource metrics to the builder.
-func (b *MetricsBuilder) Append(metrics pmetric.Metrics) error {
+func (b *MetricsBuilder) Append(metrics pmetric.Metrics) (err error) {
 	if b.released {
 		return werror.Wrap(carrow.ErrBuilderAlreadyReleased)
 	}
 
+	// The rows of a batch that fails to append are discarded, so that they
+	// are not mixed with the rows of the next batch.
+	b.checkpoint()
+	defer func() {
+		if err != nil {
+			if rollbackErr := b.rollback(); rollbackErr != nil {
+				err = werror.WrapWithContext(err, map[string]interface{}{"rollback_error": rollbackErr.Error()})
+			}
+		}
+	}()
+
 	optimizedMetrics := b.optimizer.Optimize(metrics)
-	if b.analyzer != nil {
+	if b.analyzer != nil && b.reporter.Sample() {
 		b.analyzer.Analyze(optimizedMetrics)
-		b.analyzer.ShowStats("")
+		b.reporter.Report(b.analyzer)
 	}
 
 	metricID := uint16(0)
-	var resMetricsID, scopeMetricsID string
-	var resID, scopeID int64
-	var err error
+	var resScope resourceScopeState
+
+	var expiry arrow.Timestamp
+	if b.retention > 0 {
+		expiry = arrow.Timestamp(time.Now().Add(b.retention).UnixNano())
+	}
 
 	b.builder.Reserve(len(optimizedMetrics.Metrics))
 
@@ -175,37 +259,13 @@ func (b *MetricsBuilder) Append(metrics pmetric.Metrics) error {
 		b.ib.Append(ID)
 		metricID++
 
-		// Resource spans
-		if resMetricsID != metric.ResourceMetricsID {
-			resMetricsID = metric.ResourceMetricsID
-			resID, err = b.relatedData.AttrsBuilders().Resource().Accumulator().Append(metric.Resource.Attributes())
-			if err != nil {
-				return werror.Wrap(err)
-			}
-		}
-		if err = b.rb.AppendWithID(resID, metric.Resource, metric.ResourceSchemaUrl); err != nil {
+		if err = b.appendResourceScope(&resScope, metric); err != nil {
 			return werror.Wrap(err)
 		}
-
-		// Scope spans
-		if scopeMetricsID != metric.ScopeMetricsID {
-			scopeMetricsID = metric.ScopeMetricsID
-			scopeID, err = b.relatedData.AttrsBuilders().scope.Accumulator().Append(metric.Scope.Attributes())
-			if err != nil {
-				return werror.Wrap(err)
-			}
-		}
-		if err = b.scb.AppendWithAttrsID(scopeID, metric.Scope); err != nil {
+		b.appendMetricDesc(metric.Metric.Type(), metric.Metric.Name(), metric.Metric.Description(), metric.Metric.Unit())
+		if err = b.relatedData.AttrsBuilders().MetricMetadata().Accumulator().AppendWithID(ID, metric.Metric.Metadata()); err != nil {
 			return werror.Wrap(err)
 		}
-		b.sschb.AppendNonEmpty(metric.ScopeSchemaUrl)
-
-		// Metric type is an int32 in the proto spec, but we don't expect more
-		// than 256 types, so we use an uint8 instead.
-		b.mtb.Append(uint8(metric.Metric.Type()))
-		b.nb.AppendNonEmpty(metric.Metric.Name())
-		b.db.AppendNonEmpty(metric.Metric.Description())
-		b.ub.AppendNonEmpty(metric.Metric.Unit())
 
 		switch metric.Metric.Type() {
 		case pmetric.MetricTypeGauge:
@@ -214,7 +274,7 @@ func (b *MetricsBuilder) Append(metrics pmetric.Metrics) error {
 			dps := metric.Metric.Gauge().DataPoints()
 			for i := 0; i < dps.Len(); i++ {
 				dp := dps.At(i)
-				b.relatedData.NumberDPBuilder().Accumulator().Append(ID, &dp)
+				b.relatedData.NumberDPBuilder().Accumulator().AppendWithSeriesID(ID, b.seriesID(metric, &dp), &dp)
 			}
 		case pmetric.MetricTypeSum:
 			sum := metric.Metric.Sum()
@@ -223,35 +283,127 @@ func (b *MetricsBuilder) Append(metrics pmetric.Metrics) error {
 			dps := sum.DataPoints()
 			for i := 0; i < dps.Len(); i++ {
 				dp := dps.At(i)
-				b.relatedData.NumberDPBuilder().Accumulator().Append(ID, &dp)
+				b.relatedData.NumberDPBuilder().Accumulator().AppendWithSeriesID(ID, b.seriesID(metric, &dp), &dp)
 			}
 		case pmetric.MetricTypeSummary:
 			b.atb.AppendNull()
 			b.imb.AppendNull()
 			dps := metric.Metric.Summary().DataPoints()
-			b.relatedData.SummaryDPBuilder().Accumulator().Append(ID, dps)
+			b.relatedData.SummaryDPBuilder().Accumulator().AppendWithSeriesIDs(ID, dps, b.seriesIDs(metric))
 		case pmetric.MetricTypeHistogram:
 			histogram := metric.Metric.Histogram()
 			b.atb.Append(int32(histogram.AggregationTemporality()))
 			b.imb.AppendNull()
 			dps := histogram.DataPoints()
-			b.relatedData.HistogramDPBuilder().Accumulator().Append(ID, dps)
+			b.relatedData.HistogramDPBuilder().Accumulator().AppendWithSeriesIDs(ID, dps, b.seriesIDs(metric))
 		case pmetric.MetricTypeExponentialHistogram:
 			exponentialHistogram := metric.Metric.ExponentialHistogram()
 			b.atb.Append(int32(exponentialHistogram.AggregationTemporality()))
 			b.imb.AppendNull()
 			dps := exponentialHistogram.DataPoints()
-			b.relatedData.EHistogramDPBuilder().Accumulator().Append(ID, dps)
+			b.relatedData.EHistogramDPBuilder().Accumulator().AppendWithSeriesIDs(ID, dps, b.seriesIDs(metric))
 		case pmetric.MetricTypeEmpty:
 			b.atb.AppendNull()
 			b.imb.AppendNull()
 		default:
 			// ToDo should log and ignore unknown metric types.
 		}
+
+		if expiry != 0 {
+			b.expb.Append(expiry)
+		}
 	}
 	return nil
 }
 
+// resourceScopeState tracks the resource and scope of the last row appended
+// to the main record, the resource and scope attributes being appended once
+// per resource and scope entry.
+type resourceScopeState struct {
+	resMetricsID, scopeMetricsID string
+	resID, scopeID               int64
+	scopeTableID                 uint16
+}
+
+// appendResourceScope appends the resource and scope columns of a row, only
+// the resource and scope sections of the given metric are used.
+func (b *MetricsBuilder) appendResourceScope(state *resourceScopeState, metric *FlattenedMetric) (err error) {
+	// Resource spans
+	if state.resMetricsID != metric.ResourceMetricsID {
+		state.resMetricsID = metric.ResourceMetricsID
+		state.resID, err = b.relatedData.AttrsBuilders().Resource().Accumulator().Append(metric.Resource.Attributes())
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		if state.resID < 0 && b.emptyPerRecord {
+			state.resID = b.relatedData.AttrsBuilders().Resource().Accumulator().AppendEmpty()
+		}
+	}
+	if err = b.rb.AppendWithID(state.resID, metric.Resource, metric.ResourceSchemaUrl); err != nil {
+		return werror.Wrap(err)
+	}
+
+	// Scope spans
+	if b.scopeTable {
+		if state.scopeMetricsID != metric.ScopeMetricsID {
+			state.scopeMetricsID = metric.ScopeMetricsID
+			state.scopeTableID, err = b.relatedData.ScopeTableBuilder().Accumulator().Append(metric.ScopeMetricsID, metric.Scope)
+			if err != nil {
+				return werror.Wrap(err)
+			}
+		}
+		b.scidb.Append(state.scopeTableID)
+	} else {
+		if state.scopeMetricsID != metric.ScopeMetricsID {
+			state.scopeMetricsID = metric.ScopeMetricsID
+			state.scopeID, err = b.relatedData.AttrsBuilders().scope.Accumulator().Append(metric.Scope.Attributes())
+			if err != nil {
+				return werror.Wrap(err)
+			}
+			if state.scopeID < 0 && b.emptyPerRecord {
+				state.scopeID = b.relatedData.AttrsBuilders().scope.Accumulator().AppendEmpty()
+			}
+		}
+		if err = b.scb.AppendWithAttrsID(state.scopeID, metric.Scope); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+	b.sschb.AppendNonEmpty(metric.ScopeSchemaUrl)
+	return nil
+}
+
+// appendMetricDesc appends the type, name, description and unit columns of a
+// row.
+func (b *MetricsBuilder) appendMetricDesc(metricType pmetric.MetricType, name, description, unit string) {
+	// Metric type is an int32 in the proto spec, but we don't expect more
+	// than 256 types, so we use an uint8 instead.
+	b.mtb.Append(uint8(enums.MetricTypeFrom(metricType)))
+	b.nb.AppendNonEmpty(name)
+	b.db.AppendNonEmpty(description)
+	b.ub.AppendNonEmpty(unit)
+}
+
+// seriesID returns the stable series identifier of the given data point or 0
+// if this feature is disabled.
+func (b *MetricsBuilder) seriesID(metric *FlattenedMetric, dp *pmetric.NumberDataPoint) uint32 {
+	if b.series == nil {
+		return 0
+	}
+	return b.series.SeriesID(metric, dp.Attributes())
+}
+
+// seriesIDs returns the function returning the stable series identifier of a
+// data point of the given metric given its attributes, or nil if this feature
+// is disabled.
+func (b *MetricsBuilder) seriesIDs(metric *FlattenedMetric) func(pcommon.Map) uint32 {
+	if b.series == nil {
+		return nil
+	}
+	return func(attrs pcommon.Map) uint32 {
+		return b.series.SeriesID(metric, attrs)
+	}
+}
+
 // Release releases the memory allocated by the builder.
 func (b *MetricsBuilder) Release() {
 	if !b.released {
@@ -259,6 +411,9 @@ func (b *MetricsBuilder) Release() {
 		b.released = true
 
 		b.relatedData.Release()
+		if b.reporter != nil {
+			_ = b.reporter.Close()
+		}
 	}
 }
 
@@ -44,7 +44,10 @@ var (
 		{Name: constants.IntValue, Type: arrow.PrimitiveTypes.Int64},
 		{Name: constants.DoubleValue, Type: arrow.PrimitiveTypes.Float64},
 		{Name: constants.Flags, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
-	}, nil)
+		// Stable identifier of the series the DP belongs to (see
+		// config.WithSeriesID). Absent if the option is not enabled.
+		{Name: constants.SeriesID, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
+	}, schema.SortOrder(constants.ParentID))
 )
 
 type (
@@ -62,6 +65,7 @@ type (
 		ivb   *builder.Int64Builder     // int_value builder
 		dvb   *builder.Float64Builder   // double_value builder
 		fb    *builder.Uint32Builder    // flags builder
+		sib   *builder.Uint32Builder    // series_id builder
 
 		dataPointAccumulator *DPAccumulator
 		attrsAccu            *carrow.Attributes32Accumulator
@@ -75,6 +79,8 @@ type (
 	// DPAccumulator.
 	DP struct {
 		ParentID uint16
+		// SeriesID is the stable series identifier of the DP, 0 if absent.
+		SeriesID uint32
 		Orig     *pmetric.NumberDataPoint
 	}
 
@@ -82,6 +88,9 @@ type (
 	DPAccumulator struct {
 		dps    []DP
 		sorter NumberDataPointSorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointLen int
 	}
 
 	NumberDataPointSorter interface {
@@ -138,6 +147,7 @@ func (b *DataPointBuilder) init() {
 	b.ivb = b.builder.Int64Builder(constants.IntValue)
 	b.dvb = b.builder.Float64Builder(constants.DoubleValue)
 	b.fb = b.builder.Uint32Builder(constants.Flags)
+	b.sib = b.builder.Uint32Builder(constants.SeriesID)
 }
 
 func (b *DataPointBuilder) SetAttributesAccumulator(accu *carrow.Attributes32Accumulator) {
@@ -234,6 +244,7 @@ func (b *DataPointBuilder) TryBuild(attrsAccu *carrow.Attributes32Accumulator) (
 			b.dvb.AppendNull()
 		}
 		b.fb.Append(uint32(ndp.Orig.Flags()))
+		b.sib.AppendNonZero(ndp.SeriesID)
 
 		exemplars := ndp.Orig.Exemplars()
 		if exemplars.Len() > 0 {
@@ -257,6 +268,14 @@ func (b *DataPointBuilder) Reset() {
 	b.dataPointAccumulator.Reset()
 }
 
+func (b *DataPointBuilder) Checkpoint() {
+	b.dataPointAccumulator.Checkpoint()
+}
+
+func (b *DataPointBuilder) Rollback() {
+	b.dataPointAccumulator.Rollback()
+}
+
 func (b *DataPointBuilder) PayloadType() *carrow.PayloadType {
 	return b.payloadType
 }
@@ -282,19 +301,42 @@ func (a *DPAccumulator) IsEmpty() bool {
 	return len(a.dps) == 0
 }
 
-// Append appends a slice of number data points to the accumulator.
+// Append appends a number data point to the accumulator.
 func (a *DPAccumulator) Append(
 	parentId uint16,
 	dp *pmetric.NumberDataPoint,
+) {
+	a.AppendWithSeriesID(parentId, 0, dp)
+}
+
+// AppendWithSeriesID appends a number data point and its stable series
+// identifier to the accumulator.
+func (a *DPAccumulator) AppendWithSeriesID(
+	parentId uint16,
+	seriesID uint32,
+	dp *pmetric.NumberDataPoint,
 ) {
 	a.dps = append(a.dps, DP{
 		ParentID: parentId,
+		SeriesID: seriesID,
 		Orig:     dp,
 	})
 }
 
 func (a *DPAccumulator) Reset() {
 	a.dps = a.dps[:0]
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *DPAccumulator) Checkpoint() {
+	a.checkpointLen = len(a.dps)
+}
+
+// Rollback discards the data points appended since the last checkpoint.
+func (a *DPAccumulator) Rollback() {
+	a.dps = a.dps[:a.checkpointLen]
 }
 
 // No sorting
@@ -29,6 +29,11 @@ import (
 type (
 	MetricsOptimizer struct {
 		sorter MetricSorter
+
+		// emptyPerRecord keeps the empty resources and scopes of the
+		// resource and scope metrics separate (see
+		// config.EmptyPerRecord).
+		emptyPerRecord bool
 	}
 
 	MetricsOptimized struct {
@@ -59,9 +64,10 @@ type (
 	MetricsByTypeNameResourceScope struct{}
 )
 
-func NewMetricsOptimizer(sorter MetricSorter) *MetricsOptimizer {
+func NewMetricsOptimizer(sorter MetricSorter, emptyPerRecord bool) *MetricsOptimizer {
 	return &MetricsOptimizer{
-		sorter: sorter,
+		sorter:         sorter,
+		emptyPerRecord: emptyPerRecord,
 	}
 }
 
@@ -75,14 +81,14 @@ func (t *MetricsOptimizer) Optimize(metrics pmetric.Metrics) *MetricsOptimized {
 		resMetrics := resMetricsSlice.At(i)
 		resource := resMetrics.Resource()
 		resourceSchemaUrl := resMetrics.SchemaUrl()
-		resMetricID := otlp.ResourceID(resource, resourceSchemaUrl)
+		resMetricID := otlp.ResourceEntryID(resource, resourceSchemaUrl, t.emptyPerRecord, i)
 
 		scopeMetricsSlice := resMetrics.ScopeMetrics()
 		for j := 0; j < scopeMetricsSlice.Len(); j++ {
 			scopeMetrics := scopeMetricsSlice.At(j)
 			scope := scopeMetrics.Scope()
 			scopeSchemaUrl := scopeMetrics.SchemaUrl()
-			scopeMetricsID := otlp.ScopeID(scope, scopeSchemaUrl)
+			scopeMetricsID := otlp.ScopeEntryID(scope, scopeSchemaUrl, t.emptyPerRecord, i, j)
 
 			metrics := scopeMetrics.Metrics()
 			for k := 0; k < metrics.Len(); k++ {
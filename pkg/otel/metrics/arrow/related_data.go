@@ -38,6 +38,7 @@ type (
 		relatedRecordsManager *carrow.RelatedRecordsManager
 
 		attrsBuilders       *AttrsBuilders
+		scopeTableBuilder   *carrow.ScopeTableBuilder
 		numberDPBuilder     *DataPointBuilder
 		summaryDPBuilder    *SummaryDataPointBuilder
 		histogramDPBuilder  *HistogramDataPointBuilder
@@ -55,6 +56,9 @@ type (
 		resource *carrow.Attrs16Builder
 		scope    *carrow.Attrs16Builder
 
+		// metric metadata
+		metricMetadata *carrow.Attrs16Builder
+
 		// metrics attributes
 		number_dp  *carrow.Attrs32Builder
 		summary    *carrow.Attrs32Builder
@@ -79,6 +83,16 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 		return carrow.NewAttrs16BuilderWithEncoding(b, carrow.PayloadTypes.ScopeAttrs, cfg.Attrs.Scope)
 	})
 
+	scopeTableBuilder := rrManager.Declare(carrow.PayloadTypes.Scopes, carrow.PayloadTypes.Metrics, carrow.ScopeTableSchema, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
+		sb := carrow.NewScopeTableBuilder(b)
+		sb.SetAttributesAccumulator(scopeAttrsBuilder.(*carrow.Attrs16Builder).Accumulator())
+		return sb
+	})
+
+	metricMetadataBuilder := rrManager.Declare(carrow.PayloadTypes.MetricMetadata, carrow.PayloadTypes.Metrics, carrow.AttrsSchema16, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
+		return carrow.NewAttrs16BuilderWithEncoding(b, carrow.PayloadTypes.MetricMetadata, cfg.Attrs.MetricMetadata)
+	})
+
 	numberDPBuilder := rrManager.Declare(carrow.PayloadTypes.NumberDataPoints, carrow.PayloadTypes.Metrics, DataPointSchema, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
 		return NewDataPointBuilder(b, carrow.PayloadTypes.NumberDataPoints, cfg.NumberDP)
 	})
@@ -134,7 +148,11 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 	})
 
 	ehistogramDPBuilder := rrManager.Declare(carrow.PayloadTypes.ExpHistogram, carrow.PayloadTypes.Metrics, EHistogramDataPointSchema, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
-		return NewEHistogramDataPointBuilder(b, cfg.ExpHistogram)
+		ehb := NewEHistogramDataPointBuilder(b, cfg.ExpHistogram)
+		if cfg.Global != nil && cfg.Global.DownscaleExpHistograms {
+			ehb.SetMaxScale(cfg.Global.ExpHistogramMaxScale)
+		}
+		return ehb
 	})
 
 	ehistogramAttrsBuilder := rrManager.Declare(carrow.PayloadTypes.ExpHistogramAttrs, carrow.PayloadTypes.ExpHistogram, carrow.DeltaEncodedAttrsSchema32, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
@@ -160,6 +178,7 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 		attrsBuilders: &AttrsBuilders{
 			resource:           resourceAttrsBuilder.(*carrow.Attrs16Builder),
 			scope:              scopeAttrsBuilder.(*carrow.Attrs16Builder),
+			metricMetadata:     metricMetadataBuilder.(*carrow.Attrs16Builder),
 			summary:            summaryAttrsBuilder.(*carrow.Attrs32Builder),
 			number_dp:          numberDPAttrsBuilder.(*carrow.Attrs32Builder),
 			histogram:          histogramAttrsBuilder.(*carrow.Attrs32Builder),
@@ -168,6 +187,7 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 			histogramExemplar:  histogramExemplarAttrsBuilder.(*carrow.Attrs32Builder),
 			eHistogramExemplar: ehistogramExemplarAttrsBuilder.(*carrow.Attrs32Builder),
 		},
+		scopeTableBuilder:         scopeTableBuilder.(*carrow.ScopeTableBuilder),
 		numberDPBuilder:           numberDPBuilder.(*DataPointBuilder),
 		summaryDPBuilder:          summaryDPBuilder.(*SummaryDataPointBuilder),
 		histogramDPBuilder:        histogramDPBuilder.(*HistogramDataPointBuilder),
@@ -194,6 +214,10 @@ func (r *RelatedData) AttrsBuilders() *AttrsBuilders {
 	return r.attrsBuilders
 }
 
+func (r *RelatedData) ScopeTableBuilder() *carrow.ScopeTableBuilder {
+	return r.scopeTableBuilder
+}
+
 func (r *RelatedData) NumberDPBuilder() *DataPointBuilder {
 	return r.numberDPBuilder
 }
@@ -227,6 +251,17 @@ func (r *RelatedData) Reset() {
 	r.relatedRecordsManager.Reset()
 }
 
+// Checkpoint marks the current state of the related data as the state restored
+// by the next call to Rollback.
+func (r *RelatedData) Checkpoint() {
+	r.relatedRecordsManager.Checkpoint()
+}
+
+// Rollback discards the related data appended since the last checkpoint.
+func (r *RelatedData) Rollback() {
+	r.relatedRecordsManager.Rollback()
+}
+
 func (r *RelatedData) NextMetricScopeID() uint16 {
 	c := r.nextMetricScopeID
 
@@ -245,6 +280,7 @@ func (r *RelatedData) BuildRecordMessages() ([]*record_message.RecordMessage, er
 func (ab *AttrsBuilders) Release() {
 	ab.resource.Release()
 	ab.scope.Release()
+	ab.metricMetadata.Release()
 
 	ab.number_dp.Release()
 	ab.summary.Release()
@@ -263,9 +299,14 @@ func (ab *AttrsBuilders) Scope() *carrow.Attrs16Builder {
 	return ab.scope
 }
 
+func (ab *AttrsBuilders) MetricMetadata() *carrow.Attrs16Builder {
+	return ab.metricMetadata
+}
+
 func (ab *AttrsBuilders) Reset() {
 	ab.resource.Accumulator().Reset()
 	ab.scope.Accumulator().Reset()
+	ab.metricMetadata.Accumulator().Reset()
 	ab.number_dp.Reset()
 	ab.summary.Reset()
 	ab.histogram.Reset()
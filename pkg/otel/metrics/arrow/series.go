@@ -0,0 +1,118 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+// Stable series identifiers for data points.
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/hashmap"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+)
+
+// DefaultMaxSeries is the default maximum number of series tracked by a
+// SeriesRegistry.
+const DefaultMaxSeries = 1 << 20
+
+// SeriesRegistry assigns a stable identifier to each series (resource, scope,
+// metric name, data point attributes) seen by a producer.
+//
+// The registry is owned by the MetricsBuilder and not by the underlying record
+// builders, so identifiers are preserved across schema updates. Identifiers
+// start at 1, 0 being reserved to represent the absence of identifier (in which
+// case the `series_id` column is null).
+//
+// The registry tracks at most maxSeries series in two generations of at most
+// maxSeries/2 series each. When the current generation is full, it becomes the
+// previous one and the series that were only present in the previous one are
+// evicted. A series seen again after its eviction is assigned a new
+// identifier, i.e. receivers see it as a new series. Identifiers are never
+// reused, except after the exhaustion of the uint32 range, in which case all
+// the series are evicted and the identifiers start again at 1.
+type SeriesRegistry struct {
+	current  *hashmap.StringMap
+	previous *hashmap.StringMap
+
+	nextID        uint32
+	maxGeneration int
+	keyBuf        strings.Builder
+}
+
+// NewSeriesRegistry creates a new SeriesRegistry tracking at most maxSeries
+// series (at least 2).
+func NewSeriesRegistry(maxSeries int) *SeriesRegistry {
+	maxGeneration := maxSeries / 2
+	if maxGeneration < 1 {
+		maxGeneration = 1
+	}
+	return &SeriesRegistry{
+		current:       hashmap.NewStringMap(0),
+		previous:      hashmap.NewStringMap(0),
+		nextID:        1,
+		maxGeneration: maxGeneration,
+	}
+}
+
+// SeriesID returns the identifier of the series defined by the given metric
+// context and data point attributes.
+func (r *SeriesRegistry) SeriesID(metric *FlattenedMetric, attrs pcommon.Map) uint32 {
+	return r.seriesID(metric.ResourceMetricsID, metric.ScopeMetricsID, metric.Metric.Name(), attrs)
+}
+
+func (r *SeriesRegistry) seriesID(resMetricsID, scopeMetricsID, name string, attrs pcommon.Map) uint32 {
+	r.keyBuf.Reset()
+	r.keyBuf.WriteString(resMetricsID)
+	r.keyBuf.WriteString("|")
+	r.keyBuf.WriteString(scopeMetricsID)
+	r.keyBuf.WriteString("|")
+	r.keyBuf.WriteString(name)
+	r.keyBuf.WriteString("|")
+	otlp.AttributesId(attrs, &r.keyBuf)
+
+	key := r.keyBuf.String()
+	if id, ok := r.current.Get(key); ok {
+		return id
+	}
+
+	// Series of the previous generation are promoted with their identifier.
+	id, ok := r.previous.Get(key)
+	if !ok {
+		if r.nextID == 0 {
+			// The uint32 range is exhausted.
+			r.current.Reset()
+			r.previous.Reset()
+			r.nextID = 1
+		}
+		id = r.nextID
+		r.nextID++
+	}
+
+	if r.current.Len() >= r.maxGeneration {
+		r.previous, r.current = r.current, r.previous
+		r.current.Reset()
+	}
+	r.current.GetOrInsert(key, id)
+	return id
+}
+
+// Len returns the number of entries of the two generations (at most
+// maxSeries), a promoted series being counted in both until the next
+// generation change.
+func (r *SeriesRegistry) Len() int {
+	return r.current.Len() + r.previous.Len()
+}
@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestSeriesRegistry(t *testing.T) {
+	t.Parallel()
+
+	metric := pmetric.NewMetric()
+	metric.SetName("requests")
+	fm := &FlattenedMetric{ResourceMetricsID: "res", ScopeMetricsID: "scope", Metric: metric}
+
+	attrs1 := pcommon.NewMap()
+	attrs1.PutStr("a", "1")
+	attrs1.PutStr("b", "2")
+	attrs2 := pcommon.NewMap()
+	attrs2.PutStr("b", "2")
+	attrs2.PutStr("a", "1")
+	attrs3 := pcommon.NewMap()
+	attrs3.PutStr("a", "3")
+
+	registry := NewSeriesRegistry(4)
+
+	id1 := registry.SeriesID(fm, attrs1)
+	require.Equal(t, uint32(1), id1)
+	// Attribute order doesn't matter.
+	require.Equal(t, id1, registry.SeriesID(fm, attrs2))
+	require.Equal(t, uint32(2), registry.SeriesID(fm, attrs3))
+
+	// The current generation is full, it becomes the previous one.
+	other := pmetric.NewMetric()
+	other.SetName("errors")
+	require.Equal(t, uint32(3), registry.SeriesID(&FlattenedMetric{ResourceMetricsID: "res", ScopeMetricsID: "scope", Metric: other}, attrs1))
+
+	// Known series keep their ID, the series of the previous generation are
+	// promoted.
+	require.Equal(t, id1, registry.SeriesID(fm, attrs1))
+
+	// The next generation change evicts the series that were not promoted,
+	// they get a new ID when seen again.
+	attrs4 := pcommon.NewMap()
+	attrs4.PutStr("a", "4")
+	require.Equal(t, uint32(4), registry.SeriesID(fm, attrs4))
+	require.Equal(t, uint32(5), registry.SeriesID(fm, attrs3))
+	require.Equal(t, id1, registry.SeriesID(fm, attrs1))
+	require.LessOrEqual(t, registry.Len(), 4)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package arrow
+
+import (
+	"strings"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Names of the built-in metric sorters (see RegisterMetricSorter).
+const (
+	UnsortedMetricSorter                = "unsorted"
+	ByResourceScopeTypeNameMetricSorter = "resource_scope_type_name"
+	ByTypeNameResourceScopeMetricSorter = "type_name_resource_scope"
+	DefaultMetricSorter                 = ByResourceScopeTypeNameMetricSorter
+)
+
+var metricSorters = arrow.NewSorterRegistry[MetricSorter]("metric")
+
+func init() {
+	RegisterMetricSorter(UnsortedMetricSorter, func() MetricSorter { return UnsortedMetrics() })
+	RegisterMetricSorter(ByResourceScopeTypeNameMetricSorter, func() MetricSorter { return SortMetricsByResourceScopeTypeName() })
+	RegisterMetricSorter(ByTypeNameResourceScopeMetricSorter, func() MetricSorter { return SortMetricsByTypeNameResourceScope() })
+}
+
+// RegisterMetricSorter registers a custom sort order of the metrics under the
+// given name, which can then be selected with config.WithMetricSorter. The
+// metrics are sorted before their encoding, so an order grouping the metrics
+// with similar attributes improves the compression ratio. A sorter registered
+// with the name of a built-in sorter replaces it.
+func RegisterMetricSorter(name string, newSorter func() MetricSorter) {
+	metricSorters.Register(name, newSorter)
+}
+
+// NewMetricSorter creates the metric sorter registered under the given name.
+func NewMetricSorter(name string) (MetricSorter, error) {
+	return metricSorters.New(name)
+}
+
+// MetricSorterNames returns the names of the registered metric sorters.
+func MetricSorterNames() []string {
+	return metricSorters.Names()
+}
+
+// metricSortKeys are the sort keys supported by SortMetricsByKeys.
+var metricSortKeys = map[string]arrow.Comparator[*FlattenedMetric]{
+	"resource": func(a, b *FlattenedMetric) int { return strings.Compare(a.ResourceMetricsID, b.ResourceMetricsID) },
+	"scope":    func(a, b *FlattenedMetric) int { return strings.Compare(a.ScopeMetricsID, b.ScopeMetricsID) },
+	"type":     func(a, b *FlattenedMetric) int { return int(a.Metric.Type()) - int(b.Metric.Type()) },
+	"name":     func(a, b *FlattenedMetric) int { return strings.Compare(a.Metric.Name(), b.Metric.Name()) },
+	"unit":     func(a, b *FlattenedMetric) int { return strings.Compare(a.Metric.Unit(), b.Metric.Unit()) },
+}
+
+// SortMetricsByKeys creates a sorter of the metrics by the given sort keys,
+// the first key being the most significant, among "resource", "scope",
+// "type", "name" and "unit". The default sorter is equivalent to the keys
+// resource, scope, type, name.
+func SortMetricsByKeys(keys ...string) (MetricSorter, error) {
+	sorter, err := arrow.NewKeySorter(keys, metricSortKeys)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return sorter, nil
+}
@@ -20,6 +20,7 @@ import (
 	"sort"
 
 	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
 	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
@@ -45,7 +46,10 @@ var (
 		{Name: constants.SummarySum, Type: arrow.PrimitiveTypes.Float64, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.SummaryQuantileValues, Type: arrow.ListOf(QuantileValueDT), Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.Flags, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
-	}, nil)
+		// Stable identifier of the series the DP belongs to (see
+		// config.WithSeriesID). Absent if the option is not enabled.
+		{Name: constants.SeriesID, Type: arrow.PrimitiveTypes.Uint32, Metadata: schema.Metadata(schema.Optional)},
+	}, schema.SortOrder(constants.ParentID))
 )
 
 type (
@@ -65,6 +69,7 @@ type (
 		qvlb  *builder.ListBuilder      // summary quantile value list builder
 		qvb   *QuantileValueBuilder     // summary quantile value builder
 		fb    *builder.Uint32Builder    // flags builder
+		sib   *builder.Uint32Builder    // series_id builder
 
 		accumulator *SummaryAccumulator
 		attrsAccu   *carrow.Attributes32Accumulator
@@ -74,6 +79,8 @@ type (
 
 	Summary struct {
 		ParentID uint16
+		// SeriesID is the stable series identifier of the DP, 0 if absent.
+		SeriesID uint32
 		Orig     *pmetric.SummaryDataPoint
 	}
 
@@ -81,6 +88,10 @@ type (
 		groupCount uint32
 		summaries  []Summary
 		sorter     SummarySorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointGroupCount uint32
+		checkpointLen        int
 	}
 
 	SummarySorter interface {
@@ -123,6 +134,7 @@ func (b *SummaryDataPointBuilder) init() {
 	b.qvlb = qvlb
 	b.qvb = QuantileValueBuilderFrom(qvlb.StructBuilder())
 	b.fb = b.builder.Uint32Builder(constants.Flags)
+	b.sib = b.builder.Uint32Builder(constants.SeriesID)
 }
 
 func (b *SummaryDataPointBuilder) SetAttributesAccumulator(accu *carrow.Attributes32Accumulator) {
@@ -181,6 +193,14 @@ func (b *SummaryDataPointBuilder) Reset() {
 	b.accumulator.Reset()
 }
 
+func (b *SummaryDataPointBuilder) Checkpoint() {
+	b.accumulator.Checkpoint()
+}
+
+func (b *SummaryDataPointBuilder) Rollback() {
+	b.accumulator.Rollback()
+}
+
 func (b *SummaryDataPointBuilder) PayloadType() *carrow.PayloadType {
 	return carrow.PayloadTypes.Summary
 }
@@ -222,6 +242,7 @@ func (b *SummaryDataPointBuilder) TryBuild(attrsAccu *carrow.Attributes32Accumul
 		b.ssb.AppendNonZero(summary.Orig.Sum())
 
 		b.fb.Append(uint32(summary.Orig.Flags()))
+		b.sib.AppendNonZero(summary.SeriesID)
 
 		qvs := summary.Orig.QuantileValues()
 		qvc := qvs.Len()
@@ -258,10 +279,21 @@ func (a *SummaryAccumulator) IsEmpty() bool {
 	return len(a.summaries) == 0
 }
 
-// Append appends a slice of number data points to the accumulator.
+// Append appends a slice of summary data points to the accumulator.
 func (a *SummaryAccumulator) Append(
 	parentID uint16,
 	summaries pmetric.SummaryDataPointSlice,
+) {
+	a.AppendWithSeriesIDs(parentID, summaries, nil)
+}
+
+// AppendWithSeriesIDs appends a slice of summary data points and their
+// stable series identifiers, returned by seriesID given the attributes of each
+// data point (nil if disabled), to the accumulator.
+func (a *SummaryAccumulator) AppendWithSeriesIDs(
+	parentID uint16,
+	summaries pmetric.SummaryDataPointSlice,
+	seriesID func(pcommon.Map) uint32,
 ) {
 	if a.groupCount == math.MaxUint32 {
 		panic("The maximum number of group of summary data points has been reached (max is uint32).")
@@ -274,8 +306,13 @@ func (a *SummaryAccumulator) Append(
 	for i := 0; i < summaries.Len(); i++ {
 		summary := summaries.At(i)
 
+		var ID uint32
+		if seriesID != nil {
+			ID = seriesID(summary.Attributes())
+		}
 		a.summaries = append(a.summaries, Summary{
 			ParentID: parentID,
+			SeriesID: ID,
 			Orig:     &summary,
 		})
 	}
@@ -286,6 +323,21 @@ func (a *SummaryAccumulator) Append(
 func (a *SummaryAccumulator) Reset() {
 	a.groupCount = 0
 	a.summaries = a.summaries[:0]
+	a.checkpointGroupCount = 0
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *SummaryAccumulator) Checkpoint() {
+	a.checkpointGroupCount = a.groupCount
+	a.checkpointLen = len(a.summaries)
+}
+
+// Rollback discards the summary data points appended since the last checkpoint.
+func (a *SummaryAccumulator) Rollback() {
+	a.groupCount = a.checkpointGroupCount
+	a.summaries = a.summaries[:a.checkpointLen]
 }
 
 // No sorting
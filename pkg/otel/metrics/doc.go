@@ -13,5 +13,6 @@
 // limitations under the License.
 
 // Package metrics provides functions to convert OTLP metrics to OTLP Arrow metrics and vice versa.
-// This package also supports the conversion of uni-variate metrics into multi-variate metrics.
+// This package also supports the conversion of uni-variate metrics into multi-variate metrics
+// (see MultivariateMetricsFrom).
 package metrics
@@ -0,0 +1,136 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+type (
+	// MultivariateMetric is a gauge or a sum whose number data points are
+	// grouped by timestamps and attributes. The attribute identified by the
+	// multivariate key names the variable of a data point, all the other
+	// attributes are shared by the variables of a group.
+	MultivariateMetric struct {
+		Resource pcommon.Resource
+		Scope    pcommon.InstrumentationScope
+		// Metric is the univariate metric, i.e. its name, description,
+		// unit, type and data points.
+		Metric pmetric.Metric
+		Groups []MultivariateGroup
+	}
+
+	// MultivariateGroup is a set of data points sharing their timestamps and
+	// attributes (except the multivariate key).
+	MultivariateGroup struct {
+		StartTimestamp pcommon.Timestamp
+		Timestamp      pcommon.Timestamp
+		// Attributes are the shared attributes, without the multivariate
+		// key.
+		Attributes pcommon.Map
+		Variables  []MultivariateVariable
+	}
+
+	// MultivariateVariable is a data point of a group.
+	MultivariateVariable struct {
+		// Name is the value of the multivariate key, or an empty string if
+		// the data point doesn't have this attribute.
+		Name string
+		// DataPoint has no attributes, they are moved to the group.
+		DataPoint pmetric.NumberDataPoint
+	}
+)
+
+// MultivariateMetricsFrom groups the number data points of the gauges and sums
+// of the given metrics by their timestamps and attributes, excluding the
+// multivariate key. The attributes of the data points are moved to their
+// group, so every set of shared attributes is kept only once. The other
+// metric types are ignored.
+func MultivariateMetricsFrom(metrics pmetric.Metrics, multivariateKey string) []MultivariateMetric {
+	var result []MultivariateMetric
+
+	rms := metrics.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		rm := rms.At(i)
+		sms := rm.ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			sm := sms.At(j)
+			ms := sm.Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				metric := ms.At(k)
+				var dps pmetric.NumberDataPointSlice
+				switch metric.Type() {
+				case pmetric.MetricTypeGauge:
+					dps = metric.Gauge().DataPoints()
+				case pmetric.MetricTypeSum:
+					dps = metric.Sum().DataPoints()
+				default:
+					continue
+				}
+				result = append(result, MultivariateMetric{
+					Resource: rm.Resource(),
+					Scope:    sm.Scope(),
+					Metric:   metric,
+					Groups:   GroupNumberDataPoints(dps, multivariateKey),
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// GroupNumberDataPoints groups the given data points by their timestamps and
+// attributes, excluding the multivariate key (see DataPointSig). The groups
+// and their variables are in the order of their first data point. The
+// attributes of the data points are moved to their group.
+func GroupNumberDataPoints(dps pmetric.NumberDataPointSlice, multivariateKey string) []MultivariateGroup {
+	var groups []MultivariateGroup
+	groupBySig := make(map[string]int)
+
+	for i := 0; i < dps.Len(); i++ {
+		dp := dps.At(i)
+		sig := string(DataPointSig[pmetric.NumberDataPoint](dp, multivariateKey))
+
+		idx, found := groupBySig[sig]
+		if !found {
+			attrs := pcommon.NewMap()
+			dp.Attributes().CopyTo(attrs)
+			attrs.Remove(multivariateKey)
+
+			idx = len(groups)
+			groupBySig[sig] = idx
+			groups = append(groups, MultivariateGroup{
+				StartTimestamp: dp.StartTimestamp(),
+				Timestamp:      dp.Timestamp(),
+				Attributes:     attrs,
+			})
+		}
+
+		var name string
+		if v, ok := dp.Attributes().Get(multivariateKey); ok {
+			name = v.AsString()
+		}
+		dp.Attributes().Clear()
+
+		groups[idx].Variables = append(groups[idx].Variables, MultivariateVariable{
+			Name:      name,
+			DataPoint: dp,
+		})
+	}
+
+	return groups
+}
@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestMultivariateMetricsFrom(t *testing.T) {
+	t.Parallel()
+
+	metrics := pmetric.NewMetrics()
+	ms := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	cpu := ms.AppendEmpty()
+	cpu.SetName("system.cpu.time")
+	dps := cpu.SetEmptySum().DataPoints()
+	for _, c := range []string{"cpu0", "cpu1"} {
+		for i, state := range []string{"user", "system"} {
+			dp := dps.AppendEmpty()
+			dp.SetTimestamp(10)
+			dp.Attributes().PutStr("cpu", c)
+			dp.Attributes().PutStr("metric", state)
+			dp.SetDoubleValue(float64(i))
+		}
+	}
+	// Same attributes, different timestamp.
+	dp := dps.AppendEmpty()
+	dp.SetTimestamp(20)
+	dp.Attributes().PutStr("cpu", "cpu0")
+	dp.Attributes().PutStr("metric", "user")
+
+	// Histograms are ignored.
+	ms.AppendEmpty().SetEmptyHistogram()
+
+	mvMetrics := MultivariateMetricsFrom(metrics, "metric")
+	require.Equal(t, 1, len(mvMetrics))
+	require.Equal(t, "system.cpu.time", mvMetrics[0].Metric.Name())
+
+	groups := mvMetrics[0].Groups
+	require.Equal(t, 3, len(groups))
+
+	require.Equal(t, map[string]any{"cpu": "cpu0"}, groups[0].Attributes.AsRaw())
+	require.Equal(t, 2, len(groups[0].Variables))
+	require.Equal(t, "user", groups[0].Variables[0].Name)
+	require.Equal(t, "system", groups[0].Variables[1].Name)
+	require.Equal(t, 1.0, groups[0].Variables[1].DataPoint.DoubleValue())
+	// The attributes are moved to the group.
+	require.Equal(t, 0, groups[0].Variables[0].DataPoint.Attributes().Len())
+
+	require.Equal(t, map[string]any{"cpu": "cpu1"}, groups[1].Attributes.AsRaw())
+	require.Equal(t, 2, len(groups[1].Variables))
+
+	require.Equal(t, map[string]any{"cpu": "cpu0"}, groups[2].Attributes.AsRaw())
+	require.Equal(t, 1, len(groups[2].Variables))
+}
@@ -35,9 +35,11 @@ type (
 		Sum               int
 		Scale             int
 		ZeroCount         int
+		ZeroThreshold     int
 		Positive          *EHistogramDataPointBucketsIds
 		Negative          *EHistogramDataPointBucketsIds
 		Flags             int
+		SeriesID          int
 		Min               int
 		Max               int
 	}
@@ -45,6 +47,9 @@ type (
 	EHistogramDataPointsStore struct {
 		nextID         uint16
 		dataPointsByID map[uint16]pmetric.ExponentialHistogramDataPointSlice
+		// seriesIDs contains the series ID of each data point of
+		// dataPointsByID, it is nil when the record has no series ID column.
+		seriesIDs map[uint16][]uint32
 	}
 )
 
@@ -62,6 +67,13 @@ func (s *EHistogramDataPointsStore) EHistogramMetricsByID(ID uint16) pmetric.Exp
 	return dps
 }
 
+// SeriesIDsByID returns the series IDs of the data points returned by
+// EHistogramMetricsByID for the same ID, or nil if the series IDs are not
+// present in the record.
+func (s *EHistogramDataPointsStore) SeriesIDsByID(ID uint16) []uint32 {
+	return s.seriesIDs[ID]
+}
+
 func SchemaToEHistogramIDs(schema *arrow.Schema) (*EHistogramDataPointIDs, error) {
 	ID, err := arrowutils.FieldIDFromSchema(schema, constants.ID)
 	if err != nil {
@@ -103,6 +115,11 @@ func SchemaToEHistogramIDs(schema *arrow.Schema) (*EHistogramDataPointIDs, error
 		return nil, werror.Wrap(err)
 	}
 
+	zeroThreshold, err := arrowutils.FieldIDFromSchema(schema, constants.ExpHistogramZeroThreshold)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
 	positive, err := NewEHistogramDataPointBucketsIds(schema, constants.ExpHistogramPositive)
 	if err != nil {
 		return nil, werror.Wrap(err)
@@ -118,6 +135,11 @@ func SchemaToEHistogramIDs(schema *arrow.Schema) (*EHistogramDataPointIDs, error
 		return nil, werror.Wrap(err)
 	}
 
+	seriesID, err := arrowutils.FieldIDFromSchema(schema, constants.SeriesID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
 	min, err := arrowutils.FieldIDFromSchema(schema, constants.HistogramMin)
 	if err != nil {
 		return nil, werror.Wrap(err)
@@ -137,9 +159,11 @@ func SchemaToEHistogramIDs(schema *arrow.Schema) (*EHistogramDataPointIDs, error
 		Sum:               sum,
 		Scale:             scale,
 		ZeroCount:         zeroCount,
+		ZeroThreshold:     zeroThreshold,
 		Positive:          positive,
 		Negative:          negative,
 		Flags:             flags,
+		SeriesID:          seriesID,
 		Min:               min,
 		Max:               max,
 	}, nil
@@ -156,6 +180,9 @@ func EHistogramDataPointsStoreFrom(record arrow.Record, exemplarsStore *Exemplar
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	if fieldIDs.SeriesID != arrowutils.AbsentFieldID {
+		store.seriesIDs = make(map[uint16][]uint32)
+	}
 
 	count := int(record.NumRows())
 	prevParentID := uint16(0)
@@ -222,6 +249,12 @@ func EHistogramDataPointsStoreFrom(record arrow.Record, exemplarsStore *Exemplar
 		}
 		hdp.SetZeroCount(zeroCount)
 
+		zeroThreshold, err := arrowutils.F64FromRecord(record, fieldIDs.ZeroThreshold, row)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		hdp.SetZeroThreshold(zeroThreshold)
+
 		positive, err := arrowutils.StructFromRecord(record, fieldIDs.Positive.ID, row)
 		if err != nil {
 			return nil, werror.Wrap(err)
@@ -248,6 +281,14 @@ func EHistogramDataPointsStoreFrom(record arrow.Record, exemplarsStore *Exemplar
 		}
 		hdp.SetFlags(pmetric.DataPointFlags(flags))
 
+		if store.seriesIDs != nil {
+			seriesID, err := arrowutils.U32FromRecord(record, fieldIDs.SeriesID, row)
+			if err != nil {
+				return nil, werror.Wrap(err)
+			}
+			store.seriesIDs[parentID] = append(store.seriesIDs[parentID], seriesID)
+		}
+
 		min, err := arrowutils.F64OrNilFromRecord(record, fieldIDs.Min, row)
 		if err != nil {
 			return nil, werror.Wrap(err)
@@ -37,6 +37,7 @@ type (
 		BucketCounts      int // List of uint64
 		ExplicitBounds    int // List of float64
 		Flags             int
+		SeriesID          int
 		Min               int
 		Max               int
 	}
@@ -44,6 +45,9 @@ type (
 	HistogramDataPointsStore struct {
 		nextID         uint16
 		dataPointsByID map[uint16]pmetric.HistogramDataPointSlice
+		// seriesIDs contains the series ID of each data point of
+		// dataPointsByID, it is nil when the record has no series ID column.
+		seriesIDs map[uint16][]uint32
 	}
 )
 
@@ -61,6 +65,13 @@ func (s *HistogramDataPointsStore) HistogramMetricsByID(ID uint16) pmetric.Histo
 	return dps
 }
 
+// SeriesIDsByID returns the series IDs of the data points returned by
+// HistogramMetricsByID for the same ID, or nil if the series IDs are not
+// present in the record.
+func (s *HistogramDataPointsStore) SeriesIDsByID(ID uint16) []uint32 {
+	return s.seriesIDs[ID]
+}
+
 func SchemaToHistogramIDs(schema *arrow.Schema) (*HistogramDataPointIDs, error) {
 	ID, err := arrowutils.FieldIDFromSchema(schema, constants.ID)
 	if err != nil {
@@ -107,6 +118,11 @@ func SchemaToHistogramIDs(schema *arrow.Schema) (*HistogramDataPointIDs, error)
 		return nil, werror.Wrap(err)
 	}
 
+	seriesID, err := arrowutils.FieldIDFromSchema(schema, constants.SeriesID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
 	min, err := arrowutils.FieldIDFromSchema(schema, constants.HistogramMin)
 	if err != nil {
 		return nil, werror.Wrap(err)
@@ -127,6 +143,7 @@ func SchemaToHistogramIDs(schema *arrow.Schema) (*HistogramDataPointIDs, error)
 		BucketCounts:      bucketCounts,
 		ExplicitBounds:    explicitBounds,
 		Flags:             flags,
+		SeriesID:          seriesID,
 		Min:               min,
 		Max:               max,
 	}, nil
@@ -143,6 +160,9 @@ func HistogramDataPointsStoreFrom(record arrow.Record, exemplarsStore *Exemplars
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	if fieldIDs.SeriesID != arrowutils.AbsentFieldID {
+		store.seriesIDs = make(map[uint16][]uint32)
+	}
 
 	count := int(record.NumRows())
 	prevParentID := uint16(0)
@@ -231,6 +251,14 @@ func HistogramDataPointsStoreFrom(record arrow.Record, exemplarsStore *Exemplars
 		}
 		hdp.SetFlags(pmetric.DataPointFlags(flags))
 
+		if store.seriesIDs != nil {
+			seriesID, err := arrowutils.U32FromRecord(record, fieldIDs.SeriesID, row)
+			if err != nil {
+				return nil, werror.Wrap(err)
+			}
+			store.seriesIDs[parentID] = append(store.seriesIDs[parentID], seriesID)
+		}
+
 		min, err := arrowutils.F64OrNilFromRecord(record, fieldIDs.Min, row)
 		if err != nil {
 			return nil, werror.Wrap(err)
@@ -21,6 +21,7 @@ import (
 	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/enums"
 	"github.com/f5/otel-arrow-adapter/pkg/werror"
 )
 
@@ -40,10 +41,93 @@ type (
 		AggregationTemporality int
 		IsMonotonic            int
 	}
+
+	// DataPointPosition locates a data point in a [pmetric.Metrics] by its
+	// indexes in the resource metrics, scope metrics, metric and data point
+	// slices.
+	DataPointPosition struct {
+		ResourceMetrics int
+		ScopeMetrics    int
+		Metric          int
+		DataPoint       int
+	}
+
+	// SeriesIDs contains the stable series IDs of the data points of a
+	// [pmetric.Metrics] produced with config.WithSeriesID. The series IDs are
+	// not part of the OTLP data model, they are kept aside the decoded
+	// metrics instead of being added to the data point attributes.
+	SeriesIDs struct {
+		byPosition map[DataPointPosition]uint32
+	}
 )
 
+// SeriesID returns the series ID of the data point at the given position and
+// true, or false if the data point has no series ID.
+func (s *SeriesIDs) SeriesID(pos DataPointPosition) (uint32, bool) {
+	seriesID, ok := s.byPosition[pos]
+	return seriesID, ok
+}
+
+// Len returns the number of data points having a series ID.
+func (s *SeriesIDs) Len() int {
+	return len(s.byPosition)
+}
+
+// add records the series IDs of the data points of the metric at the given
+// position, the zero series ID meaning that the data point has none.
+func (s *SeriesIDs) add(pos DataPointPosition, seriesIDs []uint32) {
+	if s == nil {
+		return
+	}
+	for i, seriesID := range seriesIDs {
+		if seriesID == 0 {
+			continue
+		}
+		pos.DataPoint = i
+		s.byPosition[pos] = seriesID
+	}
+}
+
 // MetricsFrom creates a [pmetric.Metrics] from the given Arrow Record.
+// Note: This function consume the record.
 func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics, error) {
+	return metricsFrom(record, relatedData, nil, nil)
+}
+
+// MetricsWithSeriesIDsFrom is like MetricsFrom but also returns the series IDs
+// of the data points when the record has been produced with
+// config.WithSeriesID (see SeriesIDs).
+// Note: This function consume the record.
+func MetricsWithSeriesIDsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics, *SeriesIDs, error) {
+	seriesIDs := &SeriesIDs{byPosition: make(map[DataPointPosition]uint32)}
+	metrics, err := metricsFrom(record, relatedData, nil, seriesIDs)
+	if err != nil {
+		return metrics, nil, err
+	}
+	return metrics, seriesIDs, nil
+}
+
+// MetricsByResourceFrom decodes the given Arrow Record one resource at a time,
+// each [pmetric.Metrics] passed to yield contains the metrics of a single resource.
+// The decoding stops at the first error returned by yield, which is
+// returned as is.
+// Note: This function consume the record.
+func MetricsByResourceFrom(record arrow.Record, relatedData *RelatedData, yield func(pmetric.Metrics) error) error {
+	metrics, err := metricsFrom(record, relatedData, yield, nil)
+	if err != nil {
+		return err
+	}
+	if metrics.ResourceMetrics().Len() == 0 {
+		return nil
+	}
+	return yield(metrics)
+}
+
+// metricsFrom decodes the record. When yield is not nil, the metrics of each
+// resource are passed to yield once complete and the metrics of the last
+// resource are returned. When seriesIDs is not nil, the series IDs of the data
+// points are added to it.
+func metricsFrom(record arrow.Record, relatedData *RelatedData, yield func(pmetric.Metrics) error, seriesIDs *SeriesIDs) (pmetric.Metrics, error) {
 	defer record.Release()
 
 	metrics := pmetric.NewMetrics()
@@ -57,6 +141,12 @@ func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics
 		return metrics, werror.Wrap(err)
 	}
 
+	// IDs are delta-encoded, they are decoded in bulk.
+	IDs, err := arrowutils.DeltaDecodedU16FromRecord(record, metricsIDs.ID)
+	if err != nil {
+		return metrics, werror.Wrap(err)
+	}
+
 	var resMetrics pmetric.ResourceMetrics
 	var scopeMetricsSlice pmetric.ScopeMetricsSlice
 	var metricSlice pmetric.MetricSlice
@@ -64,20 +154,25 @@ func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics
 	resMetricsSlice := metrics.ResourceMetrics()
 	rows := int(record.NumRows())
 
-	prevResID := None
-	prevScopeID := None
+	var resEntries, scopeEntries otlp.EntryTracker
 
 	for row := 0; row < rows; row++ {
 		// Process resource spans, resource, schema url (resource)
-		resID, err := otlp.ResourceIDFromRecord(record, row, metricsIDs.Resource)
+		resID, err := otlp.NullableResourceIDFromRecord(record, row, metricsIDs.Resource)
 		if err != nil {
 			return metrics, werror.Wrap(err)
 		}
-		if prevResID != int(resID) {
-			prevResID = int(resID)
+		if resEntries.IsNew(resID) {
+			if yield != nil && resMetricsSlice.Len() != 0 {
+				if err := yield(metrics); err != nil {
+					return metrics, err
+				}
+				metrics = pmetric.NewMetrics()
+				resMetricsSlice = metrics.ResourceMetrics()
+			}
 			resMetrics = resMetricsSlice.AppendEmpty()
 			scopeMetricsSlice = resMetrics.ScopeMetrics()
-			prevScopeID = None
+			scopeEntries.Reset()
 			schemaUrl, err := otlp.UpdateResourceFromRecord(resMetrics.Resource(), record, row, metricsIDs.Resource, relatedData.ResAttrMapStore)
 			if err != nil {
 				return metrics, werror.Wrap(err)
@@ -86,15 +181,14 @@ func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics
 		}
 
 		// Process scope spans, scope, schema url (scope)
-		scopeID, err := otlp.ScopeIDFromRecord(record, row, metricsIDs.Scope)
+		newScope, err := otlp.IsNewScopeEntry(record, row, metricsIDs.Scope, &scopeEntries)
 		if err != nil {
 			return metrics, werror.Wrap(err)
 		}
-		if prevScopeID != int(scopeID) {
-			prevScopeID = int(scopeID)
+		if newScope {
 			scopeMetrics := scopeMetricsSlice.AppendEmpty()
 			metricSlice = scopeMetrics.Metrics()
-			if err = otlp.UpdateScopeFromRecord(scopeMetrics.Scope(), record, row, metricsIDs.Scope, relatedData.ScopeAttrMapStore); err != nil {
+			if err = otlp.UpdateScopeFromRecord(scopeMetrics.Scope(), record, row, metricsIDs.Scope, relatedData.ScopeAttrMapStore, relatedData.ScopeTable); err != nil {
 				return metrics, werror.Wrap(err)
 			}
 
@@ -107,11 +201,7 @@ func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics
 
 		// Process metric fields
 		metric := metricSlice.AppendEmpty()
-		deltaID, err := arrowutils.U16FromRecord(record, metricsIDs.ID, row)
-		if err != nil {
-			return metrics, werror.Wrap(err)
-		}
-		ID := relatedData.MetricIDFromDelta(deltaID)
+		ID := IDs[row]
 
 		metricType, err := arrowutils.U8FromRecord(record, metricsIDs.MetricType, row)
 		if err != nil {
@@ -136,6 +226,10 @@ func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics
 		}
 		metric.SetUnit(unit)
 
+		if metadata := relatedData.MetricMetadataStore.AttributesByID(ID); metadata != nil {
+			metadata.CopyTo(metric.Metadata())
+		}
+
 		aggregationTemporality, err := arrowutils.I32FromRecord(record, metricsIDs.AggregationTemporality, row)
 		if err != nil {
 			return metrics, werror.Wrap(err)
@@ -146,31 +240,42 @@ func MetricsFrom(record arrow.Record, relatedData *RelatedData) (pmetric.Metrics
 			return metrics, werror.Wrap(err)
 		}
 
-		switch pmetric.MetricType(metricType) {
-		case pmetric.MetricTypeGauge:
+		pos := DataPointPosition{
+			ResourceMetrics: resMetricsSlice.Len() - 1,
+			ScopeMetrics:    scopeMetricsSlice.Len() - 1,
+			Metric:          metricSlice.Len() - 1,
+		}
+
+		switch enums.MetricType(metricType) {
+		case enums.MetricTypeGauge:
 			dps := relatedData.NumberDataPointsStore.NumberDataPointsByID(ID)
 			gauge := metric.SetEmptyGauge()
 			dps.MoveAndAppendTo(gauge.DataPoints())
-		case pmetric.MetricTypeSum:
+			seriesIDs.add(pos, relatedData.NumberDataPointsStore.SeriesIDsByID(ID))
+		case enums.MetricTypeSum:
 			dps := relatedData.NumberDataPointsStore.NumberDataPointsByID(ID)
 			sum := metric.SetEmptySum()
 			sum.SetAggregationTemporality(pmetric.AggregationTemporality(aggregationTemporality))
 			sum.SetIsMonotonic(isMonotonic)
 			dps.MoveAndAppendTo(sum.DataPoints())
-		case pmetric.MetricTypeSummary:
+			seriesIDs.add(pos, relatedData.NumberDataPointsStore.SeriesIDsByID(ID))
+		case enums.MetricTypeSummary:
 			dps := relatedData.SummaryDataPointsStore.SummaryMetricsByID(ID)
 			summary := metric.SetEmptySummary()
 			dps.MoveAndAppendTo(summary.DataPoints())
-		case pmetric.MetricTypeHistogram:
+			seriesIDs.add(pos, relatedData.SummaryDataPointsStore.SeriesIDsByID(ID))
+		case enums.MetricTypeHistogram:
 			dps := relatedData.HistogramDataPointsStore.HistogramMetricsByID(ID)
 			histogram := metric.SetEmptyHistogram()
 			histogram.SetAggregationTemporality(pmetric.AggregationTemporality(aggregationTemporality))
 			dps.MoveAndAppendTo(histogram.DataPoints())
-		case pmetric.MetricTypeExponentialHistogram:
+			seriesIDs.add(pos, relatedData.HistogramDataPointsStore.SeriesIDsByID(ID))
+		case enums.MetricTypeExponentialHistogram:
 			dps := relatedData.EHistogramDataPointsStore.EHistogramMetricsByID(ID)
 			expHistogram := metric.SetEmptyExponentialHistogram()
 			expHistogram.SetAggregationTemporality(pmetric.AggregationTemporality(aggregationTemporality))
 			dps.MoveAndAppendTo(expHistogram.DataPoints())
+			seriesIDs.add(pos, relatedData.EHistogramDataPointsStore.SeriesIDsByID(ID))
 		default:
 			// Todo log unknown metric type
 		}
@@ -34,11 +34,15 @@ type (
 		IntValue          int
 		DoubleValue       int
 		Flags             int
+		SeriesID          int
 	}
 
 	NumberDataPointsStore struct {
 		nextID         uint16
 		dataPointsByID map[uint16]pmetric.NumberDataPointSlice
+		// seriesIDs contains the series ID of each data point of
+		// dataPointsByID, it is nil when the record has no series ID column.
+		seriesIDs map[uint16][]uint32
 	}
 )
 
@@ -56,6 +60,13 @@ func (s *NumberDataPointsStore) NumberDataPointsByID(ID uint16) pmetric.NumberDa
 	return nbps
 }
 
+// SeriesIDsByID returns the series IDs of the data points returned by
+// NumberDataPointsByID for the same ID, or nil if the series IDs are not
+// present in the record.
+func (s *NumberDataPointsStore) SeriesIDsByID(ID uint16) []uint32 {
+	return s.seriesIDs[ID]
+}
+
 func SchemaToNDPIDs(schema *arrow.Schema) (*NumberDataPointIDs, error) {
 	ID, err := arrowutils.FieldIDFromSchema(schema, constants.ID)
 	if err != nil {
@@ -92,6 +103,11 @@ func SchemaToNDPIDs(schema *arrow.Schema) (*NumberDataPointIDs, error) {
 		return nil, werror.Wrap(err)
 	}
 
+	seriesID, err := arrowutils.FieldIDFromSchema(schema, constants.SeriesID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
 	return &NumberDataPointIDs{
 		ID:                ID,
 		ParentID:          parentID,
@@ -100,6 +116,7 @@ func SchemaToNDPIDs(schema *arrow.Schema) (*NumberDataPointIDs, error) {
 		IntValue:          intValue,
 		DoubleValue:       doubleValue,
 		Flags:             flags,
+		SeriesID:          seriesID,
 	}, nil
 }
 
@@ -114,6 +131,9 @@ func NumberDataPointsStoreFrom(record arrow.Record, exemplarsStore *ExemplarsSto
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	if fieldIDs.SeriesID != arrowutils.AbsentFieldID {
+		store.seriesIDs = make(map[uint16][]uint32)
+	}
 
 	count := int(record.NumRows())
 	prevParentID := uint16(0)
@@ -176,6 +196,14 @@ func NumberDataPointsStoreFrom(record arrow.Record, exemplarsStore *ExemplarsSto
 		}
 		ndp.SetFlags(pmetric.DataPointFlags(flags))
 
+		if store.seriesIDs != nil {
+			seriesID, err := arrowutils.U32FromRecord(record, fieldIDs.SeriesID, row)
+			if err != nil {
+				return nil, werror.Wrap(err)
+			}
+			store.seriesIDs[parentID] = append(store.seriesIDs[parentID], seriesID)
+		}
+
 		if ID != nil {
 			lastID += *ID
 
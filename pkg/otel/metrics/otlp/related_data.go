@@ -27,11 +27,13 @@ import (
 
 type (
 	RelatedData struct {
+		// Deprecated: see MetricIDFromDelta.
 		MetricID uint16
 
 		// Attributes stores
 		ResAttrMapStore                *otlp.Attributes16Store
 		ScopeAttrMapStore              *otlp.Attributes16Store
+		MetricMetadataStore            *otlp.Attributes16Store
 		NumberDPAttrsStore             *otlp.Attributes32Store
 		SummaryAttrsStore              *otlp.Attributes32Store
 		HistogramAttrsStore            *otlp.Attributes32Store
@@ -40,6 +42,9 @@ type (
 		HistogramExemplarAttrsStore    *otlp.Attributes32Store
 		ExpHistogramExemplarAttrsStore *otlp.Attributes32Store
 
+		// Scope table (see config.WithScopeTable)
+		ScopeTable *otlp.ScopeTable
+
 		// Metric stores
 		NumberDataPointsStore     *NumberDataPointsStore
 		SummaryDataPointsStore    *SummaryDataPointsStore
@@ -57,6 +62,7 @@ func NewRelatedData() *RelatedData {
 	return &RelatedData{
 		ResAttrMapStore:                otlp.NewAttributes16Store(),
 		ScopeAttrMapStore:              otlp.NewAttributes16Store(),
+		MetricMetadataStore:            otlp.NewAttributes16Store(),
 		NumberDPAttrsStore:             otlp.NewAttributes32Store(),
 		SummaryAttrsStore:              otlp.NewAttributes32Store(),
 		HistogramAttrsStore:            otlp.NewAttributes32Store(),
@@ -76,12 +82,16 @@ func NewRelatedData() *RelatedData {
 	}
 }
 
+// MetricIDFromDelta accumulates a delta-encoded ID into MetricID.
+//
+// Deprecated: MetricsFrom decodes the IDs in bulk with
+// arrowutils.DeltaDecodedU16FromRecord and no longer calls this method.
 func (r *RelatedData) MetricIDFromDelta(delta uint16) uint16 {
 	r.MetricID += delta
 	return r.MetricID
 }
 
-func RelatedDataFrom(records []*record_message.RecordMessage) (relatedData *RelatedData, metricsRecord *record_message.RecordMessage, err error) {
+func RelatedDataFrom(records []*record_message.RecordMessage, concurrency int, attrsCache *otlp.AttributesCache) (relatedData *RelatedData, metricsRecord *record_message.RecordMessage, err error) {
 	defer func() {
 		for _, record := range records {
 			record.Record().Release()
@@ -95,41 +105,48 @@ func RelatedDataFrom(records []*record_message.RecordMessage) (relatedData *Rela
 	var numberDBExRec *record_message.RecordMessage
 	var histogramDBExRec *record_message.RecordMessage
 	var expHistogramDBExRec *record_message.RecordMessage
+	var scopeTableRec *record_message.RecordMessage
+
+	var attrsTasks otlp.DecodeTasks
 
 	relatedData = NewRelatedData()
 
 	for _, record := range records {
+		record := record
 		switch record.PayloadType() {
 		case colarspb.ArrowPayloadType_RESOURCE_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.ResAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return attrsCache.Attributes16StoreFrom(record.CacheKey(), record.Record(), relatedData.ResAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_SCOPE_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.ScopeAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
+			attrsTasks = append(attrsTasks, func() error {
+				return attrsCache.Attributes16StoreFrom(record.CacheKey(), record.Record(), relatedData.ScopeAttrMapStore)
+			})
+		case colarspb.ArrowPayloadType_SCOPES:
+			if scopeTableRec != nil {
+				return nil, nil, werror.Wrap(otel.ErrDuplicatePayloadType)
 			}
+			scopeTableRec = record
+		case colarspb.ArrowPayloadType_METRIC_METADATA:
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes16StoreFrom(record.Record(), relatedData.MetricMetadataStore)
+			})
 		case colarspb.ArrowPayloadType_NUMBER_DP_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.NumberDPAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.NumberDPAttrsStore)
+			})
 		case colarspb.ArrowPayloadType_SUMMARY_DP_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.SummaryAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.SummaryAttrsStore)
+			})
 		case colarspb.ArrowPayloadType_HISTOGRAM_DP_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.HistogramAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.HistogramAttrsStore)
+			})
 		case colarspb.ArrowPayloadType_EXP_HISTOGRAM_DP_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.ExpHistogramAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.ExpHistogramAttrsStore)
+			})
 		case colarspb.ArrowPayloadType_NUMBER_DATA_POINTS:
 			if numberDPRec != nil {
 				return nil, nil, werror.Wrap(otel.ErrDuplicatePayloadType)
@@ -171,98 +188,119 @@ func RelatedDataFrom(records []*record_message.RecordMessage) (relatedData *Rela
 			}
 			expHistogramDBExRec = record
 		case colarspb.ArrowPayloadType_NUMBER_DP_EXEMPLAR_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.NumberDPExemplarAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.NumberDPExemplarAttrsStore)
+			})
 		case colarspb.ArrowPayloadType_HISTOGRAM_DP_EXEMPLAR_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.HistogramExemplarAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.HistogramExemplarAttrsStore)
+			})
 		case colarspb.ArrowPayloadType_EXP_HISTOGRAM_DP_EXEMPLAR_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.ExpHistogramExemplarAttrsStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.ExpHistogramExemplarAttrsStore)
+			})
 		default:
 			return nil, nil, werror.Wrap(otel.UnknownPayloadType)
 		}
 	}
 
-	// Process exemplar records
+	if err = attrsTasks.Run(concurrency); err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+
+	// Process exemplar records (and the scope table)
+	var exemplarsTasks otlp.DecodeTasks
+	if scopeTableRec != nil {
+		exemplarsTasks = append(exemplarsTasks, func() (err error) {
+			relatedData.ScopeTable, err = otlp.ScopeTableFrom(
+				scopeTableRec.Record(),
+				relatedData.ScopeAttrMapStore,
+			)
+			return err
+		})
+	}
+
 	if numberDBExRec != nil {
-		relatedData.NumberDataPointExemplarsStore, err = ExemplarsStoreFrom(
-			numberDBExRec.Record(),
-			relatedData.NumberDPExemplarAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		exemplarsTasks = append(exemplarsTasks, func() (err error) {
+			relatedData.NumberDataPointExemplarsStore, err = ExemplarsStoreFrom(
+				numberDBExRec.Record(),
+				relatedData.NumberDPExemplarAttrsStore,
+			)
+			return err
+		})
 	}
 
 	if histogramDBExRec != nil {
-		relatedData.HistogramDataPointExemplarsStore, err = ExemplarsStoreFrom(
-			histogramDBExRec.Record(),
-			relatedData.HistogramExemplarAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		exemplarsTasks = append(exemplarsTasks, func() (err error) {
+			relatedData.HistogramDataPointExemplarsStore, err = ExemplarsStoreFrom(
+				histogramDBExRec.Record(),
+				relatedData.HistogramExemplarAttrsStore,
+			)
+			return err
+		})
 	}
 
 	if expHistogramDBExRec != nil {
-		relatedData.EHistogramDataPointExemplarsStore, err = ExemplarsStoreFrom(
-			expHistogramDBExRec.Record(),
-			relatedData.ExpHistogramExemplarAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		exemplarsTasks = append(exemplarsTasks, func() (err error) {
+			relatedData.EHistogramDataPointExemplarsStore, err = ExemplarsStoreFrom(
+				expHistogramDBExRec.Record(),
+				relatedData.ExpHistogramExemplarAttrsStore,
+			)
+			return err
+		})
+	}
+
+	if err = exemplarsTasks.Run(concurrency); err != nil {
+		return nil, nil, werror.Wrap(err)
 	}
 
 	// Process data point records
+	var dataPointsTasks otlp.DecodeTasks
 	if numberDPRec != nil {
-		relatedData.NumberDataPointsStore, err = NumberDataPointsStoreFrom(
-			numberDPRec.Record(),
-			relatedData.NumberDataPointExemplarsStore,
-			relatedData.NumberDPAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		dataPointsTasks = append(dataPointsTasks, func() (err error) {
+			relatedData.NumberDataPointsStore, err = NumberDataPointsStoreFrom(
+				numberDPRec.Record(),
+				relatedData.NumberDataPointExemplarsStore,
+				relatedData.NumberDPAttrsStore,
+			)
+			return err
+		})
 	}
 
 	if summaryDPRec != nil {
-		relatedData.SummaryDataPointsStore, err = SummaryDataPointsStoreFrom(
-			summaryDPRec.Record(),
-			relatedData.SummaryAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		dataPointsTasks = append(dataPointsTasks, func() (err error) {
+			relatedData.SummaryDataPointsStore, err = SummaryDataPointsStoreFrom(
+				summaryDPRec.Record(),
+				relatedData.SummaryAttrsStore,
+			)
+			return err
+		})
 	}
 
 	if histogramDPRec != nil {
-		relatedData.HistogramDataPointsStore, err = HistogramDataPointsStoreFrom(
-			histogramDPRec.Record(),
-			relatedData.HistogramDataPointExemplarsStore,
-			relatedData.HistogramAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		dataPointsTasks = append(dataPointsTasks, func() (err error) {
+			relatedData.HistogramDataPointsStore, err = HistogramDataPointsStoreFrom(
+				histogramDPRec.Record(),
+				relatedData.HistogramDataPointExemplarsStore,
+				relatedData.HistogramAttrsStore,
+			)
+			return err
+		})
 	}
 
 	if expHistogramDPRec != nil {
-		relatedData.EHistogramDataPointsStore, err = EHistogramDataPointsStoreFrom(
-			expHistogramDPRec.Record(),
-			relatedData.EHistogramDataPointExemplarsStore,
-			relatedData.ExpHistogramAttrsStore,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		dataPointsTasks = append(dataPointsTasks, func() (err error) {
+			relatedData.EHistogramDataPointsStore, err = EHistogramDataPointsStoreFrom(
+				expHistogramDPRec.Record(),
+				relatedData.EHistogramDataPointExemplarsStore,
+				relatedData.ExpHistogramAttrsStore,
+			)
+			return err
+		})
+	}
+
+	if err = dataPointsTasks.Run(concurrency); err != nil {
+		return nil, nil, werror.Wrap(err)
 	}
 
 	return
@@ -35,11 +35,15 @@ type (
 		Sum               int
 		QuantileValues    *QuantileValueIds
 		Flags             int
+		SeriesID          int
 	}
 
 	SummaryDataPointsStore struct {
 		nextID         uint16
 		dataPointsByID map[uint16]pmetric.SummaryDataPointSlice
+		// seriesIDs contains the series ID of each data point of
+		// dataPointsByID, it is nil when the record has no series ID column.
+		seriesIDs map[uint16][]uint32
 	}
 )
 
@@ -57,6 +61,13 @@ func (s *SummaryDataPointsStore) SummaryMetricsByID(ID uint16) pmetric.SummaryDa
 	return nbdps
 }
 
+// SeriesIDsByID returns the series IDs of the data points returned by
+// SummaryMetricsByID for the same ID, or nil if the series IDs are not
+// present in the record.
+func (s *SummaryDataPointsStore) SeriesIDsByID(ID uint16) []uint32 {
+	return s.seriesIDs[ID]
+}
+
 func SchemaToSummaryIDs(schema *arrow.Schema) (*SummaryDataPointIDs, error) {
 	ID, err := arrowutils.FieldIDFromSchema(schema, constants.ID)
 	if err != nil {
@@ -98,6 +109,11 @@ func SchemaToSummaryIDs(schema *arrow.Schema) (*SummaryDataPointIDs, error) {
 		return nil, werror.Wrap(err)
 	}
 
+	seriesID, err := arrowutils.FieldIDFromSchema(schema, constants.SeriesID)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
 	return &SummaryDataPointIDs{
 		ID:                ID,
 		ParentID:          parentID,
@@ -107,6 +123,7 @@ func SchemaToSummaryIDs(schema *arrow.Schema) (*SummaryDataPointIDs, error) {
 		Sum:               sum,
 		QuantileValues:    quantileValues,
 		Flags:             flags,
+		SeriesID:          seriesID,
 	}, nil
 }
 
@@ -121,6 +138,9 @@ func SummaryDataPointsStoreFrom(record arrow.Record, attrsStore *otlp.Attributes
 	if err != nil {
 		return nil, werror.Wrap(err)
 	}
+	if fieldIDs.SeriesID != arrowutils.AbsentFieldID {
+		store.seriesIDs = make(map[uint16][]uint32)
+	}
 
 	count := int(record.NumRows())
 	prevParentID := uint16(0)
@@ -183,6 +203,14 @@ func SummaryDataPointsStoreFrom(record arrow.Record, attrsStore *otlp.Attributes
 		}
 		sdp.SetFlags(pmetric.DataPointFlags(flags))
 
+		if store.seriesIDs != nil {
+			seriesID, err := arrowutils.U32FromRecord(record, fieldIDs.SeriesID, row)
+			if err != nil {
+				return nil, werror.Wrap(err)
+			}
+			store.seriesIDs[parentID] = append(store.seriesIDs[parentID], seriesID)
+		}
+
 		if ID != nil {
 			attrs := attrsStore.AttributesByDeltaID(*ID)
 			if attrs != nil {
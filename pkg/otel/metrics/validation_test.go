@@ -156,7 +156,7 @@ func CheckEncodeDecode(t *testing.T, expectedRequest pmetricotlp.ExportRequest)
 		require.Error(t, schema.ErrSchemaNotUpToDate)
 	}
 
-	relatedData, _, err := otlp.RelatedDataFrom(relatedRecords)
+	relatedData, _, err := otlp.RelatedDataFrom(relatedRecords, 1, nil)
 	require.NoError(t, err)
 
 	// Convert the Arrow records back to OTLP.
@@ -218,7 +218,7 @@ func OneRoundOfMessUpArrowRecords(t *testing.T, expectedRequest pmetricotlp.Expo
 	// Mix up the Arrow records in such a way as to make decoding impossible.
 	mainRecordChanged, record, relatedRecords := common.MixUpArrowRecords(rng, record, relatedRecords)
 
-	relatedData, _, err := otlp.RelatedDataFrom(relatedRecords)
+	relatedData, _, err := otlp.RelatedDataFrom(relatedRecords, 1, nil)
 
 	// Convert the Arrow records back to OTLP.
 	_, err = otlp.MetricsFrom(record, relatedData)
@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package projection maps the rows of OTAP records (e.g. spans, data points)
+// to user-defined Go structs, bypassing pdata. This is intended for analytics
+// tools that only need a few columns of a payload.
+//
+// The mapping is defined with `otap` struct tags naming the column to read.
+// Columns nested in a struct column are addressed with a dotted path and the
+// `delta` option decodes delta-encoded columns (e.g. IDs):
+//
+//	type Span struct {
+//		ID        uint16    `otap:"id,delta"`
+//		Name      string    `otap:"name"`
+//		Start     time.Time `otap:"start_time_unix_nano"`
+//		Service   string    `otap:"resource.schema_url"`
+//		Duration  int64     `otap:"duration_time_unix_nano"`
+//	}
+//
+//	p, err := projection.New[Span]()
+//	spans, err := p.Rows(record)
+//
+// FromMessages can be used directly on the record messages returned by
+// `Consumer.Consume` to project all the payloads of a given type.
+//
+// All and AllFromMessages return iterators projecting the rows one at a time,
+// compatible with the range-over-func loops of Go 1.23. The attributes of the
+// rows are resolved on demand with Related, which only decodes the
+// attributes payloads that are looked up:
+//
+//	related := projection.NewRelated(messages)
+//	for span, err := range p.AllFromMessages(messages, v1.ArrowPayloadType_SPANS) {
+//		attrs, err := related.Attributes(v1.ArrowPayloadType_SPAN_ATTRS, uint32(span.ID))
+//		...
+//	}
+//
+// Columns absent from a record (e.g. optional columns) and null values are
+// projected as zero values.
+package projection
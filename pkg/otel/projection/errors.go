@@ -0,0 +1,34 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projection
+
+import "errors"
+
+var (
+	// ErrNotStruct is returned when a projection is defined on a type that is
+	// not a struct.
+	ErrNotStruct = errors.New("projection type must be a struct")
+
+	// ErrUnsupportedFieldType is returned when a tagged struct field has a Go
+	// type that can't be projected.
+	ErrUnsupportedFieldType = errors.New("unsupported projection field type")
+
+	// ErrIncompatibleColumn is returned when a column can't be converted to
+	// the type of the struct field it is mapped to.
+	ErrIncompatibleColumn = errors.New("column incompatible with projection field")
+
+	// ErrInvalidTag is returned when an `otap` tag is malformed.
+	ErrInvalidTag = errors.New("invalid otap tag")
+)
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projection
+
+import (
+	"reflect"
+
+	"github.com/apache/arrow/go/v12/arrow"
+
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Seq2 is an iterator over pairs of values. It has the same definition as
+// iter.Seq2 in Go 1.23, so the iterators of this package can be used in
+// range-over-func loops:
+//
+//	for span, err := range p.All(record) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// With earlier versions of Go, the iterator is called with the body of the
+// loop, which returns false to stop the iteration.
+type Seq2[K, V any] func(yield func(K, V) bool)
+
+// All returns an iterator over the projected rows of the given record. The
+// rows are projected one at a time, as they are consumed. The iteration
+// stops after the first error, which is yielded with a zero value.
+func (p *Projection[T]) All(record arrow.Record) Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		p.yieldRows(record, yield)
+	}
+}
+
+// AllFromMessages returns an iterator over the projected rows of all the
+// record messages of the given payload type (e.g. the output of
+// `Consumer.Consume`), in order. Other messages are ignored.
+func (p *Projection[T]) AllFromMessages(messages []*record_message.RecordMessage, payloadType record_message.PayloadType) Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, msg := range messages {
+			if msg.PayloadType() != payloadType {
+				continue
+			}
+			if !p.yieldRows(msg.Record(), func(row T, err error) bool {
+				if err != nil {
+					err = werror.WrapWithContext(err, map[string]interface{}{"schema_id": msg.SchemaID()})
+				}
+				return yield(row, err)
+			}) {
+				return
+			}
+		}
+	}
+}
+
+// yieldRows projects the rows of the record and passes them to yield. It
+// returns false if the iteration has been stopped by yield or by an error.
+func (p *Projection[T]) yieldRows(record arrow.Record, yield func(T, error) bool) bool {
+	c := p.newCursor(record)
+	rows := int(record.NumRows())
+	for row := 0; row < rows; row++ {
+		var value T
+		if err := c.project(reflect.ValueOf(&value).Elem(), row); err != nil {
+			var zero T
+			yield(zero, err)
+			return false
+		}
+		if !yield(value, nil) {
+			return false
+		}
+	}
+	return true
+}
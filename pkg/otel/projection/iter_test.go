@@ -0,0 +1,137 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projection
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Uint16},
+		{Name: "name", Type: arrow.BinaryTypes.String},
+	}, nil)
+	rb := array.NewRecordBuilder(pool, schema)
+	defer rb.Release()
+	rb.Field(0).(*array.Uint16Builder).AppendValues([]uint16{1, 1, 2}, nil)
+	rb.Field(1).(*array.StringBuilder).AppendValues([]string{"a", "b", "c"}, nil)
+	record := rb.NewRecord()
+	defer record.Release()
+
+	p, err := New[span]()
+	require.NoError(t, err)
+
+	var spans []span
+	p.All(record)(func(s span, err error) bool {
+		require.NoError(t, err)
+		spans = append(spans, s)
+		return true
+	})
+	require.Equal(t, []span{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 4, Name: "c"}}, spans)
+
+	// The iteration stops when yield returns false.
+	count := 0
+	p.All(record)(func(s span, err error) bool {
+		count++
+		return false
+	})
+	require.Equal(t, 1, count)
+
+	// The iteration stops after an error.
+	incompatible, err := New[struct {
+		Name bool `otap:"name"`
+	}]()
+	require.NoError(t, err)
+	count = 0
+	incompatible.All(record)(func(_ struct {
+		Name bool `otap:"name"`
+	}, err error) bool {
+		count++
+		require.True(t, errors.Is(err, ErrIncompatibleColumn))
+		return true
+	})
+	require.Equal(t, 1, count)
+}
+
+type tracedSpan struct {
+	ID   uint16 `otap:"id,delta"`
+	Name string `otap:"name"`
+}
+
+func TestAllFromMessagesWithRelated(t *testing.T) {
+	t.Parallel()
+
+	traces := ptrace.NewTraces()
+	spans := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	for i := 0; i < 5; i++ {
+		s := spans.AppendEmpty()
+		s.SetName(fmt.Sprintf("span-%d", i))
+		s.Attributes().PutInt("index", int64(i))
+	}
+
+	producer := arrow_record.NewProducerWithOptions(config.WithNoZstd())
+	defer func() { require.NoError(t, producer.Close()) }()
+	consumer := arrow_record.NewConsumer()
+	defer func() { require.NoError(t, consumer.Close()) }()
+
+	bar, err := producer.BatchArrowRecordsFromTraces(traces)
+	require.NoError(t, err)
+	messages, err := consumer.Consume(bar)
+	require.NoError(t, err)
+	defer func() {
+		for _, msg := range messages {
+			msg.Record().Release()
+		}
+	}()
+
+	p, err := New[tracedSpan]()
+	require.NoError(t, err)
+	related := NewRelated(messages)
+
+	indexByName := make(map[string]int64)
+	p.AllFromMessages(messages, colarspb.ArrowPayloadType_SPANS)(func(s tracedSpan, err error) bool {
+		require.NoError(t, err)
+		attrs, err := related.Attributes(colarspb.ArrowPayloadType_SPAN_ATTRS, uint32(s.ID))
+		require.NoError(t, err)
+		index, ok := attrs.Get("index")
+		require.True(t, ok)
+		indexByName[s.Name] = index.Int()
+		return true
+	})
+	require.Equal(t, map[string]int64{
+		"span-0": 0,
+		"span-1": 1,
+		"span-2": 2,
+		"span-3": 3,
+		"span-4": 4,
+	}, indexByName)
+}
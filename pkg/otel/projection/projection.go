@@ -0,0 +1,409 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projection
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// TagName is the struct tag used to map a struct field to a column.
+const TagName = "otap"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+type (
+	// Projection maps the rows of a record to values of type T.
+	Projection[T any] struct {
+		fields []field
+	}
+
+	// field describes the mapping between a struct field and a column.
+	field struct {
+		name  string
+		index []int
+		path  []string
+		delta bool
+		typ   reflect.Type
+	}
+
+	scalarKind int
+
+	// scalar is a value extracted from an Arrow array.
+	scalar struct {
+		kind scalarKind
+		i    int64
+		u    uint64
+		f    float64
+		b    []byte
+		s    string
+		t    bool
+	}
+)
+
+const (
+	kindInt scalarKind = iota
+	kindUint
+	kindFloat
+	kindString
+	kindBytes
+	kindBool
+	kindTimestamp
+	kindDuration
+)
+
+// New creates a projection for the struct type T. Only the fields with an
+// `otap` tag are projected, untagged fields are left untouched.
+func New[T any]() (*Projection[T], error) {
+	var zero T
+	t := reflect.TypeOf(zero)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, werror.Wrap(ErrNotStruct)
+	}
+
+	p := &Projection[T]{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup(TagName)
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, werror.WrapWithContext(ErrInvalidTag, map[string]interface{}{"field": sf.Name, "reason": "unexported field"})
+		}
+
+		parts := strings.Split(tag, ",")
+		if parts[0] == "" {
+			return nil, werror.WrapWithContext(ErrInvalidTag, map[string]interface{}{"field": sf.Name, "tag": tag})
+		}
+		f := field{
+			name:  sf.Name,
+			index: sf.Index,
+			path:  strings.Split(parts[0], "."),
+			typ:   sf.Type,
+		}
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "delta":
+				f.delta = true
+			default:
+				return nil, werror.WrapWithContext(ErrInvalidTag, map[string]interface{}{"field": sf.Name, "option": opt})
+			}
+		}
+		if !supported(f.typ) {
+			return nil, werror.WrapWithContext(ErrUnsupportedFieldType, map[string]interface{}{"field": sf.Name, "type": f.typ.String()})
+		}
+		if f.delta && !isInteger(f.typ.Kind()) {
+			return nil, werror.WrapWithContext(ErrInvalidTag, map[string]interface{}{"field": sf.Name, "reason": "delta requires an integer field"})
+		}
+		p.fields = append(p.fields, f)
+	}
+	return p, nil
+}
+
+// Rows projects all the rows of the given record.
+func (p *Projection[T]) Rows(record arrow.Record) ([]T, error) {
+	return p.AppendRows(make([]T, 0, record.NumRows()), record)
+}
+
+// AppendRows projects all the rows of the given record and appends them to
+// dst.
+func (p *Projection[T]) AppendRows(dst []T, record arrow.Record) ([]T, error) {
+	c := p.newCursor(record)
+	rows := int(record.NumRows())
+	for row := 0; row < rows; row++ {
+		var zero T
+		dst = append(dst, zero)
+		if err := c.project(reflect.ValueOf(&dst[len(dst)-1]).Elem(), row); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// FromMessages projects the rows of all the record messages of the given
+// payload type (e.g. the output of `Consumer.Consume`). Other messages are
+// ignored.
+func (p *Projection[T]) FromMessages(messages []*record_message.RecordMessage, payloadType record_message.PayloadType) ([]T, error) {
+	var rows []T
+	for _, msg := range messages {
+		if msg.PayloadType() != payloadType {
+			continue
+		}
+		var err error
+		rows, err = p.AppendRows(rows, msg.Record())
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"schema_id": msg.SchemaID()})
+		}
+	}
+	return rows, nil
+}
+
+// cursor projects the rows of a record in order. The delta-encoded columns
+// are accumulated from the first row.
+type cursor struct {
+	columns []column
+}
+
+// column is a field of the projection and the array it is read from.
+type column struct {
+	field   *field
+	array   arrow.Array
+	parents []arrow.Array
+	acc     int64
+	uacc    uint64
+}
+
+func (p *Projection[T]) newCursor(record arrow.Record) *cursor {
+	c := &cursor{columns: make([]column, 0, len(p.fields))}
+	for i := range p.fields {
+		arr, parents := resolveColumn(record, p.fields[i].path)
+		if arr == nil {
+			// Absent (optional) column.
+			continue
+		}
+		c.columns = append(c.columns, column{field: &p.fields[i], array: arr, parents: parents})
+	}
+	return c
+}
+
+// project sets the fields of dst to the values of the given row. The rows
+// must be projected in order.
+func (c *cursor) project(dst reflect.Value, row int) error {
+	for i := range c.columns {
+		col := &c.columns[i]
+		f := col.field
+		if isNullAt(col.parents, row) || col.array.IsNull(row) {
+			continue
+		}
+		s, err := scalarAt(col.array, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"field": f.name, "row": row})
+		}
+		if f.delta {
+			switch s.kind {
+			case kindInt:
+				col.acc += s.i
+				s.i = col.acc
+			case kindUint:
+				col.uacc += s.u
+				s.u = col.uacc
+			}
+		}
+		if err := assign(dst.FieldByIndex(f.index), s); err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"field": f.name, "column": strings.Join(f.path, ".")})
+		}
+	}
+	return nil
+}
+
+// resolveColumn returns the array designated by the given path as well as
+// the struct arrays traversed to reach it (used to detect null parents).
+func resolveColumn(record arrow.Record, path []string) (arrow.Array, []arrow.Array) {
+	indices := record.Schema().FieldIndices(path[0])
+	if len(indices) != 1 {
+		return nil, nil
+	}
+	column := record.Column(indices[0])
+
+	var parents []arrow.Array
+	for _, name := range path[1:] {
+		structArr, ok := column.(*array.Struct)
+		if !ok {
+			return nil, nil
+		}
+		idx, found := structArr.DataType().(*arrow.StructType).FieldIdx(name)
+		if !found {
+			return nil, nil
+		}
+		parents = append(parents, structArr)
+		column = structArr.Field(idx)
+	}
+	return column, parents
+}
+
+func isNullAt(arrays []arrow.Array, row int) bool {
+	for _, arr := range arrays {
+		if arr.IsNull(row) {
+			return true
+		}
+	}
+	return false
+}
+
+// scalarAt extracts the value of a non-null row, resolving dictionaries.
+func scalarAt(arr arrow.Array, row int) (scalar, error) {
+	switch arr := arr.(type) {
+	case *array.Dictionary:
+		return scalarAt(arr.Dictionary(), arr.GetValueIndex(row))
+	case *array.Int8:
+		return scalar{kind: kindInt, i: int64(arr.Value(row))}, nil
+	case *array.Int16:
+		return scalar{kind: kindInt, i: int64(arr.Value(row))}, nil
+	case *array.Int32:
+		return scalar{kind: kindInt, i: int64(arr.Value(row))}, nil
+	case *array.Int64:
+		return scalar{kind: kindInt, i: arr.Value(row)}, nil
+	case *array.Uint8:
+		return scalar{kind: kindUint, u: uint64(arr.Value(row))}, nil
+	case *array.Uint16:
+		return scalar{kind: kindUint, u: uint64(arr.Value(row))}, nil
+	case *array.Uint32:
+		return scalar{kind: kindUint, u: uint64(arr.Value(row))}, nil
+	case *array.Uint64:
+		return scalar{kind: kindUint, u: arr.Value(row)}, nil
+	case *array.Float32:
+		return scalar{kind: kindFloat, f: float64(arr.Value(row))}, nil
+	case *array.Float64:
+		return scalar{kind: kindFloat, f: arr.Value(row)}, nil
+	case *array.String:
+		return scalar{kind: kindString, s: arr.Value(row)}, nil
+	case *array.LargeString:
+		return scalar{kind: kindString, s: arr.Value(row)}, nil
+	case *array.Binary:
+		return scalar{kind: kindBytes, b: arr.Value(row)}, nil
+	case *array.LargeBinary:
+		return scalar{kind: kindBytes, b: arr.Value(row)}, nil
+	case *array.FixedSizeBinary:
+		return scalar{kind: kindBytes, b: arr.Value(row)}, nil
+	case *array.Boolean:
+		return scalar{kind: kindBool, t: arr.Value(row)}, nil
+	case *array.Timestamp:
+		return scalar{kind: kindTimestamp, i: int64(arr.Value(row))}, nil
+	case *array.Duration:
+		return scalar{kind: kindDuration, i: int64(arr.Value(row))}, nil
+	default:
+		return scalar{}, werror.WrapWithContext(ErrIncompatibleColumn, map[string]interface{}{"array-type": arr.DataType().Name()})
+	}
+}
+
+// assign converts and stores a scalar into a struct field.
+func assign(v reflect.Value, s scalar) error {
+	switch {
+	case v.Type() == timeType:
+		if s.kind != kindTimestamp && s.kind != kindInt && s.kind != kindUint {
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+		ns := s.i
+		if s.kind == kindUint {
+			ns = int64(s.u)
+		}
+		v.Set(reflect.ValueOf(time.Unix(0, ns).UTC()))
+		return nil
+	case v.Type() == durationType:
+		if s.kind == kindUint {
+			v.SetInt(int64(s.u))
+			return nil
+		}
+		if s.kind != kindDuration && s.kind != kindInt && s.kind != kindTimestamp {
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+		v.SetInt(s.i)
+		return nil
+	case v.Type() == bytesType:
+		switch s.kind {
+		case kindBytes:
+			// Copy the bytes, the record buffers are released by the caller.
+			v.SetBytes(append([]byte(nil), s.b...))
+		case kindString:
+			v.SetBytes([]byte(s.s))
+		default:
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch s.kind {
+		case kindInt, kindTimestamp, kindDuration:
+			v.SetInt(s.i)
+		case kindUint:
+			v.SetInt(int64(s.u))
+		default:
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch s.kind {
+		case kindUint:
+			v.SetUint(s.u)
+		case kindInt, kindTimestamp, kindDuration:
+			v.SetUint(uint64(s.i))
+		default:
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+	case reflect.Float32, reflect.Float64:
+		switch s.kind {
+		case kindFloat:
+			v.SetFloat(s.f)
+		case kindInt:
+			v.SetFloat(float64(s.i))
+		case kindUint:
+			v.SetFloat(float64(s.u))
+		default:
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+	case reflect.String:
+		switch s.kind {
+		case kindString:
+			v.SetString(s.s)
+		case kindBytes:
+			v.SetString(string(s.b))
+		default:
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+	case reflect.Bool:
+		if s.kind != kindBool {
+			return werror.Wrap(ErrIncompatibleColumn)
+		}
+		v.SetBool(s.t)
+	default:
+		return werror.Wrap(ErrUnsupportedFieldType)
+	}
+	return nil
+}
+
+func supported(t reflect.Type) bool {
+	if t == timeType || t == durationType || t == bytesType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return true
+	default:
+		return isInteger(t.Kind())
+	}
+}
+
+func isInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
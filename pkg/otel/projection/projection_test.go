@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projection
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type span struct {
+	ID        uint16    `otap:"id,delta"`
+	Name      string    `otap:"name"`
+	Start     time.Time `otap:"start_time_unix_nano"`
+	SchemaURL string    `otap:"resource.schema_url"`
+	Dropped   uint32    `otap:"resource.dropped_attributes_count"`
+	Missing   string    `otap:"missing"`
+	Ignored   string
+}
+
+func TestRows(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Uint16},
+		{Name: "name", Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Uint8, ValueType: arrow.BinaryTypes.String}},
+		{Name: "start_time_unix_nano", Type: arrow.FixedWidthTypes.Timestamp_ns},
+		{Name: "resource", Type: arrow.StructOf(
+			arrow.Field{Name: "schema_url", Type: arrow.BinaryTypes.String},
+			arrow.Field{Name: "dropped_attributes_count", Type: arrow.PrimitiveTypes.Uint32},
+		), Nullable: true},
+	}, nil)
+
+	rb := array.NewRecordBuilder(pool, schema)
+	defer rb.Release()
+
+	ids := rb.Field(0).(*array.Uint16Builder)
+	names := rb.Field(1).(*array.BinaryDictionaryBuilder)
+	starts := rb.Field(2).(*array.TimestampBuilder)
+	resources := rb.Field(3).(*array.StructBuilder)
+	schemaURLs := resources.FieldBuilder(0).(*array.StringBuilder)
+	dropped := resources.FieldBuilder(1).(*array.Uint32Builder)
+
+	// Delta-encoded IDs: 1, 2, 4
+	ids.AppendValues([]uint16{1, 1, 2}, nil)
+	require.NoError(t, names.AppendString("GET"))
+	require.NoError(t, names.AppendString("POST"))
+	names.AppendNull()
+	starts.AppendValues([]arrow.Timestamp{1000, 2000, 3000}, nil)
+	resources.Append(true)
+	schemaURLs.Append("https://a")
+	dropped.Append(3)
+	resources.AppendNull()
+	schemaURLs.AppendNull()
+	dropped.AppendNull()
+	resources.Append(true)
+	schemaURLs.Append("https://b")
+	dropped.Append(0)
+
+	record := rb.NewRecord()
+	defer record.Release()
+
+	p, err := New[span]()
+	require.NoError(t, err)
+
+	spans, err := p.Rows(record)
+	require.NoError(t, err)
+	assert.Equal(t, []span{
+		{ID: 1, Name: "GET", Start: time.Unix(0, 1000).UTC(), SchemaURL: "https://a", Dropped: 3},
+		{ID: 2, Name: "POST", Start: time.Unix(0, 2000).UTC()},
+		{ID: 4, Start: time.Unix(0, 3000).UTC(), SchemaURL: "https://b"},
+	}, spans)
+}
+
+func TestIncompatibleColumn(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	schema := arrow.NewSchema([]arrow.Field{{Name: "name", Type: arrow.FixedWidthTypes.Boolean}}, nil)
+	rb := array.NewRecordBuilder(pool, schema)
+	defer rb.Release()
+	rb.Field(0).(*array.BooleanBuilder).Append(true)
+	record := rb.NewRecord()
+	defer record.Release()
+
+	p, err := New[span]()
+	require.NoError(t, err)
+	_, err = p.Rows(record)
+	require.True(t, errors.Is(err, ErrIncompatibleColumn))
+}
+
+func TestInvalidDefinitions(t *testing.T) {
+	t.Parallel()
+
+	_, err := New[int]()
+	require.True(t, errors.Is(err, ErrNotStruct))
+
+	_, err = New[struct {
+		Name string `otap:"name,delta"`
+	}]()
+	require.True(t, errors.Is(err, ErrInvalidTag))
+
+	_, err = New[struct {
+		Attrs map[string]string `otap:"attributes"`
+	}]()
+	require.True(t, errors.Is(err, ErrUnsupportedFieldType))
+}
@@ -0,0 +1,125 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package projection
+
+import (
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Related resolves the attributes of the rows of a batch on demand. An
+// attributes payload (e.g. SPAN_ATTRS) is only decoded the first time the
+// attributes of one of its parents are requested, so the payloads that are
+// never looked up cost nothing.
+type Related struct {
+	messages map[record_message.PayloadType]*record_message.RecordMessage
+	stores16 map[record_message.PayloadType]*otlp.Attributes16Store
+	stores32 map[record_message.PayloadType]*otlp.Attributes32Store
+}
+
+// NewRelated creates a Related for the record messages of a batch (e.g. the
+// output of `Consumer.Consume`).
+// Note: The records are not consumed, they must outlive the Related.
+func NewRelated(messages []*record_message.RecordMessage) *Related {
+	r := &Related{
+		messages: make(map[record_message.PayloadType]*record_message.RecordMessage),
+		stores16: make(map[record_message.PayloadType]*otlp.Attributes16Store),
+		stores32: make(map[record_message.PayloadType]*otlp.Attributes32Store),
+	}
+	for _, msg := range messages {
+		r.messages[msg.PayloadType()] = msg
+	}
+	return r
+}
+
+// Attributes returns the attributes of the given parent in the attributes
+// payload of the given type, e.g. the SPAN_ATTRS of a span `id` or the
+// RESOURCE_ATTRS of a `resource.id`. The parent IDs are the decoded IDs,
+// i.e. the delta-encoded IDs must be projected with the `delta` option. An
+// empty map is returned when the parent has no attributes.
+func (r *Related) Attributes(payloadType record_message.PayloadType, parentID uint32) (pcommon.Map, error) {
+	if is16BitParentID(payloadType) {
+		store, err := r.store16(payloadType)
+		if err != nil {
+			return pcommon.Map{}, werror.Wrap(err)
+		}
+		if m := store.AttributesByID(uint16(parentID)); m != nil {
+			return *m, nil
+		}
+		return pcommon.NewMap(), nil
+	}
+
+	store, err := r.store32(payloadType)
+	if err != nil {
+		return pcommon.Map{}, werror.Wrap(err)
+	}
+	if m := store.AttributesByID(parentID); m != nil {
+		return *m, nil
+	}
+	return pcommon.NewMap(), nil
+}
+
+func (r *Related) store16(payloadType record_message.PayloadType) (*otlp.Attributes16Store, error) {
+	if store, ok := r.stores16[payloadType]; ok {
+		return store, nil
+	}
+	store := otlp.NewAttributes16Store()
+	if msg, ok := r.messages[payloadType]; ok {
+		// The store consumes the record, which is retained for its
+		// owner.
+		msg.Record().Retain()
+		if err := otlp.Attributes16StoreFrom(msg.Record(), store); err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"payload_type": payloadType.String()})
+		}
+	}
+	r.stores16[payloadType] = store
+	return store, nil
+}
+
+func (r *Related) store32(payloadType record_message.PayloadType) (*otlp.Attributes32Store, error) {
+	if store, ok := r.stores32[payloadType]; ok {
+		return store, nil
+	}
+	store := otlp.NewAttributes32Store()
+	if msg, ok := r.messages[payloadType]; ok {
+		// The store consumes the record, which is retained for its
+		// owner.
+		msg.Record().Retain()
+		if err := otlp.Attributes32StoreFrom(msg.Record(), store); err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"payload_type": payloadType.String()})
+		}
+	}
+	r.stores32[payloadType] = store
+	return store, nil
+}
+
+// is16BitParentID returns true for the attributes payloads whose parent IDs
+// are 16-bit wide, the other attributes have 32-bit parent IDs.
+func is16BitParentID(payloadType record_message.PayloadType) bool {
+	switch payloadType {
+	case colarspb.ArrowPayloadType_RESOURCE_ATTRS,
+		colarspb.ArrowPayloadType_SCOPE_ATTRS,
+		colarspb.ArrowPayloadType_SPAN_ATTRS,
+		colarspb.ArrowPayloadType_LOG_ATTRS,
+		colarspb.ArrowPayloadType_METRIC_METADATA:
+		return true
+	default:
+		return false
+	}
+}
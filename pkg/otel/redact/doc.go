@@ -0,0 +1,26 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package redact redacts the Arrow records of decoded OTLP Arrow batches
+// before their conversion into the OTLP representation: the values of the
+// attributes whose key is not allowed, and the parts of the attribute values,
+// log bodies, and span status messages matching blocked patterns.
+//
+// Telemetry strings are highly repetitive, which is why they are encoded as
+// dictionaries: the blocked patterns are applied to the values of the
+// dictionaries, not to the rows referencing them.
+package redact
@@ -0,0 +1,934 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common"
+	carrow "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/constants"
+	larrow "github.com/f5/otel-arrow-adapter/pkg/otel/logs/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+const (
+	// DefaultMask is the default replacement of the redacted values.
+	DefaultMask = "****"
+	// DefaultDictionarySize is the default number of distinct values whose
+	// redaction is memoized by a Dictionary.
+	DefaultDictionarySize = 1 << 16
+)
+
+// ErrInvalidDictionarySize is returned for a dictionary size lower than 1.
+var ErrInvalidDictionarySize = errors.New("invalid dictionary size")
+
+type (
+	// Config selects the redaction applied by a Redactor.
+	Config struct {
+		// AllowAllKeys disables the AllowedKeys check.
+		AllowAllKeys bool
+		// AllowedKeys lists the attribute keys whose values are kept when
+		// AllowAllKeys is false, the values of the other attributes are
+		// replaced by Mask. The flattened attributes are checked with the
+		// key of their top-level attribute.
+		AllowedKeys []string
+		// BlockedValues lists regular expressions, the matching parts of
+		// the attribute values, log bodies, and span status messages are
+		// replaced by Mask.
+		BlockedValues []string
+		// Mask is the replacement of the redacted values.
+		Mask string
+		// DictionarySize is the number of distinct values whose redaction
+		// is memoized by a Dictionary, which is reset when full.
+		DictionarySize int
+	}
+
+	// Redactor redacts the records of decoded OTLP Arrow batches. It is
+	// immutable and shared by the consumers of a receiver, each of them
+	// memoizing the redacted values of its streams in its own Dictionary.
+	Redactor struct {
+		allowAllKeys   bool
+		allowedKeys    map[string]struct{}
+		blockedValues  []*regexp.Regexp
+		mask           string
+		dictionarySize int
+		pool           memory.Allocator
+	}
+
+	// Dictionary memoizes the redaction of the string values. It is not
+	// safe for concurrent use, a consumer redacts one batch at a time.
+	Dictionary struct {
+		size   int
+		values map[string]string
+	}
+
+	// pass is a single call to Redact.
+	pass struct {
+		*Redactor
+		dict *Dictionary
+	}
+
+	// replacement is the redacted data of a column or of a struct child,
+	// appended when its index is past the existing ones.
+	replacement struct {
+		name string
+		data arrow.ArrayData
+	}
+)
+
+// DefaultConfig returns a configuration redacting nothing, the allowed keys
+// or the blocked values are to be set.
+func DefaultConfig() *Config {
+	return &Config{
+		AllowAllKeys:   true,
+		Mask:           DefaultMask,
+		DictionarySize: DefaultDictionarySize,
+	}
+}
+
+// NewRedactor creates a Redactor applying the given configuration.
+func NewRedactor(cfg *Config) (*Redactor, error) {
+	if cfg.DictionarySize < 1 {
+		return nil, werror.WrapWithContext(ErrInvalidDictionarySize, map[string]interface{}{"dictionary_size": cfg.DictionarySize})
+	}
+
+	r := &Redactor{
+		allowAllKeys:   cfg.AllowAllKeys,
+		allowedKeys:    make(map[string]struct{}, len(cfg.AllowedKeys)),
+		mask:           cfg.Mask,
+		dictionarySize: cfg.DictionarySize,
+		pool:           memory.NewGoAllocator(),
+	}
+	for _, key := range cfg.AllowedKeys {
+		r.allowedKeys[key] = struct{}{}
+	}
+	for _, expr := range cfg.BlockedValues {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"blocked_value": expr})
+		}
+		r.blockedValues = append(r.blockedValues, re)
+	}
+	return r, nil
+}
+
+// NewDictionary creates an empty Dictionary for a consumer.
+func (r *Redactor) NewDictionary() *Dictionary {
+	return &Dictionary{
+		size:   r.dictionarySize,
+		values: make(map[string]string),
+	}
+}
+
+// Redact redacts the records of a decoded OTLP Arrow batch, as returned by
+// the Consume method of the consumer, with the memoized redactions of dict.
+// The records containing values to redact are released and replaced in their
+// RecordMessage.
+func (r *Redactor) Redact(records []*record_message.RecordMessage, dict *Dictionary) error {
+	p := pass{Redactor: r, dict: dict}
+
+	for _, rm := range records {
+		rec, err := p.record(rm.Record())
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"payload_type": rm.PayloadType().String()})
+		}
+		if rec != nil {
+			rm.Record().Release()
+			rm.SetRecord(rec)
+		}
+	}
+	return nil
+}
+
+// redact replaces the parts of a value matching the blocked values with the
+// mask.
+func (r *Redactor) redact(value string) string {
+	for _, re := range r.blockedValues {
+		value = re.ReplaceAllLiteralString(value, r.mask)
+	}
+	return value
+}
+
+// allowed returns true if the values of the attributes with the given key
+// are kept.
+func (r *Redactor) allowed(key string) bool {
+	_, ok := r.allowedKeys[key]
+	return ok
+}
+
+// memoized returns the redaction of a value, memoized in the dictionary.
+func (p *pass) memoized(value string) string {
+	if redacted, ok := p.dict.values[value]; ok {
+		return redacted
+	}
+	redacted := p.redact(value)
+
+	// The values may reference the buffers of the records, which are
+	// released with the batch.
+	key := strings.Clone(value)
+	if redacted == value {
+		redacted = key
+	}
+	if len(p.dict.values) >= p.dict.size {
+		p.dict.values = make(map[string]string)
+	}
+	p.dict.values[key] = redacted
+	return redacted
+}
+
+// record returns a redacted copy of the record, or nil if there is nothing to
+// redact.
+func (p *pass) record(rec arrow.Record) (arrow.Record, error) {
+	schema := rec.Schema()
+	if isAttributes(schema) {
+		return p.attributes(rec)
+	}
+
+	cols := make(map[int]replacement)
+	defer releaseAll(cols)
+
+	if id, _ := arrowutils.FieldIDFromSchema(schema, constants.Body); id != arrowutils.AbsentFieldID {
+		data, err := p.body(rec.Column(id).Data())
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if data != nil {
+			cols[id] = replacement{name: constants.Body, data: data}
+		}
+	}
+	if id, _ := arrowutils.FieldIDFromSchema(schema, constants.Status); id != arrowutils.AbsentFieldID {
+		data, err := structChild(rec.Column(id).Data(), constants.StatusMessage, p.strings)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if data != nil {
+			cols[id] = replacement{name: constants.Status, data: data}
+		}
+	}
+	return replaceColumns(rec, cols), nil
+}
+
+// attributes redacts an attribute record. The blocked values are redacted in
+// the "str" and "ser" columns, or in their dictionaries, and the values of
+// the attributes whose key is not allowed are replaced by the mask.
+func (p *pass) attributes(rec arrow.Record) (arrow.Record, error) {
+	ids, err := otlp.SchemaToAttributeIDs(rec.Schema())
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	cols := make(map[int]replacement)
+	defer releaseAll(cols)
+
+	if err := column(rec, ids.Str, p.strings, cols); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if err := column(rec, ids.Ser, p.serialized, cols); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if !p.allowAllKeys {
+		if err := p.maskKeys(rec, ids, cols); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	out := replaceColumns(rec, cols)
+	if out == nil {
+		return nil, nil
+	}
+	parentIDs, err := p.parentIDs(rec, out, ids.ParentID)
+	if err != nil {
+		out.Release()
+		return nil, werror.Wrap(err)
+	}
+	if parentIDs == nil {
+		return out, nil
+	}
+	defer parentIDs.Release()
+
+	reencoded := replaceColumns(out, map[int]replacement{ids.ParentID: {name: constants.ParentID, data: parentIDs}})
+	out.Release()
+	return reencoded, nil
+}
+
+// maskKeys replaces the values of the attributes whose key is not allowed
+// with the mask, as string values. The "str" and "type" columns are rebuilt,
+// the "str" column being added if absent.
+func (p *pass) maskKeys(rec arrow.Record, ids *otlp.AttributeIDs, cols map[int]replacement) error {
+	allowed, err := p.keyFilter(rec.Column(ids.Key))
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	rows := int(rec.NumRows())
+	var masked []bool
+	for row := 0; row < rows; row++ {
+		flattened, err := arrowutils.BoolFromRecord(rec, ids.Flattened, row)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		if allowed(row, flattened) {
+			continue
+		}
+		if masked == nil {
+			masked = make([]bool, rows)
+		}
+		masked[row] = true
+	}
+	if masked == nil {
+		return nil
+	}
+
+	str := ids.Str
+	var values arrow.Array
+	switch r, ok := cols[str]; {
+	case str == arrowutils.AbsentFieldID:
+		str = int(rec.NumCols())
+	case ok:
+		values = array.MakeFromData(r.data)
+		defer values.Release()
+	default:
+		values = rec.Column(str)
+	}
+
+	sb := array.NewStringBuilder(p.pool)
+	defer sb.Release()
+	sb.Reserve(rows)
+	tb := array.NewUint8Builder(p.pool)
+	defer tb.Release()
+	tb.Reserve(rows)
+
+	for row := 0; row < rows; row++ {
+		if masked[row] {
+			sb.Append(p.mask)
+			tb.Append(uint8(pcommon.ValueTypeStr))
+			continue
+		}
+		if err := appendString(sb, values, row); err != nil {
+			return werror.Wrap(err)
+		}
+		vType, err := arrowutils.U8FromRecord(rec, ids.Type, row)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		tb.Append(vType)
+	}
+
+	if r, ok := cols[str]; ok {
+		r.data.Release()
+	}
+	cols[str] = replacement{name: constants.AttributeStr, data: ownedData(sb.NewArray())}
+	cols[ids.Type] = replacement{name: constants.AttributeType, data: ownedData(tb.NewArray())}
+	return nil
+}
+
+// keyFilter returns a function reporting whether the key of a row is
+// allowed, the values of a key dictionary being checked once.
+func (p *pass) keyFilter(keys arrow.Array) (func(row int, flattened bool) bool, error) {
+	dict, ok := keys.(*array.Dictionary)
+	if !ok {
+		return func(row int, flattened bool) bool {
+			key, _ := arrowutils.StringFromArray(keys, row)
+			if flattened {
+				key = carrow.FlattenedRootKey(key)
+			}
+			return p.allowed(key)
+		}, nil
+	}
+
+	values, ok := dict.Dictionary().(*array.String)
+	if !ok {
+		return nil, werror.WrapWithContext(arrowutils.ErrInvalidArrayType, map[string]interface{}{"field": constants.AttributeKey})
+	}
+	allowed := make([]bool, values.Len())
+	allowedRoot := make([]bool, values.Len())
+	for i := range allowed {
+		allowed[i] = p.allowed(values.Value(i))
+		allowedRoot[i] = p.allowed(carrow.FlattenedRootKey(values.Value(i)))
+	}
+	return func(row int, flattened bool) bool {
+		if dict.IsNull(row) {
+			return p.allowed("")
+		}
+		if flattened {
+			return allowedRoot[dict.GetValueIndex(row)]
+		}
+		return allowed[dict.GetValueIndex(row)]
+	}, nil
+}
+
+// parentIDs returns the parent IDs of the redacted attribute record out,
+// re-encoded, or nil if they are unchanged. The parent IDs are delta encoded
+// within the runs of attributes with the same key and value (see
+// otlp.AttrsParentIdDecoder), which the redaction may merge or split.
+func (p *pass) parentIDs(rec, out arrow.Record, id int) (arrow.ArrayData, error) {
+	before, err := sameAsPrevious(rec)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	after, err := sameAsPrevious(out)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	switch rec.Column(id).DataType().ID() {
+	case arrow.UINT16:
+		raw, err := arrowutils.U16ValuesFromRecord(rec, id)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if parentIDs := reencode(raw, before, after); parentIDs != nil {
+			b := array.NewUint16Builder(p.pool)
+			defer b.Release()
+			b.AppendValues(parentIDs, nil)
+			return ownedData(b.NewArray()), nil
+		}
+	case arrow.UINT32:
+		raw, err := arrowutils.U32ValuesFromRecord(rec, id)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if parentIDs := reencode(raw, before, after); parentIDs != nil {
+			b := array.NewUint32Builder(p.pool)
+			defer b.Release()
+			b.AppendValues(parentIDs, nil)
+			return ownedData(b.NewArray()), nil
+		}
+	}
+	return nil, nil
+}
+
+// reencode returns the parent IDs delta encoded after the rows marked in
+// after instead of the rows marked in before, or nil if they are unchanged.
+func reencode[T otlp.ParentID](raw []T, before, after []bool) []T {
+	var out []T
+	var prev T
+
+	for i, v := range raw {
+		parentID := v
+		if before[i] {
+			parentID = prev + v
+		}
+		encoded := parentID
+		if after[i] {
+			encoded = parentID - prev
+		}
+		if encoded != v && out == nil {
+			out = append([]T(nil), raw...)
+		}
+		if out != nil {
+			out[i] = encoded
+		}
+		prev = parentID
+	}
+	return out
+}
+
+// sameAsPrevious marks the rows of an attribute record having the key and
+// value of the previous row, as compared by the decoder (see carrow.Equal).
+func sameAsPrevious(rec arrow.Record) ([]bool, error) {
+	ids, err := otlp.SchemaToAttributeIDs(rec.Schema())
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	same := make([]bool, rec.NumRows())
+	for row := 1; row < len(same); row++ {
+		same[row], err = sameAttribute(rec, ids, row-1, row)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+	return same, nil
+}
+
+// sameAttribute returns true if two rows of an attribute record have the same
+// key and the same scalar value.
+func sameAttribute(rec arrow.Record, ids *otlp.AttributeIDs, a, b int) (bool, error) {
+	keyA, err := arrowutils.StringFromRecord(rec, ids.Key, a)
+	if err != nil {
+		return false, werror.Wrap(err)
+	}
+	keyB, err := arrowutils.StringFromRecord(rec, ids.Key, b)
+	if err != nil {
+		return false, werror.Wrap(err)
+	}
+	typeA, err := arrowutils.U8FromRecord(rec, ids.Type, a)
+	if err != nil {
+		return false, werror.Wrap(err)
+	}
+	typeB, err := arrowutils.U8FromRecord(rec, ids.Type, b)
+	if err != nil {
+		return false, werror.Wrap(err)
+	}
+	if keyA != keyB || typeA != typeB {
+		return false, nil
+	}
+
+	switch pcommon.ValueType(typeA) {
+	case pcommon.ValueTypeStr:
+		return same(rec, ids.Str, a, b, arrowutils.StringFromRecord, func(x, y string) bool { return x == y })
+	case pcommon.ValueTypeInt:
+		return same(rec, ids.Int, a, b, arrowutils.I64FromRecord, func(x, y int64) bool { return x == y })
+	case pcommon.ValueTypeDouble:
+		return same(rec, ids.Double, a, b, arrowutils.F64FromRecord, func(x, y float64) bool { return x == y })
+	case pcommon.ValueTypeBool:
+		return same(rec, ids.Bool, a, b, arrowutils.BoolFromRecord, func(x, y bool) bool { return x == y })
+	case pcommon.ValueTypeBytes:
+		return same(rec, ids.Bytes, a, b, arrowutils.BinaryFromRecord, bytes.Equal)
+	}
+	return false, nil
+}
+
+// same compares the values of two rows of a column.
+func same[T any](rec arrow.Record, id, a, b int, get func(arrow.Record, int, int) (T, error), equal func(T, T) bool) (bool, error) {
+	x, err := get(rec, id, a)
+	if err != nil {
+		return false, werror.Wrap(err)
+	}
+	y, err := get(rec, id, b)
+	if err != nil {
+		return false, werror.Wrap(err)
+	}
+	return equal(x, y), nil
+}
+
+// body redacts the string, serialized, and templated log bodies.
+func (p *pass) body(data arrow.ArrayData) (arrow.ArrayData, error) {
+	dt, ok := data.DataType().(*arrow.StructType)
+	if !ok || len(p.blockedValues) == 0 {
+		return nil, nil
+	}
+
+	children := make(map[int]replacement)
+	defer releaseAll(children)
+
+	str := arrowutils.OptionalFieldIDFromStruct(dt, constants.BodyStr)
+	if err := child(data, str, p.strings, children); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	ser := arrowutils.OptionalFieldIDFromStruct(dt, constants.BodySer)
+	if err := child(data, ser, p.serialized, children); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if tmpl := arrowutils.OptionalFieldIDFromStruct(dt, constants.BodyTemplate); tmpl != arrowutils.AbsentFieldID {
+		if err := p.templates(data, str, tmpl, children); err != nil {
+			return nil, werror.Wrap(err)
+		}
+	}
+
+	if len(children) == 0 {
+		return nil, nil
+	}
+	return withChildren(data, children), nil
+}
+
+// templates replaces the templated log bodies whose expansion is redacted
+// with their redacted expansion, as plain string bodies. The "str" and "tmpl"
+// children are rebuilt, the "str" child being added if absent.
+func (p *pass) templates(data arrow.ArrayData, str, tmpl int, children map[int]replacement) error {
+	body := array.NewStructData(data)
+	defer body.Release()
+
+	vars := arrowutils.OptionalFieldIDFromStruct(body.DataType().(*arrow.StructType), constants.BodyTemplateVars)
+	var expansions map[int]string
+
+	for row := 0; row < body.Len(); row++ {
+		if body.IsNull(row) {
+			continue
+		}
+		template, err := arrowutils.StringFromArray(body.Field(tmpl), row)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		if template == "" {
+			continue
+		}
+		var cborVars []byte
+		if vars != arrowutils.AbsentFieldID {
+			if cborVars, err = arrowutils.BinaryFromArray(body.Field(vars), row); err != nil {
+				return werror.Wrap(err)
+			}
+		}
+		// The invalid templates are left to the decoder.
+		expanded, ok := expandTemplate(template, cborVars)
+		if !ok {
+			continue
+		}
+		// The expansions are mostly distinct, they are not memoized.
+		if redacted := p.redact(expanded); redacted != expanded {
+			if expansions == nil {
+				expansions = make(map[int]string)
+			}
+			expansions[row] = redacted
+		}
+	}
+	if expansions == nil {
+		return nil
+	}
+
+	var values arrow.Array
+	switch r, ok := children[str]; {
+	case str == arrowutils.AbsentFieldID:
+		str = len(data.Children())
+	case ok:
+		values = array.MakeFromData(r.data)
+		defer values.Release()
+	default:
+		values = body.Field(str)
+	}
+
+	sb := array.NewStringBuilder(p.pool)
+	defer sb.Release()
+	sb.Reserve(body.Len())
+	tb := array.NewStringBuilder(p.pool)
+	defer tb.Release()
+	tb.Reserve(body.Len())
+
+	for row := 0; row < body.Len(); row++ {
+		if redacted, ok := expansions[row]; ok {
+			sb.Append(redacted)
+			tb.AppendNull()
+			continue
+		}
+		if err := appendString(sb, values, row); err != nil {
+			return werror.Wrap(err)
+		}
+		if err := appendString(tb, body.Field(tmpl), row); err != nil {
+			return werror.Wrap(err)
+		}
+	}
+
+	if r, ok := children[str]; ok {
+		r.data.Release()
+	}
+	children[str] = replacement{name: constants.BodyStr, data: ownedData(sb.NewArray())}
+	children[tmpl] = replacement{name: constants.BodyTemplate, data: ownedData(tb.NewArray())}
+	return nil
+}
+
+// expandTemplate expands a log body template with its CBOR encoded
+// variables, as the decoder does.
+func expandTemplate(template string, cborVars []byte) (string, bool) {
+	var vars []string
+	if cborVars != nil {
+		value := pcommon.NewValueEmpty()
+		if err := common.Deserialize(cborVars, value); err != nil || value.Type() != pcommon.ValueTypeSlice {
+			return "", false
+		}
+		slice := value.Slice()
+		vars = make([]string, slice.Len())
+		for i := range vars {
+			vars[i] = slice.At(i).Str()
+		}
+	}
+	return larrow.ExpandTemplate(template, vars)
+}
+
+// strings returns the redacted copy of a string array, or nil if none of its
+// values is redacted.
+func (p *pass) strings(data arrow.ArrayData) (arrow.ArrayData, error) {
+	if _, ok := data.DataType().(*arrow.StringType); !ok || len(p.blockedValues) == 0 {
+		return nil, nil
+	}
+	arr := array.NewStringData(data)
+	defer arr.Release()
+
+	first := -1
+	for i := 0; i < arr.Len(); i++ {
+		if arr.IsValid(i) && p.memoized(arr.Value(i)) != arr.Value(i) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nil, nil
+	}
+
+	b := array.NewStringBuilder(p.pool)
+	defer b.Release()
+	b.Reserve(arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		switch {
+		case arr.IsNull(i):
+			b.AppendNull()
+		case i < first:
+			b.Append(arr.Value(i))
+		default:
+			b.Append(p.memoized(arr.Value(i)))
+		}
+	}
+	return ownedData(b.NewArray()), nil
+}
+
+// serialized returns the redacted copy of an array of CBOR encoded maps and
+// slices, or nil if none of its values is redacted.
+func (p *pass) serialized(data arrow.ArrayData) (arrow.ArrayData, error) {
+	if _, ok := data.DataType().(*arrow.BinaryType); !ok || len(p.blockedValues) == 0 {
+		return nil, nil
+	}
+	arr := array.NewBinaryData(data)
+	defer arr.Release()
+
+	var redacted map[int][]byte
+	for i := 0; i < arr.Len(); i++ {
+		if arr.IsNull(i) {
+			continue
+		}
+		value := pcommon.NewValueEmpty()
+		if err := common.Deserialize(arr.Value(i), value); err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if !p.value(value) {
+			continue
+		}
+		ser, err := common.Serialize(&value)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
+		if redacted == nil {
+			redacted = make(map[int][]byte)
+		}
+		redacted[i] = ser
+	}
+	if redacted == nil {
+		return nil, nil
+	}
+
+	b := array.NewBinaryBuilder(p.pool, arrow.BinaryTypes.Binary)
+	defer b.Release()
+	b.Reserve(arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		if arr.IsNull(i) {
+			b.AppendNull()
+			continue
+		}
+		if ser, ok := redacted[i]; ok {
+			b.Append(ser)
+			continue
+		}
+		b.Append(arr.Value(i))
+	}
+	return ownedData(b.NewArray()), nil
+}
+
+// value redacts the strings of a map or slice value, returning true if any
+// is redacted.
+func (p *pass) value(value pcommon.Value) bool {
+	redacted := false
+	switch value.Type() {
+	case pcommon.ValueTypeStr:
+		if v := p.memoized(value.Str()); v != value.Str() {
+			value.SetStr(v)
+			redacted = true
+		}
+	case pcommon.ValueTypeMap:
+		value.Map().Range(func(_ string, v pcommon.Value) bool {
+			redacted = p.value(v) || redacted
+			return true
+		})
+	case pcommon.ValueTypeSlice:
+		for i := 0; i < value.Slice().Len(); i++ {
+			redacted = p.value(value.Slice().At(i)) || redacted
+		}
+	}
+	return redacted
+}
+
+// isAttributes returns true for the schemas of the attribute records.
+func isAttributes(schema *arrow.Schema) bool {
+	for _, name := range []string{constants.ParentID, constants.AttributeKey, constants.AttributeType} {
+		if len(schema.FieldIndices(name)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// redactValues applies redact to the values of an array, or to its dictionary,
+// returning nil if none is redacted.
+func redactValues(data arrow.ArrayData, redact func(arrow.ArrayData) (arrow.ArrayData, error)) (arrow.ArrayData, error) {
+	dt, ok := data.DataType().(*arrow.DictionaryType)
+	if !ok {
+		return redact(data)
+	}
+	dict := data.Dictionary()
+	if dict == nil {
+		return nil, nil
+	}
+	redacted, err := redact(dict)
+	if err != nil || redacted == nil {
+		return nil, err
+	}
+	out := array.NewDataWithDictionary(dt, data.Len(), data.Buffers(), data.NullN(), data.Offset(), redacted.(*array.Data))
+	redacted.Release()
+	return out, nil
+}
+
+// column adds the redacted values of a record column to cols, if any.
+func column(rec arrow.Record, id int, redact func(arrow.ArrayData) (arrow.ArrayData, error), cols map[int]replacement) error {
+	if id == arrowutils.AbsentFieldID {
+		return nil
+	}
+	data, err := redactValues(rec.Column(id).Data(), redact)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	if data != nil {
+		cols[id] = replacement{name: rec.ColumnName(id), data: data}
+	}
+	return nil
+}
+
+// child adds the redacted values of a struct child to children, if any.
+func child(data arrow.ArrayData, id int, redact func(arrow.ArrayData) (arrow.ArrayData, error), children map[int]replacement) error {
+	if id == arrowutils.AbsentFieldID {
+		return nil
+	}
+	redacted, err := redactValues(data.Children()[id], redact)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	if redacted != nil {
+		children[id] = replacement{name: data.DataType().(*arrow.StructType).Field(id).Name, data: redacted}
+	}
+	return nil
+}
+
+// structChild applies redact to the values of the named child of a struct
+// array, returning nil if none is redacted.
+func structChild(data arrow.ArrayData, name string, redact func(arrow.ArrayData) (arrow.ArrayData, error)) (arrow.ArrayData, error) {
+	dt, ok := data.DataType().(*arrow.StructType)
+	if !ok {
+		return nil, nil
+	}
+
+	children := make(map[int]replacement)
+	defer releaseAll(children)
+
+	if err := child(data, arrowutils.OptionalFieldIDFromStruct(dt, name), redact, children); err != nil {
+		return nil, werror.Wrap(err)
+	}
+	if len(children) == 0 {
+		return nil, nil
+	}
+	return withChildren(data, children), nil
+}
+
+// replaceColumns returns a copy of a record with the given columns replaced,
+// or appended, along with their types, or nil if there are none.
+func replaceColumns(rec arrow.Record, cols map[int]replacement) arrow.Record {
+	if len(cols) == 0 {
+		return nil
+	}
+	schema := rec.Schema()
+	fields := append([]arrow.Field(nil), schema.Fields()...)
+	columns := append([]arrow.Array(nil), rec.Columns()...)
+	var replaced []arrow.Array
+
+	for _, id := range sortedIDs(cols) {
+		arr := array.MakeFromData(cols[id].data)
+		replaced = append(replaced, arr)
+		if id < len(fields) {
+			fields[id].Type = arr.DataType()
+			columns[id] = arr
+			continue
+		}
+		fields = append(fields, arrow.Field{Name: cols[id].name, Type: arr.DataType(), Nullable: true})
+		columns = append(columns, arr)
+	}
+
+	metadata := schema.Metadata()
+	out := array.NewRecord(arrow.NewSchema(fields, &metadata), columns, rec.NumRows())
+	for _, arr := range replaced {
+		arr.Release()
+	}
+	return out
+}
+
+// withChildren returns a copy of a struct array data with the given children
+// replaced, or appended, along with their types.
+func withChildren(data arrow.ArrayData, children map[int]replacement) arrow.ArrayData {
+	fields := append([]arrow.Field(nil), data.DataType().(*arrow.StructType).Fields()...)
+	kids := append([]arrow.ArrayData(nil), data.Children()...)
+
+	for _, id := range sortedIDs(children) {
+		c := children[id]
+		if id < len(fields) {
+			fields[id].Type = c.data.DataType()
+			kids[id] = c.data
+			continue
+		}
+		fields = append(fields, arrow.Field{Name: c.name, Type: c.data.DataType(), Nullable: true})
+		kids = append(kids, c.data)
+	}
+	return array.NewData(arrow.StructOf(fields...), data.Len(), data.Buffers(), kids, data.NullN(), data.Offset())
+}
+
+func sortedIDs(replacements map[int]replacement) []int {
+	ids := make([]int, 0, len(replacements))
+	for id := range replacements {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func releaseAll(replacements map[int]replacement) {
+	for _, r := range replacements {
+		r.data.Release()
+	}
+}
+
+// appendString appends the value of a row of a string array, possibly
+// dictionary encoded, or null if absent.
+func appendString(b *array.StringBuilder, arr arrow.Array, row int) error {
+	if arr == nil || arr.IsNull(row) {
+		b.AppendNull()
+		return nil
+	}
+	v, err := arrowutils.StringFromArray(arr, row)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	b.Append(v)
+	return nil
+}
+
+// ownedData returns the data of an array, which is released.
+func ownedData(arr arrow.Array) arrow.ArrayData {
+	data := arr.Data()
+	data.Retain()
+	arr.Release()
+	return data
+}
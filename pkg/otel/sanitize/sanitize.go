@@ -0,0 +1,345 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package sanitize
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+)
+
+// DefaultMaxClockSkew is the default maximum distance of a timestamp in the
+// future of the consumer's clock.
+const DefaultMaxClockSkew = 24 * time.Hour
+
+type (
+	// Config selects the rules applied by a Sanitizer.
+	Config struct {
+		// FixUTF8 replaces the invalid UTF-8 sequences of the string
+		// values with the Unicode replacement character.
+		FixUTF8 bool
+		// ClampTimestamps clamps the negative timestamps to 0 and the
+		// timestamps later than now plus MaxClockSkew to now plus
+		// MaxClockSkew.
+		ClampTimestamps bool
+		MaxClockSkew    time.Duration
+		// NormalizeDurations replaces the negative durations (e.g. spans
+		// ending before their start) with 0.
+		NormalizeDurations bool
+	}
+
+	// Counts are the number of values repaired by each rule. A dictionary
+	// value is counted once per batch, whatever the number of rows
+	// referencing it.
+	Counts struct {
+		InvalidUTF8       uint64
+		ClampedTimestamps uint64
+		NegativeDurations uint64
+	}
+
+	// Sanitizer repairs the records of decoded OTLP Arrow batches. The
+	// columns are processed as a whole and only the columns containing
+	// values to repair are rebuilt. The counts are cumulative since the
+	// creation of the sanitizer.
+	Sanitizer struct {
+		cfg  Config
+		pool memory.Allocator
+		now  func() time.Time
+
+		// lock protects counts.
+		lock   sync.Mutex
+		counts Counts
+	}
+
+	// pass is a single call to Sanitize.
+	pass struct {
+		cfg          *Config
+		pool         memory.Allocator
+		maxTimestamp arrow.Timestamp
+		counts       Counts
+	}
+)
+
+// DefaultConfig returns a configuration applying all the rules.
+func DefaultConfig() *Config {
+	return &Config{
+		FixUTF8:            true,
+		ClampTimestamps:    true,
+		MaxClockSkew:       DefaultMaxClockSkew,
+		NormalizeDurations: true,
+	}
+}
+
+// NewSanitizer creates a Sanitizer applying the rules of the given
+// configuration.
+func NewSanitizer(cfg *Config) *Sanitizer {
+	return &Sanitizer{
+		cfg:  *cfg,
+		pool: memory.NewGoAllocator(),
+		now:  time.Now,
+	}
+}
+
+// Sanitize repairs the records of a decoded OTLP Arrow batch, as returned by
+// the Consume method of the consumer. The records containing values to repair
+// are released and replaced in their RecordMessage.
+func (s *Sanitizer) Sanitize(records []*record_message.RecordMessage) {
+	p := pass{
+		cfg:          &s.cfg,
+		pool:         s.pool,
+		maxTimestamp: math.MaxInt64,
+	}
+	if s.cfg.ClampTimestamps {
+		p.maxTimestamp = arrow.Timestamp(s.now().Add(s.cfg.MaxClockSkew).UnixNano())
+	}
+
+	for _, rm := range records {
+		if rec := p.record(rm.Record()); rec != nil {
+			rm.Record().Release()
+			rm.SetRecord(rec)
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.counts.InvalidUTF8 += p.counts.InvalidUTF8
+	s.counts.ClampedTimestamps += p.counts.ClampedTimestamps
+	s.counts.NegativeDurations += p.counts.NegativeDurations
+}
+
+// Counts returns the current value of the counts.
+func (s *Sanitizer) Counts() Counts {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.counts
+}
+
+// record returns a copy of the record with its repaired columns, or nil if
+// there is nothing to repair.
+func (p *pass) record(rec arrow.Record) arrow.Record {
+	cols := rec.Columns()
+	var repaired []arrow.Array
+
+	for i, col := range cols {
+		data := p.data(col.Data())
+		if data == nil {
+			continue
+		}
+		if repaired == nil {
+			cols = append([]arrow.Array(nil), cols...)
+		}
+		arr := array.MakeFromData(data)
+		data.Release()
+		cols[i] = arr
+		repaired = append(repaired, arr)
+	}
+	if repaired == nil {
+		return nil
+	}
+
+	out := array.NewRecord(rec.Schema(), cols, rec.NumRows())
+	for _, arr := range repaired {
+		arr.Release()
+	}
+	return out
+}
+
+// data returns the repaired copy of an array data, or nil if there is
+// nothing to repair. The nested types are traversed, only the repaired
+// children are replaced.
+func (p *pass) data(data arrow.ArrayData) arrow.ArrayData {
+	switch dt := data.DataType().(type) {
+	case *arrow.StringType:
+		if p.cfg.FixUTF8 {
+			return p.strings(data)
+		}
+	case *arrow.TimestampType:
+		if p.cfg.ClampTimestamps && dt.Unit == arrow.Nanosecond {
+			return p.timestamps(data, dt)
+		}
+	case *arrow.DurationType:
+		if p.cfg.NormalizeDurations {
+			return p.durations(data, dt)
+		}
+	case *arrow.DictionaryType:
+		dict := data.Dictionary()
+		if dict == nil {
+			return nil
+		}
+		repaired := p.data(dict)
+		if repaired == nil {
+			return nil
+		}
+		out := array.NewDataWithDictionary(dt, data.Len(), data.Buffers(), data.NullN(), data.Offset(), repaired.(*array.Data))
+		repaired.Release()
+		return out
+	default:
+		children := data.Children()
+		var repaired []arrow.ArrayData
+
+		for i, child := range children {
+			c := p.data(child)
+			if c == nil {
+				continue
+			}
+			if repaired == nil {
+				children = append([]arrow.ArrayData(nil), children...)
+			}
+			children[i] = c
+			repaired = append(repaired, c)
+		}
+		if repaired == nil {
+			return nil
+		}
+
+		out := array.NewData(dt, data.Len(), data.Buffers(), children, data.NullN(), data.Offset())
+		for _, c := range repaired {
+			c.Release()
+		}
+		return out
+	}
+	return nil
+}
+
+// strings replaces the invalid UTF-8 sequences of a string array.
+func (p *pass) strings(data arrow.ArrayData) arrow.ArrayData {
+	arr := array.NewStringData(data)
+	defer arr.Release()
+
+	first := -1
+	for i := 0; i < arr.Len(); i++ {
+		if arr.IsValid(i) && !utf8.ValidString(arr.Value(i)) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+
+	b := array.NewStringBuilder(p.pool)
+	defer b.Release()
+	b.Reserve(arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		if arr.IsNull(i) {
+			b.AppendNull()
+			continue
+		}
+		v := arr.Value(i)
+		if i >= first && !utf8.ValidString(v) {
+			v = strings.ToValidUTF8(v, string(utf8.RuneError))
+			p.counts.InvalidUTF8++
+		}
+		b.Append(v)
+	}
+	return ownedData(b.NewArray())
+}
+
+// timestamps clamps the timestamps of a timestamp array to
+// [0, maxTimestamp].
+func (p *pass) timestamps(data arrow.ArrayData, dt *arrow.TimestampType) arrow.ArrayData {
+	arr := array.NewTimestampData(data)
+	defer arr.Release()
+
+	values := arr.TimestampValues()
+	repair := false
+	for i, v := range values {
+		if arr.IsValid(i) && (v < 0 || v > p.maxTimestamp) {
+			repair = true
+			break
+		}
+	}
+	if !repair {
+		return nil
+	}
+
+	b := array.NewTimestampBuilder(p.pool, dt)
+	defer b.Release()
+	b.Reserve(len(values))
+
+	for i, v := range values {
+		if arr.IsNull(i) {
+			b.AppendNull()
+			continue
+		}
+		switch {
+		case v < 0:
+			v = 0
+			p.counts.ClampedTimestamps++
+		case v > p.maxTimestamp:
+			v = p.maxTimestamp
+			p.counts.ClampedTimestamps++
+		}
+		b.Append(v)
+	}
+	return ownedData(b.NewArray())
+}
+
+// durations replaces the negative durations of a duration array with 0.
+func (p *pass) durations(data arrow.ArrayData, dt *arrow.DurationType) arrow.ArrayData {
+	arr := array.NewDurationData(data)
+	defer arr.Release()
+
+	values := arr.DurationValues()
+	repair := false
+	for i, v := range values {
+		if arr.IsValid(i) && v < 0 {
+			repair = true
+			break
+		}
+	}
+	if !repair {
+		return nil
+	}
+
+	b := array.NewDurationBuilder(p.pool, dt)
+	defer b.Release()
+	b.Reserve(len(values))
+
+	for i, v := range values {
+		if arr.IsNull(i) {
+			b.AppendNull()
+			continue
+		}
+		if v < 0 {
+			v = 0
+			p.counts.NegativeDurations++
+		}
+		b.Append(v)
+	}
+	return ownedData(b.NewArray())
+}
+
+// ownedData returns the data of an array, which is released.
+func ownedData(arr arrow.Array) arrow.ArrayData {
+	data := arr.Data()
+	data.Retain()
+	arr.Release()
+	return data
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+// Histogram of the serialized sizes of the payloads and batches.
+//
+// The sizes span several orders of magnitude (from a few hundred bytes for a
+// dictionary delta to megabytes for a large batch), so the buckets are
+// exponential: each power of 2 is split into sizeSubBuckets buckets, which
+// bounds the relative error of the quantiles to ~19%.
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// sizeSubBuckets is the number of buckets per power of 2.
+	sizeSubBuckets = 4
+
+	// sizeBuckets covers all the sizes up to 2^63 bytes.
+	sizeBuckets = 63*sizeSubBuckets + 1
+)
+
+// SizeHistogram is a histogram of sizes in bytes.
+type SizeHistogram struct {
+	counts [sizeBuckets]uint64
+	count  uint64
+	sum    uint64
+	max    uint64
+}
+
+// NewSizeHistogram creates an empty SizeHistogram.
+func NewSizeHistogram() *SizeHistogram {
+	return &SizeHistogram{}
+}
+
+// Record adds a size to the histogram.
+func (h *SizeHistogram) Record(size int) {
+	if size < 0 {
+		size = 0
+	}
+	h.counts[sizeBucket(uint64(size))]++
+	h.count++
+	h.sum += uint64(size)
+	if uint64(size) > h.max {
+		h.max = uint64(size)
+	}
+}
+
+// Merge adds the sizes recorded by other to the histogram.
+func (h *SizeHistogram) Merge(other *SizeHistogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.count += other.count
+	h.sum += other.sum
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count returns the number of sizes recorded.
+func (h *SizeHistogram) Count() uint64 {
+	return h.count
+}
+
+// Mean returns the mean of the sizes recorded, or 0 if the histogram is
+// empty.
+func (h *SizeHistogram) Mean() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.count)
+}
+
+// Max returns the largest size recorded.
+func (h *SizeHistogram) Max() uint64 {
+	return h.max
+}
+
+// Quantile returns an upper bound of the q-quantile (e.g. 0.99 for the p99)
+// of the sizes recorded, i.e. the upper bound of the bucket containing it,
+// or 0 if the histogram is empty.
+func (h *SizeHistogram) Quantile(q float64) uint64 {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	if rank == 0 {
+		rank = 1
+	}
+	cumulative := uint64(0)
+	for i, count := range h.counts {
+		cumulative += count
+		if cumulative >= rank {
+			if bound := sizeBucketUpperBound(i); bound < h.max {
+				return bound
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+// Show prints the count, mean and quantiles of the histogram.
+func (h *SizeHistogram) Show(indent string, name string) {
+	fmt.Printf("%s- %s: count=%d, mean=%.0f, p50=%d, p95=%d, p99=%d, max=%d\n",
+		indent, name, h.count, h.Mean(), h.Quantile(0.5), h.Quantile(0.95), h.Quantile(0.99), h.max)
+}
+
+// sizeBucket returns the index of the bucket containing the given size, i.e.
+// the smallest i such that size <= 2^(i/sizeSubBuckets).
+func sizeBucket(size uint64) int {
+	if size <= 1 {
+		return 0
+	}
+	i := int(math.Ceil(math.Log2(float64(size)) * sizeSubBuckets))
+	if i >= sizeBuckets {
+		return sizeBuckets - 1
+	}
+	return i
+}
+
+// sizeBucketUpperBound returns the upper bound of the bucket i.
+func sizeBucketUpperBound(i int) uint64 {
+	return uint64(math.Ceil(math.Exp2(float64(i) / sizeSubBuckets)))
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeHistogram(t *testing.T) {
+	t.Parallel()
+
+	h := NewSizeHistogram()
+	require.Equal(t, uint64(0), h.Quantile(0.99))
+
+	for size := 1; size <= 1000; size++ {
+		h.Record(size)
+	}
+	require.Equal(t, uint64(1000), h.Count())
+	require.Equal(t, uint64(1000), h.Max())
+	require.InDelta(t, 500.5, h.Mean(), 0.001)
+
+	// The quantiles are upper bounds with a bounded relative error.
+	for _, q := range []float64{0.5, 0.95, 0.99} {
+		exact := q * 1000
+		quantile := float64(h.Quantile(q))
+		require.GreaterOrEqual(t, quantile, exact)
+		require.LessOrEqual(t, quantile, exact*1.19)
+	}
+	require.Equal(t, uint64(1000), h.Quantile(1))
+}
+
+func TestProducerStatsSizes(t *testing.T) {
+	t.Parallel()
+
+	s := NewProducerStats()
+	s.PayloadSize("LOGS").Record(100)
+	s.PayloadSize("LOGS").Record(200)
+	s.BatchSizes.Record(300)
+
+	snapshot := s.GetAndReset()
+	require.Equal(t, uint64(2), snapshot.PayloadSizes["LOGS"].Count())
+	require.Equal(t, uint64(1), snapshot.BatchSizes.Count())
+
+	// The reset does not affect the snapshot.
+	require.Empty(t, s.PayloadSizes)
+	require.Equal(t, uint64(0), s.BatchSizes.Count())
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+// Export of the producer stats as OpenTelemetry metrics.
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+const (
+	meterName = "github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+
+	// SignalKey is the attribute of the batches counter identifying the
+	// signal (metrics, logs or traces).
+	SignalKey = "signal"
+	// PayloadTypeKey is the attribute of the payload size histogram
+	// identifying the payload type.
+	PayloadTypeKey = "payload_type"
+)
+
+type (
+	// ProducerMetrics exports the ProducerStats as OpenTelemetry metrics. The
+	// counters are incremented with the variations of the stats since the
+	// last call to Update, so the stats can still be reset by their owner
+	// (see ProducerStats.GetAndReset).
+	ProducerMetrics struct {
+		batches                    metric.Int64Counter
+		streamProducersCreated     metric.Int64Counter
+		streamProducersClosed      metric.Int64Counter
+		schemaUpdatesPerformed     metric.Int64Counter
+		dictionaryIndexTypeChanged metric.Int64Counter
+		dictionaryOverflowDetected metric.Int64Counter
+		builderReservations        metric.Int64Counter
+		builderReallocations       metric.Int64Counter
+		optionalColumnsDeactivated metric.Int64Counter
+		rollbacks                  metric.Int64Counter
+		payloadSizes               metric.Int64Histogram
+		batchSizes                 metric.Int64Histogram
+
+		// last holds the counters of the stats at the last update.
+		last ProducerStats
+	}
+
+	// counter associates an OpenTelemetry counter with a field of the
+	// ProducerStats.
+	counter struct {
+		instrument metric.Int64Counter
+		value      func(s *ProducerStats) uint64
+		attrs      []attribute.KeyValue
+	}
+)
+
+// NewProducerMetrics creates the instruments of the producer stats with the
+// given MeterProvider.
+func NewProducerMetrics(mp metric.MeterProvider) (*ProducerMetrics, error) {
+	meter := mp.Meter(meterName)
+	m := &ProducerMetrics{}
+
+	counters := []struct {
+		instrument  *metric.Int64Counter
+		name        string
+		description string
+	}{
+		{&m.batches, "arrow_producer_batches", "Number of batches produced, by signal."},
+		{&m.streamProducersCreated, "arrow_producer_streams_created", "Number of IPC streams created."},
+		{&m.streamProducersClosed, "arrow_producer_streams_closed", "Number of IPC streams closed."},
+		{&m.schemaUpdatesPerformed, "arrow_producer_schema_updates", "Number of schema updates performed by the record builders."},
+		{&m.dictionaryIndexTypeChanged, "arrow_producer_dictionary_index_type_changes", "Number of dictionary index type changes."},
+		{&m.dictionaryOverflowDetected, "arrow_producer_dictionary_overflows", "Number of dictionary overflows detected."},
+		{&m.builderReservations, "arrow_producer_builder_reservations", "Number of builder reservations."},
+		{&m.builderReallocations, "arrow_producer_builder_reallocations", "Number of builder reallocations."},
+		{&m.optionalColumnsDeactivated, "arrow_producer_optional_columns_deactivated", "Number of optional columns deactivated."},
+		{&m.rollbacks, "arrow_producer_rollbacks", "Number of batches rolled back by the record builders."},
+	}
+	for _, c := range counters {
+		instrument, err := meter.Int64Counter(c.name, metric.WithDescription(c.description))
+		if err != nil {
+			return nil, werror.WrapWithContext(err, map[string]interface{}{"name": c.name})
+		}
+		*c.instrument = instrument
+	}
+
+	var err error
+	m.payloadSizes, err = meter.Int64Histogram("arrow_producer_payload_size",
+		metric.WithDescription("Serialized size of the payloads, by payload type."),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	m.batchSizes, err = meter.Int64Histogram("arrow_producer_batch_size",
+		metric.WithDescription("Serialized size of the batches, i.e. the sum of the sizes of their payloads."),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+
+	return m, nil
+}
+
+// Update increments the counters with the variations of the given stats
+// since the last update.
+func (m *ProducerMetrics) Update(ctx context.Context, s *ProducerStats) {
+	for _, c := range m.counters() {
+		current, last := c.value(s), c.value(&m.last)
+		delta := current
+		if current >= last {
+			delta = current - last
+		}
+		if delta > 0 {
+			c.instrument.Add(ctx, int64(delta), metric.WithAttributes(c.attrs...))
+		}
+	}
+
+	m.last.MetricsBatchesProduced = s.MetricsBatchesProduced
+	m.last.LogsBatchesProduced = s.LogsBatchesProduced
+	m.last.TracesBatchesProduced = s.TracesBatchesProduced
+	m.last.StreamProducersCreated = s.StreamProducersCreated
+	m.last.StreamProducersClosed = s.StreamProducersClosed
+	m.last.RecordBuilderStats = s.RecordBuilderStats
+}
+
+// RecordPayloadSize records the serialized size of a payload of the given
+// type.
+func (m *ProducerMetrics) RecordPayloadSize(ctx context.Context, payloadType string, size int) {
+	m.payloadSizes.Record(ctx, int64(size), metric.WithAttributes(attribute.String(PayloadTypeKey, payloadType)))
+}
+
+// RecordBatchSize records the serialized size of a batch.
+func (m *ProducerMetrics) RecordBatchSize(ctx context.Context, size int) {
+	m.batchSizes.Record(ctx, int64(size))
+}
+
+// counters returns the counters updated by Update. A reset of the stats is
+// detected when a field is lower than at the last update, its whole value is
+// then added to the counter.
+func (m *ProducerMetrics) counters() []counter {
+	return []counter{
+		{m.batches, func(s *ProducerStats) uint64 { return s.MetricsBatchesProduced }, []attribute.KeyValue{attribute.String(SignalKey, "metrics")}},
+		{m.batches, func(s *ProducerStats) uint64 { return s.LogsBatchesProduced }, []attribute.KeyValue{attribute.String(SignalKey, "logs")}},
+		{m.batches, func(s *ProducerStats) uint64 { return s.TracesBatchesProduced }, []attribute.KeyValue{attribute.String(SignalKey, "traces")}},
+		{m.streamProducersCreated, func(s *ProducerStats) uint64 { return s.StreamProducersCreated }, nil},
+		{m.streamProducersClosed, func(s *ProducerStats) uint64 { return s.StreamProducersClosed }, nil},
+		{m.schemaUpdatesPerformed, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.SchemaUpdatesPerformed }, nil},
+		{m.dictionaryIndexTypeChanged, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.DictionaryIndexTypeChanged }, nil},
+		{m.dictionaryOverflowDetected, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.DictionaryOverflowDetected }, nil},
+		{m.builderReservations, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.BuilderReservations }, nil},
+		{m.builderReallocations, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.BuilderReallocations }, nil},
+		{m.optionalColumnsDeactivated, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.OptionalColumnsDeactivated }, nil},
+		{m.rollbacks, func(s *ProducerStats) uint64 { return s.RecordBuilderStats.Rollbacks }, nil},
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectSums returns the value of the data points of the counters by metric
+// name and attribute set.
+func collectSums(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	sums := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				sums[m.Name+dp.Attributes.Encoded(attribute.DefaultEncoder())] += dp.Value
+			}
+		}
+	}
+	return sums
+}
+
+func TestProducerMetrics(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	m, err := NewProducerMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	s := NewProducerStats()
+	s.TracesBatchesProduced = 2
+	s.LogsBatchesProduced = 1
+	s.RecordBuilderStats.SchemaUpdatesPerformed = 3
+	m.Update(context.Background(), s)
+
+	s.TracesBatchesProduced = 5
+	m.Update(context.Background(), s)
+
+	sums := collectSums(t, reader)
+	require.Equal(t, int64(5), sums["arrow_producer_batchessignal=traces"])
+	require.Equal(t, int64(1), sums["arrow_producer_batchessignal=logs"])
+	require.Equal(t, int64(3), sums["arrow_producer_schema_updates"])
+	require.NotContains(t, sums, "arrow_producer_batchessignal=metrics")
+
+	// The counters keep increasing when the stats are reset.
+	s.Reset()
+	s.TracesBatchesProduced = 1
+	m.Update(context.Background(), s)
+
+	sums = collectSums(t, reader)
+	require.Equal(t, int64(6), sums["arrow_producer_batchessignal=traces"])
+	require.Equal(t, int64(3), sums["arrow_producer_schema_updates"])
+}
+
+func TestProducerMetricsSizes(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkmetric.NewManualReader()
+	m, err := NewProducerMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	m.RecordPayloadSize(context.Background(), "SPANS", 100)
+	m.RecordPayloadSize(context.Background(), "SPAN_ATTRS", 50)
+	m.RecordBatchSize(context.Background(), 150)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	histograms := make(map[string]metricdata.Histogram[int64])
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[int64]); ok {
+				histograms[m.Name] = h
+			}
+		}
+	}
+	require.Len(t, histograms["arrow_producer_payload_size"].DataPoints, 2)
+	batches := histograms["arrow_producer_batch_size"].DataPoints
+	require.Len(t, batches, 1)
+	require.Equal(t, uint64(1), batches[0].Count)
+	require.Equal(t, int64(150), batches[0].Sum)
+}
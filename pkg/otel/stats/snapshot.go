@@ -0,0 +1,195 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+// Cumulative statistics of producers and consumers, exported as a JSON
+// document.
+//
+// The document has a stable layout (snake_case keys, payload types sorted by
+// name) so that successive snapshots can be compared, e.g. when they are
+// dumped periodically or served by a debug HTTP handler.
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// StatsSnapshotVersion is the version of the layout of the JSON document
+// returned by Stats.Snapshot.
+const StatsSnapshotVersion = 1
+
+type (
+	// Stats accumulates the stats of a set of producers and consumers, e.g.
+	// of all the streams of an exporter or of a receiver. It is safe for
+	// concurrent use.
+	Stats struct {
+		lock     sync.Mutex
+		producer *ProducerStats
+		consumer *ConsumerStats
+	}
+
+	// StatsSnapshot is the JSON document returned by Stats.Snapshot.
+	StatsSnapshot struct {
+		Version  int                   `json:"version"`
+		Producer ProducerStatsSnapshot `json:"producer"`
+		Consumer ConsumerStatsSnapshot `json:"consumer"`
+	}
+
+	// ProducerStatsSnapshot is the producer section of a StatsSnapshot.
+	ProducerStatsSnapshot struct {
+		MetricsBatches uint64                          `json:"metrics_batches"`
+		LogsBatches    uint64                          `json:"logs_batches"`
+		TracesBatches  uint64                          `json:"traces_batches"`
+		StreamsCreated uint64                          `json:"streams_created"`
+		StreamsClosed  uint64                          `json:"streams_closed"`
+		RecordBuilder  RecordBuilderStatsSnapshot      `json:"record_builder"`
+		BatchSizes     SizeSnapshot                    `json:"batch_sizes"`
+		Payloads       map[string]PayloadStatsSnapshot `json:"payloads"`
+	}
+
+	// RecordBuilderStatsSnapshot is the record builder section of a
+	// ProducerStatsSnapshot.
+	RecordBuilderStatsSnapshot struct {
+		SchemaUpdates              uint64 `json:"schema_updates"`
+		DictionaryIndexTypeChanges uint64 `json:"dictionary_index_type_changes"`
+		DictionaryOverflows        uint64 `json:"dictionary_overflows"`
+		Reservations               uint64 `json:"reservations"`
+		Reallocations              uint64 `json:"reallocations"`
+		OptionalColumnsDeactivated uint64 `json:"optional_columns_deactivated"`
+		Rollbacks                  uint64 `json:"rollbacks"`
+	}
+
+	// ConsumerStatsSnapshot is the consumer section of a StatsSnapshot.
+	ConsumerStatsSnapshot struct {
+		Batches        uint64                          `json:"batches"`
+		StreamsCreated uint64                          `json:"streams_created"`
+		Payloads       map[string]PayloadStatsSnapshot `json:"payloads"`
+	}
+
+	// PayloadStatsSnapshot is the stats of a payload type. Rows is only
+	// counted by the consumers.
+	PayloadStatsSnapshot struct {
+		Rows  uint64       `json:"rows,omitempty"`
+		Sizes SizeSnapshot `json:"sizes"`
+	}
+
+	// SizeSnapshot summarizes a SizeHistogram, in bytes.
+	SizeSnapshot struct {
+		Count uint64  `json:"count"`
+		Mean  float64 `json:"mean"`
+		P50   uint64  `json:"p50"`
+		P95   uint64  `json:"p95"`
+		P99   uint64  `json:"p99"`
+		Max   uint64  `json:"max"`
+	}
+)
+
+// NewStats creates an empty Stats.
+func NewStats() *Stats {
+	return &Stats{
+		producer: NewProducerStats(),
+		consumer: NewConsumerStats(),
+	}
+}
+
+// AddProducerStats adds the stats of a producer, typically the result of
+// Producer.GetAndResetStats.
+func (s *Stats) AddProducerStats(stats ProducerStats) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.producer.add(&stats)
+}
+
+// AddConsumerStats adds the stats of a consumer, typically the result of
+// Consumer.GetAndResetStats.
+func (s *Stats) AddConsumerStats(stats ConsumerStats) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.consumer.add(&stats)
+}
+
+// Snapshot returns the cumulative stats as a JSON document (see
+// StatsSnapshot).
+func (s *Stats) Snapshot() ([]byte, error) {
+	s.lock.Lock()
+	snapshot := StatsSnapshot{
+		Version:  StatsSnapshotVersion,
+		Producer: producerSnapshot(s.producer),
+		Consumer: consumerSnapshot(s.consumer),
+	}
+	s.lock.Unlock()
+
+	doc, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return doc, nil
+}
+
+func producerSnapshot(s *ProducerStats) ProducerStatsSnapshot {
+	snapshot := ProducerStatsSnapshot{
+		MetricsBatches: s.MetricsBatchesProduced,
+		LogsBatches:    s.LogsBatchesProduced,
+		TracesBatches:  s.TracesBatchesProduced,
+		StreamsCreated: s.StreamProducersCreated,
+		StreamsClosed:  s.StreamProducersClosed,
+		RecordBuilder: RecordBuilderStatsSnapshot{
+			SchemaUpdates:              s.RecordBuilderStats.SchemaUpdatesPerformed,
+			DictionaryIndexTypeChanges: s.RecordBuilderStats.DictionaryIndexTypeChanged,
+			DictionaryOverflows:        s.RecordBuilderStats.DictionaryOverflowDetected,
+			Reservations:               s.RecordBuilderStats.BuilderReservations,
+			Reallocations:              s.RecordBuilderStats.BuilderReallocations,
+			OptionalColumnsDeactivated: s.RecordBuilderStats.OptionalColumnsDeactivated,
+			Rollbacks:                  s.RecordBuilderStats.Rollbacks,
+		},
+		BatchSizes: sizeSnapshot(s.BatchSizes),
+		Payloads:   make(map[string]PayloadStatsSnapshot, len(s.PayloadSizes)),
+	}
+	for payloadType, h := range s.PayloadSizes {
+		snapshot.Payloads[payloadType] = PayloadStatsSnapshot{Sizes: sizeSnapshot(h)}
+	}
+	return snapshot
+}
+
+func consumerSnapshot(s *ConsumerStats) ConsumerStatsSnapshot {
+	snapshot := ConsumerStatsSnapshot{
+		Batches:        s.BatchesConsumed,
+		StreamsCreated: s.StreamConsumersCreated,
+		Payloads:       make(map[string]PayloadStatsSnapshot, len(s.PayloadSizes)),
+	}
+	for payloadType, h := range s.PayloadSizes {
+		snapshot.Payloads[payloadType] = PayloadStatsSnapshot{
+			Rows:  s.PayloadRows[payloadType],
+			Sizes: sizeSnapshot(h),
+		}
+	}
+	return snapshot
+}
+
+func sizeSnapshot(h *SizeHistogram) SizeSnapshot {
+	return SizeSnapshot{
+		Count: h.Count(),
+		Mean:  h.Mean(),
+		P50:   h.Quantile(0.5),
+		P95:   h.Quantile(0.95),
+		P99:   h.Quantile(0.99),
+		Max:   h.Max(),
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsSnapshot(t *testing.T) {
+	t.Parallel()
+
+	s := NewStats()
+
+	for i := 0; i < 2; i++ {
+		ps := NewProducerStats()
+		ps.TracesBatchesProduced = 3
+		ps.RecordBuilderStats.SchemaUpdatesPerformed = 1
+		ps.PayloadSize("SPANS").Record(100)
+		ps.BatchSizes.Record(100)
+		s.AddProducerStats(ps.GetAndReset())
+
+		cs := NewConsumerStats()
+		cs.BatchesConsumed = 3
+		cs.PayloadSize("SPANS").Record(100)
+		cs.PayloadRows["SPANS"] += 10
+		s.AddConsumerStats(cs.GetAndReset())
+	}
+	// A zero value is accepted.
+	s.AddProducerStats(ProducerStats{})
+
+	doc, err := s.Snapshot()
+	require.NoError(t, err)
+
+	var snapshot StatsSnapshot
+	require.NoError(t, json.Unmarshal(doc, &snapshot))
+	require.Equal(t, StatsSnapshotVersion, snapshot.Version)
+	require.Equal(t, uint64(6), snapshot.Producer.TracesBatches)
+	require.Equal(t, uint64(2), snapshot.Producer.RecordBuilder.SchemaUpdates)
+	require.Equal(t, uint64(2), snapshot.Producer.BatchSizes.Count)
+	require.Equal(t, uint64(2), snapshot.Producer.Payloads["SPANS"].Sizes.Count)
+	require.Equal(t, uint64(6), snapshot.Consumer.Batches)
+	require.Equal(t, uint64(20), snapshot.Consumer.Payloads["SPANS"].Rows)
+	require.Equal(t, uint64(100), snapshot.Consumer.Payloads["SPANS"].Sizes.Max)
+
+	// The document is stable.
+	again, err := s.Snapshot()
+	require.NoError(t, err)
+	require.Equal(t, doc, again)
+}
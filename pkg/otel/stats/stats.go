@@ -17,10 +17,11 @@
 
 package stats
 
-// Set of general statistics about the OTLP Arrow Producer.
+// Set of general statistics about the OTLP Arrow Producer and Consumer.
 
 import (
 	"fmt"
+	"sort"
 )
 
 type (
@@ -33,6 +34,13 @@ type (
 		StreamProducersClosed  uint64
 		RecordBuilderStats     RecordBuilderStats
 
+		// PayloadSizes are the histograms of the serialized size of the
+		// payloads, per payload type (see PayloadSize).
+		PayloadSizes map[string]*SizeHistogram
+		// BatchSizes is the histogram of the serialized size of the batches,
+		// i.e. the sum of the sizes of their payloads.
+		BatchSizes *SizeHistogram
+
 		SchemaStatsEnabled bool
 	}
 
@@ -40,6 +48,23 @@ type (
 		SchemaUpdatesPerformed     uint64
 		DictionaryIndexTypeChanged uint64
 		DictionaryOverflowDetected uint64
+		BuilderReservations        uint64
+		BuilderReallocations       uint64
+		OptionalColumnsDeactivated uint64
+		Rollbacks                  uint64
+	}
+
+	// ConsumerStats is a struct that contains stats about the OTLP Arrow
+	// Consumer.
+	ConsumerStats struct {
+		BatchesConsumed        uint64
+		StreamConsumersCreated uint64
+
+		// PayloadSizes are the histograms of the serialized size of the
+		// payloads, per payload type (see PayloadSize).
+		PayloadSizes map[string]*SizeHistogram
+		// PayloadRows are the numbers of rows decoded, per payload type.
+		PayloadRows map[string]uint64
 	}
 )
 
@@ -55,7 +80,13 @@ func NewProducerStats() *ProducerStats {
 			SchemaUpdatesPerformed:     0,
 			DictionaryIndexTypeChanged: 0,
 			DictionaryOverflowDetected: 0,
+			BuilderReservations:        0,
+			BuilderReallocations:       0,
+			OptionalColumnsDeactivated: 0,
+			Rollbacks:                  0,
 		},
+		PayloadSizes:       make(map[string]*SizeHistogram),
+		BatchSizes:         NewSizeHistogram(),
 		SchemaStatsEnabled: false,
 	}
 }
@@ -75,6 +106,37 @@ func (s *ProducerStats) Reset() {
 	s.StreamProducersCreated = 0
 	s.StreamProducersClosed = 0
 	s.RecordBuilderStats.Reset()
+	// The histograms are replaced, not cleared, as they are shared with the
+	// copy returned by GetAndReset.
+	s.PayloadSizes = make(map[string]*SizeHistogram)
+	s.BatchSizes = NewSizeHistogram()
+}
+
+// PayloadSize returns the histogram of the serialized size of the payloads of
+// the given type.
+func (s *ProducerStats) PayloadSize(payloadType string) *SizeHistogram {
+	h, ok := s.PayloadSizes[payloadType]
+	if !ok {
+		h = NewSizeHistogram()
+		s.PayloadSizes[payloadType] = h
+	}
+	return h
+}
+
+// add adds the stats of other to s.
+func (s *ProducerStats) add(other *ProducerStats) {
+	s.MetricsBatchesProduced += other.MetricsBatchesProduced
+	s.LogsBatchesProduced += other.LogsBatchesProduced
+	s.TracesBatchesProduced += other.TracesBatchesProduced
+	s.StreamProducersCreated += other.StreamProducersCreated
+	s.StreamProducersClosed += other.StreamProducersClosed
+	s.RecordBuilderStats.add(&other.RecordBuilderStats)
+	for payloadType, h := range other.PayloadSizes {
+		s.PayloadSize(payloadType).Merge(h)
+	}
+	if other.BatchSizes != nil {
+		s.BatchSizes.Merge(other.BatchSizes)
+	}
 }
 
 // Reset sets all stats to zero.
@@ -82,6 +144,69 @@ func (s *RecordBuilderStats) Reset() {
 	s.SchemaUpdatesPerformed = 0
 	s.DictionaryIndexTypeChanged = 0
 	s.DictionaryOverflowDetected = 0
+	s.BuilderReservations = 0
+	s.BuilderReallocations = 0
+	s.OptionalColumnsDeactivated = 0
+	s.Rollbacks = 0
+}
+
+// add adds the stats of other to s.
+func (s *RecordBuilderStats) add(other *RecordBuilderStats) {
+	s.SchemaUpdatesPerformed += other.SchemaUpdatesPerformed
+	s.DictionaryIndexTypeChanged += other.DictionaryIndexTypeChanged
+	s.DictionaryOverflowDetected += other.DictionaryOverflowDetected
+	s.BuilderReservations += other.BuilderReservations
+	s.BuilderReallocations += other.BuilderReallocations
+	s.OptionalColumnsDeactivated += other.OptionalColumnsDeactivated
+	s.Rollbacks += other.Rollbacks
+}
+
+// NewConsumerStats creates a new ConsumerStats struct.
+func NewConsumerStats() *ConsumerStats {
+	return &ConsumerStats{
+		PayloadSizes: make(map[string]*SizeHistogram),
+		PayloadRows:  make(map[string]uint64),
+	}
+}
+
+// GetAndReset returns the current stats and resets them to zero.
+func (s *ConsumerStats) GetAndReset() ConsumerStats {
+	stats := *s
+	s.Reset()
+	return stats
+}
+
+// Reset sets all stats to zero.
+func (s *ConsumerStats) Reset() {
+	s.BatchesConsumed = 0
+	s.StreamConsumersCreated = 0
+	// The maps are replaced, not cleared, as they are shared with the copy
+	// returned by GetAndReset.
+	s.PayloadSizes = make(map[string]*SizeHistogram)
+	s.PayloadRows = make(map[string]uint64)
+}
+
+// PayloadSize returns the histogram of the serialized size of the payloads of
+// the given type.
+func (s *ConsumerStats) PayloadSize(payloadType string) *SizeHistogram {
+	h, ok := s.PayloadSizes[payloadType]
+	if !ok {
+		h = NewSizeHistogram()
+		s.PayloadSizes[payloadType] = h
+	}
+	return h
+}
+
+// add adds the stats of other to s.
+func (s *ConsumerStats) add(other *ConsumerStats) {
+	s.BatchesConsumed += other.BatchesConsumed
+	s.StreamConsumersCreated += other.StreamConsumersCreated
+	for payloadType, h := range other.PayloadSizes {
+		s.PayloadSize(payloadType).Merge(h)
+	}
+	for payloadType, rows := range other.PayloadRows {
+		s.PayloadRows[payloadType] += rows
+	}
 }
 
 // Show prints the stats to the console.
@@ -93,6 +218,16 @@ func (s *ProducerStats) Show(indent string) {
 	fmt.Printf("%s- Stream producers closed: %d\n", indent, s.StreamProducersClosed)
 	fmt.Printf("%s- RecordBuilder:\n", indent)
 	s.RecordBuilderStats.Show(indent + "  ")
+	fmt.Printf("%s- Serialized sizes (bytes):\n", indent)
+	s.BatchSizes.Show(indent+"  ", "Batches")
+	payloadTypes := make([]string, 0, len(s.PayloadSizes))
+	for payloadType := range s.PayloadSizes {
+		payloadTypes = append(payloadTypes, payloadType)
+	}
+	sort.Strings(payloadTypes)
+	for _, payloadType := range payloadTypes {
+		s.PayloadSizes[payloadType].Show(indent+"  ", payloadType)
+	}
 }
 
 // Show prints the RecordBuilder stats to the console.
@@ -100,5 +235,9 @@ func (s *RecordBuilderStats) Show(indent string) {
 	fmt.Printf("%s- Schema updates performed: %d\n", indent, s.SchemaUpdatesPerformed)
 	fmt.Printf("%s- Dictionary index type changed: %d\n", indent, s.DictionaryIndexTypeChanged)
 	fmt.Printf("%s- Dictionary overflow detected: %d\n", indent, s.DictionaryOverflowDetected)
+	fmt.Printf("%s- Builder reservations: %d\n", indent, s.BuilderReservations)
+	fmt.Printf("%s- Builder reallocations: %d\n", indent, s.BuilderReallocations)
+	fmt.Printf("%s- Optional columns deactivated: %d\n", indent, s.OptionalColumnsDeactivated)
+	fmt.Printf("%s- Rollbacks: %d\n", indent, s.Rollbacks)
 	fmt.Printf("%s- Dictionary migration stats:\n", indent)
 }
@@ -26,6 +26,7 @@ package arrow
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 
 	"github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/axiomhq/hyperloglog"
@@ -130,13 +131,18 @@ func (t *TracesAnalyzer) Analyze(traces *TracesOptimized) {
 	t.ResourceSpansStats.UpdateWith(traces)
 }
 
-func (t *TracesAnalyzer) ShowStats(indent string) {
-	println()
-	print(carrow.Green)
-	fmt.Printf("%s%d ExportTraceServiceRequest processed\n", indent, t.TraceCount)
-	print(carrow.ColorReset)
-	indent += "  "
-	t.ResourceSpansStats.ShowStats(indent)
+// ShowSummary writes the number of requests analyzed so far.
+func (t *TracesAnalyzer) ShowSummary(w io.Writer, indent string) {
+	fmt.Fprintln(w)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%s%d ExportTraceServiceRequest processed\n", indent, t.TraceCount)
+	fmt.Fprint(w, carrow.ColorReset)
+}
+
+// ShowStats writes the summary followed by the detailed statistics.
+func (t *TracesAnalyzer) ShowStats(w io.Writer, indent string) {
+	t.ShowSummary(w, indent)
+	t.ResourceSpansStats.ShowStats(w, indent+"  ")
 }
 
 func (r *ResourceSpansStats) UpdateWith(traces *TracesOptimized) {
@@ -200,28 +206,28 @@ func (r *ResourceSpansStats) UpdateWith(traces *TracesOptimized) {
 	r.ScopeSpansStats.SpanStats.TimeIntervalStats.UpdateWithSpans(spans)
 }
 
-func (r *ResourceSpansStats) ShowStats(indent string) {
-	fmt.Printf("%s                                 |         Distribution per request        |\n", indent)
-	print(carrow.Green)
-	fmt.Printf("%sResourceSpans%s |    Total|Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s              |%9d|%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
+func (r *ResourceSpansStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s                                 |         Distribution per request        |\n", indent)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sResourceSpans%s |    Total|Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s              |%9d|%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
 		r.TotalCount, r.ResSpansIDsDistinct.Estimate(), r.Distribution.Min(), r.Distribution.Max(), r.Distribution.Mean(), r.Distribution.StdDev(), r.Distribution.ValueAtQuantile(50), r.Distribution.ValueAtQuantile(99),
 	)
 	indent += "  "
-	r.ResourceStats.ShowStats(indent)
-	r.ScopeSpansStats.ShowStats(indent)
-	r.SchemaUrlStats.ShowStats(indent)
+	r.ResourceStats.ShowStats(w, indent)
+	r.ScopeSpansStats.ShowStats(w, indent)
+	r.SchemaUrlStats.ShowStats(w, indent)
 }
 
-func (s *ScopeSpansStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sScopeSpans%s |Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s           |%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
+func (s *ScopeSpansStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sScopeSpans%s |Distinct|   Min|   Max|  Mean| Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s           |%8d|%6d|%6d|%6.1f|%6.1f|%6d|%6d|\n", indent,
 		s.ScopeSpansIDsDistinct.Estimate(), s.Distribution.Min(), s.Distribution.Max(), s.Distribution.Mean(), s.Distribution.StdDev(), s.Distribution.ValueAtQuantile(50), s.Distribution.ValueAtQuantile(99),
 	)
-	s.ScopeStats.ShowStats(indent + "  ")
-	s.SpanStats.ShowStats(indent + "  ")
-	s.SchemaUrlStats.ShowStats(indent + "  ")
+	s.ScopeStats.ShowStats(w, indent+"  ")
+	s.SpanStats.ShowStats(w, indent+"  ")
+	s.SchemaUrlStats.ShowStats(w, indent+"  ")
 }
 
 func NewSpanStats() *SpanStats {
@@ -244,29 +250,29 @@ func NewSpanStats() *SpanStats {
 	}
 }
 
-func (s *SpanStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sSpans%s |   Total|   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s      |%8d|%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
+func (s *SpanStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sSpans%s |   Total|   Min|   Max|  Mean|  Stdev|   P50|   P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s      |%8d|%6d|%6d|%6.1f|%7.1f|%6d|%6d|\n", indent,
 		s.TotalCount, s.Distribution.Min(), s.Distribution.Max(), s.Distribution.Mean(), s.Distribution.StdDev(), s.Distribution.ValueAtQuantile(50), s.Distribution.ValueAtQuantile(99),
 	)
 	indent += "  "
-	s.TimeIntervalStats.ShowStats(indent)
-	s.Name.ShowStats("Name", indent)
-	fmt.Printf("%s             |Distinct|   Total|%%Distinct|\n", indent)
-	fmt.Printf("%s%sSpanID%s       |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SpanID.Estimate(), s.TotalCount, 100.0*float64(s.SpanID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sTraceID%s      |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.TraceID.Estimate(), s.TotalCount, 100.0*float64(s.TraceID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sParentSpanID%s |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.ParentSpanID.Estimate(), s.TotalCount, 100.0*float64(s.ParentSpanID.Estimate())/float64(s.TotalCount))
-	fmt.Printf("%s%sKind%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.Kind.Estimate())
-	fmt.Printf("%s%sTraceState%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.TraceState.Estimate())
-
-	s.Attributes.ShowStats(indent, "Attributes", carrow.Green)
-	s.SharedAttributes.ShowStats(indent, "SharedAttributes", carrow.Cyan)
-	s.Events.ShowStats(indent)
-	fmt.Printf("%s%sDroppedEventsCount%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.DropEventsCount.Estimate())
-	s.Links.ShowStats(indent)
-	fmt.Printf("%s%sDroppedLinksCount%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.DropLinksCount.Estimate())
-	s.StatusStats.ShowStats(indent)
+	s.TimeIntervalStats.ShowStats(w, indent)
+	s.Name.ShowStats(w, "Name", indent)
+	fmt.Fprintf(w, "%s             |Distinct|   Total|%%Distinct|\n", indent)
+	fmt.Fprintf(w, "%s%sSpanID%s       |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.SpanID.Estimate(), s.TotalCount, 100.0*float64(s.SpanID.Estimate())/float64(s.TotalCount))
+	fmt.Fprintf(w, "%s%sTraceID%s      |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.TraceID.Estimate(), s.TotalCount, 100.0*float64(s.TraceID.Estimate())/float64(s.TotalCount))
+	fmt.Fprintf(w, "%s%sParentSpanID%s |%8d|%8d|%8.1f%%|\n", indent, carrow.Green, carrow.ColorReset, s.ParentSpanID.Estimate(), s.TotalCount, 100.0*float64(s.ParentSpanID.Estimate())/float64(s.TotalCount))
+	fmt.Fprintf(w, "%s%sKind%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.Kind.Estimate())
+	fmt.Fprintf(w, "%s%sTraceState%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.TraceState.Estimate())
+
+	s.Attributes.ShowStats(w, indent, "Attributes", carrow.Green)
+	s.SharedAttributes.ShowStats(w, indent, "SharedAttributes", carrow.Cyan)
+	s.Events.ShowStats(w, indent)
+	fmt.Fprintf(w, "%s%sDroppedEventsCount%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.DropEventsCount.Estimate())
+	s.Links.ShowStats(w, indent)
+	fmt.Fprintf(w, "%s%sDroppedLinksCount%s (Distinct=%d)\n", indent, carrow.Green, carrow.ColorReset, s.DropLinksCount.Estimate())
+	s.StatusStats.ShowStats(w, indent)
 }
 
 func NewEventStats() *EventStats {
@@ -298,18 +304,18 @@ func (e *EventStats) UpdateWith(events ptrace.SpanEventSlice, dac uint32) {
 	}
 }
 
-func (e *EventStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sEvents%s |  Count|Missing|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s       |%7d|%7d|%7d|%7d|%7.1f|%7.1f|%7d|%7d|\n", indent,
+func (e *EventStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sEvents%s |  Count|Missing|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s       |%7d|%7d|%7d|%7d|%7.1f|%7.1f|%7d|%7d|\n", indent,
 		e.TotalCount, e.Missing, e.Distribution.Min(), e.Distribution.Max(), e.Distribution.Mean(), e.Distribution.StdDev(), e.Distribution.ValueAtQuantile(50), e.Distribution.ValueAtQuantile(99),
 	)
 
 	indent += "  "
 
-	e.Timestamp.ShowStats("Timestamp", indent)
-	e.Name.ShowStats("Name", indent)
-	e.Attributes.ShowStats(indent, "Attributes", carrow.Green)
+	e.Timestamp.ShowStats(w, "Timestamp", indent)
+	e.Name.ShowStats(w, "Name", indent)
+	e.Attributes.ShowStats(w, indent, "Attributes", carrow.Green)
 }
 
 func NewLinkStats() *LinkStats {
@@ -334,22 +340,22 @@ func (l *LinkStats) UpdateWith(links ptrace.SpanLinkSlice, dac uint32) {
 	}
 }
 
-func (l *LinkStats) ShowStats(indent string) {
-	print(carrow.Green)
-	fmt.Printf("%sLinks%s |  Count|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, carrow.ColorReset)
-	fmt.Printf("%s      |%7d|%7d|%7d|%7.1f|%7.1f|%7d|%7d|\n", indent,
+func (l *LinkStats) ShowStats(w io.Writer, indent string) {
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sLinks%s |  Count|    Min|    Max|   Mean|  Stdev|    P50|    P99|\n", indent, carrow.ColorReset)
+	fmt.Fprintf(w, "%s      |%7d|%7d|%7d|%7.1f|%7.1f|%7d|%7d|\n", indent,
 		l.TotalCount, l.Distribution.Min(), l.Distribution.Max(), l.Distribution.Mean(), l.Distribution.StdDev(), l.Distribution.ValueAtQuantile(50), l.Distribution.ValueAtQuantile(99),
 	)
 
 	indent += "  "
 
-	fmt.Printf("%s           |Distinct|   Total|%%Distinct|\n", indent)
-	print(carrow.Green)
-	fmt.Printf("%sTraceID%s    |%8d|%8d|%8.1f%%|\n", indent, carrow.ColorReset, l.TraceID.Estimate(), l.TotalCount, 100.0*float64(l.TraceID.Estimate())/float64(l.TotalCount))
-	print(carrow.Green)
-	fmt.Printf("%sSpanID%s     |%8d|%8d|%8.1f%%|\n", indent, carrow.ColorReset, l.SpanID.Estimate(), l.TotalCount, 100.0*float64(l.SpanID.Estimate())/float64(l.TotalCount))
-	print(carrow.Green)
-	fmt.Printf("%sTraceState%s |%8d|%8d|%8.1f%%|\n", indent, carrow.ColorReset, l.TraceState.Estimate(), l.TotalCount, 100.0*float64(l.TraceState.Estimate())/float64(l.TotalCount))
+	fmt.Fprintf(w, "%s           |Distinct|   Total|%%Distinct|\n", indent)
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sTraceID%s    |%8d|%8d|%8.1f%%|\n", indent, carrow.ColorReset, l.TraceID.Estimate(), l.TotalCount, 100.0*float64(l.TraceID.Estimate())/float64(l.TotalCount))
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sSpanID%s     |%8d|%8d|%8.1f%%|\n", indent, carrow.ColorReset, l.SpanID.Estimate(), l.TotalCount, 100.0*float64(l.SpanID.Estimate())/float64(l.TotalCount))
+	fmt.Fprint(w, carrow.Green)
+	fmt.Fprintf(w, "%sTraceState%s |%8d|%8d|%8.1f%%|\n", indent, carrow.ColorReset, l.TraceState.Estimate(), l.TotalCount, 100.0*float64(l.TraceState.Estimate())/float64(l.TotalCount))
 
-	l.Attributes.ShowStats(indent, "Attributes", carrow.Green)
+	l.Attributes.ShowStats(w, indent, "Attributes", carrow.Green)
 }
@@ -49,7 +49,7 @@ var (
 		{Name: constants.TimeUnixNano, Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
 		{Name: constants.Name, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8)},
 		{Name: constants.DroppedAttributesCount, Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
-	}, nil)
+	}, schema.SortOrder(constants.Name, constants.ParentID))
 )
 
 type (
@@ -87,6 +87,10 @@ type (
 		groupCount uint16
 		events     []*Event
 		sorter     EventSorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointGroupCount uint16
+		checkpointLen        int
 	}
 
 	EventSorter interface {
@@ -224,6 +228,14 @@ func (b *EventBuilder) Reset() {
 	b.accumulator.Reset()
 }
 
+func (b *EventBuilder) Checkpoint() {
+	b.accumulator.Checkpoint()
+}
+
+func (b *EventBuilder) Rollback() {
+	b.accumulator.Rollback()
+}
+
 func (b *EventBuilder) PayloadType() *acommon.PayloadType {
 	return acommon.PayloadTypes.Event
 }
@@ -279,6 +291,21 @@ func (a *EventAccumulator) Append(spanID uint16, events ptrace.SpanEventSlice) e
 func (a *EventAccumulator) Reset() {
 	a.groupCount = 0
 	a.events = a.events[:0]
+	a.checkpointGroupCount = 0
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *EventAccumulator) Checkpoint() {
+	a.checkpointGroupCount = a.groupCount
+	a.checkpointLen = len(a.events)
+}
+
+// Rollback discards the events appended since the last checkpoint.
+func (a *EventAccumulator) Rollback() {
+	a.groupCount = a.checkpointGroupCount
+	a.events = a.events[:a.checkpointLen]
 }
 
 // No sorting
@@ -51,7 +51,7 @@ var (
 		{Name: constants.SpanId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 8}, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
 		{Name: constants.TraceState, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
 		{Name: constants.DroppedAttributesCount, Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
-	}, nil)
+	}, schema.SortOrder(constants.TraceId, constants.ParentID))
 )
 
 type (
@@ -91,6 +91,10 @@ type (
 		groupCount uint16
 		links      []*Link
 		sorter     LinkSorter
+
+		// The state restored by Rollback (see Checkpoint).
+		checkpointGroupCount uint16
+		checkpointLen        int
 	}
 
 	LinkSorter interface {
@@ -151,6 +155,14 @@ func (b *LinkBuilder) Reset() {
 	b.accumulator.Reset()
 }
 
+func (b *LinkBuilder) Checkpoint() {
+	b.accumulator.Checkpoint()
+}
+
+func (b *LinkBuilder) Rollback() {
+	b.accumulator.Rollback()
+}
+
 func (b *LinkBuilder) PayloadType() *acommon.PayloadType {
 	return acommon.PayloadTypes.Link
 }
@@ -283,6 +295,21 @@ func (a *LinkAccumulator) Append(spanID uint16, links ptrace.SpanLinkSlice) erro
 func (a *LinkAccumulator) Reset() {
 	a.groupCount = 0
 	a.links = a.links[:0]
+	a.checkpointGroupCount = 0
+	a.checkpointLen = 0
+}
+
+// Checkpoint marks the current state of the accumulator as the state
+// restored by the next call to Rollback.
+func (a *LinkAccumulator) Checkpoint() {
+	a.checkpointGroupCount = a.groupCount
+	a.checkpointLen = len(a.links)
+}
+
+// Rollback discards the links appended since the last checkpoint.
+func (a *LinkAccumulator) Rollback() {
+	a.groupCount = a.checkpointGroupCount
+	a.links = a.links[:a.checkpointLen]
 }
 
 // No sorting
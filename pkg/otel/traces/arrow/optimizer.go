@@ -32,6 +32,10 @@ import (
 type (
 	TracesOptimizer struct {
 		sorter SpanSorter
+
+		// emptyPerRecord keeps the empty resources and scopes of the
+		// resource and scope spans separate (see config.EmptyPerRecord).
+		emptyPerRecord bool
 	}
 
 	TracesOptimized struct {
@@ -66,9 +70,10 @@ type (
 	SpansByResourceSpanIdScopeSpanIdNameTraceIdStartTimestamp struct{}
 )
 
-func NewTracesOptimizer(sorter SpanSorter) *TracesOptimizer {
+func NewTracesOptimizer(sorter SpanSorter, emptyPerRecord bool) *TracesOptimizer {
 	return &TracesOptimizer{
-		sorter: sorter,
+		sorter:         sorter,
+		emptyPerRecord: emptyPerRecord,
 	}
 }
 
@@ -82,14 +87,14 @@ func (t *TracesOptimizer) Optimize(traces ptrace.Traces) *TracesOptimized {
 		resSpan := resSpans.At(i)
 		resource := resSpan.Resource()
 		resourceSchemaUrl := resSpan.SchemaUrl()
-		resSpanID := otlp.ResourceID(resource, resourceSchemaUrl)
+		resSpanID := otlp.ResourceEntryID(resource, resourceSchemaUrl, t.emptyPerRecord, i)
 
 		scopeSpans := resSpan.ScopeSpans()
 		for j := 0; j < scopeSpans.Len(); j++ {
 			scopeSpan := scopeSpans.At(j)
 			scope := scopeSpan.Scope()
 			scopeSchemaUrl := scopeSpan.SchemaUrl()
-			scopeSpanId := otlp.ScopeID(scope, scopeSchemaUrl)
+			scopeSpanId := otlp.ScopeEntryID(scope, scopeSchemaUrl, t.emptyPerRecord, i, j)
 
 			spans := scopeSpan.Spans()
 			for k := 0; k < spans.Len(); k++ {
@@ -35,9 +35,10 @@ type (
 
 		relatedRecordsManager *carrow.RelatedRecordsManager
 
-		attrsBuilders *AttrsBuilders
-		eventBuilder  *EventBuilder
-		linkBuilder   *LinkBuilder
+		attrsBuilders     *AttrsBuilders
+		scopeTableBuilder *carrow.ScopeTableBuilder
+		eventBuilder      *EventBuilder
+		linkBuilder       *LinkBuilder
 	}
 
 	// AttrsBuilders groups together AttrsBuilder instances used to build related
@@ -63,6 +64,12 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 		return carrow.NewAttrs16BuilderWithEncoding(b, carrow.PayloadTypes.ScopeAttrs, cfg.Attrs.Scope)
 	})
 
+	scopeTableBuilder := rrManager.Declare(carrow.PayloadTypes.Scopes, carrow.PayloadTypes.Spans, carrow.ScopeTableSchema, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
+		sb := carrow.NewScopeTableBuilder(b)
+		sb.SetAttributesAccumulator(attrsScopeBuilder.(*carrow.Attrs16Builder).Accumulator())
+		return sb
+	})
+
 	attrsSpanBuilder := rrManager.Declare(carrow.PayloadTypes.SpanAttrs, carrow.PayloadTypes.Spans, carrow.AttrsSchema16, func(b *builder.RecordBuilderExt) carrow.RelatedRecordBuilder {
 		return carrow.NewAttrs16BuilderWithEncoding(b, carrow.PayloadTypes.SpanAttrs, cfg.Attrs.Span)
 	})
@@ -96,8 +103,9 @@ func NewRelatedData(cfg *Config, stats *stats.ProducerStats) (*RelatedData, erro
 			event:    attrsEventBuilder.(*carrow.Attrs32Builder),
 			link:     attrsLinkBuilder.(*carrow.Attrs32Builder),
 		},
-		eventBuilder: eventBuilder.(*EventBuilder),
-		linkBuilder:  linkBuilder.(*LinkBuilder),
+		scopeTableBuilder: scopeTableBuilder.(*carrow.ScopeTableBuilder),
+		eventBuilder:      eventBuilder.(*EventBuilder),
+		linkBuilder:       linkBuilder.(*LinkBuilder),
 	}, nil
 }
 
@@ -113,6 +121,10 @@ func (r *RelatedData) AttrsBuilders() *AttrsBuilders {
 	return r.attrsBuilders
 }
 
+func (r *RelatedData) ScopeTableBuilder() *carrow.ScopeTableBuilder {
+	return r.scopeTableBuilder
+}
+
 func (r *RelatedData) EventBuilder() *EventBuilder {
 	return r.eventBuilder
 }
@@ -130,6 +142,17 @@ func (r *RelatedData) Reset() {
 	r.relatedRecordsManager.Reset()
 }
 
+// Checkpoint marks the current state of the related data as the state restored
+// by the next call to Rollback.
+func (r *RelatedData) Checkpoint() {
+	r.relatedRecordsManager.Checkpoint()
+}
+
+// Rollback discards the related data appended since the last checkpoint.
+func (r *RelatedData) Rollback() {
+	r.relatedRecordsManager.Rollback()
+}
+
 func (r *RelatedData) SpanCount() uint16 {
 	return uint16(r.spanCount)
 }
@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arrow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	v1 "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/stats"
+)
+
+// A batch failing in the middle of a span must not leave rows or related data
+// (attributes, events, links) behind, neither in the batch built with the rows
+// appended before it nor in the next batches.
+func TestTracesRollback(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	fresh := newRollbackTracesBuilder(t, pool)
+	defer fresh.Release()
+	expected := appendAndBuildTraces(t, fresh, Traces())
+
+	tb := newRollbackTracesBuilder(t, pool)
+	defer tb.Release()
+	// Activates the optional columns of the schema.
+	appendAndBuildTraces(t, tb, Traces())
+
+	tb.RelatedData().Reset()
+	require.NoError(t, tb.Append(Traces()))
+
+	// The status is appended after the resource, the scope, the attributes,
+	// the events and the links of the first span of the batch.
+	tb.sb.released = true
+	require.Error(t, tb.Append(Traces()))
+
+	// The rows appended before the failed batch are preserved.
+	actual, err := buildTraces(tb)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	// The next batch is not mixed with the rows of the failed batch.
+	assert.Equal(t, expected, appendAndBuildTraces(t, tb, Traces()))
+}
+
+func newRollbackTracesBuilder(t *testing.T, pool memory.Allocator) *TracesBuilder {
+	rBuilder := builder.NewRecordBuilderExt(pool, TracesSchema, DefaultDictConfig, stats.NewProducerStats())
+	tb, err := NewTracesBuilder(rBuilder, DefaultConfig(), stats.NewProducerStats())
+	require.NoError(t, err)
+	return tb
+}
+
+// appendAndBuildTraces appends the traces to the builder until the schema is
+// up to date and returns the JSON representation of the records built.
+func appendAndBuildTraces(t *testing.T, tb *TracesBuilder, traces ptrace.Traces) map[v1.ArrowPayloadType]string {
+	for {
+		tb.RelatedData().Reset()
+		require.NoError(t, tb.Append(traces))
+
+		records, err := buildTraces(tb)
+		if errors.Is(err, schema.ErrSchemaNotUpToDate) {
+			continue
+		}
+		require.NoError(t, err)
+		return records
+	}
+}
+
+// buildTraces builds the main and related records and returns their JSON
+// representation by payload type.
+func buildTraces(tb *TracesBuilder) (map[v1.ArrowPayloadType]string, error) {
+	record, err := tb.Build()
+	if err != nil {
+		return nil, err
+	}
+	defer record.Release()
+
+	relatedRecords, err := tb.RelatedData().BuildRecordMessages()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[v1.ArrowPayloadType]string)
+	json, err := record.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	records[v1.ArrowPayloadType_SPANS] = string(json)
+
+	for _, relatedRecord := range relatedRecords {
+		json, err := relatedRecord.Record().MarshalJSON()
+		relatedRecord.Record().Release()
+		if err != nil {
+			return nil, err
+		}
+		records[relatedRecord.PayloadType()] = string(json)
+	}
+	return records, nil
+}
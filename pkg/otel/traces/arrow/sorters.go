@@ -0,0 +1,98 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package arrow
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+// Names of the built-in span sorters (see RegisterSpanSorter). All of them
+// sort the spans by resource and scope first.
+const (
+	UnsortedSpanSorter               = "unsorted"
+	ByStartTimeTraceIDNameSpanSorter = "resource_scope_start_time_trace_id_name"
+	ByStartTimeNameTraceIDSpanSorter = "resource_scope_start_time_name_trace_id"
+	ByNameStartTimeSpanSorter        = "resource_scope_name_start_time"
+	ByNameTraceIDSpanSorter          = "resource_scope_name_trace_id"
+	ByTraceIDNameSpanSorter          = "resource_scope_trace_id_name"
+	ByNameTraceIDStartTimeSpanSorter = "resource_scope_name_trace_id_start_time"
+	DefaultSpanSorter                = ByNameTraceIDSpanSorter
+)
+
+var spanSorters = arrow.NewSorterRegistry[SpanSorter]("span")
+
+func init() {
+	RegisterSpanSorter(UnsortedSpanSorter, func() SpanSorter { return UnsortedSpans() })
+	RegisterSpanSorter(ByStartTimeTraceIDNameSpanSorter, func() SpanSorter { return SortSpansByResourceSpanIdScopeSpanIdStartTimestampTraceIdName() })
+	RegisterSpanSorter(ByStartTimeNameTraceIDSpanSorter, func() SpanSorter { return SortSpansByResourceSpanIdScopeSpanIdStartTimestampNameTraceId() })
+	RegisterSpanSorter(ByNameStartTimeSpanSorter, func() SpanSorter { return SortSpansByResourceSpanIdScopeSpanIdNameStartTimestamp() })
+	RegisterSpanSorter(ByNameTraceIDSpanSorter, func() SpanSorter { return SortSpansByResourceSpanIdScopeSpanIdNameTraceId() })
+	RegisterSpanSorter(ByTraceIDNameSpanSorter, func() SpanSorter { return SortSpansByResourceSpanIdScopeSpanIdTraceIdName() })
+	RegisterSpanSorter(ByNameTraceIDStartTimeSpanSorter, func() SpanSorter { return SortSpansByResourceSpanIdScopeSpanIdNameTraceIdStartTimestamp() })
+}
+
+// RegisterSpanSorter registers a custom sort order of the spans under the
+// given name, which can then be selected with config.WithSpanSorter. A sorter
+// registered with the name of a built-in sorter replaces it.
+func RegisterSpanSorter(name string, newSorter func() SpanSorter) {
+	spanSorters.Register(name, newSorter)
+}
+
+// NewSpanSorter creates the span sorter registered under the given name.
+func NewSpanSorter(name string) (SpanSorter, error) {
+	return spanSorters.New(name)
+}
+
+// SpanSorterNames returns the names of the registered span sorters.
+func SpanSorterNames() []string {
+	return spanSorters.Names()
+}
+
+// spanSortKeys are the sort keys supported by SortSpansByKeys.
+var spanSortKeys = map[string]arrow.Comparator[*FlattenedSpan]{
+	"resource": func(a, b *FlattenedSpan) int { return strings.Compare(a.ResourceSpanID, b.ResourceSpanID) },
+	"scope":    func(a, b *FlattenedSpan) int { return strings.Compare(a.ScopeSpanID, b.ScopeSpanID) },
+	"trace_id": func(a, b *FlattenedSpan) int {
+		traceA, traceB := a.Span.TraceID(), b.Span.TraceID()
+		return bytes.Compare(traceA[:], traceB[:])
+	},
+	"span_id": func(a, b *FlattenedSpan) int {
+		spanA, spanB := a.Span.SpanID(), b.Span.SpanID()
+		return bytes.Compare(spanA[:], spanB[:])
+	},
+	"name": func(a, b *FlattenedSpan) int { return strings.Compare(a.Span.Name(), b.Span.Name()) },
+	"kind": func(a, b *FlattenedSpan) int { return int(a.Span.Kind()) - int(b.Span.Kind()) },
+	"start_time": func(a, b *FlattenedSpan) int {
+		return arrow.CompareUint64(uint64(a.Span.StartTimestamp()), uint64(b.Span.StartTimestamp()))
+	},
+}
+
+// SortSpansByKeys creates a sorter of the spans by the given sort keys, the
+// first key being the most significant, among "resource", "scope",
+// "trace_id", "span_id", "name", "kind" and "start_time". The default
+// sorter is equivalent to the keys resource, scope, name, trace_id.
+func SortSpansByKeys(keys ...string) (SpanSorter, error) {
+	sorter, err := arrow.NewKeySorter(keys, spanSortKeys)
+	if err != nil {
+		return nil, werror.Wrap(err)
+	}
+	return sorter, nil
+}
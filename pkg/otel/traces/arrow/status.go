@@ -18,6 +18,8 @@
 package arrow
 
 import (
+	"math"
+
 	"github.com/apache/arrow/go/v12/arrow"
 	"github.com/apache/arrow/go/v12/arrow/array"
 	"go.opentelemetry.io/collector/pdata/ptrace"
@@ -32,7 +34,7 @@ import (
 // StatusDT is the Arrow Data Type describing a span status.
 var (
 	StatusDT = arrow.StructOf([]arrow.Field{
-		{Name: constants.StatusCode, Type: arrow.PrimitiveTypes.Int32, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
+		{Name: constants.StatusCode, Type: arrow.PrimitiveTypes.Uint8, Nullable: true},
 		{Name: constants.StatusMessage, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
 	}...)
 )
@@ -42,7 +44,7 @@ type StatusBuilder struct {
 
 	builder *builder.StructBuilder // `status` builder
 
-	scb *builder.Int32Builder  // status `code` builder
+	scb *builder.Uint8Builder  // status `code` builder
 	smb *builder.StringBuilder // status `message` builder
 }
 
@@ -50,7 +52,7 @@ func StatusBuilderFrom(sb *builder.StructBuilder) *StatusBuilder {
 	return &StatusBuilder{
 		released: false,
 		builder:  sb,
-		scb:      sb.Int32Builder(constants.StatusCode),
+		scb:      sb.Uint8Builder(constants.StatusCode),
 		smb:      sb.StringBuilder(constants.StatusMessage),
 	}
 }
@@ -62,7 +64,7 @@ func (b *StatusBuilder) Append(status ptrace.Status) error {
 	}
 
 	return b.builder.Append(status, func() error {
-		b.scb.AppendNonZero(int32(status.Code()))
+		b.scb.AppendNonZero(enumToU8(int32(status.Code())))
 		b.smb.AppendNonEmpty(status.Message())
 		return nil
 	})
@@ -89,3 +91,13 @@ func (b *StatusBuilder) Release() {
 		b.released = true
 	}
 }
+
+// enumToU8 converts a span kind or a status code to its uint8 encoding. An out
+// of range value is encoded as math.MaxUint8, rejected by the decoder, instead
+// of being truncated to a possibly valid value.
+func enumToU8(value int32) uint8 {
+	if value < 0 || value > math.MaxUint8 {
+		return math.MaxUint8
+	}
+	return uint8(value)
+}
@@ -18,9 +18,13 @@
 package arrow
 
 import (
+	"encoding/binary"
+	"time"
+
 	"github.com/apache/arrow/go/v12/arrow"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 
+	"github.com/f5/otel-arrow-adapter/pkg/config"
 	acommon "github.com/f5/otel-arrow-adapter/pkg/otel/common/arrow"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema"
 	"github.com/f5/otel-arrow-adapter/pkg/otel/common/schema/builder"
@@ -35,21 +39,32 @@ var (
 		{Name: constants.ID, Type: arrow.PrimitiveTypes.Uint16, Metadata: schema.Metadata(schema.DeltaEncoding), Nullable: true},
 		{Name: constants.Resource, Type: acommon.ResourceDT, Nullable: true},
 		{Name: constants.Scope, Type: acommon.ScopeDT, Nullable: true},
+		// ID of the scope in the scope table, replacing the scope struct (see
+		// config.WithScopeTable).
+		{Name: constants.ScopeID, Type: arrow.PrimitiveTypes.Uint16, Nullable: true},
 		// This schema URL applies to the span and span events (the schema URL
 		// for the resource is in the resource struct).
 		{Name: constants.SchemaUrl, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
 		{Name: constants.StartTimeUnixNano, Type: arrow.FixedWidthTypes.Timestamp_ns},
 		{Name: constants.DurationTimeUnixNano, Type: arrow.FixedWidthTypes.Duration_ms, Metadata: schema.Metadata(schema.Dictionary8)},
-		{Name: constants.TraceId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}},
-		{Name: constants.SpanId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 8}},
+		{Name: constants.TraceId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 16}, Metadata: schema.Metadata(schema.Optional)},
+		{Name: constants.SpanId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 8}, Metadata: schema.Metadata(schema.Optional)},
 		{Name: constants.TraceState, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
 		{Name: constants.ParentSpanId, Type: &arrow.FixedSizeBinaryType{ByteWidth: 8}, Nullable: true},
 		{Name: constants.Name, Type: arrow.BinaryTypes.String, Metadata: schema.Metadata(schema.Dictionary8)},
-		{Name: constants.KIND, Type: arrow.PrimitiveTypes.Int32, Metadata: schema.Metadata(schema.Dictionary8), Nullable: true},
+		{Name: constants.KIND, Type: arrow.PrimitiveTypes.Uint8, Nullable: true},
 		{Name: constants.DroppedAttributesCount, Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
 		{Name: constants.DroppedEventsCount, Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
 		{Name: constants.DroppedLinksCount, Type: arrow.PrimitiveTypes.Uint32, Nullable: true},
 		{Name: constants.Status, Type: StatusDT, Nullable: true},
+		// Experimental encoding of the IDs (see config.WithExperimentalUint64IDs),
+		// replacing the trace_id, span_id and parent_span_id columns.
+		{Name: constants.TraceIdHigh, Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+		{Name: constants.TraceIdLow, Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+		{Name: constants.SpanIdUint64, Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+		{Name: constants.ParentSpanIdUint64, Type: arrow.PrimitiveTypes.Uint64, Nullable: true},
+		// Expiry of the spans (see config.WithRetention).
+		{Name: constants.ExpiryTimeUnixNano, Type: arrow.FixedWidthTypes.Timestamp_ns, Nullable: true},
 	}, nil)
 )
 
@@ -61,6 +76,7 @@ type TracesBuilder struct {
 
 	rb    *acommon.ResourceBuilder        // `resource` builder
 	scb   *acommon.ScopeBuilder           // `scope` builder
+	scidb *builder.Uint16Builder          // scope id builder (scope table)
 	sschb *builder.StringBuilder          // scope `schema_url` builder
 	ib    *builder.Uint16DeltaBuilder     //  id builder
 	stunb *builder.TimestampBuilder       // start time unix nano builder
@@ -70,14 +86,33 @@ type TracesBuilder struct {
 	tsb   *builder.StringBuilder          // trace state builder
 	psib  *builder.FixedSizeBinaryBuilder // parent span id builder
 	nb    *builder.StringBuilder          // name builder
-	kb    *builder.Int32Builder           // kind builder
+	kb    *builder.Uint8Builder           // kind builder
 	dacb  *builder.Uint32Builder          // dropped attributes count builder
 	decb  *builder.Uint32Builder          // dropped events count builder
 	dlcb  *builder.Uint32Builder          // dropped links count builder
 	sb    *StatusBuilder                  // status builder
+	tihb  *builder.Uint64Builder          // trace id high builder (uint64 IDs)
+	tilb  *builder.Uint64Builder          // trace id low builder (uint64 IDs)
+	siub  *builder.Uint64Builder          // span id builder (uint64 IDs)
+	psiub *builder.Uint64Builder          // parent span id builder (uint64 IDs)
+	expb  *builder.TimestampBuilder       // expiry time unix nano builder
 
 	optimizer *TracesOptimizer
 	analyzer  *TracesAnalyzer
+	reporter  *acommon.AnalyzerReporter
+
+	// emptyPerRecord reserves an attributes ID for each resource and scope
+	// entry without attributes (see config.EmptyPerRecord).
+	emptyPerRecord bool
+	// uint64IDs encodes the IDs as uint64 columns (see
+	// config.WithExperimentalUint64IDs).
+	uint64IDs bool
+	// scopeTable references the scopes by their ID in the scope table (see
+	// config.WithScopeTable).
+	scopeTable bool
+	// retention is the retention stamped in the expiry column, 0 if the
+	// column is disabled (see config.WithRetention).
+	retention time.Duration
 
 	relatedData *RelatedData
 }
@@ -90,6 +125,9 @@ func NewTracesBuilder(
 ) (*TracesBuilder, error) {
 	var optimizer *TracesOptimizer
 	var analyzer *TracesAnalyzer
+	var reporter *acommon.AnalyzerReporter
+
+	emptyPerRecord := cfg.Global != nil && cfg.Global.EmptyResourceScope == config.EmptyPerRecord
 
 	relatedData, err := NewRelatedData(cfg, stats)
 	if err != nil {
@@ -97,18 +135,29 @@ func NewTracesBuilder(
 	}
 
 	if stats.SchemaStatsEnabled {
-		optimizer = NewTracesOptimizer(cfg.Span.Sorter)
+		optimizer = NewTracesOptimizer(cfg.Span.Sorter, emptyPerRecord)
 		analyzer = NewTraceAnalyzer()
+		reporter, err = acommon.NewAnalyzerReporter(cfg.Global)
+		if err != nil {
+			return nil, werror.Wrap(err)
+		}
 	} else {
-		optimizer = NewTracesOptimizer(cfg.Span.Sorter)
+		optimizer = NewTracesOptimizer(cfg.Span.Sorter, emptyPerRecord)
 	}
 
 	b := &TracesBuilder{
-		released:    false,
-		builder:     rBuilder,
-		optimizer:   optimizer,
-		analyzer:    analyzer,
-		relatedData: relatedData,
+		released:       false,
+		builder:        rBuilder,
+		optimizer:      optimizer,
+		analyzer:       analyzer,
+		reporter:       reporter,
+		emptyPerRecord: emptyPerRecord,
+		uint64IDs:      cfg.Global != nil && cfg.Global.Uint64IDs,
+		scopeTable:     cfg.Global != nil && cfg.Global.ScopeTable,
+		relatedData:    relatedData,
+	}
+	if cfg.Global != nil {
+		b.retention = cfg.Global.Retention.Traces
 	}
 
 	if err := b.init(); err != nil {
@@ -127,6 +176,7 @@ func (b *TracesBuilder) init() error {
 	b.ib = ib
 	b.rb = acommon.ResourceBuilderFrom(b.builder.StructBuilder(constants.Resource))
 	b.scb = acommon.ScopeBuilderFrom(b.builder.StructBuilder(constants.Scope))
+	b.scidb = b.builder.Uint16Builder(constants.ScopeID)
 	b.sschb = b.builder.StringBuilder(constants.SchemaUrl)
 
 	b.stunb = b.builder.TimestampBuilder(constants.StartTimeUnixNano)
@@ -136,11 +186,16 @@ func (b *TracesBuilder) init() error {
 	b.tsb = b.builder.StringBuilder(constants.TraceState)
 	b.psib = b.builder.FixedSizeBinaryBuilder(constants.ParentSpanId)
 	b.nb = b.builder.StringBuilder(constants.Name)
-	b.kb = b.builder.Int32Builder(constants.KIND)
+	b.kb = b.builder.Uint8Builder(constants.KIND)
 	b.dacb = b.builder.Uint32Builder(constants.DroppedAttributesCount)
 	b.decb = b.builder.Uint32Builder(constants.DroppedEventsCount)
 	b.dlcb = b.builder.Uint32Builder(constants.DroppedLinksCount)
 	b.sb = StatusBuilderFrom(b.builder.StructBuilder(constants.Status))
+	b.tihb = b.builder.Uint64Builder(constants.TraceIdHigh)
+	b.tilb = b.builder.Uint64Builder(constants.TraceIdLow)
+	b.siub = b.builder.Uint64Builder(constants.SpanIdUint64)
+	b.psiub = b.builder.Uint64Builder(constants.ParentSpanIdUint64)
+	b.expb = b.builder.TimestampBuilder(constants.ExpiryTimeUnixNano)
 
 	return nil
 }
@@ -149,6 +204,12 @@ func (b *TracesBuilder) RelatedData() *RelatedData {
 	return b.relatedData
 }
 
+// Analyzer returns the analyzer of the builder, nil if the schema stats are
+// disabled (see config.WithStats).
+func (b *TracesBuilder) Analyzer() *TracesAnalyzer {
+	return b.analyzer
+}
+
 // Build builds an Arrow Record from the builder.
 //
 // Once the array is no longer needed, Release() must be called to free the
@@ -172,27 +233,61 @@ func (b *TracesBuilder) Build() (record arrow.Record, err error) {
 	return
 }
 
+// checkpoint marks the beginning of a batch, i.e. the state restored by
+// rollback.
+func (b *TracesBuilder) checkpoint() {
+	b.builder.Checkpoint()
+	b.relatedData.Checkpoint()
+}
+
+// rollback discards the rows and the related data appended since the
+// beginning of the current batch and retrieves the new underlying builders.
+func (b *TracesBuilder) rollback() error {
+	b.builder.Rollback()
+	b.relatedData.Rollback()
+	if err := b.init(); err != nil {
+		return werror.Wrap(err)
+	}
+	return nil
+}
+
 // Append appends a new set of resource spans to the builder.
-func (b *TracesBuilder) Append(traces ptrace.Traces) error {
+func (b *TracesBuilder) Append(traces ptrace.Traces) (err error) {
 	if b.released {
 		return werror.Wrap(acommon.ErrBuilderAlreadyReleased)
 	}
 
+	// The rows of a batch that fails to append are discarded, so that they
+	// are not mixed with the rows of the next batch.
+	b.checkpoint()
+	defer func() {
+		if err != nil {
+			if rollbackErr := b.rollback(); rollbackErr != nil {
+				err = werror.WrapWithContext(err, map[string]interface{}{"rollback_error": rollbackErr.Error()})
+			}
+		}
+	}()
+
 	optimTraces := b.optimizer.Optimize(traces)
-	if b.analyzer != nil {
+	if b.analyzer != nil && b.reporter.Sample() {
 		b.analyzer.Analyze(optimTraces)
-		b.analyzer.ShowStats("")
+		b.reporter.Report(b.analyzer)
 	}
 
 	spanID := uint16(0)
 	var resSpanID, scopeSpanID string
 	var resID, scopeID int64
-	var err error
+	var scopeTableID uint16
 
 	attrsAccu := b.relatedData.AttrsBuilders().Span().Accumulator()
 	eventsAccu := b.relatedData.EventBuilder().Accumulator()
 	linksAccu := b.relatedData.LinkBuilder().Accumulator()
 
+	var expiry arrow.Timestamp
+	if b.retention > 0 {
+		expiry = arrow.Timestamp(time.Now().Add(b.retention).UnixNano())
+	}
+
 	b.builder.Reserve(len(optimTraces.Spans))
 
 	for _, span := range optimTraces.Spans {
@@ -217,36 +312,63 @@ func (b *TracesBuilder) Append(traces ptrace.Traces) error {
 			if err != nil {
 				return werror.Wrap(err)
 			}
+			if resID < 0 && b.emptyPerRecord {
+				resID = b.relatedData.AttrsBuilders().Resource().Accumulator().AppendEmpty()
+			}
 		}
 		if err = b.rb.AppendWithID(resID, span.Resource, span.ResourceSchemaUrl); err != nil {
 			return werror.Wrap(err)
 		}
 
 		// Scope spans
-		if scopeSpanID != span.ScopeSpanID {
-			scopeSpanID = span.ScopeSpanID
-			scopeID, err = b.relatedData.AttrsBuilders().scope.Accumulator().Append(span.Scope.Attributes())
-			if err != nil {
+		if b.scopeTable {
+			if scopeSpanID != span.ScopeSpanID {
+				scopeSpanID = span.ScopeSpanID
+				scopeTableID, err = b.relatedData.ScopeTableBuilder().Accumulator().Append(span.ScopeSpanID, span.Scope)
+				if err != nil {
+					return werror.Wrap(err)
+				}
+			}
+			b.scidb.Append(scopeTableID)
+		} else {
+			if scopeSpanID != span.ScopeSpanID {
+				scopeSpanID = span.ScopeSpanID
+				scopeID, err = b.relatedData.AttrsBuilders().scope.Accumulator().Append(span.Scope.Attributes())
+				if err != nil {
+					return werror.Wrap(err)
+				}
+				if scopeID < 0 && b.emptyPerRecord {
+					scopeID = b.relatedData.AttrsBuilders().scope.Accumulator().AppendEmpty()
+				}
+			}
+			if err = b.scb.AppendWithAttrsID(scopeID, span.Scope); err != nil {
 				return werror.Wrap(err)
 			}
 		}
-		if err = b.scb.AppendWithAttrsID(scopeID, span.Scope); err != nil {
-			return werror.Wrap(err)
-		}
 		b.sschb.AppendNonEmpty(span.ScopeSchemaUrl)
 
 		b.stunb.Append(arrow.Timestamp(span.Span.StartTimestamp()))
 		duration := span.Span.EndTimestamp().AsTime().Sub(span.Span.StartTimestamp().AsTime()).Nanoseconds()
 		b.dtunb.Append(arrow.Duration(duration))
 		tib := span.Span.TraceID()
-		b.tib.Append(tib[:])
 		sib := span.Span.SpanID()
-		b.sib.Append(sib[:])
-		b.tsb.AppendNonEmpty(span.Span.TraceState().AsRaw())
 		psib := span.Span.ParentSpanID()
-		b.psib.Append(psib[:])
+		if b.uint64IDs {
+			b.tihb.Append(binary.BigEndian.Uint64(tib[:8]))
+			b.tilb.Append(binary.BigEndian.Uint64(tib[8:]))
+			b.siub.Append(binary.BigEndian.Uint64(sib[:]))
+		} else {
+			b.tib.Append(tib[:])
+			b.sib.Append(sib[:])
+		}
+		b.tsb.AppendNonEmpty(span.Span.TraceState().AsRaw())
+		if b.uint64IDs {
+			b.psiub.AppendNonZero(binary.BigEndian.Uint64(psib[:]))
+		} else {
+			b.psib.Append(psib[:])
+		}
 		b.nb.AppendNonEmpty(span.Span.Name())
-		b.kb.AppendNonZero(int32(span.Span.Kind()))
+		b.kb.AppendNonZero(enumToU8(int32(span.Span.Kind())))
 
 		// Span Attributes
 		if spanAttrs.Len() > 0 {
@@ -278,6 +400,10 @@ func (b *TracesBuilder) Append(traces ptrace.Traces) error {
 		if err = b.sb.Append(span.Span.Status()); err != nil {
 			return werror.Wrap(err)
 		}
+
+		if expiry != 0 {
+			b.expb.Append(expiry)
+		}
 	}
 	return nil
 }
@@ -289,6 +415,9 @@ func (b *TracesBuilder) Release() {
 		b.released = true
 
 		b.relatedData.Release()
+		if b.reporter != nil {
+			_ = b.reporter.Close()
+		}
 	}
 }
 
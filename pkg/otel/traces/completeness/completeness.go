@@ -0,0 +1,285 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package completeness
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	tracesotlp "github.com/f5/otel-arrow-adapter/pkg/otel/traces/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+const (
+	// ScopeName is the instrumentation scope of the metrics.
+	ScopeName = "github.com/f5/otel-arrow-adapter/pkg/otel/traces/completeness"
+
+	// CompleteMetric counts the traces settled with a root span.
+	CompleteMetric = "traces_completeness_complete_total"
+	// IncompleteMetric counts the traces settled without a root span.
+	IncompleteMetric = "traces_completeness_incomplete_total"
+	// DroppedSpansMetric counts the spans ignored because MaxTraces
+	// traces were already tracked.
+	DroppedSpansMetric = "traces_completeness_dropped_spans_total"
+	// ActiveMetric is the number of traces currently tracked.
+	ActiveMetric = "traces_completeness_active"
+
+	// DefaultCompletionDelay, DefaultTimeout, and DefaultMaxTraces are
+	// the defaults of the corresponding Config fields.
+	DefaultCompletionDelay = 5 * time.Second
+	DefaultTimeout         = 30 * time.Second
+	DefaultMaxTraces       = 100_000
+)
+
+type (
+	// Config configures a Tracker.
+	Config struct {
+		// CompletionDelay is the time without new spans after which a
+		// trace whose root span has been received is complete.
+		CompletionDelay time.Duration
+		// Timeout is the time without new spans after which a trace
+		// whose root span has not been received is incomplete.
+		Timeout time.Duration
+		// MaxTraces is the maximum number of traces tracked at once, the
+		// spans of the other traces are ignored until some traces settle.
+		MaxTraces int
+	}
+
+	// TraceStatus is the state of a tracked trace.
+	TraceStatus struct {
+		TraceID pcommon.TraceID
+		// Spans is the number of spans received.
+		Spans uint64
+		// Root is true if the root span (i.e. without parent) has been
+		// received.
+		Root      bool
+		FirstSeen time.Time
+		LastSeen  time.Time
+	}
+
+	// Listener is notified of each settled trace, complete (i.e. with a
+	// root span) or not.
+	Listener func(TraceStatus)
+
+	// Counts are the cumulative counts of a Tracker since its creation,
+	// and the number of traces currently tracked.
+	Counts struct {
+		Complete     uint64
+		Incomplete   uint64
+		DroppedSpans uint64
+		Active       int
+	}
+
+	// Tracker tracks the completeness of the traces of OTLP Arrow traces
+	// batches. Late spans of a settled trace start a new (rootless) trace.
+	Tracker struct {
+		cfg   Config
+		start pcommon.Timestamp
+
+		// lock protects traces, counts, and listeners.
+		lock      sync.Mutex
+		traces    map[pcommon.TraceID]*TraceStatus
+		counts    Counts
+		listeners []Listener
+	}
+)
+
+// NewTracker creates an empty Tracker. The zero fields of the config are
+// replaced by their defaults.
+func NewTracker(cfg Config) *Tracker {
+	if cfg.CompletionDelay <= 0 {
+		cfg.CompletionDelay = DefaultCompletionDelay
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxTraces <= 0 {
+		cfg.MaxTraces = DefaultMaxTraces
+	}
+	return &Tracker{
+		cfg:    cfg,
+		start:  pcommon.NewTimestampFromTime(time.Now()),
+		traces: make(map[pcommon.TraceID]*TraceStatus),
+	}
+}
+
+// AddListener registers a listener notified by Sweep of each settled trace.
+func (t *Tracker) AddListener(l Listener) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.listeners = append(t.listeners, l)
+}
+
+// Track adds the spans of a decoded OTLP Arrow traces batch, as returned by
+// the Consume method of the consumer. Only the trace ID and parent span ID
+// columns of the spans record are read, the spans of a same trace being
+// usually adjacent (the spans are sorted by trace ID by default), a trace is
+// looked up once per run of spans.
+// Note: This function does not consume the records.
+func (t *Tracker) Track(records []*record_message.RecordMessage) error {
+	var spansRecord *record_message.RecordMessage
+	for _, record := range records {
+		if record.PayloadType() == colarspb.ArrowPayloadType_SPANS {
+			if spansRecord != nil {
+				return werror.Wrap(otel.ErrMultipleTracesRecords)
+			}
+			spansRecord = record
+		}
+	}
+	if spansRecord == nil {
+		return nil
+	}
+
+	record := spansRecord.Record()
+	ids, err := tracesotlp.SchemaToIds(record.Schema())
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	now := time.Now()
+	rows := int(record.NumRows())
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var trace *TraceStatus
+	var prevTraceID pcommon.TraceID
+	looked := false
+	for row := 0; row < rows; row++ {
+		traceID, err := tracesotlp.TraceIDFromRecord(record, ids, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		parentSpanID, err := tracesotlp.ParentSpanIDFromRecord(record, ids, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+
+		if !looked || traceID != prevTraceID {
+			prevTraceID, looked = traceID, true
+			trace = t.trace(traceID, now)
+		}
+		if trace == nil {
+			t.counts.DroppedSpans++
+			continue
+		}
+		trace.Spans++
+		if parentSpanID.IsEmpty() {
+			trace.Root = true
+		}
+	}
+	return nil
+}
+
+// trace returns the status of the given trace, tracking it if needed, or nil
+// if MaxTraces traces are already tracked.
+func (t *Tracker) trace(traceID pcommon.TraceID, now time.Time) *TraceStatus {
+	trace, ok := t.traces[traceID]
+	if !ok {
+		if len(t.traces) >= t.cfg.MaxTraces {
+			return nil
+		}
+		trace = &TraceStatus{TraceID: traceID, FirstSeen: now}
+		t.traces[traceID] = trace
+	}
+	trace.LastSeen = now
+	return trace
+}
+
+// Sweep settles the traces without new spans since their completion delay
+// (root span received) or their timeout (no root span), and notifies the
+// listeners. The settled traces are returned.
+func (t *Tracker) Sweep(now time.Time) []TraceStatus {
+	t.lock.Lock()
+	var settled []TraceStatus
+	for traceID, trace := range t.traces {
+		idle := now.Sub(trace.LastSeen)
+		switch {
+		case trace.Root && idle >= t.cfg.CompletionDelay:
+			t.counts.Complete++
+		case !trace.Root && idle >= t.cfg.Timeout:
+			t.counts.Incomplete++
+		default:
+			continue
+		}
+		settled = append(settled, *trace)
+		delete(t.traces, traceID)
+	}
+	listeners := t.listeners
+	t.lock.Unlock()
+
+	// The listeners are notified without holding the lock, so they can
+	// query the tracker.
+	for _, trace := range settled {
+		for _, l := range listeners {
+			l(trace)
+		}
+	}
+	return settled
+}
+
+// Counts returns the current counts of the tracker.
+func (t *Tracker) Counts() Counts {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	counts := t.counts
+	counts.Active = len(t.traces)
+	return counts
+}
+
+// Metrics returns the current counts as three cumulative sums and a gauge.
+func (t *Tracker) Metrics(now time.Time) pmetric.Metrics {
+	counts := t.Counts()
+
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(ScopeName)
+	ts := pcommon.NewTimestampFromTime(now)
+
+	for _, sum := range []struct {
+		name  string
+		value uint64
+	}{
+		{CompleteMetric, counts.Complete},
+		{IncompleteMetric, counts.Incomplete},
+		{DroppedSpansMetric, counts.DroppedSpans},
+	} {
+		m := sm.Metrics().AppendEmpty()
+		m.SetName(sum.name)
+		s := m.SetEmptySum()
+		s.SetIsMonotonic(true)
+		s.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		dp := s.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(t.start)
+		dp.SetTimestamp(ts)
+		dp.SetIntValue(int64(sum.value))
+	}
+
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(ActiveMetric)
+	dp := m.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(int64(counts.Active))
+	return md
+}
@@ -30,9 +30,12 @@ import (
 
 type (
 	RelatedData struct {
-		SpanID                uint16
+		// Deprecated: see SpanIDFromDelta.
+		SpanID uint16
+
 		ResAttrMapStore       *otlp.Attributes16Store
 		ScopeAttrMapStore     *otlp.Attributes16Store
+		ScopeTable            *otlp.ScopeTable
 		SpanAttrMapStore      *otlp.Attributes16Store
 		SpanEventAttrMapStore *otlp.Attributes32Store
 		SpanLinkAttrMapStore  *otlp.Attributes32Store
@@ -53,65 +56,73 @@ func NewRelatedData(conf *arrow.Config) *RelatedData {
 	}
 }
 
+// SpanIDFromDelta accumulates a delta-encoded ID into SpanID.
+//
+// Deprecated: TracesFrom decodes the IDs in bulk with
+// arrowutils.DeltaDecodedU16FromRecord and no longer calls this method.
 func (r *RelatedData) SpanIDFromDelta(delta uint16) uint16 {
 	r.SpanID += delta
 	return r.SpanID
 }
 
-func RelatedDataFrom(records []*record_message.RecordMessage, conf *arrow.Config) (relatedData *RelatedData, tracesRecord *record_message.RecordMessage, err error) {
+// RelatedDataFrom decodes the related records of a batch. The independent
+// payloads (attributes, then events and links) are decoded by up to
+// `concurrency` workers. The resource and scope attributes are looked up in
+// attrsCache first, if not nil.
+func RelatedDataFrom(records []*record_message.RecordMessage, conf *arrow.Config, concurrency int, attrsCache *otlp.AttributesCache) (relatedData *RelatedData, tracesRecord *record_message.RecordMessage, err error) {
 	defer func() {
 		for _, record := range records {
 			record.Record().Release()
 		}
 	}()
 
+	var scopeTableRecord *record_message.RecordMessage
 	var spanEventRecord *record_message.RecordMessage
 	var spanLinkRecord *record_message.RecordMessage
+	var attrsTasks otlp.DecodeTasks
 
 	relatedData = NewRelatedData(conf)
 
 	// Scan the records to find the traces record and the span event record.
 	// Create the attribute map stores for all the attribute records.
 	for _, record := range records {
+		record := record
 		switch record.PayloadType() {
 		case colarspb.ArrowPayloadType_RESOURCE_ATTRS:
-			err = otlp.Attributes16StoreFrom(
-				record.Record(),
-				relatedData.ResAttrMapStore,
-			)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return attrsCache.Attributes16StoreFrom(record.CacheKey(), record.Record(), relatedData.ResAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_SCOPE_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.ScopeAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
+			attrsTasks = append(attrsTasks, func() error {
+				return attrsCache.Attributes16StoreFrom(record.CacheKey(), record.Record(), relatedData.ScopeAttrMapStore)
+			})
+		case colarspb.ArrowPayloadType_SCOPES:
+			if scopeTableRecord != nil {
+				return nil, nil, werror.Wrap(otel.ErrMultipleScopeTableRecords)
 			}
+			scopeTableRecord = record
 		case colarspb.ArrowPayloadType_SPAN_ATTRS:
-			err = otlp.Attributes16StoreFrom(record.Record(), relatedData.SpanAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes16StoreFrom(record.Record(), relatedData.SpanAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_SPAN_EVENTS:
 			if spanEventRecord != nil {
 				return nil, nil, werror.Wrap(otel.ErrMultipleSpanEventsRecords)
 			}
 			spanEventRecord = record
 		case colarspb.ArrowPayloadType_SPAN_EVENT_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.SpanEventAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.SpanEventAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_SPAN_LINKS:
 			if spanLinkRecord != nil {
 				return nil, nil, werror.Wrap(otel.ErrMultipleSpanEventsRecords)
 			}
 			spanLinkRecord = record
 		case colarspb.ArrowPayloadType_SPAN_LINK_ATTRS:
-			err = otlp.Attributes32StoreFrom(record.Record(), relatedData.SpanLinkAttrMapStore)
-			if err != nil {
-				return nil, nil, werror.Wrap(err)
-			}
+			attrsTasks = append(attrsTasks, func() error {
+				return otlp.Attributes32StoreFrom(record.Record(), relatedData.SpanLinkAttrMapStore)
+			})
 		case colarspb.ArrowPayloadType_SPANS:
 			if tracesRecord != nil {
 				return nil, nil, werror.Wrap(otel.ErrMultipleTracesRecords)
@@ -122,26 +133,46 @@ func RelatedDataFrom(records []*record_message.RecordMessage, conf *arrow.Config
 		}
 	}
 
+	if err = attrsTasks.Run(concurrency); err != nil {
+		return nil, nil, werror.Wrap(err)
+	}
+
+	// The scope table, events and links depend on their attributes but not
+	// on each other.
+	var tasks otlp.DecodeTasks
+	if scopeTableRecord != nil {
+		tasks = append(tasks, func() (err error) {
+			relatedData.ScopeTable, err = otlp.ScopeTableFrom(
+				scopeTableRecord.Record(),
+				relatedData.ScopeAttrMapStore,
+			)
+			return err
+		})
+	}
 	if spanEventRecord != nil {
-		relatedData.SpanEventsStore, err = SpanEventsStoreFrom(
-			spanEventRecord.Record(),
-			relatedData.SpanEventAttrMapStore,
-			conf.Event,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		tasks = append(tasks, func() (err error) {
+			relatedData.SpanEventsStore, err = SpanEventsStoreFrom(
+				spanEventRecord.Record(),
+				relatedData.SpanEventAttrMapStore,
+				conf.Event,
+			)
+			return err
+		})
 	}
 
 	if spanLinkRecord != nil {
-		relatedData.SpanLinksStore, err = SpanLinksStoreFrom(
-			spanLinkRecord.Record(),
-			relatedData.SpanLinkAttrMapStore,
-			conf.Link,
-		)
-		if err != nil {
-			return nil, nil, werror.Wrap(err)
-		}
+		tasks = append(tasks, func() (err error) {
+			relatedData.SpanLinksStore, err = SpanLinksStoreFrom(
+				spanLinkRecord.Record(),
+				relatedData.SpanLinkAttrMapStore,
+				conf.Link,
+			)
+			return err
+		})
+	}
+
+	if err = tasks.Run(concurrency); err != nil {
+		return nil, nil, werror.Wrap(err)
 	}
 
 	return
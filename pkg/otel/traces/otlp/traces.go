@@ -15,9 +15,11 @@
 package otlp
 
 import (
+	"encoding/binary"
 	"time"
 
 	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 
@@ -49,6 +51,13 @@ type (
 		DropEventsCount      int
 		DropLinksCount       int
 		Status               *StatusIDs
+
+		// Experimental uint64 encoding of the IDs (see
+		// config.WithExperimentalUint64IDs).
+		TraceIDHigh        int
+		TraceIDLow         int
+		SpanIDUint64       int
+		ParentSpanIDUint64 int
 	}
 
 	// StatusIDs contains the field IDs for the status Arrow struct.
@@ -62,6 +71,29 @@ type (
 // TracesFrom creates a [ptrace.Traces] from the given Arrow Record.
 // Note: This function consume the record.
 func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, error) {
+	return tracesFrom(record, relatedData, nil)
+}
+
+// TracesByResourceFrom decodes the given Arrow Record one resource at a time,
+// each [ptrace.Traces] passed to yield contains the spans of a single resource.
+// The decoding stops at the first error returned by yield, which is
+// returned as is.
+// Note: This function consume the record.
+func TracesByResourceFrom(record arrow.Record, relatedData *RelatedData, yield func(ptrace.Traces) error) error {
+	traces, err := tracesFrom(record, relatedData, yield)
+	if err != nil {
+		return err
+	}
+	if traces.ResourceSpans().Len() == 0 {
+		return nil
+	}
+	return yield(traces)
+}
+
+// tracesFrom decodes the record. When yield is not nil, the spans of each
+// resource are passed to yield once complete and the spans of the last
+// resource are returned.
+func tracesFrom(record arrow.Record, relatedData *RelatedData, yield func(ptrace.Traces) error) (ptrace.Traces, error) {
 	defer record.Release()
 
 	traces := ptrace.NewTraces()
@@ -75,6 +107,12 @@ func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, e
 		return traces, err
 	}
 
+	// IDs are delta-encoded, they are decoded in bulk.
+	IDs, err := arrowutils.DeltaDecodedU16FromRecord(record, traceIDs.ID)
+	if err != nil {
+		return traces, werror.Wrap(err)
+	}
+
 	var resSpans ptrace.ResourceSpans
 	var scopeSpansSlice ptrace.ScopeSpansSlice
 	var spanSlice ptrace.SpanSlice
@@ -82,20 +120,25 @@ func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, e
 	resSpansSlice := traces.ResourceSpans()
 	rows := int(record.NumRows())
 
-	prevResID := None
-	prevScopeID := None
+	var resEntries, scopeEntries otlp.EntryTracker
 
 	for row := 0; row < rows; row++ {
 		// Process resource spans, resource, schema url (resource)
-		resID, err := otlp.ResourceIDFromRecord(record, row, traceIDs.Resource)
+		resID, err := otlp.NullableResourceIDFromRecord(record, row, traceIDs.Resource)
 		if err != nil {
 			return traces, werror.Wrap(err)
 		}
-		if prevResID != int(resID) {
-			prevResID = int(resID)
+		if resEntries.IsNew(resID) {
+			if yield != nil && resSpansSlice.Len() != 0 {
+				if err := yield(traces); err != nil {
+					return traces, err
+				}
+				traces = ptrace.NewTraces()
+				resSpansSlice = traces.ResourceSpans()
+			}
 			resSpans = resSpansSlice.AppendEmpty()
 			scopeSpansSlice = resSpans.ScopeSpans()
-			prevScopeID = None
+			scopeEntries.Reset()
 			schemaUrl, err := otlp.UpdateResourceFromRecord(resSpans.Resource(), record, row, traceIDs.Resource, relatedData.ResAttrMapStore)
 			if err != nil {
 				return traces, werror.Wrap(err)
@@ -104,15 +147,14 @@ func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, e
 		}
 
 		// Process scope spans, scope, schema url (scope)
-		scopeID, err := otlp.ScopeIDFromRecord(record, row, traceIDs.Scope)
+		newScope, err := otlp.IsNewScopeEntry(record, row, traceIDs.Scope, &scopeEntries)
 		if err != nil {
 			return traces, werror.Wrap(err)
 		}
-		if prevScopeID != int(scopeID) {
-			prevScopeID = int(scopeID)
+		if newScope {
 			scopeSpans := scopeSpansSlice.AppendEmpty()
 			spanSlice = scopeSpans.Spans()
-			if err = otlp.UpdateScopeFromRecord(scopeSpans.Scope(), record, row, traceIDs.Scope, relatedData.ScopeAttrMapStore); err != nil {
+			if err = otlp.UpdateScopeFromRecord(scopeSpans.Scope(), record, row, traceIDs.Scope, relatedData.ScopeAttrMapStore, relatedData.ScopeTable); err != nil {
 				return traces, werror.Wrap(err)
 			}
 
@@ -125,44 +167,31 @@ func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, e
 
 		// Process span fields
 		span := spanSlice.AppendEmpty()
-		deltaID, err := arrowutils.U16FromRecord(record, traceIDs.ID, row)
-		if err != nil {
-			return traces, werror.Wrap(err)
-		}
-		ID := relatedData.SpanIDFromDelta(deltaID)
+		ID := IDs[row]
 
-		traceID, err := arrowutils.FixedSizeBinaryFromRecord(record, traceIDs.TraceID, row)
+		traceID, err := TraceIDFromRecord(record, traceIDs, row)
 		if err != nil {
-			return traces, werror.Wrap(err)
+			return traces, err
 		}
-		if len(traceID) != 16 {
-			return traces, werror.WrapWithContext(common.ErrInvalidTraceIDLength, map[string]interface{}{"traceID": traceID})
-		}
-		spanID, err := arrowutils.FixedSizeBinaryFromRecord(record, traceIDs.SpanID, row)
+		spanID, err := SpanIDFromRecord(record, traceIDs, row)
 		if err != nil {
-			return traces, werror.Wrap(err)
-		}
-		if len(spanID) != 8 {
-			return traces, werror.WrapWithContext(common.ErrInvalidSpanIDLength, map[string]interface{}{"spanID": spanID})
+			return traces, err
 		}
 		traceState, err := arrowutils.StringFromRecord(record, traceIDs.TraceState, row)
 		if err != nil {
 			return traces, werror.Wrap(err)
 		}
-		parentSpanID, err := arrowutils.FixedSizeBinaryFromRecord(record, traceIDs.ParentSpanID, row)
+		parentSpanID, err := ParentSpanIDFromRecord(record, traceIDs, row)
 		if err != nil {
-			return traces, werror.Wrap(err)
-		}
-		if parentSpanID != nil && len(parentSpanID) != 8 {
-			return traces, werror.WrapWithContext(common.ErrInvalidSpanIDLength, map[string]interface{}{"parentSpanID": parentSpanID})
+			return traces, err
 		}
 		name, err := arrowutils.StringFromRecord(record, traceIDs.Name, row)
 		if err != nil {
 			return traces, werror.Wrap(err)
 		}
-		kind, err := arrowutils.I32FromRecord(record, traceIDs.Kind, row)
+		kind, err := SpanKindFromRecord(record, traceIDs, row)
 		if err != nil {
-			return traces, werror.Wrap(err)
+			return traces, err
 		}
 		startTimeUnixNano, err := arrowutils.TimestampFromRecord(record, traceIDs.StartTimeUnixNano, row)
 		if err != nil {
@@ -197,11 +226,11 @@ func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, e
 			}
 			span.Status().SetMessage(message)
 
-			code, err := arrowutils.I32FromStruct(statusArr, row, traceIDs.Status.Code)
+			code, err := StatusCodeFromStruct(statusArr, traceIDs.Status, row)
 			if err != nil {
-				return traces, werror.Wrap(err)
+				return traces, err
 			}
-			span.Status().SetCode(ptrace.StatusCode(code))
+			span.Status().SetCode(code)
 		}
 		spanAttrs := span.Attributes()
 		attrs := relatedData.SpanAttrMapStore.AttributesByID(ID)
@@ -221,20 +250,12 @@ func TracesFrom(record arrow.Record, relatedData *RelatedData) (ptrace.Traces, e
 			link.MoveTo(linkSlice.AppendEmpty())
 		}
 
-		var tid pcommon.TraceID
-		var sid pcommon.SpanID
-		var psid pcommon.SpanID
-
-		copy(tid[:], traceID)
-		copy(sid[:], spanID)
-		copy(psid[:], parentSpanID)
-
-		span.SetTraceID(tid)
-		span.SetSpanID(sid)
+		span.SetTraceID(traceID)
+		span.SetSpanID(spanID)
 		span.TraceState().FromRaw(traceState)
-		span.SetParentSpanID(psid)
+		span.SetParentSpanID(parentSpanID)
 		span.SetName(name)
-		span.SetKind(ptrace.SpanKind(kind))
+		span.SetKind(kind)
 		span.SetStartTimestamp(pcommon.Timestamp(startTimeUnixNano))
 		span.SetEndTimestamp(pcommon.Timestamp(endTimeUnixNano.UnixNano()))
 		span.SetDroppedAttributesCount(droppedAttributesCount)
@@ -267,6 +288,10 @@ func SchemaToIds(schema *arrow.Schema) (*SpanIDs, error) {
 	droppedAttributesCount, _ := arrowutils.FieldIDFromSchema(schema, constants.DroppedAttributesCount)
 	droppedEventsCount, _ := arrowutils.FieldIDFromSchema(schema, constants.DroppedEventsCount)
 	droppedLinksCount, _ := arrowutils.FieldIDFromSchema(schema, constants.DroppedLinksCount)
+	traceIdHigh, _ := arrowutils.FieldIDFromSchema(schema, constants.TraceIdHigh)
+	traceIdLow, _ := arrowutils.FieldIDFromSchema(schema, constants.TraceIdLow)
+	spanIdUint64, _ := arrowutils.FieldIDFromSchema(schema, constants.SpanIdUint64)
+	parentSpanIdUint64, _ := arrowutils.FieldIDFromSchema(schema, constants.ParentSpanIdUint64)
 
 	status, err := NewStatusIdsFromSchema(schema)
 	if err != nil {
@@ -290,9 +315,95 @@ func SchemaToIds(schema *arrow.Schema) (*SpanIDs, error) {
 		DropEventsCount:      droppedEventsCount,
 		DropLinksCount:       droppedLinksCount,
 		Status:               status,
+		TraceIDHigh:          traceIdHigh,
+		TraceIDLow:           traceIdLow,
+		SpanIDUint64:         spanIdUint64,
+		ParentSpanIDUint64:   parentSpanIdUint64,
 	}, nil
 }
 
+// TraceIDFromRecord returns the trace ID of the span of the given row, from
+// the trace_id column or, when it's absent, from the trace_id_hi and
+// trace_id_lo columns (see config.WithExperimentalUint64IDs).
+func TraceIDFromRecord(record arrow.Record, ids *SpanIDs, row int) (pcommon.TraceID, error) {
+	var tid pcommon.TraceID
+
+	if ids.TraceID != arrowutils.AbsentFieldID {
+		traceID, err := arrowutils.FixedSizeBinaryFromRecord(record, ids.TraceID, row)
+		if err != nil {
+			return tid, werror.Wrap(err)
+		}
+		if len(traceID) != 16 {
+			return tid, werror.WrapWithContext(common.ErrInvalidTraceIDLength, map[string]interface{}{"traceID": traceID})
+		}
+		copy(tid[:], traceID)
+		return tid, nil
+	}
+
+	high, err := arrowutils.U64FromRecord(record, ids.TraceIDHigh, row)
+	if err != nil {
+		return tid, werror.Wrap(err)
+	}
+	low, err := arrowutils.U64FromRecord(record, ids.TraceIDLow, row)
+	if err != nil {
+		return tid, werror.Wrap(err)
+	}
+	binary.BigEndian.PutUint64(tid[:8], high)
+	binary.BigEndian.PutUint64(tid[8:], low)
+	return tid, nil
+}
+
+// SpanIDFromRecord returns the span ID of the span of the given row, from the
+// span_id column or, when it's absent, from the span_id_u64 column.
+func SpanIDFromRecord(record arrow.Record, ids *SpanIDs, row int) (pcommon.SpanID, error) {
+	var sid pcommon.SpanID
+
+	if ids.SpanID != arrowutils.AbsentFieldID {
+		spanID, err := arrowutils.FixedSizeBinaryFromRecord(record, ids.SpanID, row)
+		if err != nil {
+			return sid, werror.Wrap(err)
+		}
+		if len(spanID) != 8 {
+			return sid, werror.WrapWithContext(common.ErrInvalidSpanIDLength, map[string]interface{}{"spanID": spanID})
+		}
+		copy(sid[:], spanID)
+		return sid, nil
+	}
+
+	value, err := arrowutils.U64FromRecord(record, ids.SpanIDUint64, row)
+	if err != nil {
+		return sid, werror.Wrap(err)
+	}
+	binary.BigEndian.PutUint64(sid[:], value)
+	return sid, nil
+}
+
+// ParentSpanIDFromRecord returns the parent span ID of the span of the given
+// row (empty if the span has no parent), from the parent_span_id column or
+// from the parent_span_id_u64 column.
+func ParentSpanIDFromRecord(record arrow.Record, ids *SpanIDs, row int) (pcommon.SpanID, error) {
+	var psid pcommon.SpanID
+
+	if ids.ParentSpanIDUint64 != arrowutils.AbsentFieldID {
+		value, err := arrowutils.U64FromRecord(record, ids.ParentSpanIDUint64, row)
+		if err != nil {
+			return psid, werror.Wrap(err)
+		}
+		binary.BigEndian.PutUint64(psid[:], value)
+		return psid, nil
+	}
+
+	parentSpanID, err := arrowutils.FixedSizeBinaryFromRecord(record, ids.ParentSpanID, row)
+	if err != nil {
+		return psid, werror.Wrap(err)
+	}
+	if parentSpanID != nil && len(parentSpanID) != 8 {
+		return psid, werror.WrapWithContext(common.ErrInvalidSpanIDLength, map[string]interface{}{"parentSpanID": parentSpanID})
+	}
+	copy(psid[:], parentSpanID)
+	return psid, nil
+}
+
 func NewStatusIdsFromSchema(schema *arrow.Schema) (*StatusIDs, error) {
 	statusId, statusDT, err := arrowutils.StructFieldIDFromSchema(schema, constants.Status)
 	if err != nil {
@@ -308,3 +419,61 @@ func NewStatusIdsFromSchema(schema *arrow.Schema) (*StatusIDs, error) {
 		Message: message,
 	}, nil
 }
+
+// SpanKindFromRecord returns the span kind of a row, rejecting the values out
+// of the range of ptrace.SpanKind.
+func SpanKindFromRecord(record arrow.Record, ids *SpanIDs, row int) (ptrace.SpanKind, error) {
+	kind, err := enumFromRecord(record, ids.Kind, row)
+	if err != nil {
+		return ptrace.SpanKindUnspecified, werror.Wrap(err)
+	}
+	if kind < 0 || ptrace.SpanKind(kind) > ptrace.SpanKindConsumer {
+		return ptrace.SpanKindUnspecified, werror.WrapWithContext(common.ErrInvalidSpanKind, map[string]interface{}{"row": row, "kind": kind})
+	}
+	return ptrace.SpanKind(kind), nil
+}
+
+// StatusCodeFromStruct returns the status code of a row of a status struct
+// array, rejecting the values out of the range of ptrace.StatusCode.
+func StatusCodeFromStruct(statusArr *array.Struct, ids *StatusIDs, row int) (ptrace.StatusCode, error) {
+	code, err := enumFromStruct(statusArr, row, ids.Code)
+	if err != nil {
+		return ptrace.StatusCodeUnset, werror.Wrap(err)
+	}
+	if code < 0 || ptrace.StatusCode(code) > ptrace.StatusCodeError {
+		return ptrace.StatusCodeUnset, werror.WrapWithContext(common.ErrInvalidStatusCode, map[string]interface{}{"row": row, "code": code})
+	}
+	return ptrace.StatusCode(code), nil
+}
+
+// enumFromRecord returns the span kind or status code of a row. These columns
+// are encoded as uint8 but the producers of the previous versions of the
+// protocol encoded them as (dictionary-encoded) int32, which is still decoded.
+func enumFromRecord(record arrow.Record, fieldID int, row int) (int32, error) {
+	if fieldID == arrowutils.AbsentFieldID {
+		return 0, nil
+	}
+	return enumFromArray(record.Column(fieldID), row)
+}
+
+// enumFromStruct is the struct field version of enumFromRecord.
+func enumFromStruct(structArr *array.Struct, row int, fieldID int) (int32, error) {
+	if fieldID == arrowutils.AbsentFieldID {
+		return 0, nil
+	}
+	return enumFromArray(structArr.Field(fieldID), row)
+}
+
+func enumFromArray(arr arrow.Array, row int) (int32, error) {
+	values := arr
+	if ree, ok := arr.(*array.RunEndEncoded); ok {
+		values = ree.Values()
+	}
+	switch values.(type) {
+	case *array.Int32, *array.Dictionary:
+		return arrowutils.I32FromArray(arr, row)
+	default:
+		value, err := arrowutils.U8FromArray(arr, row)
+		return int32(value), err
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"testing"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEnumFromArray checks that the span kinds and status codes are decoded
+// from the uint8 columns and from the int32 columns of the previous versions
+// of the protocol.
+func TestEnumFromArray(t *testing.T) {
+	t.Parallel()
+
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	u8b := array.NewUint8Builder(pool)
+	u8b.AppendValues([]uint8{2, 0}, []bool{true, false})
+	u8Arr := u8b.NewArray()
+	u8b.Release()
+	defer u8Arr.Release()
+
+	i32b := array.NewInt32Builder(pool)
+	i32b.AppendValues([]int32{2, 0}, []bool{true, false})
+	i32Arr := i32b.NewArray()
+	i32b.Release()
+	defer i32Arr.Release()
+
+	db := array.NewDictionaryBuilder(pool, &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Uint8, ValueType: arrow.PrimitiveTypes.Int32})
+	require.NoError(t, db.(*array.Int32DictionaryBuilder).Append(2))
+	db.AppendNull()
+	dictArr := db.NewArray()
+	db.Release()
+	defer dictArr.Release()
+
+	for _, arr := range []arrow.Array{u8Arr, i32Arr, dictArr} {
+		value, err := enumFromArray(arr, 0)
+		require.NoError(t, err, arr.DataType().String())
+		require.Equal(t, int32(2), value, arr.DataType().String())
+
+		value, err = enumFromArray(arr, 1)
+		require.NoError(t, err, arr.DataType().String())
+		require.Equal(t, int32(0), value, arr.DataType().String())
+	}
+}
@@ -0,0 +1,339 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package servicegraph
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	tracesarrow "github.com/f5/otel-arrow-adapter/pkg/otel/traces/arrow"
+	tracesotlp "github.com/f5/otel-arrow-adapter/pkg/otel/traces/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+const (
+	// ScopeName is the instrumentation scope of the metrics.
+	ScopeName = "github.com/f5/otel-arrow-adapter/pkg/otel/traces/servicegraph"
+
+	// RequestsMetric counts the requests of each edge.
+	RequestsMetric = "traces_service_graph_request_total"
+	// FailedRequestsMetric counts the requests of each edge where the
+	// client or the server span has an error status.
+	FailedRequestsMetric = "traces_service_graph_request_failed_total"
+
+	// ClientKey, ServerKey, and ConnectionTypeKey are the attributes of
+	// each edge.
+	ClientKey         = "client"
+	ServerKey         = "server"
+	ConnectionTypeKey = "connection_type"
+
+	serviceNameKey = "service.name"
+)
+
+// Connection types of the edges.
+const (
+	// ConnectionRequest is a client span and its server child span.
+	ConnectionRequest = ""
+	// ConnectionMessaging is a producer span and its consumer child span.
+	ConnectionMessaging = "messaging_system"
+	// ConnectionLink is a span and a span it links to, the linked span is
+	// the client.
+	ConnectionLink = "link"
+)
+
+type (
+	// Edge is a dependency between two services.
+	Edge struct {
+		Client         string
+		Server         string
+		ConnectionType string
+	}
+
+	// EdgeCounts are the request counts of an edge.
+	EdgeCounts struct {
+		Edge
+		Requests uint64
+		Failed   uint64
+	}
+
+	// Aggregator accumulates the edges of the service graph of OTLP Arrow
+	// traces batches. Only the spans of a same batch are paired, the
+	// counts are cumulative since the creation of the aggregator.
+	Aggregator struct {
+		start pcommon.Timestamp
+
+		// lock protects edges.
+		lock  sync.Mutex
+		edges map[Edge]*EdgeCounts
+	}
+
+	// span is the part of a span record needed to pair spans.
+	span struct {
+		service string
+		kind    ptrace.SpanKind
+		failed  bool
+	}
+
+	spanKey struct {
+		traceID [16]byte
+		spanID  [8]byte
+	}
+)
+
+// NewAggregator creates an empty Aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{
+		start: pcommon.NewTimestampFromTime(time.Now()),
+		edges: make(map[Edge]*EdgeCounts),
+	}
+}
+
+// Aggregate adds the edges of a decoded OTLP Arrow traces batch, as returned
+// by the Consume method of the consumer. Only the spans, resource attributes,
+// and span links records are read.
+// Note: This function does not consume the records.
+func (a *Aggregator) Aggregate(records []*record_message.RecordMessage) error {
+	var spansRecord *record_message.RecordMessage
+	resAttrs := otlp.NewAttributes16Store()
+	links := tracesotlp.NewSpanLinksStore()
+
+	for _, record := range records {
+		switch record.PayloadType() {
+		case colarspb.ArrowPayloadType_SPANS:
+			if spansRecord != nil {
+				return werror.Wrap(otel.ErrMultipleTracesRecords)
+			}
+			spansRecord = record
+		case colarspb.ArrowPayloadType_RESOURCE_ATTRS:
+			// The stores consume the records, which are
+			// retained for their owner.
+			record.Record().Retain()
+			if err := otlp.Attributes16StoreFrom(record.Record(), resAttrs); err != nil {
+				return werror.Wrap(err)
+			}
+		case colarspb.ArrowPayloadType_SPAN_LINKS:
+			record.Record().Retain()
+			var err error
+			// The link attributes are not needed.
+			links, err = tracesotlp.SpanLinksStoreFrom(record.Record(), otlp.NewAttributes32Store(), tracesarrow.DefaultConfig().Link)
+			if err != nil {
+				return werror.Wrap(err)
+			}
+		}
+	}
+	if spansRecord == nil {
+		return nil
+	}
+
+	record := spansRecord.Record()
+	ids, err := tracesotlp.SchemaToIds(record.Schema())
+	if err != nil {
+		return werror.Wrap(err)
+	}
+	IDs, err := arrowutils.DeltaDecodedU16FromRecord(record, ids.ID)
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	rows := int(record.NumRows())
+	spans := make(map[spanKey]span, rows)
+	parents := make([]*spanKey, rows)
+	keys := make([]spanKey, rows)
+
+	prevResID := -1
+	service := ""
+
+	// First pass, index the spans of the batch.
+	for row := 0; row < rows; row++ {
+		resID, err := otlp.ResourceIDFromRecord(record, row, ids.Resource)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		if prevResID != int(resID) {
+			prevResID = int(resID)
+			if service, err = serviceName(record, row, ids, resAttrs); err != nil {
+				return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+			}
+		}
+
+		traceID, err := tracesotlp.TraceIDFromRecord(record, ids, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		spanID, err := tracesotlp.SpanIDFromRecord(record, ids, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		parentSpanID, err := tracesotlp.ParentSpanIDFromRecord(record, ids, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		kind, err := arrowutils.U8FromRecord(record, ids.Kind, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		failed, err := hasErrorStatus(record, row, ids)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+
+		keys[row] = spanKey{traceID: traceID, spanID: spanID}
+		if !parentSpanID.IsEmpty() {
+			parents[row] = &spanKey{traceID: traceID, spanID: parentSpanID}
+		}
+		spans[keys[row]] = span{service: service, kind: ptrace.SpanKind(kind), failed: failed}
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	// Second pass, pair the spans with their parents and linked spans.
+	for row := 0; row < rows; row++ {
+		s := spans[keys[row]]
+
+		if parents[row] != nil {
+			if parent, ok := spans[*parents[row]]; ok {
+				switch {
+				case parent.kind == ptrace.SpanKindClient && s.kind == ptrace.SpanKindServer:
+					a.add(Edge{Client: parent.service, Server: s.service, ConnectionType: ConnectionRequest}, parent.failed || s.failed)
+				case parent.kind == ptrace.SpanKindProducer && s.kind == ptrace.SpanKindConsumer:
+					a.add(Edge{Client: parent.service, Server: s.service, ConnectionType: ConnectionMessaging}, parent.failed || s.failed)
+				}
+			}
+		}
+
+		for _, link := range links.LinksByID(IDs[row]) {
+			linked, ok := spans[spanKey{traceID: link.TraceID(), spanID: link.SpanID()}]
+			if !ok || linked.service == s.service {
+				continue
+			}
+			a.add(Edge{Client: linked.service, Server: s.service, ConnectionType: ConnectionLink}, linked.failed || s.failed)
+		}
+	}
+	return nil
+}
+
+func (a *Aggregator) add(edge Edge, failed bool) {
+	counts, ok := a.edges[edge]
+	if !ok {
+		counts = &EdgeCounts{Edge: edge}
+		a.edges[edge] = counts
+	}
+	counts.Requests++
+	if failed {
+		counts.Failed++
+	}
+}
+
+// Edges returns the edges and their counts, sorted by client, server, and
+// connection type.
+func (a *Aggregator) Edges() []EdgeCounts {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	edges := make([]EdgeCounts, 0, len(a.edges))
+	for _, counts := range a.edges {
+		edges = append(edges, *counts)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Client != edges[j].Client {
+			return edges[i].Client < edges[j].Client
+		}
+		if edges[i].Server != edges[j].Server {
+			return edges[i].Server < edges[j].Server
+		}
+		return edges[i].ConnectionType < edges[j].ConnectionType
+	})
+	return edges
+}
+
+// Metrics returns the current counts of the edges as two cumulative sums, with
+// one data point per edge.
+func (a *Aggregator) Metrics(now time.Time) pmetric.Metrics {
+	edges := a.Edges()
+
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(ScopeName)
+	ts := pcommon.NewTimestampFromTime(now)
+
+	requests := newSum(sm.Metrics(), RequestsMetric)
+	failed := newSum(sm.Metrics(), FailedRequestsMetric)
+
+	for _, edge := range edges {
+		a.addPoint(requests, edge.Edge, edge.Requests, ts)
+		a.addPoint(failed, edge.Edge, edge.Failed, ts)
+	}
+	return md
+}
+
+func (a *Aggregator) addPoint(sum pmetric.Sum, edge Edge, value uint64, ts pcommon.Timestamp) {
+	dp := sum.DataPoints().AppendEmpty()
+	dp.Attributes().PutStr(ClientKey, edge.Client)
+	dp.Attributes().PutStr(ServerKey, edge.Server)
+	dp.Attributes().PutStr(ConnectionTypeKey, edge.ConnectionType)
+	dp.SetStartTimestamp(a.start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(int64(value))
+}
+
+func newSum(metrics pmetric.MetricSlice, name string) pmetric.Sum {
+	m := metrics.AppendEmpty()
+	m.SetName(name)
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	return sum
+}
+
+// serviceName returns the service name of the resource of the given row.
+func serviceName(record arrow.Record, row int, ids *tracesotlp.SpanIDs, resAttrs *otlp.Attributes16Store) (string, error) {
+	attrs, err := otlp.ResourceAttributesFromRecord(record, row, ids.Resource, resAttrs)
+	if err != nil || attrs == nil {
+		return "", err
+	}
+	if v, ok := attrs.Get(serviceNameKey); ok {
+		return v.AsString(), nil
+	}
+	return "", nil
+}
+
+// hasErrorStatus returns true if the span of the given row has an error
+// status.
+func hasErrorStatus(record arrow.Record, row int, ids *tracesotlp.SpanIDs) (bool, error) {
+	statusArr, err := arrowutils.StructFromRecord(record, ids.Status.Status, row)
+	if err != nil || statusArr == nil {
+		return false, err
+	}
+	code, err := arrowutils.U8FromStruct(statusArr, row, ids.Status.Code)
+	if err != nil {
+		return false, err
+	}
+	return ptrace.StatusCode(code) == ptrace.StatusCodeError, nil
+}
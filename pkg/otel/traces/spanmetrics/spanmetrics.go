@@ -0,0 +1,370 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package spanmetrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	colarspb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	arrowutils "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/common/otlp"
+	tracesotlp "github.com/f5/otel-arrow-adapter/pkg/otel/traces/otlp"
+	"github.com/f5/otel-arrow-adapter/pkg/record_message"
+	"github.com/f5/otel-arrow-adapter/pkg/werror"
+)
+
+const (
+	// ScopeName is the instrumentation scope of the metrics.
+	ScopeName = "github.com/f5/otel-arrow-adapter/pkg/otel/traces/spanmetrics"
+
+	// CallsMetric counts the spans.
+	CallsMetric = "calls"
+	// ErrorsMetric counts the spans with an error status.
+	ErrorsMetric = "errors"
+	// DurationMetric is the histogram of the span durations, in
+	// milliseconds.
+	DurationMetric = "duration"
+
+	// SpanNameKey and SpanKindKey are the attributes of each
+	// series, the service name is a resource attribute.
+	SpanNameKey = "span.name"
+	SpanKindKey = "span.kind"
+
+	serviceNameKey = "service.name"
+)
+
+// DefaultBounds are the default bucket boundaries of the duration histogram.
+var DefaultBounds = []time.Duration{
+	2 * time.Millisecond,
+	4 * time.Millisecond,
+	6 * time.Millisecond,
+	8 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	400 * time.Millisecond,
+	800 * time.Millisecond,
+	time.Second,
+	1400 * time.Millisecond,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	15 * time.Second,
+}
+
+type (
+	// Aggregator accumulates the RED metrics of spans, per service name,
+	// span name, and span kind. The spans are read either from the Arrow
+	// records of OTLP Arrow traces batches (Aggregate) or from their OTLP
+	// representation (AggregateTraces). The metrics of a series are
+	// cumulative since the first span of the series, a series without new
+	// spans for longer than the expiry being forgotten.
+	Aggregator struct {
+		// bounds are the histogram bucket boundaries in milliseconds.
+		bounds []float64
+		expiry time.Duration
+		now    func() time.Time
+
+		// lock protects series.
+		lock   sync.Mutex
+		series map[seriesKey]*series
+	}
+
+	seriesKey struct {
+		service string
+		name    string
+		kind    ptrace.SpanKind
+	}
+
+	series struct {
+		start        pcommon.Timestamp
+		lastUpdated  time.Time
+		calls        uint64
+		errors       uint64
+		sum          float64
+		bucketCounts []uint64
+	}
+)
+
+// NewAggregator creates an Aggregator using the given (sorted) histogram
+// bucket boundaries, or DefaultBounds if empty. The series without new spans
+// for longer than expiry are removed from the metrics (0 = never).
+func NewAggregator(bounds []time.Duration, expiry time.Duration) *Aggregator {
+	if len(bounds) == 0 {
+		bounds = DefaultBounds
+	}
+	msBounds := make([]float64, len(bounds))
+	for i, b := range bounds {
+		msBounds[i] = durationMillis(int64(b))
+	}
+	return &Aggregator{
+		bounds: msBounds,
+		expiry: expiry,
+		now:    time.Now,
+		series: make(map[seriesKey]*series),
+	}
+}
+
+// Aggregate adds the spans of a decoded OTLP Arrow traces batch, as returned
+// by the Consume method of the consumer, to the metrics. Only the spans and
+// resource attributes records are read, the span attributes, events, and
+// links are not decoded.
+// Note: This function does not consume the records.
+func (a *Aggregator) Aggregate(records []*record_message.RecordMessage) error {
+	var spansRecord *record_message.RecordMessage
+	resAttrs := otlp.NewAttributes16Store()
+
+	for _, record := range records {
+		switch record.PayloadType() {
+		case colarspb.ArrowPayloadType_SPANS:
+			if spansRecord != nil {
+				return werror.Wrap(otel.ErrMultipleTracesRecords)
+			}
+			spansRecord = record
+		case colarspb.ArrowPayloadType_RESOURCE_ATTRS:
+			// The store consumes the record, which is
+			// retained for its owner.
+			record.Record().Retain()
+			if err := otlp.Attributes16StoreFrom(record.Record(), resAttrs); err != nil {
+				return werror.Wrap(err)
+			}
+		}
+	}
+	if spansRecord == nil {
+		return nil
+	}
+
+	record := spansRecord.Record()
+	ids, err := tracesotlp.SchemaToIds(record.Schema())
+	if err != nil {
+		return werror.Wrap(err)
+	}
+
+	now := a.now()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var resEntries otlp.EntryTracker
+	service := ""
+	rows := int(record.NumRows())
+
+	for row := 0; row < rows; row++ {
+		// The resource attributes IDs are delta-encoded, the
+		// attributes are looked up for each new resource entry, as
+		// when the spans are decoded.
+		resID, err := otlp.NullableResourceIDFromRecord(record, row, ids.Resource)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		if resEntries.IsNew(resID) {
+			if service, err = serviceName(record, row, ids, resAttrs); err != nil {
+				return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+			}
+		}
+
+		name, err := arrowutils.StringFromRecord(record, ids.Name, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		kind, err := tracesotlp.SpanKindFromRecord(record, ids, row)
+		if err != nil {
+			return werror.Wrap(err)
+		}
+		duration, err := arrowutils.DurationFromRecord(record, ids.DurationTimeUnixNano, row)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+		code, err := statusCode(record, row, ids)
+		if err != nil {
+			return werror.WrapWithContext(err, map[string]interface{}{"row": row})
+		}
+
+		a.add(seriesKey{service: service, name: name, kind: kind}, now, durationMillis(int64(duration)), code)
+	}
+	return nil
+}
+
+// AggregateTraces adds the spans of the given traces to the metrics.
+func (a *Aggregator) AggregateTraces(traces ptrace.Traces) {
+	now := a.now()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	resSpansSlice := traces.ResourceSpans()
+	for i := 0; i < resSpansSlice.Len(); i++ {
+		resSpans := resSpansSlice.At(i)
+		service := ""
+		if v, ok := resSpans.Resource().Attributes().Get(serviceNameKey); ok {
+			service = v.AsString()
+		}
+
+		scopeSpansSlice := resSpans.ScopeSpans()
+		for j := 0; j < scopeSpansSlice.Len(); j++ {
+			spans := scopeSpansSlice.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+				duration := int64(span.EndTimestamp()) - int64(span.StartTimestamp())
+				a.add(seriesKey{service: service, name: span.Name(), kind: span.Kind()}, now, durationMillis(duration), span.Status().Code())
+			}
+		}
+	}
+}
+
+// add adds a span to its series, the lock must be held.
+func (a *Aggregator) add(key seriesKey, now time.Time, ms float64, code ptrace.StatusCode) {
+	s, ok := a.series[key]
+	if !ok {
+		s = &series{
+			start:        pcommon.NewTimestampFromTime(now),
+			bucketCounts: make([]uint64, len(a.bounds)+1),
+		}
+		a.series[key] = s
+	}
+	s.lastUpdated = now
+	s.calls++
+	if code == ptrace.StatusCodeError {
+		s.errors++
+	}
+	s.sum += ms
+	s.bucketCounts[sort.SearchFloat64s(a.bounds, ms)]++
+}
+
+// Metrics returns the current value of the metrics, with one resource per
+// service name. The series expired at the given time are removed first.
+func (a *Aggregator) Metrics(now time.Time) pmetric.Metrics {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.expiry > 0 {
+		for key, s := range a.series {
+			if now.Sub(s.lastUpdated) > a.expiry {
+				delete(a.series, key)
+			}
+		}
+	}
+
+	md := pmetric.NewMetrics()
+	ts := pcommon.NewTimestampFromTime(now)
+
+	// Group the series by service name, in a stable order.
+	keys := make([]seriesKey, 0, len(a.series))
+	for key := range a.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].service != keys[j].service {
+			return keys[i].service < keys[j].service
+		}
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].kind < keys[j].kind
+	})
+
+	var calls, errors, duration pmetric.Metric
+	prevService := ""
+	for i, key := range keys {
+		if i == 0 || key.service != prevService {
+			prevService = key.service
+			rm := md.ResourceMetrics().AppendEmpty()
+			if key.service != "" {
+				rm.Resource().Attributes().PutStr(serviceNameKey, key.service)
+			}
+			sm := rm.ScopeMetrics().AppendEmpty()
+			sm.Scope().SetName(ScopeName)
+			calls, errors, duration = newMetrics(sm.Metrics())
+		}
+		s := a.series[key]
+
+		dp := calls.Sum().DataPoints().AppendEmpty()
+		setPoint(dp.Attributes(), dp.SetStartTimestamp, dp.SetTimestamp, key, s.start, ts)
+		dp.SetIntValue(int64(s.calls))
+
+		dp = errors.Sum().DataPoints().AppendEmpty()
+		setPoint(dp.Attributes(), dp.SetStartTimestamp, dp.SetTimestamp, key, s.start, ts)
+		dp.SetIntValue(int64(s.errors))
+
+		hdp := duration.Histogram().DataPoints().AppendEmpty()
+		setPoint(hdp.Attributes(), hdp.SetStartTimestamp, hdp.SetTimestamp, key, s.start, ts)
+		hdp.SetCount(s.calls)
+		hdp.SetSum(s.sum)
+		hdp.ExplicitBounds().FromRaw(a.bounds)
+		hdp.BucketCounts().FromRaw(s.bucketCounts)
+	}
+	return md
+}
+
+func setPoint(attrs pcommon.Map, setStart, setTime func(pcommon.Timestamp), key seriesKey, start, ts pcommon.Timestamp) {
+	attrs.PutStr(SpanNameKey, key.name)
+	attrs.PutStr(SpanKindKey, key.kind.String())
+	setStart(start)
+	setTime(ts)
+}
+
+// newMetrics appends the calls, errors, and duration metrics.
+func newMetrics(metrics pmetric.MetricSlice) (calls, errors, duration pmetric.Metric) {
+	calls = metrics.AppendEmpty()
+	calls.SetName(CallsMetric)
+	calls.SetEmptySum().SetIsMonotonic(true)
+	calls.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	errors = metrics.AppendEmpty()
+	errors.SetName(ErrorsMetric)
+	errors.SetEmptySum().SetIsMonotonic(true)
+	errors.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	duration = metrics.AppendEmpty()
+	duration.SetName(DurationMetric)
+	duration.SetUnit("ms")
+	duration.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	return
+}
+
+// serviceName returns the service name of the resource of the given row.
+func serviceName(record arrow.Record, row int, ids *tracesotlp.SpanIDs, resAttrs *otlp.Attributes16Store) (string, error) {
+	attrs, err := otlp.ResourceAttributesFromRecord(record, row, ids.Resource, resAttrs)
+	if err != nil || attrs == nil {
+		return "", err
+	}
+	if v, ok := attrs.Get(serviceNameKey); ok {
+		return v.AsString(), nil
+	}
+	return "", nil
+}
+
+// statusCode returns the status code of the span of the given row.
+func statusCode(record arrow.Record, row int, ids *tracesotlp.SpanIDs) (ptrace.StatusCode, error) {
+	statusArr, err := arrowutils.StructFromRecord(record, ids.Status.Status, row)
+	if err != nil || statusArr == nil {
+		return ptrace.StatusCodeUnset, err
+	}
+	return tracesotlp.StatusCodeFromStruct(statusArr, ids.Status, row)
+}
+
+func durationMillis(nanos int64) float64 {
+	return float64(nanos) / float64(time.Millisecond)
+}
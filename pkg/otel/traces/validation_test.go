@@ -23,6 +23,7 @@ import (
 	"github.com/apache/arrow/go/v12/arrow"
 	"github.com/apache/arrow/go/v12/arrow/memory"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 
 	"github.com/f5/otel-arrow-adapter/pkg/benchmark/dataset"
@@ -80,6 +81,75 @@ func TestInvalidTracesDecoding(t *testing.T) {
 	MultiRoundOfCheckEncodeMessUpDecode(t, expectedRequest)
 }
 
+// TestInvalidSpanKindAndStatusCode checks that out of range span kinds and
+// status codes are rejected by the decoder.
+func TestInvalidSpanKindAndStatusCode(t *testing.T) {
+	t.Parallel()
+
+	newTraces := func(kind ptrace.SpanKind, code ptrace.StatusCode) ptrace.Traces {
+		traces := ptrace.NewTraces()
+		span := traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+		span.SetTraceID([16]byte{1})
+		span.SetSpanID([8]byte{1})
+		span.SetName("span")
+		span.SetKind(kind)
+		span.Status().SetCode(code)
+		return traces
+	}
+
+	_, err := encodeDecode(t, newTraces(ptrace.SpanKindConsumer, ptrace.StatusCodeError))
+	require.NoError(t, err)
+
+	_, err = encodeDecode(t, newTraces(ptrace.SpanKind(42), ptrace.StatusCodeOk))
+	require.ErrorIs(t, err, common.ErrInvalidSpanKind)
+
+	_, err = encodeDecode(t, newTraces(ptrace.SpanKindServer, ptrace.StatusCode(7)))
+	require.ErrorIs(t, err, common.ErrInvalidStatusCode)
+
+	// Values not fitting in a uint8 are not truncated to valid values.
+	_, err = encodeDecode(t, newTraces(ptrace.SpanKind(256), ptrace.StatusCodeOk))
+	require.ErrorIs(t, err, common.ErrInvalidSpanKind)
+
+	_, err = encodeDecode(t, newTraces(ptrace.SpanKindServer, ptrace.StatusCode(-1)))
+	require.ErrorIs(t, err, common.ErrInvalidStatusCode)
+}
+
+// encodeDecode converts the traces to Arrow and back to OTLP.
+func encodeDecode(t *testing.T, traces ptrace.Traces) (ptrace.Traces, error) {
+	pool := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer pool.AssertSize(t, 0)
+
+	rBuilder := builder.NewRecordBuilderExt(pool, tracesarrow.TracesSchema, DefaultDictConfig, ProducerStats)
+	defer rBuilder.Release()
+
+	var record arrow.Record
+	var relatedRecords []*record_message.RecordMessage
+
+	conf := config.DefaultConfig()
+
+	for {
+		tb, err := tracesarrow.NewTracesBuilder(rBuilder, tracesarrow.NewConfig(conf), stats.NewProducerStats())
+		require.NoError(t, err)
+		defer tb.Release()
+
+		err = tb.Append(traces)
+		require.NoError(t, err)
+
+		record, err = rBuilder.NewRecord()
+		if err == nil {
+			relatedRecords, err = tb.RelatedData().BuildRecordMessages()
+			require.NoError(t, err)
+			break
+		}
+		require.Error(t, acommon.ErrSchemaNotUpToDate)
+	}
+
+	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf), 1, nil)
+	require.NoError(t, err)
+
+	return tracesotlp.TracesFrom(record, relatedData)
+}
+
 func CheckEncodeDecode(
 	t *testing.T,
 	expectedRequest ptraceotlp.ExportRequest,
@@ -113,7 +183,7 @@ func CheckEncodeDecode(
 		require.Error(t, acommon.ErrSchemaNotUpToDate)
 	}
 
-	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf))
+	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf), 1, nil)
 	require.NoError(t, err)
 
 	// Convert the Arrow record back to OTLP.
@@ -173,7 +243,7 @@ func CheckEncodeMessUpDecode(
 	// Mix up the Arrow records in such a way as to make decoding impossible.
 	mainRecordChanged, record, relatedRecords := common.MixUpArrowRecords(rng, record, relatedRecords)
 
-	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf))
+	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf), 1, nil)
 
 	// Convert the Arrow record back to OTLP.
 	_, err = tracesotlp.TracesFrom(record, relatedData)
@@ -236,7 +306,7 @@ func checkTracesConversion(t *testing.T, expectedRequest ptraceotlp.ExportReques
 		require.Error(t, acommon.ErrSchemaNotUpToDate)
 	}
 
-	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf))
+	relatedData, _, err := tracesotlp.RelatedDataFrom(relatedRecords, tracesarrow.NewConfig(conf), 1, nil)
 	require.NoError(t, err)
 
 	// Convert the Arrow records back to OTLP.
@@ -35,6 +35,10 @@ type RecordMessage struct {
 	schemaID    string
 	payloadType PayloadType
 	record      arrow.Record
+
+	// cacheKey identifies the content of the record in the decoding caches
+	// of the consumer, empty if the record is not cacheable.
+	cacheKey string
 }
 
 // NewRecordMessage creates a record message.
@@ -111,6 +115,16 @@ func (rm *RecordMessage) SetPayloadType(payloadType PayloadType) {
 	rm.payloadType = payloadType
 }
 
+// CacheKey returns the key of the record in the decoding caches of the
+// consumer, or an empty string.
+func (rm *RecordMessage) CacheKey() string {
+	return rm.cacheKey
+}
+
+func (rm *RecordMessage) SetCacheKey(cacheKey string) {
+	rm.cacheKey = cacheKey
+}
+
 func (rm *RecordMessage) ShowStats() {
 	schema := rm.record.Schema()
 	columns := rm.record.Columns()
@@ -0,0 +1,192 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package werror
+
+// Typed error codes and key/value context of the encoder and decoder errors.
+//
+// A CodedError can be wrapped like any other error (e.g. with Wrap), the code
+// and the context remain retrievable with errors.As or CodeOf. This lets the
+// callers (e.g. the collector components) map the failures to retryable or
+// permanent statuses without parsing the error messages.
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Code classifies an error.
+type Code int
+
+const (
+	// CodeUnknown is the code of the errors without code.
+	CodeUnknown Code = iota
+	// CodeInvalidArgument is the code of the errors caused by invalid input
+	// data, e.g. a malformed record. Retrying with the same data fails again.
+	CodeInvalidArgument
+	// CodeUnsupported is the code of the errors caused by valid data that
+	// is not supported by this version of the adapter, e.g. an unknown column
+	// in strict mode.
+	CodeUnsupported
+	// CodeResourceExhausted is the code of the errors caused by a resource
+	// limit, e.g. a memory limit. The data may be accepted later.
+	CodeResourceExhausted
+	// CodeUnavailable is the code of the transient errors.
+	CodeUnavailable
+	// CodeInternal is the code of the errors caused by a bug.
+	CodeInternal
+)
+
+// Context keys of the errors.
+const (
+	PayloadTypeKey = "payload_type"
+	FieldKey       = "field"
+	RecordIndexKey = "record_index"
+)
+
+// String returns the name of the code.
+func (c Code) String() string {
+	switch c {
+	case CodeInvalidArgument:
+		return "invalid_argument"
+	case CodeUnsupported:
+		return "unsupported"
+	case CodeResourceExhausted:
+		return "resource_exhausted"
+	case CodeUnavailable:
+		return "unavailable"
+	case CodeInternal:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable returns true if an operation failing with this code may succeed
+// when retried with the same data.
+func (c Code) Retryable() bool {
+	return c == CodeResourceExhausted || c == CodeUnavailable
+}
+
+type (
+	// CodedError is an error with a code and a key/value context (see
+	// WithCode).
+	CodedError struct {
+		err    error
+		code   Code
+		fields []Field
+	}
+
+	// Field is a key/value pair of the context of a CodedError.
+	Field struct {
+		Key   string
+		Value interface{}
+	}
+)
+
+// PayloadType returns the context field of the payload type of an error.
+func PayloadType(payloadType fmt.Stringer) Field {
+	return Field{Key: PayloadTypeKey, Value: payloadType.String()}
+}
+
+// FieldName returns the context field of the name of the field (or column)
+// of an error.
+func FieldName(name string) Field {
+	return Field{Key: FieldKey, Value: name}
+}
+
+// RecordIndex returns the context field of the index of the record (or row)
+// of an error.
+func RecordIndex(index int) Field {
+	return Field{Key: RecordIndexKey, Value: index}
+}
+
+// WithCode returns an error wrapping err with the given code and context
+// fields, or nil if err is nil. The new code takes precedence over the codes
+// of the CodedErrors already in the chain of err (see CodeOf), their fields
+// remain retrievable with Value.
+func WithCode(err error, code Code, fields ...Field) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{err: err, code: code, fields: fields}
+}
+
+// CodeOf returns the code of the outermost CodedError of the chain of err, or
+// CodeUnknown if there is none.
+func CodeOf(err error) Code {
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return CodeUnknown
+}
+
+// Error returns the code, the context and the message of the wrapped error.
+func (e *CodedError) Error() string {
+	var msg strings.Builder
+
+	msg.WriteString(e.code.String())
+	if len(e.fields) > 0 {
+		msg.WriteString("{")
+		for i, f := range e.fields {
+			if i > 0 {
+				msg.WriteString(", ")
+			}
+			msg.WriteString(f.Key)
+			msg.WriteString("=")
+			msg.WriteString(fmt.Sprintf("%v", f.Value))
+		}
+		msg.WriteString("}")
+	}
+	msg.WriteString("->")
+	msg.WriteString(e.err.Error())
+
+	return msg.String()
+}
+
+// Unwrap returns the wrapped error.
+func (e *CodedError) Unwrap() error {
+	return e.err
+}
+
+// Code returns the code of the error.
+func (e *CodedError) Code() Code {
+	return e.code
+}
+
+// Value returns the value of the given key in the context of the error and
+// of the CodedErrors it wraps, the outermost value first.
+func (e *CodedError) Value(key string) (interface{}, bool) {
+	for _, f := range e.fields {
+		if f.Key == key {
+			return f.Value, true
+		}
+	}
+	var inner *CodedError
+	if errors.As(e.err, &inner) {
+		return inner.Value(key)
+	}
+	return nil, false
+}
+
+// Fields returns the context of the error, without the context of the
+// CodedErrors it wraps.
+func (e *CodedError) Fields() []Field {
+	return e.fields
+}
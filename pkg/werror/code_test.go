@@ -0,0 +1,62 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package werror
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testPayloadType string
+
+func (t testPayloadType) String() string { return string(t) }
+
+func TestCodedError(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, WithCode(nil, CodeInternal))
+	require.Equal(t, CodeUnknown, CodeOf(ErrTest))
+	require.Equal(t, CodeUnknown, CodeOf(nil))
+
+	inner := WithCode(ErrTest, CodeInvalidArgument, FieldName("name"), RecordIndex(3))
+	err := Wrap(WithCode(Wrap(inner), CodeResourceExhausted, PayloadType(testPayloadType("SPANS"))))
+
+	require.True(t, errors.Is(err, ErrTest))
+	require.Equal(t, CodeResourceExhausted, CodeOf(err))
+	require.True(t, CodeOf(err).Retryable())
+	require.False(t, CodeOf(inner).Retryable())
+
+	var coded *CodedError
+	require.True(t, errors.As(err, &coded))
+	require.Equal(t, CodeResourceExhausted, coded.Code())
+	require.Equal(t, []Field{{Key: PayloadTypeKey, Value: "SPANS"}}, coded.Fields())
+
+	// The context of the inner errors is retrievable.
+	value, ok := coded.Value(PayloadTypeKey)
+	require.True(t, ok)
+	require.Equal(t, "SPANS", value)
+	value, ok = coded.Value(RecordIndexKey)
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+	_, ok = coded.Value("missing")
+	require.False(t, ok)
+
+	require.Equal(t, "invalid_argument{field=name, record_index=3}->test error", inner.Error())
+}
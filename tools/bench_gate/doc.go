@@ -0,0 +1,28 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package main contains a CLI tool failing when benchmark results (benchstat
+// format) regress beyond thresholds compared to a baseline.
+//
+// Usage:
+//
+//	go run ./tools/bench_gate -baseline old.bench.txt -current new.bench.txt
+//
+// The results are produced by the benchmark tools (output/*.bench.txt) or by
+// `go test -bench`. The command exits with a non-zero status if the median
+// ns/op or allocs/op of a benchmark regressed beyond the thresholds.
+package main
@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/f5/otel-arrow-adapter/pkg/benchmark"
+)
+
+func main() {
+	defaults := benchmark.DefaultThresholds()
+	baseline := flag.String("baseline", "", "baseline results (benchstat format)")
+	current := flag.String("current", "", "current results (benchstat format)")
+	maxNs := flag.Float64("max-ns-increase", defaults.MaxNsPerOpIncrease, "max ns/op increase in percent (negative to disable)")
+	maxAllocs := flag.Float64("max-allocs-increase", defaults.MaxAllocsPerOpIncrease, "max allocs/op increase in percent (negative to disable)")
+	flag.Parse()
+
+	if *baseline == "" || *current == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	baseFile, err := os.Open(filepath.Clean(*baseline))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open baseline: %v\n", err)
+		os.Exit(2)
+	}
+	defer func() { _ = baseFile.Close() }()
+
+	currentFile, err := os.Open(filepath.Clean(*current))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open current results: %v\n", err)
+		os.Exit(2)
+	}
+	defer func() { _ = currentFile.Close() }()
+
+	regressions, err := benchmark.CheckRegressions(baseFile, currentFile, benchmark.Thresholds{
+		MaxNsPerOpIncrease:     *maxNs,
+		MaxAllocsPerOpIncrease: *maxAllocs,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to compare results: %v\n", err)
+		os.Exit(2)
+	}
+
+	if len(regressions) == 0 {
+		fmt.Println("no regression detected")
+		return
+	}
+	for _, r := range regressions {
+		fmt.Println(r.String())
+	}
+	os.Exit(1)
+}
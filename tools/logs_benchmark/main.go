@@ -141,6 +141,8 @@ func main() {
 
 		profiler.ExportMetricsTimesCSV(fmt.Sprintf("%d_logs_benchmark_results", i))
 		profiler.ExportMetricsBytesCSV(fmt.Sprintf("%d_logs_benchmark_results", i))
+		profiler.ExportBenchstat("Logs", fmt.Sprintf("%d_logs_benchmark_results", i))
+		profiler.ExportReport("Logs", fmt.Sprintf("%s (%s)", inputFiles[i], humanize.Bytes(uint64(ds.SizeInBytes()))), fmt.Sprintf("%d_logs_benchmark_results", i), maxIter)
 
 		ds.ShowStats()
 	}
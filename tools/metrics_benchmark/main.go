@@ -121,6 +121,8 @@ func main() {
 
 		profiler.ExportMetricsTimesCSV(fmt.Sprintf("%d_metrics_benchmark_results", i))
 		profiler.ExportMetricsBytesCSV(fmt.Sprintf("%d_metrics_benchmark_results", i))
+		profiler.ExportBenchstat("Metrics", fmt.Sprintf("%d_metrics_benchmark_results", i))
+		profiler.ExportReport("Metrics", fmt.Sprintf("%s (%s)", inputFiles[i], humanize.Bytes(uint64(ds.SizeInBytes()))), fmt.Sprintf("%d_metrics_benchmark_results", i), maxIter)
 
 		ds.ShowStats()
 	}
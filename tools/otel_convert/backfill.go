@@ -0,0 +1,282 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// Backfill of OTLP archives.
+//
+// In backfill mode, each OTLP file of the input directories is converted
+// into its own OTAP file by a pool of workers, each file being encoded by a
+// dedicated Producer so the OTAP files can be decoded independently. The
+// OTAP files mirror the tree of the input directories in the output
+// directory, with an `.otap` extension.
+//
+// An OTAP file is written to a temporary file renamed once complete, so an
+// interrupted backfill is resumed by running the same command again: the
+// input files with an existing OTAP file are skipped.
+
+// backfillSuffix is the extension of the OTAP files written by a backfill.
+const backfillSuffix = ".otap"
+
+// backfillJob is an OTLP file to convert into an OTAP file.
+type backfillJob struct {
+	input  string
+	output string
+	size   int64
+}
+
+// backfillProgress tracks the progress of a backfill.
+type backfillProgress struct {
+	start      time.Time
+	files      int
+	bytes      int64
+	doneFiles  atomic.Int64
+	doneBytes  atomic.Int64
+	items      atomic.Int64
+	failedJobs atomic.Int64
+}
+
+// backfill converts the OTLP files of the input directories (or the input
+// files) into OTAP files of the output directory with opts.workers workers.
+// The conversion errors are logged and the other files are converted, the
+// failed files are retried when the backfill is resumed.
+func backfill[T any](s *signal[T], opts *options) error {
+	jobs, skipped, err := backfillJobs(opts)
+	if err != nil {
+		return err
+	}
+
+	progress := &backfillProgress{
+		start: time.Now(),
+		files: len(jobs),
+	}
+	for _, job := range jobs {
+		progress.bytes += job.size
+	}
+	log.Printf("backfill: %d files to convert (%s), %d files already converted", progress.files, humanize.Bytes(uint64(progress.bytes)), skipped)
+
+	done := make(chan struct{})
+	var reporter sync.WaitGroup
+	if opts.progress > 0 {
+		reporter.Add(1)
+		go func() {
+			defer reporter.Done()
+			ticker := time.NewTicker(opts.progress)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					progress.report()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	queue := make(chan backfillJob)
+	var workers sync.WaitGroup
+	for i := 0; i < opts.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range queue {
+				items, err := backfillFile(s, opts, job)
+				if err != nil {
+					log.Printf("backfill: %s: %v", job.input, err)
+					progress.failedJobs.Add(1)
+					continue
+				}
+				progress.items.Add(int64(items))
+				progress.doneBytes.Add(job.size)
+				progress.doneFiles.Add(1)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	workers.Wait()
+
+	close(done)
+	reporter.Wait()
+	progress.report()
+
+	if failed := progress.failedJobs.Load(); failed > 0 {
+		return fmt.Errorf("backfill: %d files failed to convert, run the backfill again to retry them", failed)
+	}
+	return nil
+}
+
+// backfillJobs lists the OTLP files of the inputs, sorted by path, and their
+// OTAP files. The files already converted are skipped and counted.
+func backfillJobs(opts *options) (jobs []backfillJob, skipped int, err error) {
+	output, err := filepath.Abs(opts.output)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	addJob := func(path, rel string, info fs.FileInfo) error {
+		job := backfillJob{
+			input:  path,
+			output: filepath.Join(opts.output, rel+backfillSuffix),
+			size:   info.Size(),
+		}
+		if _, err := os.Stat(job.output); err == nil {
+			skipped++
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		jobs = append(jobs, job)
+		return nil
+	}
+
+	for _, input := range opts.inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !info.IsDir() {
+			if err := addJob(input, filepath.Base(input), info); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// The output directory may be in an input directory.
+				if abs, err := filepath.Abs(path); err == nil && abs == output {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(input, path)
+			if err != nil {
+				return err
+			}
+			return addJob(path, rel, info)
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].input < jobs[j].input })
+	return jobs, skipped, nil
+}
+
+// backfillFile converts an OTLP file into its OTAP file and returns the
+// number of converted items.
+func backfillFile[T any](s *signal[T], opts *options, job backfillJob) (items int, err error) {
+	producerOptions, err := opts.producerOptions()
+	if err != nil {
+		return 0, err
+	}
+	producer := arrow_record.NewProducerWithOptions(producerOptions...)
+	defer func() {
+		if closeErr := producer.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(job.output), 0700); err != nil {
+		return 0, err
+	}
+	tmp := job.output + ".tmp"
+	out, err := os.Create(filepath.Clean(tmp))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = out.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+	bw := bufio.NewWriter(out)
+	w := newOTAPWriter(bw)
+
+	fileOpts := *opts
+	fileOpts.inputs = []string{job.input}
+	err = readBatches(s, &fileOpts, func(batch T) error {
+		items += s.count(batch)
+		bar, err := s.produce(producer, batch)
+		if err != nil {
+			return err
+		}
+		return w.write(bar)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err = bw.Flush(); err != nil {
+		return 0, err
+	}
+	if err = out.Close(); err != nil {
+		return 0, err
+	}
+	return items, os.Rename(tmp, job.output)
+}
+
+// report logs the progress of the backfill.
+func (p *backfillProgress) report() {
+	elapsed := time.Since(p.start)
+	doneFiles := p.doneFiles.Load()
+	doneBytes := p.doneBytes.Load()
+
+	percent := 100.0
+	if p.bytes > 0 {
+		percent = 100 * float64(doneBytes) / float64(p.bytes)
+	}
+	throughput := float64(doneBytes) / elapsed.Seconds()
+	eta := "n/a"
+	if doneBytes > 0 {
+		remaining := time.Duration(float64(p.bytes-doneBytes) / throughput * float64(time.Second))
+		eta = remaining.Round(time.Second).String()
+	}
+
+	log.Printf("backfill: %d/%d files (%.1f%%), %d failed, %d items, %s/s, elapsed %s, ETA %s",
+		doneFiles, p.files, percent, p.failedJobs.Load(), p.items.Load(),
+		humanize.Bytes(uint64(throughput)), elapsed.Round(time.Second), eta)
+}
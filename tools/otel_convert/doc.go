@@ -0,0 +1,53 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package main contains a CLI tool converting OTLP files into OTAP files and
+// vice versa, e.g. to prepare benchmark datasets or to backfill archives of
+// OTLP data in Arrow form.
+// Usage:
+//
+//	go run ./tools/otel_convert -to otap -output traces.otap traces1.pb traces2.pb
+//	go run ./tools/otel_convert -to otlp -format jsonl -output traces.jsonl traces.otap
+//
+// The OTLP files contain either one protobuf ExportRequest (-format proto) or
+// one JSON ExportRequest per line (-format jsonl). The OTAP files contain the
+// sequence of BatchArrowRecords messages produced by a single Producer, each
+// message being prefixed by its size. The -batch-size and -sort flags control
+// the batching of the OTLP requests and the sorting of the encoded items, and
+// -encoding-overrides forces the encoding of dictionary encoded fields.
+//
+// With -dry-run, the OTLP files are encoded but no OTAP file is written, the
+// resulting schemas, field encodings and sizes are printed instead. This
+// previews the effect of the options on sample data:
+//
+//	go run ./tools/otel_convert -dry-run -encoding-overrides spans.name=plain traces1.pb
+//
+// With -backfill, the inputs are directories of OTLP files (e.g. the archive
+// of several months of telemetry) converted concurrently by -workers
+// workers. Each OTLP file is converted into its own OTAP file, mirroring the
+// tree of the input directories in the -output directory. The progress is
+// reported every -progress interval. An interrupted backfill is resumed by
+// running it again, the files already converted are skipped:
+//
+//	go run ./tools/otel_convert -backfill -signal logs -workers 8 -output archive-otap archive/2023-*
+//
+// The merge subcommand compacts several OTAP files, e.g. the segments of an
+// archive, into a single OTAP file of larger batches ordered by timestamp. The
+// data is re-encoded, so the input files may have different schemas:
+//
+//	go run ./tools/otel_convert merge -signal logs -batch-size 10000 -output logs.otap logs1.otap logs2.otap
+package main
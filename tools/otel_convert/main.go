@@ -0,0 +1,354 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// options are the command line options of the conversion.
+type options struct {
+	to        string
+	json      bool
+	batchSize int
+	sort      string
+	overrides map[string]string
+	dryRun    bool
+	backfill  bool
+	workers   int
+	progress  time.Duration
+	output    string
+	inputs    []string
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "merge" {
+		if err := mergeMain(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	signalName := flag.String("signal", "traces", "signal of the converted data: traces, logs or metrics")
+	to := flag.String("to", "otap", "output format: otap (OTLP input files) or otlp (OTAP input files)")
+	format := flag.String("format", "proto", "format of the OTLP files: proto (one ExportRequest per file) or jsonl (one JSON ExportRequest per line)")
+	batchSize := flag.Int("batch-size", 0, "minimum number of items (spans, log records or data points) per OTAP batch, 0 = one batch per OTLP request")
+	sort := flag.String("sort", "default", "sorting of the items encoded in the OTAP batches: default or none")
+	overrides := flag.String("encoding-overrides", "", "comma separated list of <payload type>.<field path>=<encoding> overrides of the OTAP encoding, e.g. spans.name=plain")
+	dryRun := flag.Bool("dry-run", false, "report the schemas, encodings and sizes of the OTAP encoding without writing the output")
+	backfill := flag.Bool("backfill", false, "convert each OTLP file of the input directories into its own OTAP file of the -output directory, skipping the files already converted")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of files converted concurrently in backfill mode")
+	progress := flag.Duration("progress", 10*time.Second, "interval of the progress reports in backfill mode, 0 = no report")
+	output := flag.String("output", "", "output file (output directory in backfill mode)")
+	flag.Parse()
+
+	if (*output == "" && !*dryRun) || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format != "proto" && *format != "jsonl" {
+		log.Fatalf("unknown OTLP format %q", *format)
+	}
+	if *dryRun && *to != "otap" {
+		log.Fatal("-dry-run requires -to otap")
+	}
+	if *backfill && (*to != "otap" || *dryRun) {
+		log.Fatal("-backfill requires -to otap and is incompatible with -dry-run")
+	}
+	if *workers < 1 {
+		log.Fatalf("invalid number of workers %d", *workers)
+	}
+	encodingOverrides, err := parseOverrides(*overrides)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := &options{
+		to:        *to,
+		json:      *format == "jsonl",
+		batchSize: *batchSize,
+		sort:      *sort,
+		overrides: encodingOverrides,
+		dryRun:    *dryRun,
+		backfill:  *backfill,
+		workers:   *workers,
+		progress:  *progress,
+		output:    *output,
+		inputs:    flag.Args(),
+	}
+
+	switch *signalName {
+	case "traces":
+		err = convert(tracesSignal, opts)
+	case "logs":
+		err = convert(logsSignal, opts)
+	case "metrics":
+		err = convert(metricsSignal, opts)
+	default:
+		err = fmt.Errorf("unknown signal %q", *signalName)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func convert[T any](s *signal[T], opts *options) error {
+	switch opts.to {
+	case "otap":
+		if opts.dryRun {
+			return previewOTAP(s, opts)
+		}
+		if opts.backfill {
+			return backfill(s, opts)
+		}
+		return toOTAP(s, opts)
+	case "otlp":
+		return toOTLP(s, opts)
+	default:
+		return fmt.Errorf("unknown output format %q", opts.to)
+	}
+}
+
+// toOTAP encodes the requests of the OTLP input files, merged into batches of
+// at least opts.batchSize items, into an OTAP file.
+func toOTAP[T any](s *signal[T], opts *options) (err error) {
+	producerOptions, err := opts.producerOptions()
+	if err != nil {
+		return err
+	}
+	producer := arrow_record.NewProducerWithOptions(producerOptions...)
+	defer func() {
+		if closeErr := producer.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	out, err := os.Create(filepath.Clean(opts.output))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	bw := bufio.NewWriter(out)
+	w := newOTAPWriter(bw)
+
+	err = readBatches(s, opts, func(batch T) error {
+		bar, err := s.produce(producer, batch)
+		if err != nil {
+			return err
+		}
+		return w.write(bar)
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// previewOTAP encodes the requests of the OTLP input files as toOTAP does and
+// prints the resulting schemas, encodings and sizes instead of writing the
+// OTAP file.
+func previewOTAP[T any](s *signal[T], opts *options) error {
+	producerOptions, err := opts.producerOptions()
+	if err != nil {
+		return err
+	}
+	d := arrow_record.NewDryRun(producerOptions...)
+	defer d.Close()
+
+	if err := readBatches(s, opts, func(batch T) error {
+		return s.dryRun(d, batch)
+	}); err != nil {
+		return err
+	}
+	d.Report().Show(os.Stdout)
+	return nil
+}
+
+// readBatches merges the requests of the OTLP input files into batches of
+// at least opts.batchSize items and passes them to produce.
+func readBatches[T any](s *signal[T], opts *options, produce func(T) error) error {
+	pending := s.empty()
+	flush := func() error {
+		if s.count(pending) == 0 {
+			return nil
+		}
+		batch := pending
+		pending = s.empty()
+		return produce(batch)
+	}
+
+	for _, input := range opts.inputs {
+		requests, err := readOTLP(s, input, opts.json)
+		if err != nil {
+			return fmt.Errorf("%s: %w", input, err)
+		}
+		for _, request := range requests {
+			s.merge(pending, request)
+			if s.count(pending) >= opts.batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return flush()
+}
+
+// producerOptions returns the options of the OTAP producer.
+func (opts *options) producerOptions() ([]config.Option, error) {
+	var producerOptions []config.Option
+	switch opts.sort {
+	case "default":
+	case "none":
+		producerOptions = append(producerOptions, config.WithNoSort())
+	default:
+		return nil, fmt.Errorf("unknown sorting %q", opts.sort)
+	}
+	if len(opts.overrides) != 0 {
+		producerOptions = append(producerOptions, config.WithEncodingOverrides(opts.overrides))
+	}
+	return producerOptions, nil
+}
+
+// parseOverrides parses a comma separated list of path=encoding overrides.
+func parseOverrides(list string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, override := range strings.Split(list, ",") {
+		if override == "" {
+			continue
+		}
+		path, encoding, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid encoding override %q", override)
+		}
+		overrides[path] = encoding
+	}
+	if err := arrow_record.ValidateEncodingOverrides(overrides); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// toOTLP decodes the batches of the OTAP input files into an OTLP file. In
+// the proto format, all the batches are merged into a single request.
+func toOTLP[T any](s *signal[T], opts *options) error {
+	consumer := arrow_record.NewConsumer()
+	defer consumer.Close()
+
+	var lines bytes.Buffer
+	merged := s.empty()
+	for _, input := range opts.inputs {
+		batches, err := readOTAP(input)
+		if err != nil {
+			return fmt.Errorf("%s: %w", input, err)
+		}
+		for _, bar := range batches {
+			data, err := s.consume(consumer, bar)
+			if err != nil {
+				return fmt.Errorf("%s: batch %d: %w", input, bar.BatchId, err)
+			}
+			for _, d := range data {
+				if !opts.json {
+					s.merge(merged, d)
+					continue
+				}
+				line, err := s.marshal(d, true)
+				if err != nil {
+					return err
+				}
+				lines.Write(line)
+				lines.WriteByte('\n')
+			}
+		}
+	}
+
+	if !opts.json {
+		request, err := s.marshal(merged, false)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Clean(opts.output), request, 0600)
+	}
+	return os.WriteFile(filepath.Clean(opts.output), lines.Bytes(), 0600)
+}
+
+// readOTLP returns the requests of an OTLP file.
+func readOTLP[T any](s *signal[T], path string, json bool) ([]T, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	if !json {
+		request, err := s.unmarshal(data, false)
+		if err != nil {
+			return nil, err
+		}
+		return []T{request}, nil
+	}
+
+	var requests []T
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		request, err := s.unmarshal(line, true)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+// readOTAP returns the batches of an OTAP file.
+func readOTAP(path string) ([]*arrowpb.BatchArrowRecords, error) {
+	in, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	r := newOTAPReader(bufio.NewReader(in))
+	var batches []*arrowpb.BatchArrowRecords
+	for {
+		bar, err := r.read()
+		if errors.Is(err, io.EOF) {
+			return batches, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, bar)
+	}
+}
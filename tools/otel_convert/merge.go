@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// mergeMain implements the merge subcommand, which merges several OTAP files
+// into a single OTAP file ordered by timestamp (see arrow_record.MergeTraces).
+func mergeMain(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	signalName := fs.String("signal", "traces", "signal of the merged data: traces, logs or metrics")
+	batchSize := fs.Int("batch-size", 0, "minimum number of items (spans, log records or data points) per merged OTAP batch, 0 = one batch per resource")
+	sort := fs.String("sort", "default", "sorting of the items encoded in the OTAP batches: default or none")
+	overrides := fs.String("encoding-overrides", "", "comma separated list of <payload type>.<field path>=<encoding> overrides of the OTAP encoding, e.g. spans.name=plain")
+	output := fs.String("output", "", "output file")
+	_ = fs.Parse(args)
+
+	if *output == "" || fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	encodingOverrides, err := parseOverrides(*overrides)
+	if err != nil {
+		return err
+	}
+
+	opts := &options{
+		batchSize: *batchSize,
+		sort:      *sort,
+		overrides: encodingOverrides,
+		output:    *output,
+		inputs:    fs.Args(),
+	}
+
+	switch *signalName {
+	case "traces":
+		return mergeFiles(tracesSignal, opts)
+	case "logs":
+		return mergeFiles(logsSignal, opts)
+	case "metrics":
+		return mergeFiles(metricsSignal, opts)
+	default:
+		return fmt.Errorf("unknown signal %q", *signalName)
+	}
+}
+
+// mergeFiles merges the OTAP input files into the OTAP output file. The input
+// files are read as the merge progresses.
+func mergeFiles[T any](s *signal[T], opts *options) (err error) {
+	producerOptions, err := opts.producerOptions()
+	if err != nil {
+		return err
+	}
+
+	sources := make([]arrow_record.BatchSource, 0, len(opts.inputs))
+	for _, input := range opts.inputs {
+		in, err := os.Open(filepath.Clean(input))
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		input := input
+		r := newOTAPReader(bufio.NewReader(in))
+		sources = append(sources, func() (*arrowpb.BatchArrowRecords, error) {
+			bar, err := r.read()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", input, err)
+			}
+			return bar, nil
+		})
+	}
+
+	out, err := os.Create(filepath.Clean(opts.output))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	bw := bufio.NewWriter(out)
+	w := newOTAPWriter(bw)
+
+	if err := s.mergeOTAP(sources, w.write, opts.batchSize, producerOptions...); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
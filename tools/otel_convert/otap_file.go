@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+)
+
+// An OTAP file is a sequence of BatchArrowRecords messages, each prefixed by
+// its size encoded as an uvarint. The payloads of the batches are fragments of
+// Arrow IPC streams (the schemas and dictionaries are only sent in the first
+// batches of each stream), so the batches of a file must be decoded in order
+// by a single Consumer.
+
+// otapWriter writes the BatchArrowRecords messages of an OTAP file.
+type otapWriter struct {
+	w    io.Writer
+	size [binary.MaxVarintLen64]byte
+}
+
+func newOTAPWriter(w io.Writer) *otapWriter {
+	return &otapWriter{w: w}
+}
+
+func (w *otapWriter) write(bar *arrowpb.BatchArrowRecords) error {
+	data, err := proto.Marshal(bar)
+	if err != nil {
+		return err
+	}
+	n := binary.PutUvarint(w.size[:], uint64(len(data)))
+	if _, err := w.w.Write(w.size[:n]); err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+// otapReader reads the BatchArrowRecords messages of an OTAP file.
+type otapReader struct {
+	r *bufio.Reader
+}
+
+func newOTAPReader(r *bufio.Reader) *otapReader {
+	return &otapReader{r: r}
+}
+
+// read returns the next message of the file, or io.EOF at the end of the
+// file.
+func (r *otapReader) read() (*arrowpb.BatchArrowRecords, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	bar := &arrowpb.BatchArrowRecords{}
+	if err := proto.Unmarshal(data, bar); err != nil {
+		return nil, err
+	}
+	return bar, nil
+}
@@ -0,0 +1,131 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	arrowpb "github.com/f5/otel-arrow-adapter/api/experimental/arrow/v1"
+	"github.com/f5/otel-arrow-adapter/pkg/config"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// signal defines the conversions of the OTLP data T of a signal.
+type signal[T any] struct {
+	empty func() T
+	// count returns the number of items (spans, log records or data
+	// points) of the data.
+	count func(T) int
+	// merge moves the resources of src to dst.
+	merge     func(dst, src T)
+	unmarshal func(data []byte, json bool) (T, error)
+	marshal   func(data T, json bool) ([]byte, error)
+	produce   func(*arrow_record.Producer, T) (*arrowpb.BatchArrowRecords, error)
+	dryRun    func(*arrow_record.DryRun, T) error
+	consume   func(*arrow_record.Consumer, *arrowpb.BatchArrowRecords) ([]T, error)
+	mergeOTAP func([]arrow_record.BatchSource, arrow_record.BatchSink, int, ...config.Option) error
+}
+
+var tracesSignal = &signal[ptrace.Traces]{
+	empty: ptrace.NewTraces,
+	count: ptrace.Traces.SpanCount,
+	merge: func(dst, src ptrace.Traces) {
+		src.ResourceSpans().MoveAndAppendTo(dst.ResourceSpans())
+	},
+	unmarshal: func(data []byte, json bool) (ptrace.Traces, error) {
+		request := ptraceotlp.NewExportRequest()
+		var err error
+		if json {
+			err = request.UnmarshalJSON(data)
+		} else {
+			err = request.UnmarshalProto(data)
+		}
+		return request.Traces(), err
+	},
+	marshal: func(traces ptrace.Traces, json bool) ([]byte, error) {
+		request := ptraceotlp.NewExportRequestFromTraces(traces)
+		if json {
+			return request.MarshalJSON()
+		}
+		return request.MarshalProto()
+	},
+	produce:   (*arrow_record.Producer).BatchArrowRecordsFromTraces,
+	dryRun:    (*arrow_record.DryRun).Traces,
+	consume:   (*arrow_record.Consumer).TracesFrom,
+	mergeOTAP: arrow_record.MergeTraces,
+}
+
+var logsSignal = &signal[plog.Logs]{
+	empty: plog.NewLogs,
+	count: plog.Logs.LogRecordCount,
+	merge: func(dst, src plog.Logs) {
+		src.ResourceLogs().MoveAndAppendTo(dst.ResourceLogs())
+	},
+	unmarshal: func(data []byte, json bool) (plog.Logs, error) {
+		request := plogotlp.NewExportRequest()
+		var err error
+		if json {
+			err = request.UnmarshalJSON(data)
+		} else {
+			err = request.UnmarshalProto(data)
+		}
+		return request.Logs(), err
+	},
+	marshal: func(logs plog.Logs, json bool) ([]byte, error) {
+		request := plogotlp.NewExportRequestFromLogs(logs)
+		if json {
+			return request.MarshalJSON()
+		}
+		return request.MarshalProto()
+	},
+	produce:   (*arrow_record.Producer).BatchArrowRecordsFromLogs,
+	dryRun:    (*arrow_record.DryRun).Logs,
+	consume:   (*arrow_record.Consumer).LogsFrom,
+	mergeOTAP: arrow_record.MergeLogs,
+}
+
+var metricsSignal = &signal[pmetric.Metrics]{
+	empty: pmetric.NewMetrics,
+	count: pmetric.Metrics.DataPointCount,
+	merge: func(dst, src pmetric.Metrics) {
+		src.ResourceMetrics().MoveAndAppendTo(dst.ResourceMetrics())
+	},
+	unmarshal: func(data []byte, json bool) (pmetric.Metrics, error) {
+		request := pmetricotlp.NewExportRequest()
+		var err error
+		if json {
+			err = request.UnmarshalJSON(data)
+		} else {
+			err = request.UnmarshalProto(data)
+		}
+		return request.Metrics(), err
+	},
+	marshal: func(metrics pmetric.Metrics, json bool) ([]byte, error) {
+		request := pmetricotlp.NewExportRequestFromMetrics(metrics)
+		if json {
+			return request.MarshalJSON()
+		}
+		return request.MarshalProto()
+	},
+	produce:   (*arrow_record.Producer).BatchArrowRecordsFromMetrics,
+	dryRun:    (*arrow_record.DryRun).Metrics,
+	consume:   (*arrow_record.Consumer).MetricsFrom,
+	mergeOTAP: arrow_record.MergeMetrics,
+}
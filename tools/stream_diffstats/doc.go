@@ -0,0 +1,31 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package main contains a CLI tool comparing two stream snapshots written by
+// Producer.WriteSnapshot or Consumer.WriteSnapshot with stream recording
+// enabled (e.g. captured before and after a sorter or encoding change).
+//
+// Usage:
+//
+//	go run ./tools/stream_diffstats -before before.zip -after after.zip [-threshold 0.01]
+//
+// For each payload type, the tool replays the recorded streams and reports the
+// number of records and rows, the size of the stream in bytes and the
+// cardinality of the dictionary columns, with the after/before ratios. A
+// summary verdict is printed at the end based on the total size; the exit
+// status is 1 when the size increased by more than the threshold.
+package main
@@ -0,0 +1,238 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+// PayloadStats are the stats of all the streams of a payload type.
+type PayloadStats struct {
+	Streams int
+	Records int
+	Rows    int64
+	Bytes   int64
+	// Cardinality is the sum of the dictionary sizes (at the end of the
+	// streams) of all the dictionary columns.
+	Cardinality int
+	Truncated   bool
+}
+
+func main() {
+	beforePath := flag.String("before", "", "snapshot archive captured before the change")
+	afterPath := flag.String("after", "", "snapshot archive captured after the change")
+	threshold := flag.Float64("threshold", 0.01, "relative size change below which the streams are considered unchanged")
+	flag.Parse()
+
+	if *beforePath == "" || *afterPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	before, err := loadStats(*beforePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	after, err := loadStats(*afterPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	payloadTypes := make([]string, 0, len(before)+len(after))
+	for pt := range before {
+		payloadTypes = append(payloadTypes, pt)
+	}
+	for pt := range after {
+		if _, ok := before[pt]; !ok {
+			payloadTypes = append(payloadTypes, pt)
+		}
+	}
+	sort.Strings(payloadTypes)
+
+	var totalBefore, totalAfter PayloadStats
+	fmt.Printf("%-24s %-12s %14s %14s %8s\n", "Payload type", "Metric", "Before", "After", "Ratio")
+	for _, pt := range payloadTypes {
+		b, a := before[pt], after[pt]
+		if b == nil {
+			b = &PayloadStats{}
+		}
+		if a == nil {
+			a = &PayloadStats{}
+		}
+		printRow(pt, "records", int64(b.Records), int64(a.Records))
+		printRow("", "rows", b.Rows, a.Rows)
+		printRow("", "bytes", b.Bytes, a.Bytes)
+		printRow("", "cardinality", int64(b.Cardinality), int64(a.Cardinality))
+		if b.Truncated || a.Truncated {
+			fmt.Printf("%-24s warning: truncated recording, the stats only cover the beginning of the stream\n", "")
+		}
+		totalBefore.add(b)
+		totalAfter.add(a)
+	}
+	printRow("TOTAL", "rows", totalBefore.Rows, totalAfter.Rows)
+	printRow("", "bytes", totalBefore.Bytes, totalAfter.Bytes)
+
+	ratio := sizeRatio(totalBefore.Bytes, totalAfter.Bytes)
+	switch {
+	case totalBefore.Rows != totalAfter.Rows:
+		fmt.Println("\nVerdict: the snapshots do not contain the same data (row counts differ), size comparison is not meaningful")
+	case ratio < 1-*threshold:
+		fmt.Printf("\nVerdict: IMPROVED, %.1f%% smaller\n", (1-ratio)*100)
+	case ratio > 1+*threshold:
+		fmt.Printf("\nVerdict: REGRESSED, %.1f%% larger\n", (ratio-1)*100)
+		os.Exit(1)
+	default:
+		fmt.Println("\nVerdict: UNCHANGED")
+	}
+}
+
+func (s *PayloadStats) add(other *PayloadStats) {
+	s.Streams += other.Streams
+	s.Records += other.Records
+	s.Rows += other.Rows
+	s.Bytes += other.Bytes
+	s.Cardinality += other.Cardinality
+	s.Truncated = s.Truncated || other.Truncated
+}
+
+func printRow(payloadType, metric string, before, after int64) {
+	fmt.Printf("%-24s %-12s %14d %14d %8s\n", payloadType, metric, before, after, formatRatio(before, after))
+}
+
+func formatRatio(before, after int64) string {
+	if before == 0 {
+		if after == 0 {
+			return "-"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%.3f", sizeRatio(before, after))
+}
+
+func sizeRatio(before, after int64) float64 {
+	if before == 0 {
+		return 1
+	}
+	return float64(after) / float64(before)
+}
+
+// loadStats reads a snapshot and replays its recorded streams to compute the
+// stats per payload type.
+func loadStats(path string) (map[string]*PayloadStats, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := arrow_record.ReadSnapshot(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]*PayloadStats)
+	for i := range snapshot.Streams {
+		stream := &snapshot.Streams[i]
+		if !stream.Recorded {
+			log.Printf("%s: stream %s (%s) not recorded, ignored (see config.WithStreamRecording)", path, stream.SchemaID, stream.PayloadType)
+			continue
+		}
+		ps, ok := stats[stream.PayloadType]
+		if !ok {
+			ps = &PayloadStats{}
+			stats[stream.PayloadType] = ps
+		}
+		if err := replay(stream, ps); err != nil {
+			return nil, fmt.Errorf("%s: stream %s (%s): %w", path, stream.SchemaID, stream.PayloadType, err)
+		}
+	}
+	return stats, nil
+}
+
+// replay decodes a recorded stream with the same IPC options as the Consumer
+// and accumulates its stats.
+func replay(stream *arrow_record.StreamSnapshot, ps *PayloadStats) error {
+	reader, err := ipc.NewReader(
+		bytes.NewReader(stream.Data),
+		ipc.WithDictionaryDeltas(true),
+		ipc.WithZstd(),
+	)
+	if err != nil {
+		return err
+	}
+	defer reader.Release()
+
+	// Dictionaries only grow with deltas (and are replaced on reset), the
+	// cardinality of a column is the largest dictionary observed.
+	dictSizes := make(map[string]int)
+	for reader.Next() {
+		record := reader.Record()
+		ps.Records++
+		ps.Rows += record.NumRows()
+		for i, col := range record.Columns() {
+			collectDictSizes(record.ColumnName(i), col, dictSizes)
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	ps.Streams++
+	ps.Bytes += int64(len(stream.Data))
+	ps.Truncated = ps.Truncated || stream.Truncated
+	for _, size := range dictSizes {
+		ps.Cardinality += size
+	}
+	return nil
+}
+
+// collectDictSizes records the dictionary sizes of the dictionary columns
+// found in arr (including nested ones) by column path.
+func collectDictSizes(path string, arr arrow.Array, sizes map[string]int) {
+	switch arr := arr.(type) {
+	case *array.Dictionary:
+		if n := arr.Dictionary().Len(); n > sizes[path] {
+			sizes[path] = n
+		}
+	case *array.Struct:
+		st := arr.DataType().(*arrow.StructType)
+		for i := 0; i < arr.NumField(); i++ {
+			collectDictSizes(path+"."+st.Field(i).Name, arr.Field(i), sizes)
+		}
+	case *array.Map:
+		collectDictSizes(path+".key", arr.Keys(), sizes)
+		collectDictSizes(path+".value", arr.Items(), sizes)
+	case *array.List:
+		collectDictSizes(path+".item", arr.ListValues(), sizes)
+	case *array.SparseUnion:
+		for i := 0; i < arr.NumFields(); i++ {
+			collectDictSizes(fmt.Sprintf("%s.%d", path, i), arr.Field(i), sizes)
+		}
+	case *array.DenseUnion:
+		for i := 0; i < arr.NumFields(); i++ {
+			collectDictSizes(fmt.Sprintf("%s.%d", path, i), arr.Field(i), sizes)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+/*
+ * Copyright The OpenTelemetry Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package main contains a CLI tool loading a stream snapshot written by
+// Producer.WriteSnapshot or Consumer.WriteSnapshot (e.g. attached to a bug
+// report).
+//
+// Usage:
+//
+//	go run ./tools/stream_inspector -snapshot snapshot.zip [-max-rows 10]
+//
+// The tool prints the configuration, the stats and the schema of each stream,
+// then replays the recorded streams with an Arrow IPC reader configured like
+// the Consumer, printing the records and reporting the first decode error of
+// each stream.
+package main
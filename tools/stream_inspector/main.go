@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+
+	carrow "github.com/f5/otel-arrow-adapter/pkg/arrow"
+	"github.com/f5/otel-arrow-adapter/pkg/otel/arrow_record"
+)
+
+func main() {
+	snapshotPath := flag.String("snapshot", "", "snapshot archive")
+	maxRows := flag.Int("max-rows", 10, "max number of rows printed per record")
+	maxPrints := flag.Int("max-prints", 5, "max number of records printed per stream")
+	flag.Parse()
+
+	if *snapshotPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(*snapshotPath))
+	if err != nil {
+		log.Fatal(err)
+	}
+	snapshot, err := arrow_record.ReadSnapshot(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("== %s snapshot (version %d) created at %s\n", snapshot.Kind, snapshot.Version, snapshot.CreatedAt)
+	printJSON("Config", snapshot.Config)
+	if snapshot.Stats != nil {
+		fmt.Println("Stats:")
+		snapshot.Stats.Show("  ")
+	}
+
+	failures := 0
+	for _, stream := range snapshot.Streams {
+		fmt.Printf("\n== Stream %s (%s), last update at %s\n", stream.SchemaID, stream.PayloadType, stream.LastUpdate)
+		fmt.Printf("Schema:\n%s\n", stream.Schema)
+
+		if !stream.Recorded {
+			fmt.Println("Stream not recorded (schema only).")
+			continue
+		}
+		if stream.Truncated {
+			fmt.Println("Warning: the recording of this stream has been truncated.")
+		}
+		if err := replay(&stream, *maxRows, *maxPrints); err != nil {
+			fmt.Printf("DECODE ERROR: %v\n", err)
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		os.Exit(1)
+	}
+}
+
+// replay decodes a recorded stream with the same IPC options as the Consumer.
+func replay(stream *arrow_record.StreamSnapshot, maxRows, maxPrints int) error {
+	reader, err := ipc.NewReader(
+		bytes.NewReader(stream.Data),
+		ipc.WithDictionaryDeltas(true),
+		ipc.WithZstd(),
+	)
+	if err != nil {
+		return err
+	}
+	defer reader.Release()
+
+	count := 0
+	for reader.Next() {
+		count++
+		if count <= maxPrints {
+			carrow.PrintRecord(stream.PayloadType, reader.Record(), maxRows, count, maxPrints)
+		}
+	}
+	fmt.Printf("\n%d records decoded\n", count)
+	return reader.Err()
+}
+
+func printJSON(name string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %s\n", name, data)
+}
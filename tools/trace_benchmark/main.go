@@ -120,6 +120,8 @@ func main() {
 
 		profiler.ExportMetricsTimesCSV(fmt.Sprintf("%d_traces_benchmark_results", i))
 		profiler.ExportMetricsBytesCSV(fmt.Sprintf("%d_traces_benchmark_results", i))
+		profiler.ExportBenchstat("Traces", fmt.Sprintf("%d_traces_benchmark_results", i))
+		profiler.ExportReport("Traces", fmt.Sprintf("%s (%s)", inputFiles[i], humanize.Bytes(uint64(ds.SizeInBytes()))), fmt.Sprintf("%d_traces_benchmark_results", i), maxIter)
 
 		ds.ShowStats()
 	}